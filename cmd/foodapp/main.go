@@ -9,6 +9,7 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 机构时区按 IANA 名称解析，镜像内可能没有 zoneinfo
 
 	"go.uber.org/zap"
 	"gorm.io/gorm"
@@ -76,7 +77,12 @@ func main() {
 	// 3.2 创建默认组织、账户、字典
 	seedDefaultData(context.Background(), food_db)
 
-	engine := server.New(food_db, cfg.Auth, cfg.Server.WebRoot)
+	// 3.3 后台定时任务（关停时取消）
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	server.StartJobs(jobCtx, food_db, cfg.Scheduler, cfg.Storage)
+
+	engine := server.New(food_db, cfg.Auth, cfg.Report, cfg.Storage, cfg.Server)
 	addr := fmt.Sprintf(":%d", cfg.Server.Port)
 	srv := &http.Server{
 		Addr:              addr,
@@ -100,6 +106,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("shutting down ...")
+	stopJobs()
 
 	// 给一些时间优雅关停
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -10,24 +10,33 @@ import (
 // ===== 原始指针结构（用于判断字段是否出现） =====
 
 type AppConfig struct {
-	Log    LogConfig    `json:"log"`
-	Server ServerConfig `json:"server"`
-	DB     DBConfig     `json:"db"`
-	Auth   AuthConfig   `json:"auth"`
+	Log       LogConfig       `json:"log"`
+	Server    ServerConfig    `json:"server"`
+	DB        DBConfig        `json:"db"`
+	Auth      AuthConfig      `json:"auth"`
+	Report    ReportConfig    `json:"report"`
+	Scheduler SchedulerConfig `json:"scheduler"`
+	Storage   StorageConfig   `json:"storage"`
 }
 
 var DefaultConfig = AppConfig{
-	Log:    DefaultLogConfig,
-	Server: DefaultServerConfig,
-	DB:     DefaultDBConfig,
-	Auth:   DefaultAuthConfig,
+	Log:       DefaultLogConfig,
+	Server:    DefaultServerConfig,
+	DB:        DefaultDBConfig,
+	Auth:      DefaultAuthConfig,
+	Report:    DefaultReportConfig,
+	Scheduler: DefaultSchedulerConfig,
+	Storage:   DefaultStorageConfig,
 }
 
 type appConfigRaw struct {
-	Log    *logConfigRaw    `json:"log"`
-	Server *serverConfigRaw `json:"server"`
-	DB     *dbConfigRaw     `json:"db"`
-	Auth   *authConfigRaw   `json:"auth"`
+	Log       *logConfigRaw       `json:"log"`
+	Server    *serverConfigRaw    `json:"server"`
+	DB        *dbConfigRaw        `json:"db"`
+	Auth      *authConfigRaw      `json:"auth"`
+	Report    *reportConfigRaw    `json:"report"`
+	Scheduler *schedulerConfigRaw `json:"scheduler"`
+	Storage   *storageConfigRaw   `json:"storage"`
 }
 
 func LoadConfig(path string) (*AppConfig, bool, error) {
@@ -60,6 +69,9 @@ func LoadConfig(path string) (*AppConfig, bool, error) {
 	mergeServer(&cfg.Server, raw.Server)
 	mergeDB(&cfg.DB, raw.DB)
 	mergeAuth(&cfg.Auth, raw.Auth)
+	mergeReport(&cfg.Report, raw.Report)
+	mergeScheduler(&cfg.Scheduler, raw.Scheduler)
+	mergeStorage(&cfg.Storage, raw.Storage)
 	writeJSON(path, cfg)
 	return &cfg, false, nil
 }
@@ -0,0 +1,24 @@
+package configs
+
+// ReportConfig 打印/报表相关配置
+type ReportConfig struct {
+	FontPath string `json:"font_path"` // 中文 TrueType 字体（.ttf，不支持 .ttc），生成 PDF 时使用
+}
+
+type reportConfigRaw struct {
+	FontPath *string `json:"font_path"`
+}
+
+// 默认打印配置
+var DefaultReportConfig = ReportConfig{
+	FontPath: "./fonts/NotoSansSC-Regular.ttf",
+}
+
+func mergeReport(dst *ReportConfig, raw *reportConfigRaw) {
+	if raw == nil {
+		return
+	}
+	if s := strPtrValid(raw.FontPath); s != "" {
+		dst.FontPath = s
+	}
+}
@@ -0,0 +1,36 @@
+package configs
+
+// SchedulerConfig 后台定时任务配置
+type SchedulerConfig struct {
+	IntervalMinute   int   `json:"interval_minute"`    // 执行间隔(分钟)，0 表示不启动定时任务
+	ExpiryNoticeDays []int `json:"expiry_notice_days"` // 供应商合同到期提醒档（天）
+}
+
+type schedulerConfigRaw struct {
+	IntervalMinute   *int  `json:"interval_minute"`
+	ExpiryNoticeDays []int `json:"expiry_notice_days"`
+}
+
+// 默认定时任务配置
+var DefaultSchedulerConfig = SchedulerConfig{
+	IntervalMinute:   10,
+	ExpiryNoticeDays: []int{30, 7, 1},
+}
+
+func mergeScheduler(dst *SchedulerConfig, raw *schedulerConfigRaw) {
+	if raw == nil {
+		return
+	}
+	if raw.IntervalMinute != nil && *raw.IntervalMinute >= 0 && *raw.IntervalMinute <= 24*60 {
+		dst.IntervalMinute = *raw.IntervalMinute
+	}
+	if raw.ExpiryNoticeDays != nil {
+		days := make([]int, 0, len(raw.ExpiryNoticeDays))
+		for _, d := range raw.ExpiryNoticeDays {
+			if d > 0 && d <= 366 {
+				days = append(days, d)
+			}
+		}
+		dst.ExpiryNoticeDays = days
+	}
+}
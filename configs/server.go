@@ -2,19 +2,22 @@ package configs
 
 // ServerConfig HTTP 服务相关配置
 type ServerConfig struct {
-	Port    int    `json:"port"`     // 监听端口
-	WebRoot string `json:"web_root"` // Web 根路径(可选)
+	Port               int    `json:"port"`                 // 监听端口
+	WebRoot            string `json:"web_root"`             // Web 根路径(可选)
+	IdempotencyTTLHour int    `json:"idempotency_ttl_hour"` // Idempotency-Key 首次响应保存时长（小时）
 }
 
 type serverConfigRaw struct {
-	Port    *int    `json:"port"`
-	WebRoot *string `json:"web_root"`
+	Port               *int    `json:"port"`
+	WebRoot            *string `json:"web_root"`
+	IdempotencyTTLHour *int    `json:"idempotency_ttl_hour"`
 }
 
 // 默认 HTTP 配置
 var DefaultServerConfig = ServerConfig{
-	Port:    7380,
-	WebRoot: "./web", // 若 WebRoot 未指定，默认为 "./web"
+	Port:               7380,
+	WebRoot:            "./web", // 若 WebRoot 未指定，默认为 "./web"
+	IdempotencyTTLHour: 24,
 }
 
 func mergeServer(dst *ServerConfig, raw *serverConfigRaw) {
@@ -27,4 +30,7 @@ func mergeServer(dst *ServerConfig, raw *serverConfigRaw) {
 	if s := strPtrNonEmpty(raw.WebRoot); s != "" {
 		dst.WebRoot = s
 	}
+	if h := intPtrInRange(raw.IdempotencyTTLHour, 1, 720); h > 0 {
+		dst.IdempotencyTTLHour = h
+	}
 }
@@ -0,0 +1,30 @@
+package configs
+
+// StorageConfig 上传文件存储配置
+type StorageConfig struct {
+	Dir         string `json:"dir"`           // 上传文件根目录
+	MaxUploadMB int    `json:"max_upload_mb"` // 单个文件大小上限(MB)
+}
+
+type storageConfigRaw struct {
+	Dir         *string `json:"dir"`
+	MaxUploadMB *int    `json:"max_upload_mb"`
+}
+
+// 默认存储配置
+var DefaultStorageConfig = StorageConfig{
+	Dir:         "./data/uploads",
+	MaxUploadMB: 20,
+}
+
+func mergeStorage(dst *StorageConfig, raw *storageConfigRaw) {
+	if raw == nil {
+		return
+	}
+	if s := strPtrValid(raw.Dir); s != "" {
+		dst.Dir = s
+	}
+	if v := intPtrInRange(raw.MaxUploadMB, 1, 200); v > 0 {
+		dst.MaxUploadMB = v
+	}
+}
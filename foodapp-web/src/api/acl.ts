@@ -22,6 +22,7 @@ export interface AccountUpdatePayload {
   org_id?: string
   description?: string | null
   role?: number
+  version?: number // 期望版本号（取自 get/list 的 Version）
 }
 
 export interface AccountUpdatePasswdPayload {
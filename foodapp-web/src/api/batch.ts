@@ -0,0 +1,22 @@
+// 批量接口（goods/category/dict 的 batch_*）公共类型
+
+// atomic：任一失败整体回滚；best_effort：失败项单独回滚，其余提交
+export type BatchMode = 'atomic' | 'best_effort'
+
+export type BatchOpType = 'create' | 'update' | 'delete' | 'reorder'
+
+export interface BatchItemResult {
+  index: number
+  op: BatchOpType
+  id?: string
+  ok: boolean
+  error?: string
+}
+
+export interface BatchResult {
+  mode: BatchMode
+  committed: boolean
+  succeeded: number
+  failed: number
+  items: BatchItemResult[]
+}
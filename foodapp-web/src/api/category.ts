@@ -1,4 +1,5 @@
 import http from './http'
+import type { BatchMode, BatchResult } from './batch'
 
 export interface CategoryListParams {
   org_id: string
@@ -20,6 +21,7 @@ export interface CategoryUpdatePayload {
   code?: string | null
   pinyin?: string | null
   sort?: number
+  version?: number // 期望版本号（取自 get/list 的 Version）
 }
 
 export interface CategoryRow {
@@ -30,6 +32,7 @@ export interface CategoryRow {
   Sort: number
   OrganID: string
   IsDeleted: number
+  Version: number
   CreatedAt: string
   UpdatedAt: string
 }
@@ -40,6 +43,9 @@ export const CategoryAPI = {
   update: (data: CategoryUpdatePayload) => http.post('/category/update_category', data),
   // 与后端路由保持一致：soft_delete_category
   remove: (id: string) => http.post('/category/soft_delete_category', { id }),
+  // 批量：ops 为 { op: 'create' | 'update' | 'delete' | 'reorder', ...字段 }，reorder 用 ids 给出顺序
+  batch: (data: { org_id: string; mode?: BatchMode; ops: Array<Record<string, unknown>> }) =>
+    http.post<BatchResult>('/category/batch_category', data),
 }
 
 export default CategoryAPI
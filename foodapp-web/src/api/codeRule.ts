@@ -0,0 +1,59 @@
+// src/api/codeRule.ts
+import http from './http'
+
+export type CodeRuleEntity =
+  | 'goods'
+  | 'category'
+  | 'supplier'
+  | 'unit'
+  | 'spec'
+  | 'meal_time'
+  | 'waste_reason'
+
+export interface CodeRuleListParams {
+  org_id?: string // 传空串仅列出全局规则
+  entity_type?: CodeRuleEntity
+}
+
+export interface CodeRuleCreatePayload {
+  org_id?: string // 为空=全局默认
+  entity_type: CodeRuleEntity
+  pattern: string // 如 {org}{cat}{seq:5}
+  reuse_gaps?: 0 | 1
+}
+
+export interface CodeRuleUpdatePayload {
+  id: string
+  pattern: string
+  reuse_gaps?: 0 | 1
+  version?: number // 期望版本号（取自 get/list 的 version）
+}
+
+export interface CodeRulePreviewPayload {
+  entity_type: CodeRuleEntity
+  org_id?: string
+  category_id?: string // goods：{cat}
+  parent_id?: string // category：{parent}
+}
+
+export type CodeRuleRow = {
+  id: string
+  org_id: string
+  entity_type: CodeRuleEntity
+  pattern: string
+  reuse_gaps: number
+  version: number
+  created_at: string
+  updated_at: string
+}
+
+export const CodeRuleAPI = {
+  create: (data: CodeRuleCreatePayload) => http.post('/code_rule/create_code_rule', data),
+  get: (id: string) => http.post('/code_rule/get_code_rule', { id }),
+  list: (params: CodeRuleListParams) => http.post('/code_rule/list_code_rule', null, { params }),
+  update: (data: CodeRuleUpdatePayload) => http.post('/code_rule/update_code_rule', data),
+  remove: (id: string) => http.post('/code_rule/delete_code_rule', { id }),
+  preview: (data: CodeRulePreviewPayload) => http.post('/code_rule/preview_code_rule', data),
+}
+
+export default CodeRuleAPI
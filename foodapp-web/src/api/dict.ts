@@ -1,4 +1,5 @@
 import http from './http'
+import type { BatchMode, BatchResult } from './batch'
 
 
 // 与后端 /dict/* 路由对齐
@@ -8,7 +9,7 @@ export const DictAPI = {
     getUnit: (ID: string) => http.post('/dict/get_unit', { ID }),
     listUnits: (params: { keyword?: string; page?: number; page_size?: number }) =>
     http.post('/dict/list_unit', null, { params }),
-    updateUnit: (data: { ID: string; Name: string; Sort?: number }) => http.post('/dict/update_unit', data),
+    updateUnit: (data: { ID: string; Name: string; Sort?: number; version?: number }) => http.post('/dict/update_unit', data),
     deleteUnit: (ID: string) => http.post('/dict/delete_unit', { ID }), // 需后端开放
 
 
@@ -17,7 +18,7 @@ export const DictAPI = {
     getSpec: (ID: string) => http.post('/dict/get_spec', { ID }),
     listSpecs: (params: { keyword?: string; page?: number; page_size?: number }) =>
     http.post('/dict/list_spec', null, { params }),
-    updateSpec: (data: { ID: string; Name: string; Sort?: number }) => http.post('/dict/update_spec', data),
+    updateSpec: (data: { ID: string; Name: string; Sort?: number; version?: number }) => http.post('/dict/update_spec', data),
     deleteSpec: (ID: string) => http.post('/dict/delete_spec', { ID }), // 需后端开放
 
 
@@ -26,6 +27,10 @@ export const DictAPI = {
     getMealTime: (ID: string) => http.post('/dict/get_mealTime', { ID }),
     listMealTimes: (params: { keyword?: string; page?: number; page_size?: number }) =>
     http.post('/dict/list_mealTime', null, { params }),
-    updateMealTime: (data: { ID: string; Name: string; Sort?: number }) => http.post('/dict/update_mealTime', data),
+    updateMealTime: (data: { ID: string; Name: string; Sort?: number; version?: number }) => http.post('/dict/update_mealTime', data),
     deleteMealTime: (ID: string) => http.post('/dict/delete_mealTime', { ID }), // 需后端开放
+
+    // 批量（同一字典类型）：ops 为 { op: 'create' | 'update' | 'delete' | 'reorder', ...字段 }
+    batch: (data: { kind: 'unit' | 'spec' | 'meal_time' | 'waste_reason'; mode?: BatchMode; ops: Array<Record<string, unknown>> }) =>
+    http.post<BatchResult>('/dict/batch_dict', data),
 }
\ No newline at end of file
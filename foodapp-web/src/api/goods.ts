@@ -1,4 +1,5 @@
 import http from './http'
+import type { BatchMode, BatchResult } from './batch'
 
 export interface GoodsListParams {
   org_id: string
@@ -33,6 +34,7 @@ export interface GoodsUpdatePayload {
   pinyin?: string | null
   image_url?: string | null
   description?: string | null
+  version?: number // 期望版本号（取自 get/list 的 Version）
 }
 
 export interface GoodsRow {
@@ -47,16 +49,73 @@ export interface GoodsRow {
   OrgID: string
   ImageURL: string | null
   Description: string | null
+  Version: number
   CreatedAt: string
   UpdatedAt: string
 }
 
+export interface GoodsBarcodeCreatePayload {
+  goods_id: string
+  barcode: string // EAN-8 / UPC-A / EAN-13 / GTIN-14，服务端校验校验位
+  unit_id?: string // 包装单位，默认商品单位
+  pack_qty?: string // 每包装折合商品单位数量，默认 1
+  is_primary?: boolean
+  remark?: string | null
+}
+
+export interface GoodsBarcodeUpdatePayload {
+  id: string
+  unit_id?: string
+  pack_qty?: string
+  is_primary?: boolean
+  remark?: string | null
+}
+
+export interface GoodsBarcodeRow {
+  id: string
+  org_id: string
+  barcode: string
+  symbology: 'ean13' | 'ean8' | 'upca' | 'gtin14'
+  goods_id: string
+  unit_id: string
+  pack_qty: string
+  is_primary: number
+  remark: string | null
+  created_at: string
+  updated_at: string
+}
+
+export interface GoodsScanResult {
+  matched_by: 'barcode' | 'code'
+  goods: GoodsRow
+  barcode?: GoodsBarcodeRow
+}
+
+export interface GoodsLabelParams {
+  id: string
+  format?: 'code128' | 'qr'
+  barcode_id?: string // 为空时编码商品 code
+  width?: number
+  height?: number
+}
+
 export const GoodsAPI = {
   create: (data: GoodsCreatePayload) => http.post('/goods/create_goods', data),
   get: (id: string) => http.post('/goods/get_goods', { id }),
   list: (params: GoodsListParams) => http.post('/goods/list_goods', null, { params }),
   update: (data: GoodsUpdatePayload) => http.post('/goods/update_goods', data),
   remove: (id: string) => http.post('/goods/soft_delete_goods', { id }),
+  // 批量：ops 为 { op: 'create' | 'update' | 'delete' | 'reorder', ...字段 }，reorder 用 ids 给出顺序
+  batch: (data: { org_id: string; mode?: BatchMode; ops: Array<Record<string, unknown>> }) =>
+    http.post<BatchResult>('/goods/batch_goods', data),
+  createBarcode: (data: GoodsBarcodeCreatePayload) => http.post('/goods/create_barcode', data),
+  listBarcodes: (goods_id: string) => http.post('/goods/list_barcode', null, { params: { goods_id } }),
+  updateBarcode: (data: GoodsBarcodeUpdatePayload) => http.post('/goods/update_barcode', data),
+  removeBarcode: (id: string) => http.post('/goods/delete_barcode', { id }),
+  scan: (org_id: string, barcode: string) =>
+    http.post<GoodsScanResult>('/goods/scan_goods', { org_id, barcode }),
+  label: (data: GoodsLabelParams) =>
+    http.post<Blob>('/goods/label_goods', data, { responseType: 'blob' }),
 }
 
 export default GoodsAPI
@@ -23,6 +23,7 @@ export interface OrganUpdatePayload {
   parent?: string
   code?: string
   description?: string
+  version?: number // 期望版本号（取自 get/list 的 Version）
 }
 
 export const OrganAPI = {
@@ -42,6 +43,7 @@ export type OrganRow = {
   Description: string
   Sort: number
   IsDeleted: number
+  Version: number
   CreatedAt: string
   UpdatedAt: string
 }
@@ -43,6 +43,7 @@ export interface SupplierUpdatePayload {
   contact_address?: string | null
   start_time?: string | null
   end_time?: string | null
+  version?: number // 期望版本号（取自 get/list 的 Version）
 }
 
 export interface SupplierRow {
@@ -61,6 +62,7 @@ export interface SupplierRow {
   ContactAddress: string | null
   StartTime: string | null
   EndTime: string | null
+  Version: number
   CreatedAt: string
   UpdatedAt: string
 }
@@ -17,6 +17,7 @@ type Account struct {
 	Role         int        `gorm:"not null;default:0;comment:角色 1管理员 0用户"`
 	Sort         int        `gorm:"not null;default:0;index;comment:排序值"`
 	IsDeleted    int        `gorm:"not null;default:0;index;comment:是否已删除 0未删除 1已删除" json:"-"`
+	Version      int        `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	LastLoginAt  *time.Time `gorm:"comment:最后登录时间"`
 	CreatedAt    time.Time  `gorm:"autoCreateTime"`
 	UpdatedAt    time.Time  `gorm:"autoUpdateTime"`
@@ -26,6 +27,9 @@ func (a *Account) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == "" {
 		a.ID = uuid.NewString()
 	}
+	if a.Version == 0 {
+		a.Version = 1
+	}
 	if a.Sort <= 0 {
 		next, err := utils.NextColoumSort(tx, a.TableName())
 		if err != nil {
@@ -0,0 +1,268 @@
+package bidding
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 轮次状态（开标前后由时间决定，见 PhaseAt）
+const (
+	StatusNormal    = 1 // 正常
+	StatusCancelled = 2 // 已取消（报价永不公开）
+)
+
+// 轮次阶段
+const (
+	PhasePending   = "pending"   // 未开始
+	PhaseOpen      = "open"      // 报价中（密封）
+	PhaseClosed    = "closed"    // 已截止（开标，可查看、排名、授标）
+	PhaseCancelled = "cancelled" // 已取消
+)
+
+// 访问日志：操作者类型
+const (
+	ActorStaff    = "staff"
+	ActorSupplier = "supplier"
+)
+
+// 访问日志：动作
+const (
+	ActionViewBids    = "view_bids"    // 员工查看全部报价
+	ActionViewRanking = "view_ranking" // 员工查看排名
+	ActionAward       = "award"        // 员工授标
+	ActionViewOwn     = "view_own"     // 供应商查看本方报价
+	ActionSubmit      = "submit"       // 供应商提交报价
+)
+
+var (
+	ErrSealed      = errors.New("报价未开标，截止前不可查看")
+	ErrNotOpen     = errors.New("报价轮次尚未开始")
+	ErrLate        = errors.New("报价已截止，迟到报价不予受理")
+	ErrCancelled   = errors.New("报价轮次已取消")
+	ErrStarted     = errors.New("报价轮次已开始，不可修改")
+	ErrEnded       = errors.New("报价轮次已截止")
+	ErrNotInvited  = errors.New("未受邀参加该报价轮次")
+	ErrNotFinished = errors.New("报价轮次尚未截止，不可授标")
+	ErrRoundActive = errors.New("询价单有未截止的密封报价轮次，截止前不可直接录入报价")
+)
+
+// Round 询价单的密封报价轮次：开始至截止期间受邀供应商报价，截止前任何人（含员工）不可查看报价
+type Round struct {
+	ID        string     `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID     string     `gorm:"column:org_id;type:char(36);not null;index;comment:机构ID（base_org.id）" json:"org_id"`
+	InquiryID string     `gorm:"column:inquiry_id;type:char(36);not null;index;comment:询价单ID（base_price_inquiry.id）" json:"inquiry_id"`
+	Title     *string    `gorm:"size:128;comment:轮次名称" json:"title"`
+	OpensAt   time.Time  `gorm:"column:opens_at;not null;comment:开始报价时间" json:"opens_at"`
+	ClosesAt  time.Time  `gorm:"column:closes_at;not null;comment:报价截止（开标）时间" json:"closes_at"`
+	Status    int        `gorm:"not null;default:1;comment:状态：1=正常 2=已取消" json:"status"`
+	AwardedAt *time.Time `gorm:"column:awarded_at;comment:最近授标时间" json:"awarded_at"`
+	CreatedBy *string    `gorm:"column:created_by;type:char(36);comment:创建人ID（base_user.id）" json:"created_by"`
+	IsDeleted int        `gorm:"column:is_deleted;not null;default:0;comment:软删：0=有效 1=删除" json:"-"`
+	Version   int        `gorm:"not null;default:1;comment:版本号（乐观锁）" json:"version"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Phase       string   `gorm:"-" json:"phase"`
+	SupplierIDs []string `gorm:"-" json:"supplier_ids"`
+	Submitted   int64    `gorm:"-" json:"submitted"` // 已报价供应商数（不含价格）
+}
+
+func (r *Round) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.Version == 0 {
+		r.Version = 1
+	}
+	if r.OrgID == "" || r.InquiryID == "" {
+		return errors.New("OrgID/InquiryID 不能为空")
+	}
+	if r.Status == 0 {
+		r.Status = StatusNormal
+	}
+	return nil
+}
+
+func (Round) TableName() string { return "inquiry_round" }
+
+// PhaseAt at 时刻的阶段；截止时刻本身仍可报价
+func (r Round) PhaseAt(at time.Time) string {
+	switch {
+	case r.Status == StatusCancelled:
+		return PhaseCancelled
+	case at.Before(r.OpensAt):
+		return PhasePending
+	case !at.After(r.ClosesAt):
+		return PhaseOpen
+	default:
+		return PhaseClosed
+	}
+}
+
+// SealedAt at 时刻报价是否仍密封（未截止或已取消）
+func (r Round) SealedAt(at time.Time) bool {
+	return r.PhaseAt(at) != PhaseClosed
+}
+
+// Invitee 受邀供应商
+type Invitee struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	RoundID    string    `gorm:"column:round_id;type:char(36);not null;uniqueIndex:uk_irs_round_supplier,priority:1;comment:轮次ID（inquiry_round.id）" json:"round_id"`
+	SupplierID string    `gorm:"column:supplier_id;type:char(36);not null;uniqueIndex:uk_irs_round_supplier,priority:2;index;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (i *Invitee) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Invitee) TableName() string { return "inquiry_round_supplier" }
+
+// Bid 密封报价；截止前可覆盖，结算价 = 单价 × 询价日生效的浮动比例
+type Bid struct {
+	ID          string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	RoundID     string          `gorm:"column:round_id;type:char(36);not null;uniqueIndex:uk_irb_round_supplier_goods,priority:1;comment:轮次ID（inquiry_round.id）" json:"round_id"`
+	SupplierID  string          `gorm:"column:supplier_id;type:char(36);not null;uniqueIndex:uk_irb_round_supplier_goods,priority:2;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	GoodsID     string          `gorm:"column:goods_id;type:char(36);not null;uniqueIndex:uk_irb_round_supplier_goods,priority:3;comment:商品ID（base_goods.id）" json:"goods_id"`
+	UnitPrice   decimal.Decimal `gorm:"column:unit_price;type:decimal(10,2);not null;comment:报价单价" json:"unit_price"`
+	FloatRatio  decimal.Decimal `gorm:"column:float_ratio;type:decimal(6,4);not null;default:1.0000;comment:浮动比例快照" json:"float_ratio"`
+	SettlePrice decimal.Decimal `gorm:"column:settle_price;type:decimal(10,2);not null;comment:结算价（单价×浮动比例）" json:"settle_price"`
+	SubmittedBy *string         `gorm:"column:submitted_by;type:char(36);comment:提交人ID（supplier_user.id）" json:"submitted_by"`
+	SubmittedAt time.Time       `gorm:"column:submitted_at;not null;comment:最近提交时间" json:"submitted_at"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (b *Bid) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Bid) TableName() string { return "inquiry_round_bid" }
+
+// Award 授标：每个商品授予一家供应商，并写入询价单正式报价
+type Award struct {
+	ID          string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	RoundID     string          `gorm:"column:round_id;type:char(36);not null;uniqueIndex:uk_ira_round_goods,priority:1;comment:轮次ID（inquiry_round.id）" json:"round_id"`
+	GoodsID     string          `gorm:"column:goods_id;type:char(36);not null;uniqueIndex:uk_ira_round_goods,priority:2;comment:商品ID（base_goods.id）" json:"goods_id"`
+	SupplierID  string          `gorm:"column:supplier_id;type:char(36);not null;comment:中标供应商ID（supplier.id）" json:"supplier_id"`
+	BidID       string          `gorm:"column:bid_id;type:char(36);not null;comment:中标报价ID（inquiry_round_bid.id）" json:"bid_id"`
+	UnitPrice   decimal.Decimal `gorm:"column:unit_price;type:decimal(10,2);not null;comment:中标单价" json:"unit_price"`
+	SettlePrice decimal.Decimal `gorm:"column:settle_price;type:decimal(10,2);not null;comment:中标结算价" json:"settle_price"`
+	RankNo      int             `gorm:"column:rank_no;not null;comment:中标报价排名" json:"rank_no"`
+	QuoteLineID *string         `gorm:"column:quote_line_id;type:char(36);comment:写入的正式报价ID（base_goods_price.id）" json:"quote_line_id"`
+	AwardedBy   *string         `gorm:"column:awarded_by;type:char(36);comment:授标人ID（base_user.id）" json:"awarded_by"`
+	AwardedAt   time.Time       `gorm:"column:awarded_at;not null;comment:授标时间" json:"awarded_at"`
+}
+
+func (a *Award) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Award) TableName() string { return "inquiry_round_award" }
+
+// AccessLog 密封数据访问审计：报价的查看、提交与授标（含被拒绝的访问）一律记录
+type AccessLog struct {
+	ID        string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID     string    `gorm:"column:org_id;type:char(36);not null;comment:机构ID（base_org.id）" json:"org_id"`
+	RoundID   string    `gorm:"column:round_id;type:char(36);not null;index:idx_irac_round_time,priority:1;comment:轮次ID（inquiry_round.id）" json:"round_id"`
+	ActorType string    `gorm:"column:actor_type;size:16;not null;comment:操作者类型：staff/supplier" json:"actor_type"`
+	ActorID   string    `gorm:"column:actor_id;type:char(36);not null;comment:操作者ID（base_user.id / supplier_user.id）" json:"actor_id"`
+	Action    string    `gorm:"size:32;not null;comment:动作：view_bids/view_ranking/award/view_own/submit" json:"action"`
+	Sealed    bool      `gorm:"not null;comment:访问时是否仍密封" json:"sealed"`
+	Allowed   bool      `gorm:"not null;comment:是否放行" json:"allowed"`
+	Detail    *string   `gorm:"size:255;comment:说明（拒绝原因等）" json:"detail"`
+	ClientIP  *string   `gorm:"column:client_ip;size:64;comment:客户端IP" json:"client_ip"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_irac_round_time,priority:2" json:"created_at"`
+}
+
+func (l *AccessLog) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (AccessLog) TableName() string { return "inquiry_round_access" }
+
+// RankedBid 开标后的报价及其在同一商品内的排名
+type RankedBid struct {
+	BidID        string          `json:"bid_id"`
+	GoodsID      string          `json:"goods_id"`
+	GoodsName    string          `json:"goods_name"`
+	SupplierID   string          `json:"supplier_id"`
+	SupplierName string          `json:"supplier_name"`
+	UnitPrice    decimal.Decimal `json:"unit_price"`
+	FloatRatio   decimal.Decimal `json:"float_ratio"`
+	SettlePrice  decimal.Decimal `json:"settle_price"`
+	SubmittedAt  time.Time       `json:"submitted_at"`
+	RankNo       int             `json:"rank_no"`
+	Awarded      bool            `json:"awarded"`
+}
+
+// Rank 按商品分组、结算价升序排名（结算价相同者名次并列，先提交者在前）
+func Rank(bids []RankedBid) []RankedBid {
+	out := append([]RankedBid(nil), bids...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.GoodsID != b.GoodsID {
+			if a.GoodsName != b.GoodsName {
+				return a.GoodsName < b.GoodsName
+			}
+			return a.GoodsID < b.GoodsID
+		}
+		if c := a.SettlePrice.Cmp(b.SettlePrice); c != 0 {
+			return c < 0
+		}
+		return a.SubmittedAt.Before(b.SubmittedAt)
+	})
+	pos := 0
+	for i := range out {
+		if i == 0 || out[i].GoodsID != out[i-1].GoodsID {
+			pos = 0
+		}
+		pos++
+		if pos > 1 && out[i].SettlePrice.Equal(out[i-1].SettlePrice) {
+			out[i].RankNo = out[i-1].RankNo
+		} else {
+			out[i].RankNo = pos
+		}
+	}
+	return out
+}
+
+// RoundView 门户可见的报价轮次（含本供应商已报价商品数）
+type RoundView struct {
+	RoundID      string    `json:"round_id"`
+	InquiryID    string    `json:"inquiry_id"`
+	InquiryTitle string    `json:"inquiry_title"`
+	InquiryDate  time.Time `json:"inquiry_date"`
+	Title        *string   `json:"title"`
+	OpensAt      time.Time `json:"opens_at"`
+	ClosesAt     time.Time `json:"closes_at"`
+	Status       int       `json:"status"`
+	Phase        string    `json:"phase"`
+	Items        int       `json:"items"`
+	Bid          int       `json:"bid"`
+}
+
+// Item 轮次报价商品及本供应商当前报价
+type Item struct {
+	GoodsID   string           `json:"goods_id"`
+	GoodsName string           `json:"goods_name"`
+	SpecName  string           `json:"spec_name"`
+	UnitName  string           `json:"unit_name"`
+	UnitPrice *decimal.Decimal `json:"unit_price"`
+}
@@ -0,0 +1,69 @@
+package bidding
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func bid(id, goodsName, supplier, settle string, minute int) RankedBid {
+	return RankedBid{
+		BidID:       id,
+		GoodsID:     "g-" + goodsName,
+		GoodsName:   goodsName,
+		SupplierID:  supplier,
+		SettlePrice: decimal.RequireFromString(settle),
+		SubmittedAt: time.Date(2026, 3, 9, 9, minute, 0, 0, time.UTC),
+	}
+}
+
+// rankText 以 报价ID:名次 拼接，便于整体比较顺序与名次
+func rankText(list []RankedBid) string {
+	parts := make([]string, len(list))
+	for i, b := range list {
+		parts[i] = fmt.Sprintf("%s:%d", b.BidID, b.RankNo)
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestRank(t *testing.T) {
+	cases := []struct {
+		name string
+		bids []RankedBid
+		want string
+	}{
+		{name: "空", bids: nil, want: ""},
+		{
+			name: "按结算价升序",
+			bids: []RankedBid{bid("a", "白菜", "s1", "2.30", 1), bid("b", "白菜", "s2", "2.10", 2), bid("c", "白菜", "s3", "2.20", 3)},
+			want: "b:1,c:2,a:3",
+		},
+		{
+			name: "同价并列名次，先提交者在前，后续名次跳过",
+			bids: []RankedBid{bid("a", "白菜", "s1", "2.10", 5), bid("b", "白菜", "s2", "2.10", 1), bid("c", "白菜", "s3", "2.50", 2)},
+			want: "b:1,a:1,c:3",
+		},
+		{
+			name: "按商品名分组，各组独立排名",
+			bids: []RankedBid{bid("a", "猪肉", "s1", "28", 1), bid("b", "白菜", "s1", "2.5", 1), bid("c", "猪肉", "s2", "27", 2), bid("d", "白菜", "s2", "2.4", 2)},
+			want: "c:1,a:2,d:1,b:2", // 商品名按字节序：猪 (U+732A) 在白 (U+767D) 之前
+		},
+		{
+			name: "结算价比较按数值而非字符串",
+			bids: []RankedBid{bid("a", "白菜", "s1", "10.00", 1), bid("b", "白菜", "s2", "9.5", 2)},
+			want: "b:1,a:2",
+		},
+	}
+	for _, c := range cases {
+		in := append([]RankedBid(nil), c.bids...)
+		if got := rankText(Rank(c.bids)); got != c.want {
+			t.Errorf("%s: Rank = %q，期望 %q", c.name, got, c.want)
+		}
+		if rankText(in) != rankText(c.bids) {
+			t.Errorf("%s: Rank 修改了入参", c.name)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"hdzk.cn/foodapp/internal/domain/coderule"
 	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
@@ -17,16 +18,27 @@ type Category struct {
 	Code      *string   `gorm:"size:64;uniqueIndex:uq_category_code;comment:品类编码（可选，建议唯一）"`
 	Pinyin    *string   `gorm:"size:64;comment:拼音（可选，用于搜索）"`
 	Sort      int       `gorm:"not null;default:0;index;comment:排序值"`
+	ParentID  *string   `gorm:"column:parent_id;type:char(36);index;comment:上级品类ID（NULL=顶级品类）"`
 	OrgID     string    `gorm:"column:org_id;type:char(36);not null;comment:所属机构ID"` // 注意 tag
 	IsDeleted int       `gorm:"not null;default:0;comment:软删标记：0=有效,1=已删除"`
+	Version   int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
 
+// TreeNode 品类树节点（tree_category 返回）
+type TreeNode struct {
+	Category
+	Children []*TreeNode `json:"children"`
+}
+
 func (c *Category) BeforeCreate(tx *gorm.DB) error {
 	if c.ID == "" {
 		c.ID = uuid.NewString()
 	}
+	if c.Version == 0 {
+		c.Version = 1
+	}
 	if c.OrgID == "" {
 		return errors.New("OrgID(org_id) 不能为空")
 	}
@@ -44,6 +56,16 @@ func (c *Category) BeforeCreate(tx *gorm.DB) error {
 	}
 	base := orgSort * 1000
 
+	// 子品类：code 前缀取上级品类 code（蔬菜 001 → 叶菜类 001001 → ...）
+	codePrefix := orgCode
+	if c.ParentID != nil && *c.ParentID != "" {
+		parentCode, err := parentCodeOf(tx, c.OrgID, *c.ParentID)
+		if err != nil {
+			return err
+		}
+		codePrefix = parentCode
+	}
+
 	// 2) sort = org.sort*1000 + 最小缺口
 	if c.Sort <= 0 {
 		suf, err := utils.NextSortSuffix(tx, c.TableName(), c.OrgID, base, true)
@@ -53,13 +75,18 @@ func (c *Category) BeforeCreate(tx *gorm.DB) error {
 		c.Sort = base + suf
 	}
 
-	// 3) code = org.code + 三位后缀（各自独立找缺口）
+	// 3) code 按编码规则生成（未配置时为 前缀(org.code / 上级品类 code) + 三位序号）
 	if c.Code == nil || (c.Code != nil && *c.Code == "") {
-		suf, err := utils.NextCodeSuffixByPrefix(tx, c.TableName(), c.OrgID, orgCode, true)
+		auto, _, err := coderule.Next(tx, coderule.Input{
+			Entity: coderule.EntityCategory,
+			OrgID:  c.OrgID,
+			Table:  c.TableName(),
+			MaxLen: 64,
+			Values: coderule.Values{Org: orgCode, Parent: codePrefix},
+		})
 		if err != nil {
 			return err
 		}
-		auto := fmt.Sprintf("%s%03d", orgCode, suf)
 		c.Code = &auto
 	}
 
@@ -77,4 +104,68 @@ func (Category) TableName() string { return "base_category" }
 
 // ---------- helpers ----------
 
-// sort 段的最小缺口：仅统计本 organ (base, base+999] 的 sort
+// 上级品类 code：必须同 org、未删除且已有编码
+func parentCodeOf(tx *gorm.DB, orgID, parentID string) (string, error) {
+	var row struct {
+		OrgID string
+		Code  *string
+	}
+	err := tx.Session(&gorm.Session{NewDB: true}).
+		Table(Category{}.TableName()).
+		Select("org_id, code").
+		Where("id = ? AND is_deleted = 0", parentID).
+		Take(&row).Error
+	if err != nil {
+		return "", fmt.Errorf("上级品类不存在: %w", err)
+	}
+	if row.OrgID != orgID {
+		return "", errors.New("上级品类不属于同一组织")
+	}
+	if row.Code == nil || *row.Code == "" {
+		return "", errors.New("上级品类 code 为空，无法派生子品类 code")
+	}
+	return *row.Code, nil
+}
+
+// BuildTree 将同一 org 的平铺品类组装为树（入参需已按 sort 排序）
+func BuildTree(list []Category) []*TreeNode {
+	nodes := make(map[string]*TreeNode, len(list))
+	for i := range list {
+		nodes[list[i].ID] = &TreeNode{Category: list[i], Children: []*TreeNode{}}
+	}
+	roots := make([]*TreeNode, 0)
+	for i := range list {
+		n := nodes[list[i].ID]
+		if p := list[i].ParentID; p != nil && *p != "" {
+			if parent, ok := nodes[*p]; ok {
+				parent.Children = append(parent.Children, n)
+				continue
+			}
+		}
+		// 无上级或上级已删除：作为顶级展示
+		roots = append(roots, n)
+	}
+	return roots
+}
+
+// DescendantIDs 返回 rootID 及其全部后代品类 ID（BFS，rootID 在首位）
+func DescendantIDs(list []Category, rootID string) []string {
+	children := make(map[string][]string, len(list))
+	for _, c := range list {
+		if c.ParentID != nil && *c.ParentID != "" {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	out := []string{rootID}
+	seen := map[string]bool{rootID: true}
+	for i := 0; i < len(out); i++ {
+		for _, child := range children[out[i]] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			out = append(out, child)
+		}
+	}
+	return out
+}
@@ -0,0 +1,209 @@
+package coderule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Input 生成编码的上下文
+type Input struct {
+	Entity string
+	OrgID  string // 字典类实体为空
+	Table  string // 实体表（编码列为 code）
+	MaxLen int    // 编码列长度，0=不校验
+	Values Values
+}
+
+// Next 按生效规则发放下一个编码；须在创建实体的事务内调用（计数器行加锁）。
+// 未配置规则且实体无内置默认时返回 ok=false，由调用方沿用旧规则
+func Next(tx *gorm.DB, in Input) (code string, ok bool, err error) {
+	return next(tx, in, false)
+}
+
+// Peek 预览下一个编码：不加锁，也不推进计数器
+func Peek(db *gorm.DB, in Input) (code string, ok bool, err error) {
+	return next(db, in, true)
+}
+
+func next(db *gorm.DB, in Input, dry bool) (string, bool, error) {
+	db = db.Session(&gorm.Session{NewDB: true})
+	pattern, reuse, err := effectiveRule(db, in.Entity, in.OrgID)
+	if err != nil || pattern == "" {
+		return "", false, err
+	}
+	p, err := Parse(pattern, in.Entity)
+	if err != nil {
+		return "", false, fmt.Errorf("编码规则 %q 非法: %w", pattern, err)
+	}
+	if in.Values.Now.IsZero() {
+		in.Values.Now = time.Now()
+	}
+	prefix, suffix, err := p.Split(in.Values)
+	if err != nil {
+		return "", false, err
+	}
+	scope := prefix + "{seq}" + suffix
+	if utf8.RuneCountInString(scope) > 191 {
+		return "", false, errors.New("编码规则渲染结果过长")
+	}
+
+	last, err := counterSeq(db, in, p, prefix, suffix, scope, dry)
+	if err != nil {
+		return "", false, err
+	}
+	seq := last + 1
+	if reuse {
+		used, _, err := usedSeqs(db, in.Table, p, prefix, suffix)
+		if err != nil {
+			return "", false, err
+		}
+		seq = firstGap(used, last)
+	}
+
+	// 跳过已被占用的编码（如手工录入的编码恰好落在序号段内）
+	var code string
+	for limit := p.MaxSeq(); ; seq++ {
+		if seq > limit {
+			return "", false, fmt.Errorf("编码序号已满（%s 最大 %d），请调整编码规则", scope, limit)
+		}
+		code = prefix + p.Format(seq) + suffix
+		if in.MaxLen > 0 && utf8.RuneCountInString(code) > in.MaxLen {
+			return "", false, fmt.Errorf("生成的编码 %s 超过 %d 字符", code, in.MaxLen)
+		}
+		var n int64
+		if err := db.Table(in.Table).Where("code = ?", code).Count(&n).Error; err != nil {
+			return "", false, err
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	if !dry && seq > last {
+		if err := db.Model(&Counter{}).
+			Where("entity_type = ? AND scope = ?", in.Entity, scope).
+			Update("last_seq", seq).Error; err != nil {
+			return "", false, err
+		}
+	}
+	return code, true, nil
+}
+
+// effectiveRule 机构规则 → 全局规则 → 内置默认
+func effectiveRule(db *gorm.DB, entity, orgID string) (string, bool, error) {
+	ent, ok := Entities[entity]
+	if !ok {
+		return "", false, fmt.Errorf("不支持的实体类型: %s", entity)
+	}
+	orgs := []string{""}
+	if orgID != "" && !ent.Global {
+		orgs = append(orgs, orgID)
+	}
+	var rules []Rule
+	if err := db.Where("entity_type = ? AND org_id IN ?", entity, orgs).
+		Order("org_id DESC").Limit(1).
+		Find(&rules).Error; err != nil {
+		return "", false, err
+	}
+	if len(rules) == 0 {
+		return ent.Default, false, nil
+	}
+	return rules[0].Pattern, rules[0].ReuseGaps == 1, nil
+}
+
+// counterSeq 读取（并锁定）作用域计数器；不存在时以表内已有编码的最大序号初始化
+func counterSeq(db *gorm.DB, in Input, p *Pattern, prefix, suffix, scope string, dry bool) (int, error) {
+	load := func() (*Counter, error) {
+		q := db.Where("entity_type = ? AND scope = ?", in.Entity, scope)
+		if !dry {
+			q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var c Counter
+		if err := q.Take(&c).Error; err != nil {
+			return nil, err
+		}
+		return &c, nil
+	}
+	c, err := load()
+	if err == nil {
+		return c.LastSeq, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, err
+	}
+
+	_, seed, err := usedSeqs(db, in.Table, p, prefix, suffix)
+	if err != nil {
+		return 0, err
+	}
+	if dry {
+		return seed, nil
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Counter{EntityType: in.Entity, Scope: scope, LastSeq: seed}).Error; err != nil {
+		return 0, err
+	}
+	if c, err = load(); err != nil {
+		return 0, err
+	}
+	return c.LastSeq, nil
+}
+
+// usedSeqs 表内（含已软删）形如 prefix + N 位数字 + suffix 的编码所占序号及其最大值
+func usedSeqs(db *gorm.DB, table string, p *Pattern, prefix, suffix string) (map[int]bool, int, error) {
+	var codes []string
+	if err := db.Table(table).
+		Where("code LIKE ? AND CHAR_LENGTH(code) = ?",
+			escapeLike(prefix)+"%"+escapeLike(suffix),
+			utf8.RuneCountInString(prefix)+p.SeqWidth+utf8.RuneCountInString(suffix)).
+		Pluck("code", &codes).Error; err != nil {
+		return nil, 0, fmt.Errorf("扫描已有编码失败: %w", err)
+	}
+	used := make(map[int]bool, len(codes))
+	top := 0
+	for _, c := range codes {
+		n, ok := seqOf(c, prefix, suffix, p.SeqWidth)
+		if !ok {
+			continue
+		}
+		used[n] = true
+		if n > top {
+			top = n
+		}
+	}
+	return used, top, nil
+}
+
+// seqOf 取 code 中 prefix 与 suffix 之间的 width 位序号；形态不符时 ok=false
+func seqOf(code, prefix, suffix string, width int) (int, bool) {
+	if len(code) < len(prefix)+len(suffix) || !strings.HasPrefix(code, prefix) || !strings.HasSuffix(code, suffix) {
+		return 0, false // 排序规则不区分大小写时 LIKE 会多匹配
+	}
+	mid := code[len(prefix) : len(code)-len(suffix)]
+	if len(mid) != width || strings.Trim(mid, "0123456789") != "" {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(mid)
+	return n, true
+}
+
+// firstGap 1..last 中最小的未占用序号；无空缺时为 last+1
+func firstGap(used map[int]bool, last int) int {
+	for s := 1; s <= last; s++ {
+		if !used[s] {
+			return s
+		}
+	}
+	return last + 1
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,89 @@
+package coderule
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 适用编码规则的实体类型
+const (
+	EntityGoods       = "goods"
+	EntityCategory    = "category"
+	EntitySupplier    = "supplier"
+	EntityUnit        = "unit"
+	EntitySpec        = "spec"
+	EntityMealTime    = "meal_time"
+	EntityWasteReason = "waste_reason"
+)
+
+// ErrDuplicate 同一机构（或全局）同一实体类型已配置规则
+var ErrDuplicate = errors.New("该实体类型已配置编码规则，请直接修改")
+
+// Rule 编码规则：按实体类型 + 机构配置，OrgID 为空表示全局默认（字典类实体只有全局规则）。
+// 生效顺序：机构规则 → 全局规则 → 内置默认（见 Entities）
+type Rule struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID      string    `gorm:"column:org_id;type:char(36);not null;default:'';uniqueIndex:uk_code_rule_org_entity,priority:1;comment:机构ID（空=全局默认）" json:"org_id"`
+	EntityType string    `gorm:"column:entity_type;size:32;not null;uniqueIndex:uk_code_rule_org_entity,priority:2;comment:实体类型：goods/category/supplier/unit/spec/meal_time/waste_reason" json:"entity_type"`
+	Pattern    string    `gorm:"size:128;not null;comment:编码模式，如 {org}{cat}{seq:5}" json:"pattern"`
+	ReuseGaps  int       `gorm:"column:reuse_gaps;not null;default:0;comment:是否回填序号空缺：0=否（计数器递增） 1=是" json:"reuse_gaps"`
+	Version    int       `gorm:"not null;default:1;comment:版本号（乐观锁）" json:"version"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *Rule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.Version == 0 {
+		r.Version = 1
+	}
+	if r.EntityType == "" || r.Pattern == "" {
+		return errors.New("EntityType/Pattern 不能为空")
+	}
+	return nil
+}
+
+func (Rule) TableName() string { return "base_code_rule" }
+
+// Counter 序号计数器：按 实体类型 + 作用域（模式渲染后 {seq} 以外的部分）计数，
+// 首次使用时以表内已有编码的最大序号初始化，之后在行锁内递增，不再扫描全部编码
+type Counter struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	EntityType string    `gorm:"column:entity_type;size:32;not null;uniqueIndex:uk_code_counter_scope,priority:1;comment:实体类型" json:"entity_type"`
+	Scope      string    `gorm:"size:191;not null;uniqueIndex:uk_code_counter_scope,priority:2;comment:作用域，如 HD001{seq}" json:"scope"`
+	LastSeq    int       `gorm:"column:last_seq;not null;default:0;comment:已发放的最大序号" json:"last_seq"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (c *Counter) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Counter) TableName() string { return "base_code_counter" }
+
+// Entity 实体类型的编码约束
+type Entity struct {
+	Tokens  map[string]bool // 允许的占位符（seq 与日期占位符总是允许）
+	Default string          // 未配置规则时的内置模式；空=沿用实体自身的旧规则
+	Global  bool            // 仅全局规则（字典类，无机构维度）
+}
+
+// Entities 支持的实体类型；内置默认与改造前的 org.code/上级 code + 三位序号一致
+var Entities = map[string]Entity{
+	EntityGoods:       {Tokens: map[string]bool{TokenOrg: true, TokenCat: true}, Default: "{org}{seq:3}"},
+	EntityCategory:    {Tokens: map[string]bool{TokenOrg: true, TokenParent: true}, Default: "{parent}{seq:3}"},
+	EntitySupplier:    {Tokens: map[string]bool{TokenOrg: true}, Default: "{org}{seq:3}"},
+	EntityUnit:        {Global: true},
+	EntitySpec:        {Global: true},
+	EntityMealTime:    {Global: true},
+	EntityWasteReason: {Global: true},
+}
@@ -0,0 +1,157 @@
+package coderule
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 模式占位符
+const (
+	TokenOrg    = "org"    // 机构 code
+	TokenCat    = "cat"    // 商品所属品类 code
+	TokenParent = "parent" // 上级品类 code（顶级品类取机构 code）
+	TokenYYYY   = "yyyy"   // 四位年份
+	TokenYY     = "yy"     // 两位年份
+	TokenMM     = "mm"     // 两位月份
+	TokenSeq    = "seq"    // 序号，{seq:N} 为 N 位补零（1..9）
+)
+
+// Values 渲染占位符所需的取值
+type Values struct {
+	Org    string
+	Cat    string
+	Parent string
+	Now    time.Time
+}
+
+type part struct {
+	lit   string
+	token string
+}
+
+// Pattern 解析后的编码模式，恰含一个 {seq:N}
+type Pattern struct {
+	parts    []part
+	seqAt    int
+	SeqWidth int
+}
+
+// Parse 解析编码模式；entity 非空时校验占位符是否适用于该实体
+func Parse(pattern, entity string) (*Pattern, error) {
+	var ent Entity
+	if entity != "" {
+		e, ok := Entities[entity]
+		if !ok {
+			return nil, fmt.Errorf("不支持的实体类型: %s", entity)
+		}
+		ent = e
+	}
+	p := &Pattern{seqAt: -1}
+	rest := pattern
+	for rest != "" {
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			p.parts = append(p.parts, part{lit: rest})
+			break
+		}
+		if i > 0 {
+			p.parts = append(p.parts, part{lit: rest[:i]})
+		}
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return nil, errors.New("编码模式缺少 }")
+		}
+		tok := rest[i+1 : i+j]
+		rest = rest[i+j+1:]
+
+		name, arg, hasArg := strings.Cut(tok, ":")
+		switch name {
+		case TokenSeq:
+			if p.seqAt >= 0 {
+				return nil, errors.New("编码模式只能包含一个 {seq:N}")
+			}
+			w, err := strconv.Atoi(arg)
+			if !hasArg || err != nil || w < 1 || w > 9 {
+				return nil, errors.New("序号占位符应为 {seq:N}，N 取 1..9")
+			}
+			p.seqAt = len(p.parts)
+			p.SeqWidth = w
+		case TokenYYYY, TokenYY, TokenMM:
+		case TokenOrg, TokenCat, TokenParent:
+			if entity != "" && !ent.Tokens[name] {
+				return nil, fmt.Errorf("占位符 {%s} 不适用于 %s", name, entity)
+			}
+		default:
+			return nil, fmt.Errorf("未知占位符 {%s}", tok)
+		}
+		if hasArg && name != TokenSeq {
+			return nil, fmt.Errorf("占位符 {%s} 不接受参数", name)
+		}
+		p.parts = append(p.parts, part{token: name})
+	}
+	if p.seqAt < 0 {
+		return nil, errors.New("编码模式必须包含 {seq:N}")
+	}
+	return p, nil
+}
+
+// Split 渲染 {seq} 两侧的文本
+func (p *Pattern) Split(v Values) (prefix, suffix string, err error) {
+	var b strings.Builder
+	for i, pt := range p.parts {
+		if i == p.seqAt {
+			prefix = b.String()
+			b.Reset()
+			continue
+		}
+		if pt.token == "" {
+			b.WriteString(pt.lit)
+			continue
+		}
+		s, err := v.value(pt.token)
+		if err != nil {
+			return "", "", err
+		}
+		b.WriteString(s)
+	}
+	return prefix, b.String(), nil
+}
+
+// MaxSeq {seq:N} 可发放的最大序号
+func (p *Pattern) MaxSeq() int {
+	m := 1
+	for i := 0; i < p.SeqWidth; i++ {
+		m *= 10
+	}
+	return m - 1
+}
+
+// Format 序号补零
+func (p *Pattern) Format(seq int) string {
+	return fmt.Sprintf("%0*d", p.SeqWidth, seq)
+}
+
+func (v Values) value(token string) (string, error) {
+	var s string
+	switch token {
+	case TokenOrg:
+		s = v.Org
+	case TokenCat:
+		s = v.Cat
+	case TokenParent:
+		s = v.Parent
+	case TokenYYYY:
+		return v.Now.Format("2006"), nil
+	case TokenYY:
+		return v.Now.Format("06"), nil
+	case TokenMM:
+		return v.Now.Format("01"), nil
+	}
+	if s == "" {
+		return "", fmt.Errorf("编码模式需要 {%s}，但对应编码为空", token)
+	}
+	return s, nil
+}
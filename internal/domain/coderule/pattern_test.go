@@ -0,0 +1,169 @@
+package coderule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		pattern string
+		entity  string
+		width   int
+		wantErr bool
+	}{
+		{pattern: "{org}{seq:3}", entity: EntityGoods, width: 3},
+		{pattern: "G{yyyy}{mm}-{seq:5}", entity: EntityGoods, width: 5},
+		{pattern: "{parent}{seq:3}", entity: EntityCategory, width: 3},
+		{pattern: "U{seq:9}", entity: EntityUnit, width: 9},
+		{pattern: "{cat}{seq:4}", width: 4},
+		{pattern: "{org}", entity: EntityGoods, wantErr: true},
+		{pattern: "{seq:3}{seq:3}", entity: EntityGoods, wantErr: true},
+		{pattern: "{seq}", entity: EntityGoods, wantErr: true},
+		{pattern: "{seq:0}", entity: EntityGoods, wantErr: true},
+		{pattern: "{seq:10}", entity: EntityGoods, wantErr: true},
+		{pattern: "{seq:x}", entity: EntityGoods, wantErr: true},
+		{pattern: "{org{seq:3}", entity: EntityGoods, wantErr: true},
+		{pattern: "{seq:3", entity: EntityGoods, wantErr: true},
+		{pattern: "{foo}{seq:3}", entity: EntityGoods, wantErr: true},
+		{pattern: "{org:2}{seq:3}", entity: EntityGoods, wantErr: true},
+		{pattern: "{cat}{seq:3}", entity: EntitySupplier, wantErr: true},
+		{pattern: "{org}{seq:3}", entity: EntityUnit, wantErr: true},
+		{pattern: "{seq:3}", entity: "unknown", wantErr: true},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.pattern, c.entity)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q, %q) 应返回错误", c.pattern, c.entity)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q, %q) 返回错误: %v", c.pattern, c.entity, err)
+			continue
+		}
+		if p.SeqWidth != c.width {
+			t.Errorf("Parse(%q, %q).SeqWidth = %d，期望 %d", c.pattern, c.entity, p.SeqWidth, c.width)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	now := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	v := Values{Org: "HD001", Cat: "001002", Parent: "001", Now: now}
+	cases := []struct {
+		pattern string
+		values  Values
+		prefix  string
+		suffix  string
+		wantErr bool
+	}{
+		{pattern: "{org}{seq:3}", values: v, prefix: "HD001"},
+		{pattern: "{cat}-{seq:4}", values: v, prefix: "001002-"},
+		{pattern: "{parent}{seq:3}", values: v, prefix: "001"},
+		{pattern: "G{yyyy}{mm}{seq:5}", values: v, prefix: "G202603"},
+		{pattern: "{yy}{seq:2}-{org}", values: v, prefix: "26", suffix: "-HD001"},
+		{pattern: "{seq:3}", values: v},
+		{pattern: "{cat}{seq:3}", values: Values{Org: "HD001", Now: now}, wantErr: true},
+	}
+	for _, c := range cases {
+		p, err := Parse(c.pattern, "")
+		if err != nil {
+			t.Fatalf("Parse(%q) 返回错误: %v", c.pattern, err)
+		}
+		prefix, suffix, err := p.Split(c.values)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q Split 应返回错误，得到 %q/%q", c.pattern, prefix, suffix)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q Split 返回错误: %v", c.pattern, err)
+			continue
+		}
+		if prefix != c.prefix || suffix != c.suffix {
+			t.Errorf("%q Split = %q/%q，期望 %q/%q", c.pattern, prefix, suffix, c.prefix, c.suffix)
+		}
+	}
+}
+
+func TestFormatAndMaxSeq(t *testing.T) {
+	cases := []struct {
+		width  int
+		seq    int
+		format string
+		max    int
+	}{
+		{width: 1, seq: 7, format: "7", max: 9},
+		{width: 3, seq: 7, format: "007", max: 999},
+		{width: 3, seq: 999, format: "999", max: 999},
+		{width: 5, seq: 42, format: "00042", max: 99999},
+		{width: 9, seq: 1, format: "000000001", max: 999999999},
+	}
+	for _, c := range cases {
+		p := &Pattern{SeqWidth: c.width}
+		if got := p.Format(c.seq); got != c.format {
+			t.Errorf("{seq:%d} Format(%d) = %q，期望 %q", c.width, c.seq, got, c.format)
+		}
+		if got := p.MaxSeq(); got != c.max {
+			t.Errorf("{seq:%d} MaxSeq() = %d，期望 %d", c.width, got, c.max)
+		}
+	}
+}
+
+func TestSeqOf(t *testing.T) {
+	cases := []struct {
+		code   string
+		prefix string
+		suffix string
+		width  int
+		seq    int
+		ok     bool
+	}{
+		{code: "HD001007", prefix: "HD001", width: 3, seq: 7, ok: true},
+		{code: "HD001000", prefix: "HD001", width: 3, seq: 0, ok: true},
+		{code: "G202603-00042-X", prefix: "G202603-", suffix: "-X", width: 5, seq: 42, ok: true},
+		{code: "HD0017", prefix: "HD001", width: 3},
+		{code: "HD0010007", prefix: "HD001", width: 3},
+		{code: "HD00100A", prefix: "HD001", width: 3},
+		{code: "hd001007", prefix: "HD001", width: 3},
+		{code: "G202603-00042-Y", prefix: "G202603-", suffix: "-X", width: 5},
+		{code: "HD", prefix: "HD001", width: 3},
+	}
+	for _, c := range cases {
+		seq, ok := seqOf(c.code, c.prefix, c.suffix, c.width)
+		if ok != c.ok || seq != c.seq {
+			t.Errorf("seqOf(%q, %q, %q, %d) = %d/%v，期望 %d/%v", c.code, c.prefix, c.suffix, c.width, seq, ok, c.seq, c.ok)
+		}
+	}
+}
+
+func TestFirstGap(t *testing.T) {
+	set := func(seqs ...int) map[int]bool {
+		m := make(map[int]bool, len(seqs))
+		for _, s := range seqs {
+			m[s] = true
+		}
+		return m
+	}
+	cases := []struct {
+		used map[int]bool
+		last int
+		want int
+	}{
+		{used: set(), last: 0, want: 1},
+		{used: set(1, 2, 3), last: 3, want: 4},
+		{used: set(1, 3), last: 3, want: 2},
+		{used: set(2, 3), last: 3, want: 1},
+		{used: set(1, 2, 3, 5), last: 5, want: 4},
+		// 计数器落后于表内编码时只在 1..last 内回填，其后由占用检查顺延
+		{used: set(1, 2, 3, 4), last: 2, want: 3},
+	}
+	for _, c := range cases {
+		if got := firstGap(c.used, c.last); got != c.want {
+			t.Errorf("firstGap(%v, %d) = %d，期望 %d", c.used, c.last, got, c.want)
+		}
+	}
+}
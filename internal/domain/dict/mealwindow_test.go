@@ -0,0 +1,72 @@
+package dict
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverlaps(t *testing.T) {
+	w := func(start, end string) MealWindow { return MealWindow{StartTime: start, EndTime: end} }
+	cases := []struct {
+		a, b MealWindow
+		want bool
+	}{
+		{w("06:00", "09:00"), w("11:00", "13:00"), false},
+		{w("06:00", "09:00"), w("08:30", "10:00"), true},
+		{w("06:00", "09:00"), w("09:00", "10:00"), false}, // 半开区间，首尾相接不算重叠
+		{w("06:00", "12:00"), w("08:00", "09:00"), true},
+		{w("22:00", "02:00"), w("01:00", "03:00"), true},
+		{w("22:00", "02:00"), w("23:00", "23:30"), true},
+		{w("22:00", "02:00"), w("02:00", "06:00"), false},
+		{w("22:00", "02:00"), w("20:00", "22:00"), false},
+		{w("22:00", "00:00"), w("00:00", "01:00"), false},
+		{w("21:00", "03:00"), w("23:00", "01:00"), true},
+		{w("08:00", "08:00"), w("00:00", "23:59"), false}, // 无效时段
+		{w("bad", "09:00"), w("06:00", "09:00"), false},
+	}
+	for _, c := range cases {
+		if got := Overlaps(c.a, c.b); got != c.want {
+			t.Errorf("Overlaps(%s-%s, %s-%s) = %v，期望 %v", c.a.StartTime, c.a.EndTime, c.b.StartTime, c.b.EndTime, got, c.want)
+		}
+		if got := Overlaps(c.b, c.a); got != c.want {
+			t.Errorf("Overlaps(%s-%s, %s-%s) = %v，期望 %v", c.b.StartTime, c.b.EndTime, c.a.StartTime, c.a.EndTime, got, c.want)
+		}
+	}
+}
+
+func TestAttribute(t *testing.T) {
+	windows := []MealWindow{
+		{MealID: "breakfast", StartTime: "06:00", EndTime: "09:00"},
+		{MealID: "lunch", StartTime: "11:00", EndTime: "13:30"},
+		{MealID: "night", StartTime: "22:00", EndTime: "02:00"},
+	}
+	cst := time.FixedZone("CST", 8*3600)
+	cases := []struct {
+		at     time.Time
+		loc    *time.Location
+		meal   string // 空表示不在任何时段内
+		day    string
+		reason string
+	}{
+		{at: time.Date(2026, 3, 9, 7, 30, 0, 0, cst), loc: cst, meal: "breakfast", day: "2026-03-09"},
+		{at: time.Date(2026, 3, 9, 9, 0, 0, 0, cst), loc: cst, day: "2026-03-09", reason: "结束时刻不含"},
+		{at: time.Date(2026, 3, 9, 13, 29, 0, 0, cst), loc: cst, meal: "lunch", day: "2026-03-09"},
+		{at: time.Date(2026, 3, 9, 23, 0, 0, 0, cst), loc: cst, meal: "night", day: "2026-03-09"},
+		{at: time.Date(2026, 3, 10, 1, 0, 0, 0, cst), loc: cst, meal: "night", day: "2026-03-09", reason: "跨零点归前一天"},
+		{at: time.Date(2026, 3, 1, 1, 0, 0, 0, cst), loc: cst, meal: "night", day: "2026-02-28", reason: "跨月"},
+		// 同一时刻以 UTC 传入，按机构时区判断
+		{at: time.Date(2026, 3, 8, 23, 30, 0, 0, time.UTC), loc: cst, meal: "breakfast", day: "2026-03-09"},
+		{at: time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC), loc: cst, meal: "night", day: "2026-03-09"},
+		{at: time.Date(2026, 3, 9, 17, 0, 0, 0, time.UTC), loc: time.UTC, day: "2026-03-09", reason: "UTC 17:00 不在时段内"},
+	}
+	for _, c := range cases {
+		w, day, ok := Attribute(windows, c.at, c.loc)
+		meal := ""
+		if ok {
+			meal = w.MealID
+		}
+		if meal != c.meal || day.Format("2006-01-02") != c.day {
+			t.Errorf("Attribute(%s, %s) = %q/%s，期望 %q/%s %s", c.at.Format(time.RFC3339), c.loc, meal, day.Format("2006-01-02"), c.meal, c.day, c.reason)
+		}
+	}
+}
@@ -1,47 +1,104 @@
 package dict
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"hdzk.cn/foodapp/internal/domain/coderule"
 	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
+// 单位量纲：同一量纲内按 Factor 换算到基准单位（质量=公斤，体积=升，计数=个）
+const (
+	DimensionMass   = "mass"
+	DimensionVolume = "volume"
+	DimensionCount  = "count"
+)
+
+// ConversionScale 换算结果保留的小数位（与 factor 列精度一致）
+const ConversionScale = 8
+
+// ErrRebaseFactor 更换量纲基准单位时，新基准须已按原基准设置系数，才能重算同量纲其它单位
+var ErrRebaseFactor = errors.New("新基准单位须先在该量纲下设置相对原基准的换算系数，才能更换基准单位")
+
+func ValidDimension(d string) bool {
+	switch d {
+	case DimensionMass, DimensionVolume, DimensionCount:
+		return true
+	}
+	return false
+}
+
 type Unit struct {
-	ID        string    `gorm:"primaryKey;type:char(36)"`
-	Name      string    `gorm:"size:32;not null;uniqueIndex:uk_unit_name;comment:单位"`
-	Code      *string   `gorm:"size:32;uniqueIndex:uk_unit_code;comment:单位编码"`
-	Sort      int       `gorm:"not null;default:0;index;comment:排序码"`
-	IsDeleted int       `gorm:"not null;default:0;index;comment:是否已删除"`
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+	ID        string           `gorm:"primaryKey;type:char(36)"`
+	Name      string           `gorm:"size:32;not null;uniqueIndex:uk_unit_name;comment:单位"`
+	Code      *string          `gorm:"size:32;uniqueIndex:uk_unit_code;comment:单位编码"`
+	Sort      int              `gorm:"not null;default:0;index;comment:排序码"`
+	Dimension *string          `gorm:"size:16;index;comment:量纲：mass/volume/count（空=未定义换算）"`
+	Factor    *decimal.Decimal `gorm:"type:decimal(20,8);comment:1 本单位 = factor 基准单位"`
+	IsBase    int              `gorm:"not null;default:0;comment:是否为该量纲基准单位：0=否 1=是"`
+	IsDeleted int              `gorm:"not null;default:0;index;comment:是否已删除"`
+	Version   int              `gorm:"not null;default:1;comment:版本号（乐观锁）"`
+	CreatedAt time.Time        `gorm:"autoCreateTime"`
+	UpdatedAt time.Time        `gorm:"autoUpdateTime"`
 }
 
 func (u *Unit) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
 		u.ID = uuid.NewString()
 	}
+	if u.Version == 0 {
+		u.Version = 1
+	}
 
 	sort, err := utils.NextColoumSort(tx, u.TableName())
 	if err != nil {
 		return err
 	}
 	u.Sort = sort
-	code := codeFromSort(sort)
+	code, err := dictCode(tx, coderule.EntityUnit, u.TableName(), sort)
+	if err != nil {
+		return err
+	}
 	u.Code = &code
 	return nil
 }
 
 func (Unit) TableName() string { return "base_unit" }
 
+// GoodsUnitConversion 商品级换算（覆盖通用换算）：1 UnitID = Factor ToUnitID，
+// 如 “该商品 1 包 = 0.5 公斤”；用于跨量纲（包→公斤）或同名单位规格不同的商品
+type GoodsUnitConversion struct {
+	ID        string          `gorm:"primaryKey;type:char(36)"`
+	GoodsID   string          `gorm:"column:goods_id;type:char(36);not null;uniqueIndex:uk_guc_goods_unit_to,priority:1;comment:商品ID（base_goods.id）"`
+	UnitID    string          `gorm:"column:unit_id;type:char(36);not null;uniqueIndex:uk_guc_goods_unit_to,priority:2;comment:源单位ID（base_unit.id）"`
+	ToUnitID  string          `gorm:"column:to_unit_id;type:char(36);not null;uniqueIndex:uk_guc_goods_unit_to,priority:3;comment:目标单位ID（base_unit.id）"`
+	Factor    decimal.Decimal `gorm:"type:decimal(20,8);not null;comment:1 源单位 = factor 目标单位"`
+	OrgID     string          `gorm:"column:org_id;type:char(36);not null;index;comment:所属机构ID"`
+	CreatedAt time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime"`
+}
+
+func (g *GoodsUnitConversion) BeforeCreate(tx *gorm.DB) error {
+	if g.ID == "" {
+		g.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (GoodsUnitConversion) TableName() string { return "base_goods_unit_conversion" }
+
 type Spec struct {
 	ID        string    `gorm:"primaryKey;type:char(36)"`
 	Name      string    `gorm:"size:32;not null;uniqueIndex:uk_spec_name;comment:规格名称"`
 	Code      *string   `gorm:"size:32;uniqueIndex:uk_spec_code;comment:规格编码"`
 	Sort      int       `gorm:"not null;default:0;index"`
 	IsDeleted int       `gorm:"not null;default:0;index;comment:是否已删除"`
+	Version   int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
@@ -50,12 +107,18 @@ func (s *Spec) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == "" {
 		s.ID = uuid.NewString()
 	}
+	if s.Version == 0 {
+		s.Version = 1
+	}
 	sort, err := utils.NextColoumSort(tx, s.TableName())
 	if err != nil {
 		return err
 	}
 	s.Sort = sort
-	code := codeFromSort(sort)
+	code, err := dictCode(tx, coderule.EntitySpec, s.TableName(), sort)
+	if err != nil {
+		return err
+	}
 	s.Code = &code
 	return nil
 }
@@ -67,7 +130,10 @@ type MealTime struct {
 	Name      string    `gorm:"size:32;not null;uniqueIndex:uk_menu_meal_name;comment:餐次"`
 	Code      *string   `gorm:"size:32;uniqueIndex:uk_meal_code;comment:餐次编码"`
 	Sort      int       `gorm:"not null;default:0;index;comment:排序码"`
+	StartTime *string   `gorm:"size:5;comment:默认供餐开始时刻 HH:MM"`
+	EndTime   *string   `gorm:"size:5;comment:默认供餐结束时刻 HH:MM（小于开始时刻表示跨零点）"`
 	IsDeleted int       `gorm:"not null;default:0;index;comment:是否已删除"`
+	Version   int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 }
@@ -76,18 +142,171 @@ func (m *MealTime) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == "" {
 		m.ID = uuid.NewString()
 	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
 	sort, err := utils.NextColoumSort(tx, m.TableName())
 	if err != nil {
 		return err
 	}
 	m.Sort = sort
-	code := codeFromSort(sort)
+	code, err := dictCode(tx, coderule.EntityMealTime, m.TableName(), sort)
+	if err != nil {
+		return err
+	}
 	m.Code = &code
 	return nil
 }
 
 func (MealTime) TableName() string { return "menu_meal" }
 
+// WasteReason 浪费原因（如 剩饭剩菜/变质/加工损耗/过期）
+type WasteReason struct {
+	ID        string    `gorm:"primaryKey;type:char(36)"`
+	Name      string    `gorm:"size:32;not null;uniqueIndex:uk_waste_reason_name;comment:浪费原因"`
+	Code      *string   `gorm:"size:32;uniqueIndex:uk_waste_reason_code;comment:原因编码"`
+	Sort      int       `gorm:"not null;default:0;index;comment:排序码"`
+	IsDeleted int       `gorm:"not null;default:0;index;comment:是否已删除"`
+	Version   int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (w *WasteReason) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == "" {
+		w.ID = uuid.NewString()
+	}
+	if w.Version == 0 {
+		w.Version = 1
+	}
+	sort, err := utils.NextColoumSort(tx, w.TableName())
+	if err != nil {
+		return err
+	}
+	w.Sort = sort
+	code, err := dictCode(tx, coderule.EntityWasteReason, w.TableName(), sort)
+	if err != nil {
+		return err
+	}
+	w.Code = &code
+	return nil
+}
+
+func (WasteReason) TableName() string { return "base_waste_reason" }
+
+// MealOrgWindow 机构级供餐时段，覆盖 MealTime 的默认时段
+type MealOrgWindow struct {
+	ID        string    `gorm:"primaryKey;type:char(36)"`
+	OrgID     string    `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_mow_org_meal,priority:1;comment:机构ID（base_org.id）"`
+	MealID    string    `gorm:"column:meal_id;type:char(36);not null;uniqueIndex:uk_mow_org_meal,priority:2;comment:餐次ID（menu_meal.id）"`
+	StartTime string    `gorm:"size:5;not null;comment:供餐开始时刻 HH:MM"`
+	EndTime   string    `gorm:"size:5;not null;comment:供餐结束时刻 HH:MM（小于开始时刻表示跨零点）"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+func (m *MealOrgWindow) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (MealOrgWindow) TableName() string { return "menu_meal_org_window" }
+
+// MealWindow 某机构实际生效的餐次时段（机构覆盖优先，否则取餐次默认值）
+type MealWindow struct {
+	MealID      string `json:"meal_id"`
+	MealName    string `json:"meal_name"`
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	OrgOverride bool   `json:"org_override"`
+}
+
+// ParseClock 解析 HH:MM，返回当日分钟数
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("时刻格式应为 HH:MM: %s", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Contains 判断当日分钟数 minute 是否落在 [start, end) 内；end<start 视为跨零点
+func (w MealWindow) Contains(minute int) bool {
+	start, err1 := ParseClock(w.StartTime)
+	end, err2 := ParseClock(w.EndTime)
+	if err1 != nil || err2 != nil || start == end {
+		return false
+	}
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// Attribute 按机构时区 loc 下的当地时刻返回 at 所属的餐次时段及其业务日：跨零点时段在零点后的部分归属前一天。
+// 业务日见 utils.DateIn
+func Attribute(windows []MealWindow, at time.Time, loc *time.Location) (*MealWindow, time.Time, bool) {
+	at = at.In(loc)
+	minute := at.Hour()*60 + at.Minute()
+	day := utils.DateIn(at, loc)
+	for i := range windows {
+		w := windows[i]
+		if !w.Contains(minute) {
+			continue
+		}
+		start, _ := ParseClock(w.StartTime)
+		end, _ := ParseClock(w.EndTime)
+		if end < start && minute < end {
+			day = day.AddDate(0, 0, -1)
+		}
+		return &w, day, true
+	}
+	return nil, day, false
+}
+
+// intervals 将时段拆为当日内的半开区间 [start, end)；跨零点时段拆为两段，无效时段返回空
+func (w MealWindow) intervals() [][2]int {
+	start, err1 := ParseClock(w.StartTime)
+	end, err2 := ParseClock(w.EndTime)
+	if err1 != nil || err2 != nil || start == end {
+		return nil
+	}
+	if start < end {
+		return [][2]int{{start, end}}
+	}
+	out := [][2]int{{start, 24 * 60}}
+	if end > 0 {
+		out = append(out, [2]int{0, end})
+	}
+	return out
+}
+
+// Overlaps 判断两个时段是否有重叠（支持跨零点）
+func Overlaps(a, b MealWindow) bool {
+	for _, x := range a.intervals() {
+		for _, y := range b.intervals() {
+			if x[0] < y[1] && y[0] < x[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func codeFromSort(sort int) string {
 	return fmt.Sprintf("%02d", sort)
 }
+
+// dictCode 按全局编码规则生成字典编码；未配置规则时沿用两位 sort 编码
+func dictCode(tx *gorm.DB, entity, table string, sort int) (string, error) {
+	code, ok, err := coderule.Next(tx, coderule.Input{Entity: entity, Table: table, MaxLen: 32})
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return codeFromSort(sort), nil
+	}
+	return code, nil
+}
@@ -0,0 +1,82 @@
+package forecast
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	price "hdzk.cn/foodapp/internal/domain/price"
+)
+
+// 基线算法
+const (
+	MethodMovingAverage = "sma" // 简单移动平均
+	MethodExpSmoothing  = "ses" // 一次指数平滑
+)
+
+// 库存来源
+const (
+	StockUnknown = ""        // 未知，不扣减
+	StockRequest = "request" // 调用方传入
+	StockLedger  = "ledger"  // 库存台账
+)
+
+// Baseline 基于历史称重消耗的日均基线
+type Baseline struct {
+	Method      string            `json:"method"`
+	Window      int               `json:"window"` // 历史天数
+	Alpha       *decimal.Decimal  `json:"alpha,omitempty"`
+	History     []decimal.Decimal `json:"history"` // 逐日消耗（按商品单位，无记录为 0）
+	HistoryFrom time.Time         `json:"history_from"`
+	Daily       decimal.Decimal   `json:"daily"`
+	Total       decimal.Decimal   `json:"total"` // Daily × 预测天数
+}
+
+// Suggestion 单个商品的采购建议，Explanation 按推导顺序逐条说明
+type Suggestion struct {
+	GoodsID     string           `json:"goods_id"`
+	UnitID      string           `json:"unit_id"`
+	Planned     decimal.Decimal  `json:"planned"` // 餐次计划展开需求
+	Baseline    *Baseline        `json:"baseline"`
+	Demand      decimal.Decimal  `json:"demand"` // 计划与基线取大后，含安全系数
+	Stock       *decimal.Decimal `json:"stock"`
+	StockSource string           `json:"stock_source"`
+	NetQty      decimal.Decimal  `json:"net_qty"` // 建议采购量
+	Quote       *price.Quote     `json:"quote"`   // 最便宜的有效报价
+	SettlePrice *decimal.Decimal `json:"settle_price"`
+	Amount      *decimal.Decimal `json:"amount"`
+	Explanation []string         `json:"explanation"`
+}
+
+// Result 预测结果
+type Result struct {
+	OrgID       string       `json:"org_id"`
+	From        time.Time    `json:"from"`
+	Days        int          `json:"days"`
+	Suggestions []Suggestion `json:"suggestions"`
+	Warnings    []string     `json:"warnings"`
+}
+
+// MovingAverage 简单移动平均；空序列为 0
+func MovingAverage(series []decimal.Decimal) decimal.Decimal {
+	if len(series) == 0 {
+		return decimal.Zero
+	}
+	sum := decimal.Zero
+	for _, v := range series {
+		sum = sum.Add(v)
+	}
+	return sum.DivRound(decimal.NewFromInt(int64(len(series))), 4)
+}
+
+// ExpSmoothing 一次指数平滑：s0 = x0，st = α·xt + (1-α)·s(t-1)，返回最后一期平滑值
+func ExpSmoothing(series []decimal.Decimal, alpha decimal.Decimal) decimal.Decimal {
+	if len(series) == 0 {
+		return decimal.Zero
+	}
+	one := decimal.NewFromInt(1)
+	s := series[0]
+	for _, x := range series[1:] {
+		s = alpha.Mul(x).Add(one.Sub(alpha).Mul(s))
+	}
+	return s.Round(4)
+}
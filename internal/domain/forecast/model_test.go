@@ -0,0 +1,60 @@
+package forecast
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func series(vals ...string) []decimal.Decimal {
+	out := make([]decimal.Decimal, len(vals))
+	for i, v := range vals {
+		out[i] = decimal.RequireFromString(v)
+	}
+	return out
+}
+
+func TestMovingAverage(t *testing.T) {
+	cases := []struct {
+		series []decimal.Decimal
+		want   string
+	}{
+		{series(), "0"},
+		{series("5"), "5"},
+		{series("2", "4", "6"), "4"},
+		{series("0", "0", "0", "3"), "0.75"},
+		{series("1", "1", "2"), "1.3333"},
+		{series("10.5", "0", "7.25"), "5.9167"},
+	}
+	for _, c := range cases {
+		if got := MovingAverage(c.series); !got.Equal(decimal.RequireFromString(c.want)) {
+			t.Errorf("MovingAverage(%v) = %s，期望 %s", c.series, got, c.want)
+		}
+	}
+}
+
+func TestExpSmoothing(t *testing.T) {
+	cases := []struct {
+		series []decimal.Decimal
+		alpha  string
+		want   string
+	}{
+		{series(), "0.3", "0"},
+		{series("8"), "0.3", "8"},
+		// s1 = 0.3×20 + 0.7×10 = 13；s2 = 0.3×10 + 0.7×13 = 12.1
+		{series("10", "20", "10"), "0.3", "12.1"},
+		// α=1 时等于最后一期
+		{series("10", "20", "7"), "1", "7"},
+		// s1 = 0.5×0 + 0.5×4 = 2；s2 = 0.5×0 + 0.5×2 = 1；s3 = 0.5×3 + 0.5×1 = 2
+		{series("4", "0", "0", "3"), "0.5", "2"},
+		// 结果保留 4 位：s1 = 0.1×1 + 0.9×0 = 0.1；s2 = 0.1×1 + 0.9×0.1 = 0.19；s3 = 0.271
+		{series("0", "1", "1", "1"), "0.1", "0.271"},
+		{series("1", "0", "0"), "0.3333", "0.4445"},
+	}
+	for _, c := range cases {
+		alpha := decimal.RequireFromString(c.alpha)
+		if got := ExpSmoothing(c.series, alpha); !got.Equal(decimal.RequireFromString(c.want)) {
+			t.Errorf("ExpSmoothing(%v, %s) = %s，期望 %s", c.series, c.alpha, got, c.want)
+		}
+	}
+}
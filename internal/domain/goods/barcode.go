@@ -0,0 +1,101 @@
+package goods
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 条码类型（GS1 商品条码）
+const (
+	SymbologyEAN13  = "ean13"
+	SymbologyEAN8   = "ean8"
+	SymbologyUPCA   = "upca"
+	SymbologyGTIN14 = "gtin14"
+)
+
+// ErrBarcodeDuplicate 同一机构内条码已被其它商品占用
+var ErrBarcodeDuplicate = errors.New("该条码已被本机构其它商品使用")
+
+// Barcode 商品条码：一个商品可有多个条码，每种包装规格（单品/中包/整箱）一个；
+// 机构内有效条码唯一。Barcode 保存规范化后的 GTIN（UPC-A 补前导 0 存为 13 位），扫码时按同样规则规范化后查找。
+// 删除为软删，ActiveBarcode 仅对未删除行取值，唯一键 uk_goods_barcode_org_active 不约束已删除行
+type Barcode struct {
+	ID        string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID     string          `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_goods_barcode_org_active,priority:1;comment:所属机构ID" json:"org_id"`
+	Barcode   string          `gorm:"size:14;not null;comment:条码（规范化 GTIN：8/13/14 位）" json:"barcode"`
+	Symbology string          `gorm:"size:16;not null;comment:录入时的条码类型：ean13/ean8/upca/gtin14" json:"symbology"`
+	GoodsID   string          `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）" json:"goods_id"`
+	UnitID    string          `gorm:"column:unit_id;type:char(36);not null;comment:包装单位ID（base_unit.id），如 瓶/箱" json:"unit_id"`
+	PackQty   decimal.Decimal `gorm:"column:pack_qty;type:decimal(20,8);not null;default:1;comment:每包装折合商品单位数量" json:"pack_qty"`
+	IsPrimary int             `gorm:"column:is_primary;not null;default:0;comment:是否为商品主条码：0=否 1=是" json:"is_primary"`
+	Remark    *string         `gorm:"size:255;comment:备注" json:"remark"`
+	IsDeleted int             `gorm:"column:is_deleted;type:tinyint(1);not null;default:0;comment:软删：0=有效 1=删除" json:"is_deleted"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// 仅对未删除行取值的生成列：NULL 不参与唯一约束
+	ActiveBarcode *string `gorm:"column:active_barcode;->;type:varchar(14) AS (CASE WHEN is_deleted = 0 THEN barcode ELSE NULL END) STORED;uniqueIndex:uk_goods_barcode_org_active,priority:2" json:"-"`
+}
+
+func (b *Barcode) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Barcode) TableName() string { return "base_goods_barcode" }
+
+// NormalizeBarcode 校验 GS1 条码（EAN-8 / UPC-A / EAN-13 / GTIN-14）的校验位并返回规范化值与类型。
+// UPC-A 规范为 13 位（前导 0），前导 0 的 GTIN-14 规范为 13 位，与扫码枪输出 EAN-13 的形式一致
+func NormalizeBarcode(raw string) (string, string, error) {
+	code := strings.TrimSpace(raw)
+	if code == "" {
+		return "", "", errors.New("条码不能为空")
+	}
+	if strings.Trim(code, "0123456789") != "" {
+		return "", "", fmt.Errorf("条码只能包含数字: %s", code)
+	}
+	var symbology string
+	switch len(code) {
+	case 8:
+		symbology = SymbologyEAN8
+	case 12:
+		symbology = SymbologyUPCA
+	case 13:
+		symbology = SymbologyEAN13
+	case 14:
+		symbology = SymbologyGTIN14
+	default:
+		return "", "", fmt.Errorf("条码长度须为 8/12/13/14 位: %s", code)
+	}
+	if !validCheckDigit(code) {
+		return "", "", fmt.Errorf("条码校验位错误: %s", code)
+	}
+	switch {
+	case symbology == SymbologyUPCA:
+		code = "0" + code
+	case symbology == SymbologyGTIN14 && code[0] == '0':
+		code = code[1:]
+	}
+	return code, symbology, nil
+}
+
+// validCheckDigit GS1 模 10 校验：自右向左（不含校验位）奇数位权 3、偶数位权 1
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		d := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
@@ -0,0 +1,68 @@
+package goods
+
+import "testing"
+
+func TestNormalizeBarcode(t *testing.T) {
+	cases := []struct {
+		raw       string
+		code      string
+		symbology string
+		wantErr   bool
+	}{
+		{raw: "96385074", code: "96385074", symbology: SymbologyEAN8},
+		{raw: "96385075", wantErr: true},
+		{raw: "036000291452", code: "0036000291452", symbology: SymbologyUPCA},
+		{raw: "036000291453", wantErr: true},
+		{raw: "4006381333931", code: "4006381333931", symbology: SymbologyEAN13},
+		{raw: " 4006381333931 ", code: "4006381333931", symbology: SymbologyEAN13},
+		{raw: "4006381333932", wantErr: true},
+		{raw: "10012345678902", code: "10012345678902", symbology: SymbologyGTIN14},
+		{raw: "00012345678905", code: "0012345678905", symbology: SymbologyGTIN14},
+		{raw: "10012345678903", wantErr: true},
+		{raw: "", wantErr: true},
+		{raw: "40063813339a1", wantErr: true},
+		{raw: "1234567", wantErr: true},
+		{raw: "123456789012345", wantErr: true},
+	}
+	for _, c := range cases {
+		code, symbology, err := NormalizeBarcode(c.raw)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("NormalizeBarcode(%q) 应返回错误，得到 %q/%q", c.raw, code, symbology)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("NormalizeBarcode(%q) 返回错误: %v", c.raw, err)
+			continue
+		}
+		if code != c.code || symbology != c.symbology {
+			t.Errorf("NormalizeBarcode(%q) = %q/%q，期望 %q/%q", c.raw, code, symbology, c.code, c.symbology)
+		}
+	}
+}
+
+func TestValidCheckDigit(t *testing.T) {
+	cases := []struct {
+		code string
+		want bool
+	}{
+		{"96385074", true},
+		{"73513537", true},
+		{"73513538", false},
+		{"036000291452", true},
+		{"012345678905", true},
+		{"012345678906", false},
+		{"4006381333931", true},
+		{"5901234123457", true},
+		{"5901234123458", false},
+		{"10012345678902", true},
+		{"00012345678905", true},
+		{"10012345678900", false},
+	}
+	for _, c := range cases {
+		if got := validCheckDigit(c.code); got != c.want {
+			t.Errorf("validCheckDigit(%q) = %v，期望 %v", c.code, got, c.want)
+		}
+	}
+}
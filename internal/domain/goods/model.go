@@ -8,6 +8,8 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	category "hdzk.cn/foodapp/internal/domain/category"
+	"hdzk.cn/foodapp/internal/domain/coderule"
 	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
@@ -24,6 +26,7 @@ type Goods struct {
 	CategoryID  string    `gorm:"column:category_id;type:char(36);not null;comment:品类ID（base_category.id）"`
 	OrgID       string    `gorm:"column:org_id;type:char(36);not null;comment:组织ID"`
 	IsDeleted   int       `gorm:"column:is_deleted;not null;default:0;comment:软删标记：0=有效,1=删除"`
+	Version     int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
 }
@@ -32,6 +35,9 @@ func (g *Goods) BeforeCreate(tx *gorm.DB) error {
 	if g.ID == "" {
 		g.ID = uuid.NewString()
 	}
+	if g.Version == 0 {
+		g.Version = 1
+	}
 	if g.OrgID == "" {
 		return errors.New("OrgID(org_id) 不能为空")
 	}
@@ -58,13 +64,22 @@ func (g *Goods) BeforeCreate(tx *gorm.DB) error {
 		g.Sort = base + suf
 	}
 
-	// 3) code = org.code + 三位后缀（各自独立找缺口）
+	// 3) code 按编码规则生成（未配置时为 org.code + 三位序号）
 	if g.Code == nil || (g.Code != nil && *g.Code == "") {
-		suf, err := utils.NextCodeSuffixByPrefix(tx, g.TableName(), g.OrgID, orgCode, true)
+		catCode, err := categoryCodeOf(tx, g.CategoryID)
+		if err != nil {
+			return err
+		}
+		auto, _, err := coderule.Next(tx, coderule.Input{
+			Entity: coderule.EntityGoods,
+			OrgID:  g.OrgID,
+			Table:  g.TableName(),
+			MaxLen: 64,
+			Values: coderule.Values{Org: orgCode, Cat: catCode},
+		})
 		if err != nil {
 			return err
 		}
-		auto := fmt.Sprintf("%s%03d", orgCode, suf)
 		g.Code = &auto
 	}
 
@@ -79,6 +94,23 @@ func (g *Goods) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (Goods) TableName() string { return "base_goods" }
+
+// 商品所属品类 code（{cat} 占位符用；品类无编码时为空）
+func categoryCodeOf(tx *gorm.DB, categoryID string) (string, error) {
+	var code *string
+	err := tx.Session(&gorm.Session{NewDB: true}).
+		Table(category.Category{}.TableName()).
+		Select("code").
+		Where("id = ?", categoryID).
+		Scan(&code).Error
+	if err != nil {
+		return "", fmt.Errorf("查询品类 code 失败: %w", err)
+	}
+	if code == nil {
+		return "", nil
+	}
+	return *code, nil
+}
 func codeFromSort(sort int) string {
 	return fmt.Sprintf("%02d", sort)
 }
@@ -0,0 +1,48 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 记录状态
+const (
+	StatusPending = 0 // 首个请求处理中
+	StatusDone    = 1 // 已保存首次响应
+)
+
+var (
+	ErrInProgress = errors.New("相同幂等键的请求正在处理中")
+	ErrMismatch   = errors.New("幂等键已用于不同的请求内容")
+)
+
+// Record 幂等键记录：同一操作者 + 幂等键在有效期内只执行一次创建，重放返回首次响应
+type Record struct {
+	ID          string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	ActorID     string    `gorm:"column:actor_id;type:char(36);not null;uniqueIndex:uk_idem_actor_key,priority:1;comment:操作者账户ID" json:"actor_id"`
+	IdemKey     string    `gorm:"column:idem_key;size:128;not null;uniqueIndex:uk_idem_actor_key,priority:2;comment:客户端幂等键（Idempotency-Key）" json:"idem_key"`
+	Method      string    `gorm:"size:8;not null;comment:请求方法" json:"method"`
+	Path        string    `gorm:"size:255;not null;comment:请求路径" json:"path"`
+	RequestHash string    `gorm:"column:request_hash;type:char(64);not null;comment:请求摘要（方法+路径+请求体 SHA-256）" json:"request_hash"`
+	Status      int       `gorm:"type:tinyint;not null;default:0;comment:状态：0=处理中,1=已完成" json:"status"`
+	StatusCode  int       `gorm:"column:status_code;not null;default:0;comment:首次响应状态码" json:"status_code"`
+	ContentType string    `gorm:"column:content_type;size:128;not null;default:'';comment:首次响应 Content-Type" json:"content_type"`
+	Body        []byte    `gorm:"type:mediumblob;comment:首次响应体" json:"-"`
+	ExpiresAt   time.Time `gorm:"column:expires_at;not null;index;comment:过期时间" json:"expires_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (r *Record) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.ActorID == "" || r.IdemKey == "" {
+		return errors.New("ActorID/IdemKey 不能为空")
+	}
+	return nil
+}
+
+func (Record) TableName() string { return "sys_idempotency_key" }
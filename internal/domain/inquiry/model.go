@@ -8,6 +8,34 @@ import (
 	"gorm.io/gorm"
 )
 
+// 询价单状态：草稿 → 已提交 → 已审核 → 已归档；已审核/已归档的询价单锁定，需重新打开（回到草稿）才能修改
+const (
+	StatusDraft     = 0
+	StatusSubmitted = 1
+	StatusApproved  = 2
+	StatusArchived  = 3
+)
+
+// 状态流转动作
+const (
+	ActionSubmit  = "submit"  // 草稿 → 已提交
+	ActionApprove = "approve" // 已提交 → 已审核
+	ActionReject  = "reject"  // 已提交 → 草稿（退回）
+	ActionArchive = "archive" // 已审核 → 已归档
+	ActionReopen  = "reopen"  // 已审核/已归档 → 草稿
+)
+
+// ErrLocked 询价单已审核或归档，禁止修改抬头、明细与报价
+var ErrLocked = errors.New("询价单已审核或归档，需重新打开后才能修改")
+
+// ErrStatus 当前状态不允许该操作
+var ErrStatus = errors.New("询价单当前状态不允许该操作")
+
+// Locked 是否处于锁定状态
+func Locked(status int) bool {
+	return status == StatusApproved || status == StatusArchived
+}
+
 // PriceInquiry represents a price inquiry header record.
 // It maps to table `base_price_inquiry`.
 type PriceInquiry struct {
@@ -15,22 +43,42 @@ type PriceInquiry struct {
 	InquiryTitle string    `gorm:"column:inquiry_title;size:64;not null;comment:询价单标题"`
 	InquiryDate  time.Time `gorm:"column:inquiry_date;type:date;not null;comment:询价单日期（业务日）"`
 
-	Market1 *string `gorm:"column:market_1;size:128;comment:市场1"`
-	Market2 *string `gorm:"column:market_2;size:128;comment:市场2"`
-	Market3 *string `gorm:"column:market_3;size:128;comment:市场3"`
+	// 旧版固定市场名称，仅作迁移来源（见 migrate_legacy_markets）；新数据使用 Markets
+	Market1 *string `gorm:"column:market_1;size:128;comment:市场1（旧）"`
+	Market2 *string `gorm:"column:market_2;size:128;comment:市场2（旧）"`
+	Market3 *string `gorm:"column:market_3;size:128;comment:市场3（旧）"`
 
 	OrgID string `gorm:"column:org_id;type:char(36);not null;comment:中队ID"`
 
+	Status int `gorm:"column:status;not null;default:0;comment:状态：0=草稿 1=已提交 2=已审核 3=已归档"`
+
 	IsDeleted int `gorm:"column:is_deleted;not null;default:0;comment:软删：0=有效 1=删除"`
+	Version   int `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	Markets []InquiryMarket `gorm:"-"`
+}
+
+// LegacyMarkets 旧版市场名称（按 1..3 顺序，空值跳过）
+func (p PriceInquiry) LegacyMarkets() []string {
+	var out []string
+	for _, v := range []*string{p.Market1, p.Market2, p.Market3} {
+		if v != nil && *v != "" {
+			out = append(out, *v)
+		}
+	}
+	return out
 }
 
 func (p *PriceInquiry) BeforeCreate(tx *gorm.DB) error {
 	if p.ID == "" {
 		p.ID = uuid.NewString()
 	}
+	if p.Version == 0 {
+		p.Version = 1
+	}
 	if p.OrgID == "" {
 		return errors.New("OrgID(org_id) 不能为空")
 	}
@@ -38,3 +86,122 @@ func (p *PriceInquiry) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (PriceInquiry) TableName() string { return "base_price_inquiry" }
+
+// History 询价单状态流转记录（只增不改）
+type History struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	InquiryID  string    `gorm:"column:inquiry_id;type:char(36);not null;index;comment:询价单ID（base_price_inquiry.id）" json:"inquiry_id"`
+	Action     string    `gorm:"size:16;not null;comment:动作：submit/approve/reject/archive/reopen" json:"action"`
+	FromStatus int       `gorm:"column:from_status;not null;comment:原状态" json:"from_status"`
+	ToStatus   int       `gorm:"column:to_status;not null;comment:新状态" json:"to_status"`
+	Comment    *string   `gorm:"size:255;comment:意见/重新打开原因" json:"comment"`
+	OperatorID string    `gorm:"column:operator_id;type:char(36);not null;comment:操作人ID（base_user.id）" json:"operator_id"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (h *History) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == "" {
+		h.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (History) TableName() string { return "base_price_inquiry_history" }
+
+// ErrMarketInUse 市场已有录入的价格，不能从询价单移除
+var ErrMarketInUse = errors.New("市场在该询价单中已录入价格，不能移除")
+
+// ErrDuplicate 同机构同日期已存在同名（未删除）询价单，对应唯一键 uk_org_active_title_date
+var ErrDuplicate = errors.New("同一机构同一日期已存在同名询价单")
+
+// ErrTemplateDuplicate 同机构已存在同名模板
+var ErrTemplateDuplicate = errors.New("同一机构已存在同名询价模板")
+
+// Template 机构询价模板：市场与商品清单，用于按周期生成询价单
+type Template struct {
+	ID           string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID        string    `gorm:"column:org_id;type:char(36);not null;index:idx_pit_org,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	Name         string    `gorm:"size:64;not null;comment:模板名称" json:"name"`
+	InquiryTitle string    `gorm:"column:inquiry_title;size:64;not null;comment:生成询价单的默认标题" json:"inquiry_title"`
+	Remark       *string   `gorm:"size:255;comment:备注" json:"remark"`
+	IsDeleted    int       `gorm:"column:is_deleted;not null;default:0;index:idx_pit_org,priority:2;comment:软删：0=有效 1=删除" json:"is_deleted"`
+	Version      int       `gorm:"not null;default:1;comment:版本号（乐观锁）" json:"version"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Markets []TemplateMarket `gorm:"-" json:"markets"`
+	Lines   []TemplateLine   `gorm:"-" json:"lines"`
+}
+
+func (t *Template) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == "" {
+		t.ID = uuid.NewString()
+	}
+	if t.Version == 0 {
+		t.Version = 1
+	}
+	if t.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Template) TableName() string { return "base_price_inquiry_template" }
+
+// TemplateLine 模板商品清单
+type TemplateLine struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	TemplateID string    `gorm:"column:template_id;type:char(36);not null;uniqueIndex:uk_pitl_template_goods,priority:1;comment:模板ID" json:"template_id"`
+	GoodsID    string    `gorm:"column:goods_id;type:char(36);not null;uniqueIndex:uk_pitl_template_goods,priority:2;index;comment:商品ID（base_goods.id）" json:"goods_id"`
+	Sort       int       `gorm:"not null;default:0;comment:排序码" json:"sort"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (l *TemplateLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (TemplateLine) TableName() string { return "base_price_inquiry_template_line" }
+
+// InquiryMarket 询价单参与的市场（任意多个，Sort 为显示顺序，1..3 对应旧版 market_1..market_3）
+type InquiryMarket struct {
+	ID        string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	InquiryID string    `gorm:"column:inquiry_id;type:char(36);not null;uniqueIndex:uk_pim_inquiry_market,priority:1;comment:询价单ID（base_price_inquiry.id）" json:"inquiry_id"`
+	MarketID  string    `gorm:"column:market_id;type:char(36);not null;uniqueIndex:uk_pim_inquiry_market,priority:2;index;comment:市场ID（base_market.id）" json:"market_id"`
+	Sort      int       `gorm:"not null;default:0;comment:排序码" json:"sort"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Name string `gorm:"->;-:migration" json:"name"` // 查询时关联 base_market.name
+}
+
+func (m *InquiryMarket) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (InquiryMarket) TableName() string { return "base_price_inquiry_market" }
+
+// TemplateMarket 询价模板的市场清单
+type TemplateMarket struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	TemplateID string    `gorm:"column:template_id;type:char(36);not null;uniqueIndex:uk_ptm_template_market,priority:1;comment:模板ID" json:"template_id"`
+	MarketID   string    `gorm:"column:market_id;type:char(36);not null;uniqueIndex:uk_ptm_template_market,priority:2;comment:市场ID（base_market.id）" json:"market_id"`
+	Sort       int       `gorm:"not null;default:0;comment:排序码" json:"sort"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Name string `gorm:"->;-:migration" json:"name"`
+}
+
+func (m *TemplateMarket) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (TemplateMarket) TableName() string { return "base_price_inquiry_template_market" }
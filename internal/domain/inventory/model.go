@@ -0,0 +1,152 @@
+package inventory
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 流水类型；数量带符号：入库为正、出库为负，调整可正可负
+const (
+	TypeReceipt     = "receipt"      // 采购入库
+	TypeIssue       = "issue"        // 领用出库（发往厨房）
+	TypeAdjust      = "adjust"       // 调整（含盘点差异）
+	TypeWaste       = "waste"        // 报损
+	TypeTransferOut = "transfer_out" // 调拨出库
+	TypeTransferIn  = "transfer_in"  // 调拨入库
+)
+
+// QtyScale 库存数量保留的小数位（与 decimal(20,3) 一致）
+const QtyScale = 3
+
+// Outbound 该类型是否为出库（数量记为负）
+func Outbound(t string) bool {
+	return t == TypeIssue || t == TypeWaste || t == TypeTransferOut
+}
+
+func ValidType(t string) bool {
+	switch t {
+	case TypeReceipt, TypeIssue, TypeAdjust, TypeWaste, TypeTransferOut, TypeTransferIn:
+		return true
+	}
+	return false
+}
+
+// Movement 库存流水（只追加，不修改不删除；更正通过调整流水完成）
+type Movement struct {
+	ID           string          `gorm:"primaryKey;type:char(36)"`
+	OrgID        string          `gorm:"column:org_id;type:char(36);not null;index:idx_inv_mv_org_goods,priority:1;comment:机构ID（base_org.id）"`
+	GoodsID      string          `gorm:"column:goods_id;type:char(36);not null;index:idx_inv_mv_org_goods,priority:2;comment:商品ID（base_goods.id）"`
+	Type         string          `gorm:"size:16;not null;index;comment:类型：receipt/issue/adjust/waste/transfer_out/transfer_in"`
+	Quantity     decimal.Decimal `gorm:"type:decimal(20,3);not null;comment:变动数量（基准单位，入正出负）"`
+	UnitID       string          `gorm:"column:unit_id;type:char(36);not null;comment:基准单位ID（base_unit.id）"`
+	InputQty     decimal.Decimal `gorm:"column:input_qty;type:decimal(20,3);not null;comment:录入数量"`
+	InputUnitID  string          `gorm:"column:input_unit_id;type:char(36);not null;comment:录入单位ID（base_unit.id）"`
+	BalanceAfter decimal.Decimal `gorm:"column:balance_after;type:decimal(20,3);not null;comment:变动后结存"`
+	OccurredAt   time.Time       `gorm:"column:occurred_at;not null;index:idx_inv_mv_org_goods,priority:3;comment:业务发生时间"`
+	WeighingID   *string         `gorm:"column:weighing_id;type:char(36);uniqueIndex:uk_inv_mv_weighing;comment:来源称重记录ID（base_weighing_record.id）"`
+	CountID      *string         `gorm:"column:count_id;type:char(36);index;comment:来源盘点单ID（inv_count.id）"`
+	TransferID   *string         `gorm:"column:transfer_id;type:char(36);index;comment:调拨批次ID（出入两条流水共用）"`
+	PeerOrgID    *string         `gorm:"column:peer_org_id;type:char(36);comment:调拨对方机构ID（base_org.id）"`
+	Remark       *string         `gorm:"size:255;comment:备注"`
+	OperatorID   *string         `gorm:"column:operator_id;type:char(36);comment:操作人ID（base_user.id）"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime"`
+}
+
+func (m *Movement) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Movement) TableName() string { return "inv_movement" }
+
+// Balance 当前结存（由流水投影，与流水同事务维护，可按流水重建）
+type Balance struct {
+	ID          string          `gorm:"primaryKey;type:char(36)"`
+	OrgID       string          `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_inv_balance,priority:1;comment:机构ID（base_org.id）"`
+	GoodsID     string          `gorm:"column:goods_id;type:char(36);not null;uniqueIndex:uk_inv_balance,priority:2;comment:商品ID（base_goods.id）"`
+	UnitID      string          `gorm:"column:unit_id;type:char(36);not null;comment:基准单位ID（base_unit.id）"`
+	Quantity    decimal.Decimal `gorm:"type:decimal(20,3);not null;default:0;comment:结存数量（基准单位）"`
+	LastMovedAt *time.Time      `gorm:"column:last_moved_at;comment:最近一次变动的业务时间"`
+	UpdatedAt   time.Time       `gorm:"autoUpdateTime"`
+}
+
+func (b *Balance) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == "" {
+		b.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Balance) TableName() string { return "inv_balance" }
+
+// 盘点单状态
+const (
+	CountDraft  = 0 // 录入中，可修改/删除
+	CountPosted = 1 // 已过账，差异已生成调整流水
+)
+
+// Count 盘点单
+type Count struct {
+	ID         string     `gorm:"primaryKey;type:char(36)"`
+	OrgID      string     `gorm:"column:org_id;type:char(36);not null;index:idx_inv_count_org_date,priority:1;comment:机构ID（base_org.id）"`
+	CountDate  time.Time  `gorm:"column:count_date;type:date;not null;index:idx_inv_count_org_date,priority:2;comment:盘点日期"`
+	Status     int        `gorm:"not null;default:0;comment:状态：0=录入中 1=已过账"`
+	Remark     *string    `gorm:"size:255;comment:备注"`
+	OperatorID *string    `gorm:"column:operator_id;type:char(36);comment:创建人ID（base_user.id）"`
+	PostedAt   *time.Time `gorm:"column:posted_at;comment:过账时间"`
+	IsDeleted  int        `gorm:"column:is_deleted;not null;default:0;index;comment:软删：0=有效 1=删除"`
+	Version    int        `gorm:"not null;default:1;comment:版本号（乐观锁）"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime"`
+
+	Lines []CountLine `gorm:"-" json:"lines"`
+}
+
+func (c *Count) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == "" {
+		c.ID = uuid.NewString()
+	}
+	if c.Version == 0 {
+		c.Version = 1
+	}
+	if c.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Count) TableName() string { return "inv_count" }
+
+// CountLine 盘点明细；账面数与差异在过账时按当时结存回填
+type CountLine struct {
+	ID          string           `gorm:"primaryKey;type:char(36)"`
+	CountID     string           `gorm:"column:count_id;type:char(36);not null;uniqueIndex:uk_inv_count_goods,priority:1;comment:盘点单ID（inv_count.id）"`
+	GoodsID     string           `gorm:"column:goods_id;type:char(36);not null;uniqueIndex:uk_inv_count_goods,priority:2;comment:商品ID（base_goods.id）"`
+	UnitID      string           `gorm:"column:unit_id;type:char(36);not null;comment:基准单位ID（base_unit.id）"`
+	InputQty    decimal.Decimal  `gorm:"column:input_qty;type:decimal(20,3);not null;comment:录入实盘数量"`
+	InputUnitID string           `gorm:"column:input_unit_id;type:char(36);not null;comment:录入单位ID（base_unit.id）"`
+	CountedQty  decimal.Decimal  `gorm:"column:counted_qty;type:decimal(20,3);not null;comment:实盘数量（基准单位）"`
+	BookQty     *decimal.Decimal `gorm:"column:book_qty;type:decimal(20,3);comment:账面数量（过账时回填）"`
+	DiffQty     *decimal.Decimal `gorm:"column:diff_qty;type:decimal(20,3);comment:差异 = 实盘 - 账面（过账时回填）"`
+	MovementID  *string          `gorm:"column:movement_id;type:char(36);comment:差异调整流水ID（inv_movement.id）"`
+	Sort        int              `gorm:"not null;default:0;comment:排序码"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime"`
+}
+
+func (l *CountLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (CountLine) TableName() string { return "inv_count_line" }
@@ -0,0 +1,61 @@
+package market
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 市场类型
+const (
+	TypeWholesale   = "wholesale"   // 批发市场
+	TypeRetail      = "retail"      // 农贸/零售市场
+	TypeSupermarket = "supermarket" // 商超
+	TypeOnline      = "online"      // 线上平台
+	TypeOther       = "other"
+)
+
+func ValidType(t string) bool {
+	switch t {
+	case TypeWholesale, TypeRetail, TypeSupermarket, TypeOnline, TypeOther:
+		return true
+	}
+	return false
+}
+
+// ErrDuplicate 同机构已存在同名市场
+var ErrDuplicate = errors.New("同一机构已存在同名市场")
+
+// Market 机构询价市场（替代询价单上固定的 market_1..market_3 字符串）
+type Market struct {
+	ID        string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID     string    `gorm:"column:org_id;type:char(36);not null;index:idx_market_org,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	Name      string    `gorm:"size:128;not null;comment:市场名称（同机构有效市场内唯一）" json:"name"`
+	Address   *string   `gorm:"size:255;comment:地址" json:"address"`
+	Type      string    `gorm:"size:16;not null;default:other;comment:类型：wholesale/retail/supermarket/online/other" json:"type"`
+	Sort      int       `gorm:"not null;default:0;comment:排序码" json:"sort"`
+	IsDeleted int       `gorm:"column:is_deleted;not null;default:0;index:idx_market_org,priority:2;comment:软删：0=有效 1=删除" json:"is_deleted"`
+	Version   int       `gorm:"not null;default:1;comment:版本号（乐观锁）" json:"version"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (m *Market) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	if m.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	if m.Type == "" {
+		m.Type = TypeOther
+	}
+	return nil
+}
+
+func (Market) TableName() string { return "base_market" }
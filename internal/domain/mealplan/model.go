@@ -0,0 +1,61 @@
+package mealplan
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Plan 餐次计划：某机构某日某餐次的菜品与计划就餐人数
+type Plan struct {
+	ID        string    `gorm:"primaryKey;type:char(36)"`
+	OrgID     string    `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_meal_plan_org_date_meal,priority:1;comment:机构ID（base_org.id）"`
+	PlanDate  time.Time `gorm:"column:plan_date;type:date;not null;uniqueIndex:uk_meal_plan_org_date_meal,priority:2;comment:就餐日期"`
+	MealID    string    `gorm:"column:meal_id;type:char(36);not null;uniqueIndex:uk_meal_plan_org_date_meal,priority:3;comment:餐次ID（menu_meal.id）"`
+	Headcount int       `gorm:"not null;default:0;comment:计划就餐人数"`
+	Remark    *string   `gorm:"size:255;comment:备注"`
+	Version   int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+
+	Dishes []Dish `gorm:"-" json:"dishes"`
+}
+
+func (p *Plan) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.NewString()
+	}
+	if p.Version == 0 {
+		p.Version = 1
+	}
+	if p.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Plan) TableName() string { return "menu_meal_plan" }
+
+// Dish 餐次计划中的菜品
+type Dish struct {
+	ID        string    `gorm:"primaryKey;type:char(36)"`
+	PlanID    string    `gorm:"column:plan_id;type:char(36);not null;index;comment:餐次计划ID（menu_meal_plan.id）"`
+	Name      string    `gorm:"size:128;not null;comment:菜品名称"`
+	RecipeID  *string   `gorm:"column:recipe_id;type:char(36);index;comment:配方ID（menu_recipe.id）"`
+	RecipeVer *int      `gorm:"column:recipe_version;comment:固定配方版本（为空取当前版本）" json:"recipe_version"`
+	Servings  *int      `gorm:"comment:份数（为空按计划人数）"`
+	Sort      int       `gorm:"not null;default:0;comment:排序码"`
+	Remark    *string   `gorm:"size:255;comment:备注"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (d *Dish) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Dish) TableName() string { return "menu_meal_plan_dish" }
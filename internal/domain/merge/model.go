@@ -0,0 +1,58 @@
+package merge
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 合并对象类型
+const (
+	EntityGoods    = "goods"
+	EntityCategory = "category"
+)
+
+// Record 合并审计：每次合并（非预览）落一条，便于事后追溯
+type Record struct {
+	ID           string    `gorm:"primaryKey;type:char(36)"`
+	EntityType   string    `gorm:"column:entity_type;size:16;not null;index:idx_merge_org_type,priority:2;comment:合并对象：goods/category"`
+	OrgID        string    `gorm:"column:org_id;type:char(36);not null;index:idx_merge_org_type,priority:1;comment:所属机构ID"`
+	SurvivorID   string    `gorm:"column:survivor_id;type:char(36);not null;index;comment:保留方ID"`
+	LoserIDs     string    `gorm:"column:loser_ids;type:text;not null;comment:被合并方ID（JSON 数组）"`
+	Affected     string    `gorm:"column:affected;type:text;not null;comment:各表改写/冲突行数（JSON）"`
+	OperatorID   string    `gorm:"column:operator_id;type:char(36);comment:操作人ID"`
+	OperatorName string    `gorm:"column:operator_name;size:64;comment:操作人用户名"`
+	Remark       *string   `gorm:"column:remark;size:255;comment:备注"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+func (m *Record) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == "" {
+		m.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Record) TableName() string { return "base_merge_record" }
+
+// RefCount 单个引用列的影响行数
+//   - Rewritten：引用改写为保留方的行数
+//   - Conflicted：与保留方唯一键冲突、改为软删的行数
+//   - Deleted：被合并方自身软删的行数
+type RefCount struct {
+	Table      string `json:"table"`
+	Column     string `json:"column"`
+	Rewritten  int64  `json:"rewritten"`
+	Conflicted int64  `json:"conflicted"`
+	Deleted    int64  `json:"deleted"`
+}
+
+// Result 合并/预览结果
+type Result struct {
+	Preview  bool       `json:"preview"`
+	RecordID string     `json:"record_id,omitempty"`
+	Survivor string     `json:"survivor_id"`
+	Losers   []string   `json:"loser_ids"`
+	Affected []RefCount `json:"affected"`
+}
@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 通知类型
+const (
+	KindSupplierExpiring = "supplier_expiring" // 供应商合同即将到期
+	KindSupplierExpired  = "supplier_expired"  // 供应商合同到期，已自动停用
+	KindSupplierActive   = "supplier_active"   // 供应商合同生效，已自动启用
+	KindDocExpiring      = "doc_expiring"      // 供应商资质即将到期
+	KindDocLapsed        = "doc_lapsed"        // 供应商必备资质已过期
+)
+
+// Notification 机构站内通知；DedupKey 相同的通知只生成一次（调度重复执行不重复提醒）
+type Notification struct {
+	ID        string     `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID     string     `gorm:"column:org_id;type:char(36);not null;index:idx_notice_org,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	Kind      string     `gorm:"size:32;not null;comment:通知类型" json:"kind"`
+	RefType   string     `gorm:"column:ref_type;size:32;not null;comment:关联对象类型，如 supplier/supplier_document" json:"ref_type"`
+	RefID     string     `gorm:"column:ref_id;type:char(36);not null;index;comment:关联对象ID" json:"ref_id"`
+	Title     string     `gorm:"size:128;not null;comment:标题" json:"title"`
+	Content   string     `gorm:"size:512;not null;default:'';comment:内容" json:"content"`
+	DedupKey  string     `gorm:"column:dedup_key;size:191;not null;uniqueIndex:uk_notice_dedup;comment:去重键" json:"-"`
+	ReadAt    *time.Time `gorm:"column:read_at;index:idx_notice_org,priority:2;comment:已读时间（空=未读）" json:"read_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == "" {
+		n.ID = uuid.NewString()
+	}
+	if n.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Notification) TableName() string { return "sys_notification" }
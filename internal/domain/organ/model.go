@@ -2,6 +2,7 @@ package organ
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,6 +10,21 @@ import (
 	"hdzk.cn/foodapp/pkg/utils"
 )
 
+// DefaultTimeZone 未设置时区的机构按北京时间处理
+const DefaultTimeZone = "Asia/Shanghai"
+
+// Location 解析 IANA 时区名，空串取 DefaultTimeZone
+func Location(tz string) (*time.Location, error) {
+	if strings.TrimSpace(tz) == "" {
+		tz = DefaultTimeZone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("时区无效: %s", tz)
+	}
+	return loc, nil
+}
+
 type Organ struct {
 	ID          string    `gorm:"primaryKey;type:char(36)"`
 	Name        string    `gorm:"size:128;not null;uniqueIndex:uk_org_parent_name;comment:组织名称"` // 注意 size 与 DB 一致
@@ -17,7 +33,9 @@ type Organ struct {
 	ParentID    *string   `gorm:"column:parent_id;type:char(36);not null;index;comment:上级组织ID"`  // 重命名 + 映射
 	Description string    `gorm:"type:text;not null;comment:组织描述"`
 	Sort        int       `gorm:"not null;default:0;index;comment:排序码"`
+	TimeZone    string    `gorm:"column:time_zone;size:64;not null;default:'Asia/Shanghai';comment:时区（IANA 名称），按当地时刻归属餐次"`
 	IsDeleted   int       `gorm:"not null;default:0;index;comment:是否已删除"`
+	Version     int       `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"autoUpdateTime"`
 }
@@ -26,6 +44,15 @@ func (m *Organ) BeforeCreate(tx *gorm.DB) error {
 	if m.ID == "" {
 		m.ID = uuid.NewString()
 	}
+	if m.Version == 0 {
+		m.Version = 1
+	}
+	if m.TimeZone = strings.TrimSpace(m.TimeZone); m.TimeZone == "" {
+		m.TimeZone = DefaultTimeZone
+	}
+	if _, err := Location(m.TimeZone); err != nil {
+		return err
+	}
 	if m.Sort <= 0 {
 		next, err := utils.NextColoumSort(tx, m.TableName())
 		if err != nil {
@@ -54,3 +81,25 @@ func codeFromSort(sort int) string {
 }
 
 func (Organ) TableName() string { return "base_org" }
+
+// DescendantIDs 返回 rootID 及其全部下级组织 ID（BFS，rootID 在首位）
+func DescendantIDs(list []Organ, rootID string) []string {
+	children := make(map[string][]string, len(list))
+	for _, o := range list {
+		if o.ParentID != nil && *o.ParentID != "" && *o.ParentID != o.ID {
+			children[*o.ParentID] = append(children[*o.ParentID], o.ID)
+		}
+	}
+	out := []string{rootID}
+	seen := map[string]bool{rootID: true}
+	for i := 0; i < len(out); i++ {
+		for _, child := range children[out[i]] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			out = append(out, child)
+		}
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package portal
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 供应商门户权限范围
+const (
+	ScopeQuote      = "quote"      // 对开放的询价单报价
+	ScopeOrder      = "order"      // 查看本供应商的采购单
+	ScopeSettlement = "settlement" // 下载结算对账单
+	ScopeDocument   = "document"   // 上传、查看资质文件
+)
+
+// Scopes 全部权限范围（展示顺序）
+var Scopes = []string{ScopeQuote, ScopeOrder, ScopeSettlement, ScopeDocument}
+
+// 账户状态
+const (
+	StatusActive   = 1 // 正常
+	StatusDisabled = 2 // 停用
+)
+
+// ErrNotInvited 询价单未向该供应商开放
+var ErrNotInvited = errors.New("询价单未向该供应商开放报价")
+
+// ErrClosed 已过报价截止时间
+var ErrClosed = errors.New("询价单报价已截止")
+
+// Account 供应商门户账户（外部用户），与员工账户 base_user 相互独立
+type Account struct {
+	ID           string     `gorm:"primaryKey;type:char(36)" json:"id"`
+	SupplierID   string     `gorm:"column:supplier_id;type:char(36);not null;index;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	OrgID        string     `gorm:"column:org_id;type:char(36);not null;index;comment:机构ID（与供应商一致）" json:"org_id"`
+	Username     string     `gorm:"size:64;not null;uniqueIndex:uk_supplier_user_username;comment:登录名" json:"username"`
+	PasswordHash string     `gorm:"column:password_hash;size:255;not null;comment:密码Hash" json:"-"`
+	DisplayName  *string    `gorm:"column:display_name;size:64;comment:姓名" json:"display_name"`
+	Phone        *string    `gorm:"size:32;comment:联系电话" json:"phone"`
+	Scopes       string     `gorm:"size:128;not null;default:'';comment:权限范围（逗号分隔）：quote,order,settlement,document" json:"-"`
+	Status       int        `gorm:"not null;default:1;comment:状态：1=正常 2=停用" json:"status"`
+	LastLoginAt  *time.Time `gorm:"column:last_login_at;comment:最后登录时间" json:"last_login_at"`
+	CreatedBy    *string    `gorm:"column:created_by;type:char(36);comment:创建人ID（base_user.id）" json:"created_by"`
+	IsDeleted    int        `gorm:"column:is_deleted;not null;default:0;comment:软删：0=有效 1=删除" json:"-"`
+	Version      int        `gorm:"not null;default:1;comment:版本号（乐观锁）" json:"version"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	ScopeList []string `gorm:"-" json:"scopes"`
+}
+
+func (a *Account) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == "" {
+		a.ID = uuid.NewString()
+	}
+	if a.Version == 0 {
+		a.Version = 1
+	}
+	if a.SupplierID == "" || a.OrgID == "" {
+		return errors.New("SupplierID/OrgID 不能为空")
+	}
+	if a.Status == 0 {
+		a.Status = StatusActive
+	}
+	return nil
+}
+
+func (a *Account) AfterFind(tx *gorm.DB) error {
+	a.ScopeList = SplitScopes(a.Scopes)
+	return nil
+}
+
+func (Account) TableName() string { return "supplier_user" }
+
+// Has 是否具有权限范围
+func (a Account) Has(scope string) bool {
+	for _, s := range SplitScopes(a.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitScopes 拆分逗号分隔的权限范围
+func SplitScopes(raw string) []string {
+	out := []string{}
+	for _, s := range strings.Split(raw, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Invitation 询价单向供应商开放报价；ClosesAt 为空时报价截止于询价单审核锁定
+type Invitation struct {
+	ID         string     `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID      string     `gorm:"column:org_id;type:char(36);not null;comment:机构ID（base_org.id）" json:"org_id"`
+	InquiryID  string     `gorm:"column:inquiry_id;type:char(36);not null;uniqueIndex:uk_sii_inquiry_supplier,priority:1;comment:询价单ID（base_price_inquiry.id）" json:"inquiry_id"`
+	SupplierID string     `gorm:"column:supplier_id;type:char(36);not null;uniqueIndex:uk_sii_inquiry_supplier,priority:2;index;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	ClosesAt   *time.Time `gorm:"column:closes_at;comment:报价截止时间（空=询价单锁定前均可报价）" json:"closes_at"`
+	InvitedBy  *string    `gorm:"column:invited_by;type:char(36);comment:开放人ID（base_user.id）" json:"invited_by"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (i *Invitation) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == "" {
+		i.ID = uuid.NewString()
+	}
+	if i.InquiryID == "" || i.SupplierID == "" {
+		return errors.New("InquiryID/SupplierID 不能为空")
+	}
+	return nil
+}
+
+func (Invitation) TableName() string { return "supplier_inquiry_invite" }
+
+// OpenAt at 时刻是否仍可报价（不含询价单锁定判断）
+func (i Invitation) OpenAt(at time.Time) bool {
+	return i.ClosesAt == nil || !at.After(*i.ClosesAt)
+}
+
+// InquiryView 门户可见的询价单（不含市场价）
+type InquiryView struct {
+	InquiryID    string     `json:"inquiry_id"`
+	InquiryTitle string     `json:"inquiry_title"`
+	InquiryDate  time.Time  `json:"inquiry_date"`
+	Status       int        `json:"status"`
+	ClosesAt     *time.Time `json:"closes_at"`
+	Quoted       int        `json:"quoted"` // 本供应商已报价的商品数
+	Items        int        `json:"items"`  // 询价商品数
+}
+
+// InquiryItem 询价商品及本供应商当前报价
+type InquiryItem struct {
+	GoodsID   string           `json:"goods_id"`
+	GoodsName string           `json:"goods_name"`
+	SpecName  string           `json:"spec_name"`
+	UnitName  string           `json:"unit_name"`
+	UnitPrice *decimal.Decimal `json:"unit_price"`
+}
@@ -0,0 +1,291 @@
+package price
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 询价相关表名（表结构见 sql/10_goods_domain.sql）
+const (
+	InquiryTable   = "base_price_inquiry"
+	AvgDetailTable = "base_goods_avg_detail"
+	QuoteTable     = "base_goods_price"
+	LinePriceTable = "base_goods_market_price"
+	SupplierTable  = "supplier"
+)
+
+// Point 某商品在某次询价中的均价（按商品自身单位计价）
+type Point struct {
+	GoodsID     string          `json:"goods_id"`
+	InquiryID   string          `json:"inquiry_id"`
+	InquiryDate time.Time       `json:"inquiry_date"`
+	AvgPrice    decimal.Decimal `json:"avg_price"`
+}
+
+// Quote 供应商对某商品的报价（取自 base_goods_price），结算价 = 单价 × 浮动比例
+type Quote struct {
+	GoodsID      string          `json:"goods_id"`
+	SupplierID   string          `json:"supplier_id"`
+	SupplierName string          `json:"supplier_name"`
+	InquiryID    string          `json:"inquiry_id"`
+	InquiryTitle string          `json:"inquiry_title"`
+	InquiryDate  time.Time       `json:"inquiry_date"`
+	UnitPrice    decimal.Decimal `json:"unit_price"`
+	FloatRatio   decimal.Decimal `json:"float_ratio"`
+}
+
+// SettlePrice 结算单价，保留 2 位
+func (q Quote) SettlePrice() decimal.Decimal {
+	return q.UnitPrice.Mul(q.FloatRatio).Round(2)
+}
+
+// 价格走势时间粒度
+const (
+	GranularityInquiry = "inquiry" // 按询价日期逐点
+	GranularityWeek    = "week"    // 按 ISO 周（周一为起点）
+	GranularityMonth   = "month"   // 按自然月
+)
+
+// Sample 某商品在某次询价中的均价及各市场最低/最高价
+type Sample struct {
+	GoodsID     string          `json:"goods_id"`
+	OrgID       string          `json:"org_id"`
+	InquiryID   string          `json:"inquiry_id"`
+	InquiryDate time.Time       `json:"inquiry_date"`
+	AvgPrice    decimal.Decimal `json:"avg_price"`
+	MinPrice    decimal.Decimal `json:"min_price"`
+	MaxPrice    decimal.Decimal `json:"max_price"`
+}
+
+// TrendPoint 走势中的一个点。
+// Change/ChangeRate 对比上一个点（可早于查询区间）；YoY* 对比去年同期（同月/同周），无数据时为空
+type TrendPoint struct {
+	Period     string           `json:"period"`
+	Start      time.Time        `json:"start"`
+	AvgPrice   decimal.Decimal  `json:"avg_price"`
+	MinPrice   decimal.Decimal  `json:"min_price"`
+	MaxPrice   decimal.Decimal  `json:"max_price"`
+	Samples    int              `json:"samples"`
+	PrevPrice  *decimal.Decimal `json:"prev_price"`
+	Change     *decimal.Decimal `json:"change"`
+	ChangeRate *decimal.Decimal `json:"change_rate"` // 百分比
+	LastYear   *decimal.Decimal `json:"last_year_price"`
+	YoYChange  *decimal.Decimal `json:"yoy_change"`
+	YoYRate    *decimal.Decimal `json:"yoy_rate"` // 百分比
+}
+
+type TrendSeries struct {
+	GoodsID string       `json:"goods_id"`
+	Points  []TrendPoint `json:"points"`
+}
+
+type Trend struct {
+	Granularity string        `json:"granularity"`
+	OrgIDs      []string      `json:"org_ids"`
+	Series      []TrendSeries `json:"series"`
+}
+
+// InquiryLine 询价明细（base_goods_avg_detail）。各市场价见 Prices，avg_price 为非空市场价的平均值（保留 2 位）；
+// market1..3_price 为旧版固定列，由前三个市场（Sort 1..3）的价格同步写入以兼容旧报表
+type InquiryLine struct {
+	ID           string           `gorm:"primaryKey;type:char(36)" json:"id"`
+	GoodsID      string           `gorm:"column:goods_id;type:char(36);not null" json:"goods_id"`
+	GuidePrice   *decimal.Decimal `gorm:"column:guide_price;type:decimal(10,2)" json:"guide_price"`
+	Market1Price *decimal.Decimal `gorm:"column:market1_price;type:decimal(10,2)" json:"market1_price"`
+	Market2Price *decimal.Decimal `gorm:"column:market2_price;type:decimal(10,2)" json:"market2_price"`
+	Market3Price *decimal.Decimal `gorm:"column:market3_price;type:decimal(10,2)" json:"market3_price"`
+	AvgPrice     *decimal.Decimal `gorm:"column:avg_price;type:decimal(10,2)" json:"avg_price"`
+	InquiryID    string           `gorm:"column:inquiry_id;type:char(36);not null" json:"inquiry_id"`
+	OrgID        *string          `gorm:"column:org_id;type:char(36)" json:"org_id"`
+	IsDeleted    int              `gorm:"column:is_deleted;not null;default:0" json:"is_deleted"`
+	CreatedAt    time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Prices []LinePrice `gorm:"-" json:"prices"`
+	Flags  []Flag      `gorm:"-" json:"flags"`
+}
+
+func (l *InquiryLine) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (InquiryLine) TableName() string { return AvgDetailTable }
+
+// MarketPrices 各市场价（市场ID → 价格）
+func (l InquiryLine) MarketPrices() map[string]decimal.Decimal {
+	out := make(map[string]decimal.Decimal, len(l.Prices))
+	for _, p := range l.Prices {
+		out[p.MarketID] = p.Price
+	}
+	return out
+}
+
+// AvgOf 各市场价的平均值（保留 2 位），无价格时为空
+func AvgOf(prices []LinePrice) *decimal.Decimal {
+	if len(prices) == 0 {
+		return nil
+	}
+	sum := decimal.Zero
+	for _, p := range prices {
+		sum = sum.Add(p.Price)
+	}
+	avg := sum.Div(decimal.NewFromInt(int64(len(prices)))).Round(2)
+	return &avg
+}
+
+// LinePrice 询价明细在某个市场的价格（未询到价的市场不建行）
+type LinePrice struct {
+	ID        string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	InquiryID string          `gorm:"column:inquiry_id;type:char(36);not null;index;comment:询价单ID（base_price_inquiry.id）" json:"inquiry_id"`
+	LineID    string          `gorm:"column:line_id;type:char(36);not null;uniqueIndex:uk_gmp_line_market,priority:1;comment:询价明细ID（base_goods_avg_detail.id）" json:"line_id"`
+	MarketID  string          `gorm:"column:market_id;type:char(36);not null;uniqueIndex:uk_gmp_line_market,priority:2;index;comment:市场ID（base_market.id）" json:"market_id"`
+	GoodsID   string          `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）" json:"goods_id"`
+	Price     decimal.Decimal `gorm:"type:decimal(10,2);not null;comment:市场价" json:"price"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (p *LinePrice) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == "" {
+		p.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (LinePrice) TableName() string { return LinePriceTable }
+
+// QuoteLine 供应商报价明细（base_goods_price）
+type QuoteLine struct {
+	ID         string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	GoodsID    string          `gorm:"column:goods_id;type:char(36);not null" json:"goods_id"`
+	SupplierID string          `gorm:"column:supplier_id;type:char(36);not null" json:"supplier_id"`
+	InquiryID  string          `gorm:"column:inquiry_id;type:char(36);not null" json:"inquiry_id"`
+	UnitPrice  decimal.Decimal `gorm:"column:unit_price;type:decimal(10,2);not null" json:"unit_price"`
+	FloatRatio decimal.Decimal `gorm:"column:float_ratio;type:decimal(6,4);not null;default:1.0000" json:"float_ratio"`
+	OrgID      *string         `gorm:"column:org_id;type:char(36)" json:"org_id"`
+	IsDeleted  int             `gorm:"column:is_deleted;not null;default:0" json:"is_deleted"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Flags []Flag `gorm:"-" json:"flags"`
+}
+
+func (q *QuoteLine) BeforeCreate(tx *gorm.DB) error {
+	if q.ID == "" {
+		q.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (QuoteLine) TableName() string { return QuoteTable }
+
+// 异常标记所在明细类型
+const (
+	LineInquiry = "inquiry_line" // base_goods_avg_detail
+	LineQuote   = "quote"        // base_goods_price
+)
+
+// 异常标记字段；market_price 时由 MarketID 指明市场
+const (
+	FieldMarketPrice = "market_price"
+	FieldUnitPrice   = "unit_price"
+)
+
+// 异常类型
+const (
+	KindBand   = "band"   // 偏离历史中位数超出允许幅度
+	KindSpread = "spread" // 各市场价之间差距过大
+)
+
+// 异常标记状态
+const (
+	FlagPending  = 0 // 待复核
+	FlagAccepted = 1 // 已确认（价格无误）
+)
+
+// 异常规则默认值（机构未配置时使用）
+var (
+	DefaultBandPct   = decimal.NewFromInt(30)
+	DefaultSpreadPct = decimal.NewFromInt(50)
+)
+
+const (
+	DefaultWindow     = 5
+	DefaultMinSamples = 3
+)
+
+// Rule 机构价格异常检测规则
+type Rule struct {
+	ID         string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID      string          `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_price_rule_org;comment:机构ID（base_org.id）" json:"org_id"`
+	BandPct    decimal.Decimal `gorm:"column:band_pct;type:decimal(6,2);not null;default:30;comment:允许偏离历史中位数的幅度（%）" json:"band_pct"`
+	SpreadPct  decimal.Decimal `gorm:"column:spread_pct;type:decimal(6,2);not null;default:50;comment:允许的市场价差（最高相对最低，%）" json:"spread_pct"`
+	Window     int             `gorm:"column:window_size;not null;default:5;comment:计算中位数取最近几次询价" json:"window_size"`
+	MinSamples int             `gorm:"column:min_samples;not null;default:3;comment:历史样本少于该数时不做偏离检测" json:"min_samples"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *Rule) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Rule) TableName() string { return "price_anomaly_rule" }
+
+// DefaultRule 机构未配置规则时的默认规则
+func DefaultRule(orgID string) Rule {
+	return Rule{
+		OrgID:      orgID,
+		BandPct:    DefaultBandPct,
+		SpreadPct:  DefaultSpreadPct,
+		Window:     DefaultWindow,
+		MinSamples: DefaultMinSamples,
+	}
+}
+
+// Flag 价格异常标记。明细重新录入时待复核标记会被重算；已确认的标记在价格不变时保留
+type Flag struct {
+	ID         string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID      string          `gorm:"column:org_id;type:char(36);not null;index:idx_paf_org_status,priority:1;comment:机构ID" json:"org_id"`
+	InquiryID  string          `gorm:"column:inquiry_id;type:char(36);not null;index;comment:询价单ID（base_price_inquiry.id）" json:"inquiry_id"`
+	GoodsID    string          `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）" json:"goods_id"`
+	SupplierID *string         `gorm:"column:supplier_id;type:char(36);comment:供应商ID（报价标记）" json:"supplier_id"`
+	LineType   string          `gorm:"column:line_type;size:16;not null;index:idx_paf_line,priority:1;comment:明细类型：inquiry_line/quote" json:"line_type"`
+	LineID     string          `gorm:"column:line_id;type:char(36);not null;index:idx_paf_line,priority:2;comment:明细ID" json:"line_id"`
+	Field      string          `gorm:"size:32;not null;comment:异常字段：market_price/unit_price" json:"field"`
+	MarketID   *string         `gorm:"column:market_id;type:char(36);comment:市场ID（market_price 标记）" json:"market_id"`
+	Kind       string          `gorm:"size:16;not null;comment:异常类型：band=偏离历史 spread=市场价差" json:"kind"`
+	Value      decimal.Decimal `gorm:"type:decimal(10,2);not null;comment:录入值" json:"value"`
+	Reference  decimal.Decimal `gorm:"type:decimal(10,2);not null;comment:参照值（历史中位数或最低市场价）" json:"reference"`
+	Deviation  decimal.Decimal `gorm:"type:decimal(10,2);not null;comment:偏离幅度（%）" json:"deviation"`
+	Limit      decimal.Decimal `gorm:"column:limit_pct;type:decimal(6,2);not null;comment:检测时的允许幅度（%）" json:"limit_pct"`
+	Status     int             `gorm:"not null;default:0;index:idx_paf_org_status,priority:2;comment:状态：0=待复核 1=已确认" json:"status"`
+	Comment    *string         `gorm:"size:255;comment:复核意见" json:"comment"`
+	ReviewerID *string         `gorm:"column:reviewer_id;type:char(36);comment:复核人ID（base_user.id）" json:"reviewer_id"`
+	ReviewedAt *time.Time      `gorm:"column:reviewed_at;comment:复核时间" json:"reviewed_at"`
+	IsDeleted  int             `gorm:"column:is_deleted;not null;default:0;comment:软删：0=有效 1=删除（明细重算时作废）" json:"is_deleted"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (f *Flag) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Flag) TableName() string { return "price_anomaly_flag" }
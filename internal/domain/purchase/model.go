@@ -0,0 +1,95 @@
+package purchase
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 采购单状态
+const (
+	StatusDraft     = 0 // 草稿，可删除
+	StatusSubmitted = 1 // 已提交
+	StatusReceived  = 2 // 已收货
+	StatusCancelled = 9 // 已作废
+)
+
+// 采购单来源
+const (
+	SourceManual   = "manual"
+	SourceForecast = "forecast"
+)
+
+// QtyScale 采购/实收数量保留的小数位（与 decimal(20,3) 一致）
+const QtyScale = 3
+
+// Order 采购单抬头（一张单对应一个供应商）
+type Order struct {
+	ID           string          `gorm:"primaryKey;type:char(36)"`
+	OrgID        string          `gorm:"column:org_id;type:char(36);not null;index:idx_po_org_date,priority:1;comment:机构ID（base_org.id）"`
+	SupplierID   string          `gorm:"column:supplier_id;type:char(36);not null;index;comment:供应商ID（supplier.id）"`
+	ExpectedDate time.Time       `gorm:"column:expected_date;type:date;not null;index:idx_po_org_date,priority:2;comment:期望到货日期"`
+	Status       int             `gorm:"not null;default:0;comment:状态：0=草稿 1=已提交 2=已收货 9=已作废"`
+	Source       string          `gorm:"size:16;not null;default:manual;comment:来源：manual=手工 forecast=需求预测"`
+	Amount       decimal.Decimal `gorm:"type:decimal(14,2);not null;default:0;comment:合计金额（按结算价）"`
+	Remark       *string         `gorm:"size:255;comment:备注"`
+	OperatorID   *string         `gorm:"column:operator_id;type:char(36);comment:创建人ID（base_user.id）"`
+	ReceivedAt   *time.Time      `gorm:"column:received_at;comment:实际到货时间"`
+	ReceivedBy   *string         `gorm:"column:received_by;type:char(36);comment:收货人ID（base_user.id）"`
+	IsDeleted    int             `gorm:"column:is_deleted;not null;default:0;index;comment:软删：0=有效 1=删除"`
+	CreatedAt    time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time       `gorm:"autoUpdateTime"`
+
+	Lines []Line `gorm:"-" json:"lines"`
+}
+
+func (o *Order) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == "" {
+		o.ID = uuid.NewString()
+	}
+	if o.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Order) TableName() string { return "purchase_order" }
+
+// Line 采购明细；结算价 = 单价 × 浮动比例，金额 = 数量 × 结算价
+type Line struct {
+	ID          string           `gorm:"primaryKey;type:char(36)"`
+	OrderID     string           `gorm:"column:order_id;type:char(36);not null;index;comment:采购单ID（purchase_order.id）"`
+	GoodsID     string           `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）"`
+	UnitID      string           `gorm:"column:unit_id;type:char(36);not null;comment:单位ID（base_unit.id）"`
+	Quantity    decimal.Decimal  `gorm:"type:decimal(20,3);not null;comment:采购数量"`
+	UnitPrice   decimal.Decimal  `gorm:"column:unit_price;type:decimal(10,2);not null;comment:单价（报价）"`
+	FloatRatio  decimal.Decimal  `gorm:"column:float_ratio;type:decimal(6,4);not null;default:1;comment:浮动比例快照"`
+	SettlePrice decimal.Decimal  `gorm:"column:settle_price;type:decimal(10,2);not null;comment:结算单价"`
+	Amount      decimal.Decimal  `gorm:"type:decimal(14,2);not null;comment:金额"`
+	InquiryID   *string          `gorm:"column:inquiry_id;type:char(36);comment:报价来源询价ID（base_price_inquiry.id）"`
+	Explanation *string          `gorm:"type:text;comment:生成依据（需求预测时记录推导过程）"`
+	ReceivedQty *decimal.Decimal `gorm:"column:received_qty;type:decimal(20,3);comment:实收数量（收货后填写）"`
+	Sort        int              `gorm:"not null;default:0;comment:排序码"`
+	CreatedAt   time.Time        `gorm:"autoCreateTime"`
+}
+
+func (l *Line) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Line) TableName() string { return "purchase_order_line" }
+
+// Compute 按单价、浮动比例计算结算价与金额
+func (l *Line) Compute() {
+	if l.FloatRatio.IsZero() {
+		l.FloatRatio = decimal.NewFromInt(1)
+	}
+	l.SettlePrice = l.UnitPrice.Mul(l.FloatRatio).Round(2)
+	l.Amount = l.Quantity.Mul(l.SettlePrice).Round(2)
+}
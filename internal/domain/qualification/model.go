@@ -0,0 +1,165 @@
+package qualification
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// 资质类型
+const (
+	TypeBusinessLicense = "business_license" // 营业执照
+	TypeFoodPermit      = "food_permit"      // 食品经营许可证
+	TypeProductionCert  = "production_cert"  // 食品生产许可证
+	TypeHealthCert      = "health_cert"      // 从业人员健康证
+	TypeInspection      = "inspection"       // 检验检测报告
+	TypeOther           = "other"            // 其他
+)
+
+// DocType 资质类型说明；Mandatory 为必备资质，过期即禁止报价、下单
+type DocType struct {
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Mandatory bool   `json:"mandatory"`
+}
+
+// DocTypes 全部资质类型（展示顺序）
+var DocTypes = []DocType{
+	{Code: TypeBusinessLicense, Name: "营业执照", Mandatory: true},
+	{Code: TypeFoodPermit, Name: "食品经营许可证", Mandatory: true},
+	{Code: TypeProductionCert, Name: "食品生产许可证"},
+	{Code: TypeHealthCert, Name: "从业人员健康证"},
+	{Code: TypeInspection, Name: "检验检测报告"},
+	{Code: TypeOther, Name: "其他"},
+}
+
+// LookupType 按编码查找资质类型
+func LookupType(code string) (DocType, bool) {
+	for _, t := range DocTypes {
+		if t.Code == code {
+			return t, true
+		}
+	}
+	return DocType{}, false
+}
+
+// MandatoryTypes 必备资质类型编码
+func MandatoryTypes() []string {
+	var out []string
+	for _, t := range DocTypes {
+		if t.Mandatory {
+			out = append(out, t.Code)
+		}
+	}
+	return out
+}
+
+// 审核状态
+const (
+	VerifyPending  = 0 // 待审核
+	VerifyApproved = 1 // 已审核
+	VerifyRejected = 2 // 已驳回
+)
+
+// ErrLapsed 必备资质已过期，不能报价、下单
+var ErrLapsed = errors.New("供应商必备资质已过期")
+
+// Document 供应商资质文件；仅已审核且在有效期内的文件视为有效
+type Document struct {
+	ID           string     `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID        string     `gorm:"column:org_id;type:char(36);not null;index:idx_sd_org_expiry,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	SupplierID   string     `gorm:"column:supplier_id;type:char(36);not null;index:idx_sd_supplier_type,priority:1;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	DocType      string     `gorm:"column:doc_type;size:32;not null;index:idx_sd_supplier_type,priority:2;comment:资质类型" json:"doc_type"`
+	DocNo        *string    `gorm:"column:doc_no;size:64;comment:证照编号" json:"doc_no"`
+	IssueDate    *time.Time `gorm:"column:issue_date;type:date;comment:发证日期" json:"issue_date"`
+	ExpiryDate   *time.Time `gorm:"column:expiry_date;type:date;index:idx_sd_org_expiry,priority:2;comment:有效期至（含，空=长期）" json:"expiry_date"`
+	FileName     string     `gorm:"column:file_name;size:255;not null;comment:原始文件名" json:"file_name"`
+	FilePath     string     `gorm:"column:file_path;size:255;not null;comment:存储相对路径" json:"-"`
+	FileSize     int64      `gorm:"column:file_size;not null;comment:文件大小（字节）" json:"file_size"`
+	ContentType  string     `gorm:"column:content_type;size:64;not null;comment:文件类型" json:"content_type"`
+	SHA256       string     `gorm:"column:sha256;type:char(64);not null;comment:文件摘要" json:"sha256"`
+	VerifyStatus int        `gorm:"column:verify_status;not null;default:0;comment:审核状态：0=待审核 1=已审核 2=已驳回" json:"verify_status"`
+	VerifyRemark *string    `gorm:"column:verify_remark;size:255;comment:审核意见" json:"verify_remark"`
+	VerifiedBy   *string    `gorm:"column:verified_by;type:char(36);comment:审核人ID" json:"verified_by"`
+	VerifiedAt   *time.Time `gorm:"column:verified_at;comment:审核时间" json:"verified_at"`
+	Remark       *string    `gorm:"size:255;comment:备注" json:"remark"`
+	UploadedBy   *string    `gorm:"column:uploaded_by;type:char(36);comment:上传人ID" json:"uploaded_by"`
+	IsDeleted    int        `gorm:"column:is_deleted;not null;default:0;comment:软删标记：0=有效,1=已删除" json:"is_deleted"`
+	Version      int        `gorm:"not null;default:1;comment:版本号（乐观锁）" json:"version"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (d *Document) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == "" {
+		d.ID = uuid.NewString()
+	}
+	if d.Version == 0 {
+		d.Version = 1
+	}
+	if d.OrgID == "" || d.SupplierID == "" {
+		return errors.New("OrgID/SupplierID 不能为空")
+	}
+	return nil
+}
+
+func (Document) TableName() string { return "supplier_document" }
+
+// ValidOn 已审核且 at 当日未过期（到期日当天仍有效）
+func (d Document) ValidOn(at time.Time) bool {
+	if d.VerifyStatus != VerifyApproved {
+		return false
+	}
+	return d.ExpiryDate == nil || !utils.DateOf(at).After(*d.ExpiryDate)
+}
+
+// 资质状态（按类型汇总）
+const (
+	StateValid    = "valid"    // 有效
+	StateExpiring = "expiring" // 即将到期
+	StateLapsed   = "lapsed"   // 已过期（曾有有效文件，现已全部到期）
+	StateMissing  = "missing"  // 无已审核文件
+)
+
+// TypeStatus 供应商某类资质的汇总状态
+type TypeStatus struct {
+	DocType
+	State      string     `json:"state"`
+	ExpiryDate *time.Time `json:"expiry_date"` // 有效文件中最晚的到期日（空=长期）
+	DocumentID *string    `json:"document_id"` // 对应的有效文件
+}
+
+// Summarize 按类型汇总资质状态；warnDays 天内到期视为即将到期
+func Summarize(docs []Document, at time.Time, warnDays int) []TypeStatus {
+	out := make([]TypeStatus, 0, len(DocTypes))
+	for _, t := range DocTypes {
+		st := TypeStatus{DocType: t, State: StateMissing}
+		var best *Document
+		for i := range docs {
+			d := &docs[i]
+			if d.DocType != t.Code || d.VerifyStatus != VerifyApproved {
+				continue
+			}
+			if st.State == StateMissing {
+				st.State = StateLapsed
+			}
+			if !d.ValidOn(at) {
+				continue
+			}
+			if best == nil || (best.ExpiryDate != nil && (d.ExpiryDate == nil || d.ExpiryDate.After(*best.ExpiryDate))) {
+				best = d
+			}
+		}
+		if best != nil {
+			st.State, st.ExpiryDate, st.DocumentID = StateValid, best.ExpiryDate, &best.ID
+			if best.ExpiryDate != nil && !best.ExpiryDate.After(utils.DateOf(at).AddDate(0, 0, warnDays)) {
+				st.State = StateExpiring
+			}
+		}
+		out = append(out, st)
+	}
+	return out
+}
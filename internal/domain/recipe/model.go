@@ -0,0 +1,139 @@
+package recipe
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Recipe 菜品配方（BOM）抬头；配方内容按版本保存，CurrentVersion 为当前生效版本
+type Recipe struct {
+	ID             string    `gorm:"primaryKey;type:char(36)"`
+	OrgID          string    `gorm:"column:org_id;type:char(36);not null;index;comment:机构ID（base_org.id）"`
+	Name           string    `gorm:"size:128;not null;comment:菜品名称"`
+	CurrentVersion int       `gorm:"column:current_version;not null;default:0;comment:当前生效版本号（0=尚无版本）"`
+	Remark         *string   `gorm:"size:255;comment:备注"`
+	IsDeleted      int       `gorm:"column:is_deleted;not null;default:0;index;comment:软删：0=有效 1=删除"`
+	Version        int       `gorm:"not null;default:1;comment:行版本号（乐观锁，与配方版本无关）"`
+	CreatedAt      time.Time `gorm:"autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime"`
+}
+
+func (r *Recipe) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.Version == 0 {
+		r.Version = 1
+	}
+	if r.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Recipe) TableName() string { return "menu_recipe" }
+
+// Version 配方版本，创建后不可修改；修改配方即新增版本
+type Version struct {
+	ID         string    `gorm:"primaryKey;type:char(36)"`
+	RecipeID   string    `gorm:"column:recipe_id;type:char(36);not null;uniqueIndex:uk_recipe_version,priority:1;comment:配方ID（menu_recipe.id）"`
+	Version    int       `gorm:"not null;uniqueIndex:uk_recipe_version,priority:2;comment:版本号（从1递增）"`
+	Remark     *string   `gorm:"size:255;comment:版本说明"`
+	OperatorID *string   `gorm:"column:operator_id;type:char(36);comment:操作人ID（base_user.id）"`
+	CreatedAt  time.Time `gorm:"autoCreateTime"`
+
+	Lines []Line `gorm:"-" json:"lines"`
+}
+
+func (v *Version) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == "" {
+		v.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Version) TableName() string { return "menu_recipe_version" }
+
+// Line 配方用料行。QtyPerServing 为每份净用量；
+// 毛用量 = 净用量 / 出成率 / (1 - 损耗率)
+type Line struct {
+	ID            string          `gorm:"primaryKey;type:char(36)"`
+	VersionID     string          `gorm:"column:version_id;type:char(36);not null;index;comment:配方版本ID（menu_recipe_version.id）"`
+	GoodsID       string          `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）"`
+	QtyPerServing decimal.Decimal `gorm:"column:qty_per_serving;type:decimal(20,4);not null;comment:每份净用量"`
+	UnitID        string          `gorm:"column:unit_id;type:char(36);not null;comment:用量单位ID（base_unit.id）"`
+	YieldRate     decimal.Decimal `gorm:"column:yield_rate;type:decimal(6,4);not null;default:1;comment:出成率 (0,1]"`
+	LossRate      decimal.Decimal `gorm:"column:loss_rate;type:decimal(6,4);not null;default:0;comment:损耗率 [0,1)"`
+	Sort          int             `gorm:"not null;default:0;comment:排序码"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime"`
+}
+
+func (l *Line) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	return nil
+}
+
+func (Line) TableName() string { return "menu_recipe_line" }
+
+// GrossPerServing 每份毛用量（按行单位）
+func (l Line) GrossPerServing() decimal.Decimal {
+	yield := l.YieldRate
+	if !yield.IsPositive() {
+		yield = decimal.NewFromInt(1)
+	}
+	keep := decimal.NewFromInt(1).Sub(l.LossRate)
+	if !keep.IsPositive() {
+		keep = decimal.NewFromInt(1)
+	}
+	return l.QtyPerServing.DivRound(yield, 8).DivRound(keep, 8)
+}
+
+// CostLine 单行成本明细；Price 为空表示该商品尚无询价均价
+type CostLine struct {
+	GoodsID      string           `json:"goods_id"`
+	GrossQty     decimal.Decimal  `json:"gross_qty"`
+	UnitID       string           `json:"unit_id"`
+	GoodsUnitID  string           `json:"goods_unit_id"`
+	GoodsUnitQty *decimal.Decimal `json:"goods_unit_qty"`
+	Price        *decimal.Decimal `json:"price"`
+	PriceDate    *time.Time       `json:"price_date"`
+	Cost         *decimal.Decimal `json:"cost"`
+	Warning      string           `json:"warning,omitempty"`
+}
+
+// Cost 配方每份成本
+type Cost struct {
+	RecipeID   string          `json:"recipe_id"`
+	Version    int             `json:"version"`
+	Servings   int             `json:"servings"`
+	Lines      []CostLine      `json:"lines"`
+	Total      decimal.Decimal `json:"total"`
+	PerServing decimal.Decimal `json:"per_serving"`
+	Complete   bool            `json:"complete"` // 所有行均有价格且可换算
+}
+
+// Demand 商品需求（按商品单位汇总；无法换算时保留原单位）
+type Demand struct {
+	GoodsID  string          `json:"goods_id"`
+	UnitID   string          `json:"unit_id"`
+	Quantity decimal.Decimal `json:"quantity"`
+	Sources  []DemandSource  `json:"sources"`
+}
+
+// DemandSource 需求来源（便于追溯到餐次计划与菜品）
+type DemandSource struct {
+	PlanID   string          `json:"plan_id"`
+	PlanDate time.Time       `json:"plan_date"`
+	MealID   string          `json:"meal_id"`
+	DishName string          `json:"dish_name"`
+	RecipeID string          `json:"recipe_id"`
+	Version  int             `json:"version"`
+	Servings int             `json:"servings"`
+	Quantity decimal.Decimal `json:"quantity"`
+}
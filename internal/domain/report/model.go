@@ -0,0 +1,141 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 单据类型
+const (
+	KindInquiry    = "inquiry"    // 询价单
+	KindSettlement = "settlement" // 供应商结算对账单
+)
+
+func ValidKind(k string) bool {
+	return k == KindInquiry || k == KindSettlement
+}
+
+// 纸张与方向
+const (
+	PageA4 = "A4"
+	PageA5 = "A5"
+
+	Portrait  = "P"
+	Landscape = "L"
+)
+
+// ErrNoFont 未配置中文字体
+var ErrNoFont = errors.New("未配置中文字体（report.font_path），无法生成 PDF")
+
+// Layout 机构打印版式；每个机构每种单据一份，未配置时使用 DefaultLayout
+type Layout struct {
+	ID             string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID          string    `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_pl_org_kind,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	Kind           string    `gorm:"size:16;not null;uniqueIndex:uk_pl_org_kind,priority:2;comment:单据类型：inquiry/settlement" json:"kind"`
+	Title          *string   `gorm:"size:64;comment:标题（为空取单据默认标题）" json:"title"`
+	Subtitle       *string   `gorm:"size:128;comment:副标题（如单位全称）" json:"subtitle"`
+	Signers        string    `gorm:"size:255;not null;default:'';comment:签字栏，逗号分隔，如 询价人,复核人,负责人" json:"signers"`
+	PageSize       string    `gorm:"column:page_size;size:8;not null;default:A4;comment:纸张：A4/A5" json:"page_size"`
+	Orientation    string    `gorm:"size:1;not null;default:P;comment:方向：P=纵向 L=横向" json:"orientation"`
+	FontSize       int       `gorm:"column:font_size;not null;default:10;comment:正文字号（pt）" json:"font_size"`
+	ShowGuidePrice int       `gorm:"column:show_guide_price;not null;default:1;comment:询价单是否打印指导价：0=否 1=是" json:"show_guide_price"`
+	Footer         *string   `gorm:"size:255;comment:页脚说明" json:"footer"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (l *Layout) BeforeCreate(tx *gorm.DB) error {
+	if l.ID == "" {
+		l.ID = uuid.NewString()
+	}
+	if l.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Layout) TableName() string { return "print_layout" }
+
+// SignerList 签字栏列表（去除空项）
+func (l Layout) SignerList() []string {
+	var out []string
+	for _, s := range strings.Split(l.Signers, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// DefaultLayout 未配置版式时使用；询价单默认三人签字
+func DefaultLayout(orgID, kind string) Layout {
+	l := Layout{
+		OrgID:          orgID,
+		Kind:           kind,
+		PageSize:       PageA4,
+		Orientation:    Portrait,
+		FontSize:       10,
+		ShowGuidePrice: 1,
+	}
+	switch kind {
+	case KindInquiry:
+		l.Signers = "询价人,复核人,负责人"
+	case KindSettlement:
+		l.Signers = "供应商,经办人,审核人"
+	}
+	return l
+}
+
+// InquirySheet 询价单打印数据
+type InquirySheet struct {
+	OrgName string
+	Title   string
+	Date    time.Time
+	Status  string
+	Markets []string
+	Lines   []InquirySheetLine
+}
+
+type InquirySheetLine struct {
+	GoodsName  string
+	Spec       string
+	Unit       string
+	GuidePrice *decimal.Decimal
+	Prices     []*decimal.Decimal // 与 InquirySheet.Markets 一一对应，未录入为 nil
+	AvgPrice   *decimal.Decimal
+}
+
+// Settlement 供应商结算对账单打印数据（已提交采购单，按期望到货日期统计）
+type Settlement struct {
+	OrgName      string
+	SupplierName string
+	DateFrom     time.Time
+	DateTo       time.Time
+	Lines        []SettlementLine
+	Total        decimal.Decimal
+}
+
+type SettlementLine struct {
+	Date        time.Time       `gorm:"column:expected_date"`
+	OrderID     string          `gorm:"column:order_id"`
+	GoodsName   string          `gorm:"column:goods_name"`
+	Unit        string          `gorm:"column:unit_name"`
+	Quantity    decimal.Decimal `gorm:"column:quantity"`
+	UnitPrice   decimal.Decimal `gorm:"column:unit_price"`
+	FloatRatio  decimal.Decimal `gorm:"column:float_ratio"`
+	SettlePrice decimal.Decimal `gorm:"column:settle_price"`
+	Amount      decimal.Decimal `gorm:"column:amount"`
+}
+
+// GoodsInfo 打印用商品名称/规格/单位
+type GoodsInfo struct {
+	ID   string `gorm:"column:id"`
+	Name string `gorm:"column:name"`
+	Spec string `gorm:"column:spec_name"`
+	Unit string `gorm:"column:unit_name"`
+}
@@ -0,0 +1,200 @@
+package scorecard
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 人工评价类型
+const (
+	KindRating    = "rating"    // 评分（1~5 分）
+	KindComplaint = "complaint" // 投诉
+)
+
+// ErrSnapshotExists 该期间已生成评分快照（需指定重算才会覆盖）
+var ErrSnapshotExists = errors.New("该期间已生成评分快照")
+
+// Rating 供应商人工评价：评分或投诉，按 RatedOn 归入评分期间
+type Rating struct {
+	ID         string    `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID      string    `gorm:"column:org_id;type:char(36);not null;index:idx_sr_org_date,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	SupplierID string    `gorm:"column:supplier_id;type:char(36);not null;index;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	Kind       string    `gorm:"size:16;not null;comment:类型：rating=评分 complaint=投诉" json:"kind"`
+	Score      *int      `gorm:"comment:评分 1~5（仅评分）" json:"score"`
+	RatedOn    time.Time `gorm:"column:rated_on;type:date;not null;index:idx_sr_org_date,priority:2;comment:评价日期" json:"rated_on"`
+	Content    *string   `gorm:"size:512;comment:评价/投诉内容" json:"content"`
+	OperatorID *string   `gorm:"column:operator_id;type:char(36);comment:登记人ID（base_user.id）" json:"operator_id"`
+	IsDeleted  int       `gorm:"column:is_deleted;not null;default:0;comment:软删：0=有效 1=删除" json:"is_deleted"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (r *Rating) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.OrgID == "" || r.SupplierID == "" {
+		return errors.New("OrgID/SupplierID 不能为空")
+	}
+	return nil
+}
+
+func (Rating) TableName() string { return "supplier_rating" }
+
+// Snapshot 供应商某期间的评分快照；生成后不随业务数据变化，保证历史排名稳定
+type Snapshot struct {
+	ID             string           `gorm:"primaryKey;type:char(36)" json:"id"`
+	OrgID          string           `gorm:"column:org_id;type:char(36);not null;uniqueIndex:uk_ss_period_supplier,priority:1;comment:机构ID（base_org.id）" json:"org_id"`
+	PeriodFrom     time.Time        `gorm:"column:period_from;type:date;not null;uniqueIndex:uk_ss_period_supplier,priority:2;comment:期间起（含）" json:"period_from"`
+	PeriodTo       time.Time        `gorm:"column:period_to;type:date;not null;uniqueIndex:uk_ss_period_supplier,priority:3;comment:期间止（含）" json:"period_to"`
+	SupplierID     string           `gorm:"column:supplier_id;type:char(36);not null;uniqueIndex:uk_ss_period_supplier,priority:4;index;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	SupplierName   string           `gorm:"column:supplier_name;size:128;not null;comment:供应商名称快照" json:"supplier_name"`
+	DueOrders      int              `gorm:"column:due_orders;not null;default:0;comment:应到货采购单数" json:"due_orders"`
+	OnTimeOrders   int              `gorm:"column:on_time_orders;not null;default:0;comment:按期到货采购单数" json:"on_time_orders"`
+	OnTimeRate     *decimal.Decimal `gorm:"column:on_time_rate;type:decimal(6,4);comment:准时到货率" json:"on_time_rate"`
+	ReceivedLines  int              `gorm:"column:received_lines;not null;default:0;comment:已收货明细数" json:"received_lines"`
+	WeightVariance *decimal.Decimal `gorm:"column:weight_variance;type:decimal(8,4);comment:平均重量偏差率 |实收-订购|/订购" json:"weight_variance"`
+	Quotes         int              `gorm:"column:quotes;not null;default:0;comment:可比报价数" json:"quotes"`
+	PriceIndex     *decimal.Decimal `gorm:"column:price_index;type:decimal(8,4);comment:价格指数 结算价/询价均价（<1 低于均价）" json:"price_index"`
+	Complaints     int              `gorm:"not null;default:0;comment:投诉次数" json:"complaints"`
+	Ratings        int              `gorm:"not null;default:0;comment:评分次数" json:"ratings"`
+	AvgRating      *decimal.Decimal `gorm:"column:avg_rating;type:decimal(4,2);comment:平均评分" json:"avg_rating"`
+	Score          decimal.Decimal  `gorm:"type:decimal(5,2);not null;comment:综合得分（0~100）" json:"score"`
+	Rank           int              `gorm:"column:rank_no;not null;comment:机构内排名" json:"rank"`
+	ComputedAt     time.Time        `gorm:"column:computed_at;not null;comment:计算时间" json:"computed_at"`
+	ComputedBy     *string          `gorm:"column:computed_by;type:char(36);comment:计算人ID（空=定时任务）" json:"computed_by"`
+}
+
+func (s *Snapshot) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == "" {
+		s.ID = uuid.NewString()
+	}
+	if s.OrgID == "" || s.SupplierID == "" {
+		return errors.New("OrgID/SupplierID 不能为空")
+	}
+	return nil
+}
+
+func (Snapshot) TableName() string { return "supplier_score_snapshot" }
+
+// Period 已生成快照的评分期间
+type Period struct {
+	PeriodFrom time.Time `json:"period_from"`
+	PeriodTo   time.Time `json:"period_to"`
+	Suppliers  int       `json:"suppliers"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// Metrics 供应商某期间的原始统计
+type Metrics struct {
+	SupplierID    string
+	SupplierName  string
+	DueOrders     int
+	OnTimeOrders  int
+	ReceivedLines int
+	VarianceSum   decimal.Decimal // Σ |实收-订购|/订购
+	Quotes        int
+	PriceRatioSum decimal.Decimal // Σ 结算价/询价均价
+	Complaints    int
+	Ratings       int
+	RatingSum     int
+}
+
+// Active 期间内是否有可评分的业务数据
+func (m Metrics) Active() bool {
+	return m.DueOrders+m.ReceivedLines+m.Quotes+m.Complaints+m.Ratings > 0
+}
+
+// 各项得分权重；无数据的项不参与加权（投诉项始终参与）
+const (
+	WeightOnTime    = 30
+	WeightVariance  = 25
+	WeightPrice     = 25
+	WeightComplaint = 10
+	WeightRating    = 10
+)
+
+// 评分口径
+var (
+	maxVariance     = decimal.RequireFromString("0.2") // 重量偏差达 20% 得 0 分
+	priceBest       = decimal.RequireFromString("0.8") // 价格指数 ≤0.8 得满分
+	priceSpan       = decimal.RequireFromString("0.4") // 价格指数 ≥1.2 得 0 分
+	complaintDeduct = decimal.NewFromInt(20)           // 每次投诉扣 20 分
+	hundred         = decimal.NewFromInt(100)
+)
+
+// Evaluate 由原始统计计算各项指标与综合得分（排名由 Rank 填写）
+func Evaluate(m Metrics) Snapshot {
+	s := Snapshot{
+		SupplierID:    m.SupplierID,
+		SupplierName:  m.SupplierName,
+		DueOrders:     m.DueOrders,
+		OnTimeOrders:  m.OnTimeOrders,
+		ReceivedLines: m.ReceivedLines,
+		Quotes:        m.Quotes,
+		Complaints:    m.Complaints,
+		Ratings:       m.Ratings,
+	}
+	var sum, weights decimal.Decimal
+	add := func(score decimal.Decimal, weight int64) {
+		w := decimal.NewFromInt(weight)
+		sum = sum.Add(clamp(score).Mul(w))
+		weights = weights.Add(w)
+	}
+
+	if m.DueOrders > 0 {
+		rate := decimal.NewFromInt(int64(m.OnTimeOrders)).Div(decimal.NewFromInt(int64(m.DueOrders))).Round(4)
+		s.OnTimeRate = &rate
+		add(rate.Mul(hundred), WeightOnTime)
+	}
+	if m.ReceivedLines > 0 {
+		v := m.VarianceSum.Div(decimal.NewFromInt(int64(m.ReceivedLines))).Round(4)
+		s.WeightVariance = &v
+		add(hundred.Sub(v.Div(maxVariance).Mul(hundred)), WeightVariance)
+	}
+	if m.Quotes > 0 {
+		idx := m.PriceRatioSum.Div(decimal.NewFromInt(int64(m.Quotes))).Round(4)
+		s.PriceIndex = &idx
+		add(hundred.Sub(idx.Sub(priceBest).Div(priceSpan).Mul(hundred)), WeightPrice)
+	}
+	add(hundred.Sub(complaintDeduct.Mul(decimal.NewFromInt(int64(m.Complaints)))), WeightComplaint)
+	if m.Ratings > 0 {
+		avg := decimal.NewFromInt(int64(m.RatingSum)).Div(decimal.NewFromInt(int64(m.Ratings))).Round(2)
+		s.AvgRating = &avg
+		add(avg.Div(decimal.NewFromInt(5)).Mul(hundred), WeightRating)
+	}
+	s.Score = sum.Div(weights).Round(2)
+	return s
+}
+
+// Rank 按得分降序排名（同分同名次），同分按供应商名称排列
+func Rank(list []Snapshot) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if c := list[i].Score.Cmp(list[j].Score); c != 0 {
+			return c > 0
+		}
+		return list[i].SupplierName < list[j].SupplierName
+	})
+	for i := range list {
+		if i > 0 && list[i].Score.Equal(list[i-1].Score) {
+			list[i].Rank = list[i-1].Rank
+		} else {
+			list[i].Rank = i + 1
+		}
+	}
+}
+
+func clamp(v decimal.Decimal) decimal.Decimal {
+	switch {
+	case v.IsNegative():
+		return decimal.Zero
+	case v.GreaterThan(hundred):
+		return hundred
+	}
+	return v
+}
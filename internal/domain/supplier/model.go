@@ -6,11 +6,22 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 
+	"hdzk.cn/foodapp/internal/domain/coderule"
 	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
+// 供应商状态
+const (
+	StatusActive   = 1 // 正常
+	StatusDisabled = 2 // 禁用
+)
+
+// ErrInactive 供应商不在合作期内或已停用，不能报价、下单
+var ErrInactive = errors.New("供应商不可用")
+
 type Supplier struct {
 	ID             string     `gorm:"primaryKey;type:char(36)"`
 	Name           string     `gorm:"size:128;not null;comment:供货商名称"`
@@ -27,15 +38,41 @@ type Supplier struct {
 	OrgID          string     `gorm:"column:org_id;type:char(36);not null;comment:所属机构ID"`
 	StartTime      *time.Time `gorm:"column:start_time"`
 	EndTime        *time.Time `gorm:"column:end_time"`
+	AutoDisabled   int        `gorm:"column:auto_disabled;not null;default:0;comment:是否因合同期由调度停用：0=否 1=是（到期后自动启用）"`
 	IsDeleted      int        `gorm:"column:is_deleted;not null;default:0;comment:软删标记：0=有效,1=已删除"`
+	Version        int        `gorm:"not null;default:1;comment:版本号（乐观锁）"`
 	CreatedAt      time.Time  `gorm:"autoCreateTime"`
 	UpdatedAt      time.Time  `gorm:"autoUpdateTime"`
 }
 
+// CheckActive 检查供应商在 at 时刻能否报价、下单；不可用时返回包装 ErrInactive 的原因
+func (s Supplier) CheckActive(at time.Time) error {
+	const layout = "2006-01-02 15:04"
+	switch {
+	case s.IsDeleted != 0:
+		return fmt.Errorf("%w：供应商已删除", ErrInactive)
+	case s.StartTime != nil && at.Before(*s.StartTime):
+		return fmt.Errorf("%w：合同期自 %s 开始", ErrInactive, s.StartTime.Format(layout))
+	case s.EndTime != nil && at.After(*s.EndTime):
+		return fmt.Errorf("%w：合同已于 %s 到期", ErrInactive, s.EndTime.Format(layout))
+	case s.Status != StatusActive && s.AutoDisabled == 0:
+		return fmt.Errorf("%w：供应商已停用", ErrInactive)
+	}
+	return nil
+}
+
+// InWindow 是否处于合同期内（未设置的起止时间视为不限）
+func (s Supplier) InWindow(at time.Time) bool {
+	return (s.StartTime == nil || !at.Before(*s.StartTime)) && (s.EndTime == nil || !at.After(*s.EndTime))
+}
+
 func (s *Supplier) BeforeCreate(tx *gorm.DB) error {
 	if s.ID == "" {
 		s.ID = uuid.NewString()
 	}
+	if s.Version == 0 {
+		s.Version = 1
+	}
 	if s.OrgID == "" {
 		return errors.New("OrgID(org_id) 不能为空")
 	}
@@ -63,12 +100,18 @@ func (s *Supplier) BeforeCreate(tx *gorm.DB) error {
 		s.Sort = base + suf
 	}
 
+	// code 按编码规则生成（未配置时为 org.code + 三位序号）
 	if s.Code == nil || (s.Code != nil && *s.Code == "") {
-		suf, err := utils.NextCodeSuffixByPrefix(tx, s.TableName(), s.OrgID, orgCode, true)
+		auto, _, err := coderule.Next(tx, coderule.Input{
+			Entity: coderule.EntitySupplier,
+			OrgID:  s.OrgID,
+			Table:  s.TableName(),
+			MaxLen: 64,
+			Values: coderule.Values{Org: orgCode},
+		})
 		if err != nil {
 			return err
 		}
-		auto := fmt.Sprintf("%s%03d", orgCode, suf)
 		s.Code = &auto
 	}
 
@@ -82,3 +125,28 @@ func (s *Supplier) BeforeCreate(tx *gorm.DB) error {
 }
 
 func (Supplier) TableName() string { return "supplier" }
+
+// FloatRatio 供应商浮动比例历史：自 EffectiveFrom 当日起生效，直至下一条生效；
+// 生效日期晚于今天的为计划调整。Supplier.FloatRatio 为当前生效值的镜像
+type FloatRatio struct {
+	ID            string          `gorm:"primaryKey;type:char(36)" json:"id"`
+	SupplierID    string          `gorm:"column:supplier_id;type:char(36);not null;uniqueIndex:uk_sfr_supplier_date,priority:1;comment:供应商ID（supplier.id）" json:"supplier_id"`
+	FloatRatio    decimal.Decimal `gorm:"column:float_ratio;type:decimal(6,4);not null;comment:浮动比例：结算价=合同价*float_ratio" json:"float_ratio"`
+	EffectiveFrom time.Time       `gorm:"column:effective_from;type:date;not null;uniqueIndex:uk_sfr_supplier_date,priority:2;comment:生效日期（含）" json:"effective_from"`
+	Remark        *string         `gorm:"size:255;comment:调整说明" json:"remark"`
+	OperatorID    *string         `gorm:"column:operator_id;type:char(36);comment:操作人ID" json:"operator_id"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (f *FloatRatio) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == "" {
+		f.ID = uuid.NewString()
+	}
+	if f.SupplierID == "" {
+		return errors.New("SupplierID(supplier_id) 不能为空")
+	}
+	return nil
+}
+
+func (FloatRatio) TableName() string { return "supplier_float_ratio" }
@@ -0,0 +1,114 @@
+package waste
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 报表分组维度
+const (
+	GroupOrg      = "org"
+	GroupCategory = "category"
+	GroupMeal     = "meal"
+	GroupReason   = "reason"
+	GroupGoods    = "goods"
+	GroupPeriod   = "period"
+)
+
+// 报表时间粒度
+const (
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+// Record 浪费记录（剩饭剩菜、变质等），可关联产生它的称重记录
+type Record struct {
+	ID         string          `gorm:"primaryKey;type:char(36)"`
+	OrgID      string          `gorm:"column:org_id;type:char(36);not null;index:idx_waste_org_time,priority:1;comment:机构ID（base_org.id）"`
+	GoodsID    string          `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）"`
+	ReasonID   string          `gorm:"column:reason_id;type:char(36);not null;index;comment:浪费原因ID（base_waste_reason.id）"`
+	Weight     decimal.Decimal `gorm:"type:decimal(20,3);not null;comment:浪费数量（按 unit_id 计）"`
+	UnitID     string          `gorm:"column:unit_id;type:char(36);not null;comment:单位ID（base_unit.id）"`
+	WastedAt   time.Time       `gorm:"column:wasted_at;not null;index:idx_waste_org_time,priority:2;comment:发生时间"`
+	MealID     *string         `gorm:"column:meal_id;type:char(36);index:idx_waste_meal,priority:2;comment:归属餐次ID（menu_meal.id）"`
+	MealDate   *time.Time      `gorm:"column:meal_date;type:date;index:idx_waste_meal,priority:1;comment:归属就餐日期（跨零点时段归前一天）"`
+	MealSource int             `gorm:"column:meal_source;not null;default:0;comment:归属来源：0=未归属 1=自动 2=人工"`
+	WeighingID *string         `gorm:"column:weighing_id;type:char(36);uniqueIndex:uk_waste_weighing;comment:来源称重记录ID（base_weighing_record.id）"`
+	MovementID *string         `gorm:"column:movement_id;type:char(36);comment:报损库存流水ID（inv_movement.id）"`
+	OperatorID *string         `gorm:"column:operator_id;type:char(36);comment:操作人ID（base_user.id）"`
+	Remark     *string         `gorm:"size:255;comment:备注"`
+	IsDeleted  int             `gorm:"column:is_deleted;not null;default:0;index;comment:软删：0=有效 1=删除"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time       `gorm:"autoUpdateTime"`
+}
+
+func (r *Record) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Record) TableName() string { return "base_waste_record" }
+
+// Agg 按 机构/品类/餐次/原因/商品/单位/日期 的原始汇总，日期优先取归属就餐日期
+type Agg struct {
+	OrgID      string
+	CategoryID *string
+	MealID     *string
+	ReasonID   string
+	GoodsID    string
+	UnitID     string
+	Day        time.Time
+	Weight     decimal.Decimal
+	Records    int64
+}
+
+// ReportRow 报表行；未参与分组的维度为空。
+// WeightKg 为换算到公斤的重量（无法换算的记录计入 Unconverted），
+// Cost 按询价均价估值（无价格的记录计入 Unpriced）
+type ReportRow struct {
+	OrgID       *string         `json:"org_id,omitempty"`
+	CategoryID  *string         `json:"category_id,omitempty"`
+	MealID      *string         `json:"meal_id,omitempty"`
+	ReasonID    *string         `json:"reason_id,omitempty"`
+	GoodsID     *string         `json:"goods_id,omitempty"`
+	Period      *string         `json:"period,omitempty"`
+	WeightKg    decimal.Decimal `json:"weight_kg"`
+	Cost        decimal.Decimal `json:"cost"`
+	Records     int64           `json:"records"`
+	Unconverted int64           `json:"unconverted"`
+	Unpriced    int64           `json:"unpriced"`
+}
+
+type Report struct {
+	DateFrom   time.Time   `json:"date_from"`
+	DateTo     time.Time   `json:"date_to"`
+	GroupBy    []string    `json:"group_by"`
+	Period     string      `json:"period,omitempty"`
+	PriceBasis string      `json:"price_basis"`
+	Rows       []ReportRow `json:"rows"`
+	Total      ReportRow   `json:"total"`
+}
+
+// PeriodLabel 日期所属统计周期：day=2006-01-02，week=ISO 周 2006-W01，month=2006-01
+func PeriodLabel(day time.Time, period string) string {
+	switch period {
+	case PeriodWeek:
+		y, w := day.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case PeriodMonth:
+		return day.Format("2006-01")
+	default:
+		return day.Format("2006-01-02")
+	}
+}
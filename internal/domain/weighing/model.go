@@ -0,0 +1,68 @@
+package weighing
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// 餐次归属来源
+const (
+	MealSourceNone   = 0 // 未归属（称重时刻不在任何供餐时段内）
+	MealSourceAuto   = 1 // 按供餐时段自动归属
+	MealSourceManual = 2 // 人工指定，重新归属时保持不变
+)
+
+// Record 智能秤称重记录
+type Record struct {
+	ID         string          `gorm:"primaryKey;type:char(36)"`
+	OrgID      string          `gorm:"column:org_id;type:char(36);not null;index:idx_weighing_org_time,priority:1;comment:机构ID（base_org.id）"`
+	ScaleID    *string         `gorm:"column:scale_id;type:char(36);index;comment:秤ID（base_smart_scale.id）"`
+	GoodsID    string          `gorm:"column:goods_id;type:char(36);not null;index;comment:商品ID（base_goods.id）"`
+	Weight     decimal.Decimal `gorm:"type:decimal(20,3);not null;comment:称重数量（按 unit_id 计）"`
+	UnitID     string          `gorm:"column:unit_id;type:char(36);not null;comment:单位ID（base_unit.id）"`
+	WeighedAt  time.Time       `gorm:"column:weighed_at;not null;index:idx_weighing_org_time,priority:2;comment:称重时间"`
+	MealID     *string         `gorm:"column:meal_id;type:char(36);index:idx_weighing_meal,priority:2;comment:归属餐次ID（menu_meal.id）"`
+	MealDate   *time.Time      `gorm:"column:meal_date;type:date;index:idx_weighing_meal,priority:1;comment:归属就餐日期（跨零点时段归前一天）"`
+	MealSource int             `gorm:"column:meal_source;not null;default:0;comment:归属来源：0=未归属 1=自动 2=人工"`
+	OperatorID *string         `gorm:"column:operator_id;type:char(36);comment:操作人ID（base_user.id）"`
+	Remark     *string         `gorm:"size:255;comment:备注"`
+	IsDeleted  int             `gorm:"column:is_deleted;not null;default:0;index;comment:软删：0=有效 1=删除"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime"`
+	UpdatedAt  time.Time       `gorm:"autoUpdateTime"`
+}
+
+func (r *Record) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == "" {
+		r.ID = uuid.NewString()
+	}
+	if r.OrgID == "" {
+		return errors.New("OrgID(org_id) 不能为空")
+	}
+	return nil
+}
+
+func (Record) TableName() string { return "base_weighing_record" }
+
+// MealConsumption 按餐次汇总的消耗，Headcount 取自当日餐次计划（无计划为 0）
+type MealConsumption struct {
+	MealDate    time.Time       `json:"meal_date"`
+	MealID      string          `json:"meal_id"`
+	GoodsID     string          `json:"goods_id"`
+	UnitID      string          `json:"unit_id"`
+	TotalWeight decimal.Decimal `json:"total_weight"`
+	Records     int64           `json:"records"`
+	Headcount   int             `json:"headcount"`
+	PerCapita   decimal.Decimal `json:"per_capita"`
+}
+
+// DailyQty 某商品某日称重合计（按 unit_id 计）
+type DailyQty struct {
+	Day     time.Time       `json:"day"`
+	GoodsID string          `json:"goods_id"`
+	UnitID  string          `json:"unit_id"`
+	Qty     decimal.Decimal `json:"qty"`
+}
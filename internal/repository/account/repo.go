@@ -18,7 +18,7 @@ type Repository interface {
 
 	// U
 	UpdatePasswordHash(ctx context.Context, id string, hash string) error
-	UpdateFields(ctx context.Context, id string, fields map[string]any) error
+	UpdateFields(ctx context.Context, id string, version int, fields map[string]any) error // 按期望版本号条件更新（乐观锁）
 
 	// D
 	SoftDelete(ctx context.Context, id string) error
@@ -10,6 +10,7 @@ import (
 	"gorm.io/gorm/clause"
 	domain "hdzk.cn/foodapp/internal/domain/account"
 	"hdzk.cn/foodapp/pkg/logger"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type GormRepo struct{ db *gorm.DB }
@@ -140,17 +141,16 @@ func (r *GormRepo) UpdatePasswordHash(ctx context.Context, id string, hash strin
 		Update("password_hash", hash).Error
 }
 
-func (r *GormRepo) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+func (r *GormRepo) UpdateFields(ctx context.Context, id string, version int, fields map[string]any) error {
 	if id == "" {
 		return errors.New("id 不能为空")
 	}
 	if len(fields) == 0 {
 		return errors.New("没有要更新项目")
 	}
-	return r.db.WithContext(ctx).
+	return utils.UpdateVersioned(r.db.WithContext(ctx).
 		Model(&domain.Account{}).
-		Where("id = ? AND is_deleted = 0", id).
-		Updates(fields).Error
+		Where("id = ? AND is_deleted = 0", id), version, fields)
 }
 
 // -------- D --------
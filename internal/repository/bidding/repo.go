@@ -0,0 +1,73 @@
+package bidding
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/bidding"
+)
+
+type ListParams struct {
+	OrgID     string
+	InquiryID *string
+	Status    *int
+	Page      int
+	PageSize  int
+}
+
+// UpdateParams 修改未开始的轮次；指针为空表示不修改
+type UpdateParams struct {
+	ID          string
+	Version     int // 期望版本号（乐观锁）
+	Title       *string
+	OpensAt     *time.Time
+	ClosesAt    *time.Time
+	SupplierIDs *[]string // 非空时整体替换受邀供应商
+	ClearTitle  bool
+}
+
+type AccessListParams struct {
+	RoundID   string
+	ActorType *string
+	Page      int
+	PageSize  int
+}
+
+type Repository interface {
+	// CreateRound 创建轮次及受邀供应商
+	CreateRound(ctx context.Context, m *domain.Round, supplierIDs []string) error
+	GetRound(ctx context.Context, id string) (*domain.Round, error)
+	ListRounds(ctx context.Context, p ListParams) ([]domain.Round, int64, error)
+	// UpdateRound 仅在 before 时刻仍未开始时修改（行锁复核），否则返回 ErrStarted
+	UpdateRound(ctx context.Context, p UpdateParams, before time.Time) error
+	// CancelRound 仅在 at 时刻尚未截止时取消，否则返回 ErrEnded/ErrCancelled
+	CancelRound(ctx context.Context, id string, at time.Time) error
+	// HasActiveRound 询价单在 at 时刻是否有未开始或报价中（未取消、未截止）的轮次
+	HasActiveRound(ctx context.Context, inquiryID string, at time.Time) (bool, error)
+
+	SupplierIDs(ctx context.Context, roundID string) ([]string, error)
+	IsInvited(ctx context.Context, roundID, supplierID string) (bool, error)
+	// SubmittedCount 已报价的供应商数
+	SubmittedCount(ctx context.Context, roundID string) (int64, error)
+
+	// SupplierRounds 供应商受邀的轮次（含本方已报价商品数），按截止时间倒序
+	SupplierRounds(ctx context.Context, supplierID string, page, pageSize int) ([]domain.RoundView, int64, error)
+	// Items 询价单商品及供应商在本轮的报价
+	Items(ctx context.Context, roundID, inquiryID, supplierID string) ([]domain.Item, error)
+
+	// SaveBids 锁定轮次行复核报价窗口后按商品覆盖写入；at 不在窗口内时返回 ErrNotOpen/ErrLate/ErrCancelled
+	SaveBids(ctx context.Context, roundID string, bids []domain.Bid, at time.Time) error
+	SupplierBids(ctx context.Context, roundID, supplierID string) ([]domain.Bid, error)
+	// Bids 全部报价（含商品、供应商名称），未排名
+	Bids(ctx context.Context, roundID string) ([]domain.RankedBid, error)
+
+	// SaveAwards 按商品覆盖授标，并记录轮次授标时间
+	SaveAwards(ctx context.Context, roundID string, awards []domain.Award, at time.Time) error
+	ListAwards(ctx context.Context, roundID string) ([]domain.Award, error)
+
+	LogAccess(ctx context.Context, m *domain.AccessLog) error
+	ListAccess(ctx context.Context, p AccessListParams) ([]domain.AccessLog, int64, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,320 @@
+package bidding
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/bidding"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) CreateRound(ctx context.Context, m *domain.Round, supplierIDs []string) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return createInvitees(tx, m.ID, supplierIDs)
+	})
+}
+
+func createInvitees(tx *gorm.DB, roundID string, supplierIDs []string) error {
+	if len(supplierIDs) == 0 {
+		return nil
+	}
+	list := make([]domain.Invitee, len(supplierIDs))
+	for i, id := range supplierIDs {
+		list[i] = domain.Invitee{RoundID: roundID, SupplierID: id}
+	}
+	return tx.Create(&list).Error
+}
+
+func (r *repo) GetRound(ctx context.Context, id string) (*domain.Round, error) {
+	var out domain.Round
+	err := utils.DB(ctx, r.db).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) ListRounds(ctx context.Context, p ListParams) ([]domain.Round, int64, error) {
+	var list []domain.Round
+	var total int64
+
+	q := utils.DB(ctx, r.db).Model(&domain.Round{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.InquiryID != nil {
+		q = q.Where("inquiry_id = ?", *p.InquiryID)
+	}
+	if p.Status != nil {
+		q = q.Where("status = ?", *p.Status)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("closes_at DESC, created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+// lockRound 行锁读取轮次
+func lockRound(tx *gorm.DB, id string) (*domain.Round, error) {
+	var m domain.Round
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_deleted = 0", id).First(&m).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *repo) UpdateRound(ctx context.Context, p UpdateParams, before time.Time) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		cur, err := lockRound(tx, p.ID)
+		if err != nil {
+			return err
+		}
+		switch cur.PhaseAt(before) {
+		case domain.PhaseCancelled:
+			return domain.ErrCancelled
+		case domain.PhasePending:
+		default:
+			return domain.ErrStarted
+		}
+		if cur.Version != p.Version {
+			return utils.ErrVersionConflict
+		}
+
+		updates := map[string]any{}
+		if p.Title != nil || p.ClearTitle {
+			updates["title"] = p.Title
+		}
+		if p.OpensAt != nil {
+			updates["opens_at"] = *p.OpensAt
+		}
+		if p.ClosesAt != nil {
+			updates["closes_at"] = *p.ClosesAt
+		}
+		// 替换受邀供应商同样递增版本号
+		if err := utils.UpdateVersioned(tx.Model(&domain.Round{}).Where("id = ?", p.ID), p.Version, updates); err != nil {
+			return err
+		}
+		if p.SupplierIDs != nil {
+			if err := tx.Where("round_id = ?", p.ID).Delete(&domain.Invitee{}).Error; err != nil {
+				return err
+			}
+			return createInvitees(tx, p.ID, *p.SupplierIDs)
+		}
+		return nil
+	})
+}
+
+func (r *repo) CancelRound(ctx context.Context, id string, at time.Time) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		cur, err := lockRound(tx, id)
+		if err != nil {
+			return err
+		}
+		switch cur.PhaseAt(at) {
+		case domain.PhaseCancelled:
+			return domain.ErrCancelled
+		case domain.PhaseClosed:
+			return domain.ErrEnded
+		}
+		return tx.Model(&domain.Round{}).Where("id = ?", id).
+			Updates(map[string]any{"status": domain.StatusCancelled, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+func (r *repo) HasActiveRound(ctx context.Context, inquiryID string, at time.Time) (bool, error) {
+	var n int64
+	err := utils.DB(ctx, r.db).Model(&domain.Round{}).
+		Where("inquiry_id = ? AND is_deleted = 0 AND status = ? AND closes_at >= ?", inquiryID, domain.StatusNormal, at).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) SupplierIDs(ctx context.Context, roundID string) ([]string, error) {
+	var ids []string
+	err := utils.DB(ctx, r.db).Model(&domain.Invitee{}).
+		Where("round_id = ?", roundID).
+		Order("created_at, supplier_id").
+		Pluck("supplier_id", &ids).Error
+	return ids, err
+}
+
+func (r *repo) IsInvited(ctx context.Context, roundID, supplierID string) (bool, error) {
+	var n int64
+	err := utils.DB(ctx, r.db).Model(&domain.Invitee{}).
+		Where("round_id = ? AND supplier_id = ?", roundID, supplierID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) SubmittedCount(ctx context.Context, roundID string) (int64, error) {
+	var n int64
+	err := utils.DB(ctx, r.db).Model(&domain.Bid{}).
+		Where("round_id = ?", roundID).
+		Distinct("supplier_id").
+		Count(&n).Error
+	return n, err
+}
+
+func (r *repo) SupplierRounds(ctx context.Context, supplierID string, page, pageSize int) ([]domain.RoundView, int64, error) {
+	var list []domain.RoundView
+	var total int64
+
+	q := utils.DB(ctx, r.db).Table("inquiry_round_supplier AS v").
+		Joins("JOIN inquiry_round AS r ON r.id = v.round_id").
+		Joins("JOIN base_price_inquiry AS i ON i.id = r.inquiry_id").
+		Where("v.supplier_id = ? AND r.is_deleted = 0 AND i.is_deleted = 0", supplierID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Select("r.id AS round_id, r.inquiry_id, i.inquiry_title, i.inquiry_date, r.title, r.opens_at, r.closes_at, r.status, " +
+		"(SELECT COUNT(*) FROM base_goods_avg_detail AS d WHERE d.inquiry_id = r.inquiry_id AND d.is_deleted = 0) AS items, " +
+		"(SELECT COUNT(*) FROM inquiry_round_bid AS b WHERE b.round_id = r.id AND b.supplier_id = v.supplier_id) AS bid").
+		Order("r.closes_at DESC, r.created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Scan(&list).Error
+	return list, total, err
+}
+
+func (r *repo) Items(ctx context.Context, roundID, inquiryID, supplierID string) ([]domain.Item, error) {
+	var list []domain.Item
+	err := utils.DB(ctx, r.db).Table("base_goods_avg_detail AS d").
+		Select("d.goods_id, COALESCE(g.name, '') AS goods_name, COALESCE(s.name, '') AS spec_name, "+
+			"COALESCE(u.name, '') AS unit_name, b.unit_price").
+		Joins("LEFT JOIN base_goods AS g ON g.id = d.goods_id").
+		Joins("LEFT JOIN base_spec AS s ON s.id = g.spec_id").
+		Joins("LEFT JOIN base_unit AS u ON u.id = g.unit_id").
+		Joins("LEFT JOIN inquiry_round_bid AS b ON b.round_id = ? AND b.goods_id = d.goods_id AND b.supplier_id = ?", roundID, supplierID).
+		Where("d.inquiry_id = ? AND d.is_deleted = 0", inquiryID).
+		Order("g.sort, g.name").
+		Scan(&list).Error
+	return list, err
+}
+
+func (r *repo) SaveBids(ctx context.Context, roundID string, bids []domain.Bid, at time.Time) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		cur, err := lockRound(tx, roundID)
+		if err != nil {
+			return err
+		}
+		switch cur.PhaseAt(at) {
+		case domain.PhaseCancelled:
+			return domain.ErrCancelled
+		case domain.PhasePending:
+			return domain.ErrNotOpen
+		case domain.PhaseClosed:
+			return domain.ErrLate
+		}
+		for i := range bids {
+			bids[i].RoundID = roundID
+			bids[i].SubmittedAt = at
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "round_id"}, {Name: "supplier_id"}, {Name: "goods_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"unit_price", "float_ratio", "settle_price", "submitted_by", "submitted_at"}),
+		}).Create(&bids).Error
+	})
+}
+
+func (r *repo) SupplierBids(ctx context.Context, roundID, supplierID string) ([]domain.Bid, error) {
+	var list []domain.Bid
+	err := utils.DB(ctx, r.db).
+		Where("round_id = ? AND supplier_id = ?", roundID, supplierID).
+		Order("goods_id").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) Bids(ctx context.Context, roundID string) ([]domain.RankedBid, error) {
+	var list []domain.RankedBid
+	err := utils.DB(ctx, r.db).Table("inquiry_round_bid AS b").
+		Select("b.id AS bid_id, b.goods_id, COALESCE(g.name, '') AS goods_name, b.supplier_id, "+
+			"COALESCE(s.name, '') AS supplier_name, b.unit_price, b.float_ratio, b.settle_price, b.submitted_at").
+		Joins("LEFT JOIN base_goods AS g ON g.id = b.goods_id").
+		Joins("LEFT JOIN supplier AS s ON s.id = b.supplier_id").
+		Where("b.round_id = ?", roundID).
+		Scan(&list).Error
+	return list, err
+}
+
+func (r *repo) SaveAwards(ctx context.Context, roundID string, awards []domain.Award, at time.Time) error {
+	if len(awards) == 0 {
+		return errors.New("授标明细不能为空")
+	}
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockRound(tx, roundID); err != nil {
+			return err
+		}
+		for i := range awards {
+			awards[i].RoundID = roundID
+			awards[i].AwardedAt = at
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "round_id"}, {Name: "goods_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"supplier_id", "bid_id", "unit_price", "settle_price",
+				"rank_no", "quote_line_id", "awarded_by", "awarded_at"}),
+		}).Create(&awards).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Round{}).Where("id = ?", roundID).
+			Updates(map[string]any{"awarded_at": at, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+func (r *repo) ListAwards(ctx context.Context, roundID string) ([]domain.Award, error) {
+	var list []domain.Award
+	err := utils.DB(ctx, r.db).
+		Where("round_id = ?", roundID).
+		Order("goods_id").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) LogAccess(ctx context.Context, m *domain.AccessLog) error {
+	return utils.DB(ctx, r.db).Create(m).Error
+}
+
+func (r *repo) ListAccess(ctx context.Context, p AccessListParams) ([]domain.AccessLog, int64, error) {
+	var list []domain.AccessLog
+	var total int64
+
+	q := utils.DB(ctx, r.db).Model(&domain.AccessLog{}).Where("round_id = ?", p.RoundID)
+	if p.ActorType != nil {
+		q = q.Where("actor_type = ?", *p.ActorType)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
@@ -10,10 +10,24 @@ import (
 type CategoryRepository interface {
 	Create(ctx context.Context, m *category.Category) error
 	Get(ctx context.Context, id string) (*category.Category, error)
-	List(ctx context.Context, keyword string, org_id string, page, pageSize int) ([]category.Category, int64, error)
-	Update(ctx context.Context, id string, name string, code *string, pinyin *string, sort *int, updateCode bool, updatePinyin bool, updateSort bool) error
+	List(ctx context.Context, keyword string, org_id string, parentID *string, page, pageSize int) ([]category.Category, int64, error)
+	ListAll(ctx context.Context, orgID string) ([]category.Category, error)
+	// LockAll 同 ListAll 并对 org 下全部有效品类加行锁；移动/改挂前在事务内调用，保证成环检查与更新看到同一棵树
+	LockAll(ctx context.Context, orgID string) ([]category.Category, error)
+	// Lock 对单个品类加行锁（含已软删的品类）
+	Lock(ctx context.Context, id string) (*category.Category, error)
+	Update(ctx context.Context, id string, version int, name string, code *string, pinyin *string, sort *int, updateCode bool, updatePinyin bool, updateSort bool) error
+	Move(ctx context.Context, id string, parentID *string) error
+	CountChildren(ctx context.Context, id string) (int64, error)
+	CountGoods(ctx context.Context, id string) (int64, error)
 	SoftDelete(ctx context.Context, id string) error
+	ReassignAndSoftDelete(ctx context.Context, id string, targetID string) error
+	// HardDelete 物理删除 id；已软删的下级品类改挂到 id 的上级，仍有（含已软删的）商品引用时拒绝
 	HardDelete(ctx context.Context, id string) error
+	// Reorder 按 ids 顺序重排 org 内品类的 sort（沿用原占用的 sort 值，见 utils.ReorderSorts）
+	Reorder(ctx context.Context, orgID string, ids []string) error
+	// InTx 在事务内执行 fn；已处于事务中时为嵌套事务（SAVEPOINT）
+	InTx(ctx context.Context, fn func(r CategoryRepository) error) error
 }
 
 func NewRepository(db *gorm.DB) CategoryRepository { return &categoryRepo{db: db} }
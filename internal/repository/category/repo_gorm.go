@@ -3,13 +3,19 @@ package category
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	category "hdzk.cn/foodapp/internal/domain/category"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type categoryRepo struct{ db *gorm.DB }
 
+// 商品表（仅用于统计/改挂品类，避免 import goods 领域）
+const goodsTable = "base_goods"
+
 func (r *categoryRepo) Create(ctx context.Context, m *category.Category) error {
 	return r.db.WithContext(ctx).Create(m).Error
 }
@@ -25,11 +31,19 @@ func (r *categoryRepo) Get(ctx context.Context, id string) (*category.Category,
 	return &out, nil
 }
 
-func (r *categoryRepo) List(ctx context.Context, keyword string, org_id string, page, pageSize int) ([]category.Category, int64, error) {
+// List parentID: nil=不过滤；指向空串=仅顶级；否则=该品类的直接下级
+func (r *categoryRepo) List(ctx context.Context, keyword string, org_id string, parentID *string, page, pageSize int) ([]category.Category, int64, error) {
 	var list []category.Category
 	var total int64
 	q := r.db.WithContext(ctx).Model(&category.Category{}).
 		Where("is_deleted = 0 AND org_id = ?", org_id)
+	if parentID != nil {
+		if *parentID == "" {
+			q = q.Where("parent_id IS NULL")
+		} else {
+			q = q.Where("parent_id = ?", *parentID)
+		}
+	}
 	if keyword != "" {
 		pattern := "%" + keyword + "%"
 		q = q.Where("(name LIKE ? OR code LIKE ? OR pinyin LIKE ?)", pattern, pattern, pattern)
@@ -49,7 +63,40 @@ func (r *categoryRepo) List(ctx context.Context, keyword string, org_id string,
 	return list, total, err
 }
 
-func (r *categoryRepo) Update(ctx context.Context, id string, name string, code *string, pinyin *string, sort *int, updateCode bool, updatePinyin bool, updateSort bool) error {
+func (r *categoryRepo) ListAll(ctx context.Context, orgID string) ([]category.Category, error) {
+	var list []category.Category
+	err := r.db.WithContext(ctx).Model(&category.Category{}).
+		Where("is_deleted = 0 AND org_id = ?", orgID).
+		Order("sort ASC").
+		Order("name ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *categoryRepo) LockAll(ctx context.Context, orgID string) ([]category.Category, error) {
+	var list []category.Category
+	err := r.db.WithContext(ctx).Model(&category.Category{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("is_deleted = 0 AND org_id = ?", orgID).
+		Order("sort ASC").
+		Order("name ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *categoryRepo) Lock(ctx context.Context, id string) (*category.Category, error) {
+	var out category.Category
+	err := r.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).
+		First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *categoryRepo) Update(ctx context.Context, id string, version int, name string, code *string, pinyin *string, sort *int, updateCode bool, updatePinyin bool, updateSort bool) error {
 	updates := map[string]any{
 		"name": name,
 	}
@@ -72,9 +119,34 @@ func (r *categoryRepo) Update(ctx context.Context, id string, name string, code
 			updates["sort"] = *sort
 		}
 	}
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&category.Category{}).
+		Where("id = ? AND is_deleted = 0", id), version, updates)
+}
+
+func (r *categoryRepo) Move(ctx context.Context, id string, parentID *string) error {
+	var v any
+	if parentID != nil {
+		v = *parentID
+	}
 	return r.db.WithContext(ctx).Model(&category.Category{}).
 		Where("id = ? AND is_deleted = 0", id).
-		Updates(updates).Error
+		Updates(map[string]any{"parent_id": v, "version": gorm.Expr("version + 1")}).Error
+}
+
+func (r *categoryRepo) CountChildren(ctx context.Context, id string) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&category.Category{}).
+		Where("parent_id = ? AND is_deleted = 0", id).
+		Count(&n).Error
+	return n, err
+}
+
+func (r *categoryRepo) CountGoods(ctx context.Context, id string) (int64, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Table(goodsTable).
+		Where("category_id = ? AND is_deleted = 0", id).
+		Count(&n).Error
+	return n, err
 }
 
 func (r *categoryRepo) SoftDelete(ctx context.Context, id string) error {
@@ -83,12 +155,72 @@ func (r *categoryRepo) SoftDelete(ctx context.Context, id string) error {
 		Update("is_deleted", 1).Error
 }
 
+// ReassignAndSoftDelete 同一事务内：下级品类与商品改挂到 targetID，再软删 id
+func (r *categoryRepo) ReassignAndSoftDelete(ctx context.Context, id string, targetID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&category.Category{}).
+			Where("parent_id = ? AND is_deleted = 0", id).
+			Updates(map[string]any{"parent_id": targetID, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		if err := tx.Table(goodsTable).
+			Where("category_id = ? AND is_deleted = 0", id).
+			Updates(map[string]any{"category_id": targetID, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&category.Category{}).
+			Where("id = ?", id).
+			Update("is_deleted", 1).Error
+	})
+}
+
 func (r *categoryRepo) HardDelete(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id 不能为空")
 	}
-	return r.db.WithContext(ctx).
-		Unscoped().
-		Where("id = ?", id).
-		Delete(&category.Category{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m category.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&m).Error; err != nil {
+			return err
+		}
+		// 软删商品仍通过外键引用品类，物理删除会失败，直接给出原因
+		var goods int64
+		if err := tx.Table(goodsTable).Where("category_id = ?", id).Count(&goods).Error; err != nil {
+			return err
+		}
+		if goods > 0 {
+			return fmt.Errorf("仍有 %d 个商品（含已删除）引用该品类，无法物理删除", goods)
+		}
+		// 已软删的下级品类改挂到上级，避免 parent_id 悬空；恢复后仍在原位置附近
+		var parent any
+		if m.ParentID != nil {
+			parent = *m.ParentID
+		}
+		if err := tx.Model(&category.Category{}).
+			Where("parent_id = ? AND is_deleted = 1", id).
+			Updates(map[string]any{"parent_id": parent, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id = ?", id).
+			Delete(&category.Category{}).Error
+	})
+}
+
+func (r *categoryRepo) Reorder(ctx context.Context, orgID string, ids []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, orgSort, err := utils.GetOrgCodeAndSortByID(ctx, tx, orgID, true)
+		if err != nil {
+			return fmt.Errorf("查询 org code/sort 失败: %w", err)
+		}
+		return utils.ReorderSorts(tx, category.Category{}.TableName(), orgID, orgSort*1000, ids)
+	})
+}
+
+func (r *categoryRepo) InTx(ctx context.Context, fn func(r CategoryRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&categoryRepo{db: tx})
+	})
 }
@@ -0,0 +1,35 @@
+package coderule
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/coderule"
+)
+
+type ListParams struct {
+	OrgID      *string // nil=全部；空串=仅全局规则
+	EntityType string
+}
+
+type PreviewParams struct {
+	EntityType string
+	OrgID      string
+	CategoryID string // goods：{cat} 取该品类 code
+	ParentID   string // category：{parent} 取该上级品类 code（为空取机构 code）
+}
+
+type Repository interface {
+	// Create 同机构同实体已有规则时返回 domain.ErrDuplicate
+	Create(ctx context.Context, m *domain.Rule) error
+	Get(ctx context.Context, id string) (*domain.Rule, error)
+	List(ctx context.Context, p ListParams) ([]domain.Rule, error)
+	// Update 版本号不符时返回 utils.ErrVersionConflict
+	Update(ctx context.Context, id string, version int, pattern string, reuseGaps int) error
+	// Delete 删除规则；计数器保留，规则恢复时继续使用
+	Delete(ctx context.Context, id string) error
+	// Preview 预览下一个编码（不推进计数器）；ok=false 表示未配置规则且沿用实体旧规则
+	Preview(ctx context.Context, p PreviewParams) (code string, ok bool, err error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,118 @@
+package coderule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	category "hdzk.cn/foodapp/internal/domain/category"
+	domain "hdzk.cn/foodapp/internal/domain/coderule"
+	dict "hdzk.cn/foodapp/internal/domain/dict"
+	goods "hdzk.cn/foodapp/internal/domain/goods"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+// 实体表与编码列长度
+var targets = map[string]struct {
+	table  string
+	maxLen int
+}{
+	domain.EntityGoods:       {goods.Goods{}.TableName(), 64},
+	domain.EntityCategory:    {category.Category{}.TableName(), 64},
+	domain.EntitySupplier:    {supplier.Supplier{}.TableName(), 64},
+	domain.EntityUnit:        {dict.Unit{}.TableName(), 32},
+	domain.EntitySpec:        {dict.Spec{}.TableName(), 32},
+	domain.EntityMealTime:    {dict.MealTime{}.TableName(), 32},
+	domain.EntityWasteReason: {dict.WasteReason{}.TableName(), 32},
+}
+
+func (r *repo) Create(ctx context.Context, m *domain.Rule) error {
+	err := r.db.WithContext(ctx).Create(m).Error
+	if isDuplicate(err) {
+		return domain.ErrDuplicate
+	}
+	return err
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Rule, error) {
+	var out domain.Rule
+	if err := r.db.WithContext(ctx).Where("id = ?", id).Take(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Rule, error) {
+	q := r.db.WithContext(ctx).Model(&domain.Rule{})
+	if p.OrgID != nil {
+		q = q.Where("org_id = ?", *p.OrgID)
+	}
+	if p.EntityType != "" {
+		q = q.Where("entity_type = ?", p.EntityType)
+	}
+	var list []domain.Rule
+	err := q.Order("org_id ASC, entity_type ASC").Find(&list).Error
+	return list, err
+}
+
+func (r *repo) Update(ctx context.Context, id string, version int, pattern string, reuseGaps int) error {
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&domain.Rule{}).Where("id = ?", id), version,
+		map[string]any{"pattern": pattern, "reuse_gaps": reuseGaps})
+}
+
+func (r *repo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&domain.Rule{}).Error
+}
+
+func (r *repo) Preview(ctx context.Context, p PreviewParams) (string, bool, error) {
+	t, ok := targets[p.EntityType]
+	if !ok {
+		return "", false, fmt.Errorf("不支持的实体类型: %s", p.EntityType)
+	}
+	db := r.db.WithContext(ctx)
+	in := domain.Input{Entity: p.EntityType, OrgID: p.OrgID, Table: t.table, MaxLen: t.maxLen}
+	if p.OrgID != "" {
+		orgCode, _, err := utils.GetOrgCodeAndSortByID(ctx, db, p.OrgID, false)
+		if err != nil {
+			return "", false, fmt.Errorf("机构不存在: %w", err)
+		}
+		in.Values.Org, in.Values.Parent = orgCode, orgCode
+	}
+	if p.CategoryID != "" {
+		code, err := categoryCode(db, p.CategoryID)
+		if err != nil {
+			return "", false, err
+		}
+		in.Values.Cat = code
+	}
+	if p.ParentID != "" {
+		code, err := categoryCode(db, p.ParentID)
+		if err != nil {
+			return "", false, err
+		}
+		in.Values.Parent = code
+	}
+	return domain.Peek(db, in)
+}
+
+func categoryCode(db *gorm.DB, id string) (string, error) {
+	var m category.Category
+	if err := db.Select("code").Where("id = ? AND is_deleted = 0", id).Take(&m).Error; err != nil {
+		return "", fmt.Errorf("品类不存在: %w", err)
+	}
+	if m.Code == nil {
+		return "", nil
+	}
+	return *m.Code, nil
+}
+
+// isDuplicate 唯一键冲突（MySQL 1062）
+func isDuplicate(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
+}
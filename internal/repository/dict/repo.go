@@ -3,6 +3,7 @@ package dict
 import (
 	"context"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	dict "hdzk.cn/foodapp/internal/domain/dict"
 )
@@ -12,22 +13,54 @@ type DictRepository interface {
 	CreateUnit(ctx context.Context, m *dict.Unit) error
 	GetUnit(ctx context.Context, id string) (*dict.Unit, error)
 	ListUnits(ctx context.Context, keyword string, page, pageSize int) ([]dict.Unit, int64, error)
-	UpdateUnit(ctx context.Context, id string, name string, code *string, sort int, updateCode bool) error
+	UpdateUnit(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error
 	DeleteUnit(ctx context.Context, id string) error
+	SetUnitConversion(ctx context.Context, id string, dimension *string, factor *decimal.Decimal, isBase int) error
+	GetBaseUnit(ctx context.Context, dimension string) (*dict.Unit, error)
+
+	// GoodsUnitConversion
+	// GoodsOrgID 有效商品的所属机构（不存在或已删除返回 gorm.ErrRecordNotFound）
+	GoodsOrgID(ctx context.Context, goodsID string) (string, error)
+	CreateGoodsUnitConversion(ctx context.Context, m *dict.GoodsUnitConversion) error
+	ListGoodsUnitConversions(ctx context.Context, goodsID string) ([]dict.GoodsUnitConversion, error)
+	DeleteGoodsUnitConversion(ctx context.Context, id string) error
 
 	// Spec
 	CreateSpec(ctx context.Context, m *dict.Spec) error
 	GetSpec(ctx context.Context, id string) (*dict.Spec, error)
 	ListSpecs(ctx context.Context, keyword string, page, pageSize int) ([]dict.Spec, int64, error)
-	UpdateSpec(ctx context.Context, id string, name string, code *string, sort int, updateCode bool) error
+	UpdateSpec(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error
 	DeleteSpec(ctx context.Context, id string) error
 
 	// MealTime
 	CreateMealTime(ctx context.Context, m *dict.MealTime) error
 	GetMealTime(ctx context.Context, id string) (*dict.MealTime, error)
 	ListMealTimes(ctx context.Context, keyword string, page, pageSize int) ([]dict.MealTime, int64, error)
-	UpdateMealTime(ctx context.Context, id string, name string, code *string, sort int, updateCode bool) error
+	UpdateMealTime(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error
 	DeleteMealTime(ctx context.Context, id string) error
+	SetMealTimeWindow(ctx context.Context, id string, start, end *string) error
+	ListAllMealTimes(ctx context.Context) ([]dict.MealTime, error)
+	// OrgTimeZone 有效机构的时区名（不存在或已删除返回 gorm.ErrRecordNotFound）
+	OrgTimeZone(ctx context.Context, orgID string) (string, error)
+
+	// WasteReason
+	CreateWasteReason(ctx context.Context, m *dict.WasteReason) error
+	GetWasteReason(ctx context.Context, id string) (*dict.WasteReason, error)
+	ListWasteReasons(ctx context.Context, keyword string, page, pageSize int) ([]dict.WasteReason, int64, error)
+	UpdateWasteReason(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error
+	DeleteWasteReason(ctx context.Context, id string) error
+
+	// MealOrgWindow
+	UpsertMealOrgWindow(ctx context.Context, m *dict.MealOrgWindow) error
+	ListMealOrgWindows(ctx context.Context, orgID string) ([]dict.MealOrgWindow, error)
+	// ListAllMealOrgWindows 全部机构的时段覆盖（校验默认时段修改对各机构的影响）
+	ListAllMealOrgWindows(ctx context.Context) ([]dict.MealOrgWindow, error)
+	DeleteMealOrgWindow(ctx context.Context, orgID, mealID string) error
+
+	// Reorder 按 ids 顺序重排字典表 tableName 的 sort（沿用原占用的 sort 值，见 utils.ReorderSorts）
+	Reorder(ctx context.Context, tableName string, ids []string) error
+	// InTx 在事务内执行 fn；已处于事务中时为嵌套事务（SAVEPOINT）
+	InTx(ctx context.Context, fn func(r DictRepository) error) error
 }
 
 func NewRepository(db *gorm.DB) DictRepository { return &dictRepo{db: db} }
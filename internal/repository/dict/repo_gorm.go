@@ -3,8 +3,11 @@ package dict
 import (
 	"context"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	dict "hdzk.cn/foodapp/internal/domain/dict"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type dictRepo struct{ db *gorm.DB }
@@ -45,7 +48,7 @@ func (r *dictRepo) ListUnits(ctx context.Context, keyword string, page, pageSize
 	return list, total, err
 }
 
-func (r *dictRepo) UpdateUnit(ctx context.Context, id string, name string, code *string, sort int, updateCode bool) error {
+func (r *dictRepo) UpdateUnit(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error {
 	updates := map[string]any{
 		"name": name,
 		"sort": sort,
@@ -57,9 +60,8 @@ func (r *dictRepo) UpdateUnit(ctx context.Context, id string, name string, code
 			updates["code"] = nil
 		}
 	}
-	return r.db.WithContext(ctx).Model(&dict.Unit{}).
-		Where("id = ? AND is_deleted = 0", id).
-		Updates(updates).Error
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&dict.Unit{}).
+		Where("id = ? AND is_deleted = 0", id), version, updates)
 }
 
 func (r *dictRepo) DeleteUnit(ctx context.Context, id string) error {
@@ -68,6 +70,118 @@ func (r *dictRepo) DeleteUnit(ctx context.Context, id string) error {
 		Update("is_deleted", 1).Error
 }
 
+// SetUnitConversion 设置量纲/换算系数；设为基准单位时同一事务内取消同量纲其它基准，
+// 并将同量纲其它单位的系数除以新基准在原基准下的系数（改为相对新基准）
+func (r *dictRepo) SetUnitConversion(ctx context.Context, id string, dimension *string, factor *decimal.Decimal, isBase int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if isBase == 1 && dimension != nil {
+			if err := rebase(tx, id, *dimension); err != nil {
+				return err
+			}
+		}
+		updates := map[string]any{"is_base": isBase, "version": gorm.Expr("version + 1")}
+		if dimension != nil {
+			updates["dimension"] = *dimension
+		} else {
+			updates["dimension"] = nil
+		}
+		if factor != nil {
+			updates["factor"] = *factor
+		} else {
+			updates["factor"] = nil
+		}
+		return tx.Model(&dict.Unit{}).
+			Where("id = ? AND is_deleted = 0", id).
+			Updates(updates).Error
+	})
+}
+
+// rebase 锁定同量纲单位后按新基准 id 重算其它单位的系数并取消原基准；量纲内尚无其它基准时不需重算
+func rebase(tx *gorm.DB, id, dimension string) error {
+	var cur dict.Unit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_deleted = 0", id).First(&cur).Error; err != nil {
+		return err
+	}
+	var others []dict.Unit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("dimension = ? AND id <> ? AND is_deleted = 0", dimension, id).
+		Find(&others).Error; err != nil {
+		return err
+	}
+	oldBase := false
+	for _, u := range others {
+		if u.IsBase == 1 {
+			oldBase = true
+		}
+	}
+	if !oldBase {
+		return nil
+	}
+	if cur.IsBase == 1 || cur.Dimension == nil || *cur.Dimension != dimension || cur.Factor == nil || !cur.Factor.IsPositive() {
+		return dict.ErrRebaseFactor
+	}
+	for _, u := range others {
+		updates := map[string]any{"is_base": 0, "version": gorm.Expr("version + 1")}
+		if u.Factor != nil {
+			updates["factor"] = u.Factor.DivRound(*cur.Factor, dict.ConversionScale)
+		}
+		if err := tx.Model(&dict.Unit{}).Where("id = ?", u.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *dictRepo) GetBaseUnit(ctx context.Context, dimension string) (*dict.Unit, error) {
+	var out dict.Unit
+	err := r.db.WithContext(ctx).
+		Where("dimension = ? AND is_base = 1 AND is_deleted = 0", dimension).
+		First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ---------- GoodsUnitConversion ----------
+func (r *dictRepo) GoodsOrgID(ctx context.Context, goodsID string) (string, error) {
+	var row struct{ OrgID string }
+	err := r.db.WithContext(ctx).Table("base_goods").
+		Select("org_id").
+		Where("id = ? AND is_deleted = 0", goodsID).
+		Take(&row).Error
+	return row.OrgID, err
+}
+
+func (r *dictRepo) OrgTimeZone(ctx context.Context, orgID string) (string, error) {
+	var row struct{ TimeZone string }
+	err := r.db.WithContext(ctx).Table("base_org").
+		Select("time_zone").
+		Where("id = ? AND is_deleted = 0", orgID).
+		Take(&row).Error
+	return row.TimeZone, err
+}
+
+func (r *dictRepo) CreateGoodsUnitConversion(ctx context.Context, m *dict.GoodsUnitConversion) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *dictRepo) ListGoodsUnitConversions(ctx context.Context, goodsID string) ([]dict.GoodsUnitConversion, error) {
+	var list []dict.GoodsUnitConversion
+	err := r.db.WithContext(ctx).
+		Where("goods_id = ?", goodsID).
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *dictRepo) DeleteGoodsUnitConversion(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).
+		Where("id = ?", id).
+		Delete(&dict.GoodsUnitConversion{}).Error
+}
+
 // ---------- Spec ----------
 func (r *dictRepo) CreateSpec(ctx context.Context, m *dict.Spec) error {
 	return r.db.WithContext(ctx).Create(m).Error
@@ -105,7 +219,7 @@ func (r *dictRepo) ListSpecs(ctx context.Context, keyword string, page, pageSize
 	return list, total, err
 }
 
-func (r *dictRepo) UpdateSpec(ctx context.Context, id string, name string, code *string, sort int, updateCode bool) error {
+func (r *dictRepo) UpdateSpec(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error {
 	updates := map[string]any{"name": name, "sort": sort}
 	if updateCode {
 		if code != nil {
@@ -114,9 +228,8 @@ func (r *dictRepo) UpdateSpec(ctx context.Context, id string, name string, code
 			updates["code"] = nil
 		}
 	}
-	return r.db.WithContext(ctx).Model(&dict.Spec{}).
-		Where("id = ? AND is_deleted = 0", id).
-		Updates(updates).Error
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&dict.Spec{}).
+		Where("id = ? AND is_deleted = 0", id), version, updates)
 }
 
 func (r *dictRepo) DeleteSpec(ctx context.Context, id string) error {
@@ -160,7 +273,7 @@ func (r *dictRepo) ListMealTimes(ctx context.Context, keyword string, page, page
 		Find(&list).Error
 	return list, total, err
 }
-func (r *dictRepo) UpdateMealTime(ctx context.Context, id string, name string, code *string, sort int, updateCode bool) error {
+func (r *dictRepo) UpdateMealTime(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error {
 	updates := map[string]any{"name": name, "sort": sort}
 	if updateCode {
 		if code != nil {
@@ -169,12 +282,133 @@ func (r *dictRepo) UpdateMealTime(ctx context.Context, id string, name string, c
 			updates["code"] = nil
 		}
 	}
-	return r.db.WithContext(ctx).Model(&dict.MealTime{}).
-		Where("id = ? AND is_deleted = 0", id).
-		Updates(updates).Error
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&dict.MealTime{}).
+		Where("id = ? AND is_deleted = 0", id), version, updates)
 }
 func (r *dictRepo) DeleteMealTime(ctx context.Context, id string) error {
 	return r.db.WithContext(ctx).Model(&dict.MealTime{}).
 		Where("id = ?", id).
 		Update("is_deleted", 1).Error
 }
+
+// SetMealTimeWindow 设置餐次默认时段；start/end 均为 nil 表示清除
+func (r *dictRepo) SetMealTimeWindow(ctx context.Context, id string, start, end *string) error {
+	res := r.db.WithContext(ctx).Model(&dict.MealTime{}).
+		Where("id = ? AND is_deleted = 0", id).
+		Updates(map[string]any{"start_time": start, "end_time": end, "version": gorm.Expr("version + 1")})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *dictRepo) ListAllMealTimes(ctx context.Context) ([]dict.MealTime, error) {
+	var list []dict.MealTime
+	err := r.db.WithContext(ctx).
+		Where("is_deleted = 0").
+		Order("sort asc, name asc").
+		Find(&list).Error
+	return list, err
+}
+
+// ---------- WasteReason ----------
+func (r *dictRepo) CreateWasteReason(ctx context.Context, m *dict.WasteReason) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *dictRepo) GetWasteReason(ctx context.Context, id string) (*dict.WasteReason, error) {
+	var out dict.WasteReason
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND is_deleted = 0", id).
+		First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+func (r *dictRepo) ListWasteReasons(ctx context.Context, keyword string, page, pageSize int) ([]dict.WasteReason, int64, error) {
+	var list []dict.WasteReason
+	var total int64
+	q := r.db.WithContext(ctx).Model(&dict.WasteReason{}).
+		Where("is_deleted = 0")
+	if keyword != "" {
+		pattern := "%" + keyword + "%"
+		q = q.Where("(name LIKE ? OR code LIKE ?)", pattern, pattern)
+	}
+	q.Count(&total)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("sort asc, name asc").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *dictRepo) UpdateWasteReason(ctx context.Context, id string, version int, name string, code *string, sort int, updateCode bool) error {
+	updates := map[string]any{"name": name, "sort": sort}
+	if updateCode {
+		if code != nil {
+			updates["code"] = *code
+		} else {
+			updates["code"] = nil
+		}
+	}
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&dict.WasteReason{}).
+		Where("id = ? AND is_deleted = 0", id), version, updates)
+}
+
+func (r *dictRepo) DeleteWasteReason(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&dict.WasteReason{}).
+		Where("id = ?", id).
+		Update("is_deleted", 1).Error
+}
+
+// ---------- MealOrgWindow ----------
+func (r *dictRepo) UpsertMealOrgWindow(ctx context.Context, m *dict.MealOrgWindow) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}, {Name: "meal_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"start_time", "end_time", "updated_at"}),
+	}).Create(m).Error
+}
+
+func (r *dictRepo) ListMealOrgWindows(ctx context.Context, orgID string) ([]dict.MealOrgWindow, error) {
+	var list []dict.MealOrgWindow
+	err := r.db.WithContext(ctx).
+		Where("org_id = ?", orgID).
+		Find(&list).Error
+	return list, err
+}
+
+func (r *dictRepo) ListAllMealOrgWindows(ctx context.Context) ([]dict.MealOrgWindow, error) {
+	var list []dict.MealOrgWindow
+	err := r.db.WithContext(ctx).
+		Order("org_id").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *dictRepo) DeleteMealOrgWindow(ctx context.Context, orgID, mealID string) error {
+	return r.db.WithContext(ctx).
+		Where("org_id = ? AND meal_id = ?", orgID, mealID).
+		Delete(&dict.MealOrgWindow{}).Error
+}
+
+// ---------- Batch ----------
+func (r *dictRepo) Reorder(ctx context.Context, tableName string, ids []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return utils.ReorderSorts(tx, tableName, "", 0, ids)
+	})
+}
+
+func (r *dictRepo) InTx(ctx context.Context, fn func(r DictRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&dictRepo{db: tx})
+	})
+}
@@ -3,12 +3,14 @@ package goods
 import (
 	"context"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 	domain "hdzk.cn/foodapp/internal/domain/goods"
 )
 
 type UpdateParams struct {
 	ID                string
+	Version           int // 期望版本号（乐观锁）
 	Name              *string
 	Code              *string
 	Pinyin            *string
@@ -23,13 +25,43 @@ type UpdateParams struct {
 	UpdateDescription bool
 }
 
+type BarcodeUpdateParams struct {
+	ID           string
+	UnitID       *string
+	PackQty      *decimal.Decimal
+	IsPrimary    *int
+	Remark       *string
+	UpdateRemark bool
+}
+
 type GoodsRepository interface {
 	CreateGoods(ctx context.Context, m *domain.Goods) error
 	GetGoods(ctx context.Context, id string) (*domain.Goods, error)
-	ListGoods(ctx context.Context, keyword string, orgID string, categoryID, specID, unitID *string, page, pageSize int) ([]domain.Goods, int64, error)
+	ListGoods(ctx context.Context, keyword string, orgID string, categoryID, specID, unitID *string, includeSubCategories bool, page, pageSize int) ([]domain.Goods, int64, error)
+	// UpdateGoods 版本号不符时返回 utils.ErrVersionConflict
 	UpdateGoods(ctx context.Context, params UpdateParams) error
 	SoftDeleteGoods(ctx context.Context, id string) error
 	HardDeleteGoods(ctx context.Context, id string) error
+	// GoodsUnits 返回商品ID → 商品单位ID（含已软删商品，便于历史数据换算）
+	GoodsUnits(ctx context.Context, ids []string) (map[string]string, error)
+	// ActiveGoodsUnits 同 GoodsUnits，但只返回 org 内未删除的商品（录入引用时校验用）
+	ActiveGoodsUnits(ctx context.Context, orgID string, ids []string) (map[string]string, error)
+	// ReorderGoods 按 ids 顺序重排 org 内商品的 sort（沿用原占用的 sort 值，见 utils.ReorderSorts）
+	ReorderGoods(ctx context.Context, orgID string, ids []string) error
+	// CreateBarcode 机构内条码已被有效商品占用时返回 domain.ErrBarcodeDuplicate；
+	// 已删除商品仍占用的条码自动软删释放。IsPrimary=1 时取消该商品其它条码的主条码标记
+	CreateBarcode(ctx context.Context, m *domain.Barcode) error
+	GetBarcode(ctx context.Context, id string) (*domain.Barcode, error)
+	ListBarcodes(ctx context.Context, goodsID string) ([]domain.Barcode, error)
+	UpdateBarcode(ctx context.Context, params BarcodeUpdateParams) error
+	// DeleteBarcode 软删条码，释放机构内唯一键
+	DeleteBarcode(ctx context.Context, id string) error
+	// FindByBarcode 按 机构 + 规范化条码 查找有效商品（走唯一索引 uk_goods_barcode_org_active）
+	FindByBarcode(ctx context.Context, orgID, barcode string) (*domain.Goods, *domain.Barcode, error)
+	// FindByCode 按 机构 + 商品编码 查找有效商品（内部 SKU 标签）
+	FindByCode(ctx context.Context, orgID, code string) (*domain.Goods, error)
+	// InTx 在事务内执行 fn；已处于事务中时为嵌套事务（SAVEPOINT）
+	InTx(ctx context.Context, fn func(r GoodsRepository) error) error
 }
 
 func NewRepository(db *gorm.DB) GoodsRepository { return &goodsRepo{db: db} }
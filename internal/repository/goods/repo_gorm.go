@@ -3,9 +3,14 @@ package goods
 import (
 	"context"
 	"errors"
+	"fmt"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	category "hdzk.cn/foodapp/internal/domain/category"
 	domain "hdzk.cn/foodapp/internal/domain/goods"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type goodsRepo struct{ db *gorm.DB }
@@ -25,7 +30,7 @@ func (r *goodsRepo) GetGoods(ctx context.Context, id string) (*domain.Goods, err
 	return &out, nil
 }
 
-func (r *goodsRepo) ListGoods(ctx context.Context, keyword string, orgID string, categoryID, specID, unitID *string, page, pageSize int) ([]domain.Goods, int64, error) {
+func (r *goodsRepo) ListGoods(ctx context.Context, keyword string, orgID string, categoryID, specID, unitID *string, includeSubCategories bool, page, pageSize int) ([]domain.Goods, int64, error) {
 	var list []domain.Goods
 	var total int64
 
@@ -33,7 +38,18 @@ func (r *goodsRepo) ListGoods(ctx context.Context, keyword string, orgID string,
 		Where("is_deleted = 0 AND org_id = ?", orgID)
 
 	if categoryID != nil && *categoryID != "" {
-		q = q.Where("category_id = ?", *categoryID)
+		if includeSubCategories {
+			var cats []category.Category
+			if err := r.db.WithContext(ctx).Model(&category.Category{}).
+				Select("id, parent_id").
+				Where("is_deleted = 0 AND org_id = ?", orgID).
+				Find(&cats).Error; err != nil {
+				return nil, 0, err
+			}
+			q = q.Where("category_id IN ?", category.DescendantIDs(cats, *categoryID))
+		} else {
+			q = q.Where("category_id = ?", *categoryID)
+		}
 	}
 	if specID != nil && *specID != "" {
 		q = q.Where("spec_id = ?", *specID)
@@ -103,26 +119,205 @@ func (r *goodsRepo) UpdateGoods(ctx context.Context, params UpdateParams) error
 			updates["description"] = nil
 		}
 	}
-	if len(updates) == 0 {
-		return nil
-	}
-	return r.db.WithContext(ctx).Model(&domain.Goods{}).
-		Where("id = ? AND is_deleted = 0", params.ID).
-		Updates(updates).Error
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&domain.Goods{}).
+		Where("id = ? AND is_deleted = 0", params.ID), params.Version, updates)
 }
 
 func (r *goodsRepo) SoftDeleteGoods(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Model(&domain.Goods{}).
-		Where("id = ?", id).
-		Update("is_deleted", 1).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 条码随商品软删：释放唯一键给其它商品登记，扫码不再命中已删除商品
+		if err := tx.Model(&domain.Barcode{}).
+			Where("goods_id = ? AND is_deleted = 0", id).
+			Update("is_deleted", 1).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Goods{}).
+			Where("id = ?", id).
+			Update("is_deleted", 1).Error
+	})
 }
 
 func (r *goodsRepo) HardDeleteGoods(ctx context.Context, id string) error {
 	if id == "" {
 		return errors.New("id 不能为空")
 	}
-	return r.db.WithContext(ctx).
-		Unscoped().
-		Where("id = ?", id).
-		Delete(&domain.Goods{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("goods_id = ?", id).Delete(&domain.Barcode{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id = ?", id).
+			Delete(&domain.Goods{}).Error
+	})
+}
+
+func (r *goodsRepo) GoodsUnits(ctx context.Context, ids []string) (map[string]string, error) {
+	return r.goodsUnits(r.db.WithContext(ctx), ids)
+}
+
+func (r *goodsRepo) ActiveGoodsUnits(ctx context.Context, orgID string, ids []string) (map[string]string, error) {
+	return r.goodsUnits(r.db.WithContext(ctx).Where("org_id = ? AND is_deleted = 0", orgID), ids)
+}
+
+func (r *goodsRepo) goodsUnits(q *gorm.DB, ids []string) (map[string]string, error) {
+	out := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var rows []domain.Goods
+	err := q.Select("id, unit_id").
+		Where("id IN ?", ids).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range rows {
+		out[g.ID] = g.UnitID
+	}
+	return out, nil
+}
+
+func (r *goodsRepo) ReorderGoods(ctx context.Context, orgID string, ids []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, orgSort, err := utils.GetOrgCodeAndSortByID(ctx, tx, orgID, true)
+		if err != nil {
+			return fmt.Errorf("查询 org code/sort 失败: %w", err)
+		}
+		return utils.ReorderSorts(tx, domain.Goods{}.TableName(), orgID, orgSort*1000, ids)
+	})
+}
+
+func (r *goodsRepo) CreateBarcode(ctx context.Context, m *domain.Barcode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur domain.Barcode
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("org_id = ? AND barcode = ? AND is_deleted = 0", m.OrgID, m.Barcode).
+			Take(&cur).Error
+		switch {
+		case err == nil:
+			var n int64
+			if err := tx.Model(&domain.Goods{}).
+				Where("id = ? AND is_deleted = 0", cur.GoodsID).
+				Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				return domain.ErrBarcodeDuplicate
+			}
+			if err := tx.Model(&cur).Update("is_deleted", 1).Error; err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		if m.IsPrimary == 1 {
+			if err := clearPrimary(tx, m.GoodsID); err != nil {
+				return err
+			}
+		}
+		err = tx.Create(m).Error
+		var me *mysql.MySQLError
+		if errors.As(err, &me) && me.Number == 1062 {
+			return domain.ErrBarcodeDuplicate
+		}
+		return err
+	})
+}
+
+func (r *goodsRepo) GetBarcode(ctx context.Context, id string) (*domain.Barcode, error) {
+	var out domain.Barcode
+	if err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).Take(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *goodsRepo) ListBarcodes(ctx context.Context, goodsID string) ([]domain.Barcode, error) {
+	var list []domain.Barcode
+	err := r.db.WithContext(ctx).
+		Where("goods_id = ? AND is_deleted = 0", goodsID).
+		Order("is_primary DESC").
+		Order("pack_qty ASC").
+		Order("created_at ASC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *goodsRepo) UpdateBarcode(ctx context.Context, params BarcodeUpdateParams) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur domain.Barcode
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_deleted = 0", params.ID).Take(&cur).Error; err != nil {
+			return err
+		}
+		updates := map[string]any{}
+		if params.UnitID != nil {
+			updates["unit_id"] = *params.UnitID
+		}
+		if params.PackQty != nil {
+			updates["pack_qty"] = *params.PackQty
+		}
+		if params.IsPrimary != nil {
+			if *params.IsPrimary == 1 {
+				if err := clearPrimary(tx, cur.GoodsID); err != nil {
+					return err
+				}
+			}
+			updates["is_primary"] = *params.IsPrimary
+		}
+		if params.UpdateRemark {
+			if params.Remark != nil {
+				updates["remark"] = *params.Remark
+			} else {
+				updates["remark"] = nil
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&domain.Barcode{}).Where("id = ?", params.ID).Updates(updates).Error
+	})
+}
+
+func (r *goodsRepo) DeleteBarcode(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Barcode{}).
+		Where("id = ? AND is_deleted = 0", id).
+		Update("is_deleted", 1).Error
+}
+
+func (r *goodsRepo) FindByBarcode(ctx context.Context, orgID, barcode string) (*domain.Goods, *domain.Barcode, error) {
+	var b domain.Barcode
+	if err := r.db.WithContext(ctx).
+		Where("org_id = ? AND active_barcode = ?", orgID, barcode).
+		Take(&b).Error; err != nil {
+		return nil, nil, err
+	}
+	g, err := r.GetGoods(ctx, b.GoodsID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, &b, nil
+}
+
+func (r *goodsRepo) FindByCode(ctx context.Context, orgID, code string) (*domain.Goods, error) {
+	var out domain.Goods
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND code = ? AND is_deleted = 0", orgID, code).
+		Take(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// clearPrimary 取消商品全部条码的主条码标记
+func clearPrimary(tx *gorm.DB, goodsID string) error {
+	return tx.Model(&domain.Barcode{}).
+		Where("goods_id = ? AND is_primary = 1 AND is_deleted = 0", goodsID).
+		Update("is_primary", 0).Error
+}
+
+func (r *goodsRepo) InTx(ctx context.Context, fn func(r GoodsRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&goodsRepo{db: tx})
+	})
 }
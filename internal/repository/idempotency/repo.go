@@ -0,0 +1,24 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/idempotency"
+)
+
+// Store 幂等键存储
+type Store interface {
+	// Begin 占用幂等键：无记录（或已过期）时写入处理中记录并返回 nil；
+	// 已完成且请求摘要一致时返回已保存的记录；摘要不一致返回 ErrMismatch，仍在处理中返回 ErrInProgress
+	Begin(ctx context.Context, rec *domain.Record, now time.Time) (*domain.Record, error)
+	// Complete 保存首次响应
+	Complete(ctx context.Context, id string, statusCode int, contentType string, body []byte) error
+	// Release 释放处理中的幂等键（首次请求失败，允许客户端重试）
+	Release(ctx context.Context, id string) error
+	// PurgeExpired 删除已过期记录，返回删除条数
+	PurgeExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+func NewStore(db *gorm.DB) Store { return &store{db: db} }
@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/idempotency"
+)
+
+type store struct{ db *gorm.DB }
+
+func (s *store) Begin(ctx context.Context, rec *domain.Record, now time.Time) (*domain.Record, error) {
+	var hit *domain.Record
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur domain.Record
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("actor_id = ? AND idem_key = ?", rec.ActorID, rec.IdemKey).
+			Take(&cur).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+		case err != nil:
+			return err
+		case !cur.ExpiresAt.After(now):
+			// 过期记录视同不存在
+			if err := tx.Delete(&domain.Record{}, "id = ?", cur.ID).Error; err != nil {
+				return err
+			}
+		case cur.RequestHash != rec.RequestHash:
+			return domain.ErrMismatch
+		case cur.Status != domain.StatusDone:
+			return domain.ErrInProgress
+		default:
+			hit = &cur
+			return nil
+		}
+		rec.Status = domain.StatusPending
+		return tx.Create(rec).Error
+	})
+	if isDuplicate(err) {
+		// 并发的同键请求抢先写入
+		return nil, domain.ErrInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	return hit, nil
+}
+
+func (s *store) Complete(ctx context.Context, id string, statusCode int, contentType string, body []byte) error {
+	return s.db.WithContext(ctx).Model(&domain.Record{}).Where("id = ?", id).Updates(map[string]any{
+		"status":       domain.StatusDone,
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"body":         body,
+	}).Error
+}
+
+func (s *store) Release(ctx context.Context, id string) error {
+	return s.db.WithContext(ctx).
+		Where("id = ? AND status = ?", id, domain.StatusPending).
+		Delete(&domain.Record{}).Error
+}
+
+func (s *store) PurgeExpired(ctx context.Context, now time.Time) (int64, error) {
+	res := s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&domain.Record{})
+	return res.RowsAffected, res.Error
+}
+
+// isDuplicate 唯一键冲突（MySQL 1062）
+func isDuplicate(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
+}
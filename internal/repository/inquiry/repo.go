@@ -6,24 +6,60 @@ import (
 
 	"gorm.io/gorm"
 	domain "hdzk.cn/foodapp/internal/domain/inquiry"
+	price "hdzk.cn/foodapp/internal/domain/price"
 )
 
 type UpdateParams struct {
 	ID           string
+	Version      int // 期望版本号（乐观锁）
 	InquiryTitle *string
 	InquiryDate  *time.Time
-	Market1      *string
-	Market2      *string
-	Market3      *string
+	Markets      []domain.InquiryMarket // 非 nil 时整体替换参与市场
+}
+
+type TemplateUpdateParams struct {
+	ID           string
+	Version      int // 期望版本号（乐观锁）
+	Name         *string
+	InquiryTitle *string
+	Remark       *string
+	Markets      []domain.TemplateMarket // 非 nil 时整体替换市场清单
+	Lines        []domain.TemplateLine   // 非 nil 时整体替换商品清单
 }
 
 type Repository interface {
+	// Create/CreateWithLines 同机构同日期已有同名有效询价单时返回 domain.ErrDuplicate
 	Create(ctx context.Context, m *domain.PriceInquiry) error
+	// CreateWithLines 同事务写入询价单、m.Markets 与明细（inquiry_id/org_id 由 repo 填充）
+	CreateWithLines(ctx context.Context, m *domain.PriceInquiry, lines []price.InquiryLine) error
+	// Get/List 返回的询价单带参与市场
 	Get(ctx context.Context, id string) (*domain.PriceInquiry, error)
+	// Lock 行锁读取有效询价单（不带参与市场）；须在 utils.Transactor 事务内调用，锁持有至事务结束
+	Lock(ctx context.Context, id string) (*domain.PriceInquiry, error)
 	List(ctx context.Context, orgID string, keyword string, dateFrom, dateTo *time.Time, page, pageSize int) ([]domain.PriceInquiry, int64, error)
+	// Update/SoftDelete/HardDelete 对已审核或归档的询价单返回 domain.ErrLocked；
+	// 移除已录入价格的市场返回 domain.ErrMarketInUse
 	Update(ctx context.Context, params UpdateParams) error
 	SoftDelete(ctx context.Context, id string) error
 	HardDelete(ctx context.Context, id string) error
+
+	// Transition 当前状态属于 from 时改为 h.ToStatus 并写入流转记录（h.FromStatus 由 repo 填充）；否则返回 domain.ErrStatus
+	Transition(ctx context.Context, id string, from []int, h *domain.History) error
+	ListHistory(ctx context.Context, inquiryID string) ([]domain.History, error)
+
+	// ListMarkets 询价单参与的市场（按 sort）
+	ListMarkets(ctx context.Context, inquiryID string) ([]domain.InquiryMarket, error)
+	// LegacyInquiries 返回机构内填写了旧版 market_1..3 但尚未关联市场的询价单（含已审核/归档）
+	LegacyInquiries(ctx context.Context, orgID string) ([]domain.PriceInquiry, error)
+	// AttachMarkets 为尚未关联市场的询价单写入市场（迁移用，不受锁定限制）；已有关联时不做任何修改
+	AttachMarkets(ctx context.Context, inquiryID string, markets []domain.InquiryMarket) error
+
+	// 询价模板；同机构模板名重复返回 domain.ErrTemplateDuplicate
+	CreateTemplate(ctx context.Context, m *domain.Template) error
+	GetTemplate(ctx context.Context, id string) (*domain.Template, error)
+	ListTemplates(ctx context.Context, orgID, keyword string, page, pageSize int) ([]domain.Template, int64, error)
+	UpdateTemplate(ctx context.Context, p TemplateUpdateParams) error
+	SoftDeleteTemplate(ctx context.Context, id string) error
 }
 
 func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -2,16 +2,53 @@ package inquiry
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	domain "hdzk.cn/foodapp/internal/domain/inquiry"
+	price "hdzk.cn/foodapp/internal/domain/price"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type repo struct{ db *gorm.DB }
 
 func (r *repo) Create(ctx context.Context, m *domain.PriceInquiry) error {
-	return r.db.WithContext(ctx).Create(m).Error
+	return r.CreateWithLines(ctx, m, nil)
+}
+
+func (r *repo) CreateWithLines(ctx context.Context, m *domain.PriceInquiry, lines []price.InquiryLine) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&domain.PriceInquiry{}).
+			Where("org_id = ? AND inquiry_title = ? AND inquiry_date = ? AND is_deleted = 0", m.OrgID, m.InquiryTitle, m.InquiryDate).
+			Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return domain.ErrDuplicate
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		if err := createMarkets(tx, m.ID, m.Markets); err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		for i := range lines {
+			lines[i].InquiryID, lines[i].OrgID = m.ID, &m.OrgID
+		}
+		return tx.Create(&lines).Error
+	})
+	if isDuplicate(err) {
+		return domain.ErrDuplicate
+	}
+	return err
 }
 
 func (r *repo) Get(ctx context.Context, id string) (*domain.PriceInquiry, error) {
@@ -20,6 +57,19 @@ func (r *repo) Get(ctx context.Context, id string) (*domain.PriceInquiry, error)
 	if err != nil {
 		return nil, err
 	}
+	if out.Markets, err = r.ListMarkets(ctx, out.ID); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) Lock(ctx context.Context, id string) (*domain.PriceInquiry, error) {
+	var out domain.PriceInquiry
+	err := utils.DB(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
 	return &out, nil
 }
 
@@ -52,7 +102,26 @@ func (r *repo) List(ctx context.Context, orgID string, keyword string, dateFrom,
 	err := q.Order("inquiry_date DESC").
 		Limit(pageSize).Offset((page - 1) * pageSize).
 		Find(&list).Error
-	return list, total, err
+	if err != nil || len(list) == 0 {
+		return list, total, err
+	}
+
+	ids := make([]string, len(list))
+	for i, m := range list {
+		ids[i] = m.ID
+	}
+	var markets []domain.InquiryMarket
+	if err := marketQuery(r.db.WithContext(ctx)).Where("im.inquiry_id IN ?", ids).Find(&markets).Error; err != nil {
+		return nil, 0, err
+	}
+	byInquiry := make(map[string][]domain.InquiryMarket, len(list))
+	for _, m := range markets {
+		byInquiry[m.InquiryID] = append(byInquiry[m.InquiryID], m)
+	}
+	for i := range list {
+		list[i].Markets = byInquiry[list[i].ID]
+	}
+	return list, total, nil
 }
 
 func (r *repo) Update(ctx context.Context, params UpdateParams) error {
@@ -63,29 +132,304 @@ func (r *repo) Update(ctx context.Context, params UpdateParams) error {
 	if params.InquiryDate != nil {
 		updates["inquiry_date"] = *params.InquiryDate
 	}
-	if params.Market1 != nil {
-		updates["market_1"] = *params.Market1
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockEditable(tx, params.ID); err != nil {
+			return err
+		}
+		// 替换参与市场同样递增版本号
+		if err := utils.UpdateVersioned(tx.Model(&domain.PriceInquiry{}).
+			Where("id = ? AND is_deleted = 0", params.ID), params.Version, updates); err != nil {
+			return err
+		}
+		if params.Markets == nil {
+			return nil
+		}
+		keep := make([]string, len(params.Markets))
+		for i, m := range params.Markets {
+			keep[i] = m.MarketID
+		}
+		var used int64
+		q := tx.Table(price.LinePriceTable).Where("inquiry_id = ?", params.ID)
+		if len(keep) > 0 {
+			q = q.Where("market_id NOT IN ?", keep)
+		}
+		if err := q.Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return domain.ErrMarketInUse
+		}
+		if err := tx.Where("inquiry_id = ?", params.ID).Delete(&domain.InquiryMarket{}).Error; err != nil {
+			return err
+		}
+		return createMarkets(tx, params.ID, params.Markets)
+	})
+}
+
+func (r *repo) SoftDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockEditable(tx, id); err != nil {
+			return err
+		}
+		return tx.Model(&domain.PriceInquiry{}).
+			Where("id = ?", id).Update("is_deleted", 1).Error
+	})
+}
+
+func (r *repo) HardDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockEditable(tx, id); err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id = ?", id).Delete(&domain.PriceInquiry{}).Error
+	})
+}
+
+func (r *repo) Transition(ctx context.Context, id string, from []int, h *domain.History) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cur, err := lock(tx, id)
+		if err != nil {
+			return err
+		}
+		if cur.IsDeleted != 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if !slices.Contains(from, cur.Status) {
+			return domain.ErrStatus
+		}
+		if err := tx.Model(&domain.PriceInquiry{}).Where("id = ?", id).
+			Updates(map[string]any{"status": h.ToStatus, "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+		h.InquiryID, h.FromStatus = id, cur.Status
+		return tx.Create(h).Error
+	})
+}
+
+func (r *repo) ListHistory(ctx context.Context, inquiryID string) ([]domain.History, error) {
+	var list []domain.History
+	err := r.db.WithContext(ctx).Where("inquiry_id = ?", inquiryID).
+		Order("created_at, id").Find(&list).Error
+	return list, err
+}
+
+func (r *repo) ListMarkets(ctx context.Context, inquiryID string) ([]domain.InquiryMarket, error) {
+	var list []domain.InquiryMarket
+	err := marketQuery(r.db.WithContext(ctx)).Where("im.inquiry_id = ?", inquiryID).Find(&list).Error
+	return list, err
+}
+
+func (r *repo) LegacyInquiries(ctx context.Context, orgID string) ([]domain.PriceInquiry, error) {
+	var list []domain.PriceInquiry
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND is_deleted = 0", orgID).
+		Where("((market_1 IS NOT NULL AND market_1 <> '') OR (market_2 IS NOT NULL AND market_2 <> '') OR (market_3 IS NOT NULL AND market_3 <> ''))").
+		Where("NOT EXISTS (SELECT 1 FROM " + domain.InquiryMarket{}.TableName() + " AS im WHERE im.inquiry_id = base_price_inquiry.id)").
+		Order("inquiry_date, created_at").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) AttachMarkets(ctx context.Context, inquiryID string, markets []domain.InquiryMarket) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lock(tx, inquiryID); err != nil {
+			return err
+		}
+		var n int64
+		if err := tx.Model(&domain.InquiryMarket{}).Where("inquiry_id = ?", inquiryID).Count(&n).Error; err != nil {
+			return err
+		}
+		if n > 0 {
+			return nil
+		}
+		return createMarkets(tx, inquiryID, markets)
+	})
+}
+
+// marketQuery 关联市场名称（含已软删的市场，保证历史询价可读）
+func marketQuery(db *gorm.DB) *gorm.DB {
+	return db.Table(domain.InquiryMarket{}.TableName() + " AS im").
+		Select("im.*, m.name").
+		Joins("LEFT JOIN base_market AS m ON m.id = im.market_id").
+		Order("im.sort, im.created_at")
+}
+
+func createMarkets(tx *gorm.DB, inquiryID string, markets []domain.InquiryMarket) error {
+	if len(markets) == 0 {
+		return nil
 	}
-	if params.Market2 != nil {
-		updates["market_2"] = *params.Market2
+	rows := make([]domain.InquiryMarket, len(markets))
+	for i, m := range markets {
+		rows[i] = domain.InquiryMarket{InquiryID: inquiryID, MarketID: m.MarketID, Sort: m.Sort}
 	}
-	if params.Market3 != nil {
-		updates["market_3"] = *params.Market3
+	return tx.Create(&rows).Error
+}
+
+// lock 行锁读取询价单（含已软删，供删除操作使用）
+func lock(tx *gorm.DB, id string) (*domain.PriceInquiry, error) {
+	var cur domain.PriceInquiry
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", id).First(&cur).Error
+	if err != nil {
+		return nil, err
 	}
-	if len(updates) == 0 {
-		return nil
+	return &cur, nil
+}
+
+func lockEditable(tx *gorm.DB, id string) (*domain.PriceInquiry, error) {
+	cur, err := lock(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if domain.Locked(cur.Status) {
+		return nil, domain.ErrLocked
 	}
-	return r.db.WithContext(ctx).Model(&domain.PriceInquiry{}).
-		Where("id = ? AND is_deleted = 0", params.ID).
-		Updates(updates).Error
+	return cur, nil
 }
 
-func (r *repo) SoftDelete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Model(&domain.PriceInquiry{}).
+func (r *repo) CreateTemplate(ctx context.Context, m *domain.Template) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := templateNameFree(tx, m.OrgID, m.Name, ""); err != nil {
+			return err
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		if err := createTemplateMarkets(tx, m.ID, m.Markets); err != nil {
+			return err
+		}
+		return createTemplateLines(tx, m.ID, m.Lines)
+	})
+}
+
+func (r *repo) GetTemplate(ctx context.Context, id string) (*domain.Template, error) {
+	var out domain.Template
+	db := r.db.WithContext(ctx)
+	if err := db.Where("id = ? AND is_deleted = 0", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Table(domain.TemplateMarket{}.TableName()+" AS tm").
+		Select("tm.*, m.name").
+		Joins("LEFT JOIN base_market AS m ON m.id = tm.market_id").
+		Where("tm.template_id = ?", id).Order("tm.sort, tm.created_at").
+		Find(&out.Markets).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("template_id = ?", id).Order("sort, created_at").Find(&out.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) ListTemplates(ctx context.Context, orgID, keyword string, page, pageSize int) ([]domain.Template, int64, error) {
+	var list []domain.Template
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Template{}).
+		Where("is_deleted = 0 AND org_id = ?", orgID)
+	if keyword != "" {
+		q = q.Where("name LIKE ?", "%"+keyword+"%")
+	}
+
+	q.Count(&total)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("name").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) UpdateTemplate(ctx context.Context, p TemplateUpdateParams) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur domain.Template
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_deleted = 0", p.ID).First(&cur).Error; err != nil {
+			return err
+		}
+		if cur.Version != p.Version {
+			return utils.ErrVersionConflict
+		}
+		updates := map[string]any{}
+		if p.Name != nil {
+			if err := templateNameFree(tx, cur.OrgID, *p.Name, cur.ID); err != nil {
+				return err
+			}
+			updates["name"] = *p.Name
+		}
+		if p.InquiryTitle != nil {
+			updates["inquiry_title"] = *p.InquiryTitle
+		}
+		if p.Remark != nil {
+			updates["remark"] = *p.Remark
+		}
+		if err := utils.UpdateVersioned(tx.Model(&domain.Template{}).Where("id = ?", cur.ID), p.Version, updates); err != nil {
+			return err
+		}
+		if p.Markets != nil {
+			if err := tx.Where("template_id = ?", cur.ID).Delete(&domain.TemplateMarket{}).Error; err != nil {
+				return err
+			}
+			if err := createTemplateMarkets(tx, cur.ID, p.Markets); err != nil {
+				return err
+			}
+		}
+		if p.Lines == nil {
+			return nil
+		}
+		if err := tx.Where("template_id = ?", cur.ID).Delete(&domain.TemplateLine{}).Error; err != nil {
+			return err
+		}
+		return createTemplateLines(tx, cur.ID, p.Lines)
+	})
+}
+
+func (r *repo) SoftDeleteTemplate(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Template{}).
 		Where("id = ?", id).Update("is_deleted", 1).Error
 }
 
-func (r *repo) HardDelete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Unscoped().
-		Where("id = ?", id).Delete(&domain.PriceInquiry{}).Error
+func templateNameFree(tx *gorm.DB, orgID, name, exceptID string) error {
+	var n int64
+	if err := tx.Model(&domain.Template{}).
+		Where("org_id = ? AND name = ? AND id <> ? AND is_deleted = 0", orgID, name, exceptID).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.ErrTemplateDuplicate
+	}
+	return nil
+}
+
+func createTemplateLines(tx *gorm.DB, templateID string, lines []domain.TemplateLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i].ID, lines[i].TemplateID = "", templateID
+	}
+	return tx.Create(&lines).Error
+}
+
+func createTemplateMarkets(tx *gorm.DB, templateID string, markets []domain.TemplateMarket) error {
+	if len(markets) == 0 {
+		return nil
+	}
+	rows := make([]domain.TemplateMarket, len(markets))
+	for i, m := range markets {
+		rows[i] = domain.TemplateMarket{TemplateID: templateID, MarketID: m.MarketID, Sort: m.Sort}
+	}
+	return tx.Create(&rows).Error
+}
+
+// isDuplicate 唯一键冲突（MySQL 1062）
+func isDuplicate(err error) bool {
+	var me *mysql.MySQLError
+	return errors.As(err, &me) && me.Number == 1062
 }
@@ -0,0 +1,66 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/inventory"
+)
+
+// ErrInsufficient 出库后结存为负
+var ErrInsufficient = errors.New("库存不足")
+
+type MovementParams struct {
+	OrgID      string
+	GoodsID    *string
+	Type       *string
+	WeighingID *string
+	DateFrom   *time.Time // 按 occurred_at 过滤，含当日
+	DateTo     *time.Time
+	Page       int
+	PageSize   int
+}
+
+type BalanceParams struct {
+	OrgID    string
+	GoodsID  *string
+	NonZero  bool
+	Page     int
+	PageSize int
+}
+
+type CountParams struct {
+	OrgID    string
+	Status   *int
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	PageSize int
+}
+
+type Repository interface {
+	// Post 在同一事务内追加流水并更新结存；出库导致结存为负时返回 ErrInsufficient
+	Post(ctx context.Context, items []*domain.Movement) error
+	ListMovements(ctx context.Context, params MovementParams) ([]domain.Movement, int64, error)
+	WeighingPosted(ctx context.Context, weighingID string) (bool, error)
+
+	GetBalance(ctx context.Context, orgID, goodsID string) (*domain.Balance, error)
+	ListBalances(ctx context.Context, params BalanceParams) ([]domain.Balance, int64, error)
+	// Balances 返回 goodsID → 结存；goodsIDs 为空时返回机构全部结存
+	Balances(ctx context.Context, orgID string, goodsIDs []string) (map[string]domain.Balance, error)
+	// RebuildBalances 按流水重算机构结存，返回重算的商品数
+	RebuildBalances(ctx context.Context, orgID string) (int, error)
+
+	CreateCount(ctx context.Context, m *domain.Count) error
+	GetCount(ctx context.Context, id string) (*domain.Count, error)
+	ListCounts(ctx context.Context, params CountParams) ([]domain.Count, int64, error)
+	// ReplaceCountLines 替换录入中盘点单的明细；version 为期望版本号（乐观锁）
+	ReplaceCountLines(ctx context.Context, countID string, version int, lines []domain.CountLine) error
+	// PostCount 锁定结存回填账面数，差异生成调整流水，盘点单置为已过账
+	PostCount(ctx context.Context, countID string, operatorID *string, at time.Time) (*domain.Count, error)
+	SoftDeleteDraftCount(ctx context.Context, id string) error
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,426 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/inventory"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+type balanceKey struct{ orgID, goodsID string }
+
+func pageOf(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+// lockBalances 按 (org_id, goods_id) 顺序加行锁，缺失的结存行先补零（避免并发插入与死锁）
+func lockBalances(tx *gorm.DB, keys []balanceKey, units map[balanceKey]string) (map[balanceKey]*domain.Balance, error) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].orgID != keys[j].orgID {
+			return keys[i].orgID < keys[j].orgID
+		}
+		return keys[i].goodsID < keys[j].goodsID
+	})
+	out := make(map[balanceKey]*domain.Balance, len(keys))
+	for _, k := range keys {
+		if _, ok := out[k]; ok {
+			continue
+		}
+		seed := &domain.Balance{OrgID: k.orgID, GoodsID: k.goodsID, UnitID: units[k], Quantity: decimal.Zero}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(seed).Error; err != nil {
+			return nil, err
+		}
+		var b domain.Balance
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("org_id = ? AND goods_id = ?", k.orgID, k.goodsID).
+			First(&b).Error; err != nil {
+			return nil, err
+		}
+		out[k] = &b
+	}
+	return out, nil
+}
+
+// apply 将一条流水计入已加锁的结存并写入流水
+func apply(tx *gorm.DB, b *domain.Balance, m *domain.Movement) error {
+	if b.UnitID != m.UnitID {
+		if !b.Quantity.IsZero() {
+			return fmt.Errorf("商品 %s 基准单位与现有结存不一致，请先按原单位清零", m.GoodsID)
+		}
+		b.UnitID = m.UnitID
+	}
+	next := b.Quantity.Add(m.Quantity)
+	if next.IsNegative() {
+		return fmt.Errorf("%w: 商品 %s 结存 %s，本次变动 %s", ErrInsufficient, m.GoodsID, b.Quantity.String(), m.Quantity.String())
+	}
+	m.BalanceAfter = next
+	if err := tx.Create(m).Error; err != nil {
+		return err
+	}
+	b.Quantity = next
+	if b.LastMovedAt == nil || m.OccurredAt.After(*b.LastMovedAt) {
+		t := m.OccurredAt
+		b.LastMovedAt = &t
+	}
+	return tx.Model(&domain.Balance{}).Where("id = ?", b.ID).Updates(map[string]any{
+		"unit_id":       b.UnitID,
+		"quantity":      b.Quantity,
+		"last_moved_at": b.LastMovedAt,
+	}).Error
+}
+
+func post(tx *gorm.DB, items []*domain.Movement) error {
+	keys := make([]balanceKey, 0, len(items))
+	units := make(map[balanceKey]string, len(items))
+	for _, m := range items {
+		k := balanceKey{m.OrgID, m.GoodsID}
+		keys = append(keys, k)
+		units[k] = m.UnitID
+	}
+	bals, err := lockBalances(tx, keys, units)
+	if err != nil {
+		return err
+	}
+	for _, m := range items {
+		if err := apply(tx, bals[balanceKey{m.OrgID, m.GoodsID}], m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repo) Post(ctx context.Context, items []*domain.Movement) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return post(tx, items)
+	})
+}
+
+func (r *repo) ListMovements(ctx context.Context, p MovementParams) ([]domain.Movement, int64, error) {
+	var list []domain.Movement
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Movement{}).Where("org_id = ?", p.OrgID)
+	if p.GoodsID != nil {
+		q = q.Where("goods_id = ?", *p.GoodsID)
+	}
+	if p.Type != nil {
+		q = q.Where("type = ?", *p.Type)
+	}
+	if p.WeighingID != nil {
+		q = q.Where("weighing_id = ?", *p.WeighingID)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("occurred_at >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("occurred_at < ?", p.DateTo.AddDate(0, 0, 1))
+	}
+
+	q.Count(&total)
+	page, pageSize := pageOf(p.Page, p.PageSize)
+	err := q.Order("occurred_at DESC, created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) WeighingPosted(ctx context.Context, weighingID string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Movement{}).
+		Where("weighing_id = ?", weighingID).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) GetBalance(ctx context.Context, orgID, goodsID string) (*domain.Balance, error) {
+	var out domain.Balance
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND goods_id = ?", orgID, goodsID).
+		First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) ListBalances(ctx context.Context, p BalanceParams) ([]domain.Balance, int64, error) {
+	var list []domain.Balance
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Balance{}).Where("org_id = ?", p.OrgID)
+	if p.GoodsID != nil {
+		q = q.Where("goods_id = ?", *p.GoodsID)
+	}
+	if p.NonZero {
+		q = q.Where("quantity <> 0")
+	}
+
+	q.Count(&total)
+	page, pageSize := pageOf(p.Page, p.PageSize)
+	err := q.Order("goods_id ASC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) Balances(ctx context.Context, orgID string, goodsIDs []string) (map[string]domain.Balance, error) {
+	var list []domain.Balance
+	q := r.db.WithContext(ctx).Where("org_id = ?", orgID)
+	if len(goodsIDs) > 0 {
+		q = q.Where("goods_id IN ?", goodsIDs)
+	}
+	if err := q.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]domain.Balance, len(list))
+	for _, b := range list {
+		out[b.GoodsID] = b
+	}
+	return out, nil
+}
+
+func (r *repo) RebuildBalances(ctx context.Context, orgID string) (int, error) {
+	type row struct {
+		GoodsID     string
+		UnitID      string
+		Quantity    decimal.Decimal
+		LastMovedAt time.Time
+	}
+	n := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []row
+		err := tx.Model(&domain.Movement{}).
+			Select("goods_id, unit_id, SUM(quantity) AS quantity, MAX(occurred_at) AS last_moved_at").
+			Where("org_id = ?", orgID).
+			Group("goods_id, unit_id").
+			Order("goods_id").
+			Scan(&rows).Error
+		if err != nil {
+			return err
+		}
+		seen := map[string]bool{}
+		list := make([]domain.Balance, 0, len(rows))
+		for _, x := range rows {
+			if seen[x.GoodsID] {
+				return fmt.Errorf("商品 %s 的流水存在多个基准单位，无法重算", x.GoodsID)
+			}
+			seen[x.GoodsID] = true
+			t := x.LastMovedAt
+			list = append(list, domain.Balance{
+				OrgID: orgID, GoodsID: x.GoodsID, UnitID: x.UnitID,
+				Quantity: x.Quantity, LastMovedAt: &t,
+			})
+		}
+		if err := tx.Where("org_id = ?", orgID).Delete(&domain.Balance{}).Error; err != nil {
+			return err
+		}
+		if len(list) > 0 {
+			if err := tx.Create(&list).Error; err != nil {
+				return err
+			}
+		}
+		n = len(list)
+		return nil
+	})
+	return n, err
+}
+
+func (r *repo) CreateCount(ctx context.Context, m *domain.Count) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return createCountLines(tx, m.ID, m.Lines)
+	})
+}
+
+func createCountLines(tx *gorm.DB, countID string, lines []domain.CountLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	for i := range lines {
+		lines[i].ID = ""
+		lines[i].CountID = countID
+		if lines[i].Sort <= 0 {
+			lines[i].Sort = i + 1
+		}
+	}
+	return tx.Create(&lines).Error
+}
+
+func (r *repo) GetCount(ctx context.Context, id string) (*domain.Count, error) {
+	var out domain.Count
+	db := r.db.WithContext(ctx)
+	if err := db.Where("id = ? AND is_deleted = 0", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("count_id = ?", id).Order("sort ASC").Find(&out.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) ListCounts(ctx context.Context, p CountParams) ([]domain.Count, int64, error) {
+	var list []domain.Count
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Count{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.Status != nil {
+		q = q.Where("status = ?", *p.Status)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("count_date >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("count_date <= ?", *p.DateTo)
+	}
+
+	q.Count(&total)
+	page, pageSize := pageOf(p.Page, p.PageSize)
+	err := q.Order("count_date DESC, created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+// lockDraftCount 锁定录入中的盘点单
+func lockDraftCount(tx *gorm.DB, id string) (*domain.Count, error) {
+	var c domain.Count
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND is_deleted = 0", id).First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	if c.Status != domain.CountDraft {
+		return nil, errors.New("盘点单已过账，不能修改")
+	}
+	return &c, nil
+}
+
+func (r *repo) ReplaceCountLines(ctx context.Context, countID string, version int, lines []domain.CountLine) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockDraftCount(tx, countID); err != nil {
+			return err
+		}
+		if err := utils.UpdateVersioned(tx.Model(&domain.Count{}).Where("id = ?", countID), version, nil); err != nil {
+			return err
+		}
+		if err := tx.Where("count_id = ?", countID).Delete(&domain.CountLine{}).Error; err != nil {
+			return err
+		}
+		return createCountLines(tx, countID, lines)
+	})
+}
+
+func (r *repo) PostCount(ctx context.Context, countID string, operatorID *string, at time.Time) (*domain.Count, error) {
+	var out *domain.Count
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := lockDraftCount(tx, countID)
+		if err != nil {
+			return err
+		}
+		var lines []domain.CountLine
+		if err := tx.Where("count_id = ?", countID).Order("sort ASC").Find(&lines).Error; err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return errors.New("盘点单没有明细")
+		}
+
+		keys := make([]balanceKey, len(lines))
+		units := make(map[balanceKey]string, len(lines))
+		for i, l := range lines {
+			keys[i] = balanceKey{c.OrgID, l.GoodsID}
+			units[keys[i]] = l.UnitID
+		}
+		bals, err := lockBalances(tx, keys, units)
+		if err != nil {
+			return err
+		}
+
+		remark := fmt.Sprintf("盘点差异（%s）", c.CountDate.Format("2006-01-02"))
+		for i := range lines {
+			l := &lines[i]
+			b := bals[balanceKey{c.OrgID, l.GoodsID}]
+			if b.UnitID != l.UnitID && !b.Quantity.IsZero() {
+				return fmt.Errorf("商品 %s 基准单位与现有结存不一致", l.GoodsID)
+			}
+			book := b.Quantity
+			diff := l.CountedQty.Sub(book)
+			l.BookQty, l.DiffQty = &book, &diff
+			if !diff.IsZero() {
+				m := &domain.Movement{
+					OrgID:       c.OrgID,
+					GoodsID:     l.GoodsID,
+					Type:        domain.TypeAdjust,
+					Quantity:    diff,
+					UnitID:      l.UnitID,
+					InputQty:    diff,
+					InputUnitID: l.UnitID,
+					OccurredAt:  at,
+					CountID:     &c.ID,
+					Remark:      &remark,
+					OperatorID:  operatorID,
+				}
+				if err := apply(tx, b, m); err != nil {
+					return err
+				}
+				l.MovementID = &m.ID
+			}
+			err := tx.Model(&domain.CountLine{}).Where("id = ?", l.ID).Updates(map[string]any{
+				"book_qty":    l.BookQty,
+				"diff_qty":    l.DiffQty,
+				"movement_id": l.MovementID,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		c.Status, c.PostedAt = domain.CountPosted, &at
+		err = tx.Model(&domain.Count{}).Where("id = ?", c.ID).Updates(map[string]any{
+			"status":    c.Status,
+			"posted_at": c.PostedAt,
+			"version":   gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		c.Version++
+		c.Lines = lines
+		out = c
+		return nil
+	})
+	return out, err
+}
+
+func (r *repo) SoftDeleteDraftCount(ctx context.Context, id string) error {
+	res := r.db.WithContext(ctx).Model(&domain.Count{}).
+		Where("id = ? AND status = ?", id, domain.CountDraft).
+		Update("is_deleted", 1)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("仅录入中的盘点单可删除")
+	}
+	return nil
+}
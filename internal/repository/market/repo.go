@@ -0,0 +1,40 @@
+package market
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/market"
+)
+
+type UpdateParams struct {
+	ID      string
+	Version int // 期望版本号（乐观锁）
+	Name    *string
+	Address *string
+	Type    *string
+	Sort    *int
+}
+
+type ListParams struct {
+	OrgID    string
+	Keyword  string
+	Type     *string
+	Page     int
+	PageSize int
+}
+
+type Repository interface {
+	// Create/Update 同机构同名返回 domain.ErrDuplicate
+	Create(ctx context.Context, m *domain.Market) error
+	Get(ctx context.Context, id string) (*domain.Market, error)
+	// GetMany 按 id 批量读取（含已软删，供历史询价显示）
+	GetMany(ctx context.Context, ids []string) (map[string]domain.Market, error)
+	List(ctx context.Context, p ListParams) ([]domain.Market, int64, error)
+	Update(ctx context.Context, p UpdateParams) error
+	SoftDelete(ctx context.Context, id string) error
+	// EnsureByNames 按名称查找机构的有效市场，不存在则创建；返回顺序与 names 一致
+	EnsureByNames(ctx context.Context, orgID string, names []string) ([]domain.Market, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,145 @@
+package market
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/market"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, m *domain.Market) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := nameFree(tx, m.OrgID, m.Name, ""); err != nil {
+			return err
+		}
+		return tx.Create(m).Error
+	})
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Market, error) {
+	var out domain.Market
+	err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) GetMany(ctx context.Context, ids []string) (map[string]domain.Market, error) {
+	out := make(map[string]domain.Market, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var list []domain.Market
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range list {
+		out[m.ID] = m
+	}
+	return out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Market, int64, error) {
+	var list []domain.Market
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Market{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.Keyword != "" {
+		like := "%" + p.Keyword + "%"
+		q = q.Where("(name LIKE ? OR address LIKE ?)", like, like)
+	}
+	if p.Type != nil {
+		q = q.Where("type = ?", *p.Type)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("sort, name").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) Update(ctx context.Context, p UpdateParams) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur domain.Market
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_deleted = 0", p.ID).First(&cur).Error; err != nil {
+			return err
+		}
+		if cur.Version != p.Version {
+			return utils.ErrVersionConflict
+		}
+		updates := map[string]any{}
+		if p.Name != nil {
+			if err := nameFree(tx, cur.OrgID, *p.Name, cur.ID); err != nil {
+				return err
+			}
+			updates["name"] = *p.Name
+		}
+		if p.Address != nil {
+			updates["address"] = *p.Address
+		}
+		if p.Type != nil {
+			updates["type"] = *p.Type
+		}
+		if p.Sort != nil {
+			updates["sort"] = *p.Sort
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return utils.UpdateVersioned(tx.Model(&domain.Market{}).Where("id = ?", cur.ID), p.Version, updates)
+	})
+}
+
+func (r *repo) SoftDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Market{}).
+		Where("id = ?", id).Update("is_deleted", 1).Error
+}
+
+func (r *repo) EnsureByNames(ctx context.Context, orgID string, names []string) ([]domain.Market, error) {
+	out := make([]domain.Market, 0, len(names))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			var m domain.Market
+			err := tx.Where("org_id = ? AND name = ? AND is_deleted = 0", orgID, name).First(&m).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				m = domain.Market{OrgID: orgID, Name: name, Type: domain.TypeOther}
+				err = tx.Create(&m).Error
+			}
+			if err != nil {
+				return err
+			}
+			out = append(out, m)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func nameFree(tx *gorm.DB, orgID, name, exceptID string) error {
+	var n int64
+	if err := tx.Model(&domain.Market{}).
+		Where("org_id = ? AND name = ? AND id <> ? AND is_deleted = 0", orgID, name, exceptID).
+		Count(&n).Error; err != nil {
+		return err
+	}
+	if n > 0 {
+		return domain.ErrDuplicate
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package mealplan
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/mealplan"
+)
+
+// UpdateParams Dishes 非 nil 时整体替换菜品
+type UpdateParams struct {
+	ID        string
+	Version   int // 期望版本号（乐观锁），替换菜品同样递增
+	Headcount *int
+	Remark    *string
+	Dishes    *[]domain.Dish
+}
+
+type ListParams struct {
+	OrgID    string
+	MealID   *string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	PageSize int
+}
+
+type Repository interface {
+	Create(ctx context.Context, m *domain.Plan) error
+	Get(ctx context.Context, id string) (*domain.Plan, error)
+	List(ctx context.Context, params ListParams) ([]domain.Plan, int64, error)
+	// ListRange 不分页返回 [from, to] 内的计划（含菜品），供需求展开使用
+	ListRange(ctx context.Context, orgID string, mealID *string, from, to time.Time) ([]domain.Plan, error)
+	Update(ctx context.Context, params UpdateParams) error
+	Delete(ctx context.Context, id string) error
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,170 @@
+package mealplan
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/mealplan"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+const mealTable = "menu_meal"
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, m *domain.Plan) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := ensureMeal(tx, m.MealID); err != nil {
+			return err
+		}
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		return createDishes(tx, m.ID, m.Dishes)
+	})
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Plan, error) {
+	var out domain.Plan
+	db := r.db.WithContext(ctx)
+	if err := db.Where("id = ?", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("plan_id = ?", id).Order("sort ASC").Find(&out.Dishes).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Plan, int64, error) {
+	var list []domain.Plan
+	var total int64
+
+	db := r.db.WithContext(ctx)
+	q := db.Model(&domain.Plan{}).Where("org_id = ?", p.OrgID)
+	if p.MealID != nil {
+		q = q.Where("meal_id = ?", *p.MealID)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("plan_date >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("plan_date <= ?", *p.DateTo)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("plan_date DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := attachDishes(db, list); err != nil {
+		return nil, 0, err
+	}
+	return list, total, nil
+}
+
+func (r *repo) ListRange(ctx context.Context, orgID string, mealID *string, from, to time.Time) ([]domain.Plan, error) {
+	var list []domain.Plan
+	db := r.db.WithContext(ctx)
+	q := db.Where("org_id = ? AND plan_date >= ? AND plan_date <= ?", orgID, from, to)
+	if mealID != nil {
+		q = q.Where("meal_id = ?", *mealID)
+	}
+	if err := q.Order("plan_date ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	if err := attachDishes(db, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// attachDishes 批量带出菜品
+func attachDishes(db *gorm.DB, list []domain.Plan) error {
+	if len(list) == 0 {
+		return nil
+	}
+	ids := make([]string, len(list))
+	for i := range list {
+		ids[i] = list[i].ID
+	}
+	var dishes []domain.Dish
+	if err := db.Where("plan_id IN ?", ids).Order("sort ASC").Find(&dishes).Error; err != nil {
+		return err
+	}
+	byPlan := make(map[string][]domain.Dish, len(list))
+	for _, d := range dishes {
+		byPlan[d.PlanID] = append(byPlan[d.PlanID], d)
+	}
+	for i := range list {
+		list[i].Dishes = byPlan[list[i].ID]
+	}
+	return nil
+}
+
+func (r *repo) Update(ctx context.Context, p UpdateParams) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]any{}
+		if p.Headcount != nil {
+			updates["headcount"] = *p.Headcount
+		}
+		if p.Remark != nil {
+			updates["remark"] = *p.Remark
+		}
+		if err := utils.UpdateVersioned(tx.Model(&domain.Plan{}).Where("id = ?", p.ID), p.Version, updates); err != nil {
+			return err
+		}
+		if p.Dishes == nil {
+			return nil
+		}
+		if err := tx.Where("plan_id = ?", p.ID).Delete(&domain.Dish{}).Error; err != nil {
+			return err
+		}
+		return createDishes(tx, p.ID, *p.Dishes)
+	})
+}
+
+func (r *repo) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("plan_id = ?", id).Delete(&domain.Dish{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&domain.Plan{}).Error
+	})
+}
+
+func createDishes(tx *gorm.DB, planID string, dishes []domain.Dish) error {
+	if len(dishes) == 0 {
+		return nil
+	}
+	for i := range dishes {
+		dishes[i].ID = ""
+		dishes[i].PlanID = planID
+		if dishes[i].Sort <= 0 {
+			dishes[i].Sort = i + 1
+		}
+	}
+	return tx.Create(&dishes).Error
+}
+
+func ensureMeal(tx *gorm.DB, mealID string) error {
+	var n int64
+	if err := tx.Table(mealTable).Where("id = ? AND is_deleted = 0", mealID).Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("餐次不存在或已删除")
+	}
+	return nil
+}
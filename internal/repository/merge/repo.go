@@ -0,0 +1,66 @@
+package merge
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/merge"
+)
+
+// RefSpec 描述一个引用被合并对象的列。
+// UniqueWith 为该列所在唯一键中的其它列：改写前若保留方已有相同组合，则该行视为冲突并软删。
+// HardDelete 表示该表无 is_deleted 列，冲突行直接物理删除。
+// Reset 为改写引用时一并覆盖的列（如被合并方的主条码标记）。
+type RefSpec struct {
+	Table      string
+	Column     string
+	UniqueWith []string
+	HardDelete bool
+	Reset      map[string]any
+}
+
+// GoodsRefs 引用 base_goods.id 的列；新增引用商品的表需登记到这里
+var GoodsRefs = []RefSpec{
+	{Table: "base_goods_avg_detail", Column: "goods_id", UniqueWith: []string{"inquiry_id"}},
+	{Table: "base_goods_price", Column: "goods_id", UniqueWith: []string{"inquiry_id", "supplier_id"}},
+	{Table: "base_goods_unit_conversion", Column: "goods_id", UniqueWith: []string{"unit_id", "to_unit_id"}, HardDelete: true},
+	{Table: "base_weighing_record", Column: "goods_id"},
+	{Table: "menu_recipe_line", Column: "goods_id"},
+	{Table: "purchase_order_line", Column: "goods_id"},
+	{Table: "inv_movement", Column: "goods_id"},
+	// 结存冲突行直接删除，MergeGoods 随后在同一事务内按流水重算相关机构结存；
+	// 盘点明细冲突行先由 sumCountLines 累加到保留方再删除
+	{Table: "inv_balance", Column: "goods_id", UniqueWith: []string{"org_id"}, HardDelete: true},
+	{Table: "inv_count_line", Column: "goods_id", UniqueWith: []string{"count_id"}, HardDelete: true},
+	{Table: "base_waste_record", Column: "goods_id"},
+	{Table: "price_anomaly_flag", Column: "goods_id"},
+	{Table: "base_price_inquiry_template_line", Column: "goods_id", UniqueWith: []string{"template_id"}, HardDelete: true},
+	{Table: "base_goods_market_price", Column: "goods_id"},
+	// 条码机构内唯一，直接改挂到保留方；移过来的条码不再是主条码，保留方原主条码不变
+	{Table: "base_goods_barcode", Column: "goods_id", Reset: map[string]any{"is_primary": 0}},
+	// 密封报价与授标是审计数据，不做冲突删除：同一轮次多个商品均有报价时 MergeGoods 直接拒绝
+	{Table: "inquiry_round_bid", Column: "goods_id"},
+	{Table: "inquiry_round_award", Column: "goods_id"},
+}
+
+// CategoryRefs 引用 base_category.id 的列
+var CategoryRefs = []RefSpec{
+	{Table: "base_goods", Column: "category_id"},
+	{Table: "base_category", Column: "parent_id"},
+}
+
+type MergeParams struct {
+	SurvivorID string
+	LoserIDs   []string
+	Preview    bool
+	Record     *domain.Record // Preview=false 时与合并同事务写入审计（Affected/OrgID 由 repo 填充）
+}
+
+type Repository interface {
+	MergeGoods(ctx context.Context, p MergeParams) ([]domain.RefCount, error)
+	MergeCategory(ctx context.Context, p MergeParams) ([]domain.RefCount, error)
+	GetRecord(ctx context.Context, id string) (*domain.Record, error)
+	ListRecords(ctx context.Context, orgID string, entityType string, page, pageSize int) ([]domain.Record, int64, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,338 @@
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	category "hdzk.cn/foodapp/internal/domain/category"
+	invdomain "hdzk.cn/foodapp/internal/domain/inventory"
+	domain "hdzk.cn/foodapp/internal/domain/merge"
+	"hdzk.cn/foodapp/internal/repository/inventory"
+)
+
+type repo struct{ db *gorm.DB }
+
+// errPreview 预览模式：按真实流程执行后回滚，保证计数与正式合并一致
+var errPreview = errors.New("merge preview rollback")
+
+func (r *repo) MergeGoods(ctx context.Context, p MergeParams) ([]domain.RefCount, error) {
+	return r.run(ctx, p, func(tx *gorm.DB) ([]domain.RefCount, error) {
+		orgID, err := lockSameOrg(tx, "base_goods", p.SurvivorID, p.LoserIDs)
+		if err != nil {
+			return nil, err
+		}
+		// 库存、盘点数量均按商品基准单位记录，单位不同时无法直接合并
+		var units []string
+		if err := tx.Table("base_goods").
+			Where("id IN ?", append([]string{p.SurvivorID}, p.LoserIDs...)).
+			Distinct().Pluck("unit_id", &units).Error; err != nil {
+			return nil, err
+		}
+		if len(units) > 1 {
+			return nil, errors.New("商品基准单位不一致，无法合并")
+		}
+		if err := checkRoundBids(tx, append([]string{p.SurvivorID}, p.LoserIDs...)); err != nil {
+			return nil, err
+		}
+		// 被合并方有结存的机构在改写后按流水重算（冲突结存行会被删除）
+		var balanceOrgs []string
+		if err := tx.Table("inv_balance").
+			Where("goods_id IN ?", p.LoserIDs).
+			Distinct().Pluck("org_id", &balanceOrgs).Error; err != nil {
+			return nil, err
+		}
+		if err := sumCountLines(tx, p.SurvivorID, p.LoserIDs); err != nil {
+			return nil, err
+		}
+		counts, err := rewriteRefs(tx, GoodsRefs, p.SurvivorID, p.LoserIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, org := range balanceOrgs {
+			if _, err := inventory.NewRepository(tx).RebuildBalances(ctx, org); err != nil {
+				return nil, fmt.Errorf("重算机构 %s 结存失败: %w", org, err)
+			}
+		}
+		del, err := softDeleteLosers(tx, "base_goods", p.LoserIDs)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, del)
+		if p.Record != nil {
+			p.Record.OrgID = orgID
+		}
+		return counts, nil
+	})
+}
+
+func (r *repo) MergeCategory(ctx context.Context, p MergeParams) ([]domain.RefCount, error) {
+	return r.run(ctx, p, func(tx *gorm.DB) ([]domain.RefCount, error) {
+		orgID, err := lockSameOrg(tx, "base_category", p.SurvivorID, p.LoserIDs)
+		if err != nil {
+			return nil, err
+		}
+		// 保留方不能位于任一被合并方之下，否则下级改挂后会成环
+		var cats []category.Category
+		if err := tx.Model(&category.Category{}).
+			Select("id, parent_id").
+			Where("is_deleted = 0 AND org_id = ?", orgID).
+			Find(&cats).Error; err != nil {
+			return nil, err
+		}
+		for _, loser := range p.LoserIDs {
+			for _, d := range category.DescendantIDs(cats, loser) {
+				if d == p.SurvivorID {
+					return nil, errors.New("保留品类不能是被合并品类的下级")
+				}
+			}
+		}
+		counts, err := rewriteRefs(tx, CategoryRefs, p.SurvivorID, p.LoserIDs)
+		if err != nil {
+			return nil, err
+		}
+		del, err := softDeleteLosers(tx, "base_category", p.LoserIDs)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, del)
+		if p.Record != nil {
+			p.Record.OrgID = orgID
+		}
+		return counts, nil
+	})
+}
+
+func (r *repo) run(ctx context.Context, p MergeParams, fn func(tx *gorm.DB) ([]domain.RefCount, error)) ([]domain.RefCount, error) {
+	var out []domain.RefCount
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		counts, err := fn(tx)
+		if err != nil {
+			return err
+		}
+		out = counts
+		if p.Preview {
+			return errPreview
+		}
+		if p.Record != nil {
+			// 审计与合并同事务落库，记录最终影响行数
+			b, err := json.Marshal(counts)
+			if err != nil {
+				return err
+			}
+			p.Record.Affected = string(b)
+			return tx.Create(p.Record).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errPreview) {
+		return out, nil
+	}
+	return out, err
+}
+
+func (r *repo) GetRecord(ctx context.Context, id string) (*domain.Record, error) {
+	var out domain.Record
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) ListRecords(ctx context.Context, orgID string, entityType string, page, pageSize int) ([]domain.Record, int64, error) {
+	var list []domain.Record
+	var total int64
+	q := r.db.WithContext(ctx).Model(&domain.Record{}).Where("org_id = ?", orgID)
+	if entityType != "" {
+		q = q.Where("entity_type = ?", entityType)
+	}
+	q.Count(&total)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+// ---------- helpers ----------
+
+// lockSameOrg 锁定保留方与被合并方，要求全部存在、未删除且同属一个 org
+func lockSameOrg(tx *gorm.DB, table, survivorID string, loserIDs []string) (string, error) {
+	var rows []struct {
+		ID    string
+		OrgID string
+	}
+	ids := append([]string{survivorID}, loserIDs...)
+	if err := tx.Table(table).
+		Select("id, org_id").
+		Where("id IN ? AND is_deleted = 0", ids).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Scan(&rows).Error; err != nil {
+		return "", err
+	}
+	if len(rows) != len(ids) {
+		return "", fmt.Errorf("部分记录不存在或已删除（期望 %d 条，实际 %d 条）", len(ids), len(rows))
+	}
+	orgID := rows[0].OrgID
+	for _, row := range rows[1:] {
+		if row.OrgID != orgID {
+			return "", errors.New("只能合并同一组织内的记录")
+		}
+	}
+	return orgID, nil
+}
+
+// checkRoundBids 同一报价轮次中有两个及以上待合并商品收到报价时拒绝合并，避免密封报价被覆盖或删除
+func checkRoundBids(tx *gorm.DB, ids []string) error {
+	var rounds []string
+	if err := tx.Table("inquiry_round_bid").
+		Where("goods_id IN ?", ids).
+		Group("round_id").
+		Having("COUNT(DISTINCT goods_id) > 1").
+		Limit(1).
+		Pluck("round_id", &rounds).Error; err != nil {
+		return err
+	}
+	if len(rounds) > 0 {
+		return fmt.Errorf("报价轮次 %s 中待合并商品均已有报价，无法合并", rounds[0])
+	}
+	return nil
+}
+
+// sumCountLines 同一盘点单中保留方与被合并方都有明细时，将被合并方数量累加到保留方明细
+// （随后被合并方明细作为冲突行删除）；录入单位不同时按基准单位回填录入数量
+func sumCountLines(tx *gorm.DB, survivorID string, loserIDs []string) error {
+	var lines []invdomain.CountLine
+	if err := tx.Where("goods_id IN ?", append([]string{survivorID}, loserIDs...)).
+		Where("count_id IN (SELECT count_id FROM inv_count_line WHERE goods_id = ?)", survivorID).
+		Order("count_id").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&lines).Error; err != nil {
+		return err
+	}
+	survivors := map[string]*invdomain.CountLine{}
+	for i := range lines {
+		if lines[i].GoodsID == survivorID {
+			survivors[lines[i].CountID] = &lines[i]
+		}
+	}
+	changed := map[string]bool{}
+	for _, l := range lines {
+		s := survivors[l.CountID]
+		if l.GoodsID == survivorID || s == nil {
+			continue
+		}
+		if s.InputUnitID == l.InputUnitID {
+			s.InputQty = s.InputQty.Add(l.InputQty)
+		} else {
+			s.InputUnitID = s.UnitID
+			s.InputQty = s.CountedQty.Add(l.CountedQty)
+		}
+		s.CountedQty = s.CountedQty.Add(l.CountedQty)
+		s.BookQty = addQty(s.BookQty, l.BookQty)
+		s.DiffQty = addQty(s.DiffQty, l.DiffQty)
+		changed[l.CountID] = true
+	}
+	for countID := range changed {
+		s := survivors[countID]
+		if err := tx.Model(&invdomain.CountLine{}).
+			Where("id = ?", s.ID).
+			Updates(map[string]any{
+				"input_qty":     s.InputQty,
+				"input_unit_id": s.InputUnitID,
+				"counted_qty":   s.CountedQty,
+				"book_qty":      s.BookQty,
+				"diff_qty":      s.DiffQty,
+			}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func addQty(a, b *decimal.Decimal) *decimal.Decimal {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	}
+	v := a.Add(*b)
+	return &v
+}
+
+// rewriteRefs 逐个被合并方改写引用；与保留方唯一键冲突的行保持原引用并软删
+func rewriteRefs(tx *gorm.DB, refs []RefSpec, survivorID string, loserIDs []string) ([]domain.RefCount, error) {
+	out := make([]domain.RefCount, 0, len(refs))
+	for _, ref := range refs {
+		rc := domain.RefCount{Table: ref.Table, Column: ref.Column}
+		for _, loser := range loserIDs {
+			if len(ref.UniqueWith) > 0 {
+				cols := strings.Join(ref.UniqueWith, ", ")
+				// MySQL 不允许 UPDATE 子查询直接引用自身，包一层派生表
+				dup := fmt.Sprintf("(%s) IN (SELECT %s FROM (SELECT %s FROM %s WHERE %s = ?) AS s)",
+					cols, cols, cols, ref.Table, ref.Column)
+
+				var res *gorm.DB
+				if ref.HardDelete {
+					res = tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s", ref.Table, ref.Column, dup),
+						loser, survivorID)
+				} else {
+					res = tx.Table(ref.Table).
+						Where(ref.Column+" = ? AND is_deleted = 0", loser).
+						Where(dup, survivorID).
+						Update("is_deleted", 1)
+				}
+				if res.Error != nil {
+					return nil, fmt.Errorf("处理 %s.%s 冲突失败: %w", ref.Table, ref.Column, res.Error)
+				}
+				rc.Conflicted += res.RowsAffected
+
+				res = tx.Table(ref.Table).
+					Where(ref.Column+" = ?", loser).
+					Where("NOT "+dup, survivorID).
+					Updates(ref.updates(survivorID))
+				if res.Error != nil {
+					return nil, fmt.Errorf("改写 %s.%s 失败: %w", ref.Table, ref.Column, res.Error)
+				}
+				rc.Rewritten += res.RowsAffected
+				continue
+			}
+			res := tx.Table(ref.Table).
+				Where(ref.Column+" = ?", loser).
+				Updates(ref.updates(survivorID))
+			if res.Error != nil {
+				return nil, fmt.Errorf("改写 %s.%s 失败: %w", ref.Table, ref.Column, res.Error)
+			}
+			rc.Rewritten += res.RowsAffected
+		}
+		out = append(out, rc)
+	}
+	return out, nil
+}
+
+// updates 改写引用列及 Reset 中的附带列
+func (ref RefSpec) updates(survivorID string) map[string]any {
+	out := map[string]any{ref.Column: survivorID}
+	for k, v := range ref.Reset {
+		out[k] = v
+	}
+	return out
+}
+
+func softDeleteLosers(tx *gorm.DB, table string, loserIDs []string) (domain.RefCount, error) {
+	res := tx.Table(table).
+		Where("id IN ? AND is_deleted = 0", loserIDs).
+		Update("is_deleted", 1)
+	return domain.RefCount{Table: table, Column: "id", Deleted: res.RowsAffected}, res.Error
+}
@@ -0,0 +1,126 @@
+package merge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// sqlRecorder 记录 DryRun 下生成的 SQL（参数已内联）
+type sqlRecorder struct {
+	logger.Interface
+	stmts []string
+}
+
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.stmts = append(r.stmts, sql)
+}
+
+func dryRunDB(t *testing.T) (*gorm.DB, *sqlRecorder) {
+	t.Helper()
+	rec := &sqlRecorder{Interface: logger.Discard}
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "dry:run@/dry", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true, Logger: rec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, rec
+}
+
+func TestRewriteRefs(t *testing.T) {
+	cases := []struct {
+		name  string
+		ref   RefSpec
+		loser []string
+		want  []string
+	}{
+		{
+			name:  "无唯一键直接改写",
+			ref:   RefSpec{Table: "purchase_order_line", Column: "goods_id"},
+			loser: []string{"l1", "l2"},
+			want: []string{
+				"UPDATE `purchase_order_line` SET `goods_id`='s' WHERE goods_id = 'l1'",
+				"UPDATE `purchase_order_line` SET `goods_id`='s' WHERE goods_id = 'l2'",
+			},
+		},
+		{
+			name:  "改写时覆盖附带列",
+			ref:   RefSpec{Table: "base_goods_barcode", Column: "goods_id", Reset: map[string]any{"is_primary": 0}},
+			loser: []string{"l1"},
+			want: []string{
+				"UPDATE `base_goods_barcode` SET `goods_id`='s',`is_primary`=0 WHERE goods_id = 'l1'",
+			},
+		},
+		{
+			name:  "唯一键冲突行软删，其余改写",
+			ref:   RefSpec{Table: "base_goods_price", Column: "goods_id", UniqueWith: []string{"inquiry_id", "supplier_id"}},
+			loser: []string{"l1"},
+			want: []string{
+				"UPDATE `base_goods_price` SET `is_deleted`=1 WHERE (goods_id = 'l1' AND is_deleted = 0) AND (inquiry_id, supplier_id) IN (SELECT inquiry_id, supplier_id FROM (SELECT inquiry_id, supplier_id FROM base_goods_price WHERE goods_id = 's') AS s)",
+				"UPDATE `base_goods_price` SET `goods_id`='s' WHERE goods_id = 'l1' AND NOT (inquiry_id, supplier_id) IN (SELECT inquiry_id, supplier_id FROM (SELECT inquiry_id, supplier_id FROM base_goods_price WHERE goods_id = 's') AS s)",
+			},
+		},
+		{
+			name:  "无软删列的表冲突行物理删除",
+			ref:   RefSpec{Table: "inv_balance", Column: "goods_id", UniqueWith: []string{"org_id"}, HardDelete: true},
+			loser: []string{"l1"},
+			want: []string{
+				"DELETE FROM inv_balance WHERE goods_id = 'l1' AND (org_id) IN (SELECT org_id FROM (SELECT org_id FROM inv_balance WHERE goods_id = 's') AS s)",
+				"UPDATE `inv_balance` SET `goods_id`='s' WHERE goods_id = 'l1' AND NOT (org_id) IN (SELECT org_id FROM (SELECT org_id FROM inv_balance WHERE goods_id = 's') AS s)",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, rec := dryRunDB(t)
+			counts, err := rewriteRefs(db, []RefSpec{c.ref}, "s", c.loser)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(counts) != 1 || counts[0].Table != c.ref.Table || counts[0].Column != c.ref.Column {
+				t.Errorf("RefCount = %+v，期望单条 %s.%s", counts, c.ref.Table, c.ref.Column)
+			}
+			if len(rec.stmts) != len(c.want) {
+				t.Fatalf("生成 %d 条 SQL，期望 %d 条：\n%v", len(rec.stmts), len(c.want), rec.stmts)
+			}
+			for i := range c.want {
+				if rec.stmts[i] != c.want[i] {
+					t.Errorf("第 %d 条 SQL:\n得到 %s\n期望 %s", i+1, rec.stmts[i], c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRefSpecUpdates(t *testing.T) {
+	ref := RefSpec{Column: "goods_id", Reset: map[string]any{"is_primary": 0}}
+	got := ref.updates("s")
+	if len(got) != 2 || got["goods_id"] != "s" || got["is_primary"] != 0 {
+		t.Errorf("updates = %v，期望 goods_id=s, is_primary=0", got)
+	}
+	if len(ref.Reset) != 1 {
+		t.Errorf("updates 修改了 Reset: %v", ref.Reset)
+	}
+}
+
+// 登记的引用表不应重复，UniqueWith 不应包含引用列自身
+func TestRefSpecs(t *testing.T) {
+	seen := map[string]bool{}
+	for _, ref := range append(append([]RefSpec(nil), GoodsRefs...), CategoryRefs...) {
+		key := ref.Table + "." + ref.Column
+		if seen[key] {
+			t.Errorf("%s 重复登记", key)
+		}
+		seen[key] = true
+		for _, col := range ref.UniqueWith {
+			if col == ref.Column {
+				t.Errorf("%s 的 UniqueWith 包含引用列自身", key)
+			}
+		}
+	}
+}
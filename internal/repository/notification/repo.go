@@ -0,0 +1,27 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/notification"
+)
+
+type ListParams struct {
+	OrgID      string
+	Kind       *string
+	UnreadOnly bool
+	Page       int
+	PageSize   int
+}
+
+type Repository interface {
+	// Create 写入通知；DedupKey 已存在时不写入并返回 false
+	Create(ctx context.Context, n *domain.Notification) (bool, error)
+	List(ctx context.Context, p ListParams) ([]domain.Notification, int64, error)
+	// MarkRead 将机构内指定通知标记为已读（ids 为空时标记全部未读），返回标记条数
+	MarkRead(ctx context.Context, orgID string, ids []string, at time.Time) (int64, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/notification"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, n *domain.Notification) (bool, error) {
+	res := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(n)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Notification, int64, error) {
+	var list []domain.Notification
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Notification{}).Where("org_id = ?", p.OrgID)
+	if p.Kind != nil {
+		q = q.Where("kind = ?", *p.Kind)
+	}
+	if p.UnreadOnly {
+		q = q.Where("read_at IS NULL")
+	}
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize <= 0 || p.PageSize > 1000 {
+		p.PageSize = 20
+	}
+	err := q.Order("created_at DESC, id").
+		Limit(p.PageSize).Offset((p.Page - 1) * p.PageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) MarkRead(ctx context.Context, orgID string, ids []string, at time.Time) (int64, error) {
+	q := r.db.WithContext(ctx).Model(&domain.Notification{}).
+		Where("org_id = ? AND read_at IS NULL", orgID)
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	res := q.Update("read_at", at)
+	return res.RowsAffected, res.Error
+}
@@ -11,9 +11,12 @@ type Repository interface {
 	Create(ctx context.Context, m *domain.Organ) error
 	GetByID(ctx context.Context, id string) (*domain.Organ, error)
 	List(ctx context.Context, NameLike string, Deleted, Role *int, page, page_size int) ([]domain.Organ, int64, error)
-	UpdateFields(ctx context.Context, id string, fields map[string]any) error
+	// UpdateFields 按期望版本号条件更新（乐观锁）
+	UpdateFields(ctx context.Context, id string, version int, fields map[string]any) error
 	SoftDelete(ctx context.Context, id string) error
 	HardDelete(ctx context.Context, id string) error
+	// SubtreeIDs 返回 rootID 及其全部下级组织 ID
+	SubtreeIDs(ctx context.Context, rootID string) ([]string, error)
 }
 
 func NewRepository(db *gorm.DB) Repository {
@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 	domain "hdzk.cn/foodapp/internal/domain/organ"
 	foodDB "hdzk.cn/foodapp/internal/storage/db"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type gormRepo struct {
@@ -74,14 +75,13 @@ func (r *gormRepo) List(ctx context.Context, keyword string, Deleted, Role *int,
 	return list, total, err
 }
 
-func (r *gormRepo) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+func (r *gormRepo) UpdateFields(ctx context.Context, id string, version int, fields map[string]any) error {
 	if len(fields) == 0 {
 		return errors.New("没有要更新项目")
 	}
-	return r.db.WithContext(ctx).
+	return utils.UpdateVersioned(r.db.WithContext(ctx).
 		Model(&domain.Organ{}).
-		Where("id = ? AND is_deleted = 0", id).
-		Updates(fields).Error
+		Where("id = ? AND is_deleted = 0", id), version, fields)
 }
 
 func (r *gormRepo) SoftDelete(ctx context.Context, id string) error {
@@ -96,3 +96,14 @@ func (r *gormRepo) HardDelete(ctx context.Context, id string) error {
 		Where("id =?", id).
 		Delete(&domain.Organ{}).Error
 }
+
+func (r *gormRepo) SubtreeIDs(ctx context.Context, rootID string) ([]string, error) {
+	var list []domain.Organ
+	if err := r.db.WithContext(ctx).Model(&domain.Organ{}).
+		Select("id, parent_id").
+		Where("is_deleted = 0").
+		Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return domain.DescendantIDs(list, rootID), nil
+}
@@ -0,0 +1,56 @@
+package portal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/portal"
+)
+
+type AccountListParams struct {
+	OrgID      string
+	SupplierID *string
+	Status     *int
+	Page       int
+	PageSize   int
+}
+
+// AccountUpdateParams 修改门户账户；指针为空表示不修改
+type AccountUpdateParams struct {
+	ID          string
+	Version     int // 期望版本号（乐观锁）
+	DisplayName *string
+	Phone       *string
+	Scopes      *string
+	Status      *int
+	// 显式清空
+	ClearDisplayName bool
+	ClearPhone       bool
+}
+
+type Repository interface {
+	CreateAccount(ctx context.Context, a *domain.Account) error
+	GetAccount(ctx context.Context, id string) (*domain.Account, error)
+	GetAccountByUsername(ctx context.Context, username string) (*domain.Account, error)
+	// UsernameTaken 登录名是否已被使用（含已删除账户，唯一索引不区分）
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+	ListAccounts(ctx context.Context, p AccountListParams) ([]domain.Account, int64, error)
+	UpdateAccount(ctx context.Context, p AccountUpdateParams) error
+	UpdatePasswordHash(ctx context.Context, id, hash string) error
+	TouchLogin(ctx context.Context, id string, at time.Time) error
+	SoftDeleteAccount(ctx context.Context, id string) error
+
+	// SaveInvitation 开放询价单给供应商（已开放时更新截止时间）
+	SaveInvitation(ctx context.Context, m *domain.Invitation) error
+	DeleteInvitation(ctx context.Context, inquiryID, supplierID string) error
+	GetInvitation(ctx context.Context, inquiryID, supplierID string) (*domain.Invitation, error)
+	ListInvitations(ctx context.Context, inquiryID string) ([]domain.Invitation, error)
+
+	// OpenInquiries 向供应商开放、未删除的询价单（含报价进度），按询价日期倒序
+	OpenInquiries(ctx context.Context, supplierID string, page, pageSize int) ([]domain.InquiryView, int64, error)
+	// InquiryItems 询价单的商品及供应商当前报价
+	InquiryItems(ctx context.Context, inquiryID, supplierID string) ([]domain.InquiryItem, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
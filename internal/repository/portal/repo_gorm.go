@@ -0,0 +1,200 @@
+package portal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/portal"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) CreateAccount(ctx context.Context, a *domain.Account) error {
+	return r.db.WithContext(ctx).Create(a).Error
+}
+
+func (r *repo) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
+	var out domain.Account
+	err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) GetAccountByUsername(ctx context.Context, username string) (*domain.Account, error) {
+	var out domain.Account
+	err := r.db.WithContext(ctx).Where("username = ? AND is_deleted = 0", username).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Account{}).
+		Where("username = ?", username).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) ListAccounts(ctx context.Context, p AccountListParams) ([]domain.Account, int64, error) {
+	var list []domain.Account
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Account{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.SupplierID != nil {
+		q = q.Where("supplier_id = ?", *p.SupplierID)
+	}
+	if p.Status != nil {
+		q = q.Where("status = ?", *p.Status)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("supplier_id, username").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) UpdateAccount(ctx context.Context, p AccountUpdateParams) error {
+	updates := map[string]any{}
+	if p.DisplayName != nil || p.ClearDisplayName {
+		updates["display_name"] = p.DisplayName
+	}
+	if p.Phone != nil || p.ClearPhone {
+		updates["phone"] = p.Phone
+	}
+	if p.Scopes != nil {
+		updates["scopes"] = *p.Scopes
+	}
+	if p.Status != nil {
+		updates["status"] = *p.Status
+	}
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&domain.Account{}).
+		Where("id = ? AND is_deleted = 0", p.ID), p.Version, updates)
+}
+
+func (r *repo) UpdatePasswordHash(ctx context.Context, id, hash string) error {
+	res := r.db.WithContext(ctx).Model(&domain.Account{}).
+		Where("id = ? AND is_deleted = 0", id).
+		Update("password_hash", hash)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repo) TouchLogin(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&domain.Account{}).
+		Where("id = ?", id).
+		UpdateColumn("last_login_at", at).Error
+}
+
+func (r *repo) SoftDeleteAccount(ctx context.Context, id string) error {
+	res := r.db.WithContext(ctx).Model(&domain.Account{}).
+		Where("id = ? AND is_deleted = 0", id).
+		Update("is_deleted", 1)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repo) SaveInvitation(ctx context.Context, m *domain.Invitation) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"closes_at", "invited_by", "updated_at"}),
+	}).Create(m).Error
+}
+
+func (r *repo) DeleteInvitation(ctx context.Context, inquiryID, supplierID string) error {
+	res := r.db.WithContext(ctx).
+		Where("inquiry_id = ? AND supplier_id = ?", inquiryID, supplierID).
+		Delete(&domain.Invitation{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repo) GetInvitation(ctx context.Context, inquiryID, supplierID string) (*domain.Invitation, error) {
+	var out domain.Invitation
+	err := r.db.WithContext(ctx).
+		Where("inquiry_id = ? AND supplier_id = ?", inquiryID, supplierID).
+		First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) ListInvitations(ctx context.Context, inquiryID string) ([]domain.Invitation, error) {
+	var list []domain.Invitation
+	err := r.db.WithContext(ctx).
+		Where("inquiry_id = ?", inquiryID).
+		Order("created_at").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) OpenInquiries(ctx context.Context, supplierID string, page, pageSize int) ([]domain.InquiryView, int64, error) {
+	var list []domain.InquiryView
+	var total int64
+
+	q := r.db.WithContext(ctx).Table("supplier_inquiry_invite AS v").
+		Joins("JOIN base_price_inquiry AS i ON i.id = v.inquiry_id").
+		Where("v.supplier_id = ? AND i.is_deleted = 0", supplierID)
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Select("i.id AS inquiry_id, i.inquiry_title, i.inquiry_date, i.status, v.closes_at, " +
+		"(SELECT COUNT(*) FROM base_goods_avg_detail AS d WHERE d.inquiry_id = i.id AND d.is_deleted = 0) AS items, " +
+		"(SELECT COUNT(*) FROM base_goods_price AS q WHERE q.inquiry_id = i.id AND q.supplier_id = v.supplier_id AND q.is_deleted = 0) AS quoted").
+		Order("i.inquiry_date DESC, i.created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Scan(&list).Error
+	return list, total, err
+}
+
+func (r *repo) InquiryItems(ctx context.Context, inquiryID, supplierID string) ([]domain.InquiryItem, error) {
+	var list []domain.InquiryItem
+	err := r.db.WithContext(ctx).Table("base_goods_avg_detail AS d").
+		Select("d.goods_id, COALESCE(g.name, '') AS goods_name, COALESCE(s.name, '') AS spec_name, "+
+			"COALESCE(u.name, '') AS unit_name, q.unit_price").
+		Joins("LEFT JOIN base_goods AS g ON g.id = d.goods_id").
+		Joins("LEFT JOIN base_spec AS s ON s.id = g.spec_id").
+		Joins("LEFT JOIN base_unit AS u ON u.id = g.unit_id").
+		Joins("LEFT JOIN base_goods_price AS q ON q.inquiry_id = d.inquiry_id AND q.goods_id = d.goods_id "+
+			"AND q.supplier_id = ? AND q.is_deleted = 0", supplierID).
+		Where("d.inquiry_id = ? AND d.is_deleted = 0", inquiryID).
+		Order("g.sort, g.name").
+		Scan(&list).Error
+	return list, err
+}
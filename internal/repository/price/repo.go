@@ -0,0 +1,77 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/price"
+)
+
+// ErrNotFound 记录不存在或状态不符
+var ErrNotFound = errors.New("记录不存在")
+
+type SampleParams struct {
+	OrgIDs      []string
+	GoodsIDs    []string
+	CategoryIDs []string
+	MarketID    *string // 给出时取该市场的价格，否则取各市场均价
+	From, To    time.Time
+}
+
+type FlagListParams struct {
+	OrgID     string
+	InquiryID *string
+	GoodsID   *string
+	LineType  *string
+	Status    *int // 为空默认待复核
+	Page      int
+	PageSize  int
+}
+
+type Repository interface {
+	// LatestAvgPrices 返回各商品截至 asOf（为空不限）最近一次有效询价的均价；无价格的商品不出现在结果中
+	LatestAvgPrices(ctx context.Context, orgID string, goodsIDs []string, asOf *time.Time) (map[string]domain.Point, error)
+	// MeanAvgPrices 返回各商品在 [from, to] 内各次询价均价的平均值；区间内无询价的商品不出现在结果中
+	MeanAvgPrices(ctx context.Context, orgID string, goodsIDs []string, from, to time.Time) (map[string]decimal.Decimal, error)
+	// Samples 返回询价样本；GoodsIDs 与 CategoryIDs 至少给出一个
+	Samples(ctx context.Context, p SampleParams) ([]domain.Sample, error)
+	// MatchingGoods 返回 orgIDs 内与 goodsID 同名、同规格、同单位的有效商品（商品按机构建档，跨机构按该自然键对应）
+	MatchingGoods(ctx context.Context, goodsID string, orgIDs []string) ([]string, error)
+	// MatchingCategories 返回 orgIDs 内与 categoryID 同名的有效品类
+	MatchingCategories(ctx context.Context, categoryID string, orgIDs []string) ([]string, error)
+	// ActiveQuotes 返回 at 时刻处于启用且在合作期内的供应商，对各商品的最近一次报价（每个供应商一条）
+	// 存在待复核异常标记的报价不参与
+	ActiveQuotes(ctx context.Context, orgID string, goodsIDs []string, at time.Time) (map[string][]domain.Quote, error)
+
+	// 询价明细/供应商报价（按询价单+商品[+供应商]唯一，存在则更新）；
+	// SaveInquiryLine 同时整体替换明细的各市场价，ListInquiryLines 返回带市场价的明细
+	SaveInquiryLine(ctx context.Context, m *domain.InquiryLine, prices []domain.LinePrice) error
+	ListInquiryLines(ctx context.Context, inquiryID string) ([]domain.InquiryLine, error)
+	// BackfillLegacyPrices 将询价单明细的 market{slot}_price 写入 slots[slot] 市场的价格（已存在的跳过），返回写入行数
+	BackfillLegacyPrices(ctx context.Context, inquiryID string, slots map[int]string) (int64, error)
+	SaveQuoteLine(ctx context.Context, m *domain.QuoteLine) error
+	GetQuoteLine(ctx context.Context, id string) (*domain.QuoteLine, error)
+	ListQuoteLines(ctx context.Context, inquiryID string) ([]domain.QuoteLine, error)
+	// DeleteQuoteLine 软删报价，并作废其待复核标记
+	DeleteQuoteLine(ctx context.Context, id string) error
+
+	// TrailingAvgPrices 返回 date 之前（不含 excludeInquiryID）最近 limit 次询价的均价
+	TrailingAvgPrices(ctx context.Context, orgID, goodsID, excludeInquiryID string, date time.Time, limit int) ([]decimal.Decimal, error)
+	// TrailingQuotePrices 返回 date 之前（不含 excludeInquiryID）最近 limit 条报价单价（不分供应商）
+	TrailingQuotePrices(ctx context.Context, orgID, goodsID, excludeInquiryID string, date time.Time, limit int) ([]decimal.Decimal, error)
+
+	// 异常规则/标记
+	GetRule(ctx context.Context, orgID string) (*domain.Rule, error)
+	SaveRule(ctx context.Context, m *domain.Rule) error
+	// ReplaceFlags 作废明细的待复核标记后写入 flags；与已确认标记（同字段、同类型、同值）重复的不再写入
+	ReplaceFlags(ctx context.Context, lineType, lineID string, flags []domain.Flag) ([]domain.Flag, error)
+	FlagsOf(ctx context.Context, lineType string, lineIDs []string) (map[string][]domain.Flag, error)
+	ListFlags(ctx context.Context, p FlagListParams) ([]domain.Flag, int64, error)
+	// AcceptFlag 确认待复核标记；不存在或已确认返回 ErrNotFound
+	AcceptFlag(ctx context.Context, id, reviewerID string, comment *string, at time.Time) error
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,477 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/price"
+	qualification "hdzk.cn/foodapp/internal/domain/qualification"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+const (
+	goodsTable    = "base_goods"
+	categoryTable = "base_category"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) LatestAvgPrices(ctx context.Context, orgID string, goodsIDs []string, asOf *time.Time) (map[string]domain.Point, error) {
+	out := make(map[string]domain.Point, len(goodsIDs))
+	if len(goodsIDs) == 0 {
+		return out, nil
+	}
+
+	var rows []domain.Point
+	q := utils.DB(ctx, r.db).Table(domain.AvgDetailTable+" AS d").
+		Select("d.goods_id, d.inquiry_id, i.inquiry_date, d.avg_price").
+		Joins("JOIN "+domain.InquiryTable+" AS i ON i.id = d.inquiry_id").
+		Where("d.is_deleted = 0 AND i.is_deleted = 0 AND i.org_id = ?", orgID).
+		Where("d.goods_id IN ? AND d.avg_price IS NOT NULL", goodsIDs)
+	if asOf != nil {
+		q = q.Where("i.inquiry_date <= ?", *asOf)
+	}
+	if err := q.Order("i.inquiry_date DESC, i.created_at DESC").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	// 已按日期倒序，每个商品取第一条
+	for _, p := range rows {
+		if _, ok := out[p.GoodsID]; !ok {
+			out[p.GoodsID] = p
+		}
+	}
+	return out, nil
+}
+
+func (r *repo) MeanAvgPrices(ctx context.Context, orgID string, goodsIDs []string, from, to time.Time) (map[string]decimal.Decimal, error) {
+	out := make(map[string]decimal.Decimal, len(goodsIDs))
+	if len(goodsIDs) == 0 {
+		return out, nil
+	}
+
+	var rows []struct {
+		GoodsID  string
+		AvgPrice decimal.Decimal
+	}
+	err := utils.DB(ctx, r.db).Table(domain.AvgDetailTable+" AS d").
+		Select("d.goods_id, AVG(d.avg_price) AS avg_price").
+		Joins("JOIN "+domain.InquiryTable+" AS i ON i.id = d.inquiry_id").
+		Where("d.is_deleted = 0 AND i.is_deleted = 0 AND i.org_id = ?", orgID).
+		Where("d.goods_id IN ? AND d.avg_price IS NOT NULL", goodsIDs).
+		Where("i.inquiry_date >= ? AND i.inquiry_date <= ?", from, to).
+		Group("d.goods_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range rows {
+		out[x.GoodsID] = x.AvgPrice.Round(2)
+	}
+	return out, nil
+}
+
+// 各市场价中的最低/最高：取市场价明细，未迁移的旧数据回退到 market1..3_price（忽略空值）
+const (
+	legacyMinPrice = "LEAST(COALESCE(d.market1_price, d.market2_price, d.market3_price), COALESCE(d.market2_price, d.market1_price, d.market3_price), COALESCE(d.market3_price, d.market1_price, d.market2_price))"
+	legacyMaxPrice = "GREATEST(COALESCE(d.market1_price, d.market2_price, d.market3_price), COALESCE(d.market2_price, d.market1_price, d.market3_price), COALESCE(d.market3_price, d.market1_price, d.market2_price))"
+	minMarketPrice = "COALESCE((SELECT MIN(mp.price) FROM " + domain.LinePriceTable + " AS mp WHERE mp.line_id = d.id), " + legacyMinPrice + ")"
+	maxMarketPrice = "COALESCE((SELECT MAX(mp.price) FROM " + domain.LinePriceTable + " AS mp WHERE mp.line_id = d.id), " + legacyMaxPrice + ")"
+)
+
+func (r *repo) Samples(ctx context.Context, p SampleParams) ([]domain.Sample, error) {
+	var rows []domain.Sample
+	if len(p.OrgIDs) == 0 || (len(p.GoodsIDs) == 0 && len(p.CategoryIDs) == 0) {
+		return rows, nil
+	}
+	q := utils.DB(ctx, r.db).Table(domain.AvgDetailTable+" AS d").
+		Joins("JOIN "+domain.InquiryTable+" AS i ON i.id = d.inquiry_id").
+		Where("d.is_deleted = 0 AND i.is_deleted = 0 AND i.org_id IN ?", p.OrgIDs).
+		Where("i.inquiry_date >= ? AND i.inquiry_date <= ?", p.From, p.To)
+	if p.MarketID != nil {
+		// 单个市场的走势：均价/最低/最高均为该市场价
+		q = q.Select("d.goods_id, i.org_id, d.inquiry_id, i.inquiry_date, mp.price AS avg_price, mp.price AS min_price, mp.price AS max_price").
+			Joins("JOIN "+domain.LinePriceTable+" AS mp ON mp.line_id = d.id AND mp.market_id = ?", *p.MarketID)
+	} else {
+		q = q.Select("d.goods_id, i.org_id, d.inquiry_id, i.inquiry_date, d.avg_price, " +
+			minMarketPrice + " AS min_price, " + maxMarketPrice + " AS max_price").
+			Where("d.avg_price IS NOT NULL")
+	}
+	if len(p.GoodsIDs) > 0 {
+		q = q.Where("d.goods_id IN ?", p.GoodsIDs)
+	}
+	if len(p.CategoryIDs) > 0 {
+		q = q.Joins("JOIN "+goodsTable+" AS g ON g.id = d.goods_id").
+			Where("g.category_id IN ?", p.CategoryIDs)
+	}
+	err := q.Order("d.goods_id, i.inquiry_date").Scan(&rows).Error
+	return rows, err
+}
+
+func (r *repo) MatchingGoods(ctx context.Context, goodsID string, orgIDs []string) ([]string, error) {
+	var ids []string
+	if len(orgIDs) == 0 {
+		return ids, nil
+	}
+	err := utils.DB(ctx, r.db).Table(goodsTable+" AS g").
+		Joins("JOIN "+goodsTable+" AS s ON s.name = g.name AND s.spec_id = g.spec_id AND s.unit_id = g.unit_id").
+		Where("s.id = ? AND g.is_deleted = 0 AND g.org_id IN ?", goodsID, orgIDs).
+		Order("g.id").
+		Pluck("g.id", &ids).Error
+	return ids, err
+}
+
+func (r *repo) MatchingCategories(ctx context.Context, categoryID string, orgIDs []string) ([]string, error) {
+	var ids []string
+	if len(orgIDs) == 0 {
+		return ids, nil
+	}
+	err := utils.DB(ctx, r.db).Table(categoryTable+" AS c").
+		Joins("JOIN "+categoryTable+" AS s ON s.name = c.name").
+		Where("s.id = ? AND c.is_deleted = 0 AND c.org_id IN ?", categoryID, orgIDs).
+		Order("c.id").
+		Pluck("c.id", &ids).Error
+	return ids, err
+}
+
+// lapsedSupplierCond 排除必备资质已过期的供应商：某类必备资质有已审核文件，但无一在当日有效
+var lapsedSupplierCond = "NOT EXISTS (SELECT 1 FROM " + qualification.Document{}.TableName() + ` AS d
+	WHERE d.supplier_id = q.supplier_id AND d.is_deleted = 0 AND d.verify_status = 1 AND d.doc_type IN ?
+	AND NOT EXISTS (SELECT 1 FROM ` + qualification.Document{}.TableName() + ` AS d2
+		WHERE d2.supplier_id = d.supplier_id AND d2.doc_type = d.doc_type AND d2.is_deleted = 0 AND d2.verify_status = 1
+		AND (d2.expiry_date IS NULL OR d2.expiry_date >= DATE(?))))`
+
+func (r *repo) ActiveQuotes(ctx context.Context, orgID string, goodsIDs []string, at time.Time) (map[string][]domain.Quote, error) {
+	out := make(map[string][]domain.Quote, len(goodsIDs))
+	if len(goodsIDs) == 0 {
+		return out, nil
+	}
+
+	var rows []domain.Quote
+	err := utils.DB(ctx, r.db).Table(domain.QuoteTable+" AS q").
+		Select(`q.goods_id, q.supplier_id, s.name AS supplier_name, q.inquiry_id,
+			i.inquiry_title, i.inquiry_date, q.unit_price, q.float_ratio`).
+		Joins("JOIN "+domain.SupplierTable+" AS s ON s.id = q.supplier_id").
+		Joins("JOIN "+domain.InquiryTable+" AS i ON i.id = q.inquiry_id").
+		Where("q.is_deleted = 0 AND i.is_deleted = 0 AND s.is_deleted = 0 AND s.status = 1").
+		Where("i.org_id = ? AND q.goods_id IN ?", orgID, goodsIDs).
+		Where("(s.start_time IS NULL OR s.start_time <= ?) AND (s.end_time IS NULL OR s.end_time >= ?)", at, at).
+		Where(lapsedSupplierCond, qualification.MandatoryTypes(), at).
+		Where("NOT EXISTS (SELECT 1 FROM "+domain.Flag{}.TableName()+" AS f WHERE f.line_type = ? AND f.line_id = q.id AND f.status = ? AND f.is_deleted = 0)",
+			domain.LineQuote, domain.FlagPending).
+		Order("i.inquiry_date DESC, i.created_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[[2]string]bool, len(rows))
+	for _, q := range rows {
+		k := [2]string{q.GoodsID, q.SupplierID}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out[q.GoodsID] = append(out[q.GoodsID], q)
+	}
+	return out, nil
+}
+
+func (r *repo) SaveInquiryLine(ctx context.Context, m *domain.InquiryLine, prices []domain.LinePrice) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// 唯一键包含已软删的行，存在即复用
+		var cur domain.InquiryLine
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("inquiry_id = ? AND goods_id = ?", m.InquiryID, m.GoodsID).
+			First(&cur).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(m).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			m.ID = cur.ID
+			if err := tx.Model(&domain.InquiryLine{}).Where("id = ?", cur.ID).Updates(map[string]any{
+				"guide_price":   m.GuidePrice,
+				"market1_price": m.Market1Price,
+				"market2_price": m.Market2Price,
+				"market3_price": m.Market3Price,
+				"avg_price":     m.AvgPrice,
+				"org_id":        m.OrgID,
+				"is_deleted":    0,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("line_id = ?", m.ID).Delete(&domain.LinePrice{}).Error; err != nil {
+			return err
+		}
+		if len(prices) > 0 {
+			for i := range prices {
+				prices[i].ID, prices[i].LineID, prices[i].InquiryID, prices[i].GoodsID = "", m.ID, m.InquiryID, m.GoodsID
+			}
+			if err := tx.Create(&prices).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("id = ?", m.ID).First(m).Error; err != nil {
+			return err
+		}
+		m.Prices = prices
+		return nil
+	})
+}
+
+func (r *repo) ListInquiryLines(ctx context.Context, inquiryID string) ([]domain.InquiryLine, error) {
+	var list []domain.InquiryLine
+	db := utils.DB(ctx, r.db)
+	if err := db.Where("inquiry_id = ? AND is_deleted = 0", inquiryID).
+		Order("created_at, id").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	var prices []domain.LinePrice
+	if err := db.Where("inquiry_id = ?", inquiryID).Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	byLine := make(map[string][]domain.LinePrice, len(list))
+	for _, p := range prices {
+		byLine[p.LineID] = append(byLine[p.LineID], p)
+	}
+	for i := range list {
+		list[i].Prices = byLine[list[i].ID]
+		if list[i].Prices == nil {
+			list[i].Prices = []domain.LinePrice{}
+		}
+	}
+	return list, nil
+}
+
+func (r *repo) BackfillLegacyPrices(ctx context.Context, inquiryID string, slots map[int]string) (int64, error) {
+	var total int64
+	err := utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for slot := 1; slot <= 3; slot++ {
+			marketID, ok := slots[slot]
+			if !ok {
+				continue
+			}
+			col := fmt.Sprintf("d.market%d_price", slot)
+			res := tx.Exec("INSERT IGNORE INTO "+domain.LinePriceTable+
+				" (id, inquiry_id, line_id, market_id, goods_id, price, created_at, updated_at)"+
+				" SELECT UUID(), d.inquiry_id, d.id, ?, d.goods_id, "+col+", NOW(), NOW()"+
+				" FROM "+domain.AvgDetailTable+" AS d"+
+				" WHERE d.inquiry_id = ? AND d.is_deleted = 0 AND "+col+" IS NOT NULL", marketID, inquiryID)
+			if res.Error != nil {
+				return res.Error
+			}
+			total += res.RowsAffected
+		}
+		return nil
+	})
+	return total, err
+}
+
+func (r *repo) SaveQuoteLine(ctx context.Context, m *domain.QuoteLine) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var cur domain.QuoteLine
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("inquiry_id = ? AND supplier_id = ? AND goods_id = ?", m.InquiryID, m.SupplierID, m.GoodsID).
+			First(&cur).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return tx.Create(m).Error
+		case err != nil:
+			return err
+		}
+		m.ID, m.CreatedAt = cur.ID, cur.CreatedAt
+		return tx.Model(&domain.QuoteLine{}).Where("id = ?", cur.ID).Updates(map[string]any{
+			"unit_price":  m.UnitPrice,
+			"float_ratio": m.FloatRatio,
+			"org_id":      m.OrgID,
+			"is_deleted":  0,
+		}).Error
+	})
+}
+
+func (r *repo) GetQuoteLine(ctx context.Context, id string) (*domain.QuoteLine, error) {
+	var out domain.QuoteLine
+	if err := utils.DB(ctx, r.db).Where("id = ? AND is_deleted = 0", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) DeleteQuoteLine(ctx context.Context, id string) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.QuoteLine{}).Where("id = ?", id).Update("is_deleted", 1).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Flag{}).
+			Where("line_type = ? AND line_id = ? AND status = ? AND is_deleted = 0", domain.LineQuote, id, domain.FlagPending).
+			Update("is_deleted", 1).Error
+	})
+}
+
+func (r *repo) ListQuoteLines(ctx context.Context, inquiryID string) ([]domain.QuoteLine, error) {
+	var list []domain.QuoteLine
+	err := utils.DB(ctx, r.db).
+		Where("inquiry_id = ? AND is_deleted = 0", inquiryID).
+		Order("goods_id, supplier_id").Find(&list).Error
+	return list, err
+}
+
+func (r *repo) TrailingAvgPrices(ctx context.Context, orgID, goodsID, excludeInquiryID string, date time.Time, limit int) ([]decimal.Decimal, error) {
+	var out []decimal.Decimal
+	err := utils.DB(ctx, r.db).Table(domain.AvgDetailTable+" AS d").
+		Joins("JOIN "+domain.InquiryTable+" AS i ON i.id = d.inquiry_id").
+		Where("d.is_deleted = 0 AND i.is_deleted = 0 AND d.avg_price IS NOT NULL").
+		Where("i.org_id = ? AND d.goods_id = ? AND i.id <> ? AND i.inquiry_date <= ?", orgID, goodsID, excludeInquiryID, date).
+		Order("i.inquiry_date DESC, i.created_at DESC").
+		Limit(limit).Pluck("d.avg_price", &out).Error
+	return out, err
+}
+
+func (r *repo) TrailingQuotePrices(ctx context.Context, orgID, goodsID, excludeInquiryID string, date time.Time, limit int) ([]decimal.Decimal, error) {
+	var out []decimal.Decimal
+	err := utils.DB(ctx, r.db).Table(domain.QuoteTable+" AS q").
+		Joins("JOIN "+domain.InquiryTable+" AS i ON i.id = q.inquiry_id").
+		Where("q.is_deleted = 0 AND i.is_deleted = 0").
+		Where("i.org_id = ? AND q.goods_id = ? AND i.id <> ? AND i.inquiry_date <= ?", orgID, goodsID, excludeInquiryID, date).
+		Order("i.inquiry_date DESC, i.created_at DESC").
+		Limit(limit).Pluck("q.unit_price", &out).Error
+	return out, err
+}
+
+func (r *repo) GetRule(ctx context.Context, orgID string) (*domain.Rule, error) {
+	var out domain.Rule
+	err := utils.DB(ctx, r.db).Where("org_id = ?", orgID).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) SaveRule(ctx context.Context, m *domain.Rule) error {
+	return utils.DB(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "org_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"band_pct", "spread_pct", "window_size", "min_samples", "updated_at"}),
+	}).Create(m).Error
+}
+
+func (r *repo) ReplaceFlags(ctx context.Context, lineType, lineID string, flags []domain.Flag) ([]domain.Flag, error) {
+	out := []domain.Flag{}
+	err := utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&domain.Flag{}).
+			Where("line_type = ? AND line_id = ? AND status = ? AND is_deleted = 0", lineType, lineID, domain.FlagPending).
+			Update("is_deleted", 1).Error; err != nil {
+			return err
+		}
+		var accepted []domain.Flag
+		if err := tx.Where("line_type = ? AND line_id = ? AND status = ? AND is_deleted = 0", lineType, lineID, domain.FlagAccepted).
+			Find(&accepted).Error; err != nil {
+			return err
+		}
+		for _, f := range flags {
+			dup := false
+			for _, a := range accepted {
+				if a.Field == f.Field && a.Kind == f.Kind && a.Value.Equal(f.Value) && sameMarket(a.MarketID, f.MarketID) {
+					dup = true
+					break
+				}
+			}
+			if dup {
+				continue
+			}
+			f.LineType, f.LineID = lineType, lineID
+			if err := tx.Create(&f).Error; err != nil {
+				return err
+			}
+			out = append(out, f)
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (r *repo) FlagsOf(ctx context.Context, lineType string, lineIDs []string) (map[string][]domain.Flag, error) {
+	out := make(map[string][]domain.Flag, len(lineIDs))
+	if len(lineIDs) == 0 {
+		return out, nil
+	}
+	var list []domain.Flag
+	err := utils.DB(ctx, r.db).
+		Where("line_type = ? AND line_id IN ? AND is_deleted = 0", lineType, lineIDs).
+		Order("created_at, field").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range list {
+		out[f.LineID] = append(out[f.LineID], f)
+	}
+	return out, nil
+}
+
+func (r *repo) ListFlags(ctx context.Context, p FlagListParams) ([]domain.Flag, int64, error) {
+	var list []domain.Flag
+	var total int64
+
+	status := domain.FlagPending
+	if p.Status != nil {
+		status = *p.Status
+	}
+	q := utils.DB(ctx, r.db).Model(&domain.Flag{}).
+		Where("is_deleted = 0 AND org_id = ? AND status = ?", p.OrgID, status)
+	if p.InquiryID != nil {
+		q = q.Where("inquiry_id = ?", *p.InquiryID)
+	}
+	if p.GoodsID != nil {
+		q = q.Where("goods_id = ?", *p.GoodsID)
+	}
+	if p.LineType != nil {
+		q = q.Where("line_type = ?", *p.LineType)
+	}
+
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("created_at DESC, id").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) AcceptFlag(ctx context.Context, id, reviewerID string, comment *string, at time.Time) error {
+	res := utils.DB(ctx, r.db).Model(&domain.Flag{}).
+		Where("id = ? AND status = ? AND is_deleted = 0", id, domain.FlagPending).
+		Updates(map[string]any{
+			"status":      domain.FlagAccepted,
+			"comment":     comment,
+			"reviewer_id": reviewerID,
+			"reviewed_at": at,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func sameMarket(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
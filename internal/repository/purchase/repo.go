@@ -0,0 +1,44 @@
+package purchase
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/purchase"
+)
+
+type ListParams struct {
+	OrgID        string
+	SupplierID   *string
+	Status       *int
+	ExcludeDraft bool // 不含草稿（供应商门户）
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	Page         int
+	PageSize     int
+}
+
+// ReceiveParams 收货登记；Lines 为明细ID → 实收数量，未列出的明细按实收 0 记录
+type ReceiveParams struct {
+	ID         string
+	ReceivedAt time.Time
+	ReceivedBy *string
+	Lines      map[string]decimal.Decimal
+}
+
+type Repository interface {
+	// Create 写入采购单及明细，合计金额按明细累加
+	Create(ctx context.Context, m *domain.Order) error
+	Get(ctx context.Context, id string) (*domain.Order, error)
+	List(ctx context.Context, params ListParams) ([]domain.Order, int64, error)
+	// UpdateStatus 仅当当前状态为 from 时更新为 to
+	UpdateStatus(ctx context.Context, id string, from, to int) error
+	// Receive 已提交 → 已收货，并写入各明细实收数量
+	Receive(ctx context.Context, p ReceiveParams) error
+	// SoftDeleteDraft 仅允许删除草稿
+	SoftDeleteDraft(ctx context.Context, id string) error
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,152 @@
+package purchase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/purchase"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, m *domain.Order) error {
+	if len(m.Lines) == 0 {
+		return errors.New("采购单至少需要一行明细")
+	}
+	m.Amount = decimal.Zero
+	for i := range m.Lines {
+		m.Lines[i].Compute()
+		m.Amount = m.Amount.Add(m.Lines[i].Amount)
+	}
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		for i := range m.Lines {
+			m.Lines[i].ID = ""
+			m.Lines[i].OrderID = m.ID
+			if m.Lines[i].Sort <= 0 {
+				m.Lines[i].Sort = i + 1
+			}
+		}
+		return tx.Create(&m.Lines).Error
+	})
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Order, error) {
+	var out domain.Order
+	db := utils.DB(ctx, r.db)
+	if err := db.Where("id = ? AND is_deleted = 0", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("order_id = ?", id).Order("sort ASC").Find(&out.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Order, int64, error) {
+	var list []domain.Order
+	var total int64
+
+	q := utils.DB(ctx, r.db).Model(&domain.Order{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.SupplierID != nil {
+		q = q.Where("supplier_id = ?", *p.SupplierID)
+	}
+	if p.Status != nil {
+		q = q.Where("status = ?", *p.Status)
+	}
+	if p.ExcludeDraft {
+		q = q.Where("status <> ?", domain.StatusDraft)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("expected_date >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("expected_date <= ?", *p.DateTo)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("expected_date DESC, created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) UpdateStatus(ctx context.Context, id string, from, to int) error {
+	res := utils.DB(ctx, r.db).Model(&domain.Order{}).
+		Where("id = ? AND is_deleted = 0 AND status = ?", id, from).
+		Update("status", to)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("采购单不存在或状态已变更")
+	}
+	return nil
+}
+
+func (r *repo) Receive(ctx context.Context, p ReceiveParams) error {
+	return utils.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var lines []domain.Line
+		if err := tx.Where("order_id = ?", p.ID).Find(&lines).Error; err != nil {
+			return err
+		}
+		known := make(map[string]bool, len(lines))
+		for _, l := range lines {
+			known[l.ID] = true
+		}
+		for id := range p.Lines {
+			if !known[id] {
+				return fmt.Errorf("明细 %s 不属于该采购单", id)
+			}
+		}
+
+		res := tx.Model(&domain.Order{}).
+			Where("id = ? AND is_deleted = 0 AND status = ?", p.ID, domain.StatusSubmitted).
+			Updates(map[string]any{
+				"status":      domain.StatusReceived,
+				"received_at": p.ReceivedAt,
+				"received_by": p.ReceivedBy,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errors.New("仅已提交的采购单可收货")
+		}
+		for _, l := range lines {
+			qty := p.Lines[l.ID]
+			if err := tx.Model(&domain.Line{}).Where("id = ?", l.ID).
+				Update("received_qty", qty).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *repo) SoftDeleteDraft(ctx context.Context, id string) error {
+	res := utils.DB(ctx, r.db).Model(&domain.Order{}).
+		Where("id = ? AND status = ?", id, domain.StatusDraft).
+		Update("is_deleted", 1)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("仅草稿状态的采购单可删除")
+	}
+	return nil
+}
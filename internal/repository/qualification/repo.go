@@ -0,0 +1,56 @@
+package qualification
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/qualification"
+)
+
+// UpdateParams 修改证照信息；日期变更后需重新审核
+type UpdateParams struct {
+	ID              string
+	Version         int // 期望版本号（乐观锁）
+	DocNo           *string
+	IssueDate       *time.Time
+	ExpiryDate      *time.Time
+	Remark          *string
+	UpdateDocNo     bool
+	UpdateIssueDate bool
+	UpdateExpiry    bool
+	UpdateRemark    bool
+}
+
+type VerifyParams struct {
+	ID         string
+	Status     int
+	Remark     *string
+	VerifiedBy *string
+	VerifiedAt time.Time
+}
+
+type ListParams struct {
+	OrgID        string
+	SupplierID   *string
+	DocType      *string
+	VerifyStatus *int
+	ExpireBefore *time.Time // 有效期至不晚于该日期（不含长期有效）
+	Page         int
+	PageSize     int // <0 表示不分页（导出）
+}
+
+type Repository interface {
+	Create(ctx context.Context, d *domain.Document) error
+	Get(ctx context.Context, id string) (*domain.Document, error)
+	List(ctx context.Context, p ListParams) ([]domain.Document, int64, error)
+	Update(ctx context.Context, p UpdateParams) error
+	Verify(ctx context.Context, p VerifyParams) error
+	SoftDelete(ctx context.Context, id string) error
+	// ListBySupplier 供应商全部有效记录（用于汇总资质状态）
+	ListBySupplier(ctx context.Context, supplierID string) ([]domain.Document, error)
+	// ExpiringApproved 已审核、有效期至在 [from, to] 内的文件
+	ExpiringApproved(ctx context.Context, from, to time.Time) ([]domain.Document, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,139 @@
+package qualification
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/qualification"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, d *domain.Document) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Document, error) {
+	var out domain.Document
+	err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Document, int64, error) {
+	var list []domain.Document
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Document{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.SupplierID != nil {
+		q = q.Where("supplier_id = ?", *p.SupplierID)
+	}
+	if p.DocType != nil {
+		q = q.Where("doc_type = ?", *p.DocType)
+	}
+	if p.VerifyStatus != nil {
+		q = q.Where("verify_status = ?", *p.VerifyStatus)
+	}
+	if p.ExpireBefore != nil {
+		q = q.Where("expiry_date IS NOT NULL AND expiry_date <= ?", *p.ExpireBefore)
+	}
+
+	q.Count(&total)
+	q = q.Order("supplier_id, doc_type, expiry_date DESC")
+	if p.PageSize >= 0 {
+		page, pageSize := p.Page, p.PageSize
+		if page < 1 {
+			page = 1
+		}
+		if pageSize <= 0 || pageSize > 1000 {
+			pageSize = 20
+		}
+		q = q.Limit(pageSize).Offset((page - 1) * pageSize)
+	}
+	err := q.Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) Update(ctx context.Context, p UpdateParams) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur domain.Document
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_deleted = 0", p.ID).First(&cur).Error; err != nil {
+			return err
+		}
+		if cur.Version != p.Version {
+			return utils.ErrVersionConflict
+		}
+		updates := map[string]any{}
+		if p.UpdateDocNo {
+			updates["doc_no"] = p.DocNo
+		}
+		if p.UpdateRemark {
+			updates["remark"] = p.Remark
+		}
+		if p.UpdateIssueDate {
+			updates["issue_date"] = p.IssueDate
+		}
+		if p.UpdateExpiry {
+			updates["expiry_date"] = p.ExpiryDate
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		// 证照编号或日期变化后原审核结论失效
+		if p.UpdateDocNo || p.UpdateIssueDate || p.UpdateExpiry {
+			updates["verify_status"] = domain.VerifyPending
+			updates["verified_by"] = nil
+			updates["verified_at"] = nil
+		}
+		return utils.UpdateVersioned(tx.Model(&domain.Document{}).Where("id = ?", cur.ID), p.Version, updates)
+	})
+}
+
+func (r *repo) Verify(ctx context.Context, p VerifyParams) error {
+	res := r.db.WithContext(ctx).Model(&domain.Document{}).
+		Where("id = ? AND is_deleted = 0", p.ID).
+		Updates(map[string]any{
+			"verify_status": p.Status,
+			"verify_remark": p.Remark,
+			"verified_by":   p.VerifiedBy,
+			"verified_at":   p.VerifiedAt,
+			"version":       gorm.Expr("version + 1"),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repo) SoftDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Document{}).
+		Where("id = ?", id).Update("is_deleted", 1).Error
+}
+
+func (r *repo) ListBySupplier(ctx context.Context, supplierID string) ([]domain.Document, error) {
+	var list []domain.Document
+	err := r.db.WithContext(ctx).
+		Where("supplier_id = ? AND is_deleted = 0", supplierID).
+		Order("doc_type, expiry_date DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) ExpiringApproved(ctx context.Context, from, to time.Time) ([]domain.Document, error) {
+	var list []domain.Document
+	err := r.db.WithContext(ctx).
+		Where("is_deleted = 0 AND verify_status = ? AND expiry_date >= ? AND expiry_date <= ?", domain.VerifyApproved, from, to).
+		Order("expiry_date, org_id").
+		Find(&list).Error
+	return list, err
+}
@@ -0,0 +1,34 @@
+package recipe
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/recipe"
+)
+
+type UpdateParams struct {
+	ID      string
+	Version int // 期望行版本号（乐观锁）
+	Name    *string
+	Remark  *string
+}
+
+type Repository interface {
+	// Create 新建配方并写入版本 1
+	Create(ctx context.Context, m *domain.Recipe, v *domain.Version) error
+	Get(ctx context.Context, id string) (*domain.Recipe, error)
+	List(ctx context.Context, orgID, keyword string, page, pageSize int) ([]domain.Recipe, int64, error)
+	ExistsName(ctx context.Context, orgID, name, excludeID string) (bool, error)
+	Update(ctx context.Context, params UpdateParams) error
+	SoftDelete(ctx context.Context, id string) error
+
+	// AddVersion 追加新版本并设为当前版本，版本号在锁内分配
+	AddVersion(ctx context.Context, recipeID string, v *domain.Version) error
+	ListVersions(ctx context.Context, recipeID string) ([]domain.Version, error)
+	// GetVersion 返回指定版本及用料行；version<=0 取当前版本
+	GetVersion(ctx context.Context, recipeID string, version int) (*domain.Version, error)
+	SetCurrentVersion(ctx context.Context, recipeID string, version int) error
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
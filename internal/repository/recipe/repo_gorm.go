@@ -0,0 +1,172 @@
+package recipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	domain "hdzk.cn/foodapp/internal/domain/recipe"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, m *domain.Recipe, v *domain.Version) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		m.CurrentVersion = 1
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		v.RecipeID, v.Version = m.ID, 1
+		return createVersion(tx, v)
+	})
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Recipe, error) {
+	var out domain.Recipe
+	err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, orgID, keyword string, page, pageSize int) ([]domain.Recipe, int64, error) {
+	var list []domain.Recipe
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Recipe{}).
+		Where("is_deleted = 0 AND org_id = ?", orgID)
+	if keyword != "" {
+		q = q.Where("name LIKE ?", "%"+keyword+"%")
+	}
+
+	q.Count(&total)
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("name ASC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) ExistsName(ctx context.Context, orgID, name, excludeID string) (bool, error) {
+	var n int64
+	q := r.db.WithContext(ctx).Model(&domain.Recipe{}).
+		Where("is_deleted = 0 AND org_id = ? AND name = ?", orgID, name)
+	if excludeID != "" {
+		q = q.Where("id <> ?", excludeID)
+	}
+	err := q.Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) Update(ctx context.Context, p UpdateParams) error {
+	updates := map[string]any{}
+	if p.Name != nil {
+		updates["name"] = *p.Name
+	}
+	if p.Remark != nil {
+		updates["remark"] = *p.Remark
+	}
+	return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&domain.Recipe{}).
+		Where("id = ? AND is_deleted = 0", p.ID), p.Version, updates)
+}
+
+func (r *repo) SoftDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Recipe{}).
+		Where("id = ?", id).Update("is_deleted", 1).Error
+}
+
+func (r *repo) AddVersion(ctx context.Context, recipeID string, v *domain.Version) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m domain.Recipe
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND is_deleted = 0", recipeID).First(&m).Error
+		if err != nil {
+			return err
+		}
+		var maxVer int
+		if err := tx.Model(&domain.Version{}).Where("recipe_id = ?", recipeID).
+			Select("COALESCE(MAX(version), 0)").Scan(&maxVer).Error; err != nil {
+			return err
+		}
+		v.RecipeID, v.Version = recipeID, maxVer+1
+		if err := createVersion(tx, v); err != nil {
+			return err
+		}
+		return tx.Model(&domain.Recipe{}).Where("id = ?", recipeID).
+			Updates(map[string]any{"current_version": v.Version, "version": gorm.Expr("version + 1")}).Error
+	})
+}
+
+func (r *repo) ListVersions(ctx context.Context, recipeID string) ([]domain.Version, error) {
+	var list []domain.Version
+	err := r.db.WithContext(ctx).Where("recipe_id = ?", recipeID).
+		Order("version DESC").Find(&list).Error
+	return list, err
+}
+
+func (r *repo) GetVersion(ctx context.Context, recipeID string, version int) (*domain.Version, error) {
+	db := r.db.WithContext(ctx)
+	if version <= 0 {
+		m, err := r.Get(ctx, recipeID)
+		if err != nil {
+			return nil, err
+		}
+		version = m.CurrentVersion
+	}
+	var out domain.Version
+	if err := db.Where("recipe_id = ? AND version = ?", recipeID, version).First(&out).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where("version_id = ?", out.ID).Order("sort ASC").Find(&out.Lines).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) SetCurrentVersion(ctx context.Context, recipeID string, version int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var n int64
+		if err := tx.Model(&domain.Version{}).
+			Where("recipe_id = ? AND version = ?", recipeID, version).Count(&n).Error; err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("版本 %d 不存在", version)
+		}
+		res := tx.Model(&domain.Recipe{}).Where("id = ? AND is_deleted = 0", recipeID).
+			Updates(map[string]any{"current_version": version, "version": gorm.Expr("version + 1")})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func createVersion(tx *gorm.DB, v *domain.Version) error {
+	if len(v.Lines) == 0 {
+		return errors.New("配方至少需要一行用料")
+	}
+	if err := tx.Create(v).Error; err != nil {
+		return err
+	}
+	for i := range v.Lines {
+		v.Lines[i].ID = ""
+		v.Lines[i].VersionID = v.ID
+		if v.Lines[i].Sort <= 0 {
+			v.Lines[i].Sort = i + 1
+		}
+	}
+	return tx.Create(&v.Lines).Error
+}
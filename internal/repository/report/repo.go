@@ -0,0 +1,23 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/report"
+)
+
+type Repository interface {
+	// GetLayout 未配置时返回 gorm.ErrRecordNotFound
+	GetLayout(ctx context.Context, orgID, kind string) (*domain.Layout, error)
+	// SaveLayout 按机构+单据类型覆盖保存
+	SaveLayout(ctx context.Context, m *domain.Layout) error
+
+	// GoodsInfo 返回商品名称、规格、单位（含已删除商品）
+	GoodsInfo(ctx context.Context, ids []string) (map[string]domain.GoodsInfo, error)
+	// SettlementLines 供应商在 [from, to] 内已提交及已收货采购单的明细，按到货日期、采购单、明细顺序排列
+	SettlementLines(ctx context.Context, orgID, supplierID string, from, to time.Time) ([]domain.SettlementLine, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
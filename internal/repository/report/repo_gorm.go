@@ -0,0 +1,67 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	purchase "hdzk.cn/foodapp/internal/domain/purchase"
+	domain "hdzk.cn/foodapp/internal/domain/report"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) GetLayout(ctx context.Context, orgID, kind string) (*domain.Layout, error) {
+	var out domain.Layout
+	err := r.db.WithContext(ctx).Where("org_id = ? AND kind = ?", orgID, kind).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) SaveLayout(ctx context.Context, m *domain.Layout) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "org_id"}, {Name: "kind"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"title", "subtitle", "signers", "page_size", "orientation", "font_size", "show_guide_price", "footer", "updated_at",
+		}),
+	}).Create(m).Error
+}
+
+func (r *repo) GoodsInfo(ctx context.Context, ids []string) (map[string]domain.GoodsInfo, error) {
+	out := make(map[string]domain.GoodsInfo, len(ids))
+	if len(ids) == 0 {
+		return out, nil
+	}
+	var rows []domain.GoodsInfo
+	err := r.db.WithContext(ctx).Table("base_goods AS g").
+		Select("g.id, g.name, COALESCE(s.name, '') AS spec_name, COALESCE(u.name, '') AS unit_name").
+		Joins("LEFT JOIN base_spec AS s ON s.id = g.spec_id").
+		Joins("LEFT JOIN base_unit AS u ON u.id = g.unit_id").
+		Where("g.id IN ?", ids).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range rows {
+		out[v.ID] = v
+	}
+	return out, nil
+}
+
+func (r *repo) SettlementLines(ctx context.Context, orgID, supplierID string, from, to time.Time) ([]domain.SettlementLine, error) {
+	var rows []domain.SettlementLine
+	err := r.db.WithContext(ctx).Table("purchase_order AS o").
+		Select("o.expected_date, o.id AS order_id, COALESCE(g.name, '') AS goods_name, COALESCE(u.name, '') AS unit_name, "+
+			"l.quantity, l.unit_price, l.float_ratio, l.settle_price, l.amount").
+		Joins("JOIN purchase_order_line AS l ON l.order_id = o.id").
+		Joins("LEFT JOIN base_goods AS g ON g.id = l.goods_id").
+		Joins("LEFT JOIN base_unit AS u ON u.id = l.unit_id").
+		Where("o.is_deleted = 0 AND o.status IN ? AND o.org_id = ? AND o.supplier_id = ?", []int{purchase.StatusSubmitted, purchase.StatusReceived}, orgID, supplierID).
+		Where("o.expected_date >= ? AND o.expected_date <= ?", from, to).
+		Order("o.expected_date, o.created_at, o.id, l.sort, l.id").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,42 @@
+package scorecard
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/scorecard"
+)
+
+type RatingListParams struct {
+	OrgID      string
+	SupplierID *string
+	Kind       *string
+	DateFrom   *time.Time
+	DateTo     *time.Time
+	Page       int
+	PageSize   int
+}
+
+type Repository interface {
+	CreateRating(ctx context.Context, m *domain.Rating) error
+	ListRatings(ctx context.Context, p RatingListParams) ([]domain.Rating, int64, error)
+	SoftDeleteRating(ctx context.Context, id string) error
+
+	// Metrics 统计机构内各供应商在 [from, to] 的原始指标；asOf 之前到期仍未收货的采购单计为未按期到货
+	Metrics(ctx context.Context, orgID string, from, to, asOf time.Time) ([]domain.Metrics, error)
+
+	// SaveSnapshots 写入期间快照；已有快照且 replace=false 时返回 ErrSnapshotExists，replace=true 时整体替换
+	SaveSnapshots(ctx context.Context, orgID string, from, to time.Time, list []domain.Snapshot, replace bool) error
+	HasSnapshots(ctx context.Context, orgID string, from, to time.Time) (bool, error)
+	// Ranking 期间快照，按名次排列
+	Ranking(ctx context.Context, orgID string, from, to time.Time) ([]domain.Snapshot, error)
+	// Periods 机构已生成快照的期间（期间止倒序）
+	Periods(ctx context.Context, orgID string) ([]domain.Period, error)
+	// SupplierHistory 供应商历次快照（期间止倒序）
+	SupplierHistory(ctx context.Context, supplierID string) ([]domain.Snapshot, error)
+	// OrgsWithSuppliers 有有效供应商的机构
+	OrgsWithSuppliers(ctx context.Context) ([]string, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,237 @@
+package scorecard
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	purchase "hdzk.cn/foodapp/internal/domain/purchase"
+	domain "hdzk.cn/foodapp/internal/domain/scorecard"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) CreateRating(ctx context.Context, m *domain.Rating) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *repo) ListRatings(ctx context.Context, p RatingListParams) ([]domain.Rating, int64, error) {
+	var list []domain.Rating
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Rating{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.SupplierID != nil {
+		q = q.Where("supplier_id = ?", *p.SupplierID)
+	}
+	if p.Kind != nil {
+		q = q.Where("kind = ?", *p.Kind)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("rated_on >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("rated_on <= ?", *p.DateTo)
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("rated_on DESC, created_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) SoftDeleteRating(ctx context.Context, id string) error {
+	res := r.db.WithContext(ctx).Model(&domain.Rating{}).
+		Where("id = ? AND is_deleted = 0", id).
+		Update("is_deleted", 1)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repo) Metrics(ctx context.Context, orgID string, from, to, asOf time.Time) ([]domain.Metrics, error) {
+	db := r.db.WithContext(ctx)
+
+	var suppliers []struct {
+		ID   string
+		Name string
+	}
+	if err := db.Table("supplier").Select("id, name").
+		Where("org_id = ? AND is_deleted = 0", orgID).
+		Order("sort, name").Scan(&suppliers).Error; err != nil {
+		return nil, err
+	}
+	out := make([]domain.Metrics, len(suppliers))
+	idx := make(map[string]*domain.Metrics, len(suppliers))
+	for i, s := range suppliers {
+		out[i] = domain.Metrics{SupplierID: s.ID, SupplierName: s.Name}
+		idx[s.ID] = &out[i]
+	}
+
+	// 按期到货：期望到货日期在期间内的已收货单，及已过期望日期仍未收货的已提交单
+	var orders []struct {
+		SupplierID   string
+		DueOrders    int
+		OnTimeOrders int
+	}
+	if err := db.Table("purchase_order").
+		Select("supplier_id, COUNT(*) AS due_orders, "+
+			"SUM(CASE WHEN status = ? AND DATE(received_at) <= expected_date THEN 1 ELSE 0 END) AS on_time_orders", purchase.StatusReceived).
+		Where("is_deleted = 0 AND org_id = ? AND expected_date >= ? AND expected_date <= ?", orgID, from, to).
+		Where("status = ? OR (status = ? AND expected_date < ?)", purchase.StatusReceived, purchase.StatusSubmitted, utils.DateOf(asOf)).
+		Group("supplier_id").Scan(&orders).Error; err != nil {
+		return nil, err
+	}
+	for _, o := range orders {
+		if m := idx[o.SupplierID]; m != nil {
+			m.DueOrders, m.OnTimeOrders = o.DueOrders, o.OnTimeOrders
+		}
+	}
+
+	// 重量偏差：已收货明细 |实收-订购|/订购（实收在收货时已按单位换算为明细单位）
+	var lines []struct {
+		SupplierID    string
+		ReceivedLines int
+		VarianceSum   decimal.Decimal
+	}
+	if err := db.Table("purchase_order AS o").
+		Select("o.supplier_id, COUNT(*) AS received_lines, "+
+			"SUM(ABS(COALESCE(l.received_qty, 0) - l.quantity) / l.quantity) AS variance_sum").
+		Joins("JOIN purchase_order_line AS l ON l.order_id = o.id").
+		Where("o.is_deleted = 0 AND o.org_id = ? AND o.status = ? AND l.quantity > 0", orgID, purchase.StatusReceived).
+		Where("o.expected_date >= ? AND o.expected_date <= ?", from, to).
+		Group("o.supplier_id").Scan(&lines).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range lines {
+		if m := idx[l.SupplierID]; m != nil {
+			m.ReceivedLines, m.VarianceSum = l.ReceivedLines, l.VarianceSum
+		}
+	}
+
+	// 价格竞争力：报价结算价 / 同一询价单该商品的询价均价
+	var quotes []struct {
+		SupplierID    string
+		Quotes        int
+		PriceRatioSum decimal.Decimal
+	}
+	if err := db.Table("base_goods_price AS q").
+		Select("q.supplier_id, COUNT(*) AS quotes, SUM(q.unit_price * q.float_ratio / d.avg_price) AS price_ratio_sum").
+		Joins("JOIN base_price_inquiry AS i ON i.id = q.inquiry_id").
+		Joins("JOIN base_goods_avg_detail AS d ON d.inquiry_id = q.inquiry_id AND d.goods_id = q.goods_id AND d.is_deleted = 0").
+		Where("q.is_deleted = 0 AND i.is_deleted = 0 AND i.org_id = ? AND d.avg_price > 0", orgID).
+		Where("i.inquiry_date >= ? AND i.inquiry_date <= ?", from, to).
+		Group("q.supplier_id").Scan(&quotes).Error; err != nil {
+		return nil, err
+	}
+	for _, q := range quotes {
+		if m := idx[q.SupplierID]; m != nil {
+			m.Quotes, m.PriceRatioSum = q.Quotes, q.PriceRatioSum
+		}
+	}
+
+	var ratings []struct {
+		SupplierID string
+		Complaints int
+		Ratings    int
+		RatingSum  int
+	}
+	if err := db.Model(&domain.Rating{}).
+		Select("supplier_id, "+
+			"SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END) AS complaints, "+
+			"SUM(CASE WHEN kind = ? THEN 1 ELSE 0 END) AS ratings, "+
+			"COALESCE(SUM(CASE WHEN kind = ? THEN score ELSE 0 END), 0) AS rating_sum",
+			domain.KindComplaint, domain.KindRating, domain.KindRating).
+		Where("is_deleted = 0 AND org_id = ? AND rated_on >= ? AND rated_on <= ?", orgID, from, to).
+		Group("supplier_id").Scan(&ratings).Error; err != nil {
+		return nil, err
+	}
+	for _, v := range ratings {
+		if m := idx[v.SupplierID]; m != nil {
+			m.Complaints, m.Ratings, m.RatingSum = v.Complaints, v.Ratings, v.RatingSum
+		}
+	}
+	return out, nil
+}
+
+func (r *repo) SaveSnapshots(ctx context.Context, orgID string, from, to time.Time, list []domain.Snapshot, replace bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Where("org_id = ? AND period_from = ? AND period_to = ?", orgID, from, to)
+		if replace {
+			if err := q.Delete(&domain.Snapshot{}).Error; err != nil {
+				return err
+			}
+		} else {
+			var n int64
+			if err := q.Model(&domain.Snapshot{}).Count(&n).Error; err != nil {
+				return err
+			}
+			if n > 0 {
+				return domain.ErrSnapshotExists
+			}
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		return tx.Create(&list).Error
+	})
+}
+
+func (r *repo) HasSnapshots(ctx context.Context, orgID string, from, to time.Time) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Snapshot{}).
+		Where("org_id = ? AND period_from = ? AND period_to = ?", orgID, from, to).
+		Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) Ranking(ctx context.Context, orgID string, from, to time.Time) ([]domain.Snapshot, error) {
+	var list []domain.Snapshot
+	err := r.db.WithContext(ctx).
+		Where("org_id = ? AND period_from = ? AND period_to = ?", orgID, from, to).
+		Order("rank_no, supplier_name").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) Periods(ctx context.Context, orgID string) ([]domain.Period, error) {
+	var list []domain.Period
+	err := r.db.WithContext(ctx).Model(&domain.Snapshot{}).
+		Select("period_from, period_to, COUNT(*) AS suppliers, MAX(computed_at) AS computed_at").
+		Where("org_id = ?", orgID).
+		Group("period_from, period_to").
+		Order("period_to DESC, period_from DESC").
+		Scan(&list).Error
+	return list, err
+}
+
+func (r *repo) SupplierHistory(ctx context.Context, supplierID string) ([]domain.Snapshot, error) {
+	var list []domain.Snapshot
+	err := r.db.WithContext(ctx).
+		Where("supplier_id = ?", supplierID).
+		Order("period_to DESC, period_from DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) OrgsWithSuppliers(ctx context.Context) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Table("supplier").
+		Where("is_deleted = 0").
+		Distinct().Pluck("org_id", &ids).Error
+	return ids, err
+}
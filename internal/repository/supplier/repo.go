@@ -10,6 +10,7 @@ import (
 
 type UpdateParams struct {
 	ID                   string
+	Version              int // 期望版本号（乐观锁）
 	Name                 *string
 	Code                 *string
 	Pinyin               *string
@@ -17,6 +18,7 @@ type UpdateParams struct {
 	Status               *int
 	Description          *string
 	FloatRatio           *float64
+	FloatRatioFrom       time.Time // FloatRatio 非空时写入比例历史的生效日期
 	ContactName          *string
 	ContactPhone         *string
 	ContactEmail         *string
@@ -41,6 +43,24 @@ type SupplierRepository interface {
 	UpdateSupplier(ctx context.Context, params UpdateParams) error
 	SoftDeleteSupplier(ctx context.Context, id string) error
 	HardDeleteSupplier(ctx context.Context, id string) error
+
+	// 合同期调度：ActivateDue 启用已到生效时间、此前由调度停用的供应商；
+	// DeactivateDue 停用不在合同期内的正常供应商（标记为调度停用）。均返回本次变更的供应商
+	ActivateDue(ctx context.Context, now time.Time) ([]domain.Supplier, error)
+	DeactivateDue(ctx context.Context, now time.Time) ([]domain.Supplier, error)
+	// ExpiringSuppliers 正常状态且合同在 (now, until] 内到期的供应商
+	ExpiringSuppliers(ctx context.Context, now, until time.Time) ([]domain.Supplier, error)
+
+	// 浮动比例历史：同一供应商同一生效日期仅一条（重复保存覆盖）
+	SaveFloatRatio(ctx context.Context, m *domain.FloatRatio) error
+	GetFloatRatio(ctx context.Context, id string) (*domain.FloatRatio, error)
+	ListFloatRatios(ctx context.Context, supplierID string) ([]domain.FloatRatio, error)
+	// FloatRatioOn 返回 at 当日生效的比例；早于首条记录时取首条，无历史时取供应商当前比例
+	FloatRatioOn(ctx context.Context, supplierID string, at time.Time) (*domain.FloatRatio, error)
+	// DeleteFloatRatio 删除生效日期晚于 after 的计划调整，返回是否删除
+	DeleteFloatRatio(ctx context.Context, id string, after time.Time) (bool, error)
+	// SyncFloatRatios 将供应商当前比例同步为 at 当日生效的历史比例，返回更新的供应商数
+	SyncFloatRatios(ctx context.Context, at time.Time, supplierID *string) (int64, error)
 }
 
 func NewRepository(db *gorm.DB) SupplierRepository { return &supplierRepo{db: db} }
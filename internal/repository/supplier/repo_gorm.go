@@ -3,16 +3,34 @@ package supplier
 import (
 	"context"
 	"errors"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"hdzk.cn/foodapp/internal/domain/supplier"
 	domain "hdzk.cn/foodapp/internal/domain/supplier"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
 type supplierRepo struct{ db *gorm.DB }
 
+// CreateSupplier 同时写入首条浮动比例历史（自合同开始日或创建日起生效）
 func (r *supplierRepo) CreateSupplier(ctx context.Context, m *domain.Supplier) error {
-	return r.db.WithContext(ctx).Create(m).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(m).Error; err != nil {
+			return err
+		}
+		from := time.Now()
+		if m.StartTime != nil {
+			from = *m.StartTime
+		}
+		return tx.Create(&domain.FloatRatio{
+			SupplierID:    m.ID,
+			FloatRatio:    decimal.NewFromFloat(m.FloatRatio).Round(4),
+			EffectiveFrom: utils.DateOf(from),
+		}).Error
+	})
 }
 
 func (r *supplierRepo) GetSupplier(ctx context.Context, id string) (*domain.Supplier, error) {
@@ -101,7 +119,9 @@ func (r *supplierRepo) UpdateSupplier(ctx context.Context, params UpdateParams)
 		}
 	}
 	if params.Status != nil {
+		// 手工设置状态后不再由合同期调度自动启用
 		updates["status"] = *params.Status
+		updates["auto_disabled"] = 0
 	}
 	if params.Description != nil {
 		updates["description"] = *params.Description
@@ -151,12 +171,22 @@ func (r *supplierRepo) UpdateSupplier(ctx context.Context, params UpdateParams)
 			updates["end_time"] = nil
 		}
 	}
-	if len(updates) == 0 {
-		return nil
+	if params.FloatRatio == nil {
+		return utils.UpdateVersioned(r.db.WithContext(ctx).Model(&domain.Supplier{}).
+			Where("id = ? AND is_deleted = 0", params.ID), params.Version, updates)
 	}
-	return r.db.WithContext(ctx).Model(&domain.Supplier{}).
-		Where("id = ? AND is_deleted = 0", params.ID).
-		Updates(updates).Error
+	// 直接修改比例视为自 FloatRatioFrom 起生效的调整，并记入历史
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := utils.UpdateVersioned(tx.Model(&domain.Supplier{}).
+			Where("id = ? AND is_deleted = 0", params.ID), params.Version, updates); err != nil {
+			return err
+		}
+		return upsertFloatRatio(tx, &domain.FloatRatio{
+			SupplierID:    params.ID,
+			FloatRatio:    decimal.NewFromFloat(*params.FloatRatio).Round(4),
+			EffectiveFrom: utils.DateOf(params.FloatRatioFrom),
+		})
+	})
 }
 
 func (r *supplierRepo) SoftDeleteSupplier(ctx context.Context, id string) error {
@@ -169,8 +199,138 @@ func (r *supplierRepo) HardDeleteSupplier(ctx context.Context, id string) error
 	if id == "" {
 		return errors.New("id 不能为空")
 	}
-	return r.db.WithContext(ctx).
-		Unscoped().
-		Where("id = ?", id).
-		Delete(&domain.Supplier{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("supplier_id = ?", id).Delete(&domain.FloatRatio{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().
+			Where("id = ?", id).
+			Delete(&domain.Supplier{}).Error
+	})
+}
+
+func (r *supplierRepo) ActivateDue(ctx context.Context, now time.Time) ([]domain.Supplier, error) {
+	return r.switchStatus(ctx, func(q *gorm.DB) *gorm.DB {
+		return q.Where("status = ? AND auto_disabled = 1", domain.StatusDisabled).
+			Where("(start_time IS NULL OR start_time <= ?) AND (end_time IS NULL OR end_time >= ?)", now, now)
+	}, map[string]any{"status": domain.StatusActive, "auto_disabled": 0})
+}
+
+func (r *supplierRepo) DeactivateDue(ctx context.Context, now time.Time) ([]domain.Supplier, error) {
+	return r.switchStatus(ctx, func(q *gorm.DB) *gorm.DB {
+		return q.Where("status = ?", domain.StatusActive).
+			Where("(start_time > ? OR end_time < ?)", now, now)
+	}, map[string]any{"status": domain.StatusDisabled, "auto_disabled": 1})
+}
+
+// switchStatus 锁定符合条件的有效供应商并批量更新
+func (r *supplierRepo) switchStatus(ctx context.Context, scope func(*gorm.DB) *gorm.DB, updates map[string]any) ([]domain.Supplier, error) {
+	var list []domain.Supplier
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("is_deleted = 0")
+		if err := scope(q).Order("org_id, sort").Find(&list).Error; err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		ids := make([]string, len(list))
+		for i, s := range list {
+			ids[i] = s.ID
+		}
+		// 系统改写同样递增版本号，使持有旧版本的编辑失效
+		updates["version"] = gorm.Expr("version + 1")
+		return tx.Model(&domain.Supplier{}).Where("id IN ?", ids).Updates(updates).Error
+	})
+	return list, err
+}
+
+func (r *supplierRepo) ExpiringSuppliers(ctx context.Context, now, until time.Time) ([]domain.Supplier, error) {
+	var list []domain.Supplier
+	err := r.db.WithContext(ctx).
+		Where("is_deleted = 0 AND status = ? AND end_time > ? AND end_time <= ?", domain.StatusActive, now, until).
+		Order("end_time, org_id").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *supplierRepo) SaveFloatRatio(ctx context.Context, m *domain.FloatRatio) error {
+	return upsertFloatRatio(r.db.WithContext(ctx), m)
+}
+
+func upsertFloatRatio(tx *gorm.DB, m *domain.FloatRatio) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "supplier_id"}, {Name: "effective_from"}},
+		DoUpdates: clause.AssignmentColumns([]string{"float_ratio", "remark", "operator_id", "updated_at"}),
+	}).Create(m).Error
+}
+
+func (r *supplierRepo) GetFloatRatio(ctx context.Context, id string) (*domain.FloatRatio, error) {
+	var out domain.FloatRatio
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&out).Error; err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *supplierRepo) ListFloatRatios(ctx context.Context, supplierID string) ([]domain.FloatRatio, error) {
+	var list []domain.FloatRatio
+	err := r.db.WithContext(ctx).
+		Where("supplier_id = ?", supplierID).
+		Order("effective_from DESC").
+		Find(&list).Error
+	return list, err
+}
+
+func (r *supplierRepo) FloatRatioOn(ctx context.Context, supplierID string, at time.Time) (*domain.FloatRatio, error) {
+	var out domain.FloatRatio
+	err := r.db.WithContext(ctx).
+		Where("supplier_id = ? AND effective_from <= ?", supplierID, utils.DateOf(at)).
+		Order("effective_from DESC").
+		First(&out).Error
+	if err == nil {
+		return &out, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	err = r.db.WithContext(ctx).
+		Where("supplier_id = ?", supplierID).
+		Order("effective_from ASC").
+		First(&out).Error
+	if err == nil {
+		return &out, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	sup, err := r.GetSupplier(ctx, supplierID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.FloatRatio{SupplierID: sup.ID, FloatRatio: decimal.NewFromFloat(sup.FloatRatio).Round(4)}, nil
+}
+
+func (r *supplierRepo) DeleteFloatRatio(ctx context.Context, id string, after time.Time) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("id = ? AND effective_from > ?", id, utils.DateOf(after)).
+		Delete(&domain.FloatRatio{})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *supplierRepo) SyncFloatRatios(ctx context.Context, at time.Time, supplierID *string) (int64, error) {
+	ratioTable := domain.FloatRatio{}.TableName()
+	q := `UPDATE ` + domain.Supplier{}.TableName() + ` AS s
+		JOIN ` + ratioTable + ` AS h ON h.supplier_id = s.id AND h.effective_from = (
+			SELECT MAX(h2.effective_from) FROM ` + ratioTable + ` AS h2
+			WHERE h2.supplier_id = s.id AND h2.effective_from <= ?)
+		SET s.float_ratio = h.float_ratio, s.version = s.version + 1
+		WHERE s.is_deleted = 0 AND s.float_ratio <> h.float_ratio`
+	args := []any{utils.DateOf(at)}
+	if supplierID != nil {
+		q += " AND s.id = ?"
+		args = append(args, *supplierID)
+	}
+	res := r.db.WithContext(ctx).Exec(q, args...)
+	return res.RowsAffected, res.Error
 }
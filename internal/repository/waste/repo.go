@@ -0,0 +1,33 @@
+package waste
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/waste"
+)
+
+type ListParams struct {
+	OrgID    string
+	GoodsID  *string
+	ReasonID *string
+	MealID   *string
+	DateFrom *time.Time // 按 wasted_at 过滤，含当日
+	DateTo   *time.Time
+	Page     int
+	PageSize int
+}
+
+type Repository interface {
+	Create(ctx context.Context, m *domain.Record) error
+	Get(ctx context.Context, id string) (*domain.Record, error)
+	List(ctx context.Context, params ListParams) ([]domain.Record, int64, error)
+	SoftDelete(ctx context.Context, id string) error
+	// WeighingLinked 称重记录是否已登记为浪费
+	WeighingLinked(ctx context.Context, weighingID string) (bool, error)
+	// Aggregate 汇总 orgIDs 在 [dateFrom, dateTo] 的浪费，日期优先取归属就餐日期
+	Aggregate(ctx context.Context, orgIDs []string, dateFrom, dateTo time.Time) ([]domain.Agg, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,93 @@
+package waste
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/waste"
+)
+
+const goodsTable = "base_goods"
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, m *domain.Record) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Record, error) {
+	var out domain.Record
+	err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Record, int64, error) {
+	var list []domain.Record
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Record{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.GoodsID != nil {
+		q = q.Where("goods_id = ?", *p.GoodsID)
+	}
+	if p.ReasonID != nil {
+		q = q.Where("reason_id = ?", *p.ReasonID)
+	}
+	if p.MealID != nil {
+		q = q.Where("meal_id = ?", *p.MealID)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("wasted_at >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("wasted_at < ?", p.DateTo.AddDate(0, 0, 1))
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("wasted_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+// SoftDelete 同时解除称重关联，便于该称重记录重新登记
+func (r *repo) SoftDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Record{}).
+		Where("id = ?", id).
+		Updates(map[string]any{"is_deleted": 1, "weighing_id": nil}).Error
+}
+
+func (r *repo) WeighingLinked(ctx context.Context, weighingID string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&domain.Record{}).
+		Where("weighing_id = ? AND is_deleted = 0", weighingID).Count(&n).Error
+	return n > 0, err
+}
+
+func (r *repo) Aggregate(ctx context.Context, orgIDs []string, dateFrom, dateTo time.Time) ([]domain.Agg, error) {
+	var rows []domain.Agg
+	if len(orgIDs) == 0 {
+		return rows, nil
+	}
+	const day = "COALESCE(w.meal_date, DATE(w.wasted_at))"
+	err := r.db.WithContext(ctx).Table(domain.Record{}.TableName()+" AS w").
+		Select(`w.org_id, g.category_id, w.meal_id, w.reason_id, w.goods_id, w.unit_id,
+			`+day+` AS day, SUM(w.weight) AS weight, COUNT(*) AS records`).
+		Joins("LEFT JOIN "+goodsTable+" AS g ON g.id = w.goods_id").
+		Where("w.is_deleted = 0 AND w.org_id IN ?", orgIDs).
+		Where(day+" >= ? AND "+day+" <= ?", dateFrom.Format("2006-01-02"), dateTo.Format("2006-01-02")).
+		Group("w.org_id, g.category_id, w.meal_id, w.reason_id, w.goods_id, w.unit_id, " + day).
+		Scan(&rows).Error
+	return rows, err
+}
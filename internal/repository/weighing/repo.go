@@ -0,0 +1,43 @@
+package weighing
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/weighing"
+)
+
+type ListParams struct {
+	OrgID    string
+	GoodsID  *string
+	MealID   *string
+	DateFrom *time.Time // 按 weighed_at 过滤，含当日
+	DateTo   *time.Time
+	Page     int
+	PageSize int
+}
+
+// MealAssign 单条记录的餐次归属
+type MealAssign struct {
+	ID       string
+	MealID   *string
+	MealDate *time.Time
+	Source   int
+}
+
+type Repository interface {
+	Create(ctx context.Context, m *domain.Record) error
+	Get(ctx context.Context, id string) (*domain.Record, error)
+	List(ctx context.Context, params ListParams) ([]domain.Record, int64, error)
+	SoftDelete(ctx context.Context, id string) error
+	// ListForAttribution 返回时间范围内非人工归属的记录
+	ListForAttribution(ctx context.Context, orgID string, from, to time.Time) ([]domain.Record, error)
+	AssignMeals(ctx context.Context, items []MealAssign) error
+	// MealConsumption 按 就餐日期/餐次/商品/单位 汇总，并带出餐次计划人数
+	MealConsumption(ctx context.Context, orgID string, dateFrom, dateTo time.Time, mealID *string) ([]domain.MealConsumption, error)
+	// DailyConsumption 按日汇总 [dateFrom, dateTo] 的称重消耗；日期优先取归属就餐日期
+	DailyConsumption(ctx context.Context, orgID string, dateFrom, dateTo time.Time) ([]domain.DailyQty, error)
+}
+
+func NewRepository(db *gorm.DB) Repository { return &repo{db: db} }
@@ -0,0 +1,157 @@
+package weighing
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/weighing"
+)
+
+const mealPlanTable = "menu_meal_plan"
+
+type repo struct{ db *gorm.DB }
+
+func (r *repo) Create(ctx context.Context, m *domain.Record) error {
+	return r.db.WithContext(ctx).Create(m).Error
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*domain.Record, error) {
+	var out domain.Record
+	err := r.db.WithContext(ctx).Where("id = ? AND is_deleted = 0", id).First(&out).Error
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (r *repo) List(ctx context.Context, p ListParams) ([]domain.Record, int64, error) {
+	var list []domain.Record
+	var total int64
+
+	q := r.db.WithContext(ctx).Model(&domain.Record{}).
+		Where("is_deleted = 0 AND org_id = ?", p.OrgID)
+	if p.GoodsID != nil {
+		q = q.Where("goods_id = ?", *p.GoodsID)
+	}
+	if p.MealID != nil {
+		q = q.Where("meal_id = ?", *p.MealID)
+	}
+	if p.DateFrom != nil {
+		q = q.Where("weighed_at >= ?", *p.DateFrom)
+	}
+	if p.DateTo != nil {
+		q = q.Where("weighed_at < ?", p.DateTo.AddDate(0, 0, 1))
+	}
+
+	q.Count(&total)
+	page, pageSize := p.Page, p.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 || pageSize > 1000 {
+		pageSize = 20
+	}
+	err := q.Order("weighed_at DESC").
+		Limit(pageSize).Offset((page - 1) * pageSize).
+		Find(&list).Error
+	return list, total, err
+}
+
+func (r *repo) SoftDelete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&domain.Record{}).
+		Where("id = ?", id).Update("is_deleted", 1).Error
+}
+
+func (r *repo) ListForAttribution(ctx context.Context, orgID string, from, to time.Time) ([]domain.Record, error) {
+	var list []domain.Record
+	err := r.db.WithContext(ctx).
+		Where("is_deleted = 0 AND org_id = ? AND meal_source <> ?", orgID, domain.MealSourceManual).
+		Where("weighed_at >= ? AND weighed_at < ?", from, to).
+		Find(&list).Error
+	return list, err
+}
+
+func (r *repo) AssignMeals(ctx context.Context, items []MealAssign) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, it := range items {
+			err := tx.Model(&domain.Record{}).
+				Where("id = ? AND is_deleted = 0", it.ID).
+				Updates(map[string]any{
+					"meal_id":     it.MealID,
+					"meal_date":   it.MealDate,
+					"meal_source": it.Source,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *repo) MealConsumption(ctx context.Context, orgID string, dateFrom, dateTo time.Time, mealID *string) ([]domain.MealConsumption, error) {
+	type row struct {
+		MealDate    time.Time
+		MealID      string
+		GoodsID     string
+		UnitID      string
+		TotalWeight decimal.Decimal
+		Records     int64
+		Headcount   *int
+	}
+	var rows []row
+
+	q := r.db.WithContext(ctx).Table(domain.Record{}.TableName()+" AS w").
+		Select(`w.meal_date, w.meal_id, w.goods_id, w.unit_id,
+			SUM(w.weight) AS total_weight, COUNT(*) AS records, MAX(p.headcount) AS headcount`).
+		Joins("LEFT JOIN "+mealPlanTable+" AS p ON p.org_id = w.org_id AND p.plan_date = w.meal_date AND p.meal_id = w.meal_id").
+		Where("w.is_deleted = 0 AND w.org_id = ? AND w.meal_id IS NOT NULL", orgID).
+		Where("w.meal_date >= ? AND w.meal_date <= ?", dateFrom, dateTo)
+	if mealID != nil {
+		q = q.Where("w.meal_id = ?", *mealID)
+	}
+	err := q.Group("w.meal_date, w.meal_id, w.goods_id, w.unit_id").
+		Order("w.meal_date ASC, w.meal_id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.MealConsumption, len(rows))
+	for i, rw := range rows {
+		mc := domain.MealConsumption{
+			MealDate:    rw.MealDate,
+			MealID:      rw.MealID,
+			GoodsID:     rw.GoodsID,
+			UnitID:      rw.UnitID,
+			TotalWeight: rw.TotalWeight,
+			Records:     rw.Records,
+			PerCapita:   decimal.Zero,
+		}
+		if rw.Headcount != nil && *rw.Headcount > 0 {
+			mc.Headcount = *rw.Headcount
+			mc.PerCapita = rw.TotalWeight.DivRound(decimal.NewFromInt(int64(mc.Headcount)), 3)
+		}
+		out[i] = mc
+	}
+	return out, nil
+}
+
+func (r *repo) DailyConsumption(ctx context.Context, orgID string, dateFrom, dateTo time.Time) ([]domain.DailyQty, error) {
+	var rows []domain.DailyQty
+	err := r.db.WithContext(ctx).Model(&domain.Record{}).
+		Select("COALESCE(meal_date, DATE(weighed_at)) AS day, goods_id, unit_id, SUM(weight) AS qty").
+		Where("is_deleted = 0 AND org_id = ?", orgID).
+		// weighed_at 前后各放宽一天：既能走索引，又不漏掉跨零点归属到相邻日期的记录
+		Where("weighed_at >= ? AND weighed_at < ?", dateFrom.AddDate(0, 0, -1), dateTo.AddDate(0, 0, 2)).
+		Where("COALESCE(meal_date, DATE(weighed_at)) BETWEEN ? AND ?", dateFrom, dateTo).
+		Group("day, goods_id, unit_id").
+		Order("day ASC").
+		Scan(&rows).Error
+	return rows, err
+}
@@ -24,7 +24,7 @@ func NewAccountHandler(s *svc.Service) *AccountHandler { return &AccountHandler{
 func (h *AccountHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/accounts")
 
-	g.POST("/create_account", h.create)
+	g.POST("/create_account", middleware.Idempotent(), h.create)
 	g.POST("/get_account", h.get)
 	g.POST("/get_account_by_username", h.getByUsername)
 	g.POST("/list_account", h.list)
@@ -67,7 +67,8 @@ type acc_updateReq struct {
 	Username    *string `json:"username"    binding:"omitempty,min=1,max=64"`
 	OrgID       *string `json:"org_id"      binding:"omitempty,uuid4"`
 	Description *string `json:"description"`
-	Role        *int    `json:"role"` // 仅管理员可改；0=用户 1=管理员
+	Role        *int    `json:"role"`    // 仅管理员可改；0=用户 1=管理员
+	Version     *int    `json:"version"` // 期望版本号，也可用 If-Match
 }
 
 type changePasswordReq struct {
@@ -142,7 +143,7 @@ func (h *AccountHandler) get(c *gin.Context) {
 		NotFoundError(c, errTitle, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, a)
+	withETag(c, a)
 }
 
 func (h *AccountHandler) getByUsername(c *gin.Context) {
@@ -166,7 +167,7 @@ func (h *AccountHandler) getByUsername(c *gin.Context) {
 		NotFoundError(c, errTitle, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, a)
+	withETag(c, a)
 }
 
 func (h *AccountHandler) list(c *gin.Context) {
@@ -257,6 +258,10 @@ func (h *AccountHandler) update(c *gin.Context) {
 		BadRequest(c, errTitle, "输入格式非法")
 		return
 	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
 
 	// 非管理员：仅可改“本人”
 	if act.Role != middleware.RoleAdmin && act.ID != req.ID {
@@ -270,15 +275,20 @@ func (h *AccountHandler) update(c *gin.Context) {
 
 	in := svc.UpdateInput{
 		ID:          req.ID,
+		Version:     version,
 		Username:    req.Username,
 		OrgID:       req.OrgID,
 		Description: req.Description,
 		Role:        req.Role,
 	}
 	if err := h.s.Update(c, in); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetByID(c, req.ID) }) {
+			return
+		}
 		InternalError(c, errTitle, err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
 
@@ -0,0 +1,422 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/bidding"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	portal "hdzk.cn/foodapp/internal/domain/portal"
+	qualification "hdzk.cn/foodapp/internal/domain/qualification"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/bidding"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+// BiddingHandler 密封报价轮次：员工侧管理、开标查看与授标；门户侧供应商报价
+type BiddingHandler struct{ s *svc.Service }
+
+func NewBiddingHandler(s *svc.Service) *BiddingHandler { return &BiddingHandler{s: s} }
+
+// Register 员工侧接口（需 RequireAuth）
+func (h *BiddingHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/inquiry_round")
+
+	g.POST("/create_round", middleware.Idempotent(), h.create) // 创建轮次（管理员）
+	g.POST("/get_round", h.get)                                // 轮次抬头与进度（不含价格）
+	g.POST("/list_round", h.list)                              // 列表（query：org_id/inquiry_id/status）
+	g.POST("/update_round", h.update)                          // 修改未开始的轮次（管理员）
+	g.POST("/cancel_round", h.cancel)                          // 取消未截止的轮次（管理员）
+	g.POST("/list_bid", h.listBids)                            // 开标后的全部报价（审计）
+	g.POST("/list_ranking", h.ranking)                         // 开标后的排名与授标（审计）
+	g.POST("/award", h.award)                                  // 授标并写入正式报价（管理员，审计）
+	g.POST("/list_access_log", h.listAccessLog)                // 密封数据访问审计（管理员，query：round_id/actor_type）
+}
+
+// RegisterPortal 门户侧接口（需 RequireSupplierAuth，报价权限）
+func (h *BiddingHandler) RegisterPortal(rg *gin.RouterGroup) {
+	g := rg.Group("/portal", middleware.RequireScope(portal.ScopeQuote))
+
+	g.POST("/list_round", h.portalList)                           // 本供应商受邀的轮次（query）
+	g.POST("/get_round", h.portalGet)                             // 报价商品及本方报价（审计）
+	g.POST("/save_bid", middleware.Idempotent(), h.portalSaveBid) // 提交密封报价（截止前可覆盖，审计）
+}
+
+type roundCreateReq struct {
+	InquiryID   string   `json:"inquiry_id" binding:"required,uuid4"`
+	Title       *string  `json:"title" binding:"omitempty,max=128"`
+	OpensAt     string   `json:"opens_at" binding:"required"`  // YYYY-MM-DD HH:MM:SS
+	ClosesAt    string   `json:"closes_at" binding:"required"` // YYYY-MM-DD HH:MM:SS
+	SupplierIDs []string `json:"supplier_ids" binding:"required,min=1,max=200,dive,uuid4"`
+}
+
+type roundUpdateReq struct {
+	ID          string    `json:"id" binding:"required,uuid4"`
+	Title       *string   `json:"title" binding:"omitempty,max=128"`
+	OpensAt     *string   `json:"opens_at"`
+	ClosesAt    *string   `json:"closes_at"`
+	SupplierIDs *[]string `json:"supplier_ids" binding:"omitempty,min=1,max=200,dive,uuid4"`
+	Version     *int      `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type roundAwardItemReq struct {
+	GoodsID    string `json:"goods_id" binding:"required,uuid4"`
+	SupplierID string `json:"supplier_id" binding:"required,uuid4"`
+}
+
+type roundAwardReq struct {
+	RoundID string              `json:"round_id" binding:"required,uuid4"`
+	Items   []roundAwardItemReq `json:"items" binding:"omitempty,max=1000,dive"`
+	Auto    bool                `json:"auto"` // 未给出明细时按排名第一授标全部商品
+}
+
+type bidItemReq struct {
+	GoodsID   string          `json:"goods_id" binding:"required,uuid4"`
+	UnitPrice decimal.Decimal `json:"unit_price"`
+}
+
+type bidSaveReq struct {
+	RoundID string       `json:"round_id" binding:"required,uuid4"`
+	Items   []bidItemReq `json:"items" binding:"required,min=1,max=1000,dive"`
+}
+
+func staffViewer(c *gin.Context) svc.Viewer {
+	return svc.Viewer{Type: domain.ActorStaff, ID: middleware.GetActor(c).ID, ClientIP: c.ClientIP()}
+}
+
+func bidder(c *gin.Context) svc.Bidder {
+	a := middleware.GetSupplierActor(c)
+	return svc.Bidder{AccountID: a.ID, SupplierID: a.SupplierID, ClientIP: c.ClientIP()}
+}
+
+func (h *BiddingHandler) create(c *gin.Context) {
+	const errTitle = "创建报价轮次失败"
+	act, ok := adminOnly(c, errTitle)
+	if !ok {
+		return
+	}
+
+	var req roundCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	opens, err := parseDateTime(req.OpensAt)
+	if err != nil {
+		BadRequest(c, errTitle, "opens_at 格式应为 YYYY-MM-DD HH:MM:SS")
+		return
+	}
+	closes, err := parseDateTime(req.ClosesAt)
+	if err != nil {
+		BadRequest(c, errTitle, "closes_at 格式应为 YYYY-MM-DD HH:MM:SS")
+		return
+	}
+	out, err := h.s.CreateRound(c, svc.CreateParams{
+		InquiryID:   req.InquiryID,
+		Title:       req.Title,
+		OpensAt:     opens,
+		ClosesAt:    closes,
+		SupplierIDs: req.SupplierIDs,
+		CreatedBy:   &act.ID,
+	})
+	if err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *BiddingHandler) get(c *gin.Context) {
+	const errTitle = "获取报价轮次失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetRound(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "报价轮次不存在")
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *BiddingHandler) list(c *gin.Context) {
+	const errTitle = "获取报价轮次列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	var status *int
+	if v := strings.TrimSpace(c.Query("status")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			BadRequest(c, errTitle, "status 非法")
+			return
+		}
+		status = &n
+	}
+	inquiryID := c.Query("inquiry_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListRounds(c, svc.ListParams{
+		OrgID:     orgID,
+		InquiryID: &inquiryID,
+		Status:    status,
+		Page:      page,
+		PageSize:  ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *BiddingHandler) update(c *gin.Context) {
+	const errTitle = "修改报价轮次失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req roundUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	var opens, closes *time.Time
+	if req.OpensAt != nil {
+		t, err := parseDateTime(*req.OpensAt)
+		if err != nil {
+			BadRequest(c, errTitle, "opens_at 格式应为 YYYY-MM-DD HH:MM:SS")
+			return
+		}
+		opens = &t
+	}
+	if req.ClosesAt != nil {
+		t, err := parseDateTime(*req.ClosesAt)
+		if err != nil {
+			BadRequest(c, errTitle, "closes_at 格式应为 YYYY-MM-DD HH:MM:SS")
+			return
+		}
+		closes = &t
+	}
+	out, err := h.s.UpdateRound(c, svc.UpdateParams{
+		ID:          req.ID,
+		Version:     version,
+		Title:       req.Title,
+		OpensAt:     opens,
+		ClosesAt:    closes,
+		SupplierIDs: req.SupplierIDs,
+	})
+	if err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetRound(c, req.ID) }) {
+			return
+		}
+		roundError(c, errTitle, err)
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *BiddingHandler) cancel(c *gin.Context) {
+	const errTitle = "取消报价轮次失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.CancelRound(c, req.ID); err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *BiddingHandler) listBids(c *gin.Context) {
+	const errTitle = "获取轮次报价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.Bids(c, staffViewer(c), req.ID)
+	if err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *BiddingHandler) ranking(c *gin.Context) {
+	const errTitle = "获取轮次排名失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Ranking(c, staffViewer(c), req.ID)
+	if err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *BiddingHandler) award(c *gin.Context) {
+	const errTitle = "授标失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req roundAwardReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	items := make([]svc.AwardItem, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = svc.AwardItem{GoodsID: it.GoodsID, SupplierID: it.SupplierID}
+	}
+	list, err := h.s.Award(c, staffViewer(c), svc.AwardParams{
+		RoundID: req.RoundID,
+		Items:   items,
+		Auto:    req.Auto,
+	})
+	if err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *BiddingHandler) listAccessLog(c *gin.Context) {
+	const errTitle = "获取访问审计失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	roundID := strings.TrimSpace(c.Query("round_id"))
+	if roundID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 round_id")
+		return
+	}
+	actorType := c.Query("actor_type")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListAccess(c, svc.AccessListParams{
+		RoundID:   roundID,
+		ActorType: &actorType,
+		Page:      page,
+		PageSize:  ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *BiddingHandler) portalList(c *gin.Context) {
+	const errTitle = "获取报价轮次列表失败"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.SupplierRounds(c, bidder(c), page, ps)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *BiddingHandler) portalGet(c *gin.Context) {
+	const errTitle = "获取报价轮次失败"
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.SupplierRound(c, bidder(c), req.ID)
+	if err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *BiddingHandler) portalSaveBid(c *gin.Context) {
+	const errTitle = "提交报价失败"
+	var req bidSaveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	items := make([]svc.BidItem, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = svc.BidItem{GoodsID: it.GoodsID, UnitPrice: it.UnitPrice}
+	}
+	out, err := h.s.SubmitBids(c, bidder(c), req.RoundID, items)
+	if err != nil {
+		roundError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(out), "items": out})
+}
+
+// roundError 密封未开标返回 403；未受邀或不存在返回 404；阶段、锁定与资质冲突返回 409
+func roundError(c *gin.Context, errTitle string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSealed):
+		ForbiddenError(c, errTitle, err.Error())
+	case errors.Is(err, domain.ErrNotInvited), errors.Is(err, gorm.ErrRecordNotFound):
+		NotFoundError(c, errTitle, err.Error())
+	case errors.Is(err, domain.ErrNotOpen), errors.Is(err, domain.ErrLate), errors.Is(err, domain.ErrCancelled),
+		errors.Is(err, domain.ErrStarted), errors.Is(err, domain.ErrEnded), errors.Is(err, domain.ErrNotFinished),
+		errors.Is(err, domain.ErrRoundActive), errors.Is(err, inquiry.ErrLocked), errors.Is(err, supplier.ErrInactive), errors.Is(err, qualification.ErrLapsed):
+		ConflictError(c, errTitle, err.Error())
+	default:
+		BadRequest(c, errTitle, err.Error())
+	}
+}
@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	middleware "hdzk.cn/foodapp/internal/server/middleware"
@@ -17,28 +19,62 @@ func NewCategoryHandler(s *svc.Service) *CategoryHandler { return &CategoryHandl
 func (h *CategoryHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/category")
 
-	g.POST("/create_category", h.Create)          // 新增品类
-	g.POST("/get_category", h.Get)                // 按 id 获取
-	g.POST("/list_category", h.List)              // 列表（分页/条件）
-	g.POST("/update_category", h.Update)          // 更新品类
-	g.POST("/soft_delete_category", h.SoftDelete) // 删除品类
-	g.POST("/hard_delete_category", h.HardDelete) // 删除品类
+	g.POST("/create_category", middleware.Idempotent(), h.Create) // 新增品类
+	g.POST("/get_category", h.Get)                                // 按 id 获取
+	g.POST("/list_category", h.List)                              // 列表（分页/条件）
+	g.POST("/update_category", h.Update)                          // 更新品类
+	g.POST("/tree_category", h.Tree)                              // 品类树
+	g.POST("/move_category", h.Move)                              // 调整上级品类
+	g.POST("/soft_delete_category", h.SoftDelete)                 // 删除品类
+	g.POST("/hard_delete_category", h.HardDelete)                 // 删除品类
+	g.POST("/batch_category", middleware.Idempotent(), h.Batch)   // 批量新增/更新/软删/重排（单事务，逐项结果）
 }
 
 // 请求体
 type category_createReq struct {
-	Name   string  `json:"name" binding:"required,min=1,max=64"`
-	OrgID  string  `json:"org_id" binding:"required,uuid4"`
-	Code   *string `json:"code" binding:"omitempty,max=64"`
-	Pinyin *string `json:"pinyin" binding:"omitempty,max=64"`
+	Name     string  `json:"name" binding:"required,min=1,max=64"`
+	OrgID    string  `json:"org_id" binding:"required,uuid4"`
+	ParentID *string `json:"parent_id" binding:"omitempty,uuid4"`
+	Code     *string `json:"code" binding:"omitempty,max=64"`
+	Pinyin   *string `json:"pinyin" binding:"omitempty,max=64"`
 }
 
 type category_updateReq struct {
-	ID     string  `json:"id"   binding:"required,uuid4"`
-	Name   string  `json:"name" binding:"required,min=1,max=64"`
-	Code   *string `json:"code" binding:"omitempty,max=64"`
-	Pinyin *string `json:"pinyin" binding:"omitempty,max=64"`
-	Sort   *int    `json:"sort" binding:"omitempty,min=0"`
+	ID      string  `json:"id"   binding:"required,uuid4"`
+	Name    string  `json:"name" binding:"required,min=1,max=64"`
+	Code    *string `json:"code" binding:"omitempty,max=64"`
+	Pinyin  *string `json:"pinyin" binding:"omitempty,max=64"`
+	Sort    *int    `json:"sort" binding:"omitempty,min=0"`
+	Version *int    `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type category_moveReq struct {
+	ID       string  `json:"id" binding:"required,uuid4"`
+	ParentID *string `json:"parent_id"` // 为空或不传表示移为顶级
+}
+
+type category_deleteReq struct {
+	ID         string  `json:"id" binding:"required,uuid4"`
+	ReassignTo *string `json:"reassign_to" binding:"omitempty,uuid4"` // 下级品类/商品改挂目标
+}
+
+type category_batchOpReq struct {
+	Op         string   `json:"op" binding:"required,oneof=create update delete reorder"`
+	ID         string   `json:"id" binding:"omitempty,uuid4"` // update / delete
+	Version    *int     `json:"version"`                      // update 必填
+	Name       string   `json:"name" binding:"omitempty,max=64"`
+	ParentID   *string  `json:"parent_id" binding:"omitempty,uuid4"`
+	Code       *string  `json:"code" binding:"omitempty,max=64"`
+	Pinyin     *string  `json:"pinyin" binding:"omitempty,max=64"`
+	Sort       *int     `json:"sort" binding:"omitempty,min=0"`
+	ReassignTo *string  `json:"reassign_to" binding:"omitempty,uuid4"`
+	IDs        []string `json:"ids" binding:"omitempty,dive,uuid4"` // reorder：按期望顺序
+}
+
+type category_batchReq struct {
+	OrgID string                `json:"org_id" binding:"required,uuid4"`
+	Mode  string                `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Ops   []category_batchOpReq `json:"ops" binding:"required,min=1,max=500,dive"`
 }
 
 // ---------- Category ----------
@@ -59,7 +95,7 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 		BadRequest(c, err_title, "输入格式非法")
 		return
 	}
-	m, err := h.s.Create(c, req.Name, req.OrgID, req.Code, req.Pinyin)
+	m, err := h.s.Create(c, req.Name, req.OrgID, req.ParentID, req.Code, req.Pinyin)
 	if err != nil {
 		ConflictError(c, err_title, "添加品类失败:"+err.Error())
 		return
@@ -87,7 +123,7 @@ func (h *CategoryHandler) Get(c *gin.Context) {
 		NotFoundError(c, err_title, "品类不存在:"+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, m)
+	withETag(c, m)
 }
 
 func (h *CategoryHandler) List(c *gin.Context) {
@@ -104,9 +140,15 @@ func (h *CategoryHandler) List(c *gin.Context) {
 		BadRequest(c, err_title, "参数错误：缺少 org_id")
 		return
 	}
+	// parent_id：不传=全部；传空串=仅顶级；否则=直接下级
+	var parentPtr *string
+	if raw, ok := c.GetQuery("parent_id"); ok {
+		parentID := strings.TrimSpace(raw)
+		parentPtr = &parentID
+	}
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	list, total, err := h.s.List(c, kw, orgID, page, ps)
+	list, total, err := h.s.List(c, kw, orgID, parentPtr, page, ps)
 	if err != nil {
 		InternalError(c, err_title, err.Error())
 		return
@@ -130,10 +172,61 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		BadRequest(c, err_title, "输入格式非法")
 		return
 	}
-	if err := h.s.Update(c, req.ID, req.Name, req.Code, req.Pinyin, req.Sort); err != nil {
+	version, ok := bindVersion(c, err_title, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.Update(c, req.ID, version, req.Name, req.Code, req.Pinyin, req.Sort); err != nil {
+		if versionError(c, err_title, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
 		ConflictError(c, err_title, "更新品类失败:"+err.Error())
 		return
 	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	err_title := "获取品类树失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, err_title, "参数错误：缺少 org_id")
+		return
+	}
+	tree, err := h.s.Tree(c, orgID)
+	if err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": tree})
+}
+
+func (h *CategoryHandler) Move(c *gin.Context) {
+	var req category_moveReq
+	err_title := "调整上级品类失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可调整品类")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	if err := h.s.Move(c, req.ID, req.ParentID); err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
 	c.Status(http.StatusNoContent)
 }
 
@@ -149,13 +242,13 @@ func (h *CategoryHandler) SoftDelete(c *gin.Context) {
 		return
 	}
 
-	var req types.IDReq
+	var req category_deleteReq
 	if err := c.ShouldBindJSON(&req); err != nil {
 		BadRequest(c, err_title, err.Error())
 		return
 	}
-	if err := h.s.SoftDelete(c, req.ID); err != nil {
-		InternalError(c, err_title, err.Error())
+	if err := h.s.SoftDelete(c, req.ID, req.ReassignTo); err != nil {
+		ConflictError(c, err_title, err.Error())
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"ok": true})
@@ -183,3 +276,49 @@ func (h *CategoryHandler) HardDelete(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *CategoryHandler) Batch(c *gin.Context) {
+	var req category_batchReq
+	err_title := "批量操作品类失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可批量操作品类")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	ops := make([]svc.BatchOp, len(req.Ops))
+	for i, o := range req.Ops {
+		op := svc.BatchOp{
+			Op:         o.Op,
+			ID:         o.ID,
+			Name:       o.Name,
+			ParentID:   o.ParentID,
+			Code:       o.Code,
+			Pinyin:     o.Pinyin,
+			Sort:       o.Sort,
+			ReassignTo: o.ReassignTo,
+			IDs:        o.IDs,
+		}
+		if o.Op == "update" {
+			if o.Version == nil {
+				BadRequest(c, err_title, fmt.Sprintf("第 %d 项 update 缺少 version", i+1))
+				return
+			}
+			op.Version = *o.Version
+		}
+		ops[i] = op
+	}
+	res, err := h.s.Batch(c, req.OrgID, req.Mode, ops)
+	if err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	domain "hdzk.cn/foodapp/internal/domain/coderule"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/coderule"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type CodeRuleHandler struct{ s *svc.Service }
+
+func NewCodeRuleHandler(s *svc.Service) *CodeRuleHandler { return &CodeRuleHandler{s: s} }
+
+func (h *CodeRuleHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/code_rule")
+
+	g.POST("/create_code_rule", middleware.Idempotent(), h.create) // 新建编码规则
+	g.POST("/get_code_rule", h.get)                                // 按 id 获取
+	g.POST("/list_code_rule", h.list)                              // 列表（?org_id=&entity_type=）
+	g.POST("/update_code_rule", h.update)                          // 修改模式/空缺回填
+	g.POST("/delete_code_rule", h.delete)                          // 删除（回退到全局规则或内置默认）
+	g.POST("/preview_code_rule", h.preview)                        // 预览下一个编码（不占用序号）
+}
+
+type codeRuleCreateReq struct {
+	OrgID      string `json:"org_id" binding:"omitempty,uuid4"` // 为空=全局默认
+	EntityType string `json:"entity_type" binding:"required,max=32"`
+	Pattern    string `json:"pattern" binding:"required,max=128"`
+	ReuseGaps  int    `json:"reuse_gaps" binding:"oneof=0 1"`
+}
+
+type codeRuleUpdateReq struct {
+	ID        string `json:"id" binding:"required,uuid4"`
+	Pattern   string `json:"pattern" binding:"required,max=128"`
+	ReuseGaps int    `json:"reuse_gaps" binding:"oneof=0 1"`
+	Version   *int   `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type codeRulePreviewReq struct {
+	EntityType string `json:"entity_type" binding:"required,max=32"`
+	OrgID      string `json:"org_id" binding:"omitempty,uuid4"`
+	CategoryID string `json:"category_id" binding:"omitempty,uuid4"` // goods：{cat}
+	ParentID   string `json:"parent_id" binding:"omitempty,uuid4"`   // category：{parent}
+}
+
+func (h *CodeRuleHandler) create(c *gin.Context) {
+	const errTitle = "创建编码规则失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护编码规则")
+		return
+	}
+
+	var req codeRuleCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Create(c, svc.CreateParams{
+		OrgID:      req.OrgID,
+		EntityType: req.EntityType,
+		Pattern:    req.Pattern,
+		ReuseGaps:  req.ReuseGaps,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrDuplicate) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *CodeRuleHandler) get(c *gin.Context) {
+	const errTitle = "获取编码规则失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "编码规则不存在: "+err.Error())
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *CodeRuleHandler) list(c *gin.Context) {
+	const errTitle = "获取编码规则列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	p := svc.ListParams{EntityType: c.Query("entity_type")}
+	if orgID, ok := c.GetQuery("org_id"); ok {
+		orgID = strings.TrimSpace(orgID)
+		p.OrgID = &orgID
+	}
+	list, err := h.s.List(c, p)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *CodeRuleHandler) update(c *gin.Context) {
+	const errTitle = "更新编码规则失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护编码规则")
+		return
+	}
+
+	var req codeRuleUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	err := h.s.Update(c, svc.UpdateParams{
+		ID:        req.ID,
+		Version:   version,
+		Pattern:   req.Pattern,
+		ReuseGaps: req.ReuseGaps,
+	})
+	if err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CodeRuleHandler) delete(c *gin.Context) {
+	const errTitle = "删除编码规则失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护编码规则")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.Delete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *CodeRuleHandler) preview(c *gin.Context) {
+	const errTitle = "预览编码失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req codeRulePreviewReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	code, ok, err := h.s.Preview(c, svc.PreviewParams{
+		EntityType: req.EntityType,
+		OrgID:      req.OrgID,
+		CategoryID: req.CategoryID,
+		ParentID:   req.ParentID,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	// rule=false：字典类未配置规则，创建时沿用两位排序码
+	c.JSON(http.StatusOK, gin.H{"code": code, "rule": ok})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -17,23 +18,43 @@ func NewDictHandler(s *svc.Service) *DictHandler { return &DictHandler{s: s} }
 func (h *DictHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/dict")
 
-	g.POST("/create_unit", h.CreateUnit)  // 新增单位
-	g.POST("/get_unit", h.GetUnit)        // 按 id 获取
-	g.POST("/list_unit", h.ListUnits)     // 列表（分页/条件）
-	g.POST("/update_unit", h.UpdateUnit)  // 更新单位
-	g.POST("/udelete_unit", h.DeleteUnit) // 删除单位
+	g.POST("/create_unit", middleware.Idempotent(), h.CreateUnit) // 新增单位
+	g.POST("/get_unit", h.GetUnit)                                // 按 id 获取
+	g.POST("/list_unit", h.ListUnits)                             // 列表（分页/条件）
+	g.POST("/update_unit", h.UpdateUnit)                          // 更新单位
+	g.POST("/udelete_unit", h.DeleteUnit)                         // 删除单位
 
-	g.POST("/create_spec", h.CreateSpec)  // 新增规格
-	g.POST("/get_spec", h.GetSpec)        // 按 id 获取
-	g.POST("/list_spec", h.ListSpecs)     // 列表（分页/条件）
-	g.POST("/update_spec", h.UpdateSpec)  // 更新规格
-	g.POST("/udelete_spec", h.DeleteSpec) // 删除规格
+	g.POST("/set_unit_conversion", h.SetUnitConversion)                                           // 设置单位量纲/换算系数
+	g.POST("/create_goods_unit_conversion", middleware.Idempotent(), h.CreateGoodsUnitConversion) // 新增商品级换算
+	g.POST("/list_goods_unit_conversion", h.ListGoodsUnitConversions)                             // 商品级换算列表
+	g.POST("/delete_goods_unit_conversion", h.DeleteGoodsUnitConversion)                          // 删除商品级换算
+	g.POST("/convert_unit", h.ConvertUnit)                                                        // 数量换算
+	g.POST("/convert_price", h.ConvertPrice)                                                      // 单价换算（如 元/斤 → 元/公斤）
 
-	g.POST("/create_mealTime", h.CreateMealTime)  // 新增餐次
-	g.POST("/get_mealTime", h.GetMealTime)        // 按 id 获取
-	g.POST("/list_mealTime", h.ListMealTimes)     // 列表（分页/条件）
-	g.POST("/update_mealTime", h.UpdateMealTime)  // 更新餐次
-	g.POST("/udelete_mealTime", h.DeleteMealTime) // 删除规格
+	g.POST("/create_spec", middleware.Idempotent(), h.CreateSpec) // 新增规格
+	g.POST("/get_spec", h.GetSpec)                                // 按 id 获取
+	g.POST("/list_spec", h.ListSpecs)                             // 列表（分页/条件）
+	g.POST("/update_spec", h.UpdateSpec)                          // 更新规格
+	g.POST("/udelete_spec", h.DeleteSpec)                         // 删除规格
+
+	g.POST("/create_mealTime", middleware.Idempotent(), h.CreateMealTime) // 新增餐次
+	g.POST("/get_mealTime", h.GetMealTime)                                // 按 id 获取
+	g.POST("/list_mealTime", h.ListMealTimes)                             // 列表（分页/条件）
+	g.POST("/update_mealTime", h.UpdateMealTime)                          // 更新餐次
+	g.POST("/udelete_mealTime", h.DeleteMealTime)                         // 删除规格
+
+	g.POST("/create_wasteReason", middleware.Idempotent(), h.CreateWasteReason) // 新增浪费原因
+	g.POST("/get_wasteReason", h.GetWasteReason)                                // 按 id 获取
+	g.POST("/list_wasteReason", h.ListWasteReasons)                             // 列表（分页/条件）
+	g.POST("/update_wasteReason", h.UpdateWasteReason)                          // 更新浪费原因
+	g.POST("/udelete_wasteReason", h.DeleteWasteReason)                         // 删除浪费原因
+
+	g.POST("/set_mealTime_window", h.SetMealTimeWindow)      // 设置餐次默认供餐时段
+	g.POST("/set_org_meal_window", h.SetOrgMealWindow)       // 设置机构供餐时段（覆盖默认）
+	g.POST("/delete_org_meal_window", h.DeleteOrgMealWindow) // 删除机构覆盖
+	g.POST("/list_org_meal_window", h.ListOrgMealWindows)    // 机构生效时段
+
+	g.POST("/batch_dict", middleware.Idempotent(), h.Batch) // 批量新增/更新/软删/重排（单事务，逐项结果）
 }
 
 // 通用请求体
@@ -44,10 +65,27 @@ type dict_createReq struct {
 }
 
 type dict_updateReq struct {
-	ID   string  `json:"id"   binding:"required,uuid4"`
-	Name string  `json:"name" binding:"required,min=1,max=32"`
-	Code *string `json:"code" binding:"omitempty,max=32"`
-	Sort int     `json:"sort" binding:"gte=0"`
+	ID      string  `json:"id"   binding:"required,uuid4"`
+	Name    string  `json:"name" binding:"required,min=1,max=32"`
+	Code    *string `json:"code" binding:"omitempty,max=32"`
+	Sort    int     `json:"sort" binding:"gte=0"`
+	Version *int    `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type dict_batchOpReq struct {
+	Op      string   `json:"op" binding:"required,oneof=create update delete reorder"`
+	ID      string   `json:"id" binding:"omitempty,uuid4"` // update / delete
+	Version *int     `json:"version"`                      // update 必填
+	Name    string   `json:"name" binding:"omitempty,max=32"`
+	Code    *string  `json:"code" binding:"omitempty,max=32"`
+	Sort    *int     `json:"sort" binding:"omitempty,gte=0"`     // update 不传保持原值
+	IDs     []string `json:"ids" binding:"omitempty,dive,uuid4"` // reorder：按期望顺序
+}
+
+type dict_batchReq struct {
+	Kind string            `json:"kind" binding:"required,oneof=unit spec meal_time waste_reason"`
+	Mode string            `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Ops  []dict_batchOpReq `json:"ops" binding:"required,min=1,max=500,dive"`
 }
 
 // ---------- Unit ----------
@@ -89,7 +127,7 @@ func (h *DictHandler) GetUnit(c *gin.Context) {
 		NotFoundError(c, err_title, "单位不存在:"+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, m)
+	withETag(c, m)
 }
 func (h *DictHandler) ListUnits(c *gin.Context) {
 	kw := c.Query("keyword")
@@ -127,10 +165,18 @@ func (h *DictHandler) UpdateUnit(c *gin.Context) {
 		BadRequest(c, err_title, "输入格式非法")
 		return
 	}
-	if err := h.s.UpdateUnit(c, req.ID, req.Name, req.Code, req.Sort); err != nil {
+	version, ok := bindVersion(c, err_title, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.UpdateUnit(c, req.ID, version, req.Name, req.Code, req.Sort); err != nil {
+		if versionError(c, err_title, err, func() (any, error) { return h.s.GetUnit(c, req.ID) }) {
+			return
+		}
 		ConflictError(c, err_title, "更新单位失败:"+err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.Status(http.StatusNoContent)
 }
 func (h *DictHandler) DeleteUnit(c *gin.Context) {
@@ -189,7 +235,7 @@ func (h *DictHandler) GetSpec(c *gin.Context) {
 		NotFoundError(c, err_title, "规格不存在:"+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, m)
+	withETag(c, m)
 }
 func (h *DictHandler) ListSpecs(c *gin.Context) {
 	kw := c.Query("keyword")
@@ -224,10 +270,18 @@ func (h *DictHandler) UpdateSpec(c *gin.Context) {
 		BadRequest(c, err_title, "输入格式非法")
 		return
 	}
-	if err := h.s.UpdateSpec(c, req.ID, req.Name, req.Code, req.Sort); err != nil {
+	version, ok := bindVersion(c, err_title, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.UpdateSpec(c, req.ID, version, req.Name, req.Code, req.Sort); err != nil {
+		if versionError(c, err_title, err, func() (any, error) { return h.s.GetSpec(c, req.ID) }) {
+			return
+		}
 		ConflictError(c, err_title, "更新规格失败:"+err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.Status(http.StatusNoContent)
 }
 func (h *DictHandler) DeleteSpec(c *gin.Context) {
@@ -286,7 +340,7 @@ func (h *DictHandler) GetMealTime(c *gin.Context) {
 		NotFoundError(c, err_title, "就餐时段不存在:"+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, m)
+	withETag(c, m)
 }
 func (h *DictHandler) ListMealTimes(c *gin.Context) {
 	kw := c.Query("keyword")
@@ -321,10 +375,18 @@ func (h *DictHandler) UpdateMealTime(c *gin.Context) {
 		BadRequest(c, err_title, "输入格式非法")
 		return
 	}
-	if err := h.s.UpdateMealTime(c, req.ID, req.Name, req.Code, req.Sort); err != nil {
+	version, ok := bindVersion(c, err_title, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.UpdateMealTime(c, req.ID, version, req.Name, req.Code, req.Sort); err != nil {
+		if versionError(c, err_title, err, func() (any, error) { return h.s.GetMealTime(c, req.ID) }) {
+			return
+		}
 		ConflictError(c, err_title, "更新就餐时段失败:"+err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.Status(http.StatusNoContent)
 }
 func (h *DictHandler) DeleteMealTime(c *gin.Context) {
@@ -345,3 +407,153 @@ func (h *DictHandler) DeleteMealTime(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// ---------- WasteReason ----------
+func (h *DictHandler) CreateWasteReason(c *gin.Context) {
+	var req dict_createReq
+	err_title := "创建浪费原因失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可新增浪费原因")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	m, err := h.s.CreateWasteReason(c, req.Name, req.Code, req.Sort)
+	if err != nil {
+		ConflictError(c, err_title, "添加浪费原因失败:"+err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, m)
+}
+func (h *DictHandler) GetWasteReason(c *gin.Context) {
+	var req types.IDReq
+	err_title := "获取浪费原因失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	m, err := h.s.GetWasteReason(c, req.ID)
+	if err != nil {
+		NotFoundError(c, err_title, "浪费原因不存在:"+err.Error())
+		return
+	}
+	withETag(c, m)
+}
+func (h *DictHandler) ListWasteReasons(c *gin.Context) {
+	kw := c.Query("keyword")
+	err_title := "获取浪费原因列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListWasteReasons(c, kw, page, ps)
+	if err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+func (h *DictHandler) UpdateWasteReason(c *gin.Context) {
+	var req dict_updateReq
+	err_title := "更新浪费原因失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可编辑浪费原因")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, err_title, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.UpdateWasteReason(c, req.ID, version, req.Name, req.Code, req.Sort); err != nil {
+		if versionError(c, err_title, err, func() (any, error) { return h.s.GetWasteReason(c, req.ID) }) {
+			return
+		}
+		ConflictError(c, err_title, "更新浪费原因失败:"+err.Error())
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+func (h *DictHandler) DeleteWasteReason(c *gin.Context) {
+	var req types.IDReq
+	err_title := "删除浪费原因失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可删除浪费原因")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	if err := h.s.DeleteWasteReason(c, req.ID); err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ---------- Batch ----------
+func (h *DictHandler) Batch(c *gin.Context) {
+	var req dict_batchReq
+	err_title := "批量操作字典失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可批量操作字典")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	ops := make([]svc.BatchOp, len(req.Ops))
+	for i, o := range req.Ops {
+		op := svc.BatchOp{Op: o.Op, ID: o.ID, Name: o.Name, Code: o.Code, Sort: o.Sort, IDs: o.IDs}
+		if o.Op == "update" {
+			if o.Version == nil {
+				BadRequest(c, err_title, fmt.Sprintf("第 %d 项 update 缺少 version", i+1))
+				return
+			}
+			op.Version = *o.Version
+		}
+		ops[i] = op
+	}
+	res, err := h.s.Batch(c, req.Kind, req.Mode, ops)
+	if err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
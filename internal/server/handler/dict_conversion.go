@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+// 单位换算请求体（数量/系数均为十进制，建议以字符串传入避免精度丢失）
+type dict_unitConvReq struct {
+	ID        string           `json:"id" binding:"required,uuid4"`
+	Dimension *string          `json:"dimension" binding:"omitempty,oneof=mass volume count"`
+	Factor    *decimal.Decimal `json:"factor"`
+	IsBase    bool             `json:"is_base"`
+}
+
+type dict_goodsConvCreateReq struct {
+	OrgID    string          `json:"org_id" binding:"required,uuid4"`
+	GoodsID  string          `json:"goods_id" binding:"required,uuid4"`
+	UnitID   string          `json:"unit_id" binding:"required,uuid4"`
+	ToUnitID string          `json:"to_unit_id" binding:"required,uuid4"`
+	Factor   decimal.Decimal `json:"factor" binding:"required"`
+}
+
+type dict_convertReq struct {
+	Quantity   decimal.Decimal `json:"quantity"`
+	FromUnitID string          `json:"from_unit_id" binding:"required,uuid4"`
+	ToUnitID   string          `json:"to_unit_id" binding:"required,uuid4"`
+	GoodsID    *string         `json:"goods_id" binding:"omitempty,uuid4"`
+}
+
+type dict_convertPriceReq struct {
+	Price        decimal.Decimal `json:"price"`
+	PriceUnitID  string          `json:"price_unit_id" binding:"required,uuid4"`
+	TargetUnitID string          `json:"target_unit_id" binding:"required,uuid4"`
+	GoodsID      *string         `json:"goods_id" binding:"omitempty,uuid4"`
+}
+
+// ---------- Unit Conversion ----------
+func (h *DictHandler) SetUnitConversion(c *gin.Context) {
+	var req dict_unitConvReq
+	err_title := "设置单位换算失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可设置单位换算")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	if err := h.s.SetUnitConversion(c, req.ID, req.Dimension, req.Factor, req.IsBase); err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *DictHandler) CreateGoodsUnitConversion(c *gin.Context) {
+	var req dict_goodsConvCreateReq
+	err_title := "创建商品单位换算失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可设置商品单位换算")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	m, err := h.s.CreateGoodsUnitConversion(c, req.OrgID, req.GoodsID, req.UnitID, req.ToUnitID, req.Factor)
+	if err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, m)
+}
+
+func (h *DictHandler) ListGoodsUnitConversions(c *gin.Context) {
+	err_title := "获取商品单位换算失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	goodsID := strings.TrimSpace(c.Query("goods_id"))
+	if goodsID == "" {
+		BadRequest(c, err_title, "参数错误：缺少 goods_id")
+		return
+	}
+	list, err := h.s.ListGoodsUnitConversions(c, goodsID)
+	if err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *DictHandler) DeleteGoodsUnitConversion(c *gin.Context) {
+	var req types.IDReq
+	err_title := "删除商品单位换算失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可删除商品单位换算")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	if err := h.s.DeleteGoodsUnitConversion(c, req.ID); err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *DictHandler) ConvertUnit(c *gin.Context) {
+	var req dict_convertReq
+	err_title := "单位换算失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	out, err := h.s.ConvertQuantity(c, req.Quantity, req.FromUnitID, req.ToUnitID, req.GoodsID)
+	if err != nil {
+		BadRequest(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"quantity": out, "unit_id": req.ToUnitID})
+}
+
+func (h *DictHandler) ConvertPrice(c *gin.Context) {
+	var req dict_convertPriceReq
+	err_title := "单价换算失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	out, err := h.s.ConvertPrice(c, req.Price, req.PriceUnitID, req.TargetUnitID, req.GoodsID)
+	if err != nil {
+		BadRequest(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"price": out, "unit_id": req.TargetUnitID})
+}
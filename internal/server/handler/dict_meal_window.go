@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+)
+
+// 供餐时段请求体，时刻格式 HH:MM；结束早于开始表示跨零点
+type dict_mealWindowReq struct {
+	ID        string  `json:"id" binding:"required,uuid4"`
+	StartTime *string `json:"start_time" binding:"omitempty,len=5"`
+	EndTime   *string `json:"end_time" binding:"omitempty,len=5"`
+}
+
+type dict_orgMealWindowReq struct {
+	OrgID     string `json:"org_id" binding:"required,uuid4"`
+	MealID    string `json:"meal_id" binding:"required,uuid4"`
+	StartTime string `json:"start_time" binding:"required,len=5"`
+	EndTime   string `json:"end_time" binding:"required,len=5"`
+}
+
+type dict_orgMealWindowDeleteReq struct {
+	OrgID  string `json:"org_id" binding:"required,uuid4"`
+	MealID string `json:"meal_id" binding:"required,uuid4"`
+}
+
+// ---------- Meal Window ----------
+func (h *DictHandler) SetMealTimeWindow(c *gin.Context) {
+	var req dict_mealWindowReq
+	err_title := "设置供餐时段失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可设置供餐时段")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	if err := h.s.SetMealTimeWindow(c, req.ID, req.StartTime, req.EndTime); err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *DictHandler) SetOrgMealWindow(c *gin.Context) {
+	var req dict_orgMealWindowReq
+	err_title := "设置机构供餐时段失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可设置供餐时段")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	m, err := h.s.SetOrgMealWindow(c, req.OrgID, req.MealID, req.StartTime, req.EndTime)
+	if err != nil {
+		ConflictError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+func (h *DictHandler) DeleteOrgMealWindow(c *gin.Context) {
+	var req dict_orgMealWindowDeleteReq
+	err_title := "删除机构供餐时段失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, err_title, "仅管理员可删除供餐时段")
+		return
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, err_title, "输入格式非法")
+		return
+	}
+	if err := h.s.DeleteOrgMealWindow(c, req.OrgID, req.MealID); err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListOrgMealWindows 返回机构实际生效的供餐时段
+func (h *DictHandler) ListOrgMealWindows(c *gin.Context) {
+	err_title := "获取供餐时段失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, err_title, "账户已删除，禁止操作")
+		return
+	}
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, err_title, "参数错误：缺少 org_id")
+		return
+	}
+	list, err := h.s.EffectiveMealWindows(c, orgID)
+	if err != nil {
+		InternalError(c, err_title, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/forecast"
+)
+
+type ForecastHandler struct{ s *svc.Service }
+
+func NewForecastHandler(s *svc.Service) *ForecastHandler { return &ForecastHandler{s: s} }
+
+func (h *ForecastHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/forecast")
+
+	g.POST("/suggest_purchase", h.suggest)                                        // 仅计算建议，不落库
+	g.POST("/generate_purchase_draft", middleware.Idempotent(), h.generateDrafts) // 按供货商生成采购草稿
+}
+
+type forecastStockReq struct {
+	GoodsID string          `json:"goods_id" binding:"required,uuid4"`
+	Qty     decimal.Decimal `json:"qty"`
+}
+
+type forecastReq struct {
+	OrgID      string             `json:"org_id" binding:"required,uuid4"`
+	From       *string            `json:"from"` // YYYY-MM-DD，为空取明天
+	Days       int                `json:"days" binding:"required,min=1,max=60"`
+	Method     string             `json:"method" binding:"omitempty,oneof=sma ses"`
+	Window     int                `json:"window" binding:"omitempty,min=1,max=365"`
+	Alpha      *decimal.Decimal   `json:"alpha"`
+	SafetyRate *decimal.Decimal   `json:"safety_rate"`
+	Stock      []forecastStockReq `json:"stock" binding:"omitempty,dive"`
+	GoodsIDs   []string           `json:"goods_ids" binding:"omitempty,dive,uuid4"`
+}
+
+func (r *forecastReq) params() (svc.Params, error) {
+	p := svc.Params{
+		OrgID:      r.OrgID,
+		Days:       r.Days,
+		Method:     r.Method,
+		Window:     r.Window,
+		Alpha:      r.Alpha,
+		SafetyRate: r.SafetyRate,
+		GoodsIDs:   r.GoodsIDs,
+	}
+	from, err := parseOptionalDate(r.From)
+	if err != nil {
+		return p, err
+	}
+	if from != nil {
+		p.From = *from
+	}
+	if r.Stock != nil {
+		p.Stock = make(map[string]decimal.Decimal, len(r.Stock))
+		for _, s := range r.Stock {
+			p.Stock[s.GoodsID] = p.Stock[s.GoodsID].Add(s.Qty)
+		}
+	}
+	return p, nil
+}
+
+func (h *ForecastHandler) suggest(c *gin.Context) {
+	const errTitle = "生成采购建议失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req forecastReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	p, err := req.params()
+	if err != nil {
+		BadRequest(c, errTitle, "from 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.Suggest(c, p)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *ForecastHandler) generateDrafts(c *gin.Context) {
+	const errTitle = "生成采购草稿失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可生成采购草稿")
+		return
+	}
+
+	var req forecastReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	p, err := req.params()
+	if err != nil {
+		BadRequest(c, errTitle, "from 格式应为 YYYY-MM-DD")
+		return
+	}
+	result, orders, err := h.s.GenerateDrafts(c, p, &act.ID)
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"result": result, "orders": orders})
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,12 +19,20 @@ func NewGoodsHandler(s *svc.Service) *GoodsHandler { return &GoodsHandler{s: s}
 func (h *GoodsHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/goods")
 
-	g.POST("/create_goods", h.create)
+	g.POST("/create_goods", middleware.Idempotent(), h.create)
 	g.POST("/get_goods", h.get)
 	g.POST("/list_goods", h.list)
 	g.POST("/update_goods", h.update)
 	g.POST("/soft_delete_goods", h.softDelete)
 	g.POST("/hard_delete_goods", h.hardDelete)
+	g.POST("/batch_goods", middleware.Idempotent(), h.batch) // 批量新增/更新/软删/重排（单事务，逐项结果）
+
+	g.POST("/create_barcode", middleware.Idempotent(), h.createBarcode) // 新增商品条码（每种包装一个）
+	g.POST("/list_barcode", h.listBarcodes)                             // 商品条码列表（?goods_id=）
+	g.POST("/update_barcode", h.updateBarcode)                          // 修改包装单位/数量/主条码
+	g.POST("/delete_barcode", h.deleteBarcode)                          // 删除商品条码
+	g.POST("/scan_goods", h.scan)                                       // 扫码查商品（条码或商品编码）
+	g.POST("/label_goods", h.label)                                     // 商品标签图片（Code128/QR）
 }
 
 type goodsCreateReq struct {
@@ -50,6 +59,29 @@ type goodsUpdateReq struct {
 	Pinyin      *string `json:"pinyin" binding:"omitempty,max=128"`
 	ImageURL    *string `json:"image_url" binding:"omitempty,max=512"`
 	Description *string `json:"description" binding:"omitempty,max=512"`
+	Version     *int    `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type goodsBatchOpReq struct {
+	Op          string   `json:"op" binding:"required,oneof=create update delete reorder"`
+	ID          string   `json:"id" binding:"omitempty,uuid4"` // update / delete
+	Version     *int     `json:"version"`                      // update 必填
+	Name        *string  `json:"name" binding:"omitempty,min=1,max=128"`
+	Code        *string  `json:"code" binding:"omitempty,min=1,max=64"`
+	Sort        *int     `json:"sort" binding:"omitempty,min=0"`
+	SpecID      *string  `json:"spec_id" binding:"omitempty,uuid4"`
+	UnitID      *string  `json:"unit_id" binding:"omitempty,uuid4"`
+	CategoryID  *string  `json:"category_id" binding:"omitempty,uuid4"`
+	Pinyin      *string  `json:"pinyin" binding:"omitempty,max=128"`
+	ImageURL    *string  `json:"image_url" binding:"omitempty,max=512"`
+	Description *string  `json:"description" binding:"omitempty,max=512"`
+	IDs         []string `json:"ids" binding:"omitempty,dive,uuid4"` // reorder：按期望顺序
+}
+
+type goodsBatchReq struct {
+	OrgID string            `json:"org_id" binding:"required,uuid4"`
+	Mode  string            `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Ops   []goodsBatchOpReq `json:"ops" binding:"required,min=1,max=500,dive"`
 }
 
 func (h *GoodsHandler) create(c *gin.Context) {
@@ -115,7 +147,7 @@ func (h *GoodsHandler) get(c *gin.Context) {
 		NotFoundError(c, errTitle, "商品不存在: "+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, goods)
+	withETag(c, goods)
 }
 
 func (h *GoodsHandler) list(c *gin.Context) {
@@ -148,10 +180,13 @@ func (h *GoodsHandler) list(c *gin.Context) {
 		unitPtr = &unitID
 	}
 
+	// include_sub=1：按品类筛选时包含全部下级品类
+	includeSub := c.Query("include_sub") == "1" || c.Query("include_sub") == "true"
+
 	kw := c.Query("keyword")
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	list, total, err := h.s.ListGoods(c, kw, orgID, categoryPtr, specPtr, unitPtr, page, ps)
+	list, total, err := h.s.ListGoods(c, kw, orgID, categoryPtr, specPtr, unitPtr, includeSub, page, ps)
 	if err != nil {
 		InternalError(c, errTitle, err.Error())
 		return
@@ -177,9 +212,14 @@ func (h *GoodsHandler) update(c *gin.Context) {
 		BadRequest(c, errTitle, "输入格式非法")
 		return
 	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
 
 	params := svc.UpdateParams{
 		ID:          req.ID,
+		Version:     version,
 		Name:        req.Name,
 		Code:        req.Code,
 		Sort:        req.Sort,
@@ -191,9 +231,13 @@ func (h *GoodsHandler) update(c *gin.Context) {
 		Description: req.Description,
 	}
 	if err := h.s.UpdateGoods(c, params); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetGoods(c, req.ID) }) {
+			return
+		}
 		ConflictError(c, errTitle, "更新商品失败: "+err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.Status(http.StatusNoContent)
 }
 
@@ -244,3 +288,74 @@ func (h *GoodsHandler) hardDelete(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *GoodsHandler) batch(c *gin.Context) {
+	const errTitle = "批量操作商品失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可批量操作商品")
+		return
+	}
+
+	var req goodsBatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	ops := make([]svc.BatchOp, len(req.Ops))
+	for i, o := range req.Ops {
+		if o.Op == "update" && o.Version == nil {
+			BadRequest(c, errTitle, fmt.Sprintf("第 %d 项 update 缺少 version", i+1))
+			return
+		}
+		op := svc.BatchOp{Op: o.Op, ID: o.ID, IDs: o.IDs}
+		switch o.Op {
+		case "create":
+			op.Create = svc.CreateParams{
+				Name:        deref(o.Name),
+				SpecID:      deref(o.SpecID),
+				UnitID:      deref(o.UnitID),
+				CategoryID:  deref(o.CategoryID),
+				Sort:        o.Sort,
+				Code:        o.Code,
+				Pinyin:      o.Pinyin,
+				ImageURL:    o.ImageURL,
+				Description: o.Description,
+			}
+		case "update":
+			op.Update = svc.UpdateParams{
+				ID:          o.ID,
+				Version:     *o.Version,
+				Name:        o.Name,
+				Code:        o.Code,
+				Sort:        o.Sort,
+				SpecID:      o.SpecID,
+				UnitID:      o.UnitID,
+				CategoryID:  o.CategoryID,
+				Pinyin:      o.Pinyin,
+				ImageURL:    o.ImageURL,
+				Description: o.Description,
+			}
+		}
+		ops[i] = op
+	}
+
+	res, err := h.s.Batch(c, req.OrgID, req.Mode, ops)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// deref 空指针取零值
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
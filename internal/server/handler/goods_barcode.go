@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/goods"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/goods"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type goodsBarcodeCreateReq struct {
+	GoodsID   string           `json:"goods_id" binding:"required,uuid4"`
+	Barcode   string           `json:"barcode" binding:"required,max=14"` // EAN-8/UPC-A/EAN-13/GTIN-14
+	UnitID    *string          `json:"unit_id" binding:"omitempty,uuid4"` // 包装单位，为空取商品单位
+	PackQty   *decimal.Decimal `json:"pack_qty"`                          // 每包装折合商品单位数量，为空取 1
+	IsPrimary bool             `json:"is_primary"`
+	Remark    *string          `json:"remark" binding:"omitempty,max=255"`
+}
+
+type goodsBarcodeUpdateReq struct {
+	ID        string           `json:"id" binding:"required,uuid4"`
+	UnitID    *string          `json:"unit_id" binding:"omitempty,uuid4"`
+	PackQty   *decimal.Decimal `json:"pack_qty"`
+	IsPrimary *bool            `json:"is_primary"`
+	Remark    *string          `json:"remark" binding:"omitempty,max=255"`
+}
+
+type goodsScanReq struct {
+	OrgID   string `json:"org_id" binding:"required,uuid4"`
+	Barcode string `json:"barcode" binding:"required,max=64"` // 扫码原文：商品条码或商品编码
+}
+
+type goodsLabelReq struct {
+	ID        string  `json:"id" binding:"required,uuid4"`
+	Format    string  `json:"format" binding:"omitempty,oneof=code128 qr"` // 默认 code128
+	BarcodeID *string `json:"barcode_id" binding:"omitempty,uuid4"`        // 为空时编码商品 code
+	Width     int     `json:"width" binding:"omitempty,min=0,max=2000"`
+	Height    int     `json:"height" binding:"omitempty,min=0,max=2000"`
+}
+
+func (h *GoodsHandler) createBarcode(c *gin.Context) {
+	const errTitle = "新增商品条码失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护商品条码")
+		return
+	}
+
+	var req goodsBarcodeCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.CreateBarcode(c, svc.BarcodeCreateParams{
+		GoodsID:   req.GoodsID,
+		Barcode:   req.Barcode,
+		UnitID:    req.UnitID,
+		PackQty:   req.PackQty,
+		IsPrimary: req.IsPrimary,
+		Remark:    req.Remark,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrBarcodeDuplicate) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *GoodsHandler) listBarcodes(c *gin.Context) {
+	const errTitle = "获取商品条码失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	goodsID := strings.TrimSpace(c.Query("goods_id"))
+	if goodsID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 goods_id")
+		return
+	}
+	list, err := h.s.ListBarcodes(c, goodsID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *GoodsHandler) updateBarcode(c *gin.Context) {
+	const errTitle = "更新商品条码失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护商品条码")
+		return
+	}
+
+	var req goodsBarcodeUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	err := h.s.UpdateBarcode(c, svc.BarcodeUpdateParams{
+		ID:        req.ID,
+		UnitID:    req.UnitID,
+		PackQty:   req.PackQty,
+		IsPrimary: req.IsPrimary,
+		Remark:    req.Remark,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "条码不存在")
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *GoodsHandler) deleteBarcode(c *gin.Context) {
+	const errTitle = "删除商品条码失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护商品条码")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.DeleteBarcode(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// scan 秤/手持终端扫码后查商品
+func (h *GoodsHandler) scan(c *gin.Context) {
+	const errTitle = "扫码查询商品失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req goodsScanReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Scan(c, req.OrgID, req.Barcode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "未找到条码对应的商品: "+strings.TrimSpace(req.Barcode))
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// label 商品标签图片（PNG）
+func (h *GoodsHandler) label(c *gin.Context) {
+	const errTitle = "生成商品标签失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req goodsLabelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if req.Format == "" {
+		req.Format = svc.LabelCode128
+	}
+	data, err := h.s.Label(c, svc.LabelParams{
+		GoodsID:   req.ID,
+		Format:    req.Format,
+		BarcodeID: req.BarcodeID,
+		Width:     req.Width,
+		Height:    req.Height,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="label_`+req.ID+`_`+req.Format+`.png"`)
+	c.Data(http.StatusOK, "image/png", data)
+}
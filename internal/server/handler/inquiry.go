@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/inquiry"
 	middleware "hdzk.cn/foodapp/internal/server/middleware"
 	svc "hdzk.cn/foodapp/internal/service/inquiry"
 	types "hdzk.cn/foodapp/internal/transport"
@@ -18,30 +22,93 @@ func NewInquiryHandler(s *svc.Service) *InquiryHandler { return &InquiryHandler{
 
 func (h *InquiryHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/inquiry")
-	g.POST("/create_inquiry", h.create)
+	g.POST("/create_inquiry", middleware.Idempotent(), h.create)
 	g.POST("/get_inquiry", h.get)
 	g.POST("/list_inquiry", h.list)
 	g.POST("/update_inquiry", h.update)
 	g.POST("/soft_delete_inquiry", h.softDelete)
 	g.POST("/hard_delete_inquiry", h.hardDelete)
+
+	g.POST("/submit_inquiry", h.submit)            // 草稿 → 已提交
+	g.POST("/approve_inquiry", h.approve)          // 已提交 → 已审核（锁定，须填写意见）
+	g.POST("/reject_inquiry", h.reject)            // 已提交 → 草稿（须填写意见）
+	g.POST("/archive_inquiry", h.archive)          // 已审核 → 已归档
+	g.POST("/reopen_inquiry", h.reopen)            // 已审核/已归档 → 草稿（须填写原因）
+	g.POST("/list_inquiry_history", h.listHistory) // 状态流转记录
+
+	g.POST("/clone_inquiry", middleware.Idempotent(), h.clone) // 复制询价单（可带出上期均价作为指导价）
+	g.POST("/create_inquiry_template", middleware.Idempotent(), h.createTemplate)
+	g.POST("/get_inquiry_template", h.getTemplate)
+	g.POST("/list_inquiry_template", h.listTemplates)
+	g.POST("/update_inquiry_template", h.updateTemplate)
+	g.POST("/soft_delete_inquiry_template", h.softDeleteTemplate)
+	g.POST("/create_inquiry_from_template", middleware.Idempotent(), h.createFromTemplate)
+
+	g.POST("/migrate_legacy_markets", h.migrateLegacyMarkets) // 旧版 market_1..3 迁移为市场记录（可重复执行）
 }
 
 type inquiryCreateReq struct {
-	OrgID        string  `json:"org_id" binding:"required,uuid4"`
-	InquiryTitle string  `json:"inquiry_title" binding:"required,min=1,max=64"`
+	OrgID        string   `json:"org_id" binding:"required,uuid4"`
+	InquiryTitle string   `json:"inquiry_title" binding:"required,min=1,max=64"`
+	InquiryDate  string   `json:"inquiry_date" binding:"required"`           // YYYY-MM-DD
+	MarketIDs    []string `json:"market_ids" binding:"omitempty,dive,uuid4"` // 参与市场（按顺序）
+	Market1      *string  `json:"market_1" binding:"omitempty,max=128"`      // 旧版：市场名称，按名称匹配或新建市场
+	Market2      *string  `json:"market_2" binding:"omitempty,max=128"`
+	Market3      *string  `json:"market_3" binding:"omitempty,max=128"`
+}
+
+type inquiryTransitionReq struct {
+	ID      string  `json:"id" binding:"required,uuid4"`
+	Comment *string `json:"comment" binding:"omitempty,max=255"`
+}
+
+type inquiryCloneReq struct {
+	SourceID     string `json:"source_id" binding:"required,uuid4"`
+	InquiryTitle string `json:"inquiry_title" binding:"required,min=1,max=64"`
+	InquiryDate  string `json:"inquiry_date" binding:"required"` // YYYY-MM-DD
+	CarryPrices  bool   `json:"carry_prices"`
+}
+
+type inquiryTemplateCreateReq struct {
+	OrgID           string   `json:"org_id" binding:"required,uuid4"`
+	Name            string   `json:"name" binding:"required,min=1,max=64"`
+	InquiryTitle    *string  `json:"inquiry_title" binding:"omitempty,max=64"`
+	MarketIDs       []string `json:"market_ids" binding:"omitempty,dive,uuid4"`
+	Remark          *string  `json:"remark" binding:"omitempty,max=255"`
+	GoodsIDs        []string `json:"goods_ids" binding:"omitempty,dive,uuid4"`
+	SourceInquiryID *string  `json:"source_inquiry_id" binding:"omitempty,uuid4"`
+}
+
+type inquiryTemplateUpdateReq struct {
+	ID           string    `json:"id" binding:"required,uuid4"`
+	Name         *string   `json:"name" binding:"omitempty,min=1,max=64"`
+	InquiryTitle *string   `json:"inquiry_title" binding:"omitempty,max=64"`
+	MarketIDs    *[]string `json:"market_ids" binding:"omitempty,dive,uuid4"`
+	Remark       *string   `json:"remark" binding:"omitempty,max=255"`
+	GoodsIDs     *[]string `json:"goods_ids" binding:"omitempty,dive,uuid4"`
+	Version      *int      `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type inquiryFromTemplateReq struct {
+	TemplateID   string  `json:"template_id" binding:"required,uuid4"`
+	InquiryTitle *string `json:"inquiry_title" binding:"omitempty,max=64"`
 	InquiryDate  string  `json:"inquiry_date" binding:"required"` // YYYY-MM-DD
-	Market1      *string `json:"market_1" binding:"omitempty,max=128"`
-	Market2      *string `json:"market_2" binding:"omitempty,max=128"`
-	Market3      *string `json:"market_3" binding:"omitempty,max=128"`
+	CarryPrices  bool    `json:"carry_prices"`
 }
 
 type inquiryUpdateReq struct {
-	ID           string  `json:"id" binding:"required,uuid4"`
-	InquiryTitle *string `json:"inquiry_title" binding:"omitempty,min=1,max=64"`
-	InquiryDate  *string `json:"inquiry_date"`
-	Market1      *string `json:"market_1" binding:"omitempty,max=128"`
-	Market2      *string `json:"market_2" binding:"omitempty,max=128"`
-	Market3      *string `json:"market_3" binding:"omitempty,max=128"`
+	ID           string    `json:"id" binding:"required,uuid4"`
+	InquiryTitle *string   `json:"inquiry_title" binding:"omitempty,min=1,max=64"`
+	InquiryDate  *string   `json:"inquiry_date"`
+	MarketIDs    *[]string `json:"market_ids" binding:"omitempty,dive,uuid4"` // 非空时整体替换参与市场
+	Market1      *string   `json:"market_1" binding:"omitempty,max=128"`
+	Market2      *string   `json:"market_2" binding:"omitempty,max=128"`
+	Market3      *string   `json:"market_3" binding:"omitempty,max=128"`
+	Version      *int      `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type inquiryMigrateReq struct {
+	OrgID string `json:"org_id" binding:"required,uuid4"`
 }
 
 func parseDate(raw string) (time.Time, error) {
@@ -74,6 +141,26 @@ func parseOptionalDateTime(p *string) (*time.Time, error) {
 	return &t, nil
 }
 
+// queryDateRange 读取 query 中的 date_from/date_to（YYYY-MM-DD，均可空）
+func queryDateRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	var fromPtr, toPtr *time.Time
+	if raw := strings.TrimSpace(c.Query("date_from")); raw != "" {
+		t, err := parseDate(raw)
+		if err != nil {
+			return nil, nil, errors.New("date_from 格式应为 YYYY-MM-DD")
+		}
+		fromPtr = &t
+	}
+	if raw := strings.TrimSpace(c.Query("date_to")); raw != "" {
+		t, err := parseDate(raw)
+		if err != nil {
+			return nil, nil, errors.New("date_to 格式应为 YYYY-MM-DD")
+		}
+		toPtr = &t
+	}
+	return fromPtr, toPtr, nil
+}
+
 func (h *InquiryHandler) create(c *gin.Context) {
 	const errTitle = "创建询价失败"
 	act := middleware.GetActor(c)
@@ -102,6 +189,7 @@ func (h *InquiryHandler) create(c *gin.Context) {
 		OrgID:        req.OrgID,
 		InquiryTitle: req.InquiryTitle,
 		InquiryDate:  d,
+		MarketIDs:    req.MarketIDs,
 		Market1:      req.Market1,
 		Market2:      req.Market2,
 		Market3:      req.Market3,
@@ -132,7 +220,7 @@ func (h *InquiryHandler) get(c *gin.Context) {
 		NotFoundError(c, errTitle, "询价不存在: "+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, out)
+	withETag(c, out)
 }
 
 func (h *InquiryHandler) list(c *gin.Context) {
@@ -197,6 +285,10 @@ func (h *InquiryHandler) update(c *gin.Context) {
 		BadRequest(c, errTitle, "输入格式非法")
 		return
 	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
 
 	datePtr, err := parseOptionalDate(req.InquiryDate)
 	if err != nil {
@@ -206,16 +298,26 @@ func (h *InquiryHandler) update(c *gin.Context) {
 
 	params := svc.UpdateParams{
 		ID:           req.ID,
+		Version:      version,
 		InquiryTitle: req.InquiryTitle,
 		InquiryDate:  datePtr,
+		MarketIDs:    req.MarketIDs,
 		Market1:      req.Market1,
 		Market2:      req.Market2,
 		Market3:      req.Market3,
 	}
 	if err := h.s.Update(c, params); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
+		if errors.Is(err, domain.ErrLocked) || errors.Is(err, domain.ErrMarketInUse) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
 		ConflictError(c, errTitle, "更新询价失败: "+err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.Status(http.StatusNoContent)
 }
 
@@ -236,6 +338,10 @@ func (h *InquiryHandler) softDelete(c *gin.Context) {
 		return
 	}
 	if err := h.s.SoftDelete(c, req.ID); err != nil {
+		if errors.Is(err, domain.ErrLocked) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
 		InternalError(c, errTitle, err.Error())
 		return
 	}
@@ -264,3 +370,323 @@ func (h *InquiryHandler) hardDelete(c *gin.Context) {
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *InquiryHandler) submit(c *gin.Context) {
+	h.transition(c, "提交询价失败", false, h.s.Submit)
+}
+
+func (h *InquiryHandler) approve(c *gin.Context) {
+	h.transition(c, "审核询价失败", true, h.s.Approve)
+}
+
+func (h *InquiryHandler) reject(c *gin.Context) {
+	h.transition(c, "退回询价失败", true, h.s.Reject)
+}
+
+func (h *InquiryHandler) archive(c *gin.Context) {
+	h.transition(c, "归档询价失败", true, h.s.Archive)
+}
+
+func (h *InquiryHandler) reopen(c *gin.Context) {
+	h.transition(c, "重新打开询价失败", true, h.s.Reopen)
+}
+
+// transition 状态流转公共处理；adminOnly 的动作仅管理员（审核人）可执行
+func (h *InquiryHandler) transition(c *gin.Context, errTitle string, adminOnly bool, fn func(ctx context.Context, p svc.TransitionParams) error) {
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if adminOnly && act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可执行该操作")
+		return
+	}
+
+	var req inquiryTransitionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	err := fn(c, svc.TransitionParams{ID: req.ID, OperatorID: act.ID, Comment: req.Comment})
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		NotFoundError(c, errTitle, "询价不存在")
+	case errors.Is(err, domain.ErrStatus):
+		ConflictError(c, errTitle, err.Error())
+	default:
+		BadRequest(c, errTitle, err.Error())
+	}
+}
+
+func (h *InquiryHandler) listHistory(c *gin.Context) {
+	const errTitle = "获取询价流转记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.History(c, req.ID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+// inquiryWriteError 将询价写操作的错误映射为响应：不存在 404，重名 409，其余 400
+func inquiryWriteError(c *gin.Context, errTitle string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		NotFoundError(c, errTitle, "记录不存在")
+	case errors.Is(err, domain.ErrDuplicate), errors.Is(err, domain.ErrTemplateDuplicate), errors.Is(err, domain.ErrMarketInUse):
+		ConflictError(c, errTitle, err.Error())
+	default:
+		BadRequest(c, errTitle, err.Error())
+	}
+}
+
+func (h *InquiryHandler) clone(c *gin.Context) {
+	const errTitle = "复制询价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可创建询价")
+		return
+	}
+
+	var req inquiryCloneReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	d, err := parseDate(req.InquiryDate)
+	if err != nil {
+		BadRequest(c, errTitle, "inquiry_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.Clone(c, svc.CloneParams{
+		SourceID:     req.SourceID,
+		InquiryTitle: req.InquiryTitle,
+		InquiryDate:  d,
+		CarryPrices:  req.CarryPrices,
+	})
+	if err != nil {
+		inquiryWriteError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *InquiryHandler) createTemplate(c *gin.Context) {
+	const errTitle = "创建询价模板失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护询价模板")
+		return
+	}
+
+	var req inquiryTemplateCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.CreateTemplate(c, svc.TemplateParams{
+		OrgID:           req.OrgID,
+		Name:            req.Name,
+		InquiryTitle:    req.InquiryTitle,
+		MarketIDs:       req.MarketIDs,
+		Remark:          req.Remark,
+		GoodsIDs:        req.GoodsIDs,
+		SourceInquiryID: req.SourceInquiryID,
+	})
+	if err != nil {
+		inquiryWriteError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *InquiryHandler) getTemplate(c *gin.Context) {
+	const errTitle = "获取询价模板失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetTemplate(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "询价模板不存在: "+err.Error())
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *InquiryHandler) listTemplates(c *gin.Context) {
+	const errTitle = "获取询价模板列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListTemplates(c, orgID, c.Query("keyword"), page, ps)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *InquiryHandler) updateTemplate(c *gin.Context) {
+	const errTitle = "更新询价模板失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护询价模板")
+		return
+	}
+
+	var req inquiryTemplateUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	err := h.s.UpdateTemplate(c, svc.TemplateUpdateParams{
+		ID:           req.ID,
+		Version:      version,
+		Name:         req.Name,
+		InquiryTitle: req.InquiryTitle,
+		MarketIDs:    req.MarketIDs,
+		Remark:       req.Remark,
+		GoodsIDs:     req.GoodsIDs,
+	})
+	if err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetTemplate(c, req.ID) }) {
+			return
+		}
+		inquiryWriteError(c, errTitle, err)
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *InquiryHandler) softDeleteTemplate(c *gin.Context) {
+	const errTitle = "删除询价模板失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护询价模板")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SoftDeleteTemplate(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *InquiryHandler) createFromTemplate(c *gin.Context) {
+	const errTitle = "按模板创建询价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可创建询价")
+		return
+	}
+
+	var req inquiryFromTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	d, err := parseDate(req.InquiryDate)
+	if err != nil {
+		BadRequest(c, errTitle, "inquiry_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.CreateFromTemplate(c, svc.FromTemplateParams{
+		TemplateID:   req.TemplateID,
+		InquiryTitle: req.InquiryTitle,
+		InquiryDate:  d,
+		CarryPrices:  req.CarryPrices,
+	})
+	if err != nil {
+		inquiryWriteError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *InquiryHandler) migrateLegacyMarkets(c *gin.Context) {
+	const errTitle = "迁移询价市场失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可迁移询价市场")
+		return
+	}
+
+	var req inquiryMigrateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.MigrateLegacyMarkets(c, req.OrgID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,488 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	inv "hdzk.cn/foodapp/internal/domain/inventory"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/inventory"
+	types "hdzk.cn/foodapp/internal/transport"
+	"hdzk.cn/foodapp/pkg/utils"
+)
+
+type InventoryHandler struct{ s *svc.Service }
+
+func NewInventoryHandler(s *svc.Service) *InventoryHandler { return &InventoryHandler{s: s} }
+
+func (h *InventoryHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/inventory")
+
+	g.POST("/create_inventory_movement", middleware.Idempotent(), h.move)      // 入库/领用/报损/调整
+	g.POST("/post_weighing_movement", middleware.Idempotent(), h.postWeighing) // 按称重记录生成领用/报损
+	g.POST("/transfer_inventory", middleware.Idempotent(), h.transfer)         // 机构间调拨
+	g.POST("/list_inventory_movement", h.listMovements)                        // 流水查询
+	g.POST("/list_inventory_balance", h.listBalances)                          // 当前结存
+	g.POST("/rebuild_inventory_balance", h.rebuildBalances)                    // 按流水重算结存
+
+	g.POST("/create_stock_count", middleware.Idempotent(), h.createCount)
+	g.POST("/get_stock_count", h.getCount)
+	g.POST("/list_stock_count", h.listCounts)
+	g.POST("/update_stock_count_lines", h.updateCountLines) // 录入中可替换明细
+	g.POST("/post_stock_count", h.postCount)                // 过账生成差异调整
+	g.POST("/soft_delete_stock_count", h.softDeleteCount)
+}
+
+type inventoryMoveReq struct {
+	OrgID      string          `json:"org_id" binding:"required,uuid4"`
+	GoodsID    string          `json:"goods_id" binding:"required,uuid4"`
+	Type       string          `json:"type" binding:"required,oneof=receipt issue adjust waste"`
+	Quantity   decimal.Decimal `json:"quantity"`
+	UnitID     *string         `json:"unit_id" binding:"omitempty,uuid4"` // 为空取商品单位
+	OccurredAt *string         `json:"occurred_at"`                       // YYYY-MM-DD HH:MM:SS，空为当前时间
+	WeighingID *string         `json:"weighing_id" binding:"omitempty,uuid4"`
+	Remark     *string         `json:"remark" binding:"omitempty,max=255"`
+}
+
+type inventoryWeighingReq struct {
+	WeighingID string `json:"weighing_id" binding:"required,uuid4"`
+	Type       string `json:"type" binding:"omitempty,oneof=issue waste"` // 默认 issue
+}
+
+type inventoryTransferReq struct {
+	FromOrgID  string          `json:"from_org_id" binding:"required,uuid4"`
+	ToOrgID    string          `json:"to_org_id" binding:"required,uuid4"`
+	GoodsID    string          `json:"goods_id" binding:"required,uuid4"`    // 调出机构的商品
+	ToGoodsID  string          `json:"to_goods_id" binding:"required,uuid4"` // 调入机构的对应商品
+	Quantity   decimal.Decimal `json:"quantity"`
+	UnitID     *string         `json:"unit_id" binding:"omitempty,uuid4"`
+	OccurredAt *string         `json:"occurred_at"`
+	Remark     *string         `json:"remark" binding:"omitempty,max=255"`
+}
+
+type inventoryOrgReq struct {
+	OrgID string `json:"org_id" binding:"required,uuid4"`
+}
+
+type stockCountLineReq struct {
+	GoodsID  string          `json:"goods_id" binding:"required,uuid4"`
+	Quantity decimal.Decimal `json:"quantity"`
+	UnitID   *string         `json:"unit_id" binding:"omitempty,uuid4"`
+	Sort     int             `json:"sort" binding:"gte=0"`
+}
+
+type stockCountCreateReq struct {
+	OrgID     string              `json:"org_id" binding:"required,uuid4"`
+	CountDate string              `json:"count_date" binding:"required"` // YYYY-MM-DD
+	Remark    *string             `json:"remark" binding:"omitempty,max=255"`
+	Lines     []stockCountLineReq `json:"lines" binding:"omitempty,dive"`
+}
+
+type stockCountLinesReq struct {
+	ID      string              `json:"id" binding:"required,uuid4"`
+	Lines   []stockCountLineReq `json:"lines" binding:"required,dive"`
+	Version *int                `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+func countLineParams(in []stockCountLineReq) []svc.CountLineParams {
+	out := make([]svc.CountLineParams, len(in))
+	for i, l := range in {
+		out[i] = svc.CountLineParams{GoodsID: l.GoodsID, Quantity: l.Quantity, Sort: l.Sort}
+		if l.UnitID != nil {
+			out[i].UnitID = *l.UnitID
+		}
+	}
+	return out
+}
+
+// inventoryError 库存不足按冲突返回，其余按参数错误返回
+func inventoryError(c *gin.Context, errTitle string, err error) {
+	if errors.Is(err, svc.ErrInsufficient) {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	BadRequest(c, errTitle, err.Error())
+}
+
+func optionalDateTime(raw *string) (time.Time, error) {
+	t, err := parseOptionalDateTime(raw)
+	if err != nil || t == nil {
+		return time.Time{}, err
+	}
+	return *t, nil
+}
+
+func (h *InventoryHandler) move(c *gin.Context) {
+	const errTitle = "记录库存流水失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req inventoryMoveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if req.Type == inv.TypeAdjust && act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可调整库存")
+		return
+	}
+	at, err := optionalDateTime(req.OccurredAt)
+	if err != nil {
+		BadRequest(c, errTitle, "occurred_at 格式应为 YYYY-MM-DD HH:MM:SS")
+		return
+	}
+	p := svc.MoveParams{
+		OrgID:      req.OrgID,
+		GoodsID:    req.GoodsID,
+		Type:       req.Type,
+		Quantity:   req.Quantity,
+		OccurredAt: at,
+		WeighingID: req.WeighingID,
+		Remark:     req.Remark,
+		OperatorID: &act.ID,
+	}
+	if req.UnitID != nil {
+		p.UnitID = *req.UnitID
+	}
+	out, err := h.s.Move(c, p)
+	if err != nil {
+		inventoryError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *InventoryHandler) postWeighing(c *gin.Context) {
+	const errTitle = "称重记录出库失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req inventoryWeighingReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.PostWeighing(c, req.WeighingID, req.Type, &act.ID)
+	if err != nil {
+		inventoryError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *InventoryHandler) transfer(c *gin.Context) {
+	const errTitle = "库存调拨失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req inventoryTransferReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	at, err := optionalDateTime(req.OccurredAt)
+	if err != nil {
+		BadRequest(c, errTitle, "occurred_at 格式应为 YYYY-MM-DD HH:MM:SS")
+		return
+	}
+	p := svc.TransferParams{
+		FromOrgID:  req.FromOrgID,
+		ToOrgID:    req.ToOrgID,
+		GoodsID:    req.GoodsID,
+		ToGoodsID:  req.ToGoodsID,
+		Quantity:   req.Quantity,
+		OccurredAt: at,
+		Remark:     req.Remark,
+		OperatorID: &act.ID,
+	}
+	if req.UnitID != nil {
+		p.UnitID = *req.UnitID
+	}
+	out, err := h.s.Transfer(c, p)
+	if err != nil {
+		inventoryError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"items": out})
+}
+
+func (h *InventoryHandler) listMovements(c *gin.Context) {
+	const errTitle = "获取库存流水失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	goodsID, typ, weighingID := c.Query("goods_id"), c.Query("type"), c.Query("weighing_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListMovements(c, svc.MovementParams{
+		OrgID:      orgID,
+		GoodsID:    &goodsID,
+		Type:       &typ,
+		WeighingID: &weighingID,
+		DateFrom:   from,
+		DateTo:     to,
+		Page:       page,
+		PageSize:   ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *InventoryHandler) listBalances(c *gin.Context) {
+	const errTitle = "获取库存结存失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	goodsID := c.Query("goods_id")
+	nonZero := c.Query("non_zero") == "1" || c.Query("non_zero") == "true"
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListBalances(c, svc.BalanceParams{
+		OrgID:    orgID,
+		GoodsID:  &goodsID,
+		NonZero:  nonZero,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *InventoryHandler) rebuildBalances(c *gin.Context) {
+	const errTitle = "重算库存结存失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可重算结存")
+		return
+	}
+
+	var req inventoryOrgReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	n, err := h.s.RebuildBalances(c, req.OrgID)
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"goods": n})
+}
+
+func (h *InventoryHandler) createCount(c *gin.Context) {
+	const errTitle = "创建盘点单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req stockCountCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	d, err := parseDate(req.CountDate)
+	if err != nil {
+		BadRequest(c, errTitle, "count_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.CreateCount(c, svc.CountCreateParams{
+		OrgID:      req.OrgID,
+		CountDate:  d,
+		Remark:     req.Remark,
+		OperatorID: &act.ID,
+		Lines:      countLineParams(req.Lines),
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *InventoryHandler) getCount(c *gin.Context) {
+	const errTitle = "获取盘点单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetCount(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "盘点单不存在: "+err.Error())
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *InventoryHandler) listCounts(c *gin.Context) {
+	const errTitle = "获取盘点单列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	status, err := utils.GetQueryIntPointer(c, "status")
+	if err != nil {
+		BadRequest(c, errTitle, "status 非法")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListCounts(c, svc.CountParams{
+		OrgID:    orgID,
+		Status:   status,
+		DateFrom: from,
+		DateTo:   to,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *InventoryHandler) updateCountLines(c *gin.Context) {
+	const errTitle = "更新盘点明细失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req stockCountLinesReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.UpdateCountLines(c, req.ID, version, countLineParams(req.Lines)); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetCount(c, req.ID) }) {
+			return
+		}
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *InventoryHandler) postCount(c *gin.Context) {
+	const errTitle = "盘点过账失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可过账盘点单")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.PostCount(c, req.ID, &act.ID)
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *InventoryHandler) softDeleteCount(c *gin.Context) {
+	const errTitle = "删除盘点单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除盘点单")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SoftDeleteCount(c, req.ID); err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	domain "hdzk.cn/foodapp/internal/domain/market"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/market"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type MarketHandler struct{ s *svc.Service }
+
+func NewMarketHandler(s *svc.Service) *MarketHandler { return &MarketHandler{s: s} }
+
+func (h *MarketHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/market")
+
+	g.POST("/create_market", middleware.Idempotent(), h.create) // 新建市场
+	g.POST("/get_market", h.get)                                // 按 id 获取
+	g.POST("/list_market", h.list)                              // 列表
+	g.POST("/update_market", h.update)                          // 更新
+	g.POST("/soft_delete_market", h.softDelete)                 // 软删
+}
+
+type marketCreateReq struct {
+	OrgID   string  `json:"org_id" binding:"required,uuid4"`
+	Name    string  `json:"name" binding:"required,min=1,max=128"`
+	Address *string `json:"address" binding:"omitempty,max=255"`
+	Type    string  `json:"type" binding:"omitempty,oneof=wholesale retail supermarket online other"`
+	Sort    int     `json:"sort"`
+}
+
+type marketUpdateReq struct {
+	ID      string  `json:"id" binding:"required,uuid4"`
+	Name    *string `json:"name" binding:"omitempty,min=1,max=128"`
+	Address *string `json:"address" binding:"omitempty,max=255"`
+	Type    *string `json:"type" binding:"omitempty,oneof=wholesale retail supermarket online other"`
+	Sort    *int    `json:"sort"`
+	Version *int    `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+func (h *MarketHandler) create(c *gin.Context) {
+	const errTitle = "创建市场失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护市场")
+		return
+	}
+
+	var req marketCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Create(c, svc.CreateParams{
+		OrgID:   req.OrgID,
+		Name:    req.Name,
+		Address: req.Address,
+		Type:    req.Type,
+		Sort:    req.Sort,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrDuplicate) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *MarketHandler) get(c *gin.Context) {
+	const errTitle = "获取市场失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "市场不存在: "+err.Error())
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *MarketHandler) list(c *gin.Context) {
+	const errTitle = "获取市场列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	typ := c.Query("type")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, svc.ListParams{
+		OrgID:    orgID,
+		Keyword:  c.Query("keyword"),
+		Type:     &typ,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *MarketHandler) update(c *gin.Context) {
+	const errTitle = "更新市场失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护市场")
+		return
+	}
+
+	var req marketUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	err := h.s.Update(c, svc.UpdateParams{
+		ID:      req.ID,
+		Version: version,
+		Name:    req.Name,
+		Address: req.Address,
+		Type:    req.Type,
+		Sort:    req.Sort,
+	})
+	if err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
+		if errors.Is(err, domain.ErrDuplicate) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MarketHandler) softDelete(c *gin.Context) {
+	const errTitle = "删除市场失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可维护市场")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SoftDelete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/mealplan"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type MealPlanHandler struct{ s *svc.Service }
+
+func NewMealPlanHandler(s *svc.Service) *MealPlanHandler { return &MealPlanHandler{s: s} }
+
+func (h *MealPlanHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/meal_plan")
+
+	g.POST("/create_meal_plan", middleware.Idempotent(), h.create)
+	g.POST("/get_meal_plan", h.get)
+	g.POST("/list_meal_plan", h.list)
+	g.POST("/update_meal_plan", h.update)
+	g.POST("/delete_meal_plan", h.delete)
+}
+
+type mealPlanDishReq struct {
+	Name          string  `json:"name" binding:"required,min=1,max=128"`
+	RecipeID      *string `json:"recipe_id" binding:"omitempty,uuid4"`
+	RecipeVersion *int    `json:"recipe_version" binding:"omitempty,min=1"`
+	Servings      *int    `json:"servings" binding:"omitempty,min=0"`
+	Sort          int     `json:"sort" binding:"gte=0"`
+	Remark        *string `json:"remark" binding:"omitempty,max=255"`
+}
+
+type mealPlanCreateReq struct {
+	OrgID     string            `json:"org_id" binding:"required,uuid4"`
+	PlanDate  string            `json:"plan_date" binding:"required"` // YYYY-MM-DD
+	MealID    string            `json:"meal_id" binding:"required,uuid4"`
+	Headcount int               `json:"headcount" binding:"gte=0"`
+	Remark    *string           `json:"remark" binding:"omitempty,max=255"`
+	Dishes    []mealPlanDishReq `json:"dishes" binding:"omitempty,dive"`
+}
+
+type mealPlanUpdateReq struct {
+	ID        string             `json:"id" binding:"required,uuid4"`
+	Headcount *int               `json:"headcount" binding:"omitempty,min=0"`
+	Remark    *string            `json:"remark" binding:"omitempty,max=255"`
+	Dishes    *[]mealPlanDishReq `json:"dishes" binding:"omitempty,dive"`
+	Version   *int               `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+func toDishParams(in []mealPlanDishReq) []svc.DishParams {
+	out := make([]svc.DishParams, len(in))
+	for i, d := range in {
+		out[i] = svc.DishParams{Name: d.Name, RecipeID: d.RecipeID, RecipeVer: d.RecipeVersion, Servings: d.Servings, Sort: d.Sort, Remark: d.Remark}
+	}
+	return out
+}
+
+func (h *MealPlanHandler) create(c *gin.Context) {
+	const errTitle = "创建餐次计划失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可创建餐次计划")
+		return
+	}
+
+	var req mealPlanCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	d, err := parseDate(req.PlanDate)
+	if err != nil {
+		BadRequest(c, errTitle, "plan_date 格式应为 YYYY-MM-DD")
+		return
+	}
+
+	out, err := h.s.Create(c, svc.CreateParams{
+		OrgID:     req.OrgID,
+		PlanDate:  d,
+		MealID:    req.MealID,
+		Headcount: req.Headcount,
+		Remark:    req.Remark,
+		Dishes:    toDishParams(req.Dishes),
+	})
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *MealPlanHandler) get(c *gin.Context) {
+	const errTitle = "获取餐次计划失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "餐次计划不存在: "+err.Error())
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *MealPlanHandler) list(c *gin.Context) {
+	const errTitle = "获取餐次计划列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	var mealPtr *string
+	if mealID := strings.TrimSpace(c.Query("meal_id")); mealID != "" {
+		mealPtr = &mealID
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, svc.ListParams{
+		OrgID:    orgID,
+		MealID:   mealPtr,
+		DateFrom: from,
+		DateTo:   to,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *MealPlanHandler) update(c *gin.Context) {
+	const errTitle = "更新餐次计划失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可更新餐次计划")
+		return
+	}
+
+	var req mealPlanUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	params := svc.UpdateParams{ID: req.ID, Version: version, Headcount: req.Headcount, Remark: req.Remark}
+	if req.Dishes != nil {
+		dishes := toDishParams(*req.Dishes)
+		params.Dishes = &dishes
+	}
+	if err := h.s.Update(c, params); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MealPlanHandler) delete(c *gin.Context) {
+	const errTitle = "删除餐次计划失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除餐次计划")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.Delete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	domain "hdzk.cn/foodapp/internal/domain/merge"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/merge"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type MergeHandler struct{ s *svc.Service }
+
+func NewMergeHandler(s *svc.Service) *MergeHandler { return &MergeHandler{s: s} }
+
+func (h *MergeHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/merge")
+
+	g.POST("/merge_goods", h.mergeGoods)       // 合并重复商品（preview=true 仅预览）
+	g.POST("/merge_category", h.mergeCategory) // 合并重复品类（preview=true 仅预览）
+	g.POST("/get_merge_record", h.getRecord)   // 合并审计详情
+	g.POST("/list_merge_record", h.listRecord) // 合并审计列表
+}
+
+type mergeReq struct {
+	SurvivorID string   `json:"survivor_id" binding:"required,uuid4"`
+	LoserIDs   []string `json:"loser_ids" binding:"required,min=1,dive,uuid4"`
+	Preview    bool     `json:"preview"`
+	Remark     *string  `json:"remark" binding:"omitempty,max=255"`
+}
+
+func (h *MergeHandler) mergeGoods(c *gin.Context) {
+	h.merge(c, domain.EntityGoods, "合并商品失败")
+}
+
+func (h *MergeHandler) mergeCategory(c *gin.Context) {
+	h.merge(c, domain.EntityCategory, "合并品类失败")
+}
+
+func (h *MergeHandler) merge(c *gin.Context, entityType string, errTitle string) {
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可合并")
+		return
+	}
+
+	var req mergeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+
+	out, err := h.s.Merge(c, svc.MergeParams{
+		EntityType:   entityType,
+		SurvivorID:   req.SurvivorID,
+		LoserIDs:     req.LoserIDs,
+		Preview:      req.Preview,
+		OperatorID:   act.ID,
+		OperatorName: act.Username,
+		Remark:       req.Remark,
+	})
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *MergeHandler) getRecord(c *gin.Context) {
+	const errTitle = "获取合并记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetRecord(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "合并记录不存在: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *MergeHandler) listRecord(c *gin.Context) {
+	const errTitle = "获取合并记录列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	entityType := c.Query("entity_type")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListRecords(c, orgID, entityType, page, ps)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/notification"
+)
+
+type NotificationHandler struct{ s *svc.Service }
+
+func NewNotificationHandler(s *svc.Service) *NotificationHandler {
+	return &NotificationHandler{s: s}
+}
+
+func (h *NotificationHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/notification")
+
+	g.POST("/list_notification", h.list)     // 机构通知列表（可仅未读）
+	g.POST("/read_notification", h.markRead) // 标记已读（ids 为空时全部已读）
+}
+
+type notificationReadReq struct {
+	OrgID string   `json:"org_id" binding:"required,uuid4"`
+	IDs   []string `json:"ids" binding:"omitempty,max=500,dive,uuid4"`
+}
+
+func (h *NotificationHandler) list(c *gin.Context) {
+	const errTitle = "获取通知列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	var kind *string
+	if v := strings.TrimSpace(c.Query("kind")); v != "" {
+		kind = &v
+	}
+	unread, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, svc.ListParams{
+		OrgID:      orgID,
+		Kind:       kind,
+		UnreadOnly: unread,
+		Page:       page,
+		PageSize:   ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *NotificationHandler) markRead(c *gin.Context) {
+	const errTitle = "标记通知已读失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req notificationReadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	n, err := h.s.MarkRead(c, req.OrgID, req.IDs)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"marked": n})
+}
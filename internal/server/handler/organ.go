@@ -21,12 +21,12 @@ func NewOrganHandler(s *svc.Service) *OrganHandler { return &OrganHandler{s: s}
 func (h *OrganHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/orgs")
 
-	g.POST("/create_organ", h.create)          // 仅管理员
-	g.POST("/get_organ", h.get)                // 所有人可查
-	g.POST("/list_organ", h.list)              // 所有人可查
-	g.POST("/update_organ", h.update)          // 仅管理员
-	g.POST("/soft_delete_organ", h.softDelete) // 仅管理员（软删）
-	g.POST("/hard_delete_organ", h.hardDelete) // 仅管理员（硬删）
+	g.POST("/create_organ", middleware.Idempotent(), h.create) // 仅管理员
+	g.POST("/get_organ", h.get)                                // 所有人可查
+	g.POST("/list_organ", h.list)                              // 所有人可查
+	g.POST("/update_organ", h.update)                          // 仅管理员
+	g.POST("/soft_delete_organ", h.softDelete)                 // 仅管理员（软删）
+	g.POST("/hard_delete_organ", h.hardDelete)                 // 仅管理员（硬删）
 }
 
 /************* 请求体 *************/
@@ -36,6 +36,7 @@ type orgCreateReq struct {
 	Code        *string `json:"code"`        // 可空；空串将被置为 NULL
 	Description *string `json:"description"` // 可空
 	Sort        *int    `json:"sort"`        // 可空
+	TimeZone    *string `json:"time_zone"`   // 可空，默认 Asia/Shanghai
 }
 
 type orgUpdateReq struct {
@@ -44,6 +45,8 @@ type orgUpdateReq struct {
 	ParentID    *string `json:"parent_id"`
 	Code        *string `json:"code"` // 若传入空串，将置为 NULL
 	Description *string `json:"description"`
+	TimeZone    *string `json:"time_zone"` // IANA 时区名，如 Asia/Shanghai
+	Version     *int    `json:"version"`   // 期望版本号，也可用 If-Match
 }
 
 /************* 处理函数 *************/
@@ -88,6 +91,9 @@ func (h *OrganHandler) create(c *gin.Context) {
 	if req.Sort != nil {
 		m.Sort = *req.Sort
 	}
+	if req.TimeZone != nil {
+		m.TimeZone = *req.TimeZone
+	}
 
 	if err := h.s.Create(c, m); err != nil {
 		InternalError(c, errTitle, err.Error())
@@ -108,7 +114,7 @@ func (h *OrganHandler) get(c *gin.Context) {
 		NotFoundError(c, errTitle, err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, o)
+	withETag(c, o)
 }
 
 func ptr[T any](v T) *T { return &v }
@@ -169,11 +175,16 @@ func (h *OrganHandler) update(c *gin.Context) {
 		BadRequest(c, errTitle, "请求参数无效: "+err.Error())
 		return
 	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
 
 	// 组装要更新的模型（你的 Service.Update 要求 m.Name 非空）
 	update_m := svc.UpdateInput{}
 
 	update_m.ID = req.ID
+	update_m.Version = version
 	if req.Name != nil {
 		update_m.Name = req.Name
 	}
@@ -186,8 +197,14 @@ func (h *OrganHandler) update(c *gin.Context) {
 	if req.Code != nil {
 		update_m.Code = req.Code
 	}
+	if req.TimeZone != nil {
+		update_m.TimeZone = req.TimeZone
+	}
 
 	if err := h.s.Update(c, update_m); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetByID(c, req.ID) }) {
+			return
+		}
 		InternalError(c, errTitle, err.Error())
 		return
 	}
@@ -197,7 +214,7 @@ func (h *OrganHandler) update(c *gin.Context) {
 		InternalError(c, errTitle, "获取更新后数据失败: "+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, obj)
+	withETag(c, obj)
 }
 
 func (h *OrganHandler) softDelete(c *gin.Context) {
@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	bidding "hdzk.cn/foodapp/internal/domain/bidding"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	domain "hdzk.cn/foodapp/internal/domain/portal"
+	qualification "hdzk.cn/foodapp/internal/domain/qualification"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/portal"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+// PortalHandler 供应商门户接口（外部用户），所有操作限定在登录账户所属供应商
+type PortalHandler struct {
+	s        *svc.Service
+	secret   string
+	ttlMins  int
+	maxBytes int64 // 上传文件大小上限
+}
+
+func NewPortalHandler(s *svc.Service, secret string, ttlMins int, maxBytes int64) *PortalHandler {
+	return &PortalHandler{s: s, secret: secret, ttlMins: ttlMins, maxBytes: maxBytes}
+}
+
+// RegisterPublic 门户登录（无需鉴权）
+func (h *PortalHandler) RegisterPublic(rg *gin.RouterGroup) {
+	rg.POST("/portal/auth/login", h.login)
+}
+
+// Register 门户受保护接口（需 RequireSupplierAuth），按权限范围拦截
+func (h *PortalHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/portal")
+
+	g.POST("/me", h.me)                          // 当前账户
+	g.POST("/change_password", h.changePassword) // 修改密码
+
+	quote := g.Group("/", middleware.RequireScope(domain.ScopeQuote))
+	quote.POST("/list_inquiry", h.listInquiries) // 向本供应商开放的询价单（query）
+	quote.POST("/get_inquiry", h.getInquiry)     // 询价商品及本供应商报价
+	quote.POST("/save_quote", h.saveQuote)       // 提交报价（可多次覆盖，截止前有效）
+
+	order := g.Group("/", middleware.RequireScope(domain.ScopeOrder))
+	order.POST("/list_purchase_order", h.listOrders) // 本供应商采购单（query，不含草稿）
+	order.POST("/get_purchase_order", h.getOrder)
+
+	settlement := g.Group("/", middleware.RequireScope(domain.ScopeSettlement))
+	settlement.POST("/settlement_pdf", h.settlementPDF) // 结算对账单
+
+	doc := g.Group("/", middleware.RequireScope(domain.ScopeDocument))
+	doc.POST("/upload_document", middleware.Idempotent(), h.uploadDocument) // 上传资质文件（multipart/form-data，待审核）
+	doc.POST("/list_document", h.listDocuments)                             // 本供应商资质文件（query）
+}
+
+type portalLoginReq struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type portalPasswordReq struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=64"`
+}
+
+type portalQuoteItemReq struct {
+	GoodsID   string          `json:"goods_id" binding:"required,uuid4"`
+	UnitPrice decimal.Decimal `json:"unit_price"`
+}
+
+type portalQuoteReq struct {
+	InquiryID string               `json:"inquiry_id" binding:"required,uuid4"`
+	Items     []portalQuoteItemReq `json:"items" binding:"required,min=1,max=1000,dive"`
+}
+
+type portalSettlementReq struct {
+	DateFrom string `json:"date_from" binding:"required"` // YYYY-MM-DD
+	DateTo   string `json:"date_to" binding:"required"`   // YYYY-MM-DD
+}
+
+func portalActor(c *gin.Context) svc.Actor {
+	a := middleware.GetSupplierActor(c)
+	return svc.Actor{AccountID: a.ID, SupplierID: a.SupplierID, OrgID: a.OrgID}
+}
+
+func (h *PortalHandler) login(c *gin.Context) {
+	const errTitle = "登录失败"
+	var req portalLoginReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	a, err := h.s.Login(c, req.Username, req.Password)
+	if err != nil {
+		if errors.Is(err, svc.ErrLogin) {
+			UnauthorizedError(c, errTitle, err.Error())
+			return
+		}
+		ForbiddenError(c, errTitle, err.Error())
+		return
+	}
+
+	now := time.Now()
+	exp := now.Add(time.Duration(h.ttlMins) * time.Minute)
+	claims := jwt.MapClaims{
+		"sub":    a.ID,
+		"usr":    a.Username,
+		"typ":    middleware.TokenTypeSupplier,
+		"sid":    a.SupplierID,
+		"org_id": a.OrgID,
+		"iat":    now.Unix(),
+		"exp":    exp.Unix(),
+		"iss":    "foodapp",
+	}
+	ss, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.secret))
+	if err != nil {
+		InternalError(c, errTitle, "生成token失败"+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"token":      ss,
+		"token_type": "Bearer",
+		"expires_in": int(exp.Sub(now).Seconds()),
+		"scopes":     a.ScopeList,
+	})
+}
+
+func (h *PortalHandler) me(c *gin.Context) {
+	const errTitle = "获取账户信息失败"
+	out, err := h.s.GetAccount(c, middleware.GetSupplierActor(c).ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "账户不存在")
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PortalHandler) changePassword(c *gin.Context) {
+	const errTitle = "修改密码失败"
+	var req portalPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.ChangePassword(c, middleware.GetSupplierActor(c).ID, req.OldPassword, req.NewPassword); err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PortalHandler) listInquiries(c *gin.Context) {
+	const errTitle = "获取询价单列表失败"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListInquiries(c, portalActor(c), page, ps)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *PortalHandler) getInquiry(c *gin.Context) {
+	const errTitle = "获取询价单失败"
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetInquiry(c, portalActor(c), req.ID)
+	if err != nil {
+		portalError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PortalHandler) saveQuote(c *gin.Context) {
+	const errTitle = "提交报价失败"
+	var req portalQuoteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	items := make([]svc.QuoteItem, len(req.Items))
+	for i, it := range req.Items {
+		items[i] = svc.QuoteItem{GoodsID: it.GoodsID, UnitPrice: it.UnitPrice}
+	}
+	out, err := h.s.SubmitQuotes(c, portalActor(c), req.InquiryID, items)
+	if err != nil {
+		portalError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(out), "items": out})
+}
+
+func (h *PortalHandler) listOrders(c *gin.Context) {
+	const errTitle = "获取采购单列表失败"
+	var status *int
+	if v := strings.TrimSpace(c.Query("status")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			BadRequest(c, errTitle, "status 非法")
+			return
+		}
+		status = &n
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListOrders(c, portalActor(c), svc.OrderListParams{
+		Status:   status,
+		DateFrom: from,
+		DateTo:   to,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *PortalHandler) getOrder(c *gin.Context) {
+	const errTitle = "获取采购单失败"
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetOrder(c, portalActor(c), req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "采购单不存在")
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PortalHandler) settlementPDF(c *gin.Context) {
+	const errTitle = "生成结算对账单失败"
+	var req portalSettlementReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, to, ok := bindPeriod(c, errTitle, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+	data, err := h.s.Settlement(c, portalActor(c), from, to)
+	if err != nil {
+		pdfError(c, errTitle, err)
+		return
+	}
+	sendPDF(c, "settlement_"+from.Format("20060102")+"_"+to.Format("20060102")+".pdf", data)
+}
+
+func (h *PortalHandler) uploadDocument(c *gin.Context) {
+	const errTitle = "上传资质文件失败"
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+1<<20)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		BadRequest(c, errTitle, "缺少文件或文件过大")
+		return
+	}
+	docType := strings.TrimSpace(c.PostForm("doc_type"))
+	if docType == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 doc_type")
+		return
+	}
+	issue, err := parseClearableDate(formPtr(c, "issue_date"))
+	if err != nil {
+		BadRequest(c, errTitle, "issue_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	expiry, err := parseClearableDate(formPtr(c, "expiry_date"))
+	if err != nil {
+		BadRequest(c, errTitle, "expiry_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		BadRequest(c, errTitle, "读取文件失败")
+		return
+	}
+	defer f.Close()
+
+	out, err := h.s.UploadDocument(c, portalActor(c), svc.DocumentUploadParams{
+		DocType:    docType,
+		DocNo:      formPtr(c, "doc_no"),
+		IssueDate:  issue,
+		ExpiryDate: expiry,
+		Remark:     formPtr(c, "remark"),
+		FileName:   fh.Filename,
+		File:       f,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *PortalHandler) listDocuments(c *gin.Context) {
+	const errTitle = "获取资质文件列表失败"
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListDocuments(c, portalActor(c), page, ps)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func portalError(c *gin.Context, errTitle string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotInvited), errors.Is(err, gorm.ErrRecordNotFound):
+		NotFoundError(c, errTitle, err.Error())
+	case errors.Is(err, domain.ErrClosed), errors.Is(err, bidding.ErrRoundActive), errors.Is(err, inquiry.ErrLocked),
+		errors.Is(err, supplier.ErrInactive), errors.Is(err, qualification.ErrLapsed):
+		ConflictError(c, errTitle, err.Error())
+	default:
+		BadRequest(c, errTitle, err.Error())
+	}
+}
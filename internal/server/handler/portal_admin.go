@@ -0,0 +1,340 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	domain "hdzk.cn/foodapp/internal/domain/portal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/portal"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+// PortalAdminHandler 员工侧：供应商门户账户管理与询价单开放
+type PortalAdminHandler struct{ s *svc.Service }
+
+func NewPortalAdminHandler(s *svc.Service) *PortalAdminHandler { return &PortalAdminHandler{s: s} }
+
+func (h *PortalAdminHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/supplier_portal")
+
+	g.POST("/list_scope", h.listScopes)                                 // 门户权限范围
+	g.POST("/create_account", middleware.Idempotent(), h.createAccount) // 创建门户账户
+	g.POST("/get_account", h.getAccount)                                // 按 id 获取
+	g.POST("/list_account", h.listAccounts)                             // 列表（query：org_id/supplier_id/status）
+	g.POST("/update_account", h.updateAccount)                          // 修改资料/权限范围/启停
+	g.POST("/reset_password", h.resetPassword)                          // 重置密码
+	g.POST("/soft_delete_account", h.deleteAccount)                     // 软删
+	g.POST("/open_inquiry", h.openInquiry)                              // 询价单向供应商开放报价
+	g.POST("/revoke_inquiry", h.revokeInquiry)                          // 取消开放
+	g.POST("/list_inquiry_supplier", h.listInvitations)                 // 询价单已开放的供应商
+}
+
+type portalAccountCreateReq struct {
+	SupplierID  string   `json:"supplier_id" binding:"required,uuid4"`
+	Username    string   `json:"username" binding:"required,min=3,max=64"`
+	Password    string   `json:"password" binding:"required,min=8,max=64"`
+	DisplayName *string  `json:"display_name" binding:"omitempty,max=64"`
+	Phone       *string  `json:"phone" binding:"omitempty,max=32"`
+	Scopes      []string `json:"scopes" binding:"required,min=1"`
+}
+
+type portalAccountUpdateReq struct {
+	ID          string    `json:"id" binding:"required,uuid4"`
+	DisplayName *string   `json:"display_name" binding:"omitempty,max=64"`
+	Phone       *string   `json:"phone" binding:"omitempty,max=32"`
+	Scopes      *[]string `json:"scopes" binding:"omitempty,min=1"`
+	Status      *int      `json:"status" binding:"omitempty,oneof=1 2"`
+	Version     *int      `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type portalResetPasswordReq struct {
+	ID       string `json:"id" binding:"required,uuid4"`
+	Password string `json:"password" binding:"required,min=8,max=64"`
+}
+
+type portalOpenInquiryReq struct {
+	InquiryID   string   `json:"inquiry_id" binding:"required,uuid4"`
+	SupplierIDs []string `json:"supplier_ids" binding:"required,min=1,max=200,dive,uuid4"`
+	ClosesAt    *string  `json:"closes_at"` // YYYY-MM-DD HH:MM:SS，空=询价单审核前均可报价
+}
+
+type portalRevokeInquiryReq struct {
+	InquiryID  string `json:"inquiry_id" binding:"required,uuid4"`
+	SupplierID string `json:"supplier_id" binding:"required,uuid4"`
+}
+
+type portalInquiryReq struct {
+	InquiryID string `json:"inquiry_id" binding:"required,uuid4"`
+}
+
+// adminOnly 门户账户与开放管理仅限管理员
+func adminOnly(c *gin.Context, errTitle string) (*middleware.Actor, bool) {
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return nil, false
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可管理供应商门户")
+		return nil, false
+	}
+	return act, true
+}
+
+func (h *PortalAdminHandler) listScopes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"items": domain.Scopes})
+}
+
+func (h *PortalAdminHandler) createAccount(c *gin.Context) {
+	const errTitle = "创建门户账户失败"
+	act, ok := adminOnly(c, errTitle)
+	if !ok {
+		return
+	}
+
+	var req portalAccountCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.CreateAccount(c, svc.CreateAccountParams{
+		SupplierID:  req.SupplierID,
+		Username:    req.Username,
+		Password:    req.Password,
+		DisplayName: req.DisplayName,
+		Phone:       req.Phone,
+		Scopes:      req.Scopes,
+		CreatedBy:   &act.ID,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *PortalAdminHandler) getAccount(c *gin.Context) {
+	const errTitle = "获取门户账户失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetAccount(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "门户账户不存在")
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *PortalAdminHandler) listAccounts(c *gin.Context) {
+	const errTitle = "获取门户账户列表失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	var status *int
+	if v := strings.TrimSpace(c.Query("status")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			BadRequest(c, errTitle, "status 非法")
+			return
+		}
+		status = &n
+	}
+	supplierID := c.Query("supplier_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListAccounts(c, svc.AccountListParams{
+		OrgID:      orgID,
+		SupplierID: &supplierID,
+		Status:     status,
+		Page:       page,
+		PageSize:   ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *PortalAdminHandler) updateAccount(c *gin.Context) {
+	const errTitle = "修改门户账户失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req portalAccountUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	out, err := h.s.UpdateAccount(c, svc.UpdateAccountParams{
+		ID:          req.ID,
+		Version:     version,
+		DisplayName: req.DisplayName,
+		Phone:       req.Phone,
+		Scopes:      req.Scopes,
+		Status:      req.Status,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "门户账户不存在")
+			return
+		}
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetAccount(c, req.ID) }) {
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *PortalAdminHandler) resetPassword(c *gin.Context) {
+	const errTitle = "重置门户账户密码失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req portalResetPasswordReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.ResetPassword(c, req.ID, req.Password); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "门户账户不存在")
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PortalAdminHandler) deleteAccount(c *gin.Context) {
+	const errTitle = "删除门户账户失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.DeleteAccount(c, req.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "门户账户不存在")
+			return
+		}
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PortalAdminHandler) openInquiry(c *gin.Context) {
+	const errTitle = "开放询价单失败"
+	act, ok := adminOnly(c, errTitle)
+	if !ok {
+		return
+	}
+
+	var req portalOpenInquiryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	var closesAt *time.Time
+	if req.ClosesAt != nil && strings.TrimSpace(*req.ClosesAt) != "" {
+		t, err := parseDateTime(*req.ClosesAt)
+		if err != nil {
+			BadRequest(c, errTitle, "closes_at 格式应为 YYYY-MM-DD HH:MM:SS")
+			return
+		}
+		closesAt = &t
+	}
+	list, err := h.s.OpenInquiry(c, svc.OpenInquiryParams{
+		InquiryID:   req.InquiryID,
+		SupplierIDs: req.SupplierIDs,
+		ClosesAt:    closesAt,
+		InvitedBy:   &act.ID,
+	})
+	if err != nil {
+		if errors.Is(err, inquiry.ErrLocked) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *PortalAdminHandler) revokeInquiry(c *gin.Context) {
+	const errTitle = "取消开放询价单失败"
+	if _, ok := adminOnly(c, errTitle); !ok {
+		return
+	}
+
+	var req portalRevokeInquiryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.RevokeInquiry(c, req.InquiryID, req.SupplierID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "询价单未向该供应商开放")
+			return
+		}
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *PortalAdminHandler) listInvitations(c *gin.Context) {
+	const errTitle = "获取询价单开放供应商失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req portalInquiryReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.ListInvitations(c, req.InquiryID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
@@ -0,0 +1,359 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	bidding "hdzk.cn/foodapp/internal/domain/bidding"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	qualification "hdzk.cn/foodapp/internal/domain/qualification"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/price"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type PriceHandler struct{ s *svc.Service }
+
+func NewPriceHandler(s *svc.Service) *PriceHandler { return &PriceHandler{s: s} }
+
+func (h *PriceHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/price")
+
+	g.POST("/price_trend", h.trend) // 商品/品类均价走势（环比、同比）
+
+	g.POST("/save_inquiry_line", h.saveInquiryLine)  // 录入询价明细市场价（自动检测异常）
+	g.POST("/list_inquiry_line", h.listInquiryLines) // 询价单明细及异常标记
+	g.POST("/save_supplier_quote", h.saveQuote)      // 录入供应商报价（自动检测异常）
+	g.POST("/list_supplier_quote", h.listQuotes)     // 询价单报价及异常标记
+	g.POST("/get_price_rule", h.getRule)             // 机构异常检测规则
+	g.POST("/set_price_rule", h.setRule)             // 设置机构异常检测规则
+	g.POST("/list_price_flag", h.listFlags)          // 异常复核队列
+	g.POST("/accept_price_flag", h.acceptFlag)       // 确认异常（附复核意见）
+}
+
+type priceTrendReq struct {
+	OrgID          string  `json:"org_id" binding:"required,uuid4"`
+	IncludeSubOrgs bool    `json:"include_sub_orgs"`
+	GoodsID        *string `json:"goods_id" binding:"omitempty,uuid4"`
+	CategoryID     *string `json:"category_id" binding:"omitempty,uuid4"`
+	MarketID       *string `json:"market_id" binding:"omitempty,uuid4"` // 只看该市场的价格
+	DateFrom       string  `json:"date_from" binding:"required"`
+	DateTo         string  `json:"date_to" binding:"required"`
+	Granularity    string  `json:"granularity" binding:"omitempty,oneof=inquiry week month"` // 默认 inquiry
+}
+
+func (h *PriceHandler) trend(c *gin.Context) {
+	const errTitle = "获取价格走势失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req priceTrendReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, err := parseDate(req.DateFrom)
+	if err != nil {
+		BadRequest(c, errTitle, "date_from 格式应为 YYYY-MM-DD")
+		return
+	}
+	to, err := parseDate(req.DateTo)
+	if err != nil {
+		BadRequest(c, errTitle, "date_to 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.Trend(c, svc.TrendParams{
+		OrgID:          req.OrgID,
+		IncludeSubOrgs: req.IncludeSubOrgs,
+		GoodsID:        req.GoodsID,
+		CategoryID:     req.CategoryID,
+		MarketID:       req.MarketID,
+		DateFrom:       from,
+		DateTo:         to,
+		Granularity:    req.Granularity,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+type marketPriceReq struct {
+	MarketID string          `json:"market_id" binding:"required,uuid4"`
+	Price    decimal.Decimal `json:"price"`
+}
+
+type inquiryLineReq struct {
+	InquiryID    string           `json:"inquiry_id" binding:"required,uuid4"`
+	GoodsID      string           `json:"goods_id" binding:"required,uuid4"`
+	GuidePrice   *decimal.Decimal `json:"guide_price"`
+	Prices       []marketPriceReq `json:"prices" binding:"omitempty,dive"` // 各市场价
+	Market1Price *decimal.Decimal `json:"market1_price"`                   // 旧版：询价单第 1..3 个市场的价格
+	Market2Price *decimal.Decimal `json:"market2_price"`
+	Market3Price *decimal.Decimal `json:"market3_price"`
+}
+
+type quoteReq struct {
+	InquiryID  string          `json:"inquiry_id" binding:"required,uuid4"`
+	SupplierID string          `json:"supplier_id" binding:"required,uuid4"`
+	GoodsID    string          `json:"goods_id" binding:"required,uuid4"`
+	UnitPrice  decimal.Decimal `json:"unit_price"`
+}
+
+type inquiryIDReq struct {
+	InquiryID string `json:"inquiry_id" binding:"required,uuid4"`
+}
+
+type orgIDReq struct {
+	OrgID string `json:"org_id" binding:"required,uuid4"`
+}
+
+type priceRuleReq struct {
+	OrgID      string          `json:"org_id" binding:"required,uuid4"`
+	BandPct    decimal.Decimal `json:"band_pct"`
+	SpreadPct  decimal.Decimal `json:"spread_pct"`
+	Window     int             `json:"window_size" binding:"required,min=1,max=100"`
+	MinSamples int             `json:"min_samples" binding:"required,min=1"`
+}
+
+type acceptFlagReq struct {
+	ID      string `json:"id" binding:"required,uuid4"`
+	Comment string `json:"comment" binding:"required,max=255"`
+}
+
+func (h *PriceHandler) saveInquiryLine(c *gin.Context) {
+	const errTitle = "录入询价明细失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req inquiryLineReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	prices := make([]svc.MarketPriceParam, len(req.Prices))
+	for i, p := range req.Prices {
+		prices[i] = svc.MarketPriceParam{MarketID: p.MarketID, Price: p.Price}
+	}
+	out, err := h.s.SaveInquiryLine(c, svc.InquiryLineParams{
+		InquiryID:    req.InquiryID,
+		GoodsID:      req.GoodsID,
+		GuidePrice:   req.GuidePrice,
+		Prices:       prices,
+		Market1Price: req.Market1Price,
+		Market2Price: req.Market2Price,
+		Market3Price: req.Market3Price,
+	})
+	if err != nil {
+		if errors.Is(err, inquiry.ErrLocked) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PriceHandler) listInquiryLines(c *gin.Context) {
+	const errTitle = "获取询价明细失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req inquiryIDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.ListInquiryLines(c, req.InquiryID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *PriceHandler) saveQuote(c *gin.Context) {
+	const errTitle = "录入供应商报价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req quoteReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.SaveQuote(c, svc.QuoteParams{
+		InquiryID:  req.InquiryID,
+		SupplierID: req.SupplierID,
+		GoodsID:    req.GoodsID,
+		UnitPrice:  req.UnitPrice,
+	})
+	if err != nil {
+		if errors.Is(err, inquiry.ErrLocked) || errors.Is(err, bidding.ErrRoundActive) ||
+			errors.Is(err, supplier.ErrInactive) || errors.Is(err, qualification.ErrLapsed) {
+			ConflictError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PriceHandler) listQuotes(c *gin.Context) {
+	const errTitle = "获取供应商报价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req inquiryIDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.ListQuotes(c, req.InquiryID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *PriceHandler) getRule(c *gin.Context) {
+	const errTitle = "获取价格异常规则失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req orgIDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetRule(c, req.OrgID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PriceHandler) setRule(c *gin.Context) {
+	const errTitle = "设置价格异常规则失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可设置价格异常规则")
+		return
+	}
+
+	var req priceRuleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.SaveRule(c, svc.RuleParams{
+		OrgID:      req.OrgID,
+		BandPct:    req.BandPct,
+		SpreadPct:  req.SpreadPct,
+		Window:     req.Window,
+		MinSamples: req.MinSamples,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PriceHandler) listFlags(c *gin.Context) {
+	const errTitle = "获取价格异常失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	inquiryID, goodsID, lineType := c.Query("inquiry_id"), c.Query("goods_id"), c.Query("line_type")
+	status, err := utils.GetQueryIntPointer(c, "status")
+	if err != nil {
+		BadRequest(c, errTitle, "参数错误：status 必须为整数")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListFlags(c, svc.FlagListParams{
+		OrgID:     orgID,
+		InquiryID: &inquiryID,
+		GoodsID:   &goodsID,
+		LineType:  &lineType,
+		Status:    status,
+		Page:      page,
+		PageSize:  ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *PriceHandler) acceptFlag(c *gin.Context) {
+	const errTitle = "确认价格异常失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可确认价格异常")
+		return
+	}
+
+	var req acceptFlagReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.AcceptFlag(c, req.ID, act.ID, req.Comment); err != nil {
+		if errors.Is(err, svc.ErrNotFound) {
+			NotFoundError(c, errTitle, "异常标记不存在或已确认")
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/purchase"
+	types "hdzk.cn/foodapp/internal/transport"
+	"hdzk.cn/foodapp/pkg/utils"
+)
+
+type PurchaseHandler struct{ s *svc.Service }
+
+func NewPurchaseHandler(s *svc.Service) *PurchaseHandler { return &PurchaseHandler{s: s} }
+
+func (h *PurchaseHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/purchase")
+
+	g.POST("/create_purchase_order", middleware.Idempotent(), h.create)
+	g.POST("/get_purchase_order", h.get)
+	g.POST("/list_purchase_order", h.list)
+	g.POST("/submit_purchase_order", h.submit)          // 草稿 → 已提交
+	g.POST("/cancel_purchase_order", h.cancel)          // 已提交 → 已作废
+	g.POST("/receive_purchase_order", h.receive)        // 已提交 → 已收货（登记实收数量）
+	g.POST("/soft_delete_purchase_order", h.softDelete) // 仅草稿可删
+}
+
+type purchaseLineReq struct {
+	GoodsID    string           `json:"goods_id" binding:"required,uuid4"`
+	UnitID     string           `json:"unit_id" binding:"required,uuid4"`
+	Quantity   decimal.Decimal  `json:"quantity"`
+	UnitPrice  decimal.Decimal  `json:"unit_price"`
+	FloatRatio *decimal.Decimal `json:"float_ratio"`
+	InquiryID  *string          `json:"inquiry_id" binding:"omitempty,uuid4"`
+	Sort       int              `json:"sort" binding:"gte=0"`
+}
+
+type purchaseCreateReq struct {
+	OrgID        string            `json:"org_id" binding:"required,uuid4"`
+	SupplierID   string            `json:"supplier_id" binding:"required,uuid4"`
+	ExpectedDate string            `json:"expected_date" binding:"required"` // YYYY-MM-DD
+	Remark       *string           `json:"remark" binding:"omitempty,max=255"`
+	Lines        []purchaseLineReq `json:"lines" binding:"required,min=1,dive"`
+}
+
+func (h *PurchaseHandler) create(c *gin.Context) {
+	const errTitle = "创建采购单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可创建采购单")
+		return
+	}
+
+	var req purchaseCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	d, err := parseDate(req.ExpectedDate)
+	if err != nil {
+		BadRequest(c, errTitle, "expected_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	lines := make([]svc.LineParams, len(req.Lines))
+	for i, l := range req.Lines {
+		lines[i] = svc.LineParams{
+			GoodsID:    l.GoodsID,
+			UnitID:     l.UnitID,
+			Quantity:   l.Quantity,
+			UnitPrice:  l.UnitPrice,
+			FloatRatio: l.FloatRatio,
+			InquiryID:  l.InquiryID,
+			Sort:       l.Sort,
+		}
+	}
+	out, err := h.s.Create(c, svc.CreateParams{
+		OrgID:        req.OrgID,
+		SupplierID:   req.SupplierID,
+		ExpectedDate: d,
+		Remark:       req.Remark,
+		OperatorID:   &act.ID,
+		Lines:        lines,
+	})
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *PurchaseHandler) get(c *gin.Context) {
+	const errTitle = "获取采购单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "采购单不存在: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PurchaseHandler) list(c *gin.Context) {
+	const errTitle = "获取采购单列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	status, err := utils.GetQueryIntPointer(c, "status")
+	if err != nil {
+		BadRequest(c, errTitle, "status 非法")
+		return
+	}
+	supplierID := c.Query("supplier_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, svc.ListParams{
+		OrgID:      orgID,
+		SupplierID: &supplierID,
+		Status:     status,
+		DateFrom:   from,
+		DateTo:     to,
+		Page:       page,
+		PageSize:   ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+type purchaseReceiveLineReq struct {
+	LineID      string          `json:"line_id" binding:"required,uuid4"`
+	ReceivedQty decimal.Decimal `json:"received_qty"`
+	UnitID      string          `json:"unit_id" binding:"omitempty,uuid4"` // 称重单位，空=明细单位
+}
+
+type purchaseReceiveReq struct {
+	ID         string                   `json:"id" binding:"required,uuid4"`
+	ReceivedAt string                   `json:"received_at"` // YYYY-MM-DD HH:MM:SS，空=当前时间
+	Lines      []purchaseReceiveLineReq `json:"lines" binding:"omitempty,dive"`
+}
+
+func (h *PurchaseHandler) receive(c *gin.Context) {
+	const errTitle = "采购单收货失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可操作采购单")
+		return
+	}
+
+	var req purchaseReceiveReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	var at time.Time
+	if strings.TrimSpace(req.ReceivedAt) != "" {
+		t, err := parseDateTime(req.ReceivedAt)
+		if err != nil {
+			BadRequest(c, errTitle, "received_at 格式应为 YYYY-MM-DD HH:MM:SS")
+			return
+		}
+		at = t
+	}
+	lines := make([]svc.ReceiveLine, len(req.Lines))
+	for i, l := range req.Lines {
+		lines[i] = svc.ReceiveLine{LineID: l.LineID, ReceivedQty: l.ReceivedQty, UnitID: l.UnitID}
+	}
+	out, err := h.s.Receive(c, svc.ReceiveParams{
+		ID:         req.ID,
+		ReceivedAt: at,
+		ReceivedBy: &act.ID,
+		Lines:      lines,
+	})
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *PurchaseHandler) submit(c *gin.Context) {
+	h.transition(c, "提交采购单失败", h.s.Submit)
+}
+
+func (h *PurchaseHandler) cancel(c *gin.Context) {
+	h.transition(c, "作废采购单失败", h.s.Cancel)
+}
+
+func (h *PurchaseHandler) softDelete(c *gin.Context) {
+	h.transition(c, "删除采购单失败", h.s.SoftDelete)
+}
+
+func (h *PurchaseHandler) transition(c *gin.Context, errTitle string, fn func(ctx context.Context, id string) error) {
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可操作采购单")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := fn(c, req.ID); err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
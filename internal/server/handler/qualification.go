@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/qualification"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/qualification"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type QualificationHandler struct {
+	s        *svc.Service
+	maxBytes int64 // 上传文件大小上限
+}
+
+func NewQualificationHandler(s *svc.Service, maxBytes int64) *QualificationHandler {
+	return &QualificationHandler{s: s, maxBytes: maxBytes}
+}
+
+func (h *QualificationHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/supplier_doc")
+
+	g.POST("/list_doc_type", h.listTypes)                         // 资质类型（含是否必备）
+	g.POST("/upload_document", middleware.Idempotent(), h.upload) // 上传资质文件（multipart/form-data）
+	g.POST("/get_document", h.get)                                // 按 id 获取
+	g.POST("/list_document", h.list)                              // 列表（按机构/供应商/类型/审核状态/到期日筛选）
+	g.POST("/download_document", h.download)                      // 下载资质文件
+	g.POST("/update_document", h.update)                          // 修改证照编号/日期/备注（日期变化需重新审核）
+	g.POST("/verify_document", h.verify)                          // 审核通过/驳回
+	g.POST("/soft_delete_document", h.softDelete)                 // 软删
+	g.POST("/supplier_compliance", h.compliance)                  // 供应商各类资质状态
+	g.POST("/export_document", h.export)                          // 导出清单与文件（zip，供检查）
+}
+
+type documentUpdateReq struct {
+	ID         string  `json:"id" binding:"required,uuid4"`
+	DocNo      *string `json:"doc_no" binding:"omitempty,max=64"`
+	IssueDate  *string `json:"issue_date"`  // YYYY-MM-DD，空串清空
+	ExpiryDate *string `json:"expiry_date"` // YYYY-MM-DD，空串为长期有效
+	Remark     *string `json:"remark" binding:"omitempty,max=255"`
+	Version    *int    `json:"version"` // 期望版本号，也可用 If-Match
+}
+
+type documentVerifyReq struct {
+	ID      string  `json:"id" binding:"required,uuid4"`
+	Approve bool    `json:"approve"`
+	Remark  *string `json:"remark" binding:"omitempty,max=255"`
+}
+
+type complianceReq struct {
+	SupplierID string  `json:"supplier_id" binding:"required,uuid4"`
+	Date       *string `json:"date"` // YYYY-MM-DD，默认今天
+	WarnDays   *int    `json:"warn_days" binding:"omitempty,min=0,max=366"`
+}
+
+type documentExportReq struct {
+	OrgID      string  `json:"org_id" binding:"required,uuid4"`
+	SupplierID *string `json:"supplier_id" binding:"omitempty,uuid4"`
+}
+
+// docError 资质相关错误映射：记录不存在 404，其余 400
+func docError(c *gin.Context, errTitle string, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		NotFoundError(c, errTitle, "记录不存在")
+		return
+	}
+	BadRequest(c, errTitle, err.Error())
+}
+
+func (h *QualificationHandler) listTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"items": domain.DocTypes})
+}
+
+func (h *QualificationHandler) upload(c *gin.Context) {
+	const errTitle = "上传资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可上传资质文件")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+1<<20)
+	fh, err := c.FormFile("file")
+	if err != nil {
+		BadRequest(c, errTitle, "缺少文件或文件过大")
+		return
+	}
+	supplierID := strings.TrimSpace(c.PostForm("supplier_id"))
+	docType := strings.TrimSpace(c.PostForm("doc_type"))
+	if supplierID == "" || docType == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 supplier_id 或 doc_type")
+		return
+	}
+	issue, err := parseClearableDate(formPtr(c, "issue_date"))
+	if err != nil {
+		BadRequest(c, errTitle, "issue_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	expiry, err := parseClearableDate(formPtr(c, "expiry_date"))
+	if err != nil {
+		BadRequest(c, errTitle, "expiry_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	f, err := fh.Open()
+	if err != nil {
+		BadRequest(c, errTitle, "读取文件失败")
+		return
+	}
+	defer f.Close()
+
+	out, err := h.s.Upload(c, svc.UploadParams{
+		SupplierID: supplierID,
+		DocType:    docType,
+		DocNo:      formPtr(c, "doc_no"),
+		IssueDate:  issue,
+		ExpiryDate: expiry,
+		Remark:     formPtr(c, "remark"),
+		FileName:   fh.Filename,
+		File:       f,
+		UploadedBy: &act.ID,
+	})
+	if err != nil {
+		docError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *QualificationHandler) get(c *gin.Context) {
+	const errTitle = "获取资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		docError(c, errTitle, err)
+		return
+	}
+	withETag(c, out)
+}
+
+func (h *QualificationHandler) list(c *gin.Context) {
+	const errTitle = "获取资质文件列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	p := svc.ListParams{OrgID: orgID}
+	if v := strings.TrimSpace(c.Query("supplier_id")); v != "" {
+		p.SupplierID = &v
+	}
+	if v := strings.TrimSpace(c.Query("doc_type")); v != "" {
+		p.DocType = &v
+	}
+	if v := strings.TrimSpace(c.Query("verify_status")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			BadRequest(c, errTitle, "verify_status 非法")
+			return
+		}
+		p.VerifyStatus = &n
+	}
+	if v := strings.TrimSpace(c.Query("expire_before")); v != "" {
+		d, err := parseDate(v)
+		if err != nil {
+			BadRequest(c, errTitle, "expire_before 格式应为 YYYY-MM-DD")
+			return
+		}
+		p.ExpireBefore = &d
+	}
+	p.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	p.PageSize, _ = strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if p.PageSize < 0 {
+		p.PageSize = 20
+	}
+	list, total, err := h.s.List(c, p)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *QualificationHandler) download(c *gin.Context) {
+	const errTitle = "下载资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	d, f, err := h.s.Open(c, req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "记录不存在")
+			return
+		}
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	defer f.Close()
+	c.Header("Content-Disposition", `attachment; filename="`+d.ID+fileExt(d.FileName)+`"`)
+	c.DataFromReader(http.StatusOK, d.FileSize, d.ContentType, f, nil)
+}
+
+func (h *QualificationHandler) update(c *gin.Context) {
+	const errTitle = "修改资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可修改资质文件")
+		return
+	}
+
+	var req documentUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	issue, err := parseClearableDate(req.IssueDate)
+	if err != nil {
+		BadRequest(c, errTitle, "issue_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	expiry, err := parseClearableDate(req.ExpiryDate)
+	if err != nil {
+		BadRequest(c, errTitle, "expiry_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	if err := h.s.Update(c, svc.UpdateParams{
+		ID:              req.ID,
+		Version:         version,
+		DocNo:           req.DocNo,
+		IssueDate:       issue,
+		ExpiryDate:      expiry,
+		Remark:          req.Remark,
+		UpdateDocNo:     req.DocNo != nil,
+		UpdateIssueDate: req.IssueDate != nil,
+		UpdateExpiry:    req.ExpiryDate != nil,
+		UpdateRemark:    req.Remark != nil,
+	}); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
+		docError(c, errTitle, err)
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *QualificationHandler) verify(c *gin.Context) {
+	const errTitle = "审核资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可审核资质文件")
+		return
+	}
+
+	var req documentVerifyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Verify(c, req.ID, req.Approve, req.Remark, &act.ID)
+	if err != nil {
+		docError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *QualificationHandler) softDelete(c *gin.Context) {
+	const errTitle = "删除资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除资质文件")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.Delete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *QualificationHandler) compliance(c *gin.Context) {
+	const errTitle = "获取供应商资质状态失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req complianceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	at := time.Now()
+	if req.Date != nil && strings.TrimSpace(*req.Date) != "" {
+		d, err := parseDate(*req.Date)
+		if err != nil {
+			BadRequest(c, errTitle, "date 格式应为 YYYY-MM-DD")
+			return
+		}
+		at = d
+	}
+	warn := 30
+	if req.WarnDays != nil {
+		warn = *req.WarnDays
+	}
+	out, err := h.s.Compliance(c, req.SupplierID, at, warn)
+	if err != nil {
+		docError(c, errTitle, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"date": at.Format("2006-01-02"), "items": out})
+}
+
+func (h *QualificationHandler) export(c *gin.Context) {
+	const errTitle = "导出资质文件失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req documentExportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	now := time.Now()
+	var buf bytes.Buffer
+	if err := h.s.Export(c, req.OrgID, req.SupplierID, now, &buf); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="supplier_docs_`+now.Format("20060102")+`.zip"`)
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// parseClearableDate 可选日期：未提交或空串为 nil
+func parseClearableDate(p *string) (*time.Time, error) {
+	if p == nil || strings.TrimSpace(*p) == "" {
+		return nil, nil
+	}
+	return parseOptionalDate(p)
+}
+
+// formPtr 表单字段，未提交时为 nil
+func formPtr(c *gin.Context, key string) *string {
+	v, ok := c.GetPostForm(key)
+	if !ok {
+		return nil
+	}
+	return &v
+}
+
+func fileExt(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 && len(name)-i <= 8 {
+		return name[i:]
+	}
+	return ""
+}
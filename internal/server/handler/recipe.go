@@ -0,0 +1,396 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/recipe"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type RecipeHandler struct{ s *svc.Service }
+
+func NewRecipeHandler(s *svc.Service) *RecipeHandler { return &RecipeHandler{s: s} }
+
+func (h *RecipeHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/recipe")
+
+	g.POST("/create_recipe", middleware.Idempotent(), h.create)
+	g.POST("/get_recipe", h.get)
+	g.POST("/list_recipe", h.list)
+	g.POST("/update_recipe", h.update)
+	g.POST("/soft_delete_recipe", h.softDelete)
+
+	g.POST("/add_recipe_version", middleware.Idempotent(), h.addVersion) // 修改用料即新增版本
+	g.POST("/list_recipe_version", h.listVersions)                       // 版本列表
+	g.POST("/get_recipe_version", h.getVersion)                          // 版本详情（含用料）
+	g.POST("/set_recipe_version", h.setCurrentVersion)                   // 切换当前版本
+	g.POST("/recipe_cost", h.cost)                                       // 按最近询价均价计算成本
+	g.POST("/expand_meal_plan", h.expandMealPlan)                        // 餐次计划展开为商品需求
+}
+
+type recipeLineReq struct {
+	GoodsID       string           `json:"goods_id" binding:"required,uuid4"`
+	QtyPerServing decimal.Decimal  `json:"qty_per_serving"`
+	UnitID        string           `json:"unit_id" binding:"required,uuid4"`
+	YieldRate     *decimal.Decimal `json:"yield_rate"`
+	LossRate      *decimal.Decimal `json:"loss_rate"`
+	Sort          int              `json:"sort" binding:"gte=0"`
+}
+
+type recipeCreateReq struct {
+	OrgID         string          `json:"org_id" binding:"required,uuid4"`
+	Name          string          `json:"name" binding:"required,min=1,max=128"`
+	Remark        *string         `json:"remark" binding:"omitempty,max=255"`
+	VersionRemark *string         `json:"version_remark" binding:"omitempty,max=255"`
+	Lines         []recipeLineReq `json:"lines" binding:"required,min=1,dive"`
+}
+
+type recipeUpdateReq struct {
+	ID      string  `json:"id" binding:"required,uuid4"`
+	Name    *string `json:"name" binding:"omitempty,min=1,max=128"`
+	Remark  *string `json:"remark" binding:"omitempty,max=255"`
+	Version *int    `json:"version"` // 期望行版本号（即 recipe.Version），也可用 If-Match
+}
+
+type recipeVersionAddReq struct {
+	RecipeID string          `json:"recipe_id" binding:"required,uuid4"`
+	Remark   *string         `json:"remark" binding:"omitempty,max=255"`
+	Lines    []recipeLineReq `json:"lines" binding:"required,min=1,dive"`
+}
+
+type recipeVersionReq struct {
+	RecipeID string `json:"recipe_id" binding:"required,uuid4"`
+	Version  int    `json:"version" binding:"gte=0"` // 0 表示当前版本
+}
+
+type recipeCostReq struct {
+	RecipeID string  `json:"recipe_id" binding:"required,uuid4"`
+	Version  int     `json:"version" binding:"gte=0"`
+	Servings int     `json:"servings" binding:"gte=0"`
+	AsOf     *string `json:"as_of"` // YYYY-MM-DD，取该日及之前最近一次询价
+}
+
+type recipeExpandReq struct {
+	PlanID   *string `json:"plan_id" binding:"omitempty,uuid4"`
+	OrgID    *string `json:"org_id" binding:"omitempty,uuid4"`
+	MealID   *string `json:"meal_id" binding:"omitempty,uuid4"`
+	DateFrom *string `json:"date_from"`
+	DateTo   *string `json:"date_to"`
+}
+
+func toLineParams(in []recipeLineReq) []svc.LineParams {
+	out := make([]svc.LineParams, len(in))
+	for i, l := range in {
+		out[i] = svc.LineParams{
+			GoodsID:       l.GoodsID,
+			QtyPerServing: l.QtyPerServing,
+			UnitID:        l.UnitID,
+			YieldRate:     l.YieldRate,
+			LossRate:      l.LossRate,
+			Sort:          l.Sort,
+		}
+	}
+	return out
+}
+
+func (h *RecipeHandler) create(c *gin.Context) {
+	const errTitle = "创建配方失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可创建配方")
+		return
+	}
+
+	var req recipeCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	m, v, err := h.s.Create(c, svc.CreateParams{
+		OrgID:         req.OrgID,
+		Name:          req.Name,
+		Remark:        req.Remark,
+		VersionRemark: req.VersionRemark,
+		OperatorID:    &act.ID,
+		Lines:         toLineParams(req.Lines),
+	})
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"recipe": m, "version": v})
+}
+
+func (h *RecipeHandler) get(c *gin.Context) {
+	const errTitle = "获取配方失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	m, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "配方不存在: "+err.Error())
+		return
+	}
+	v, err := h.s.GetVersion(c, m.ID, 0)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	setETag(c, m.Version)
+	c.JSON(http.StatusOK, gin.H{"recipe": m, "version": v})
+}
+
+func (h *RecipeHandler) list(c *gin.Context) {
+	const errTitle = "获取配方列表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, orgID, c.Query("keyword"), page, ps)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *RecipeHandler) update(c *gin.Context) {
+	const errTitle = "更新配方失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可更新配方")
+		return
+	}
+
+	var req recipeUpdateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
+	if err := h.s.Update(c, svc.UpdateParams{ID: req.ID, Version: version, Name: req.Name, Remark: req.Remark}); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.Get(c, req.ID) }) {
+			return
+		}
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	setETag(c, version+1)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RecipeHandler) softDelete(c *gin.Context) {
+	const errTitle = "删除配方失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除配方")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SoftDelete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *RecipeHandler) addVersion(c *gin.Context) {
+	const errTitle = "新增配方版本失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可修改配方")
+		return
+	}
+
+	var req recipeVersionAddReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	v, err := h.s.AddVersion(c, req.RecipeID, req.Remark, &act.ID, toLineParams(req.Lines))
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, v)
+}
+
+func (h *RecipeHandler) listVersions(c *gin.Context) {
+	const errTitle = "获取配方版本失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	recipeID := strings.TrimSpace(c.Query("recipe_id"))
+	if recipeID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 recipe_id")
+		return
+	}
+	list, err := h.s.ListVersions(c, recipeID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *RecipeHandler) getVersion(c *gin.Context) {
+	const errTitle = "获取配方版本失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req recipeVersionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	v, err := h.s.GetVersion(c, req.RecipeID, req.Version)
+	if err != nil {
+		NotFoundError(c, errTitle, "配方版本不存在: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, v)
+}
+
+func (h *RecipeHandler) setCurrentVersion(c *gin.Context) {
+	const errTitle = "切换配方版本失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可切换配方版本")
+		return
+	}
+
+	var req recipeVersionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SetCurrentVersion(c, req.RecipeID, req.Version); err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RecipeHandler) cost(c *gin.Context) {
+	const errTitle = "计算配方成本失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req recipeCostReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	asOf, err := parseOptionalDate(req.AsOf)
+	if err != nil {
+		BadRequest(c, errTitle, "as_of 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.Cost(c, req.RecipeID, req.Version, req.Servings, asOf)
+	if err != nil {
+		NotFoundError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// expandMealPlan 传 plan_id 展开单个计划，或传 org_id + date_from/date_to（可选 meal_id）展开区间
+func (h *RecipeHandler) expandMealPlan(c *gin.Context) {
+	const errTitle = "展开餐次计划失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req recipeExpandReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+
+	var (
+		items    any
+		warnings []string
+		err      error
+	)
+	switch {
+	case req.PlanID != nil:
+		items, warnings, err = h.s.ExpandPlan(c, *req.PlanID)
+	case req.OrgID != nil && req.DateFrom != nil && req.DateTo != nil:
+		from, e1 := parseDate(*req.DateFrom)
+		to, e2 := parseDate(*req.DateTo)
+		if e1 != nil || e2 != nil {
+			BadRequest(c, errTitle, "date_from/date_to 格式应为 YYYY-MM-DD")
+			return
+		}
+		items, warnings, err = h.s.ExpandPlans(c, *req.OrgID, req.MealID, from, to)
+	default:
+		BadRequest(c, errTitle, "需提供 plan_id，或 org_id + date_from + date_to")
+		return
+	}
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": items, "warnings": warnings})
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/report"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/report"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type ReportHandler struct{ s *svc.Service }
+
+func NewReportHandler(s *svc.Service) *ReportHandler { return &ReportHandler{s: s} }
+
+func (h *ReportHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/report")
+
+	g.POST("/inquiry_pdf", h.inquiryPDF)       // 询价单打印件（PDF）
+	g.POST("/settlement_pdf", h.settlementPDF) // 供应商结算对账单（PDF）
+	g.POST("/get_print_layout", h.getLayout)   // 机构打印版式（未配置返回默认）
+	g.POST("/set_print_layout", h.setLayout)   // 设置机构打印版式
+}
+
+type settlementPDFReq struct {
+	OrgID      string `json:"org_id" binding:"required,uuid4"`
+	SupplierID string `json:"supplier_id" binding:"required,uuid4"`
+	DateFrom   string `json:"date_from" binding:"required"` // YYYY-MM-DD
+	DateTo     string `json:"date_to" binding:"required"`   // YYYY-MM-DD
+}
+
+type printLayoutGetReq struct {
+	OrgID string `json:"org_id" binding:"required,uuid4"`
+	Kind  string `json:"kind" binding:"required,oneof=inquiry settlement"`
+}
+
+type printLayoutReq struct {
+	OrgID          string   `json:"org_id" binding:"required,uuid4"`
+	Kind           string   `json:"kind" binding:"required,oneof=inquiry settlement"`
+	Title          *string  `json:"title" binding:"omitempty,max=64"`
+	Subtitle       *string  `json:"subtitle" binding:"omitempty,max=128"`
+	Signers        []string `json:"signers" binding:"omitempty,max=6,dive,max=16"`
+	PageSize       string   `json:"page_size" binding:"omitempty,oneof=A4 A5"`
+	Orientation    string   `json:"orientation" binding:"omitempty,oneof=P L"`
+	FontSize       int      `json:"font_size" binding:"omitempty,min=6,max=16"`
+	ShowGuidePrice bool     `json:"show_guide_price"`
+	Footer         *string  `json:"footer" binding:"omitempty,max=255"`
+}
+
+// sendPDF 以附件形式返回 PDF
+func sendPDF(c *gin.Context, filename string, data []byte) {
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// pdfError 生成 PDF 的错误映射：记录不存在 404，未配置字体 500，其余 400
+func pdfError(c *gin.Context, errTitle string, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		NotFoundError(c, errTitle, "记录不存在")
+	case errors.Is(err, domain.ErrNoFont):
+		InternalError(c, errTitle, err.Error())
+	default:
+		BadRequest(c, errTitle, err.Error())
+	}
+}
+
+func (h *ReportHandler) inquiryPDF(c *gin.Context) {
+	const errTitle = "生成询价单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	data, inq, err := h.s.InquiryPDF(c, req.ID)
+	if err != nil {
+		pdfError(c, errTitle, err)
+		return
+	}
+	sendPDF(c, "inquiry_"+inq.InquiryDate.Format("20060102")+".pdf", data)
+}
+
+func (h *ReportHandler) settlementPDF(c *gin.Context) {
+	const errTitle = "生成结算对账单失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req settlementPDFReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, err := parseDate(req.DateFrom)
+	if err != nil {
+		BadRequest(c, errTitle, "date_from 格式应为 YYYY-MM-DD")
+		return
+	}
+	to, err := parseDate(req.DateTo)
+	if err != nil {
+		BadRequest(c, errTitle, "date_to 格式应为 YYYY-MM-DD")
+		return
+	}
+	data, err := h.s.SettlementPDF(c, svc.SettlementParams{
+		OrgID:      req.OrgID,
+		SupplierID: req.SupplierID,
+		DateFrom:   from,
+		DateTo:     to,
+	})
+	if err != nil {
+		pdfError(c, errTitle, err)
+		return
+	}
+	sendPDF(c, "settlement_"+from.Format("20060102")+"_"+to.Format("20060102")+".pdf", data)
+}
+
+func (h *ReportHandler) getLayout(c *gin.Context) {
+	const errTitle = "获取打印版式失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req printLayoutGetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.GetLayout(c, req.OrgID, req.Kind)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *ReportHandler) setLayout(c *gin.Context) {
+	const errTitle = "设置打印版式失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可设置打印版式")
+		return
+	}
+
+	var req printLayoutReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.SaveLayout(c, svc.LayoutParams{
+		OrgID:          req.OrgID,
+		Kind:           req.Kind,
+		Title:          req.Title,
+		Subtitle:       req.Subtitle,
+		Signers:        req.Signers,
+		PageSize:       req.PageSize,
+		Orientation:    req.Orientation,
+		FontSize:       req.FontSize,
+		ShowGuidePrice: req.ShowGuidePrice,
+		Footer:         req.Footer,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
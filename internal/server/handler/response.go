@@ -12,6 +12,7 @@ import (
 type ErrorResponse struct {
 	Error   string      `json:"error"`
 	Details interface{} `json:"details,omitempty"`
+	Current interface{} `json:"current,omitempty"` // 乐观锁冲突时返回当前行
 }
 
 // badRequest 返回 400 错误
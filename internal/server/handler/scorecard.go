@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/scorecard"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/scorecard"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type ScorecardHandler struct{ s *svc.Service }
+
+func NewScorecardHandler(s *svc.Service) *ScorecardHandler { return &ScorecardHandler{s: s} }
+
+func (h *ScorecardHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/supplier_score")
+
+	g.POST("/create_rating", middleware.Idempotent(), h.createRating) // 登记人工评分/投诉
+	g.POST("/list_rating", h.listRatings)                             // 评价列表（query）
+	g.POST("/soft_delete_rating", h.deleteRating)                     // 软删评价
+	g.POST("/compute_score", h.compute)                               // 计算期间评分并保存快照
+	g.POST("/list_ranking", h.ranking)                                // 期间排名（读取快照）
+	g.POST("/list_score_period", h.periods)                           // 已生成快照的期间
+	g.POST("/list_supplier_score", h.supplierHistory)                 // 供应商历次评分
+}
+
+type ratingCreateReq struct {
+	SupplierID string  `json:"supplier_id" binding:"required,uuid4"`
+	Kind       string  `json:"kind" binding:"required,oneof=rating complaint"`
+	Score      *int    `json:"score" binding:"omitempty,min=1,max=5"`
+	RatedOn    *string `json:"rated_on"` // YYYY-MM-DD，默认今天
+	Content    *string `json:"content" binding:"omitempty,max=512"`
+}
+
+type scoreComputeReq struct {
+	OrgID    string `json:"org_id" binding:"required,uuid4"`
+	DateFrom string `json:"date_from" binding:"required"` // YYYY-MM-DD
+	DateTo   string `json:"date_to" binding:"required"`   // YYYY-MM-DD
+	Replace  bool   `json:"replace"`                      // 已有快照时重算覆盖
+}
+
+type supplierScoreReq struct {
+	SupplierID string `json:"supplier_id" binding:"required,uuid4"`
+}
+
+func (h *ScorecardHandler) createRating(c *gin.Context) {
+	const errTitle = "登记供应商评价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req ratingCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	ratedOn, err := parseOptionalDate(req.RatedOn)
+	if err != nil {
+		BadRequest(c, errTitle, "rated_on 格式应为 YYYY-MM-DD")
+		return
+	}
+	p := svc.RatingParams{
+		SupplierID: req.SupplierID,
+		Kind:       req.Kind,
+		Score:      req.Score,
+		Content:    req.Content,
+		OperatorID: &act.ID,
+	}
+	if ratedOn != nil {
+		p.RatedOn = *ratedOn
+	}
+	out, err := h.s.CreateRating(c, p)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *ScorecardHandler) listRatings(c *gin.Context) {
+	const errTitle = "获取供应商评价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	supplierID, kind := c.Query("supplier_id"), c.Query("kind")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.ListRatings(c, svc.RatingListParams{
+		OrgID:      orgID,
+		SupplierID: &supplierID,
+		Kind:       &kind,
+		DateFrom:   from,
+		DateTo:     to,
+		Page:       page,
+		PageSize:   ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *ScorecardHandler) deleteRating(c *gin.Context) {
+	const errTitle = "删除供应商评价失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除评价")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.DeleteRating(c, req.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "评价不存在")
+			return
+		}
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ScorecardHandler) compute(c *gin.Context) {
+	const errTitle = "计算供应商评分失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可计算评分")
+		return
+	}
+
+	var req scoreComputeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, to, ok := bindPeriod(c, errTitle, req.DateFrom, req.DateTo)
+	if !ok {
+		return
+	}
+	list, err := h.s.Compute(c, svc.ComputeParams{
+		OrgID:      req.OrgID,
+		From:       from,
+		To:         to,
+		Replace:    req.Replace,
+		OperatorID: &act.ID,
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrSnapshotExists) {
+			ConflictError(c, errTitle, err.Error()+"，如需重算请指定 replace")
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *ScorecardHandler) ranking(c *gin.Context) {
+	const errTitle = "获取供应商排名失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, ok := bindPeriod(c, errTitle, c.Query("date_from"), c.Query("date_to"))
+	if !ok {
+		return
+	}
+	list, err := h.s.Ranking(c, orgID, from, to)
+	if err != nil {
+		if errors.Is(err, svc.ErrNoSnapshot) {
+			NotFoundError(c, errTitle, err.Error())
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *ScorecardHandler) periods(c *gin.Context) {
+	const errTitle = "获取评分期间失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	list, err := h.s.Periods(c, orgID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+func (h *ScorecardHandler) supplierHistory(c *gin.Context) {
+	const errTitle = "获取供应商评分失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req supplierScoreReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.SupplierHistory(c, req.SupplierID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
+
+// bindPeriod 解析必填的评分期间（YYYY-MM-DD），失败时写入 400
+func bindPeriod(c *gin.Context, errTitle, fromRaw, toRaw string) (time.Time, time.Time, bool) {
+	from, err := parseDate(fromRaw)
+	if err != nil {
+		BadRequest(c, errTitle, "date_from 格式应为 YYYY-MM-DD")
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseDate(toRaw)
+	if err != nil {
+		BadRequest(c, errTitle, "date_to 格式应为 YYYY-MM-DD")
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"hdzk.cn/foodapp/configs"
 	middleware "hdzk.cn/foodapp/internal/server/middleware"
 	svc "hdzk.cn/foodapp/internal/service/supplier"
 	types "hdzk.cn/foodapp/internal/transport"
@@ -19,12 +23,34 @@ func NewSupplierHandler(s *svc.Service) *SupplierHandler { return &SupplierHandl
 func (h *SupplierHandler) Register(rg *gin.RouterGroup) {
 	g := rg.Group("/supplier")
 
-	g.POST("/create_supplier", h.create)
+	g.POST("/create_supplier", middleware.Idempotent(), h.create)
 	g.POST("/get_supplier", h.get)
 	g.POST("/list_supplier", h.list)
 	g.POST("/update_supplier", h.update)
 	g.POST("/soft_delete_supplier", h.softDelete)
 	g.POST("/hard_delete_supplier", h.hardDelete)
+	g.POST("/run_contract_schedule", h.runSchedule) // 立即执行合同期启停与到期提醒
+
+	g.POST("/schedule_float_ratio", middleware.Idempotent(), h.scheduleRatio) // 设置自某日起生效的浮动比例（可为将来日期）
+	g.POST("/list_float_ratio", h.listRatios)                                 // 浮动比例历史（含计划调整）
+	g.POST("/get_float_ratio", h.getRatio)                                    // 指定日期生效的浮动比例
+	g.POST("/cancel_float_ratio", h.cancelRatio)                              // 撤销尚未生效的计划调整
+}
+
+type floatRatioReq struct {
+	SupplierID    string          `json:"supplier_id" binding:"required,uuid4"`
+	FloatRatio    decimal.Decimal `json:"float_ratio" binding:"required"`
+	EffectiveFrom string          `json:"effective_from" binding:"required"` // YYYY-MM-DD
+	Remark        *string         `json:"remark" binding:"omitempty,max=255"`
+}
+
+type floatRatioOnReq struct {
+	SupplierID string `json:"supplier_id" binding:"required,uuid4"`
+	Date       string `json:"date" binding:"required"` // YYYY-MM-DD
+}
+
+type contractScheduleReq struct {
+	NoticeDays []int `json:"notice_days" binding:"omitempty,max=10,dive,min=1,max=366"` // 为空取默认提醒档
 }
 
 type supplierCreateReq struct {
@@ -58,6 +84,7 @@ type supplierUpdateReq struct {
 	ContactAddress *string  `json:"contact_address" binding:"omitempty,max=255"`
 	StartTime      *string  `json:"start_time"`
 	EndTime        *string  `json:"end_time"`
+	Version        *int     `json:"version"` // 期望版本号，也可用 If-Match
 }
 
 func (h *SupplierHandler) create(c *gin.Context) {
@@ -132,7 +159,7 @@ func (h *SupplierHandler) get(c *gin.Context) {
 		NotFoundError(c, errTitle, "供应商不存在: "+err.Error())
 		return
 	}
-	c.JSON(http.StatusOK, supplier)
+	withETag(c, supplier)
 }
 
 func (h *SupplierHandler) list(c *gin.Context) {
@@ -211,6 +238,10 @@ func (h *SupplierHandler) update(c *gin.Context) {
 		BadRequest(c, errTitle, "输入格式非法")
 		return
 	}
+	version, ok := bindVersion(c, errTitle, req.Version)
+	if !ok {
+		return
+	}
 
 	startTime, updateStart, err := parseOptionalTimeWithFlag(req.StartTime)
 	if err != nil {
@@ -225,6 +256,7 @@ func (h *SupplierHandler) update(c *gin.Context) {
 
 	params := svc.UpdateParams{
 		ID:              req.ID,
+		Version:         version,
 		Name:            req.Name,
 		Code:            req.Code,
 		Pinyin:          req.Pinyin,
@@ -243,9 +275,13 @@ func (h *SupplierHandler) update(c *gin.Context) {
 		UpdateEndTime:   updateEnd,
 	}
 	if err := h.s.UpdateSupplier(c, params); err != nil {
+		if versionError(c, errTitle, err, func() (any, error) { return h.s.GetSupplier(c, req.ID) }) {
+			return
+		}
 		ConflictError(c, errTitle, "更新供应商失败: "+err.Error())
 		return
 	}
+	setETag(c, version+1)
 	c.Status(http.StatusNoContent)
 }
 
@@ -299,6 +335,154 @@ func (h *SupplierHandler) hardDelete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+func (h *SupplierHandler) runSchedule(c *gin.Context) {
+	const errTitle = "执行合同期调度失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可执行合同期调度")
+		return
+	}
+
+	var req contractScheduleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	days := req.NoticeDays
+	if len(days) == 0 {
+		days = configs.DefaultSchedulerConfig.ExpiryNoticeDays
+	}
+	out, err := h.s.RunContractSchedule(c, time.Now(), days)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *SupplierHandler) scheduleRatio(c *gin.Context) {
+	const errTitle = "设置浮动比例失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可调整浮动比例")
+		return
+	}
+
+	var req floatRatioReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, err := parseDate(req.EffectiveFrom)
+	if err != nil {
+		BadRequest(c, errTitle, "effective_from 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.ScheduleFloatRatio(c, svc.FloatRatioParams{
+		SupplierID:    req.SupplierID,
+		FloatRatio:    req.FloatRatio,
+		EffectiveFrom: from,
+		Remark:        req.Remark,
+		OperatorID:    &act.ID,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "供应商不存在")
+			return
+		}
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *SupplierHandler) listRatios(c *gin.Context) {
+	const errTitle = "获取浮动比例历史失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	list, err := h.s.ListFloatRatios(c, req.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "供应商不存在")
+			return
+		}
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"items": list})
+}
+
+func (h *SupplierHandler) getRatio(c *gin.Context) {
+	const errTitle = "获取浮动比例失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req floatRatioOnReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	at, err := parseDate(req.Date)
+	if err != nil {
+		BadRequest(c, errTitle, "date 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.FloatRatioOn(c, req.SupplierID, at)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			NotFoundError(c, errTitle, "供应商不存在")
+			return
+		}
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *SupplierHandler) cancelRatio(c *gin.Context) {
+	const errTitle = "撤销浮动比例调整失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可调整浮动比例")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.CancelFloatRatio(c, req.ID); err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
 func parseOptionalTime(raw *string) (*time.Time, error) {
 	if raw == nil {
 		return nil, nil
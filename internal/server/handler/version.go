@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// bindVersion 取更新请求的期望版本号：请求体 version 优先，其次 If-Match 头（ETag 值）。
+// 两者都缺失时返回 428，两者不一致时返回 400
+func bindVersion(c *gin.Context, errTitle string, body *int) (int, bool) {
+	header, hasHeader, err := ifMatch(c)
+	if err != nil {
+		BadRequest(c, errTitle, "If-Match 非法："+err.Error())
+		return 0, false
+	}
+	switch {
+	case body != nil && hasHeader && *body != header:
+		BadRequest(c, errTitle, "version 与 If-Match 不一致")
+		return 0, false
+	case body != nil:
+		return *body, true
+	case hasHeader:
+		return header, true
+	}
+	c.JSON(http.StatusPreconditionRequired, ErrorResponse{Error: errTitle, Details: "缺少版本号：请在请求体传 version 或使用 If-Match 头"})
+	return 0, false
+}
+
+// ifMatch 解析 If-Match 头，兼容 "3"、W/"3" 与 3；"*" 视为未提供
+func ifMatch(c *gin.Context) (int, bool, error) {
+	raw := strings.TrimSpace(c.GetHeader("If-Match"))
+	if raw == "" || raw == "*" {
+		return 0, false, nil
+	}
+	raw = strings.Trim(strings.TrimPrefix(raw, "W/"), `"`)
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false, errors.New("应为版本号 ETag")
+	}
+	return v, true, nil
+}
+
+// setETag 以版本号作为 ETag
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// withETag 返回带版本号的行并设置 ETag
+func withETag(c *gin.Context, row any) {
+	if v, ok := versionOf(row); ok {
+		setETag(c, v)
+	}
+	c.JSON(http.StatusOK, row)
+}
+
+// versionError 处理条件更新的共性错误：版本冲突返回 409 及当前行（并设置其 ETag），
+// 目标行不存在返回 404；返回是否已处理
+func versionError(c *gin.Context, errTitle string, err error, load func() (any, error)) bool {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		NotFoundError(c, errTitle, "记录不存在或已删除")
+		return true
+	}
+	if !errors.Is(err, utils.ErrVersionConflict) {
+		return false
+	}
+	resp := ErrorResponse{Error: errTitle, Details: err.Error()}
+	if cur, lerr := load(); lerr == nil {
+		resp.Current = cur
+		if v, ok := versionOf(cur); ok {
+			setETag(c, v)
+		}
+	}
+	c.JSON(http.StatusConflict, resp)
+	return true
+}
+
+// versionOf 读取结构体（或其指针）的 Version 字段
+func versionOf(row any) (int, bool) {
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+	f := v.FieldByName("Version")
+	if !f.IsValid() || f.Kind() != reflect.Int {
+		return 0, false
+	}
+	return int(f.Int()), true
+}
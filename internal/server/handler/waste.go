@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/waste"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type WasteHandler struct{ s *svc.Service }
+
+func NewWasteHandler(s *svc.Service) *WasteHandler { return &WasteHandler{s: s} }
+
+func (h *WasteHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/waste")
+
+	g.POST("/create_waste", middleware.Idempotent(), h.create) // 登记浪费（可关联称重记录）
+	g.POST("/get_waste", h.get)                                // 按 id 获取
+	g.POST("/list_waste", h.list)                              // 列表
+	g.POST("/soft_delete_waste", h.softDelete)                 // 软删
+	g.POST("/waste_report", h.report)                          // 按机构/品类/餐次/周期汇总
+}
+
+type wasteCreateReq struct {
+	OrgID         string          `json:"org_id" binding:"required,uuid4"`
+	GoodsID       *string         `json:"goods_id" binding:"omitempty,uuid4"` // 关联称重记录时可空
+	ReasonID      string          `json:"reason_id" binding:"required,uuid4"`
+	Weight        decimal.Decimal `json:"weight"`
+	UnitID        *string         `json:"unit_id" binding:"omitempty,uuid4"`
+	WastedAt      *string         `json:"wasted_at"` // YYYY-MM-DD HH:MM:SS
+	MealID        *string         `json:"meal_id" binding:"omitempty,uuid4"`
+	MealDate      *string         `json:"meal_date"` // YYYY-MM-DD
+	WeighingID    *string         `json:"weighing_id" binding:"omitempty,uuid4"`
+	PostInventory bool            `json:"post_inventory"`
+	Remark        *string         `json:"remark" binding:"omitempty,max=255"`
+}
+
+type wasteReportReq struct {
+	OrgID          string   `json:"org_id" binding:"required,uuid4"`
+	IncludeSubOrgs bool     `json:"include_sub_orgs"`
+	DateFrom       string   `json:"date_from" binding:"required"`
+	DateTo         string   `json:"date_to" binding:"required"`
+	GroupBy        []string `json:"group_by"`                                        // org/category/meal/reason/goods/period
+	Period         string   `json:"period" binding:"omitempty,oneof=day week month"` // 默认 month
+}
+
+func (h *WasteHandler) create(c *gin.Context) {
+	const errTitle = "登记浪费失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req wasteCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	params := svc.CreateParams{
+		OrgID:         req.OrgID,
+		ReasonID:      req.ReasonID,
+		Weight:        req.Weight,
+		MealID:        req.MealID,
+		WeighingID:    req.WeighingID,
+		PostInventory: req.PostInventory,
+		OperatorID:    &act.ID,
+		Remark:        req.Remark,
+	}
+	if req.GoodsID != nil {
+		params.GoodsID = *req.GoodsID
+	}
+	if req.UnitID != nil {
+		params.UnitID = *req.UnitID
+	}
+	at, err := parseOptionalDateTime(req.WastedAt)
+	if err != nil {
+		BadRequest(c, errTitle, "wasted_at 格式应为 YYYY-MM-DD HH:MM:SS")
+		return
+	}
+	if at != nil {
+		params.WastedAt = *at
+	}
+	if params.MealDate, err = parseOptionalDate(req.MealDate); err != nil {
+		BadRequest(c, errTitle, "meal_date 格式应为 YYYY-MM-DD")
+		return
+	}
+
+	out, err := h.s.Create(c, params)
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *WasteHandler) get(c *gin.Context) {
+	const errTitle = "获取浪费记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "浪费记录不存在: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *WasteHandler) list(c *gin.Context) {
+	const errTitle = "获取浪费记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	goodsID, reasonID, mealID := c.Query("goods_id"), c.Query("reason_id"), c.Query("meal_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, svc.ListParams{
+		OrgID:    orgID,
+		GoodsID:  &goodsID,
+		ReasonID: &reasonID,
+		MealID:   &mealID,
+		DateFrom: from,
+		DateTo:   to,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *WasteHandler) softDelete(c *gin.Context) {
+	const errTitle = "删除浪费记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除浪费记录")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SoftDelete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WasteHandler) report(c *gin.Context) {
+	const errTitle = "获取浪费报表失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req wasteReportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, err := parseDate(req.DateFrom)
+	if err != nil {
+		BadRequest(c, errTitle, "date_from 格式应为 YYYY-MM-DD")
+		return
+	}
+	to, err := parseDate(req.DateTo)
+	if err != nil {
+		BadRequest(c, errTitle, "date_to 格式应为 YYYY-MM-DD")
+		return
+	}
+	out, err := h.s.Report(c, svc.ReportParams{
+		OrgID:          req.OrgID,
+		IncludeSubOrgs: req.IncludeSubOrgs,
+		DateFrom:       from,
+		DateTo:         to,
+		GroupBy:        req.GroupBy,
+		Period:         req.Period,
+	})
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
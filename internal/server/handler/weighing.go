@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	middleware "hdzk.cn/foodapp/internal/server/middleware"
+	svc "hdzk.cn/foodapp/internal/service/weighing"
+	types "hdzk.cn/foodapp/internal/transport"
+)
+
+type WeighingHandler struct{ s *svc.Service }
+
+func NewWeighingHandler(s *svc.Service) *WeighingHandler { return &WeighingHandler{s: s} }
+
+func (h *WeighingHandler) Register(rg *gin.RouterGroup) {
+	g := rg.Group("/weighing")
+
+	g.POST("/create_weighing", middleware.Idempotent(), h.create) // 上报称重（自动归属餐次）
+	g.POST("/get_weighing", h.get)                                // 按 id 获取
+	g.POST("/list_weighing", h.list)                              // 列表
+	g.POST("/soft_delete_weighing", h.softDelete)                 // 软删
+	g.POST("/set_weighing_meal", h.setMeal)                       // 人工指定/取消指定餐次
+	g.POST("/reattribute_weighing", h.reattribute)                // 按当前供餐时段重新归属
+	g.POST("/meal_consumption", h.mealConsumption)                // 按餐次消耗统计
+}
+
+type weighingCreateReq struct {
+	OrgID     string          `json:"org_id" binding:"required,uuid4"`
+	ScaleID   *string         `json:"scale_id" binding:"omitempty,uuid4"`
+	GoodsID   string          `json:"goods_id" binding:"required,uuid4"`
+	Weight    decimal.Decimal `json:"weight"`
+	UnitID    string          `json:"unit_id" binding:"required,uuid4"`
+	WeighedAt *string         `json:"weighed_at"` // YYYY-MM-DD HH:MM:SS，空为当前时间
+	MealID    *string         `json:"meal_id" binding:"omitempty,uuid4"`
+	MealDate  *string         `json:"meal_date"` // YYYY-MM-DD
+	Remark    *string         `json:"remark" binding:"omitempty,max=255"`
+}
+
+type weighingSetMealReq struct {
+	ID       string  `json:"id" binding:"required,uuid4"`
+	MealID   *string `json:"meal_id" binding:"omitempty,uuid4"` // 为空表示恢复自动归属
+	MealDate *string `json:"meal_date"`
+}
+
+type weighingReattributeReq struct {
+	OrgID    string `json:"org_id" binding:"required,uuid4"`
+	DateFrom string `json:"date_from" binding:"required"`
+	DateTo   string `json:"date_to" binding:"required"`
+}
+
+func (h *WeighingHandler) create(c *gin.Context) {
+	const errTitle = "上报称重失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req weighingCreateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	params := svc.CreateParams{
+		OrgID:      req.OrgID,
+		ScaleID:    req.ScaleID,
+		GoodsID:    req.GoodsID,
+		Weight:     req.Weight,
+		UnitID:     req.UnitID,
+		MealID:     req.MealID,
+		OperatorID: &act.ID,
+		Remark:     req.Remark,
+	}
+	at, err := parseOptionalDateTime(req.WeighedAt)
+	if err != nil {
+		BadRequest(c, errTitle, "weighed_at 格式应为 YYYY-MM-DD HH:MM:SS")
+		return
+	}
+	if at != nil {
+		params.WeighedAt = *at
+	}
+	if params.MealDate, err = parseOptionalDate(req.MealDate); err != nil {
+		BadRequest(c, errTitle, "meal_date 格式应为 YYYY-MM-DD")
+		return
+	}
+
+	out, err := h.s.Create(c, params)
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+func (h *WeighingHandler) get(c *gin.Context) {
+	const errTitle = "获取称重记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	out, err := h.s.Get(c, req.ID)
+	if err != nil {
+		NotFoundError(c, errTitle, "称重记录不存在: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+func (h *WeighingHandler) list(c *gin.Context) {
+	const errTitle = "获取称重记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	goodsID := c.Query("goods_id")
+	mealID := c.Query("meal_id")
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	ps, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	list, total, err := h.s.List(c, svc.ListParams{
+		OrgID:    orgID,
+		GoodsID:  &goodsID,
+		MealID:   &mealID,
+		DateFrom: from,
+		DateTo:   to,
+		Page:     page,
+		PageSize: ps,
+	})
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": list})
+}
+
+func (h *WeighingHandler) softDelete(c *gin.Context) {
+	const errTitle = "删除称重记录失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可删除称重记录")
+		return
+	}
+
+	var req types.IDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	if err := h.s.SoftDelete(c, req.ID); err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true})
+}
+
+func (h *WeighingHandler) setMeal(c *gin.Context) {
+	const errTitle = "设置称重餐次失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可调整称重餐次")
+		return
+	}
+
+	var req weighingSetMealReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	mealDate, err := parseOptionalDate(req.MealDate)
+	if err != nil {
+		BadRequest(c, errTitle, "meal_date 格式应为 YYYY-MM-DD")
+		return
+	}
+	if err := h.s.SetMeal(c, req.ID, req.MealID, mealDate); err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WeighingHandler) reattribute(c *gin.Context) {
+	const errTitle = "重新归属餐次失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+	if act.Role != middleware.RoleAdmin {
+		ForbiddenError(c, errTitle, "仅管理员可重新归属餐次")
+		return
+	}
+
+	var req weighingReattributeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		BadRequest(c, errTitle, "输入格式非法")
+		return
+	}
+	from, err := parseDate(req.DateFrom)
+	if err != nil {
+		BadRequest(c, errTitle, "date_from 格式应为 YYYY-MM-DD")
+		return
+	}
+	to, err := parseDate(req.DateTo)
+	if err != nil {
+		BadRequest(c, errTitle, "date_to 格式应为 YYYY-MM-DD")
+		return
+	}
+	n, err := h.s.Reattribute(c, req.OrgID, from, to)
+	if err != nil {
+		ConflictError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"changed": n})
+}
+
+func (h *WeighingHandler) mealConsumption(c *gin.Context) {
+	const errTitle = "获取餐次消耗统计失败"
+	act := middleware.GetActor(c)
+	if act.Deleted != middleware.DeletedNo {
+		ForbiddenError(c, errTitle, "账户已删除，禁止操作")
+		return
+	}
+
+	orgID := strings.TrimSpace(c.Query("org_id"))
+	if orgID == "" {
+		BadRequest(c, errTitle, "参数错误：缺少 org_id")
+		return
+	}
+	from, to, err := queryDateRange(c)
+	if err != nil {
+		BadRequest(c, errTitle, err.Error())
+		return
+	}
+	if from == nil || to == nil {
+		BadRequest(c, errTitle, "参数错误：缺少 date_from/date_to")
+		return
+	}
+	mealID := c.Query("meal_id")
+	list, err := h.s.MealConsumption(c, orgID, *from, *to, &mealID)
+	if err != nil {
+		InternalError(c, errTitle, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": len(list), "items": list})
+}
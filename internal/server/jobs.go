@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"hdzk.cn/foodapp/configs"
+	idemrepo "hdzk.cn/foodapp/internal/repository/idempotency"
+	notificationrepo "hdzk.cn/foodapp/internal/repository/notification"
+	qualificationrepo "hdzk.cn/foodapp/internal/repository/qualification"
+	scorecardrepo "hdzk.cn/foodapp/internal/repository/scorecard"
+	supplierrepo "hdzk.cn/foodapp/internal/repository/supplier"
+	notificationsvc "hdzk.cn/foodapp/internal/service/notification"
+	qualificationsvc "hdzk.cn/foodapp/internal/service/qualification"
+	scorecardsvc "hdzk.cn/foodapp/internal/service/scorecard"
+	suppliersvc "hdzk.cn/foodapp/internal/service/supplier"
+	"hdzk.cn/foodapp/pkg/logger"
+)
+
+// StartJobs 启动后台定时任务（供应商合同期启停、到期提醒、计划浮动比例生效、资质到期提醒、上月评分快照与过期幂等键清理），ctx 取消后退出。
+// 启动时立即执行一次，之后按 IntervalMinute 周期执行
+func StartJobs(ctx context.Context, gdb *gorm.DB, cfg configs.SchedulerConfig, storageCfg configs.StorageConfig) {
+	if cfg.IntervalMinute <= 0 {
+		logger.L().Info("scheduler disabled")
+		return
+	}
+	notifySvc := notificationsvc.NewService(notificationrepo.NewRepository(gdb))
+	supplierSvc := suppliersvc.NewService(supplierrepo.NewRepository(gdb), notifySvc)
+	qualificationSvc := qualificationsvc.NewService(
+		qualificationrepo.NewRepository(gdb),
+		supplierrepo.NewRepository(gdb),
+		notifySvc,
+		storageCfg.Dir,
+		storageCfg.MaxUploadMB,
+	)
+	scorecardSvc := scorecardsvc.NewService(scorecardrepo.NewRepository(gdb), supplierrepo.NewRepository(gdb))
+	idemStore := idemrepo.NewStore(gdb)
+	run := func() {
+		res, err := supplierSvc.RunContractSchedule(ctx, time.Now(), cfg.ExpiryNoticeDays)
+		if err != nil {
+			logger.L().Warn("supplier contract schedule failed", zap.Error(err))
+		} else if len(res.Activated)+len(res.Deactivated)+res.Notified > 0 || res.RatioSynced > 0 {
+			logger.L().Info("supplier contract schedule done",
+				zap.Strings("activated", res.Activated),
+				zap.Strings("deactivated", res.Deactivated),
+				zap.Int("notified", res.Notified),
+				zap.Int64("ratio_synced", res.RatioSynced),
+			)
+		}
+
+		sent, err := qualificationSvc.RunExpirySchedule(ctx, time.Now(), cfg.ExpiryNoticeDays)
+		if err != nil {
+			logger.L().Warn("supplier document expiry schedule failed", zap.Error(err))
+		} else if sent > 0 {
+			logger.L().Info("supplier document expiry notices sent", zap.Int("notified", sent))
+		}
+
+		orgs, err := scorecardSvc.RunMonthlySnapshot(ctx, time.Now())
+		if err != nil {
+			logger.L().Warn("supplier score snapshot failed", zap.Error(err))
+		}
+		if orgs > 0 {
+			logger.L().Info("supplier score snapshots generated", zap.Int("orgs", orgs))
+		}
+
+		purged, err := idemStore.PurgeExpired(ctx, time.Now())
+		if err != nil {
+			logger.L().Warn("purge idempotency keys failed", zap.Error(err))
+		} else if purged > 0 {
+			logger.L().Info("expired idempotency keys purged", zap.Int64("rows", purged))
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(cfg.IntervalMinute) * time.Minute)
+		defer ticker.Stop()
+		run()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+}
@@ -51,16 +51,16 @@ func RequireAuth(secret string, lookup AccountLookup) gin.HandlerFunc {
 		}
 		tokenString := strings.TrimSpace(ah[len("Bearer "):])
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrTokenSignatureInvalid
-			}
-			return sec, nil
-		})
+		token, err := parseToken(tokenString, sec)
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "非法token"})
 			return
 		}
+		// 供应商门户 token 不能访问员工接口
+		if claims, ok := token.Claims.(jwt.MapClaims); ok && claims["typ"] != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "非法token"})
+			return
+		}
 
 		// 缺省值
 		var (
@@ -136,6 +136,15 @@ func ActiveGuard() gin.HandlerFunc {
 
 /************* 便捷函数 *************/
 
+func parseToken(tokenString string, sec []byte) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return sec, nil
+	})
+}
+
 func GetActor(c *gin.Context) *Actor {
 	a := &Actor{}
 	if v, ok := c.Get(ContextUserIDKey); ok {
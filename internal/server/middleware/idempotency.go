@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	domain "hdzk.cn/foodapp/internal/domain/idempotency"
+	idemrepo "hdzk.cn/foodapp/internal/repository/idempotency"
+	"hdzk.cn/foodapp/pkg/logger"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed 重放响应标记
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	contextIdempotencyKey = "idempotency"
+	maxIdempotencyKeyLen  = 128
+)
+
+type idempotencyConfig struct {
+	store idemrepo.Store
+	ttl   time.Duration
+}
+
+// WithIdempotency 注入幂等键存储与有效期（挂在引擎上，由各路由的 Idempotent 使用）
+func WithIdempotency(store idemrepo.Store, ttl time.Duration) gin.HandlerFunc {
+	cfg := &idempotencyConfig{store: store, ttl: ttl}
+	return func(c *gin.Context) {
+		c.Set(contextIdempotencyKey, cfg)
+		c.Next()
+	}
+}
+
+// Idempotent 按路由启用 Idempotency-Key（写在路由上，位于 RequireAuth/RequireSupplierAuth 之后），
+// 用于重复提交会产生重复数据的接口：create_*、复制、生成、调拨、过账、上传、批量等。
+// 首次响应按 操作者 + 幂等键 + 请求摘要 保存；有效期内同键同内容重放返回首次响应，同键不同内容返回 422
+func Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(HeaderIdempotencyKey))
+		if key == "" {
+			c.Next()
+			return
+		}
+		v, ok := c.Get(contextIdempotencyKey)
+		cfg, _ := v.(*idempotencyConfig)
+		actorID := GetActor(c).ID
+		if actorID == "" {
+			actorID = GetSupplierActor(c).ID
+		}
+		if !ok || cfg == nil || actorID == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key 过长（最多128字符）"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "读取请求体失败"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		rec := &domain.Record{
+			ActorID:     actorID,
+			IdemKey:     key,
+			Method:      c.Request.Method,
+			Path:        c.FullPath(),
+			RequestHash: requestHash(c, body),
+			ExpiresAt:   now.Add(cfg.ttl),
+		}
+		hit, err := cfg.store.Begin(c.Request.Context(), rec, now)
+		switch {
+		case errors.Is(err, domain.ErrMismatch):
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, domain.ErrInProgress):
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "幂等键校验失败"})
+			return
+		case hit != nil:
+			c.Header(HeaderIdempotentReplayed, "true")
+			if hit.ContentType != "" {
+				c.Header("Content-Type", hit.ContentType)
+			}
+			c.Status(hit.StatusCode)
+			_, _ = c.Writer.Write(hit.Body)
+			c.Abort()
+			return
+		}
+
+		w := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		// 客户端断线（厨房 Wi-Fi 抖动）时请求上下文已取消，保存响应不能随之失败
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			// 服务端异常不保存，释放幂等键允许重试
+			err = cfg.store.Release(ctx, rec.ID)
+		} else {
+			err = cfg.store.Complete(ctx, rec.ID, status, w.Header().Get("Content-Type"), w.buf.Bytes())
+		}
+		if err != nil {
+			logger.L().Warn("save idempotency record failed",
+				zap.String("rid", c.GetString("rid")),
+				zap.String("key", key),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// requestHash 方法 + 路由 + 查询串 + 请求体 的 SHA-256
+func requestHash(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + "\n" + c.FullPath() + "\n" + c.Request.URL.RawQuery + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// captureWriter 透传响应的同时留存响应体
+type captureWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.buf.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
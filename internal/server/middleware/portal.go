@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// TokenTypeSupplier 供应商门户 token 的 typ 声明；员工 token 无 typ
+	TokenTypeSupplier = "supplier"
+
+	ContextSupplierActorKey = "supplier_actor"
+)
+
+// SupplierActor 供应商门户操作者
+type SupplierActor struct {
+	ID         string // 门户账户ID（supplier_user.id）
+	Username   string
+	SupplierID string
+	OrgID      string
+	Scopes     []string
+}
+
+// Has 是否具有权限范围
+func (a *SupplierActor) Has(scope string) bool {
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SupplierLookup 按门户账户ID实时查询账户（停用、删除或供应商已删除时返回错误）
+type SupplierLookup func(ctx context.Context, uid string) (*SupplierActor, error)
+
+// RequireSupplierAuth 校验供应商门户 Bearer JWT 并按库中账户刷新供应商、权限范围，注入 SupplierActor。
+// 员工 token 不能访问门户接口
+func RequireSupplierAuth(secret string, lookup SupplierLookup) gin.HandlerFunc {
+	sec := []byte(secret)
+
+	return func(c *gin.Context) {
+		ah := c.GetHeader("Authorization")
+		if !strings.HasPrefix(strings.ToLower(ah), "bearer ") {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "请登录后操作"})
+			return
+		}
+		token, err := parseToken(strings.TrimSpace(ah[len("Bearer "):]), sec)
+		if err != nil || !token.Valid {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "非法token"})
+			return
+		}
+		claims, _ := token.Claims.(jwt.MapClaims)
+		uid, _ := claims["sub"].(string)
+		if typ, _ := claims["typ"].(string); typ != TokenTypeSupplier || uid == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "非法token"})
+			return
+		}
+
+		act, err := lookup(c.Request.Context(), uid)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "禁止操作",
+				"details": "账户已停用或删除",
+			})
+			return
+		}
+		c.Set(ContextSupplierActorKey, act)
+		c.Next()
+	}
+}
+
+// RequireScope 要求门户账户具有权限范围（需放在 RequireSupplierAuth 之后）
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !GetSupplierActor(c).Has(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":   "禁止操作",
+				"details": "账户无此权限：" + scope,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+func GetSupplierActor(c *gin.Context) *SupplierActor {
+	if v, ok := c.Get(ContextSupplierActorKey); ok {
+		if a, ok := v.(*SupplierActor); ok {
+			return a
+		}
+	}
+	return &SupplierActor{}
+}
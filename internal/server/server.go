@@ -5,24 +5,60 @@ import (
 	"net/http"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"hdzk.cn/foodapp/configs"
 	accrepo "hdzk.cn/foodapp/internal/repository/account"
+	biddingrepo "hdzk.cn/foodapp/internal/repository/bidding"
 	categoryrepo "hdzk.cn/foodapp/internal/repository/category"
+	coderulerepo "hdzk.cn/foodapp/internal/repository/coderule"
 	dictrepo "hdzk.cn/foodapp/internal/repository/dict"
-    goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
-    inquiryrepo "hdzk.cn/foodapp/internal/repository/inquiry"
+	goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
+	idemrepo "hdzk.cn/foodapp/internal/repository/idempotency"
+	inquiryrepo "hdzk.cn/foodapp/internal/repository/inquiry"
+	inventoryrepo "hdzk.cn/foodapp/internal/repository/inventory"
+	marketrepo "hdzk.cn/foodapp/internal/repository/market"
+	mealplanrepo "hdzk.cn/foodapp/internal/repository/mealplan"
+	mergerepo "hdzk.cn/foodapp/internal/repository/merge"
+	notificationrepo "hdzk.cn/foodapp/internal/repository/notification"
 	organrepo "hdzk.cn/foodapp/internal/repository/organ"
+	portalrepo "hdzk.cn/foodapp/internal/repository/portal"
+	pricerepo "hdzk.cn/foodapp/internal/repository/price"
+	purchaserepo "hdzk.cn/foodapp/internal/repository/purchase"
+	qualificationrepo "hdzk.cn/foodapp/internal/repository/qualification"
+	reciperepo "hdzk.cn/foodapp/internal/repository/recipe"
+	reportrepo "hdzk.cn/foodapp/internal/repository/report"
+	scorecardrepo "hdzk.cn/foodapp/internal/repository/scorecard"
 	supplierrepo "hdzk.cn/foodapp/internal/repository/supplier"
+	wasterepo "hdzk.cn/foodapp/internal/repository/waste"
+	weighingrepo "hdzk.cn/foodapp/internal/repository/weighing"
 	handler "hdzk.cn/foodapp/internal/server/handler"
 	"hdzk.cn/foodapp/internal/server/middleware"
 	accsvc "hdzk.cn/foodapp/internal/service/account"
+	biddingsvc "hdzk.cn/foodapp/internal/service/bidding"
 	categorysvc "hdzk.cn/foodapp/internal/service/category"
+	coderulesvc "hdzk.cn/foodapp/internal/service/coderule"
 	dictsvc "hdzk.cn/foodapp/internal/service/dict"
-    goodssvc "hdzk.cn/foodapp/internal/service/goods"
-    inquirysvc "hdzk.cn/foodapp/internal/service/inquiry"
+	forecastsvc "hdzk.cn/foodapp/internal/service/forecast"
+	goodssvc "hdzk.cn/foodapp/internal/service/goods"
+	inquirysvc "hdzk.cn/foodapp/internal/service/inquiry"
+	inventorysvc "hdzk.cn/foodapp/internal/service/inventory"
+	marketsvc "hdzk.cn/foodapp/internal/service/market"
+	mealplansvc "hdzk.cn/foodapp/internal/service/mealplan"
+	mergesvc "hdzk.cn/foodapp/internal/service/merge"
+	notificationsvc "hdzk.cn/foodapp/internal/service/notification"
 	organsvc "hdzk.cn/foodapp/internal/service/organ"
+	portalsvc "hdzk.cn/foodapp/internal/service/portal"
+	pricesvc "hdzk.cn/foodapp/internal/service/price"
+	purchasesvc "hdzk.cn/foodapp/internal/service/purchase"
+	qualificationsvc "hdzk.cn/foodapp/internal/service/qualification"
+	recipesvc "hdzk.cn/foodapp/internal/service/recipe"
+	reportsvc "hdzk.cn/foodapp/internal/service/report"
+	scorecardsvc "hdzk.cn/foodapp/internal/service/scorecard"
 	suppliersvc "hdzk.cn/foodapp/internal/service/supplier"
+	wastesvc "hdzk.cn/foodapp/internal/service/waste"
+	weighingsvc "hdzk.cn/foodapp/internal/service/weighing"
+	utils "hdzk.cn/foodapp/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -111,22 +147,22 @@ func registerGoodsRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig
 }
 
 func registerInquiryRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
-    repo := inquiryrepo.NewRepository(gdb)
-    svc := inquirysvc.NewService(repo)
-    h := handler.NewInquiryHandler(svc)
+	repo := inquiryrepo.NewRepository(gdb)
+	svc := inquirysvc.NewService(repo, pricerepo.NewRepository(gdb), marketrepo.NewRepository(gdb))
+	h := handler.NewInquiryHandler(svc)
 
-    v1 := r.Group("/api/v1")
-    protected := v1.Group("/")
-    protected.Use(
-        middleware.RequireAuth(authCfg.JWTSecret, nil),
-        middleware.ActiveGuard(),
-    )
-    h.Register(protected)
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	h.Register(protected)
 }
 
 func registerSupplierRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
 	supplierRepo := supplierrepo.NewRepository(gdb)
-	supplierSvc := suppliersvc.NewService(supplierRepo)
+	supplierSvc := suppliersvc.NewService(supplierRepo, notificationsvc.NewService(notificationrepo.NewRepository(gdb)))
 	supplierH := handler.NewSupplierHandler(supplierSvc)
 	v1 := r.Group("/api/v1")
 	protected := v1.Group("/")
@@ -137,15 +173,351 @@ func registerSupplierRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthCon
 	supplierH.Register(protected)
 }
 
-func New(gdb *gorm.DB, authCfg configs.AuthConfig, webDir string) *gin.Engine {
+func registerMergeRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	mergeSvc := mergesvc.NewService(mergerepo.NewRepository(gdb))
+	mergeH := handler.NewMergeHandler(mergeSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	mergeH.Register(protected)
+}
+
+func registerMealPlanRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	mealplanSvc := mealplansvc.NewService(mealplanrepo.NewRepository(gdb))
+	mealplanH := handler.NewMealPlanHandler(mealplanSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	mealplanH.Register(protected)
+}
+
+func registerWeighingRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	weighingSvc := weighingsvc.NewService(weighingrepo.NewRepository(gdb), goodsrepo.NewRepository(gdb), dictsvc.NewService(dictrepo.NewRepository(gdb)))
+	weighingH := handler.NewWeighingHandler(weighingSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	weighingH.Register(protected)
+}
+
+func registerRecipeRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	recipeSvc := recipesvc.NewService(
+		reciperepo.NewRepository(gdb),
+		goodsrepo.NewRepository(gdb),
+		mealplanrepo.NewRepository(gdb),
+		pricerepo.NewRepository(gdb),
+		dictsvc.NewService(dictrepo.NewRepository(gdb)),
+	)
+	recipeH := handler.NewRecipeHandler(recipeSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	recipeH.Register(protected)
+}
+
+func registerPurchaseRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	purchaseSvc := purchasesvc.NewService(purchaserepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), qualificationChecker(gdb), dictsvc.NewService(dictrepo.NewRepository(gdb)))
+	purchaseH := handler.NewPurchaseHandler(purchaseSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	purchaseH.Register(protected)
+}
+
+func registerForecastRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	dictSvc := dictsvc.NewService(dictrepo.NewRepository(gdb))
+	recipeSvc := recipesvc.NewService(
+		reciperepo.NewRepository(gdb),
+		goodsrepo.NewRepository(gdb),
+		mealplanrepo.NewRepository(gdb),
+		pricerepo.NewRepository(gdb),
+		dictSvc,
+	)
+	forecastSvc := forecastsvc.NewService(
+		weighingrepo.NewRepository(gdb),
+		pricerepo.NewRepository(gdb),
+		goodsrepo.NewRepository(gdb),
+		recipeSvc,
+		dictSvc,
+		purchasesvc.NewService(purchaserepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), qualificationChecker(gdb), dictsvc.NewService(dictrepo.NewRepository(gdb))),
+		inventorysvc.NewService(inventoryrepo.NewRepository(gdb), goodsrepo.NewRepository(gdb), weighingrepo.NewRepository(gdb), dictSvc),
+		utils.NewTransactor(gdb),
+	)
+	forecastH := handler.NewForecastHandler(forecastSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	forecastH.Register(protected)
+}
+
+func registerInventoryRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	inventorySvc := inventorysvc.NewService(inventoryrepo.NewRepository(gdb), goodsrepo.NewRepository(gdb), weighingrepo.NewRepository(gdb), dictsvc.NewService(dictrepo.NewRepository(gdb)))
+	inventoryH := handler.NewInventoryHandler(inventorySvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	inventoryH.Register(protected)
+}
+
+func registerWasteRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	dictSvc := dictsvc.NewService(dictrepo.NewRepository(gdb))
+	wasteSvc := wastesvc.NewService(
+		wasterepo.NewRepository(gdb),
+		weighingrepo.NewRepository(gdb),
+		goodsrepo.NewRepository(gdb),
+		pricerepo.NewRepository(gdb),
+		dictSvc,
+		dictSvc,
+		dictSvc,
+		organrepo.NewRepository(gdb),
+		inventorysvc.NewService(inventoryrepo.NewRepository(gdb), goodsrepo.NewRepository(gdb), weighingrepo.NewRepository(gdb), dictSvc),
+	)
+	wasteH := handler.NewWasteHandler(wasteSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	wasteH.Register(protected)
+}
+
+func registerPriceRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	priceSvc := priceService(gdb)
+	priceH := handler.NewPriceHandler(priceSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	priceH.Register(protected)
+}
+
+func registerMarketRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	marketSvc := marketsvc.NewService(marketrepo.NewRepository(gdb))
+	marketH := handler.NewMarketHandler(marketSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	marketH.Register(protected)
+}
+
+func registerCodeRuleRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	codeRuleSvc := coderulesvc.NewService(coderulerepo.NewRepository(gdb))
+	codeRuleH := handler.NewCodeRuleHandler(codeRuleSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	codeRuleH.Register(protected)
+}
+
+func registerReportRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig, reportCfg configs.ReportConfig) {
+	reportSvc := reportsvc.NewService(reportrepo.NewRepository(gdb), inquiryrepo.NewRepository(gdb), pricerepo.NewRepository(gdb), organrepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), reportCfg.FontPath)
+	reportH := handler.NewReportHandler(reportSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	reportH.Register(protected)
+}
+
+func registerNotificationRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	notificationH := handler.NewNotificationHandler(notificationsvc.NewService(notificationrepo.NewRepository(gdb)))
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	notificationH.Register(protected)
+}
+
+// priceService 询价录入与价格分析；询价、报价轮次及授标服务共用同一组装方式
+func priceService(gdb *gorm.DB) *pricesvc.Service {
+	return pricesvc.NewService(
+		pricerepo.NewRepository(gdb),
+		inquiryrepo.NewRepository(gdb),
+		supplierrepo.NewRepository(gdb),
+		organrepo.NewRepository(gdb),
+		categorysvc.NewService(categoryrepo.NewRepository(gdb)),
+		qualificationChecker(gdb),
+		biddingrepo.NewRepository(gdb),
+		utils.NewTransactor(gdb),
+	)
+}
+
+// qualificationChecker 供报价、采购校验供应商必备资质（不涉及文件存储）
+func qualificationChecker(gdb *gorm.DB) *qualificationsvc.Service {
+	return qualificationsvc.NewService(qualificationrepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), nil, "", 0)
+}
+
+func registerQualificationRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig, storageCfg configs.StorageConfig) {
+	qualificationSvc := qualificationsvc.NewService(
+		qualificationrepo.NewRepository(gdb),
+		supplierrepo.NewRepository(gdb),
+		notificationsvc.NewService(notificationrepo.NewRepository(gdb)),
+		storageCfg.Dir,
+		storageCfg.MaxUploadMB,
+	)
+	qualificationH := handler.NewQualificationHandler(qualificationSvc, int64(storageCfg.MaxUploadMB)<<20)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	qualificationH.Register(protected)
+}
+
+func registerScorecardRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	scorecardSvc := scorecardsvc.NewService(scorecardrepo.NewRepository(gdb), supplierrepo.NewRepository(gdb))
+	scorecardH := handler.NewScorecardHandler(scorecardSvc)
+
+	v1 := r.Group("/api/v1")
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	scorecardH.Register(protected)
+}
+
+// supplierAuth 门户鉴权：每次请求查库刷新门户账户状态与权限范围（停用、改权限立刻生效）
+func supplierAuth(gdb *gorm.DB, authCfg configs.AuthConfig) gin.HandlerFunc {
+	accounts := portalsvc.NewService(portalrepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), nil, nil, nil, nil, nil, nil)
+	lookup := func(ctx context.Context, uid string) (*middleware.SupplierActor, error) {
+		a, err := accounts.Lookup(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		return &middleware.SupplierActor{ID: a.ID, Username: a.Username, SupplierID: a.SupplierID, OrgID: a.OrgID, Scopes: a.ScopeList}, nil
+	}
+	return middleware.RequireSupplierAuth(authCfg.JWTSecret, lookup)
+}
+
+func registerPortalRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig, reportCfg configs.ReportConfig, storageCfg configs.StorageConfig) {
+	portalSvc := portalsvc.NewService(
+		portalrepo.NewRepository(gdb),
+		supplierrepo.NewRepository(gdb),
+		inquiryrepo.NewRepository(gdb),
+		priceService(gdb),
+		purchasesvc.NewService(purchaserepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), qualificationChecker(gdb), dictsvc.NewService(dictrepo.NewRepository(gdb))),
+		reportsvc.NewService(reportrepo.NewRepository(gdb), inquiryrepo.NewRepository(gdb), pricerepo.NewRepository(gdb), organrepo.NewRepository(gdb), supplierrepo.NewRepository(gdb), reportCfg.FontPath),
+		qualificationsvc.NewService(
+			qualificationrepo.NewRepository(gdb),
+			supplierrepo.NewRepository(gdb),
+			notificationsvc.NewService(notificationrepo.NewRepository(gdb)),
+			storageCfg.Dir,
+			storageCfg.MaxUploadMB,
+		),
+		utils.NewTransactor(gdb),
+	)
+	portalH := handler.NewPortalHandler(portalSvc, authCfg.JWTSecret, authCfg.AccessTokenTTLMinute, int64(storageCfg.MaxUploadMB)<<20)
+	adminH := handler.NewPortalAdminHandler(portalSvc)
+
+	v1 := r.Group("/api/v1")
+
+	// —— 门户公开路由（供应商登录）——
+	portalH.RegisterPublic(v1)
+
+	// —— 门户路由：仅接受供应商令牌 ——
+	supplierSide := v1.Group("/")
+	supplierSide.Use(supplierAuth(gdb, authCfg))
+	portalH.Register(supplierSide)
+
+	// —— 员工侧管理路由 ——
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	adminH.Register(protected)
+}
+
+func registerBiddingRoutes(r *gin.Engine, gdb *gorm.DB, authCfg configs.AuthConfig) {
+	biddingSvc := biddingsvc.NewService(
+		biddingrepo.NewRepository(gdb),
+		inquiryrepo.NewRepository(gdb),
+		supplierrepo.NewRepository(gdb),
+		priceService(gdb),
+		qualificationChecker(gdb),
+		utils.NewTransactor(gdb),
+	)
+	biddingH := handler.NewBiddingHandler(biddingSvc)
+
+	v1 := r.Group("/api/v1")
+
+	supplierSide := v1.Group("/")
+	supplierSide.Use(supplierAuth(gdb, authCfg))
+	biddingH.RegisterPortal(supplierSide)
+
+	protected := v1.Group("/")
+	protected.Use(
+		middleware.RequireAuth(authCfg.JWTSecret, nil),
+		middleware.ActiveGuard(),
+	)
+	biddingH.Register(protected)
+}
+
+func New(gdb *gorm.DB, authCfg configs.AuthConfig, reportCfg configs.ReportConfig, storageCfg configs.StorageConfig, serverCfg configs.ServerConfig) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
-	r.Use(gin.Recovery(), middleware.RequestID(), middleware.AccessLog())
+	r.Use(
+		gin.Recovery(),
+		middleware.RequestID(),
+		middleware.AccessLog(),
+		middleware.WithIdempotency(idemrepo.NewStore(gdb), time.Duration(serverCfg.IdempotencyTTLHour)*time.Hour),
+	)
 
 	// 健康探针
 	r.GET("/healthz", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
 
 	// 静态资源
+	webDir := serverCfg.WebRoot
 	if webDir == "" {
 		webDir = "./web"
 	}
@@ -168,9 +540,26 @@ func New(gdb *gorm.DB, authCfg configs.AuthConfig, webDir string) *gin.Engine {
 	registerDictRoutes(r, gdb, authCfg)
 	registerOrganRoutes(r, gdb, authCfg)
 	registerCategoryRoutes(r, gdb, authCfg)
-    registerSupplierRoutes(r, gdb, authCfg)
-    registerInquiryRoutes(r, gdb, authCfg)
+	registerSupplierRoutes(r, gdb, authCfg)
+	registerInquiryRoutes(r, gdb, authCfg)
 	registerGoodsRoutes(r, gdb, authCfg)
+	registerMergeRoutes(r, gdb, authCfg)
+	registerMealPlanRoutes(r, gdb, authCfg)
+	registerWeighingRoutes(r, gdb, authCfg)
+	registerRecipeRoutes(r, gdb, authCfg)
+	registerPurchaseRoutes(r, gdb, authCfg)
+	registerForecastRoutes(r, gdb, authCfg)
+	registerInventoryRoutes(r, gdb, authCfg)
+	registerWasteRoutes(r, gdb, authCfg)
+	registerPriceRoutes(r, gdb, authCfg)
+	registerMarketRoutes(r, gdb, authCfg)
+	registerCodeRuleRoutes(r, gdb, authCfg)
+	registerReportRoutes(r, gdb, authCfg, reportCfg)
+	registerNotificationRoutes(r, gdb, authCfg)
+	registerQualificationRoutes(r, gdb, authCfg, storageCfg)
+	registerScorecardRoutes(r, gdb, authCfg)
+	registerPortalRoutes(r, gdb, authCfg, reportCfg, storageCfg)
+	registerBiddingRoutes(r, gdb, authCfg)
 
 	return r
 }
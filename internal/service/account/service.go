@@ -76,6 +76,7 @@ func (s *Service) ChangePassword(ctx context.Context, username, oldPlain, newPla
 // ✅ 新增：通用字段更新（Username/OrgID/Description/Role）
 type UpdateInput struct {
 	ID          string
+	Version     int // 期望版本号（乐观锁）
 	Username    *string
 	OrgID       *string
 	Description *string
@@ -108,5 +109,5 @@ func (s *Service) Update(ctx context.Context, in UpdateInput) error {
 	if len(fields) == 0 {
 		return nil
 	}
-	return s.r.UpdateFields(ctx, in.ID, fields)
+	return s.r.UpdateFields(ctx, in.ID, in.Version, fields)
 }
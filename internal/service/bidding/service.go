@@ -0,0 +1,487 @@
+package bidding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/bidding"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	price "hdzk.cn/foodapp/internal/domain/price"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/bidding"
+	pricesvc "hdzk.cn/foodapp/internal/service/price"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// InquirySource 询价单抬头（由询价仓储实现）
+type InquirySource interface {
+	Get(ctx context.Context, id string) (*inquiry.PriceInquiry, error)
+}
+
+// SupplierSource 供应商及浮动比例（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+	FloatRatioOn(ctx context.Context, supplierID string, at time.Time) (*supplier.FloatRatio, error)
+}
+
+// QuoteSaver 授标写入询价单正式报价（由价格服务实现，含合同期、资质与异常检测）；
+// 改授其它供应商时撤回原中标报价
+type QuoteSaver interface {
+	SaveQuote(ctx context.Context, p pricesvc.QuoteParams) (*price.QuoteLine, error)
+	RetractQuote(ctx context.Context, id string) error
+}
+
+// Service 密封报价轮次：截止前报价对任何人密封，截止后排名、授标；密封数据的每次访问都记入审计
+type Service struct {
+	r         repo.Repository
+	inquiries InquirySource
+	suppliers SupplierSource
+	quotes    QuoteSaver
+	quals     pricesvc.QualificationChecker
+	tx        utils.Transactor
+}
+
+func NewService(r repo.Repository, inquiries InquirySource, suppliers SupplierSource, quotes QuoteSaver, quals pricesvc.QualificationChecker, tx utils.Transactor) *Service {
+	return &Service{r: r, inquiries: inquiries, suppliers: suppliers, quotes: quotes, quals: quals, tx: tx}
+}
+
+// Viewer 访问者（用于审计）
+type Viewer struct {
+	Type     string // domain.ActorStaff / domain.ActorSupplier
+	ID       string
+	ClientIP string
+}
+
+type CreateParams struct {
+	InquiryID   string
+	Title       *string
+	OpensAt     time.Time
+	ClosesAt    time.Time
+	SupplierIDs []string
+	CreatedBy   *string
+}
+
+// CreateRound 在询价单上创建报价轮次；询价单须未锁定，受邀供应商须与询价单同机构
+func (s *Service) CreateRound(ctx context.Context, p CreateParams) (*domain.Round, error) {
+	inq, err := s.inquiries.Get(ctx, strings.TrimSpace(p.InquiryID))
+	if err != nil {
+		return nil, fmt.Errorf("询价单不存在: %w", err)
+	}
+	if inquiry.Locked(inq.Status) {
+		return nil, inquiry.ErrLocked
+	}
+	if err := checkWindow(p.OpensAt, p.ClosesAt, time.Now()); err != nil {
+		return nil, err
+	}
+	ids, err := s.invitees(ctx, inq.OrgID, p.SupplierIDs)
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.Round{
+		OrgID:     inq.OrgID,
+		InquiryID: inq.ID,
+		Title:     utils.NormalizePtr(p.Title),
+		OpensAt:   p.OpensAt,
+		ClosesAt:  p.ClosesAt,
+		Status:    domain.StatusNormal,
+		CreatedBy: utils.NormalizePtr(p.CreatedBy),
+	}
+	if err := s.r.CreateRound(ctx, m, ids); err != nil {
+		return nil, err
+	}
+	return s.GetRound(ctx, m.ID)
+}
+
+type UpdateParams struct {
+	ID          string
+	Version     int
+	Title       *string // 空串清空
+	OpensAt     *time.Time
+	ClosesAt    *time.Time
+	SupplierIDs *[]string
+}
+
+// UpdateRound 修改轮次时间、名称或受邀供应商；仅限尚未开始的轮次
+func (s *Service) UpdateRound(ctx context.Context, p UpdateParams) (*domain.Round, error) {
+	cur, err := s.r.GetRound(ctx, strings.TrimSpace(p.ID))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	opens, closes := cur.OpensAt, cur.ClosesAt
+	if p.OpensAt != nil {
+		opens = *p.OpensAt
+	}
+	if p.ClosesAt != nil {
+		closes = *p.ClosesAt
+	}
+	if err := checkWindow(opens, closes, now); err != nil {
+		return nil, err
+	}
+	up := repo.UpdateParams{ID: cur.ID, Version: p.Version, OpensAt: p.OpensAt, ClosesAt: p.ClosesAt}
+	if p.Title != nil {
+		up.Title, up.ClearTitle = utils.NormalizePtr(p.Title), true
+	}
+	if p.SupplierIDs != nil {
+		ids, err := s.invitees(ctx, cur.OrgID, *p.SupplierIDs)
+		if err != nil {
+			return nil, err
+		}
+		up.SupplierIDs = &ids
+	}
+	if err := s.r.UpdateRound(ctx, up, now); err != nil {
+		return nil, err
+	}
+	return s.GetRound(ctx, cur.ID)
+}
+
+// CancelRound 取消未截止的轮次；已提交的报价保持密封，不再公开
+func (s *Service) CancelRound(ctx context.Context, id string) error {
+	return s.r.CancelRound(ctx, strings.TrimSpace(id), time.Now())
+}
+
+// GetRound 轮次抬头、受邀供应商及已报价供应商数（不含价格）
+func (s *Service) GetRound(ctx context.Context, id string) (*domain.Round, error) {
+	m, err := s.r.GetRound(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fill(ctx, m, time.Now()); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+type ListParams = repo.ListParams
+
+func (s *Service) ListRounds(ctx context.Context, p ListParams) ([]domain.Round, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.InquiryID = utils.NormalizePtr(p.InquiryID)
+	list, total, err := s.r.ListRounds(ctx, p)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range list {
+		if err := s.fill(ctx, &list[i], now); err != nil {
+			return nil, 0, err
+		}
+	}
+	return list, total, nil
+}
+
+func (s *Service) fill(ctx context.Context, m *domain.Round, now time.Time) error {
+	ids, err := s.r.SupplierIDs(ctx, m.ID)
+	if err != nil {
+		return err
+	}
+	n, err := s.r.SubmittedCount(ctx, m.ID)
+	if err != nil {
+		return err
+	}
+	m.Phase, m.SupplierIDs, m.Submitted = m.PhaseAt(now), ids, n
+	return nil
+}
+
+// Bids 开标后的全部报价（按商品排名）；截止前拒绝并记录
+func (s *Service) Bids(ctx context.Context, v Viewer, roundID string) ([]domain.RankedBid, error) {
+	m, err := s.r.GetRound(ctx, strings.TrimSpace(roundID))
+	if err != nil {
+		return nil, err
+	}
+	return s.ranked(ctx, v, m, domain.ActionViewBids)
+}
+
+// RankingResult 排名及已授标情况
+type RankingResult struct {
+	Round  *domain.Round      `json:"round"`
+	Bids   []domain.RankedBid `json:"bids"`
+	Awards []domain.Award     `json:"awards"`
+}
+
+// Ranking 开标后按商品的报价排名及授标结果；截止前拒绝并记录
+func (s *Service) Ranking(ctx context.Context, v Viewer, roundID string) (*RankingResult, error) {
+	m, err := s.r.GetRound(ctx, strings.TrimSpace(roundID))
+	if err != nil {
+		return nil, err
+	}
+	bids, err := s.ranked(ctx, v, m, domain.ActionViewRanking)
+	if err != nil {
+		return nil, err
+	}
+	awards, err := s.r.ListAwards(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fill(ctx, m, time.Now()); err != nil {
+		return nil, err
+	}
+	return &RankingResult{Round: m, Bids: bids, Awards: awards}, nil
+}
+
+// ranked 审计后读取并排名全部报价，标记已授标的报价
+func (s *Service) ranked(ctx context.Context, v Viewer, m *domain.Round, action string) ([]domain.RankedBid, error) {
+	if err := s.gate(ctx, v, m, action); err != nil {
+		return nil, err
+	}
+	bids, err := s.r.Bids(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	awards, err := s.r.ListAwards(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	awarded := make(map[string]bool, len(awards))
+	for _, a := range awards {
+		awarded[a.BidID] = true
+	}
+	out := domain.Rank(bids)
+	for i := range out {
+		out[i].Awarded = awarded[out[i].BidID]
+	}
+	return out, nil
+}
+
+// gate 员工访问报价前的开标检查；放行与拒绝都记入审计（审计写入失败时拒绝访问）
+func (s *Service) gate(ctx context.Context, v Viewer, m *domain.Round, action string) error {
+	now := time.Now()
+	var denied error
+	switch m.PhaseAt(now) {
+	case domain.PhaseCancelled:
+		denied = domain.ErrCancelled
+	case domain.PhasePending, domain.PhaseOpen:
+		denied = domain.ErrSealed
+	}
+	if err := s.audit(ctx, v, m, action, now, denied); err != nil {
+		return err
+	}
+	return denied
+}
+
+func (s *Service) audit(ctx context.Context, v Viewer, m *domain.Round, action string, at time.Time, denied error) error {
+	l := &domain.AccessLog{
+		OrgID:     m.OrgID,
+		RoundID:   m.ID,
+		ActorType: v.Type,
+		ActorID:   v.ID,
+		Action:    action,
+		Sealed:    m.SealedAt(at),
+		Allowed:   denied == nil,
+		ClientIP:  utils.NormalizePtr(&v.ClientIP),
+	}
+	if denied != nil {
+		msg := []rune(denied.Error())
+		if len(msg) > 255 {
+			msg = msg[:255]
+		}
+		detail := string(msg)
+		l.Detail = &detail
+	}
+	if err := s.r.LogAccess(ctx, l); err != nil {
+		return fmt.Errorf("审计记录失败: %w", err)
+	}
+	return nil
+}
+
+type AwardItem struct {
+	GoodsID    string
+	SupplierID string
+}
+
+type AwardParams struct {
+	RoundID string
+	Items   []AwardItem // 为空且 Auto 时按排名第一授标全部商品
+	Auto    bool
+}
+
+// Award 开标后将商品授予供应商：写入询价单正式报价（供采购取价）并记录授标，可重复授标覆盖
+func (s *Service) Award(ctx context.Context, v Viewer, p AwardParams) ([]domain.Award, error) {
+	m, err := s.r.GetRound(ctx, strings.TrimSpace(p.RoundID))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var denied error
+	switch m.PhaseAt(now) {
+	case domain.PhaseCancelled:
+		denied = domain.ErrCancelled
+	case domain.PhasePending, domain.PhaseOpen:
+		denied = domain.ErrNotFinished
+	}
+	if err := s.audit(ctx, v, m, domain.ActionAward, now, denied); err != nil {
+		return nil, err
+	}
+	if denied != nil {
+		return nil, denied
+	}
+
+	bids, err := s.r.Bids(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	eligible := map[string]bool{}
+	picks, err := pickAwards(domain.Rank(bids), p, func(supplierID string) bool {
+		ok, seen := eligible[supplierID]
+		if !seen {
+			ok = s.canQuote(ctx, supplierID, now) == nil
+			eligible[supplierID] = ok
+		}
+		return ok
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	prev, err := s.r.ListAwards(ctx, m.ID)
+	if err != nil {
+		return nil, err
+	}
+	prevByGoods := make(map[string]domain.Award, len(prev))
+	for _, a := range prev {
+		prevByGoods[a.GoodsID] = a
+	}
+
+	// 报价写入与授标在同一事务内，任一商品失败则整体回滚
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		awards := make([]domain.Award, 0, len(picks))
+		for _, b := range picks {
+			if old, ok := prevByGoods[b.GoodsID]; ok && old.SupplierID != b.SupplierID && old.QuoteLineID != nil {
+				if err := s.quotes.RetractQuote(ctx, *old.QuoteLineID); err != nil {
+					return fmt.Errorf("撤回商品 %s 原中标报价失败: %w", b.GoodsName, err)
+				}
+			}
+			q, err := s.quotes.SaveQuote(ctx, pricesvc.QuoteParams{
+				InquiryID:  m.InquiryID,
+				SupplierID: b.SupplierID,
+				GoodsID:    b.GoodsID,
+				UnitPrice:  b.UnitPrice,
+			})
+			if err != nil {
+				return fmt.Errorf("商品 %s 授予 %s 失败: %w", b.GoodsName, b.SupplierName, err)
+			}
+			awards = append(awards, domain.Award{
+				GoodsID:     b.GoodsID,
+				SupplierID:  b.SupplierID,
+				BidID:       b.BidID,
+				UnitPrice:   b.UnitPrice,
+				SettlePrice: b.SettlePrice,
+				RankNo:      b.RankNo,
+				QuoteLineID: &q.ID,
+				AwardedBy:   utils.NormalizePtr(&v.ID),
+			})
+		}
+		return s.r.SaveAwards(ctx, m.ID, awards, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.r.ListAwards(ctx, m.ID)
+}
+
+// pickAwards 选出授标的报价：指定明细时逐行匹配报价，自动授标时每个商品取排名最靠前、
+// 且 canQuote 判定供应商当前仍可报价的报价；ranked 须已按 domain.Rank 排序
+func pickAwards(ranked []domain.RankedBid, p AwardParams, canQuote func(supplierID string) bool) ([]domain.RankedBid, error) {
+	byKey := make(map[string]domain.RankedBid, len(ranked))
+	for _, b := range ranked {
+		byKey[b.GoodsID+"|"+b.SupplierID] = b
+	}
+
+	var picks []domain.RankedBid
+	switch {
+	case len(p.Items) > 0:
+		seen := map[string]bool{}
+		for i, it := range p.Items {
+			gid, sid := strings.TrimSpace(it.GoodsID), strings.TrimSpace(it.SupplierID)
+			if seen[gid] {
+				return nil, fmt.Errorf("第 %d 行商品重复", i+1)
+			}
+			b, ok := byKey[gid+"|"+sid]
+			if !ok {
+				return nil, fmt.Errorf("第 %d 行：该供应商未对该商品报价", i+1)
+			}
+			seen[gid] = true
+			picks = append(picks, b)
+		}
+	case p.Auto:
+		// 每个商品取排名最靠前、且供应商当前仍可报价（启用、在合同期内、必备资质有效）的报价
+		picked := map[string]bool{}
+		for _, b := range ranked {
+			if picked[b.GoodsID] {
+				continue
+			}
+			if canQuote(b.SupplierID) {
+				picked[b.GoodsID] = true
+				picks = append(picks, b)
+			}
+		}
+		if len(picks) == 0 {
+			return nil, errors.New("本轮没有可授标的报价")
+		}
+	default:
+		return nil, errors.New("请指定授标明细或选择按排名自动授标")
+	}
+	return picks, nil
+}
+
+// canQuote 供应商当前是否可报价/中标：启用、在合同期内且必备资质有效
+func (s *Service) canQuote(ctx context.Context, supplierID string, at time.Time) error {
+	sup, err := s.suppliers.GetSupplier(ctx, supplierID)
+	if err != nil {
+		return fmt.Errorf("供应商不存在: %w", err)
+	}
+	if err := sup.CheckActive(at); err != nil {
+		return err
+	}
+	return s.quals.Check(ctx, sup.ID, at)
+}
+
+type AccessListParams = repo.AccessListParams
+
+// ListAccess 轮次的密封数据访问审计
+func (s *Service) ListAccess(ctx context.Context, p AccessListParams) ([]domain.AccessLog, int64, error) {
+	p.RoundID = strings.TrimSpace(p.RoundID)
+	p.ActorType = utils.NormalizePtr(p.ActorType)
+	return s.r.ListAccess(ctx, p)
+}
+
+// invitees 校验并去重受邀供应商（须与询价单同机构）
+func (s *Service) invitees(ctx context.Context, orgID string, in []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(in))
+	for _, raw := range in {
+		id := strings.TrimSpace(raw)
+		if id == "" || seen[id] {
+			continue
+		}
+		sup, err := s.suppliers.GetSupplier(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("供应商 %s 不存在: %w", id, err)
+		}
+		if sup.OrgID != orgID {
+			return nil, fmt.Errorf("供应商 %s 与询价单不属于同一机构", sup.Name)
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("至少需要邀请一家供应商")
+	}
+	return out, nil
+}
+
+func checkWindow(opens, closes, now time.Time) error {
+	if !closes.After(opens) {
+		return errors.New("截止时间须晚于开始时间")
+	}
+	if !closes.After(now) {
+		return errors.New("截止时间须晚于当前时间")
+	}
+	return nil
+}
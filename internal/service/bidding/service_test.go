@@ -0,0 +1,77 @@
+package bidding
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/bidding"
+)
+
+func TestPickAwards(t *testing.T) {
+	at := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+	bid := func(id, goods, supplier, settle string) domain.RankedBid {
+		return domain.RankedBid{BidID: id, GoodsID: goods, GoodsName: goods, SupplierID: supplier,
+			SettlePrice: decimal.RequireFromString(settle), SubmittedAt: at}
+	}
+	ranked := domain.Rank([]domain.RankedBid{
+		bid("cab-s1", "cabbage", "s1", "2.10"),
+		bid("cab-s2", "cabbage", "s2", "2.30"),
+		bid("pork-s2", "pork", "s2", "27.00"),
+		bid("pork-s3", "pork", "s3", "28.00"),
+	})
+	all := func(string) bool { return true }
+	cases := []struct {
+		name     string
+		params   AwardParams
+		canQuote func(string) bool
+		want     string // 选中的报价ID，逗号分隔
+		wantErr  string
+	}{
+		{name: "自动授标取各商品第一名", params: AwardParams{Auto: true}, canQuote: all, want: "cab-s1,pork-s2"},
+		{
+			name: "第一名供应商不可报价时顺延", params: AwardParams{Auto: true},
+			canQuote: func(id string) bool { return id != "s2" }, want: "cab-s1,pork-s3",
+		},
+		{name: "没有可授标的报价", params: AwardParams{Auto: true}, canQuote: func(string) bool { return false }, wantErr: "没有可授标"},
+		{
+			name: "指定明细优先于自动，不检查排名",
+			params: AwardParams{Auto: true, Items: []AwardItem{
+				{GoodsID: " cabbage ", SupplierID: "s2"},
+			}},
+			canQuote: all, want: "cab-s2",
+		},
+		{
+			name:     "指定明细商品重复",
+			params:   AwardParams{Items: []AwardItem{{GoodsID: "pork", SupplierID: "s2"}, {GoodsID: "pork", SupplierID: "s3"}}},
+			canQuote: all, wantErr: "第 2 行商品重复",
+		},
+		{
+			name:     "指定的供应商未报价",
+			params:   AwardParams{Items: []AwardItem{{GoodsID: "pork", SupplierID: "s1"}}},
+			canQuote: all, wantErr: "第 1 行",
+		},
+		{name: "未指定明细也未选择自动", params: AwardParams{}, canQuote: all, wantErr: "请指定授标明细"},
+	}
+	for _, c := range cases {
+		picks, err := pickAwards(ranked, c.params, c.canQuote)
+		if c.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+				t.Errorf("%s: 期望错误含 %q，得到 %v", c.name, c.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: 返回错误: %v", c.name, err)
+			continue
+		}
+		ids := make([]string, len(picks))
+		for i, b := range picks {
+			ids[i] = b.BidID
+		}
+		if got := strings.Join(ids, ","); got != c.want {
+			t.Errorf("%s: pickAwards = %q，期望 %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package bidding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/bidding"
+)
+
+// Bidder 门户报价方（供应商账户）
+type Bidder struct {
+	AccountID  string
+	SupplierID string
+	ClientIP   string
+}
+
+func (b Bidder) viewer() Viewer {
+	return Viewer{Type: domain.ActorSupplier, ID: b.AccountID, ClientIP: b.ClientIP}
+}
+
+// SupplierRounds 本供应商受邀的轮次
+func (s *Service) SupplierRounds(ctx context.Context, b Bidder, page, pageSize int) ([]domain.RoundView, int64, error) {
+	list, total, err := s.r.SupplierRounds(ctx, b.SupplierID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range list {
+		list[i].Phase = domain.Round{
+			OpensAt:  list[i].OpensAt,
+			ClosesAt: list[i].ClosesAt,
+			Status:   list[i].Status,
+		}.PhaseAt(now)
+	}
+	return list, total, nil
+}
+
+// SupplierRoundDetail 轮次抬头及报价商品（仅含本供应商报价）
+type SupplierRoundDetail struct {
+	RoundID   string        `json:"round_id"`
+	InquiryID string        `json:"inquiry_id"`
+	Title     *string       `json:"title"`
+	OpensAt   time.Time     `json:"opens_at"`
+	ClosesAt  time.Time     `json:"closes_at"`
+	Phase     string        `json:"phase"`
+	Items     []domain.Item `json:"items"`
+}
+
+// SupplierRound 供应商查看本方在轮次中的报价（记入审计）
+func (s *Service) SupplierRound(ctx context.Context, b Bidder, roundID string) (*SupplierRoundDetail, error) {
+	m, err := s.invitedRound(ctx, b, roundID, domain.ActionViewOwn)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := s.audit(ctx, b.viewer(), m, domain.ActionViewOwn, now, nil); err != nil {
+		return nil, err
+	}
+	items, err := s.r.Items(ctx, m.ID, m.InquiryID, b.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	return &SupplierRoundDetail{
+		RoundID:   m.ID,
+		InquiryID: m.InquiryID,
+		Title:     m.Title,
+		OpensAt:   m.OpensAt,
+		ClosesAt:  m.ClosesAt,
+		Phase:     m.PhaseAt(now),
+		Items:     items,
+	}, nil
+}
+
+type BidItem struct {
+	GoodsID   string
+	UnitPrice decimal.Decimal
+}
+
+// SubmitBids 报价窗口内提交（或覆盖）密封报价；未开始、已截止（迟到）或已取消时拒绝。
+// 结算价按询价日生效的浮动比例计算，提交与拒绝都记入审计
+func (s *Service) SubmitBids(ctx context.Context, b Bidder, roundID string, items []BidItem) ([]domain.Bid, error) {
+	m, err := s.invitedRound(ctx, b, roundID, domain.ActionSubmit)
+	if err != nil {
+		return nil, err
+	}
+	bids, err := s.buildBids(ctx, b, m, items)
+	if err == nil {
+		err = s.r.SaveBids(ctx, m.ID, bids, time.Now())
+	}
+	if aerr := s.audit(ctx, b.viewer(), m, domain.ActionSubmit, time.Now(), err); aerr != nil && err == nil {
+		return nil, aerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.r.SupplierBids(ctx, m.ID, b.SupplierID)
+}
+
+func (s *Service) buildBids(ctx context.Context, b Bidder, m *domain.Round, items []BidItem) ([]domain.Bid, error) {
+	// 窗口先行校验，避免迟到报价走完后续检查；落库时还会在行锁内复核
+	switch m.PhaseAt(time.Now()) {
+	case domain.PhaseCancelled:
+		return nil, domain.ErrCancelled
+	case domain.PhasePending:
+		return nil, domain.ErrNotOpen
+	case domain.PhaseClosed:
+		return nil, domain.ErrLate
+	}
+	if len(items) == 0 {
+		return nil, errors.New("报价明细不能为空")
+	}
+	goods, err := s.r.Items(ctx, m.ID, m.InquiryID, b.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	inRound := make(map[string]bool, len(goods))
+	for _, g := range goods {
+		inRound[g.GoodsID] = true
+	}
+
+	sup, err := s.suppliers.GetSupplier(ctx, b.SupplierID)
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+	if err := sup.CheckActive(time.Now()); err != nil {
+		return nil, err
+	}
+	// 必备资质过期的供应商不能报价，以免排名第一却无法授标
+	if err := s.quals.Check(ctx, sup.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	inq, err := s.inquiries.Get(ctx, m.InquiryID)
+	if err != nil {
+		return nil, fmt.Errorf("询价单不存在: %w", err)
+	}
+	ratio, err := s.suppliers.FloatRatioOn(ctx, sup.ID, inq.InquiryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(items))
+	out := make([]domain.Bid, 0, len(items))
+	for i, it := range items {
+		id := strings.TrimSpace(it.GoodsID)
+		if !inRound[id] {
+			return nil, fmt.Errorf("第 %d 行商品不在询价单中", i+1)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("第 %d 行商品重复", i+1)
+		}
+		if !it.UnitPrice.IsPositive() {
+			return nil, fmt.Errorf("第 %d 行单价必须大于 0", i+1)
+		}
+		seen[id] = true
+		unit := it.UnitPrice.Round(2)
+		out = append(out, domain.Bid{
+			SupplierID:  sup.ID,
+			GoodsID:     id,
+			UnitPrice:   unit,
+			FloatRatio:  ratio.FloatRatio,
+			SettlePrice: unit.Mul(ratio.FloatRatio).Round(2),
+			SubmittedBy: &b.AccountID,
+		})
+	}
+	return out, nil
+}
+
+// invitedRound 读取本供应商受邀的轮次；未受邀时记入审计并返回 ErrNotInvited
+func (s *Service) invitedRound(ctx context.Context, b Bidder, roundID, action string) (*domain.Round, error) {
+	m, err := s.r.GetRound(ctx, strings.TrimSpace(roundID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrNotInvited
+		}
+		return nil, err
+	}
+	ok, err := s.r.IsInvited(ctx, m.ID, b.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if err := s.audit(ctx, b.viewer(), m, action, time.Now(), domain.ErrNotInvited); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrNotInvited
+	}
+	return m, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	repo "hdzk.cn/foodapp/internal/repository/category"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// BatchOp 批量中的单项操作：Op 决定使用哪些字段
+type BatchOp struct {
+	Op         string
+	ID         string   // update / delete
+	Version    int      // update：期望版本号
+	Name       string   // create / update
+	ParentID   *string  // create
+	Code       *string  // create / update
+	Pinyin     *string  // create / update
+	Sort       *int     // update
+	ReassignTo *string  // delete：下级品类/商品改挂目标
+	IDs        []string // reorder：按期望顺序给出的品类ID
+}
+
+// Batch 在一个事务内执行 org 下品类的批量新增/更新/软删/重排，逐项返回结果；
+// mode 见 utils.BatchAtomic / utils.BatchBestEffort
+func (s *Service) Batch(ctx context.Context, orgID, mode string, ops []BatchOp) (*utils.BatchResult, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	mode, err := utils.NormalizeBatchMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 || len(ops) > utils.BatchMaxOps {
+		return nil, fmt.Errorf("ops 数量须在 1..%d 之间", utils.BatchMaxOps)
+	}
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.Op
+	}
+
+	var res *utils.BatchResult
+	err = s.r.InTx(ctx, func(tx repo.CategoryRepository) error {
+		ts := &Service{r: tx}
+		var err error
+		res, err = utils.RunBatch(mode, names,
+			func(fn func() error) error {
+				return tx.InTx(ctx, func(repo.CategoryRepository) error { return fn() })
+			},
+			func(i int) (string, error) { return ts.applyBatchOp(ctx, orgID, ops[i]) },
+		)
+		return err
+	})
+	if errors.Is(err, utils.ErrBatchAborted) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Committed = true
+	return res, nil
+}
+
+func (s *Service) applyBatchOp(ctx context.Context, orgID string, op BatchOp) (string, error) {
+	switch op.Op {
+	case utils.BatchOpCreate:
+		name := strings.TrimSpace(op.Name)
+		if name == "" {
+			return "", errors.New("name 不能为空")
+		}
+		m, err := s.Create(ctx, name, orgID, op.ParentID, op.Code, op.Pinyin)
+		if err != nil {
+			return "", err
+		}
+		return m.ID, nil
+	case utils.BatchOpUpdate:
+		id := strings.TrimSpace(op.ID)
+		if err := s.ensureInOrg(ctx, orgID, id); err != nil {
+			return id, err
+		}
+		name := strings.TrimSpace(op.Name)
+		if name == "" {
+			return id, errors.New("name 不能为空")
+		}
+		return id, s.Update(ctx, id, op.Version, name, op.Code, op.Pinyin, op.Sort)
+	case utils.BatchOpDelete:
+		id := strings.TrimSpace(op.ID)
+		if err := s.ensureInOrg(ctx, orgID, id); err != nil {
+			return id, err
+		}
+		return id, s.SoftDelete(ctx, id, op.ReassignTo)
+	case utils.BatchOpReorder:
+		return "", s.r.Reorder(ctx, orgID, op.IDs)
+	}
+	return "", fmt.Errorf("不支持的操作: %s", op.Op)
+}
+
+func (s *Service) ensureInOrg(ctx context.Context, orgID, id string) error {
+	m, err := s.r.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("品类不存在: %w", err)
+	}
+	if m.OrgID != orgID {
+		return errors.New("品类不属于该组织")
+	}
+	return nil
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/google/uuid"
@@ -15,15 +17,26 @@ type Service struct {
 
 func NewService(r repo.CategoryRepository) *Service { return &Service{r: r} }
 
-func (s *Service) Create(ctx context.Context, name string, org_id string, code *string, pinyin *string) (*domain.Category, error) {
+func (s *Service) Create(ctx context.Context, name string, org_id string, parentID *string, code *string, pinyin *string) (*domain.Category, error) {
 	normalizedCode, _ := normalizeString(code)
 	normalizedPinyin, _ := normalizeString(pinyin)
+	normalizedParent, _ := normalizeString(parentID)
+	if normalizedParent != nil {
+		parent, err := s.r.Get(ctx, *normalizedParent)
+		if err != nil {
+			return nil, fmt.Errorf("上级品类不存在: %w", err)
+		}
+		if parent.OrgID != org_id {
+			return nil, errors.New("上级品类不属于同一组织")
+		}
+	}
 	m := &domain.Category{
-		ID:     uuid.NewString(),
-		Name:   name,
-		OrgID:  org_id,
-		Code:   normalizedCode,
-		Pinyin: normalizedPinyin,
+		ID:       uuid.NewString(),
+		Name:     name,
+		OrgID:    org_id,
+		ParentID: normalizedParent,
+		Code:     normalizedCode,
+		Pinyin:   normalizedPinyin,
 	}
 	return m, s.r.Create(ctx, m)
 }
@@ -32,23 +45,143 @@ func (s *Service) Get(ctx context.Context, id string) (*domain.Category, error)
 	return s.r.Get(ctx, id)
 }
 
-func (s *Service) List(ctx context.Context, keyword string, org_id string, page, pageSize int) ([]domain.Category, int64, error) {
-	return s.r.List(ctx, keyword, org_id, page, pageSize)
+func (s *Service) List(ctx context.Context, keyword string, org_id string, parentID *string, page, pageSize int) ([]domain.Category, int64, error) {
+	return s.r.List(ctx, keyword, org_id, parentID, page, pageSize)
+}
+
+// Tree 返回 org 下完整品类树
+func (s *Service) Tree(ctx context.Context, orgID string) ([]*domain.TreeNode, error) {
+	list, err := s.r.ListAll(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.BuildTree(list), nil
+}
+
+// DescendantIDs 返回 id 自身及全部后代品类 ID
+func (s *Service) DescendantIDs(ctx context.Context, id string) ([]string, error) {
+	m, err := s.r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.r.ListAll(ctx, m.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.DescendantIDs(list, id), nil
 }
 
-func (s *Service) Update(ctx context.Context, id, name string, code *string, pinyin *string, sort *int) error {
+func (s *Service) Update(ctx context.Context, id string, version int, name string, code *string, pinyin *string, sort *int) error {
 	normalizedCode, updateCode := normalizeString(code)
 	normalizedPinyin, updatePinyin := normalizeString(pinyin)
 	updateSort := sort != nil
-	return s.r.Update(ctx, id, name, normalizedCode, normalizedPinyin, sort, updateCode, updatePinyin, updateSort)
+	return s.r.Update(ctx, id, version, name, normalizedCode, normalizedPinyin, sort, updateCode, updatePinyin, updateSort)
+}
+
+// Move 调整上级品类；parentID 为空表示移为顶级。
+// 不允许移到自身或自身后代之下（成环），已有 code 保持不变。
+// 成环检查与更新在同一事务内进行，并先锁住 org 下全部品类，避免并发移动交叉成环。
+func (s *Service) Move(ctx context.Context, id string, parentID *string) error {
+	m, err := s.r.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("品类不存在: %w", err)
+	}
+	target, _ := normalizeString(parentID)
+	return s.r.InTx(ctx, func(r repo.CategoryRepository) error {
+		list, err := r.LockAll(ctx, m.OrgID)
+		if err != nil {
+			return err
+		}
+		if target != nil {
+			if err := checkTarget(list, id, *target); err != nil {
+				return err
+			}
+		} else if !containsID(list, id) {
+			return errors.New("品类不存在或已删除")
+		}
+		return r.Move(ctx, id, target)
+	})
 }
 
-func (s *Service) SoftDelete(ctx context.Context, id string) error {
+// SoftDelete 仍有下级品类或商品时必须指定 reassignTo（改挂目标），否则拒绝删除
+func (s *Service) SoftDelete(ctx context.Context, id string, reassignTo *string) error {
+	m, err := s.r.Get(ctx, id)
+	if err != nil {
+		return fmt.Errorf("品类不存在: %w", err)
+	}
+	target, _ := normalizeString(reassignTo)
+	if target != nil {
+		return s.r.InTx(ctx, func(r repo.CategoryRepository) error {
+			list, err := r.LockAll(ctx, m.OrgID)
+			if err != nil {
+				return err
+			}
+			if err := checkTarget(list, id, *target); err != nil {
+				return err
+			}
+			return r.ReassignAndSoftDelete(ctx, id, *target)
+		})
+	}
+	if err := s.ensureEmpty(ctx, id); err != nil {
+		return err
+	}
 	return s.r.SoftDelete(ctx, id)
 }
 
+// HardDelete 加锁后检查并删除；已软删的下级品类见 repo HardDelete
 func (s *Service) HardDelete(ctx context.Context, id string) error {
-	return s.r.HardDelete(ctx, id)
+	return s.r.InTx(ctx, func(r repo.CategoryRepository) error {
+		if _, err := r.Lock(ctx, id); err != nil {
+			return fmt.Errorf("品类不存在: %w", err)
+		}
+		if err := (&Service{r: r}).ensureEmpty(ctx, id); err != nil {
+			return err
+		}
+		return r.HardDelete(ctx, id)
+	})
+}
+
+// checkTarget 在已加锁的 org 品类列表内校验：id 与目标均有效（同 org），且目标不是 id 自身或其后代
+func checkTarget(list []domain.Category, id, targetID string) error {
+	if !containsID(list, id) {
+		return errors.New("品类不存在或已删除")
+	}
+	if !containsID(list, targetID) {
+		return errors.New("目标品类不存在或不属于同一组织")
+	}
+	for _, d := range domain.DescendantIDs(list, id) {
+		if d == targetID {
+			return errors.New("目标品类不能是自身或其下级品类")
+		}
+	}
+	return nil
+}
+
+func containsID(list []domain.Category, id string) bool {
+	for _, c := range list {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Service) ensureEmpty(ctx context.Context, id string) error {
+	children, err := s.r.CountChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return fmt.Errorf("该品类下仍有 %d 个下级品类，请先移走或指定 reassign_to", children)
+	}
+	goods, err := s.r.CountGoods(ctx, id)
+	if err != nil {
+		return err
+	}
+	if goods > 0 {
+		return fmt.Errorf("该品类下仍有 %d 个商品，请先移走或指定 reassign_to", goods)
+	}
+	return nil
 }
 
 func normalizeString(str *string) (*string, bool) {
@@ -0,0 +1,103 @@
+package coderule
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domain "hdzk.cn/foodapp/internal/domain/coderule"
+	repo "hdzk.cn/foodapp/internal/repository/coderule"
+)
+
+type Service struct{ r repo.Repository }
+
+func NewService(r repo.Repository) *Service { return &Service{r: r} }
+
+type CreateParams struct {
+	OrgID      string
+	EntityType string
+	Pattern    string
+	ReuseGaps  int
+}
+
+type UpdateParams struct {
+	ID        string
+	Version   int
+	Pattern   string
+	ReuseGaps int
+}
+
+type ListParams = repo.ListParams
+
+type PreviewParams = repo.PreviewParams
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Rule, error) {
+	orgID, entity := strings.TrimSpace(p.OrgID), strings.TrimSpace(p.EntityType)
+	pattern, err := validate(entity, orgID, p.Pattern, p.ReuseGaps)
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.Rule{OrgID: orgID, EntityType: entity, Pattern: pattern, ReuseGaps: p.ReuseGaps}
+	return m, s.r.Create(ctx, m)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Rule, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Rule, error) {
+	if p.OrgID != nil {
+		v := strings.TrimSpace(*p.OrgID)
+		p.OrgID = &v
+	}
+	p.EntityType = strings.TrimSpace(p.EntityType)
+	return s.r.List(ctx, p)
+}
+
+// Update 修改模式；新模式的作用域与旧模式不同时使用各自的计数器，已发放的编码不受影响
+func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	cur, err := s.r.Get(ctx, strings.TrimSpace(p.ID))
+	if err != nil {
+		return err
+	}
+	pattern, err := validate(cur.EntityType, cur.OrgID, p.Pattern, p.ReuseGaps)
+	if err != nil {
+		return err
+	}
+	return s.r.Update(ctx, cur.ID, p.Version, pattern, p.ReuseGaps)
+}
+
+// Delete 删除规则后回退到全局规则或内置默认
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.r.Delete(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) Preview(ctx context.Context, p PreviewParams) (string, bool, error) {
+	p.EntityType, p.OrgID = strings.TrimSpace(p.EntityType), strings.TrimSpace(p.OrgID)
+	e, ok := domain.Entities[p.EntityType]
+	if !ok {
+		return "", false, fmt.Errorf("不支持的实体类型: %s", p.EntityType)
+	}
+	if !e.Global && p.OrgID == "" {
+		return "", false, fmt.Errorf("org_id 不能为空")
+	}
+	return s.r.Preview(ctx, p)
+}
+
+func validate(entity, orgID, pattern string, reuseGaps int) (string, error) {
+	e, ok := domain.Entities[entity]
+	if !ok {
+		return "", fmt.Errorf("不支持的实体类型: %s", entity)
+	}
+	if e.Global && orgID != "" {
+		return "", fmt.Errorf("%s 为全局字典，仅支持全局规则（org_id 须为空）", entity)
+	}
+	if reuseGaps != 0 && reuseGaps != 1 {
+		return "", fmt.Errorf("reuse_gaps 只能为 0 或 1")
+	}
+	pattern = strings.TrimSpace(pattern)
+	if _, err := domain.Parse(pattern, entity); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	domain "hdzk.cn/foodapp/internal/domain/dict"
+	repo "hdzk.cn/foodapp/internal/repository/dict"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// 批量操作支持的字典类型
+const (
+	KindUnit        = "unit"
+	KindSpec        = "spec"
+	KindMealTime    = "meal_time"
+	KindWasteReason = "waste_reason"
+)
+
+// BatchOp 批量中的单项操作：Op 决定使用哪些字段
+type BatchOp struct {
+	Op      string
+	ID      string   // update / delete
+	Version int      // update：期望版本号
+	Name    string   // create / update
+	Code    *string  // create / update
+	Sort    *int     // update：为空保持原值
+	IDs     []string // reorder：按期望顺序给出的ID
+}
+
+// dictOps 某一字典类型的单项操作
+type dictOps struct {
+	table  string
+	create func(s *Service, ctx context.Context, name string, code *string) (string, error)
+	update func(s *Service, ctx context.Context, op BatchOp, sort int) error
+	delete func(s *Service, ctx context.Context, id string) error
+	sortOf func(s *Service, ctx context.Context, id string) (int, error) // 当前 sort（记录不存在时报错）
+}
+
+var batchKinds = map[string]dictOps{
+	KindUnit: {
+		table: domain.Unit{}.TableName(),
+		create: func(s *Service, ctx context.Context, name string, code *string) (string, error) {
+			m, err := s.CreateUnit(ctx, name, code, 0)
+			if err != nil {
+				return "", err
+			}
+			return m.ID, nil
+		},
+		update: func(s *Service, ctx context.Context, op BatchOp, sort int) error {
+			return s.UpdateUnit(ctx, op.ID, op.Version, op.Name, op.Code, sort)
+		},
+		delete: (*Service).DeleteUnit,
+		sortOf: func(s *Service, ctx context.Context, id string) (int, error) {
+			m, err := s.GetUnit(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return m.Sort, nil
+		},
+	},
+	KindSpec: {
+		table: domain.Spec{}.TableName(),
+		create: func(s *Service, ctx context.Context, name string, code *string) (string, error) {
+			m, err := s.CreateSpec(ctx, name, code, 0)
+			if err != nil {
+				return "", err
+			}
+			return m.ID, nil
+		},
+		update: func(s *Service, ctx context.Context, op BatchOp, sort int) error {
+			return s.UpdateSpec(ctx, op.ID, op.Version, op.Name, op.Code, sort)
+		},
+		delete: (*Service).DeleteSpec,
+		sortOf: func(s *Service, ctx context.Context, id string) (int, error) {
+			m, err := s.GetSpec(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return m.Sort, nil
+		},
+	},
+	KindMealTime: {
+		table: domain.MealTime{}.TableName(),
+		create: func(s *Service, ctx context.Context, name string, code *string) (string, error) {
+			m, err := s.CreateMealTime(ctx, name, code, 0)
+			if err != nil {
+				return "", err
+			}
+			return m.ID, nil
+		},
+		update: func(s *Service, ctx context.Context, op BatchOp, sort int) error {
+			return s.UpdateMealTime(ctx, op.ID, op.Version, op.Name, op.Code, sort)
+		},
+		delete: (*Service).DeleteMealTime,
+		sortOf: func(s *Service, ctx context.Context, id string) (int, error) {
+			m, err := s.GetMealTime(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return m.Sort, nil
+		},
+	},
+	KindWasteReason: {
+		table: domain.WasteReason{}.TableName(),
+		create: func(s *Service, ctx context.Context, name string, code *string) (string, error) {
+			m, err := s.CreateWasteReason(ctx, name, code, 0)
+			if err != nil {
+				return "", err
+			}
+			return m.ID, nil
+		},
+		update: func(s *Service, ctx context.Context, op BatchOp, sort int) error {
+			return s.UpdateWasteReason(ctx, op.ID, op.Version, op.Name, op.Code, sort)
+		},
+		delete: (*Service).DeleteWasteReason,
+		sortOf: func(s *Service, ctx context.Context, id string) (int, error) {
+			m, err := s.GetWasteReason(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return m.Sort, nil
+		},
+	},
+}
+
+// Batch 在一个事务内执行某一字典类型的批量新增/更新/软删/重排，逐项返回结果；
+// 新增的 sort/code 仍由模型按最小缺口（utils.NextColoumSort）派生
+func (s *Service) Batch(ctx context.Context, kind, mode string, ops []BatchOp) (*utils.BatchResult, error) {
+	k, ok := batchKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("不支持的字典类型: %s", kind)
+	}
+	mode, err := utils.NormalizeBatchMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 || len(ops) > utils.BatchMaxOps {
+		return nil, fmt.Errorf("ops 数量须在 1..%d 之间", utils.BatchMaxOps)
+	}
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.Op
+	}
+
+	var res *utils.BatchResult
+	err = s.r.InTx(ctx, func(tx repo.DictRepository) error {
+		ts := &Service{r: tx}
+		var err error
+		res, err = utils.RunBatch(mode, names,
+			func(fn func() error) error {
+				return tx.InTx(ctx, func(repo.DictRepository) error { return fn() })
+			},
+			func(i int) (string, error) { return ts.applyBatchOp(ctx, k, ops[i]) },
+		)
+		return err
+	})
+	if errors.Is(err, utils.ErrBatchAborted) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Committed = true
+	return res, nil
+}
+
+func (s *Service) applyBatchOp(ctx context.Context, k dictOps, op BatchOp) (string, error) {
+	op.ID = strings.TrimSpace(op.ID)
+	op.Name = strings.TrimSpace(op.Name)
+	switch op.Op {
+	case utils.BatchOpCreate:
+		if op.Name == "" {
+			return "", errors.New("name 不能为空")
+		}
+		return k.create(s, ctx, op.Name, op.Code)
+	case utils.BatchOpUpdate:
+		if op.ID == "" || op.Name == "" {
+			return op.ID, errors.New("id、name 不能为空")
+		}
+		sort, err := k.sortOf(s, ctx, op.ID)
+		if err != nil {
+			return op.ID, fmt.Errorf("记录不存在: %w", err)
+		}
+		if op.Sort != nil {
+			sort = *op.Sort
+		}
+		return op.ID, k.update(s, ctx, op, sort)
+	case utils.BatchOpDelete:
+		if op.ID == "" {
+			return "", errors.New("id 不能为空")
+		}
+		if _, err := k.sortOf(s, ctx, op.ID); err != nil {
+			return op.ID, fmt.Errorf("记录不存在: %w", err)
+		}
+		return op.ID, k.delete(s, ctx, op.ID)
+	case utils.BatchOpReorder:
+		return "", s.r.Reorder(ctx, k.table, op.IDs)
+	}
+	return "", fmt.Errorf("不支持的操作: %s", op.Op)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/dict"
+)
+
+// UnitConverter 单位换算，由 Service 实现；配方、库存、损耗、预测等服务依赖该接口
+type UnitConverter interface {
+	ConvertQuantity(ctx context.Context, qty decimal.Decimal, fromUnitID, toUnitID string, goodsID *string) (decimal.Decimal, error)
+	ToBaseUnit(ctx context.Context, qty decimal.Decimal, unitID string, goodsID *string, dimension string) (decimal.Decimal, *domain.Unit, error)
+	GoodsBaseUnit(ctx context.Context, goodsID, unitID string) (*domain.Unit, error)
+}
+
+var _ UnitConverter = (*Service)(nil)
+
+// 量纲匹配顺序（固定顺序保证结果确定）
+var dimensionOrder = []string{domain.DimensionMass, domain.DimensionVolume, domain.DimensionCount}
+
+// SetUnitConversion 设置单位量纲与换算系数；dimension 为空表示清除换算定义。
+// 设为基准单位时同量纲其它单位的系数按新基准重算（见 domain.ErrRebaseFactor）
+func (s *Service) SetUnitConversion(ctx context.Context, id string, dimension *string, factor *decimal.Decimal, isBase bool) error {
+	if _, err := s.r.GetUnit(ctx, id); err != nil {
+		return fmt.Errorf("单位不存在: %w", err)
+	}
+	dim, _ := normalizeCode(dimension)
+	if dim == nil {
+		return s.r.SetUnitConversion(ctx, id, nil, nil, 0)
+	}
+	if !domain.ValidDimension(*dim) {
+		return fmt.Errorf("dimension 非法: %s（可选 mass/volume/count）", *dim)
+	}
+	if factor == nil || !factor.IsPositive() {
+		return errors.New("factor 必须大于 0")
+	}
+	base := 0
+	if isBase {
+		if !factor.Equal(decimal.NewFromInt(1)) {
+			return errors.New("基准单位的 factor 必须为 1")
+		}
+		base = 1
+	}
+	return s.r.SetUnitConversion(ctx, id, dim, factor, base)
+}
+
+func (s *Service) GetBaseUnit(ctx context.Context, dimension string) (*domain.Unit, error) {
+	return s.r.GetBaseUnit(ctx, dimension)
+}
+
+// CreateGoodsUnitConversion 商品级换算：商品须属于 orgID；源单位已定义量纲时须与目标单位同量纲
+// （未定义量纲的包装单位如 包/袋 由此获得换算）
+func (s *Service) CreateGoodsUnitConversion(ctx context.Context, orgID, goodsID, unitID, toUnitID string, factor decimal.Decimal) (*domain.GoodsUnitConversion, error) {
+	orgID, goodsID = strings.TrimSpace(orgID), strings.TrimSpace(goodsID)
+	if unitID == toUnitID {
+		return nil, errors.New("源单位与目标单位不能相同")
+	}
+	if !factor.IsPositive() {
+		return nil, errors.New("factor 必须大于 0")
+	}
+	goodsOrg, err := s.r.GoodsOrgID(ctx, goodsID)
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %w", err)
+	}
+	if goodsOrg != orgID {
+		return nil, errors.New("商品不属于该机构")
+	}
+	from, err := s.r.GetUnit(ctx, unitID)
+	if err != nil {
+		return nil, fmt.Errorf("源单位不存在: %w", err)
+	}
+	to, err := s.r.GetUnit(ctx, toUnitID)
+	if err != nil {
+		return nil, fmt.Errorf("目标单位不存在: %w", err)
+	}
+	if to.Dimension == nil || to.Factor == nil {
+		return nil, fmt.Errorf("目标单位 %s 未定义量纲/换算系数", to.Name)
+	}
+	if from.Dimension != nil && *from.Dimension != *to.Dimension {
+		return nil, fmt.Errorf("源单位 %s 与目标单位 %s 量纲不一致", from.Name, to.Name)
+	}
+	m := &domain.GoodsUnitConversion{
+		OrgID:    orgID,
+		GoodsID:  goodsID,
+		UnitID:   unitID,
+		ToUnitID: toUnitID,
+		Factor:   factor,
+	}
+	return m, s.r.CreateGoodsUnitConversion(ctx, m)
+}
+
+func (s *Service) ListGoodsUnitConversions(ctx context.Context, goodsID string) ([]domain.GoodsUnitConversion, error) {
+	return s.r.ListGoodsUnitConversions(ctx, strings.TrimSpace(goodsID))
+}
+
+func (s *Service) DeleteGoodsUnitConversion(ctx context.Context, id string) error {
+	return s.r.DeleteGoodsUnitConversion(ctx, id)
+}
+
+// ConvertQuantity 数量换算：qty 个 fromUnit = ? 个 toUnit。
+// goodsID 非空时优先使用商品级换算（如 1 包 = 0.5 公斤），结果按 ConversionScale 四舍五入。
+func (s *Service) ConvertQuantity(ctx context.Context, qty decimal.Decimal, fromUnitID, toUnitID string, goodsID *string) (decimal.Decimal, error) {
+	if fromUnitID == toUnitID {
+		return qty, nil
+	}
+	overrides, err := s.goodsOverrides(ctx, goodsID)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	fromF, err := s.unitFactors(ctx, fromUnitID, overrides)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	toF, err := s.unitFactors(ctx, toUnitID, overrides)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	for _, d := range dimensionOrder {
+		f, ok1 := fromF[d]
+		t, ok2 := toF[d]
+		if ok1 && ok2 {
+			return qty.Mul(f).DivRound(t, domain.ConversionScale), nil
+		}
+	}
+	return decimal.Zero, errors.New("两个单位之间没有可用的换算关系")
+}
+
+// ConvertPrice 单价换算：price 元/priceUnit = ? 元/targetUnit（如 元/斤 → 元/公斤）
+func (s *Service) ConvertPrice(ctx context.Context, price decimal.Decimal, priceUnitID, targetUnitID string, goodsID *string) (decimal.Decimal, error) {
+	// 1 targetUnit = k priceUnit，则 元/targetUnit = price * k
+	return s.ConvertQuantity(ctx, price, targetUnitID, priceUnitID, goodsID)
+}
+
+// ToBaseUnit 换算到指定量纲的基准单位（如称重统一为公斤）
+func (s *Service) ToBaseUnit(ctx context.Context, qty decimal.Decimal, unitID string, goodsID *string, dimension string) (decimal.Decimal, *domain.Unit, error) {
+	base, err := s.r.GetBaseUnit(ctx, dimension)
+	if err != nil {
+		return decimal.Zero, nil, fmt.Errorf("量纲 %s 未设置基准单位: %w", dimension, err)
+	}
+	out, err := s.ConvertQuantity(ctx, qty, unitID, base.ID, goodsID)
+	if err != nil {
+		return decimal.Zero, nil, err
+	}
+	return out, base, nil
+}
+
+// unitFactors 单位在各量纲下折合基准单位的系数：通用定义 + 商品级覆盖（覆盖优先）
+func (s *Service) unitFactors(ctx context.Context, unitID string, overrides []domain.GoodsUnitConversion) (map[string]decimal.Decimal, error) {
+	u, err := s.r.GetUnit(ctx, unitID)
+	if err != nil {
+		return nil, fmt.Errorf("单位不存在: %w", err)
+	}
+	out := map[string]decimal.Decimal{}
+	if u.Dimension != nil && u.Factor != nil {
+		out[*u.Dimension] = *u.Factor
+	}
+	for _, o := range overrides {
+		if o.UnitID != unitID {
+			continue
+		}
+		to, err := s.r.GetUnit(ctx, o.ToUnitID)
+		if err != nil || to.Dimension == nil || to.Factor == nil {
+			continue
+		}
+		out[*to.Dimension] = o.Factor.Mul(*to.Factor)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("单位 %s 未定义换算关系", u.Name)
+	}
+	return out, nil
+}
+
+func (s *Service) goodsOverrides(ctx context.Context, goodsID *string) ([]domain.GoodsUnitConversion, error) {
+	if goodsID == nil || strings.TrimSpace(*goodsID) == "" {
+		return nil, nil
+	}
+	return s.r.ListGoodsUnitConversions(ctx, strings.TrimSpace(*goodsID))
+}
+
+// GoodsBaseUnit 商品的库存基准单位：商品单位所属量纲的基准单位；
+// 商品单位未定义量纲时按商品级换算推断，仍无法确定则以商品单位本身为准。
+func (s *Service) GoodsBaseUnit(ctx context.Context, goodsID, unitID string) (*domain.Unit, error) {
+	u, err := s.r.GetUnit(ctx, unitID)
+	if err != nil {
+		return nil, fmt.Errorf("单位不存在: %w", err)
+	}
+	dim := u.Dimension
+	if dim == nil {
+		overrides, err := s.goodsOverrides(ctx, &goodsID)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range overrides {
+			if o.UnitID != unitID {
+				continue
+			}
+			if to, err := s.r.GetUnit(ctx, o.ToUnitID); err == nil && to.Dimension != nil {
+				dim = to.Dimension
+				break
+			}
+		}
+	}
+	if dim == nil {
+		return u, nil
+	}
+	base, err := s.r.GetBaseUnit(ctx, *dim)
+	if err != nil {
+		return nil, fmt.Errorf("量纲 %s 未设置基准单位: %w", *dim, err)
+	}
+	return base, nil
+}
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/dict"
+	organ "hdzk.cn/foodapp/internal/domain/organ"
+)
+
+// SetMealTimeWindow 设置餐次默认供餐时段（start/end 同时为空表示清除）。
+// 默认时段对未覆盖该餐次的机构生效，需与默认时段及这些机构的生效时段均不重叠
+func (s *Service) SetMealTimeWindow(ctx context.Context, id string, start, end *string) error {
+	start, end = normalizeClockPtr(start), normalizeClockPtr(end)
+	if (start == nil) != (end == nil) {
+		return errors.New("start_time 与 end_time 需同时设置或同时清空")
+	}
+	if start != nil {
+		if err := validateWindow(*start, *end); err != nil {
+			return err
+		}
+		meals, err := s.r.ListAllMealTimes(ctx)
+		if err != nil {
+			return err
+		}
+		overrides, err := s.r.ListAllMealOrgWindows(ctx)
+		if err != nil {
+			return err
+		}
+		byOrg := map[string][]domain.MealOrgWindow{}
+		for _, o := range overrides {
+			byOrg[o.OrgID] = append(byOrg[o.OrgID], o)
+		}
+		candidate := domain.MealWindow{MealID: id, StartTime: *start, EndTime: *end}
+		if err := checkOverlap(effectiveWindows(meals, nil), candidate, ""); err != nil {
+			return err
+		}
+		for orgID, list := range byOrg {
+			if err := checkOverlap(effectiveWindows(meals, list), candidate, orgID); err != nil {
+				return err
+			}
+		}
+	}
+	return s.r.SetMealTimeWindow(ctx, id, start, end)
+}
+
+// checkOverlap 默认时段候选值与某机构（orgID 为空表示默认时段）生效时段的重叠校验；机构已覆盖该餐次时不受影响
+func checkOverlap(windows []domain.MealWindow, candidate domain.MealWindow, orgID string) error {
+	for _, w := range windows {
+		if w.MealID != candidate.MealID || !w.OrgOverride {
+			continue
+		}
+		return nil
+	}
+	for _, w := range windows {
+		if w.MealID == candidate.MealID || !domain.Overlaps(w, candidate) {
+			continue
+		}
+		if orgID == "" {
+			return fmt.Errorf("时段与餐次「%s」(%s-%s) 重叠", w.MealName, w.StartTime, w.EndTime)
+		}
+		return fmt.Errorf("时段与机构 %s 的餐次「%s」(%s-%s) 重叠", orgID, w.MealName, w.StartTime, w.EndTime)
+	}
+	return nil
+}
+
+// SetOrgMealWindow 设置机构级供餐时段，覆盖默认值；与该机构其它生效时段不得重叠
+func (s *Service) SetOrgMealWindow(ctx context.Context, orgID, mealID, start, end string) (*domain.MealOrgWindow, error) {
+	orgID, mealID = strings.TrimSpace(orgID), strings.TrimSpace(mealID)
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	if err := validateWindow(start, end); err != nil {
+		return nil, err
+	}
+	meal, err := s.r.GetMealTime(ctx, mealID)
+	if err != nil {
+		return nil, fmt.Errorf("餐次不存在: %w", err)
+	}
+	windows, err := s.EffectiveMealWindows(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	candidate := domain.MealWindow{MealID: meal.ID, MealName: meal.Name, StartTime: start, EndTime: end}
+	for _, w := range windows {
+		if w.MealID != meal.ID && domain.Overlaps(w, candidate) {
+			return nil, fmt.Errorf("时段与餐次「%s」(%s-%s) 重叠", w.MealName, w.StartTime, w.EndTime)
+		}
+	}
+	m := &domain.MealOrgWindow{OrgID: orgID, MealID: meal.ID, StartTime: start, EndTime: end}
+	return m, s.r.UpsertMealOrgWindow(ctx, m)
+}
+
+// DeleteOrgMealWindow 删除机构覆盖，恢复使用餐次默认时段
+func (s *Service) DeleteOrgMealWindow(ctx context.Context, orgID, mealID string) error {
+	return s.r.DeleteMealOrgWindow(ctx, strings.TrimSpace(orgID), strings.TrimSpace(mealID))
+}
+
+// EffectiveMealWindows 返回机构实际生效的供餐时段，未配置时段的餐次不参与归属
+func (s *Service) EffectiveMealWindows(ctx context.Context, orgID string) ([]domain.MealWindow, error) {
+	meals, err := s.r.ListAllMealTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	overrides, err := s.r.ListMealOrgWindows(ctx, strings.TrimSpace(orgID))
+	if err != nil {
+		return nil, err
+	}
+	return effectiveWindows(meals, overrides), nil
+}
+
+// effectiveWindows 餐次默认时段叠加机构覆盖
+func effectiveWindows(meals []domain.MealTime, overrides []domain.MealOrgWindow) []domain.MealWindow {
+	byMeal := make(map[string]domain.MealOrgWindow, len(overrides))
+	for _, o := range overrides {
+		byMeal[o.MealID] = o
+	}
+
+	out := make([]domain.MealWindow, 0, len(meals))
+	for _, m := range meals {
+		w := domain.MealWindow{MealID: m.ID, MealName: m.Name}
+		if o, ok := byMeal[m.ID]; ok {
+			w.StartTime, w.EndTime, w.OrgOverride = o.StartTime, o.EndTime, true
+		} else if m.StartTime != nil && m.EndTime != nil {
+			w.StartTime, w.EndTime = *m.StartTime, *m.EndTime
+		} else {
+			continue
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// OrgLocation 机构时区，供餐时段按该时区的当地时刻判断
+func (s *Service) OrgLocation(ctx context.Context, orgID string) (*time.Location, error) {
+	tz, err := s.r.OrgTimeZone(ctx, strings.TrimSpace(orgID))
+	if err != nil {
+		return nil, fmt.Errorf("机构不存在: %w", err)
+	}
+	return organ.Location(tz)
+}
+
+// ResolveMeal 按机构生效时段（机构时区）判断 at 所属餐次；不在任何时段内时返回 nil
+func (s *Service) ResolveMeal(ctx context.Context, orgID string, at time.Time) (*domain.MealWindow, time.Time, error) {
+	windows, err := s.EffectiveMealWindows(ctx, orgID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	loc, err := s.OrgLocation(ctx, orgID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	w, day, ok := domain.Attribute(windows, at, loc)
+	if !ok {
+		return nil, day, nil
+	}
+	return w, day, nil
+}
+
+func validateWindow(start, end string) error {
+	s, err := domain.ParseClock(start)
+	if err != nil {
+		return err
+	}
+	e, err := domain.ParseClock(end)
+	if err != nil {
+		return err
+	}
+	if s == e {
+		return errors.New("开始时刻与结束时刻不能相同")
+	}
+	return nil
+}
+
+func normalizeClockPtr(p *string) *string {
+	if p == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*p)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -27,9 +27,9 @@ func (s *Service) GetUnit(ctx context.Context, id string) (*domain.Unit, error)
 func (s *Service) ListUnits(ctx context.Context, keyword string, page, pageSize int) ([]domain.Unit, int64, error) {
 	return s.r.ListUnits(ctx, keyword, page, pageSize)
 }
-func (s *Service) UpdateUnit(ctx context.Context, id, name string, code *string, sort int) error {
+func (s *Service) UpdateUnit(ctx context.Context, id string, version int, name string, code *string, sort int) error {
 	normalizedCode, updateCode := normalizeCode(code)
-	return s.r.UpdateUnit(ctx, id, name, normalizedCode, sort, updateCode)
+	return s.r.UpdateUnit(ctx, id, version, name, normalizedCode, sort, updateCode)
 }
 func (s *Service) DeleteUnit(ctx context.Context, id string) error {
 	return s.r.DeleteUnit(ctx, id)
@@ -47,9 +47,9 @@ func (s *Service) GetSpec(ctx context.Context, id string) (*domain.Spec, error)
 func (s *Service) ListSpecs(ctx context.Context, keyword string, page, pageSize int) ([]domain.Spec, int64, error) {
 	return s.r.ListSpecs(ctx, keyword, page, pageSize)
 }
-func (s *Service) UpdateSpec(ctx context.Context, id, name string, code *string, sort int) error {
+func (s *Service) UpdateSpec(ctx context.Context, id string, version int, name string, code *string, sort int) error {
 	normalizedCode, updateCode := normalizeCode(code)
-	return s.r.UpdateSpec(ctx, id, name, normalizedCode, sort, updateCode)
+	return s.r.UpdateSpec(ctx, id, version, name, normalizedCode, sort, updateCode)
 }
 func (s *Service) DeleteSpec(ctx context.Context, id string) error {
 	return s.r.DeleteSpec(ctx, id)
@@ -67,14 +67,34 @@ func (s *Service) GetMealTime(ctx context.Context, id string) (*domain.MealTime,
 func (s *Service) ListMealTimes(ctx context.Context, keyword string, page, pageSize int) ([]domain.MealTime, int64, error) {
 	return s.r.ListMealTimes(ctx, keyword, page, pageSize)
 }
-func (s *Service) UpdateMealTime(ctx context.Context, id, name string, code *string, sort int) error {
+func (s *Service) UpdateMealTime(ctx context.Context, id string, version int, name string, code *string, sort int) error {
 	normalizedCode, updateCode := normalizeCode(code)
-	return s.r.UpdateMealTime(ctx, id, name, normalizedCode, sort, updateCode)
+	return s.r.UpdateMealTime(ctx, id, version, name, normalizedCode, sort, updateCode)
 }
 func (s *Service) DeleteMealTime(ctx context.Context, id string) error {
 	return s.r.DeleteMealTime(ctx, id)
 }
 
+// WasteReason
+func (s *Service) CreateWasteReason(ctx context.Context, name string, code *string, sort int) (*domain.WasteReason, error) {
+	normalizedCode, _ := normalizeCode(code)
+	m := &domain.WasteReason{ID: uuid.NewString(), Name: name, Sort: sort, Code: normalizedCode}
+	return m, s.r.CreateWasteReason(ctx, m)
+}
+func (s *Service) GetWasteReason(ctx context.Context, id string) (*domain.WasteReason, error) {
+	return s.r.GetWasteReason(ctx, id)
+}
+func (s *Service) ListWasteReasons(ctx context.Context, keyword string, page, pageSize int) ([]domain.WasteReason, int64, error) {
+	return s.r.ListWasteReasons(ctx, keyword, page, pageSize)
+}
+func (s *Service) UpdateWasteReason(ctx context.Context, id string, version int, name string, code *string, sort int) error {
+	normalizedCode, updateCode := normalizeCode(code)
+	return s.r.UpdateWasteReason(ctx, id, version, name, normalizedCode, sort, updateCode)
+}
+func (s *Service) DeleteWasteReason(ctx context.Context, id string) error {
+	return s.r.DeleteWasteReason(ctx, id)
+}
+
 func normalizeCode(code *string) (*string, bool) {
 	if code == nil {
 		return nil, false
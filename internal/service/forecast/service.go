@@ -0,0 +1,399 @@
+package forecast
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/forecast"
+	price "hdzk.cn/foodapp/internal/domain/price"
+	purchase "hdzk.cn/foodapp/internal/domain/purchase"
+	recipe "hdzk.cn/foodapp/internal/domain/recipe"
+	goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
+	pricerepo "hdzk.cn/foodapp/internal/repository/price"
+	weighingrepo "hdzk.cn/foodapp/internal/repository/weighing"
+	dictsvc "hdzk.cn/foodapp/internal/service/dict"
+	purchasesvc "hdzk.cn/foodapp/internal/service/purchase"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// DemandExpander 餐次计划展开为商品需求（由配方服务实现）
+type DemandExpander interface {
+	ExpandPlans(ctx context.Context, orgID string, mealID *string, from, to time.Time) ([]recipe.Demand, []string, error)
+}
+
+// StockSource 当前库存（按商品单位）；未接入库存时可为 nil
+type StockSource interface {
+	OnHand(ctx context.Context, orgID string, goodsIDs []string) (map[string]decimal.Decimal, error)
+}
+
+type Service struct {
+	weighings weighingrepo.Repository
+	prices    pricerepo.Repository
+	goods     goodsrepo.GoodsRepository
+	demand    DemandExpander
+	units     dictsvc.UnitConverter
+	purchases *purchasesvc.Service
+	stock     StockSource
+	tx        utils.Transactor
+}
+
+func NewService(
+	weighings weighingrepo.Repository,
+	prices pricerepo.Repository,
+	goods goodsrepo.GoodsRepository,
+	demand DemandExpander,
+	units dictsvc.UnitConverter,
+	purchases *purchasesvc.Service,
+	stock StockSource,
+	tx utils.Transactor,
+) *Service {
+	return &Service{
+		weighings: weighings,
+		prices:    prices,
+		goods:     goods,
+		demand:    demand,
+		units:     units,
+		purchases: purchases,
+		stock:     stock,
+		tx:        tx,
+	}
+}
+
+type Params struct {
+	OrgID      string
+	From       time.Time // 预测起始日（含），为空取明天
+	Days       int       // 预测天数 1~60
+	Method     string    // sma / ses，默认 sma
+	Window     int       // 历史天数，默认 28
+	Alpha      *decimal.Decimal
+	SafetyRate *decimal.Decimal // 安全系数，默认 0
+	Stock      map[string]decimal.Decimal
+	GoodsIDs   []string // 仅预测这些商品（为空不限）
+}
+
+const (
+	defaultWindow = 28
+	maxWindow     = 365
+	maxDays       = 60
+)
+
+var defaultAlpha = decimal.NewFromFloat(0.3)
+
+// Suggest 计算采购建议，不落库
+func (s *Service) Suggest(ctx context.Context, p Params) (*domain.Result, error) {
+	if err := normalizeParams(&p); err != nil {
+		return nil, err
+	}
+	to := p.From.AddDate(0, 0, p.Days-1)
+	histFrom := p.From.AddDate(0, 0, -p.Window)
+	histTo := p.From.AddDate(0, 0, -1)
+	res := &domain.Result{OrgID: p.OrgID, From: p.From, Days: p.Days}
+
+	// 1) 餐次计划需求
+	demands, warns, err := s.demand.ExpandPlans(ctx, p.OrgID, nil, p.From, to)
+	if err != nil {
+		return nil, err
+	}
+	res.Warnings = append(res.Warnings, warns...)
+
+	// 2) 历史称重消耗
+	daily, err := s.weighings.DailyConsumption(ctx, p.OrgID, histFrom, histTo)
+	if err != nil {
+		return nil, err
+	}
+
+	goodsSet := map[string]struct{}{}
+	for _, d := range demands {
+		goodsSet[d.GoodsID] = struct{}{}
+	}
+	for _, d := range daily {
+		goodsSet[d.GoodsID] = struct{}{}
+	}
+	if len(p.GoodsIDs) > 0 {
+		filter := map[string]struct{}{}
+		for _, id := range p.GoodsIDs {
+			filter[id] = struct{}{}
+		}
+		for id := range goodsSet {
+			if _, ok := filter[id]; !ok {
+				delete(goodsSet, id)
+			}
+		}
+		for id := range filter {
+			goodsSet[id] = struct{}{}
+		}
+	}
+	goodsIDs := make([]string, 0, len(goodsSet))
+	for id := range goodsSet {
+		goodsIDs = append(goodsIDs, id)
+	}
+	sort.Strings(goodsIDs)
+	if len(goodsIDs) == 0 {
+		res.Warnings = append(res.Warnings, "预测期内无餐次计划，且历史期内无称重记录")
+		return res, nil
+	}
+
+	goodsUnits, err := s.goods.GoodsUnits(ctx, goodsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := map[string]decimal.Decimal{}
+	for _, d := range demands {
+		if _, ok := goodsSet[d.GoodsID]; !ok {
+			continue
+		}
+		gid := d.GoodsID
+		qty := d.Quantity
+		if gu := goodsUnits[gid]; gu != d.UnitID {
+			conv, err := s.units.ConvertQuantity(ctx, qty, d.UnitID, gu, &gid)
+			if err != nil {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("商品 %s 的计划需求无法换算到商品单位，未计入", gid))
+				continue
+			}
+			qty = conv
+		}
+		planned[gid] = planned[gid].Add(qty)
+	}
+
+	history := map[string][]decimal.Decimal{}
+	for _, id := range goodsIDs {
+		history[id] = make([]decimal.Decimal, p.Window)
+	}
+	for _, d := range daily {
+		series, ok := history[d.GoodsID]
+		if !ok {
+			continue
+		}
+		idx := int(utils.DateOf(d.Day).Sub(histFrom).Hours() / 24)
+		if idx < 0 || idx >= p.Window {
+			continue
+		}
+		gid := d.GoodsID
+		qty := d.Qty
+		if gu := goodsUnits[gid]; gu != "" && gu != d.UnitID {
+			conv, err := s.units.ConvertQuantity(ctx, qty, d.UnitID, gu, &gid)
+			if err != nil {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("商品 %s 的部分称重记录无法换算到商品单位，未计入基线", gid))
+				continue
+			}
+			qty = conv
+		}
+		series[idx] = series[idx].Add(qty)
+	}
+
+	// 3) 库存
+	stock, stockSource, err := s.onHand(ctx, p, goodsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4) 报价
+	quotes, err := s.prices.ActiveQuotes(ctx, p.OrgID, goodsIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	days := decimal.NewFromInt(int64(p.Days))
+	one := decimal.NewFromInt(1)
+	for _, gid := range goodsIDs {
+		sg := domain.Suggestion{GoodsID: gid, UnitID: goodsUnits[gid], Planned: planned[gid].Round(3)}
+
+		bl := &domain.Baseline{Method: p.Method, Window: p.Window, History: history[gid], HistoryFrom: histFrom}
+		switch p.Method {
+		case domain.MethodExpSmoothing:
+			bl.Alpha = p.Alpha
+			bl.Daily = domain.ExpSmoothing(bl.History, *p.Alpha)
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("历史基线：%s 起 %d 天称重消耗，指数平滑(α=%s) 日均 %s",
+				histFrom.Format("2006-01-02"), p.Window, p.Alpha.String(), bl.Daily.String()))
+		default:
+			bl.Daily = domain.MovingAverage(bl.History)
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("历史基线：%s 起 %d 天称重消耗，移动平均日均 %s",
+				histFrom.Format("2006-01-02"), p.Window, bl.Daily.String()))
+		}
+		bl.Total = bl.Daily.Mul(days).Round(3)
+		sg.Baseline = bl
+		sg.Explanation = append(sg.Explanation, fmt.Sprintf("基线预测 %d 天：%s × %d = %s", p.Days, bl.Daily.String(), p.Days, bl.Total.String()))
+		sg.Explanation = append(sg.Explanation, fmt.Sprintf("餐次计划展开需求：%s", sg.Planned.String()))
+
+		base := sg.Planned
+		if bl.Total.GreaterThan(base) {
+			base = bl.Total
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("取计划需求与基线的较大值：基线 %s", base.String()))
+		} else {
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("取计划需求与基线的较大值：计划 %s", base.String()))
+		}
+		sg.Demand = base
+		if p.SafetyRate.IsPositive() {
+			sg.Demand = base.Mul(one.Add(*p.SafetyRate)).Round(3)
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("安全系数 %s：%s × %s = %s",
+				p.SafetyRate.String(), base.String(), one.Add(*p.SafetyRate).String(), sg.Demand.String()))
+		}
+
+		sg.NetQty = sg.Demand
+		if q, ok := stock[gid]; ok {
+			sg.Stock, sg.StockSource = &q, stockSource
+			sg.NetQty = decimal.Max(sg.Demand.Sub(q), decimal.Zero)
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("扣减当前库存 %s（来源：%s）：建议采购 %s", q.String(), stockLabel(stockSource), sg.NetQty.String()))
+		} else {
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("库存未知，未扣减：建议采购 %s", sg.NetQty.String()))
+		}
+
+		if q := cheapest(quotes[gid]); q != nil {
+			settle := q.SettlePrice()
+			amount := sg.NetQty.Mul(settle).Round(2)
+			sg.Quote, sg.SettlePrice, sg.Amount = q, &settle, &amount
+			sg.Explanation = append(sg.Explanation, fmt.Sprintf("供应商「%s」报价最低：单价 %s × 浮动比例 %s = 结算价 %s（询价「%s」%s），金额 %s",
+				q.SupplierName, q.UnitPrice.String(), q.FloatRatio.String(), settle.String(),
+				q.InquiryTitle, q.InquiryDate.Format("2006-01-02"), amount.String()))
+		} else {
+			sg.Explanation = append(sg.Explanation, "无有效供应商报价，未分配供应商")
+		}
+		res.Suggestions = append(res.Suggestions, sg)
+	}
+	return res, nil
+}
+
+// GenerateDrafts 计算建议并按供应商生成草稿采购单；无报价或建议量为 0 的商品不生成
+func (s *Service) GenerateDrafts(ctx context.Context, p Params, operatorID *string) (*domain.Result, []*purchase.Order, error) {
+	res, err := s.Suggest(ctx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bySupplier := map[string][]purchasesvc.LineParams{}
+	var suppliers []string
+	for _, sg := range res.Suggestions {
+		if !sg.NetQty.IsPositive() {
+			continue
+		}
+		if sg.Quote == nil {
+			res.Warnings = append(res.Warnings, fmt.Sprintf("商品 %s 无有效报价，未生成采购明细", sg.GoodsID))
+			continue
+		}
+		sid := sg.Quote.SupplierID
+		if _, ok := bySupplier[sid]; !ok {
+			suppliers = append(suppliers, sid)
+		}
+		inquiryID := sg.Quote.InquiryID
+		explain := strings.Join(sg.Explanation, "\n")
+		// 浮动比例不取报价快照，由采购单按到货日期生效的比例结算
+		bySupplier[sid] = append(bySupplier[sid], purchasesvc.LineParams{
+			GoodsID:     sg.GoodsID,
+			UnitID:      sg.UnitID,
+			Quantity:    sg.NetQty,
+			UnitPrice:   sg.Quote.UnitPrice,
+			InquiryID:   &inquiryID,
+			Explanation: &explain,
+		})
+	}
+
+	remark := fmt.Sprintf("需求预测生成：%s 起 %d 天", res.From.Format("2006-01-02"), res.Days)
+	// 各供应商草稿同事务生成：任一失败则全部不生成，避免重试时重复下单
+	var orders []*purchase.Order
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		orders = make([]*purchase.Order, 0, len(suppliers))
+		for _, sid := range suppliers {
+			o, err := s.purchases.Create(ctx, purchasesvc.CreateParams{
+				OrgID:        res.OrgID,
+				SupplierID:   sid,
+				ExpectedDate: res.From,
+				Source:       purchase.SourceForecast,
+				Remark:       &remark,
+				OperatorID:   operatorID,
+				Lines:        bySupplier[sid],
+			})
+			if err != nil {
+				return err
+			}
+			orders = append(orders, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return res, nil, err
+	}
+	return res, orders, nil
+}
+
+func (s *Service) onHand(ctx context.Context, p Params, goodsIDs []string) (map[string]decimal.Decimal, string, error) {
+	if len(p.Stock) > 0 {
+		return p.Stock, domain.StockRequest, nil
+	}
+	if s.stock == nil {
+		return map[string]decimal.Decimal{}, domain.StockUnknown, nil
+	}
+	m, err := s.stock.OnHand(ctx, p.OrgID, goodsIDs)
+	if err != nil {
+		return nil, "", err
+	}
+	return m, domain.StockLedger, nil
+}
+
+// cheapest 结算价最低的报价；同价取较新的询价（入参已按询价日期倒序）
+func cheapest(quotes []price.Quote) *price.Quote {
+	var best *price.Quote
+	for i := range quotes {
+		if best == nil || quotes[i].SettlePrice().LessThan(best.SettlePrice()) {
+			best = &quotes[i]
+		}
+	}
+	return best
+}
+
+func normalizeParams(p *Params) error {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return fmt.Errorf("org_id 不能为空")
+	}
+	if p.From.IsZero() {
+		p.From = utils.DateOf(time.Now()).AddDate(0, 0, 1)
+	} else {
+		p.From = utils.DateOf(p.From)
+	}
+	if p.Days <= 0 || p.Days > maxDays {
+		return fmt.Errorf("days 须在 1~%d 之间", maxDays)
+	}
+	if p.Window <= 0 {
+		p.Window = defaultWindow
+	}
+	if p.Window > maxWindow {
+		return fmt.Errorf("window 不能超过 %d 天", maxWindow)
+	}
+	switch p.Method {
+	case "":
+		p.Method = domain.MethodMovingAverage
+	case domain.MethodMovingAverage, domain.MethodExpSmoothing:
+	default:
+		return fmt.Errorf("method 仅支持 sma / ses")
+	}
+	if p.Alpha == nil {
+		alpha := defaultAlpha // 结果中回显 alpha，每次取副本，不共享包级变量
+		p.Alpha = &alpha
+	}
+	if !p.Alpha.IsPositive() || p.Alpha.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("alpha 须在 (0,1] 之间")
+	}
+	if p.SafetyRate == nil {
+		zero := decimal.Zero
+		p.SafetyRate = &zero
+	}
+	if p.SafetyRate.IsNegative() || p.SafetyRate.GreaterThan(decimal.NewFromInt(1)) {
+		return fmt.Errorf("safety_rate 须在 [0,1] 之间")
+	}
+	return nil
+}
+
+func stockLabel(src string) string {
+	switch src {
+	case domain.StockRequest:
+		return "手工录入"
+	case domain.StockLedger:
+		return "库存台账"
+	}
+	return "未知"
+}
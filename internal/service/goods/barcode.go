@@ -0,0 +1,258 @@
+package goods
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	domain "hdzk.cn/foodapp/internal/domain/goods"
+	repo "hdzk.cn/foodapp/internal/repository/goods"
+)
+
+// 标签图片格式
+const (
+	LabelCode128 = "code128"
+	LabelQR      = "qr"
+)
+
+// 扫码命中方式
+const (
+	MatchBarcode = "barcode" // 商品条码（EAN/UPC）
+	MatchCode    = "code"    // 商品编码（内部 SKU 标签）
+)
+
+const maxLabelSide = 2000
+
+type BarcodeCreateParams struct {
+	GoodsID   string
+	Barcode   string
+	UnitID    *string          // 为空取商品单位
+	PackQty   *decimal.Decimal // 为空取 1
+	IsPrimary bool
+	Remark    *string
+}
+
+type BarcodeUpdateParams struct {
+	ID        string
+	UnitID    *string
+	PackQty   *decimal.Decimal
+	IsPrimary *bool
+	Remark    *string
+}
+
+type ScanResult struct {
+	MatchedBy string          `json:"matched_by"`
+	Goods     *domain.Goods   `json:"goods"`
+	Barcode   *domain.Barcode `json:"barcode,omitempty"` // 按条码命中时的包装信息
+}
+
+type LabelParams struct {
+	GoodsID   string
+	Format    string
+	BarcodeID *string // 为空时编码商品 code
+	Width     int     // 0=默认（code128 每模块 2 像素，qr 每模块 8 像素）
+	Height    int     // code128 条高，0=80；qr 忽略
+}
+
+func (s *Service) CreateBarcode(ctx context.Context, p BarcodeCreateParams) (*domain.Barcode, error) {
+	g, err := s.r.GetGoods(ctx, strings.TrimSpace(p.GoodsID))
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %w", err)
+	}
+	code, symbology, err := domain.NormalizeBarcode(p.Barcode)
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.Barcode{
+		OrgID:     g.OrgID,
+		Barcode:   code,
+		Symbology: symbology,
+		GoodsID:   g.ID,
+		UnitID:    g.UnitID,
+		PackQty:   decimal.NewFromInt(1),
+	}
+	if unitID, _ := normalizeOptional(p.UnitID); unitID != nil {
+		m.UnitID = *unitID
+	}
+	if p.PackQty != nil {
+		if !p.PackQty.IsPositive() {
+			return nil, errors.New("pack_qty 须大于 0")
+		}
+		m.PackQty = *p.PackQty
+	}
+	if p.IsPrimary {
+		m.IsPrimary = 1
+	}
+	m.Remark, _ = normalizeOptional(p.Remark)
+	return m, s.r.CreateBarcode(ctx, m)
+}
+
+func (s *Service) ListBarcodes(ctx context.Context, goodsID string) ([]domain.Barcode, error) {
+	return s.r.ListBarcodes(ctx, strings.TrimSpace(goodsID))
+}
+
+func (s *Service) UpdateBarcode(ctx context.Context, p BarcodeUpdateParams) error {
+	if p.PackQty != nil && !p.PackQty.IsPositive() {
+		return errors.New("pack_qty 须大于 0")
+	}
+	unitID, err := normalizeOptionalRequired(p.UnitID, "unit_id")
+	if err != nil {
+		return err
+	}
+	remark, updateRemark := normalizeOptional(p.Remark)
+	params := repo.BarcodeUpdateParams{
+		ID:           strings.TrimSpace(p.ID),
+		UnitID:       unitID,
+		PackQty:      p.PackQty,
+		Remark:       remark,
+		UpdateRemark: updateRemark,
+	}
+	if p.IsPrimary != nil {
+		v := 0
+		if *p.IsPrimary {
+			v = 1
+		}
+		params.IsPrimary = &v
+	}
+	return s.r.UpdateBarcode(ctx, params)
+}
+
+func (s *Service) DeleteBarcode(ctx context.Context, id string) error {
+	return s.r.DeleteBarcode(ctx, strings.TrimSpace(id))
+}
+
+// Scan 扫码查商品：先按 GS1 条码（校验通过并规范化后）查，未命中再按商品编码查；
+// 均未命中返回 gorm.ErrRecordNotFound
+func (s *Service) Scan(ctx context.Context, orgID, raw string) (*ScanResult, error) {
+	orgID, raw = strings.TrimSpace(orgID), strings.TrimSpace(raw)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if raw == "" {
+		return nil, errors.New("条码不能为空")
+	}
+	if code, _, err := domain.NormalizeBarcode(raw); err == nil {
+		g, b, err := s.r.FindByBarcode(ctx, orgID, code)
+		if err == nil {
+			return &ScanResult{MatchedBy: MatchBarcode, Goods: g, Barcode: b}, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	g, err := s.r.FindByCode(ctx, orgID, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &ScanResult{MatchedBy: MatchCode, Goods: g}, nil
+}
+
+// Label 生成商品标签 PNG：code128 一维码或 QR 二维码，四周留静区
+func (s *Service) Label(ctx context.Context, p LabelParams) ([]byte, error) {
+	g, err := s.r.GetGoods(ctx, strings.TrimSpace(p.GoodsID))
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %w", err)
+	}
+	content := ""
+	if g.Code != nil {
+		content = *g.Code
+	}
+	if id, _ := normalizeOptional(p.BarcodeID); id != nil {
+		b, err := s.r.GetBarcode(ctx, *id)
+		if err != nil || b.GoodsID != g.ID {
+			return nil, errors.New("条码不存在或不属于该商品")
+		}
+		content = b.Barcode
+	}
+	if content == "" {
+		return nil, errors.New("商品无编码，无法生成标签")
+	}
+	if p.Width < 0 || p.Width > maxLabelSide || p.Height < 0 || p.Height > maxLabelSide {
+		return nil, fmt.Errorf("图片尺寸须在 0~%d 像素之间", maxLabelSide)
+	}
+
+	var img image.Image
+	switch p.Format {
+	case LabelCode128:
+		img, err = renderCode128(content, p.Width, p.Height)
+	case LabelQR:
+		img, err = renderQR(content, p.Width)
+	default:
+		return nil, fmt.Errorf("不支持的标签格式: %s", p.Format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderCode128(content string, width, height int) (image.Image, error) {
+	for _, r := range content {
+		if r > 127 {
+			return nil, fmt.Errorf("Code128 仅支持 ASCII 字符: %s", content)
+		}
+	}
+	bc, err := code128.Encode(content)
+	if err != nil {
+		return nil, fmt.Errorf("生成 Code128 失败: %w", err)
+	}
+	if height == 0 {
+		height = 80
+	}
+	// 左右静区各 10 个模块
+	return scaleWithQuietZone(bc, width, height, 10, 0, 2)
+}
+
+func renderQR(content string, width int) (image.Image, error) {
+	bc, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return nil, fmt.Errorf("生成二维码失败: %w", err)
+	}
+	// 四周静区各 4 个模块
+	return scaleWithQuietZone(bc, width, 0, 4, 4, 8)
+}
+
+// scaleWithQuietZone 按整数倍放大（不小于 1 倍，width 为 0 时取 defaultFactor 倍）并在白底上留出静区；
+// height 为 0 时按宽度等比（二维码）
+func scaleWithQuietZone(bc barcode.Barcode, width, height, quietX, quietY, defaultFactor int) (image.Image, error) {
+	modules := bc.Bounds().Dx()
+	factor := defaultFactor
+	if width > 0 {
+		factor = width / (modules + 2*quietX)
+	}
+	if factor < 1 {
+		factor = 1
+	}
+	w := modules * factor
+	h := height
+	if h == 0 {
+		h = bc.Bounds().Dy() * factor
+	}
+	scaled, err := barcode.Scale(bc, w, h)
+	if err != nil {
+		return nil, err
+	}
+	canvas := image.NewGray(image.Rect(0, 0, w+2*quietX*factor, h+2*quietY*factor))
+	draw.Draw(canvas, canvas.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	offset := image.Pt(quietX*factor, quietY*factor)
+	draw.Draw(canvas, scaled.Bounds().Add(offset), scaled, scaled.Bounds().Min, draw.Src)
+	if canvas.Bounds().Dx() > maxLabelSide || canvas.Bounds().Dy() > maxLabelSide {
+		return nil, fmt.Errorf("标签内容过长，生成的图片超过 %d 像素", maxLabelSide)
+	}
+	return canvas, nil
+}
@@ -0,0 +1,99 @@
+package goods
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	repo "hdzk.cn/foodapp/internal/repository/goods"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// BatchOp 批量中的单项操作：Op 决定使用哪组参数
+type BatchOp struct {
+	Op     string
+	Create CreateParams // create（OrgID 取批量的 org）
+	Update UpdateParams // update
+	ID     string       // delete
+	IDs    []string     // reorder：按期望顺序给出的商品ID
+}
+
+// Batch 在一个事务内执行 org 下商品的批量新增/更新/软删/重排，逐项返回结果；
+// mode 见 utils.BatchAtomic / utils.BatchBestEffort
+func (s *Service) Batch(ctx context.Context, orgID, mode string, ops []BatchOp) (*utils.BatchResult, error) {
+	orgID, err := normalizeRequiredValue(orgID, "org_id")
+	if err != nil {
+		return nil, err
+	}
+	if mode, err = utils.NormalizeBatchMode(mode); err != nil {
+		return nil, err
+	}
+	if len(ops) == 0 || len(ops) > utils.BatchMaxOps {
+		return nil, fmt.Errorf("ops 数量须在 1..%d 之间", utils.BatchMaxOps)
+	}
+	names := make([]string, len(ops))
+	for i, op := range ops {
+		names[i] = op.Op
+	}
+
+	var res *utils.BatchResult
+	err = s.r.InTx(ctx, func(tx repo.GoodsRepository) error {
+		ts := &Service{r: tx}
+		var err error
+		res, err = utils.RunBatch(mode, names,
+			func(fn func() error) error {
+				return tx.InTx(ctx, func(repo.GoodsRepository) error { return fn() })
+			},
+			func(i int) (string, error) { return ts.applyBatchOp(ctx, orgID, ops[i]) },
+		)
+		return err
+	})
+	if errors.Is(err, utils.ErrBatchAborted) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.Committed = true
+	return res, nil
+}
+
+func (s *Service) applyBatchOp(ctx context.Context, orgID string, op BatchOp) (string, error) {
+	switch op.Op {
+	case utils.BatchOpCreate:
+		p := op.Create
+		p.OrgID = orgID
+		m, err := s.CreateGoods(ctx, p)
+		if err != nil {
+			return "", err
+		}
+		return m.ID, nil
+	case utils.BatchOpUpdate:
+		id := strings.TrimSpace(op.Update.ID)
+		if err := s.ensureInOrg(ctx, orgID, id); err != nil {
+			return id, err
+		}
+		return id, s.UpdateGoods(ctx, op.Update)
+	case utils.BatchOpDelete:
+		id := strings.TrimSpace(op.ID)
+		if err := s.ensureInOrg(ctx, orgID, id); err != nil {
+			return id, err
+		}
+		return id, s.SoftDeleteGoods(ctx, id)
+	case utils.BatchOpReorder:
+		return "", s.r.ReorderGoods(ctx, orgID, op.IDs)
+	}
+	return "", fmt.Errorf("不支持的操作: %s", op.Op)
+}
+
+func (s *Service) ensureInOrg(ctx context.Context, orgID, id string) error {
+	m, err := s.r.GetGoods(ctx, id)
+	if err != nil {
+		return fmt.Errorf("商品不存在: %w", err)
+	}
+	if m.OrgID != orgID {
+		return errors.New("商品不属于该组织")
+	}
+	return nil
+}
@@ -31,6 +31,7 @@ type CreateParams struct {
 
 type UpdateParams struct {
 	ID          string
+	Version     int
 	Name        *string
 	Code        *string
 	Pinyin      *string
@@ -91,7 +92,7 @@ func (s *Service) GetGoods(ctx context.Context, id string) (*domain.Goods, error
 	return s.r.GetGoods(ctx, strings.TrimSpace(id))
 }
 
-func (s *Service) ListGoods(ctx context.Context, keyword string, orgID string, categoryID, specID, unitID *string, page, pageSize int) ([]domain.Goods, int64, error) {
+func (s *Service) ListGoods(ctx context.Context, keyword string, orgID string, categoryID, specID, unitID *string, includeSubCategories bool, page, pageSize int) ([]domain.Goods, int64, error) {
 	trimmedOrg := strings.TrimSpace(orgID)
 	if trimmedOrg == "" {
 		return nil, 0, fmt.Errorf("org_id 不能为空")
@@ -122,7 +123,7 @@ func (s *Service) ListGoods(ctx context.Context, keyword string, orgID string, c
 		unitPtr = normalized
 	}
 	kw := strings.TrimSpace(keyword)
-	return s.r.ListGoods(ctx, kw, trimmedOrg, categoryPtr, specPtr, unitPtr, page, pageSize)
+	return s.r.ListGoods(ctx, kw, trimmedOrg, categoryPtr, specPtr, unitPtr, includeSubCategories, page, pageSize)
 }
 
 func (s *Service) UpdateGoods(ctx context.Context, params UpdateParams) error {
@@ -148,6 +149,7 @@ func (s *Service) UpdateGoods(ctx context.Context, params UpdateParams) error {
 
 	repoParams := repo.UpdateParams{
 		ID:                strings.TrimSpace(params.ID),
+		Version:           params.Version,
 		Name:              normalizedName,
 		Code:              normalizedCode,
 		Pinyin:            normalizedPinyin,
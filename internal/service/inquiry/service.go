@@ -7,26 +7,53 @@ import (
 	"time"
 
 	domain "hdzk.cn/foodapp/internal/domain/inquiry"
+	market "hdzk.cn/foodapp/internal/domain/market"
+	price "hdzk.cn/foodapp/internal/domain/price"
 	repo "hdzk.cn/foodapp/internal/repository/inquiry"
+	utils "hdzk.cn/foodapp/pkg/utils"
 )
 
-type Service struct{ r repo.Repository }
+// PriceSource 询价明细与历史均价（由价格仓储实现）
+type PriceSource interface {
+	ListInquiryLines(ctx context.Context, inquiryID string) ([]price.InquiryLine, error)
+	LatestAvgPrices(ctx context.Context, orgID string, goodsIDs []string, asOf *time.Time) (map[string]price.Point, error)
+	BackfillLegacyPrices(ctx context.Context, inquiryID string, slots map[int]string) (int64, error)
+}
+
+// MarketSource 市场主数据（由市场仓储实现）
+type MarketSource interface {
+	GetMany(ctx context.Context, ids []string) (map[string]market.Market, error)
+	EnsureByNames(ctx context.Context, orgID string, names []string) ([]market.Market, error)
+}
+
+type Service struct {
+	r       repo.Repository
+	prices  PriceSource
+	markets MarketSource
+}
 
-func NewService(r repo.Repository) *Service { return &Service{r: r} }
+func NewService(r repo.Repository, prices PriceSource, markets MarketSource) *Service {
+	return &Service{r: r, prices: prices, markets: markets}
+}
 
+// CreateParams 参与市场取 MarketIDs；旧版 Market1..3 名称仍可用，按名称匹配（不存在则新建）机构市场后追加在其后
 type CreateParams struct {
 	OrgID        string
 	InquiryTitle string
 	InquiryDate  time.Time
+	MarketIDs    []string
 	Market1      *string
 	Market2      *string
 	Market3      *string
 }
 
+// UpdateParams MarketIDs 非 nil 时整体替换参与市场；否则旧版 MarketN 名称替换第 N 个市场
 type UpdateParams struct {
 	ID           string
+	Version      int
 	InquiryTitle *string
 	InquiryDate  *time.Time
+	MarketIDs    *[]string
 	Market1      *string
 	Market2      *string
 	Market3      *string
@@ -40,15 +67,32 @@ func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.PriceInqu
 		return nil, fmt.Errorf("inquiry_title 不能为空")
 	}
 
+	orgID := strings.TrimSpace(p.OrgID)
+	ids := append([]string(nil), p.MarketIDs...)
+	if names := legacyNames(p.Market1, p.Market2, p.Market3); len(names) > 0 {
+		list, err := s.markets.EnsureByNames(ctx, orgID, names)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range list {
+			ids = append(ids, m.ID)
+		}
+	}
+	markets, err := s.resolveMarkets(ctx, orgID, ids)
+	if err != nil {
+		return nil, err
+	}
+
 	m := &domain.PriceInquiry{
-		OrgID:        strings.TrimSpace(p.OrgID),
+		OrgID:        orgID,
 		InquiryTitle: strings.TrimSpace(p.InquiryTitle),
 		InquiryDate:  p.InquiryDate,
-		Market1:      normalizePtr(p.Market1),
-		Market2:      normalizePtr(p.Market2),
-		Market3:      normalizePtr(p.Market3),
+		Markets:      markets,
+	}
+	if err := s.r.Create(ctx, m); err != nil {
+		return nil, err
 	}
-	return m, s.r.Create(ctx, m)
+	return s.r.Get(ctx, m.ID)
 }
 
 func (s *Service) Get(ctx context.Context, id string) (*domain.PriceInquiry, error) {
@@ -67,11 +111,46 @@ func (s *Service) List(ctx context.Context, orgID string, keyword string, dateFr
 func (s *Service) Update(ctx context.Context, p UpdateParams) error {
 	rp := repo.UpdateParams{
 		ID:           strings.TrimSpace(p.ID),
-		InquiryTitle: normalizePtr(p.InquiryTitle),
+		Version:      p.Version,
+		InquiryTitle: utils.NormalizePtr(p.InquiryTitle),
 		InquiryDate:  p.InquiryDate,
-		Market1:      normalizePtr(p.Market1),
-		Market2:      normalizePtr(p.Market2),
-		Market3:      normalizePtr(p.Market3),
+	}
+	slots := []*string{utils.NormalizePtr(p.Market1), utils.NormalizePtr(p.Market2), utils.NormalizePtr(p.Market3)}
+	if p.MarketIDs == nil && slots[0] == nil && slots[1] == nil && slots[2] == nil {
+		return s.r.Update(ctx, rp)
+	}
+
+	cur, err := s.r.Get(ctx, rp.ID)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	if p.MarketIDs != nil {
+		ids = *p.MarketIDs
+	} else {
+		for _, m := range cur.Markets {
+			ids = append(ids, m.MarketID)
+		}
+		for i, name := range slots {
+			if name == nil {
+				continue
+			}
+			list, err := s.markets.EnsureByNames(ctx, cur.OrgID, []string{*name})
+			if err != nil {
+				return err
+			}
+			if i < len(ids) {
+				ids[i] = list[0].ID
+			} else {
+				ids = append(ids, list[0].ID)
+			}
+		}
+	}
+	if rp.Markets, err = s.resolveMarkets(ctx, cur.OrgID, ids); err != nil {
+		return err
+	}
+	if rp.Markets == nil {
+		rp.Markets = []domain.InquiryMarket{}
 	}
 	return s.r.Update(ctx, rp)
 }
@@ -84,14 +163,388 @@ func (s *Service) HardDelete(ctx context.Context, id string) error {
 	return s.r.HardDelete(ctx, strings.TrimSpace(id))
 }
 
-func normalizePtr(p *string) *string {
-	if p == nil {
-		return nil
+// TransitionParams 状态流转；审核、退回须填写意见，重新打开须填写原因
+type TransitionParams struct {
+	ID         string
+	OperatorID string
+	Comment    *string
+}
+
+// Submit 草稿 → 已提交
+func (s *Service) Submit(ctx context.Context, p TransitionParams) error {
+	return s.transition(ctx, p, domain.ActionSubmit, []int{domain.StatusDraft}, domain.StatusSubmitted, false)
+}
+
+// Approve 已提交 → 已审核（锁定）
+func (s *Service) Approve(ctx context.Context, p TransitionParams) error {
+	return s.transition(ctx, p, domain.ActionApprove, []int{domain.StatusSubmitted}, domain.StatusApproved, true)
+}
+
+// Reject 已提交 → 草稿
+func (s *Service) Reject(ctx context.Context, p TransitionParams) error {
+	return s.transition(ctx, p, domain.ActionReject, []int{domain.StatusSubmitted}, domain.StatusDraft, true)
+}
+
+// Archive 已审核 → 已归档
+func (s *Service) Archive(ctx context.Context, p TransitionParams) error {
+	return s.transition(ctx, p, domain.ActionArchive, []int{domain.StatusApproved}, domain.StatusArchived, false)
+}
+
+// Reopen 已审核/已归档 → 草稿，解除锁定
+func (s *Service) Reopen(ctx context.Context, p TransitionParams) error {
+	return s.transition(ctx, p, domain.ActionReopen, []int{domain.StatusApproved, domain.StatusArchived}, domain.StatusDraft, true)
+}
+
+func (s *Service) History(ctx context.Context, inquiryID string) ([]domain.History, error) {
+	return s.r.ListHistory(ctx, strings.TrimSpace(inquiryID))
+}
+
+func (s *Service) transition(ctx context.Context, p TransitionParams, action string, from []int, to int, needComment bool) error {
+	comment := utils.NormalizePtr(p.Comment)
+	if needComment && comment == nil {
+		return fmt.Errorf("%s 操作须填写意见或原因", action)
+	}
+	return s.r.Transition(ctx, strings.TrimSpace(p.ID), from, &domain.History{
+		Action:     action,
+		ToStatus:   to,
+		Comment:    comment,
+		OperatorID: p.OperatorID,
+	})
+}
+
+type CloneParams struct {
+	SourceID     string
+	InquiryTitle string
+	InquiryDate  time.Time
+	CarryPrices  bool // 以源询价单各商品均价（无均价时取其指导价）作为新单指导价
+}
+
+// Clone 复制询价单的市场与商品清单为新的草稿询价单（不复制市场价与供应商报价）
+func (s *Service) Clone(ctx context.Context, p CloneParams) (*domain.PriceInquiry, error) {
+	src, err := s.r.Get(ctx, strings.TrimSpace(p.SourceID))
+	if err != nil {
+		return nil, err
 	}
-	trimmed := strings.TrimSpace(*p)
-	if trimmed == "" {
-		return nil
+	title := strings.TrimSpace(p.InquiryTitle)
+	if title == "" {
+		return nil, fmt.Errorf("inquiry_title 不能为空")
+	}
+	srcLines, err := s.prices.ListInquiryLines(ctx, src.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]price.InquiryLine, 0, len(srcLines))
+	for _, l := range srcLines {
+		line := price.InquiryLine{GoodsID: l.GoodsID}
+		if p.CarryPrices {
+			line.GuidePrice = l.AvgPrice
+			if line.GuidePrice == nil {
+				line.GuidePrice = l.GuidePrice
+			}
+		}
+		lines = append(lines, line)
+	}
+	m := &domain.PriceInquiry{
+		OrgID:        src.OrgID,
+		InquiryTitle: title,
+		InquiryDate:  p.InquiryDate,
+		Status:       domain.StatusDraft,
+		Markets:      src.Markets,
+	}
+	if err := s.r.CreateWithLines(ctx, m, lines); err != nil {
+		return nil, err
+	}
+	return s.r.Get(ctx, m.ID)
+}
+
+type TemplateParams struct {
+	OrgID           string
+	Name            string
+	InquiryTitle    *string // 为空取模板名称
+	Remark          *string
+	MarketIDs       []string
+	GoodsIDs        []string
+	SourceInquiryID *string // 给出时从该询价单取市场（MarketIDs 为空时）与商品清单（GoodsIDs 为空时）
+}
+
+type TemplateUpdateParams struct {
+	ID           string
+	Version      int
+	Name         *string
+	InquiryTitle *string
+	Remark       *string
+	MarketIDs    *[]string // 非空时整体替换市场清单
+	GoodsIDs     *[]string // 非空时整体替换商品清单
+}
+
+type FromTemplateParams struct {
+	TemplateID   string
+	InquiryTitle *string // 为空取模板默认标题
+	InquiryDate  time.Time
+	CarryPrices  bool // 以机构内各商品截至询价日的最近均价作为指导价
+}
+
+func (s *Service) CreateTemplate(ctx context.Context, p TemplateParams) (*domain.Template, error) {
+	orgID, name := strings.TrimSpace(p.OrgID), strings.TrimSpace(p.Name)
+	if orgID == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("name 不能为空")
+	}
+	m := &domain.Template{
+		OrgID:  orgID,
+		Name:   name,
+		Remark: utils.NormalizePtr(p.Remark),
+	}
+	m.InquiryTitle = name
+	if t := utils.NormalizePtr(p.InquiryTitle); t != nil {
+		m.InquiryTitle = *t
+	}
+
+	goodsIDs, marketIDs := p.GoodsIDs, p.MarketIDs
+	if srcID := utils.NormalizePtr(p.SourceInquiryID); srcID != nil {
+		src, err := s.r.Get(ctx, *srcID)
+		if err != nil {
+			return nil, fmt.Errorf("源询价单不存在: %w", err)
+		}
+		if src.OrgID != orgID {
+			return nil, fmt.Errorf("源询价单不属于该机构")
+		}
+		if len(marketIDs) == 0 {
+			for _, im := range src.Markets {
+				marketIDs = append(marketIDs, im.MarketID)
+			}
+		}
+		if len(goodsIDs) == 0 {
+			lines, err := s.prices.ListInquiryLines(ctx, src.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, l := range lines {
+				goodsIDs = append(goodsIDs, l.GoodsID)
+			}
+		}
+	}
+	markets, err := s.resolveMarkets(ctx, orgID, marketIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, im := range markets {
+		m.Markets = append(m.Markets, domain.TemplateMarket{MarketID: im.MarketID, Sort: im.Sort})
+	}
+	m.Lines = templateLines(goodsIDs)
+	if err := s.r.CreateTemplate(ctx, m); err != nil {
+		return nil, err
+	}
+	return s.r.GetTemplate(ctx, m.ID)
+}
+
+func (s *Service) GetTemplate(ctx context.Context, id string) (*domain.Template, error) {
+	return s.r.GetTemplate(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) ListTemplates(ctx context.Context, orgID, keyword string, page, pageSize int) ([]domain.Template, int64, error) {
+	trimmedOrg := strings.TrimSpace(orgID)
+	if trimmedOrg == "" {
+		return nil, 0, fmt.Errorf("org_id 不能为空")
+	}
+	return s.r.ListTemplates(ctx, trimmedOrg, strings.TrimSpace(keyword), page, pageSize)
+}
+
+func (s *Service) UpdateTemplate(ctx context.Context, p TemplateUpdateParams) error {
+	rp := repo.TemplateUpdateParams{
+		ID:           strings.TrimSpace(p.ID),
+		Version:      p.Version,
+		Name:         utils.NormalizePtr(p.Name),
+		InquiryTitle: utils.NormalizePtr(p.InquiryTitle),
+		Remark:       utils.NormalizePtr(p.Remark),
+	}
+	if p.MarketIDs != nil {
+		t, err := s.r.GetTemplate(ctx, rp.ID)
+		if err != nil {
+			return err
+		}
+		markets, err := s.resolveMarkets(ctx, t.OrgID, *p.MarketIDs)
+		if err != nil {
+			return err
+		}
+		rp.Markets = []domain.TemplateMarket{}
+		for _, im := range markets {
+			rp.Markets = append(rp.Markets, domain.TemplateMarket{MarketID: im.MarketID, Sort: im.Sort})
+		}
+	}
+	if p.GoodsIDs != nil {
+		rp.Lines = templateLines(*p.GoodsIDs)
+	}
+	return s.r.UpdateTemplate(ctx, rp)
+}
+
+func (s *Service) SoftDeleteTemplate(ctx context.Context, id string) error {
+	return s.r.SoftDeleteTemplate(ctx, strings.TrimSpace(id))
+}
+
+// CreateFromTemplate 按模板生成草稿询价单
+func (s *Service) CreateFromTemplate(ctx context.Context, p FromTemplateParams) (*domain.PriceInquiry, error) {
+	t, err := s.r.GetTemplate(ctx, strings.TrimSpace(p.TemplateID))
+	if err != nil {
+		return nil, err
+	}
+	title := t.InquiryTitle
+	if v := utils.NormalizePtr(p.InquiryTitle); v != nil {
+		title = *v
+	}
+
+	var latest map[string]price.Point
+	if p.CarryPrices && len(t.Lines) > 0 {
+		goodsIDs := make([]string, len(t.Lines))
+		for i, l := range t.Lines {
+			goodsIDs[i] = l.GoodsID
+		}
+		if latest, err = s.prices.LatestAvgPrices(ctx, t.OrgID, goodsIDs, &p.InquiryDate); err != nil {
+			return nil, err
+		}
+	}
+	lines := make([]price.InquiryLine, 0, len(t.Lines))
+	for _, l := range t.Lines {
+		line := price.InquiryLine{GoodsID: l.GoodsID}
+		if pt, ok := latest[l.GoodsID]; ok {
+			v := pt.AvgPrice
+			line.GuidePrice = &v
+		}
+		lines = append(lines, line)
+	}
+	m := &domain.PriceInquiry{
+		OrgID:        t.OrgID,
+		InquiryTitle: title,
+		InquiryDate:  p.InquiryDate,
+		Status:       domain.StatusDraft,
+	}
+	for _, tm := range t.Markets {
+		m.Markets = append(m.Markets, domain.InquiryMarket{MarketID: tm.MarketID, Sort: tm.Sort})
+	}
+	if err := s.r.CreateWithLines(ctx, m, lines); err != nil {
+		return nil, err
+	}
+	return s.r.Get(ctx, m.ID)
+}
+
+// templateLines 去重（保留首次出现顺序）并按顺序编排序码
+func templateLines(goodsIDs []string) []domain.TemplateLine {
+	seen := make(map[string]bool, len(goodsIDs))
+	lines := make([]domain.TemplateLine, 0, len(goodsIDs))
+	for _, id := range goodsIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		lines = append(lines, domain.TemplateLine{GoodsID: id, Sort: len(lines) + 1})
+	}
+	return lines
+}
+
+// MigrateResult 旧版市场迁移结果
+type MigrateResult struct {
+	Inquiries int   `json:"inquiries"` // 完成迁移的询价单数
+	Markets   int   `json:"markets"`   // 涉及的市场数（含新建）
+	Prices    int64 `json:"prices"`    // 写入的市场价行数
+}
+
+// MigrateLegacyMarkets 将机构内询价单的旧版 market_1..3 名称映射为市场记录（按名称匹配，不存在则新建），
+// 建立询价单-市场关联，并把明细的 market1..3_price 写入对应市场的价格。可重复执行：已关联市场的询价单会跳过
+func (s *Service) MigrateLegacyMarkets(ctx context.Context, orgID string) (*MigrateResult, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	list, err := s.r.LegacyInquiries(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &MigrateResult{}
+	seen := map[string]bool{}
+	for _, inq := range list {
+		slots := map[int]string{}
+		var markets []domain.InquiryMarket
+		for i, name := range []*string{inq.Market1, inq.Market2, inq.Market3} {
+			name = utils.NormalizePtr(name)
+			if name == nil {
+				continue
+			}
+			ms, err := s.markets.EnsureByNames(ctx, orgID, []string{*name})
+			if err != nil {
+				return nil, err
+			}
+			id := ms[0].ID
+			slots[i+1] = id
+			if !seen[id] {
+				seen[id] = true
+				out.Markets++
+			}
+			dup := false
+			for _, im := range markets {
+				dup = dup || im.MarketID == id
+			}
+			if !dup {
+				markets = append(markets, domain.InquiryMarket{MarketID: id, Sort: i + 1})
+			}
+		}
+		// 先写价格再建关联：中途失败时询价单仍被视为未迁移，重跑时价格按唯一键跳过
+		n, err := s.prices.BackfillLegacyPrices(ctx, inq.ID, slots)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.r.AttachMarkets(ctx, inq.ID, markets); err != nil {
+			return nil, err
+		}
+		out.Inquiries++
+		out.Prices += n
+	}
+	return out, nil
+}
+
+// resolveMarkets 去重并校验市场属于该机构且有效，按顺序编排序码（1 起）
+func (s *Service) resolveMarkets(ctx context.Context, orgID string, ids []string) ([]domain.InquiryMarket, error) {
+	var uniq []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		uniq = append(uniq, id)
+	}
+	if len(uniq) == 0 {
+		return nil, nil
+	}
+	found, err := s.markets.GetMany(ctx, uniq)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.InquiryMarket, 0, len(uniq))
+	for i, id := range uniq {
+		m, ok := found[id]
+		if !ok || m.IsDeleted != 0 {
+			return nil, fmt.Errorf("市场不存在: %s", id)
+		}
+		if m.OrgID != orgID {
+			return nil, fmt.Errorf("市场 %s 不属于该机构", m.Name)
+		}
+		out = append(out, domain.InquiryMarket{MarketID: id, Sort: i + 1, Name: m.Name})
+	}
+	return out, nil
+}
+
+func legacyNames(ps ...*string) []string {
+	var out []string
+	for _, p := range ps {
+		if v := utils.NormalizePtr(p); v != nil {
+			out = append(out, *v)
+		}
 	}
-	v := trimmed
-	return &v
+	return out
 }
@@ -0,0 +1,387 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/inventory"
+	goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
+	repo "hdzk.cn/foodapp/internal/repository/inventory"
+	weighingrepo "hdzk.cn/foodapp/internal/repository/weighing"
+	dictsvc "hdzk.cn/foodapp/internal/service/dict"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type Service struct {
+	r         repo.Repository
+	goods     goodsrepo.GoodsRepository
+	weighings weighingrepo.Repository
+	units     dictsvc.UnitConverter
+}
+
+func NewService(r repo.Repository, goods goodsrepo.GoodsRepository, weighings weighingrepo.Repository, units dictsvc.UnitConverter) *Service {
+	return &Service{r: r, goods: goods, weighings: weighings, units: units}
+}
+
+// ErrInsufficient 出库后结存为负
+var ErrInsufficient = repo.ErrInsufficient
+
+type (
+	MovementParams = repo.MovementParams
+	BalanceParams  = repo.BalanceParams
+	CountParams    = repo.CountParams
+)
+
+// MoveParams 单条出入库；Quantity 为录入单位下的数量：
+// 入库/出库/报损须为正数（出库类按负数记账），调整可正可负
+type MoveParams struct {
+	OrgID      string
+	GoodsID    string
+	Type       string
+	Quantity   decimal.Decimal
+	UnitID     string // 为空取商品单位
+	OccurredAt time.Time
+	WeighingID *string
+	Remark     *string
+	OperatorID *string
+}
+
+// TransferParams 商品按机构建档：GoodsID 为调出机构的商品，ToGoodsID 为调入机构的对应商品
+type TransferParams struct {
+	FromOrgID  string
+	ToOrgID    string
+	GoodsID    string
+	ToGoodsID  string
+	Quantity   decimal.Decimal
+	UnitID     string
+	OccurredAt time.Time
+	Remark     *string
+	OperatorID *string
+}
+
+type CountLineParams struct {
+	GoodsID  string
+	Quantity decimal.Decimal
+	UnitID   string // 为空取商品单位
+	Sort     int
+}
+
+type CountCreateParams struct {
+	OrgID      string
+	CountDate  time.Time
+	Remark     *string
+	OperatorID *string
+	Lines      []CountLineParams
+}
+
+// toBase 校验商品属于 orgID 并将录入数量换算为商品基准单位
+func (s *Service) toBase(ctx context.Context, orgID, goodsID string, qty decimal.Decimal, unitID string) (decimal.Decimal, string, string, error) {
+	g, err := s.goods.GetGoods(ctx, goodsID)
+	if err != nil {
+		return decimal.Zero, "", "", fmt.Errorf("商品不存在: %w", err)
+	}
+	if g.OrgID != orgID {
+		return decimal.Zero, "", "", fmt.Errorf("商品 %s 不属于该机构", g.Name)
+	}
+	if unitID == "" {
+		unitID = g.UnitID
+	}
+	base, err := s.units.GoodsBaseUnit(ctx, g.ID, g.UnitID)
+	if err != nil {
+		return decimal.Zero, "", "", err
+	}
+	out, err := s.units.ConvertQuantity(ctx, qty, unitID, base.ID, &g.ID)
+	if err != nil {
+		return decimal.Zero, "", "", fmt.Errorf("商品 %s 无法换算到基准单位 %s: %w", g.Name, base.Name, err)
+	}
+	return out.Round(domain.QtyScale), base.ID, unitID, nil
+}
+
+func (s *Service) build(ctx context.Context, p MoveParams) (*domain.Movement, error) {
+	p.OrgID, p.GoodsID, p.UnitID = strings.TrimSpace(p.OrgID), strings.TrimSpace(p.GoodsID), strings.TrimSpace(p.UnitID)
+	if p.OrgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if p.GoodsID == "" {
+		return nil, errors.New("goods_id 不能为空")
+	}
+	if !domain.ValidType(p.Type) {
+		return nil, fmt.Errorf("type 非法: %s", p.Type)
+	}
+	if p.Type == domain.TypeAdjust {
+		if p.Quantity.IsZero() {
+			return nil, errors.New("调整数量不能为 0")
+		}
+	} else if !p.Quantity.IsPositive() {
+		return nil, errors.New("quantity 必须大于 0")
+	}
+	if p.OccurredAt.IsZero() {
+		p.OccurredAt = time.Now()
+	}
+
+	qty, baseUnitID, inputUnitID, err := s.toBase(ctx, p.OrgID, p.GoodsID, p.Quantity, p.UnitID)
+	if err != nil {
+		return nil, err
+	}
+	if qty.IsZero() {
+		return nil, errors.New("换算到基准单位后数量为 0")
+	}
+	if domain.Outbound(p.Type) {
+		qty = qty.Neg()
+	}
+	return &domain.Movement{
+		OrgID:       p.OrgID,
+		GoodsID:     p.GoodsID,
+		Type:        p.Type,
+		Quantity:    qty,
+		UnitID:      baseUnitID,
+		InputQty:    p.Quantity,
+		InputUnitID: inputUnitID,
+		OccurredAt:  p.OccurredAt,
+		WeighingID:  utils.NormalizePtr(p.WeighingID),
+		Remark:      utils.NormalizePtr(p.Remark),
+		OperatorID:  utils.NormalizePtr(p.OperatorID),
+	}, nil
+}
+
+// Move 入库、领用、报损或调整（调拨请用 Transfer）
+func (s *Service) Move(ctx context.Context, p MoveParams) (*domain.Movement, error) {
+	if p.Type == domain.TypeTransferIn || p.Type == domain.TypeTransferOut {
+		return nil, errors.New("调拨请使用调拨接口")
+	}
+	m, err := s.build(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	if m.WeighingID != nil {
+		if err := s.checkWeighing(ctx, *m.WeighingID, m.OrgID, m.GoodsID); err != nil {
+			return nil, err
+		}
+	}
+	return m, s.r.Post(ctx, []*domain.Movement{m})
+}
+
+// PostWeighing 按称重记录生成领用/报损流水，一条称重记录只能过账一次
+func (s *Service) PostWeighing(ctx context.Context, weighingID, typ string, operatorID *string) (*domain.Movement, error) {
+	if typ == "" {
+		typ = domain.TypeIssue
+	}
+	if typ != domain.TypeIssue && typ != domain.TypeWaste {
+		return nil, errors.New("称重记录只能生成领用或报损流水")
+	}
+	w, err := s.weighings.Get(ctx, strings.TrimSpace(weighingID))
+	if err != nil {
+		return nil, fmt.Errorf("称重记录不存在: %w", err)
+	}
+	return s.Move(ctx, MoveParams{
+		OrgID:      w.OrgID,
+		GoodsID:    w.GoodsID,
+		Type:       typ,
+		Quantity:   w.Weight,
+		UnitID:     w.UnitID,
+		OccurredAt: w.WeighedAt,
+		WeighingID: &w.ID,
+		OperatorID: operatorID,
+	})
+}
+
+func (s *Service) checkWeighing(ctx context.Context, weighingID, orgID, goodsID string) error {
+	w, err := s.weighings.Get(ctx, weighingID)
+	if err != nil {
+		return fmt.Errorf("称重记录不存在: %w", err)
+	}
+	if w.OrgID != orgID || w.GoodsID != goodsID {
+		return errors.New("称重记录的机构或商品与流水不一致")
+	}
+	posted, err := s.r.WeighingPosted(ctx, weighingID)
+	if err != nil {
+		return err
+	}
+	if posted {
+		return errors.New("该称重记录已生成过库存流水")
+	}
+	return nil
+}
+
+// Transfer 机构间调拨：调出、调入两条流水同事务记账，共用调拨批次号；两侧商品分别校验归属机构
+func (s *Service) Transfer(ctx context.Context, p TransferParams) ([]*domain.Movement, error) {
+	p.FromOrgID, p.ToOrgID = strings.TrimSpace(p.FromOrgID), strings.TrimSpace(p.ToOrgID)
+	if p.FromOrgID == p.ToOrgID {
+		return nil, errors.New("调出与调入机构不能相同")
+	}
+	if p.OccurredAt.IsZero() {
+		p.OccurredAt = time.Now()
+	}
+	if strings.TrimSpace(p.ToGoodsID) == "" {
+		return nil, errors.New("to_goods_id 不能为空")
+	}
+	out, err := s.build(ctx, MoveParams{
+		OrgID: p.FromOrgID, GoodsID: p.GoodsID, Type: domain.TypeTransferOut,
+		Quantity: p.Quantity, UnitID: p.UnitID, OccurredAt: p.OccurredAt,
+		Remark: p.Remark, OperatorID: p.OperatorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	in, err := s.build(ctx, MoveParams{
+		OrgID: p.ToOrgID, GoodsID: p.ToGoodsID, Type: domain.TypeTransferIn,
+		Quantity: p.Quantity, UnitID: p.UnitID, OccurredAt: p.OccurredAt,
+		Remark: p.Remark, OperatorID: p.OperatorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	tid := uuid.NewString()
+	out.TransferID, out.PeerOrgID = &tid, &in.OrgID
+	in.TransferID, in.PeerOrgID = &tid, &out.OrgID
+	items := []*domain.Movement{out, in}
+	return items, s.r.Post(ctx, items)
+}
+
+func (s *Service) ListMovements(ctx context.Context, p MovementParams) ([]domain.Movement, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.GoodsID, p.Type, p.WeighingID = utils.NormalizePtr(p.GoodsID), utils.NormalizePtr(p.Type), utils.NormalizePtr(p.WeighingID)
+	return s.r.ListMovements(ctx, p)
+}
+
+func (s *Service) ListBalances(ctx context.Context, p BalanceParams) ([]domain.Balance, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.GoodsID = utils.NormalizePtr(p.GoodsID)
+	return s.r.ListBalances(ctx, p)
+}
+
+func (s *Service) RebuildBalances(ctx context.Context, orgID string) (int, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return 0, errors.New("org_id 不能为空")
+	}
+	return s.r.RebuildBalances(ctx, orgID)
+}
+
+// OnHand 当前结存，按商品单位返回（供需求预测扣减库存）；无法换算的商品跳过
+func (s *Service) OnHand(ctx context.Context, orgID string, goodsIDs []string) (map[string]decimal.Decimal, error) {
+	bals, err := s.r.Balances(ctx, orgID, goodsIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(bals))
+	for id := range bals {
+		ids = append(ids, id)
+	}
+	units, err := s.goods.GoodsUnits(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]decimal.Decimal, len(bals))
+	for id, b := range bals {
+		unitID, ok := units[id]
+		if !ok {
+			continue
+		}
+		gid := id
+		qty, err := s.units.ConvertQuantity(ctx, b.Quantity, b.UnitID, unitID, &gid)
+		if err != nil {
+			continue
+		}
+		out[id] = qty
+	}
+	return out, nil
+}
+
+func (s *Service) countLines(ctx context.Context, orgID string, in []CountLineParams) ([]domain.CountLine, error) {
+	seen := map[string]bool{}
+	lines := make([]domain.CountLine, 0, len(in))
+	for _, l := range in {
+		goodsID := strings.TrimSpace(l.GoodsID)
+		if goodsID == "" {
+			return nil, errors.New("goods_id 不能为空")
+		}
+		if seen[goodsID] {
+			return nil, fmt.Errorf("商品 %s 在盘点单中重复", goodsID)
+		}
+		seen[goodsID] = true
+		if l.Quantity.IsNegative() {
+			return nil, errors.New("实盘数量不能为负")
+		}
+		qty, baseUnitID, inputUnitID, err := s.toBase(ctx, orgID, goodsID, l.Quantity, strings.TrimSpace(l.UnitID))
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, domain.CountLine{
+			GoodsID:     goodsID,
+			UnitID:      baseUnitID,
+			InputQty:    l.Quantity,
+			InputUnitID: inputUnitID,
+			CountedQty:  qty,
+			Sort:        l.Sort,
+		})
+	}
+	return lines, nil
+}
+
+func (s *Service) CreateCount(ctx context.Context, p CountCreateParams) (*domain.Count, error) {
+	orgID := strings.TrimSpace(p.OrgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if p.CountDate.IsZero() {
+		p.CountDate = time.Now()
+	}
+	lines, err := s.countLines(ctx, orgID, p.Lines)
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.Count{
+		OrgID:      orgID,
+		CountDate:  p.CountDate,
+		Status:     domain.CountDraft,
+		Remark:     utils.NormalizePtr(p.Remark),
+		OperatorID: utils.NormalizePtr(p.OperatorID),
+		Lines:      lines,
+	}
+	return m, s.r.CreateCount(ctx, m)
+}
+
+func (s *Service) GetCount(ctx context.Context, id string) (*domain.Count, error) {
+	return s.r.GetCount(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) ListCounts(ctx context.Context, p CountParams) ([]domain.Count, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	return s.r.ListCounts(ctx, p)
+}
+
+func (s *Service) UpdateCountLines(ctx context.Context, id string, version int, in []CountLineParams) error {
+	cur, err := s.r.GetCount(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return err
+	}
+	lines, err := s.countLines(ctx, cur.OrgID, in)
+	if err != nil {
+		return err
+	}
+	return s.r.ReplaceCountLines(ctx, cur.ID, version, lines)
+}
+
+// PostCount 盘点过账：账面数取过账时结存，差异生成调整流水
+func (s *Service) PostCount(ctx context.Context, id string, operatorID *string) (*domain.Count, error) {
+	return s.r.PostCount(ctx, strings.TrimSpace(id), utils.NormalizePtr(operatorID), time.Now())
+}
+
+func (s *Service) SoftDeleteCount(ctx context.Context, id string) error {
+	return s.r.SoftDeleteDraftCount(ctx, strings.TrimSpace(id))
+}
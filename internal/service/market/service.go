@@ -0,0 +1,80 @@
+package market
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	domain "hdzk.cn/foodapp/internal/domain/market"
+	repo "hdzk.cn/foodapp/internal/repository/market"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type Service struct{ r repo.Repository }
+
+func NewService(r repo.Repository) *Service { return &Service{r: r} }
+
+type CreateParams struct {
+	OrgID   string
+	Name    string
+	Address *string
+	Type    string
+	Sort    int
+}
+
+type UpdateParams = repo.UpdateParams
+
+type ListParams = repo.ListParams
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Market, error) {
+	orgID, name := strings.TrimSpace(p.OrgID), strings.TrimSpace(p.Name)
+	if orgID == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("name 不能为空")
+	}
+	typ := strings.TrimSpace(p.Type)
+	if typ == "" {
+		typ = domain.TypeOther
+	}
+	if !domain.ValidType(typ) {
+		return nil, fmt.Errorf("不支持的市场类型: %s", typ)
+	}
+	m := &domain.Market{
+		OrgID:   orgID,
+		Name:    name,
+		Address: utils.NormalizePtr(p.Address),
+		Type:    typ,
+		Sort:    p.Sort,
+	}
+	return m, s.r.Create(ctx, m)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Market, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Market, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, fmt.Errorf("org_id 不能为空")
+	}
+	p.Keyword = strings.TrimSpace(p.Keyword)
+	p.Type = utils.NormalizePtr(p.Type)
+	return s.r.List(ctx, p)
+}
+
+func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	p.ID = strings.TrimSpace(p.ID)
+	p.Name, p.Address, p.Type = utils.NormalizePtr(p.Name), utils.NormalizePtr(p.Address), utils.NormalizePtr(p.Type)
+	if p.Type != nil && !domain.ValidType(*p.Type) {
+		return fmt.Errorf("不支持的市场类型: %s", *p.Type)
+	}
+	return s.r.Update(ctx, p)
+}
+
+// SoftDelete 删除市场；已关联的历史询价仍保留市场名称与价格
+func (s *Service) SoftDelete(ctx context.Context, id string) error {
+	return s.r.SoftDelete(ctx, strings.TrimSpace(id))
+}
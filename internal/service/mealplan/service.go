@@ -0,0 +1,145 @@
+package mealplan
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/mealplan"
+	repo "hdzk.cn/foodapp/internal/repository/mealplan"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type Service struct{ r repo.Repository }
+
+func NewService(r repo.Repository) *Service { return &Service{r: r} }
+
+type DishParams struct {
+	Name      string
+	RecipeID  *string
+	RecipeVer *int
+	Servings  *int
+	Sort      int
+	Remark    *string
+}
+
+type CreateParams struct {
+	OrgID     string
+	PlanDate  time.Time
+	MealID    string
+	Headcount int
+	Remark    *string
+	Dishes    []DishParams
+}
+
+// UpdateParams Dishes 非 nil 时整体替换菜品
+type UpdateParams struct {
+	ID        string
+	Version   int
+	Headcount *int
+	Remark    *string
+	Dishes    *[]DishParams
+}
+
+type ListParams struct {
+	OrgID    string
+	MealID   *string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	PageSize int
+}
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Plan, error) {
+	if strings.TrimSpace(p.OrgID) == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	if strings.TrimSpace(p.MealID) == "" {
+		return nil, fmt.Errorf("meal_id 不能为空")
+	}
+	if p.Headcount < 0 {
+		return nil, fmt.Errorf("headcount 不能为负数")
+	}
+	dishes, err := buildDishes(p.Dishes)
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.Plan{
+		OrgID:     strings.TrimSpace(p.OrgID),
+		PlanDate:  p.PlanDate,
+		MealID:    strings.TrimSpace(p.MealID),
+		Headcount: p.Headcount,
+		Remark:    utils.NormalizePtr(p.Remark),
+		Dishes:    dishes,
+	}
+	return m, s.r.Create(ctx, m)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Plan, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Plan, int64, error) {
+	orgID := strings.TrimSpace(p.OrgID)
+	if orgID == "" {
+		return nil, 0, fmt.Errorf("org_id 不能为空")
+	}
+	return s.r.List(ctx, repo.ListParams{
+		OrgID:    orgID,
+		MealID:   utils.NormalizePtr(p.MealID),
+		DateFrom: p.DateFrom,
+		DateTo:   p.DateTo,
+		Page:     p.Page,
+		PageSize: p.PageSize,
+	})
+}
+
+func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	if p.Headcount != nil && *p.Headcount < 0 {
+		return fmt.Errorf("headcount 不能为负数")
+	}
+	rp := repo.UpdateParams{
+		ID:        strings.TrimSpace(p.ID),
+		Version:   p.Version,
+		Headcount: p.Headcount,
+		Remark:    p.Remark,
+	}
+	if p.Dishes != nil {
+		dishes, err := buildDishes(*p.Dishes)
+		if err != nil {
+			return err
+		}
+		rp.Dishes = &dishes
+	}
+	return s.r.Update(ctx, rp)
+}
+
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.r.Delete(ctx, strings.TrimSpace(id))
+}
+
+func buildDishes(in []DishParams) ([]domain.Dish, error) {
+	out := make([]domain.Dish, 0, len(in))
+	for i, d := range in {
+		name := strings.TrimSpace(d.Name)
+		if name == "" {
+			return nil, fmt.Errorf("第 %d 个菜品名称不能为空", i+1)
+		}
+		if d.Servings != nil && *d.Servings < 0 {
+			return nil, fmt.Errorf("菜品「%s」份数不能为负数", name)
+		}
+		if d.RecipeVer != nil && *d.RecipeVer <= 0 {
+			return nil, fmt.Errorf("菜品「%s」配方版本非法", name)
+		}
+		out = append(out, domain.Dish{
+			Name:      name,
+			RecipeID:  utils.NormalizePtr(d.RecipeID),
+			RecipeVer: d.RecipeVer,
+			Servings:  d.Servings,
+			Sort:      d.Sort,
+			Remark:    utils.NormalizePtr(d.Remark),
+		})
+	}
+	return out, nil
+}
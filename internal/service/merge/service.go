@@ -0,0 +1,100 @@
+package merge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	domain "hdzk.cn/foodapp/internal/domain/merge"
+	repo "hdzk.cn/foodapp/internal/repository/merge"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type Service struct{ r repo.Repository }
+
+func NewService(r repo.Repository) *Service { return &Service{r: r} }
+
+type MergeParams struct {
+	EntityType   string // domain.EntityGoods / domain.EntityCategory
+	SurvivorID   string
+	LoserIDs     []string
+	Preview      bool
+	OperatorID   string
+	OperatorName string
+	Remark       *string
+}
+
+// Merge 合并重复商品/品类：改写全部引用、软删被合并方并记录审计；Preview=true 只返回影响行数
+func (s *Service) Merge(ctx context.Context, p MergeParams) (*domain.Result, error) {
+	survivor := strings.TrimSpace(p.SurvivorID)
+	if survivor == "" {
+		return nil, errors.New("survivor_id 不能为空")
+	}
+	losers := normalizeLosers(survivor, p.LoserIDs)
+	if len(losers) == 0 {
+		return nil, errors.New("loser_ids 不能为空，且不能只包含保留方")
+	}
+
+	rp := repo.MergeParams{SurvivorID: survivor, LoserIDs: losers, Preview: p.Preview}
+	if !p.Preview {
+		loserJSON, _ := json.Marshal(losers)
+		rp.Record = &domain.Record{
+			EntityType:   p.EntityType,
+			SurvivorID:   survivor,
+			LoserIDs:     string(loserJSON),
+			OperatorID:   p.OperatorID,
+			OperatorName: p.OperatorName,
+			Remark:       utils.NormalizePtr(p.Remark),
+		}
+	}
+
+	var (
+		counts []domain.RefCount
+		err    error
+	)
+	switch p.EntityType {
+	case domain.EntityGoods:
+		counts, err = s.r.MergeGoods(ctx, rp)
+	case domain.EntityCategory:
+		counts, err = s.r.MergeCategory(ctx, rp)
+	default:
+		return nil, errors.New("不支持的合并类型: " + p.EntityType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	out := &domain.Result{Preview: p.Preview, Survivor: survivor, Losers: losers, Affected: counts}
+	if rp.Record != nil {
+		out.RecordID = rp.Record.ID
+	}
+	return out, nil
+}
+
+func (s *Service) GetRecord(ctx context.Context, id string) (*domain.Record, error) {
+	return s.r.GetRecord(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) ListRecords(ctx context.Context, orgID string, entityType string, page, pageSize int) ([]domain.Record, int64, error) {
+	trimmedOrg := strings.TrimSpace(orgID)
+	if trimmedOrg == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	return s.r.ListRecords(ctx, trimmedOrg, strings.TrimSpace(entityType), page, pageSize)
+}
+
+// normalizeLosers 去空、去重并剔除保留方自身
+func normalizeLosers(survivor string, ids []string) []string {
+	seen := map[string]bool{survivor: true}
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/notification"
+	repo "hdzk.cn/foodapp/internal/repository/notification"
+)
+
+type Service struct{ r repo.Repository }
+
+func NewService(r repo.Repository) *Service { return &Service{r: r} }
+
+type ListParams = repo.ListParams
+
+// Notify 发送通知；同一去重键只发送一次，返回是否新发送
+func (s *Service) Notify(ctx context.Context, n *domain.Notification) (bool, error) {
+	if strings.TrimSpace(n.DedupKey) == "" {
+		return false, errors.New("dedup_key 不能为空")
+	}
+	return s.r.Create(ctx, n)
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Notification, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	return s.r.List(ctx, p)
+}
+
+// MarkRead ids 为空时将机构全部未读通知标记为已读
+func (s *Service) MarkRead(ctx context.Context, orgID string, ids []string) (int64, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return 0, errors.New("org_id 不能为空")
+	}
+	return s.r.MarkRead(ctx, orgID, ids, time.Now())
+}
@@ -28,10 +28,12 @@ func NewService(r repo.Repository) *Service { return &Service{r: r} }
 /************ DTO ************/
 type UpdateInput struct {
 	ID          string
+	Version     int // 期望版本号（乐观锁）
 	Name        *string
 	Parent      *string
 	Code        *string
 	Description *string
+	TimeZone    *string
 }
 
 /************ 方法实现 ************/
@@ -78,7 +80,13 @@ func (s *Service) Update(ctx context.Context, in UpdateInput) error {
 	if in.Description != nil {
 		updates["description"] = *in.Description
 	}
-	return s.r.UpdateFields(ctx, in.ID, updates)
+	if in.TimeZone != nil {
+		if _, err := domain.Location(*in.TimeZone); err != nil {
+			return err
+		}
+		updates["time_zone"] = *in.TimeZone
+	}
+	return s.r.UpdateFields(ctx, in.ID, in.Version, updates)
 }
 
 func (s *Service) SoftDelete(ctx context.Context, id string) error {
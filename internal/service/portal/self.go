@@ -0,0 +1,253 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	domain "hdzk.cn/foodapp/internal/domain/portal"
+	price "hdzk.cn/foodapp/internal/domain/price"
+	purchase "hdzk.cn/foodapp/internal/domain/purchase"
+	qualification "hdzk.cn/foodapp/internal/domain/qualification"
+	pricesvc "hdzk.cn/foodapp/internal/service/price"
+	purchasesvc "hdzk.cn/foodapp/internal/service/purchase"
+	qualificationsvc "hdzk.cn/foodapp/internal/service/qualification"
+	reportsvc "hdzk.cn/foodapp/internal/service/report"
+)
+
+// QuoteSaver 报价录入（由价格服务实现，含合同期、资质与异常检测）
+type QuoteSaver interface {
+	SaveQuote(ctx context.Context, p pricesvc.QuoteParams) (*price.QuoteLine, error)
+}
+
+// OrderSource 采购单查询（由采购服务实现）
+type OrderSource interface {
+	Get(ctx context.Context, id string) (*purchase.Order, error)
+	List(ctx context.Context, p purchasesvc.ListParams) ([]purchase.Order, int64, error)
+}
+
+// SettlementRenderer 结算对账单（由报表服务实现）
+type SettlementRenderer interface {
+	SettlementPDF(ctx context.Context, p reportsvc.SettlementParams) ([]byte, error)
+}
+
+// DocumentStore 资质文件（由资质服务实现）
+type DocumentStore interface {
+	Upload(ctx context.Context, p qualificationsvc.UploadParams) (*qualification.Document, error)
+	List(ctx context.Context, p qualificationsvc.ListParams) ([]qualification.Document, int64, error)
+}
+
+// Actor 门户操作者；所有门户侧操作都限定在其供应商范围内
+type Actor struct {
+	AccountID  string
+	SupplierID string
+	OrgID      string
+}
+
+// ListInquiries 向本供应商开放的询价单
+func (s *Service) ListInquiries(ctx context.Context, act Actor, page, pageSize int) ([]domain.InquiryView, int64, error) {
+	return s.r.OpenInquiries(ctx, act.SupplierID, page, pageSize)
+}
+
+// InquiryDetail 询价单抬头、截止时间及询价商品（含本供应商当前报价，不含市场价）
+type InquiryDetail struct {
+	InquiryID    string               `json:"inquiry_id"`
+	InquiryTitle string               `json:"inquiry_title"`
+	InquiryDate  time.Time            `json:"inquiry_date"`
+	Status       int                  `json:"status"`
+	ClosesAt     *time.Time           `json:"closes_at"`
+	Open         bool                 `json:"open"` // 当前是否可报价
+	Items        []domain.InquiryItem `json:"items"`
+}
+
+func (s *Service) GetInquiry(ctx context.Context, act Actor, inquiryID string) (*InquiryDetail, error) {
+	inq, inv, err := s.invited(ctx, act, inquiryID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.r.InquiryItems(ctx, inq.ID, act.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	return &InquiryDetail{
+		InquiryID:    inq.ID,
+		InquiryTitle: inq.InquiryTitle,
+		InquiryDate:  inq.InquiryDate,
+		Status:       inq.Status,
+		ClosesAt:     inv.ClosesAt,
+		Open:         inv.OpenAt(time.Now()) && !inquiry.Locked(inq.Status),
+		Items:        items,
+	}, nil
+}
+
+type QuoteItem struct {
+	GoodsID   string
+	UnitPrice decimal.Decimal
+}
+
+// SubmitQuotes 门户提交报价：询价单须已向本供应商开放且未截止、未锁定、没有未截止的密封报价轮次，商品须在询价明细中。
+// 先整体校验再在同一事务内保存，已有报价按商品覆盖
+func (s *Service) SubmitQuotes(ctx context.Context, act Actor, inquiryID string, items []QuoteItem) ([]price.QuoteLine, error) {
+	inq, inv, err := s.invited(ctx, act, inquiryID)
+	if err != nil {
+		return nil, err
+	}
+	if !inv.OpenAt(time.Now()) {
+		return nil, fmt.Errorf("%w（%s）", domain.ErrClosed, inv.ClosesAt.Format("2006-01-02 15:04"))
+	}
+	if inquiry.Locked(inq.Status) {
+		return nil, inquiry.ErrLocked
+	}
+	if len(items) == 0 {
+		return nil, errors.New("报价明细不能为空")
+	}
+	goods, err := s.r.InquiryItems(ctx, inq.ID, act.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	inInquiry := make(map[string]bool, len(goods))
+	for _, g := range goods {
+		inInquiry[g.GoodsID] = true
+	}
+	seen := make(map[string]bool, len(items))
+	for i, it := range items {
+		id := strings.TrimSpace(it.GoodsID)
+		if !inInquiry[id] {
+			return nil, fmt.Errorf("第 %d 行商品不在询价单中", i+1)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("第 %d 行商品重复", i+1)
+		}
+		if !it.UnitPrice.IsPositive() {
+			return nil, fmt.Errorf("第 %d 行单价必须大于 0", i+1)
+		}
+		seen[id] = true
+	}
+
+	// 整单提交：任一行失败则全部回滚
+	var out []price.QuoteLine
+	err = s.tx.InTx(ctx, func(ctx context.Context) error {
+		out = make([]price.QuoteLine, 0, len(items))
+		for i, it := range items {
+			q, err := s.quotes.SaveQuote(ctx, pricesvc.QuoteParams{
+				InquiryID:  inq.ID,
+				SupplierID: act.SupplierID,
+				GoodsID:    strings.TrimSpace(it.GoodsID),
+				UnitPrice:  it.UnitPrice,
+			})
+			if err != nil {
+				return fmt.Errorf("第 %d 行保存失败: %w", i+1, err)
+			}
+			out = append(out, *q)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// invited 读取询价单及本供应商的开放记录；未开放时返回 ErrNotInvited
+func (s *Service) invited(ctx context.Context, act Actor, inquiryID string) (*inquiry.PriceInquiry, *domain.Invitation, error) {
+	inv, err := s.r.GetInvitation(ctx, strings.TrimSpace(inquiryID), act.SupplierID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrNotInvited
+		}
+		return nil, nil, err
+	}
+	inq, err := s.inquiries.Get(ctx, inv.InquiryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrNotInvited
+		}
+		return nil, nil, err
+	}
+	return inq, inv, nil
+}
+
+type OrderListParams struct {
+	Status   *int
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	PageSize int
+}
+
+// ListOrders 本供应商的采购单（不含草稿）
+func (s *Service) ListOrders(ctx context.Context, act Actor, p OrderListParams) ([]purchase.Order, int64, error) {
+	return s.orders.List(ctx, purchasesvc.ListParams{
+		OrgID:        act.OrgID,
+		SupplierID:   &act.SupplierID,
+		Status:       p.Status,
+		ExcludeDraft: true,
+		DateFrom:     p.DateFrom,
+		DateTo:       p.DateTo,
+		Page:         p.Page,
+		PageSize:     p.PageSize,
+	})
+}
+
+// GetOrder 本供应商的采购单；其他供应商的或草稿视为不存在
+func (s *Service) GetOrder(ctx context.Context, act Actor, id string) (*purchase.Order, error) {
+	o, err := s.orders.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.SupplierID != act.SupplierID || o.Status == purchase.StatusDraft {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return o, nil
+}
+
+// Settlement 本供应商在期间内的结算对账单 PDF
+func (s *Service) Settlement(ctx context.Context, act Actor, from, to time.Time) ([]byte, error) {
+	return s.settlements.SettlementPDF(ctx, reportsvc.SettlementParams{
+		OrgID:      act.OrgID,
+		SupplierID: act.SupplierID,
+		DateFrom:   from,
+		DateTo:     to,
+	})
+}
+
+type DocumentUploadParams struct {
+	DocType    string
+	DocNo      *string
+	IssueDate  *time.Time
+	ExpiryDate *time.Time
+	Remark     *string
+	FileName   string
+	File       io.Reader
+}
+
+// UploadDocument 上传本供应商资质文件，待员工审核后生效
+func (s *Service) UploadDocument(ctx context.Context, act Actor, p DocumentUploadParams) (*qualification.Document, error) {
+	return s.documents.Upload(ctx, qualificationsvc.UploadParams{
+		SupplierID: act.SupplierID,
+		DocType:    p.DocType,
+		DocNo:      p.DocNo,
+		IssueDate:  p.IssueDate,
+		ExpiryDate: p.ExpiryDate,
+		Remark:     p.Remark,
+		FileName:   p.FileName,
+		File:       p.File,
+		UploadedBy: &act.AccountID,
+	})
+}
+
+// ListDocuments 本供应商的资质文件
+func (s *Service) ListDocuments(ctx context.Context, act Actor, page, pageSize int) ([]qualification.Document, int64, error) {
+	return s.documents.List(ctx, qualificationsvc.ListParams{
+		OrgID:      act.OrgID,
+		SupplierID: &act.SupplierID,
+		Page:       page,
+		PageSize:   pageSize,
+	})
+}
@@ -0,0 +1,315 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	domain "hdzk.cn/foodapp/internal/domain/portal"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/portal"
+	"hdzk.cn/foodapp/pkg/crypto"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// SupplierSource 供应商（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+}
+
+// InquirySource 询价单抬头（由询价仓储实现）
+type InquirySource interface {
+	Get(ctx context.Context, id string) (*inquiry.PriceInquiry, error)
+}
+
+// Service 供应商门户：账户管理、询价开放（员工侧），以及按供应商限定范围的报价、采购单、结算与资质（门户侧）
+type Service struct {
+	r           repo.Repository
+	suppliers   SupplierSource
+	inquiries   InquirySource
+	quotes      QuoteSaver
+	orders      OrderSource
+	settlements SettlementRenderer
+	documents   DocumentStore
+	tx          utils.Transactor
+}
+
+func NewService(r repo.Repository, suppliers SupplierSource, inquiries InquirySource, quotes QuoteSaver, orders OrderSource, settlements SettlementRenderer, documents DocumentStore, tx utils.Transactor) *Service {
+	return &Service{
+		r:           r,
+		suppliers:   suppliers,
+		inquiries:   inquiries,
+		quotes:      quotes,
+		orders:      orders,
+		settlements: settlements,
+		documents:   documents,
+		tx:          tx,
+	}
+}
+
+// 密码最短长度
+const minPasswordLen = 8
+
+// ErrLogin 登录名或密码错误（不区分原因）
+var ErrLogin = errors.New("登录名或密码错误")
+
+type CreateAccountParams struct {
+	SupplierID  string
+	Username    string
+	Password    string
+	DisplayName *string
+	Phone       *string
+	Scopes      []string
+	CreatedBy   *string
+}
+
+// CreateAccount 为供应商创建门户账户；登录名全局唯一（与员工账户互不影响）
+func (s *Service) CreateAccount(ctx context.Context, p CreateAccountParams) (*domain.Account, error) {
+	username := strings.TrimSpace(p.Username)
+	if len(username) < 3 {
+		return nil, errors.New("登录名至少 3 个字符")
+	}
+	if len(p.Password) < minPasswordLen {
+		return nil, fmt.Errorf("密码至少 %d 位", minPasswordLen)
+	}
+	scopes, err := normalizeScopes(p.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+	taken, err := s.r.UsernameTaken(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, fmt.Errorf("登录名 %s 已被使用", username)
+	}
+	hash, err := crypto.HashPassword(p.Password)
+	if err != nil {
+		return nil, err
+	}
+	a := &domain.Account{
+		SupplierID:   sup.ID,
+		OrgID:        sup.OrgID,
+		Username:     username,
+		PasswordHash: hash,
+		DisplayName:  utils.NormalizePtr(p.DisplayName),
+		Phone:        utils.NormalizePtr(p.Phone),
+		Scopes:       scopes,
+		Status:       domain.StatusActive,
+		CreatedBy:    utils.NormalizePtr(p.CreatedBy),
+	}
+	if err := s.r.CreateAccount(ctx, a); err != nil {
+		return nil, err
+	}
+	a.ScopeList = domain.SplitScopes(a.Scopes)
+	return a, nil
+}
+
+func (s *Service) GetAccount(ctx context.Context, id string) (*domain.Account, error) {
+	return s.r.GetAccount(ctx, strings.TrimSpace(id))
+}
+
+type AccountListParams = repo.AccountListParams
+
+func (s *Service) ListAccounts(ctx context.Context, p AccountListParams) ([]domain.Account, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.SupplierID = utils.NormalizePtr(p.SupplierID)
+	return s.r.ListAccounts(ctx, p)
+}
+
+type UpdateAccountParams struct {
+	ID          string
+	Version     int
+	DisplayName *string // 空串清空
+	Phone       *string // 空串清空
+	Scopes      *[]string
+	Status      *int
+}
+
+// UpdateAccount 修改门户账户资料、权限范围或启停状态（即时生效）
+func (s *Service) UpdateAccount(ctx context.Context, p UpdateAccountParams) (*domain.Account, error) {
+	up := repo.AccountUpdateParams{ID: strings.TrimSpace(p.ID), Version: p.Version, Status: p.Status}
+	if p.DisplayName != nil {
+		up.DisplayName, up.ClearDisplayName = utils.NormalizePtr(p.DisplayName), true
+	}
+	if p.Phone != nil {
+		up.Phone, up.ClearPhone = utils.NormalizePtr(p.Phone), true
+	}
+	if p.Scopes != nil {
+		scopes, err := normalizeScopes(*p.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		up.Scopes = &scopes
+	}
+	if p.Status != nil && *p.Status != domain.StatusActive && *p.Status != domain.StatusDisabled {
+		return nil, errors.New("status 仅支持 1=正常 2=停用")
+	}
+	if err := s.r.UpdateAccount(ctx, up); err != nil {
+		return nil, err
+	}
+	return s.r.GetAccount(ctx, up.ID)
+}
+
+// ResetPassword 员工重置门户账户密码
+func (s *Service) ResetPassword(ctx context.Context, id, password string) error {
+	if len(password) < minPasswordLen {
+		return fmt.Errorf("密码至少 %d 位", minPasswordLen)
+	}
+	hash, err := crypto.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.r.UpdatePasswordHash(ctx, strings.TrimSpace(id), hash)
+}
+
+func (s *Service) DeleteAccount(ctx context.Context, id string) error {
+	return s.r.SoftDeleteAccount(ctx, strings.TrimSpace(id))
+}
+
+// Login 门户登录：账户须为正常状态且供应商未删除
+func (s *Service) Login(ctx context.Context, username, password string) (*domain.Account, error) {
+	a, err := s.r.GetAccountByUsername(ctx, strings.TrimSpace(username))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrLogin
+		}
+		return nil, err
+	}
+	if !crypto.VerifyPassword(a.PasswordHash, password) {
+		return nil, ErrLogin
+	}
+	if err := s.checkUsable(ctx, a); err != nil {
+		return nil, err
+	}
+	_ = s.r.TouchLogin(ctx, a.ID, time.Now())
+	return a, nil
+}
+
+// Lookup 按账户ID读取可用的门户账户（供鉴权中间件每次请求刷新权限）
+func (s *Service) Lookup(ctx context.Context, id string) (*domain.Account, error) {
+	a, err := s.r.GetAccount(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUsable(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ChangePassword 门户用户修改自己的密码
+func (s *Service) ChangePassword(ctx context.Context, accountID, oldPlain, newPlain string) error {
+	a, err := s.r.GetAccount(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	if !crypto.VerifyPassword(a.PasswordHash, oldPlain) {
+		return errors.New("旧密码错误")
+	}
+	return s.ResetPassword(ctx, a.ID, newPlain)
+}
+
+func (s *Service) checkUsable(ctx context.Context, a *domain.Account) error {
+	if a.Status != domain.StatusActive {
+		return errors.New("账户已停用")
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, a.SupplierID)
+	if err != nil || sup.OrgID != a.OrgID {
+		return errors.New("供应商不存在或已删除")
+	}
+	return nil
+}
+
+type OpenInquiryParams struct {
+	InquiryID   string
+	SupplierIDs []string
+	ClosesAt    *time.Time
+	InvitedBy   *string
+}
+
+// OpenInquiry 将询价单开放给供应商在门户报价；已开放的供应商更新截止时间
+func (s *Service) OpenInquiry(ctx context.Context, p OpenInquiryParams) ([]domain.Invitation, error) {
+	inq, err := s.inquiries.Get(ctx, strings.TrimSpace(p.InquiryID))
+	if err != nil {
+		return nil, fmt.Errorf("询价单不存在: %w", err)
+	}
+	if inquiry.Locked(inq.Status) {
+		return nil, inquiry.ErrLocked
+	}
+	if p.ClosesAt != nil && !p.ClosesAt.After(time.Now()) {
+		return nil, errors.New("报价截止时间须晚于当前时间")
+	}
+	if len(p.SupplierIDs) == 0 {
+		return nil, errors.New("supplier_ids 不能为空")
+	}
+	for _, id := range p.SupplierIDs {
+		sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(id))
+		if err != nil {
+			return nil, fmt.Errorf("供应商 %s 不存在: %w", id, err)
+		}
+		if sup.OrgID != inq.OrgID {
+			return nil, fmt.Errorf("供应商 %s 与询价单不属于同一机构", sup.Name)
+		}
+		if err := s.r.SaveInvitation(ctx, &domain.Invitation{
+			OrgID:      inq.OrgID,
+			InquiryID:  inq.ID,
+			SupplierID: sup.ID,
+			ClosesAt:   p.ClosesAt,
+			InvitedBy:  utils.NormalizePtr(p.InvitedBy),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return s.r.ListInvitations(ctx, inq.ID)
+}
+
+// RevokeInquiry 取消向供应商开放询价单（已提交的报价保留）
+func (s *Service) RevokeInquiry(ctx context.Context, inquiryID, supplierID string) error {
+	return s.r.DeleteInvitation(ctx, strings.TrimSpace(inquiryID), strings.TrimSpace(supplierID))
+}
+
+func (s *Service) ListInvitations(ctx context.Context, inquiryID string) ([]domain.Invitation, error) {
+	return s.r.ListInvitations(ctx, strings.TrimSpace(inquiryID))
+}
+
+// normalizeScopes 校验、去重并按固定顺序拼接权限范围
+func normalizeScopes(in []string) (string, error) {
+	seen := map[string]bool{}
+	for _, s := range in {
+		s = strings.TrimSpace(s)
+		valid := false
+		for _, v := range domain.Scopes {
+			valid = valid || v == s
+		}
+		if !valid {
+			return "", fmt.Errorf("不支持的权限范围: %s", s)
+		}
+		seen[s] = true
+	}
+	if len(seen) == 0 {
+		return "", errors.New("至少需要一项权限范围")
+	}
+	out := make([]string, 0, len(seen))
+	for s := range seen {
+		out = append(out, s)
+	}
+	order := map[string]int{}
+	for i, v := range domain.Scopes {
+		order[v] = i
+	}
+	sort.Slice(out, func(i, j int) bool { return order[out[i]] < order[out[j]] })
+	return strings.Join(out, ","), nil
+}
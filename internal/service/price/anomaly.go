@@ -0,0 +1,516 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	bidding "hdzk.cn/foodapp/internal/domain/bidding"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	domain "hdzk.cn/foodapp/internal/domain/price"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/price"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// InquirySource 询价单抬头及参与市场（由询价仓储实现）；
+// Lock 在事务内行锁读取询价单，录入价格期间审核/归档须等待
+type InquirySource interface {
+	Get(ctx context.Context, id string) (*inquiry.PriceInquiry, error)
+	Lock(ctx context.Context, id string) (*inquiry.PriceInquiry, error)
+	ListMarkets(ctx context.Context, inquiryID string) ([]inquiry.InquiryMarket, error)
+}
+
+// SupplierSource 供应商及浮动比例历史（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+	FloatRatioOn(ctx context.Context, supplierID string, at time.Time) (*supplier.FloatRatio, error)
+}
+
+// RoundSource 询价单上的密封报价轮次（由报价轮次仓储实现）
+type RoundSource interface {
+	HasActiveRound(ctx context.Context, inquiryID string, at time.Time) (bool, error)
+}
+
+// QualificationChecker 供应商必备资质校验（由资质服务实现）
+type QualificationChecker interface {
+	Check(ctx context.Context, supplierID string, at time.Time) error
+}
+
+var ErrNotFound = repo.ErrNotFound
+
+type MarketPriceParam struct {
+	MarketID string
+	Price    decimal.Decimal
+}
+
+// InquiryLineParams 市场价按 Prices 录入；旧版 Market1..3Price 对应询价单第 1..3 个市场（sort 1..3）
+type InquiryLineParams struct {
+	InquiryID    string
+	GoodsID      string
+	GuidePrice   *decimal.Decimal
+	Prices       []MarketPriceParam
+	Market1Price *decimal.Decimal
+	Market2Price *decimal.Decimal
+	Market3Price *decimal.Decimal
+}
+
+type QuoteParams struct {
+	InquiryID  string
+	SupplierID string
+	GoodsID    string
+	UnitPrice  decimal.Decimal
+}
+
+type RuleParams struct {
+	OrgID      string
+	BandPct    decimal.Decimal
+	SpreadPct  decimal.Decimal
+	Window     int
+	MinSamples int
+}
+
+type FlagListParams = repo.FlagListParams
+
+// SaveInquiryLine 录入（或覆盖）询价明细的各市场价，均价取已录入市场价的平均，并按机构规则重新检测异常
+func (s *Service) SaveInquiryLine(ctx context.Context, p InquiryLineParams) (*domain.InquiryLine, error) {
+	var out *domain.InquiryLine
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		m, err := s.saveInquiryLine(ctx, p)
+		out = m
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// saveInquiryLine 在事务内行锁询价单后校验锁定状态并写入，审核/归档不会与录入交错
+func (s *Service) saveInquiryLine(ctx context.Context, p InquiryLineParams) (*domain.InquiryLine, error) {
+	inq, err := s.inquiries.Lock(ctx, strings.TrimSpace(p.InquiryID))
+	if err != nil {
+		return nil, fmt.Errorf("询价单不存在: %w", err)
+	}
+	if inquiry.Locked(inq.Status) {
+		return nil, inquiry.ErrLocked
+	}
+	goodsID := strings.TrimSpace(p.GoodsID)
+	if goodsID == "" {
+		return nil, errors.New("goods_id 不能为空")
+	}
+	if p.GuidePrice != nil && p.GuidePrice.IsNegative() {
+		return nil, errors.New("价格不能为负数")
+	}
+	prices, err := s.linePrices(ctx, inq.ID, p)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]domain.LinePrice, len(prices))
+	for i, lp := range prices {
+		rows[i] = domain.LinePrice{MarketID: lp.MarketID, Price: lp.Price}
+	}
+	m := &domain.InquiryLine{
+		GoodsID:    goodsID,
+		GuidePrice: p.GuidePrice,
+		AvgPrice:   domain.AvgOf(rows),
+		InquiryID:  inq.ID,
+		OrgID:      &inq.OrgID,
+	}
+	// 旧版 market1..3_price 按市场顺序同步保留，兼容尚未改造的读取方
+	for _, lp := range prices {
+		v := lp.Price
+		switch lp.Sort {
+		case 1:
+			m.Market1Price = &v
+		case 2:
+			m.Market2Price = &v
+		case 3:
+			m.Market3Price = &v
+		}
+	}
+	if err := s.r.SaveInquiryLine(ctx, m, rows); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.rule(ctx, inq.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.r.TrailingAvgPrices(ctx, inq.OrgID, goodsID, inq.ID, inq.InquiryDate, rule.Window)
+	if err != nil {
+		return nil, err
+	}
+	byMarket := m.MarketPrices()
+	flags := append(bandFlags(byMarket, history, rule), spreadFlags(byMarket, rule)...)
+	for i := range flags {
+		marketID := flags[i].Field
+		flags[i].Field, flags[i].MarketID = domain.FieldMarketPrice, &marketID
+		flags[i].OrgID, flags[i].InquiryID, flags[i].GoodsID = inq.OrgID, inq.ID, goodsID
+	}
+	if _, err := s.r.ReplaceFlags(ctx, domain.LineInquiry, m.ID, flags); err != nil {
+		return nil, err
+	}
+	byLine, err := s.r.FlagsOf(ctx, domain.LineInquiry, []string{m.ID})
+	if err != nil {
+		return nil, err
+	}
+	m.Flags = flagsOrEmpty(byLine[m.ID])
+	return m, nil
+}
+
+type slotPrice struct {
+	MarketID string
+	Sort     int
+	Price    decimal.Decimal
+}
+
+// linePrices 校验市场均为询价单的参与市场，并将旧版 Market1..3Price 映射到对应顺序的市场
+func (s *Service) linePrices(ctx context.Context, inquiryID string, p InquiryLineParams) ([]slotPrice, error) {
+	markets, err := s.inquiries.ListMarkets(ctx, inquiryID)
+	if err != nil {
+		return nil, err
+	}
+	sortOf := make(map[string]int, len(markets))
+	bySort := make(map[int]string, len(markets))
+	for _, mk := range markets {
+		sortOf[mk.MarketID] = mk.Sort
+		bySort[mk.Sort] = mk.MarketID
+	}
+	params := append([]MarketPriceParam(nil), p.Prices...)
+	for i, v := range []*decimal.Decimal{p.Market1Price, p.Market2Price, p.Market3Price} {
+		if v == nil {
+			continue
+		}
+		marketID, ok := bySort[i+1]
+		if !ok {
+			return nil, fmt.Errorf("询价单没有第 %d 个市场，无法录入 market%d_price", i+1, i+1)
+		}
+		params = append(params, MarketPriceParam{MarketID: marketID, Price: *v})
+	}
+	out := make([]slotPrice, 0, len(params))
+	seen := make(map[string]bool, len(params))
+	for _, mp := range params {
+		id := strings.TrimSpace(mp.MarketID)
+		n, ok := sortOf[id]
+		if !ok {
+			return nil, fmt.Errorf("市场 %s 不是该询价单的参与市场", id)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("市场 %s 重复录入", id)
+		}
+		if mp.Price.IsNegative() {
+			return nil, errors.New("价格不能为负数")
+		}
+		seen[id] = true
+		out = append(out, slotPrice{MarketID: id, Sort: n, Price: mp.Price.Round(2)})
+	}
+	return out, nil
+}
+
+// ListInquiryLines 返回询价单的明细及其异常标记
+func (s *Service) ListInquiryLines(ctx context.Context, inquiryID string) ([]domain.InquiryLine, error) {
+	list, err := s.r.ListInquiryLines(ctx, strings.TrimSpace(inquiryID))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(list))
+	for i, l := range list {
+		ids[i] = l.ID
+	}
+	byLine, err := s.r.FlagsOf(ctx, domain.LineInquiry, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Flags = flagsOrEmpty(byLine[list[i].ID])
+	}
+	return list, nil
+}
+
+// SaveQuote 录入（或覆盖）供应商报价，浮动比例取询价日期生效的比例（FloatRatioOn）作为快照，并检测异常
+func (s *Service) SaveQuote(ctx context.Context, p QuoteParams) (*domain.QuoteLine, error) {
+	var out *domain.QuoteLine
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		m, err := s.saveQuote(ctx, p)
+		out = m
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// saveQuote 在事务内行锁询价单后校验锁定状态并写入
+func (s *Service) saveQuote(ctx context.Context, p QuoteParams) (*domain.QuoteLine, error) {
+	inq, err := s.inquiries.Lock(ctx, strings.TrimSpace(p.InquiryID))
+	if err != nil {
+		return nil, fmt.Errorf("询价单不存在: %w", err)
+	}
+	if inquiry.Locked(inq.Status) {
+		return nil, inquiry.ErrLocked
+	}
+	// 密封轮次截止前直接写入正式报价会绕过密封（门户提交、人工录入均不允许）
+	active, err := s.rounds.HasActiveRound(ctx, inq.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, bidding.ErrRoundActive
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+	if sup.OrgID != inq.OrgID {
+		return nil, errors.New("供应商与询价单不属于同一机构")
+	}
+	if err := sup.CheckActive(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.quals.Check(ctx, sup.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	goodsID := strings.TrimSpace(p.GoodsID)
+	if goodsID == "" {
+		return nil, errors.New("goods_id 不能为空")
+	}
+	if p.UnitPrice.IsNegative() {
+		return nil, errors.New("价格不能为负数")
+	}
+	// 报价按询价日期生效的浮动比例结算
+	ratio, err := s.suppliers.FloatRatioOn(ctx, sup.ID, inq.InquiryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &domain.QuoteLine{
+		GoodsID:    goodsID,
+		SupplierID: sup.ID,
+		InquiryID:  inq.ID,
+		UnitPrice:  p.UnitPrice.Round(2),
+		FloatRatio: ratio.FloatRatio,
+		OrgID:      &inq.OrgID,
+	}
+	if err := s.r.SaveQuoteLine(ctx, m); err != nil {
+		return nil, err
+	}
+
+	rule, err := s.rule(ctx, inq.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.r.TrailingQuotePrices(ctx, inq.OrgID, goodsID, inq.ID, inq.InquiryDate, rule.Window)
+	if err != nil {
+		return nil, err
+	}
+	flags := bandFlags(map[string]decimal.Decimal{domain.FieldUnitPrice: m.UnitPrice}, history, rule)
+	for i := range flags {
+		flags[i].OrgID, flags[i].InquiryID, flags[i].GoodsID, flags[i].SupplierID = inq.OrgID, inq.ID, goodsID, &sup.ID
+	}
+	if _, err := s.r.ReplaceFlags(ctx, domain.LineQuote, m.ID, flags); err != nil {
+		return nil, err
+	}
+	byLine, err := s.r.FlagsOf(ctx, domain.LineQuote, []string{m.ID})
+	if err != nil {
+		return nil, err
+	}
+	m.Flags = flagsOrEmpty(byLine[m.ID])
+	return m, nil
+}
+
+// RetractQuote 撤回报价（如授标改授其它供应商时撤回原中标报价），不再参与取价；询价单已锁定时返回 inquiry.ErrLocked
+func (s *Service) RetractQuote(ctx context.Context, id string) error {
+	return s.tx.InTx(ctx, func(ctx context.Context) error {
+		q, err := s.r.GetQuoteLine(ctx, strings.TrimSpace(id))
+		if err != nil {
+			return fmt.Errorf("报价不存在: %w", err)
+		}
+		inq, err := s.inquiries.Lock(ctx, q.InquiryID)
+		if err != nil {
+			return fmt.Errorf("询价单不存在: %w", err)
+		}
+		if inquiry.Locked(inq.Status) {
+			return inquiry.ErrLocked
+		}
+		return s.r.DeleteQuoteLine(ctx, q.ID)
+	})
+}
+
+// ListQuotes 返回询价单的供应商报价及其异常标记
+func (s *Service) ListQuotes(ctx context.Context, inquiryID string) ([]domain.QuoteLine, error) {
+	list, err := s.r.ListQuoteLines(ctx, strings.TrimSpace(inquiryID))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(list))
+	for i, l := range list {
+		ids[i] = l.ID
+	}
+	byLine, err := s.r.FlagsOf(ctx, domain.LineQuote, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Flags = flagsOrEmpty(byLine[list[i].ID])
+	}
+	return list, nil
+}
+
+// GetRule 返回机构的异常检测规则，未配置时返回默认规则
+func (s *Service) GetRule(ctx context.Context, orgID string) (*domain.Rule, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	return s.rule(ctx, orgID)
+}
+
+func (s *Service) SaveRule(ctx context.Context, p RuleParams) (*domain.Rule, error) {
+	orgID := strings.TrimSpace(p.OrgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if !p.BandPct.IsPositive() || !p.SpreadPct.IsPositive() {
+		return nil, errors.New("band_pct 与 spread_pct 必须大于 0")
+	}
+	if p.Window < 1 || p.MinSamples < 1 || p.MinSamples > p.Window {
+		return nil, errors.New("window_size 须 ≥ 1，min_samples 须在 1 与 window_size 之间")
+	}
+	m := &domain.Rule{
+		OrgID:      orgID,
+		BandPct:    p.BandPct.Round(2),
+		SpreadPct:  p.SpreadPct.Round(2),
+		Window:     p.Window,
+		MinSamples: p.MinSamples,
+	}
+	if err := s.r.SaveRule(ctx, m); err != nil {
+		return nil, err
+	}
+	return s.r.GetRule(ctx, orgID)
+}
+
+// ListFlags 异常复核队列（默认只列待复核）
+func (s *Service) ListFlags(ctx context.Context, p FlagListParams) ([]domain.Flag, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.InquiryID, p.GoodsID, p.LineType = utils.NormalizePtr(p.InquiryID), utils.NormalizePtr(p.GoodsID), utils.NormalizePtr(p.LineType)
+	return s.r.ListFlags(ctx, p)
+}
+
+// AcceptFlag 复核人确认价格无误，须填写意见
+func (s *Service) AcceptFlag(ctx context.Context, id, reviewerID string, comment string) error {
+	comment = strings.TrimSpace(comment)
+	if comment == "" {
+		return errors.New("确认异常须填写复核意见")
+	}
+	return s.r.AcceptFlag(ctx, strings.TrimSpace(id), reviewerID, &comment, time.Now())
+}
+
+func (s *Service) rule(ctx context.Context, orgID string) (*domain.Rule, error) {
+	m, err := s.r.GetRule(ctx, orgID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		d := domain.DefaultRule(orgID)
+		return &d, nil
+	}
+	return m, err
+}
+
+// bandFlags 检测各字段相对历史中位数的偏离；历史样本不足或中位数为 0 时不检测
+func bandFlags(values map[string]decimal.Decimal, history []decimal.Decimal, rule *domain.Rule) []domain.Flag {
+	if len(history) < rule.MinSamples || len(history) == 0 {
+		return nil
+	}
+	med := median(history)
+	if !med.IsPositive() {
+		return nil
+	}
+	var out []domain.Flag
+	for _, field := range sortedKeys(values) {
+		v := values[field]
+		dev := v.Sub(med).Mul(hundred).Div(med).Round(2)
+		if dev.Abs().GreaterThan(rule.BandPct) {
+			out = append(out, domain.Flag{
+				Field:     field,
+				Kind:      domain.KindBand,
+				Value:     v,
+				Reference: med.Round(2),
+				Deviation: dev,
+				Limit:     rule.BandPct,
+			})
+		}
+	}
+	return out
+}
+
+// spreadFlags 检测市场价之间的差距：最高价相对最低价超出 SpreadPct 时标记最高价所在市场
+func spreadFlags(values map[string]decimal.Decimal, rule *domain.Rule) []domain.Flag {
+	if len(values) < 2 {
+		return nil
+	}
+	keys := sortedKeys(values)
+	minField, maxField := keys[0], keys[0]
+	for _, k := range keys[1:] {
+		if values[k].LessThan(values[minField]) {
+			minField = k
+		}
+		if values[k].GreaterThan(values[maxField]) {
+			maxField = k
+		}
+	}
+	lo, hi := values[minField], values[maxField]
+	if hi.Equal(lo) {
+		return nil
+	}
+	// 最低价为 0 时差距视为无穷大
+	dev := decimal.NewFromInt(9999999)
+	if lo.IsPositive() {
+		dev = hi.Sub(lo).Mul(hundred).Div(lo).Round(2)
+	}
+	if dev.LessThanOrEqual(rule.SpreadPct) {
+		return nil
+	}
+	return []domain.Flag{{
+		Field:     maxField,
+		Kind:      domain.KindSpread,
+		Value:     hi,
+		Reference: lo,
+		Deviation: dev,
+		Limit:     rule.SpreadPct,
+	}}
+}
+
+func median(vals []decimal.Decimal) decimal.Decimal {
+	sorted := append([]decimal.Decimal(nil), vals...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LessThan(sorted[j]) })
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return sorted[n/2-1].Add(sorted[n/2]).Div(decimal.NewFromInt(2))
+}
+
+func sortedKeys(m map[string]decimal.Decimal) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func flagsOrEmpty(list []domain.Flag) []domain.Flag {
+	if list == nil {
+		return []domain.Flag{}
+	}
+	return list
+}
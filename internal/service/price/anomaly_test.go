@@ -0,0 +1,86 @@
+package price
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/price"
+)
+
+func decs(vals ...string) []decimal.Decimal {
+	out := make([]decimal.Decimal, len(vals))
+	for i, v := range vals {
+		out[i] = decimal.RequireFromString(v)
+	}
+	return out
+}
+
+func prices(kv ...string) map[string]decimal.Decimal {
+	m := make(map[string]decimal.Decimal, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		m[kv[i]] = decimal.RequireFromString(kv[i+1])
+	}
+	return m
+}
+
+// flagText 以 字段:值/参照/偏离 拼接，便于整体比较
+func flagText(list []domain.Flag) string {
+	parts := make([]string, len(list))
+	for i, f := range list {
+		parts[i] = f.Field + ":" + f.Value.String() + "/" + f.Reference.String() + "/" + f.Deviation.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestBandFlags(t *testing.T) {
+	rule := &domain.Rule{BandPct: decimal.NewFromInt(30), MinSamples: 3}
+	cases := []struct {
+		name    string
+		values  map[string]decimal.Decimal
+		history []decimal.Decimal
+		want    string
+	}{
+		{name: "样本不足", values: prices("unit_price", "100"), history: decs("10", "10"), want: ""},
+		{name: "无历史", values: prices("unit_price", "100"), history: nil, want: ""},
+		{name: "中位数为 0", values: prices("unit_price", "5"), history: decs("0", "0", "0"), want: ""},
+		{name: "偏离在范围内", values: prices("unit_price", "12.5"), history: decs("10", "9", "11"), want: ""},
+		{name: "恰好等于上限不标记", values: prices("unit_price", "13"), history: decs("10", "9", "11"), want: ""},
+		{name: "偏高", values: prices("unit_price", "13.01"), history: decs("10", "9", "11"), want: "unit_price:13.01/10/30.1"},
+		{name: "偏低", values: prices("unit_price", "6"), history: decs("10", "9", "11"), want: "unit_price:6/10/-40"},
+		{name: "偶数个样本取中间两数均值", values: prices("unit_price", "15"), history: decs("8", "10", "12", "40"), want: "unit_price:15/11/36.36"},
+		{
+			name:    "多个市场按字段名排序",
+			values:  prices("m2", "20", "m1", "5", "m3", "10"),
+			history: decs("10", "10", "10"),
+			want:    "m1:5/10/-50,m2:20/10/100",
+		},
+	}
+	for _, c := range cases {
+		if got := flagText(bandFlags(c.values, c.history, rule)); got != c.want {
+			t.Errorf("%s: bandFlags = %q，期望 %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSpreadFlags(t *testing.T) {
+	rule := &domain.Rule{SpreadPct: decimal.NewFromInt(50)}
+	cases := []struct {
+		name   string
+		values map[string]decimal.Decimal
+		want   string
+	}{
+		{name: "单个市场", values: prices("m1", "10"), want: ""},
+		{name: "价格相同", values: prices("m1", "10", "m2", "10"), want: ""},
+		{name: "差距在范围内", values: prices("m1", "10", "m2", "14.9"), want: ""},
+		{name: "恰好等于上限不标记", values: prices("m1", "10", "m2", "15"), want: ""},
+		{name: "标记最高价市场", values: prices("m1", "10", "m2", "16", "m3", "12"), want: "m2:16/10/60"},
+		{name: "最低价为 0", values: prices("m1", "0", "m2", "1"), want: "m2:1/0/9999999"},
+		{name: "最高价并列取字段名在前者", values: prices("m3", "20", "m1", "10", "m2", "20"), want: "m2:20/10/100"},
+	}
+	for _, c := range cases {
+		if got := flagText(spreadFlags(c.values, rule)); got != c.want {
+			t.Errorf("%s: spreadFlags = %q，期望 %q", c.name, got, c.want)
+		}
+	}
+}
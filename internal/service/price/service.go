@@ -0,0 +1,276 @@
+package price
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/price"
+	repo "hdzk.cn/foodapp/internal/repository/price"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// OrgTree 组织层级（由组织仓储实现）
+type OrgTree interface {
+	SubtreeIDs(ctx context.Context, rootID string) ([]string, error)
+}
+
+// CategoryTree 品类层级（由品类服务实现）
+type CategoryTree interface {
+	DescendantIDs(ctx context.Context, id string) ([]string, error)
+}
+
+type Service struct {
+	r          repo.Repository
+	inquiries  InquirySource
+	suppliers  SupplierSource
+	orgs       OrgTree
+	categories CategoryTree
+	quals      QualificationChecker
+	rounds     RoundSource
+	tx         utils.Transactor
+}
+
+func NewService(r repo.Repository, inquiries InquirySource, suppliers SupplierSource, orgs OrgTree, categories CategoryTree, quals QualificationChecker, rounds RoundSource, tx utils.Transactor) *Service {
+	return &Service{r: r, inquiries: inquiries, suppliers: suppliers, orgs: orgs, categories: categories, quals: quals, rounds: rounds, tx: tx}
+}
+
+type TrendParams struct {
+	OrgID          string
+	IncludeSubOrgs bool
+	GoodsID        *string
+	CategoryID     *string // 含全部后代品类
+	MarketID       *string // 给出时只看该市场的价格，否则看各市场均价
+	DateFrom       time.Time
+	DateTo         time.Time
+	Granularity    string // inquiry/week/month，默认 inquiry
+}
+
+var hundred = decimal.NewFromInt(100)
+
+// Trend 返回商品（或品类下各商品）在区间内的均价走势，每个商品一条序列。
+// 含下级机构时，商品与品类按各机构的对应记录展开（见 scope）：指定商品时各机构样本并入该商品的序列，
+// 指定品类时按各机构同名品类取商品，每个商品一条序列。
+// 为计算环比与同比，会额外读取 DateFrom 前一年的数据，但只输出区间内的点
+func (s *Service) Trend(ctx context.Context, p TrendParams) (*domain.Trend, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	goodsID, categoryID, marketID := utils.NormalizePtr(p.GoodsID), utils.NormalizePtr(p.CategoryID), utils.NormalizePtr(p.MarketID)
+	if goodsID == nil && categoryID == nil {
+		return nil, errors.New("goods_id 与 category_id 至少填写一个")
+	}
+	from, to := utils.DateOf(p.DateFrom), utils.DateOf(p.DateTo)
+	if to.Before(from) {
+		return nil, errors.New("date_to 不能早于 date_from")
+	}
+	switch p.Granularity {
+	case "":
+		p.Granularity = domain.GranularityInquiry
+	case domain.GranularityInquiry, domain.GranularityWeek, domain.GranularityMonth:
+	default:
+		return nil, fmt.Errorf("不支持的时间粒度: %s", p.Granularity)
+	}
+
+	orgIDs := []string{p.OrgID}
+	if p.IncludeSubOrgs {
+		ids, err := s.orgs.SubtreeIDs(ctx, p.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		orgIDs = ids
+	}
+	goodsIDs, categoryIDs, err := s.scope(ctx, orgIDs, goodsID, categoryID, p.IncludeSubOrgs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同比需要去年同月/同周的数据；按周时多取一周以覆盖跨年 ISO 周
+	lookback := from.AddDate(-1, 0, -7)
+	samples, err := s.r.Samples(ctx, repo.SampleParams{
+		OrgIDs:      orgIDs,
+		GoodsIDs:    goodsIDs,
+		CategoryIDs: categoryIDs,
+		MarketID:    marketID,
+		From:        lookback,
+		To:          to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byGoods := map[string][]domain.Sample{}
+	for _, v := range samples {
+		if goodsID != nil {
+			// 下级机构的对应商品并入所选商品
+			v.GoodsID = *goodsID
+		}
+		byGoods[v.GoodsID] = append(byGoods[v.GoodsID], v)
+	}
+	keys := make([]string, 0, len(byGoods))
+	for k := range byGoods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	out := &domain.Trend{Granularity: p.Granularity, OrgIDs: orgIDs, Series: []domain.TrendSeries{}}
+	for _, gid := range keys {
+		points := series(byGoods[gid], p.Granularity, from)
+		if len(points) == 0 {
+			continue
+		}
+		out.Series = append(out.Series, domain.TrendSeries{GoodsID: gid, Points: points})
+	}
+	return out, nil
+}
+
+// scope 将所选商品/品类换算为样本查询条件。商品与品类按机构建档，含下级机构时
+// 商品取各机构同名同规格同单位的商品，品类取各机构同名品类及其后代
+func (s *Service) scope(ctx context.Context, orgIDs []string, goodsID, categoryID *string, subOrgs bool) ([]string, []string, error) {
+	var goodsIDs, categoryIDs []string
+	if goodsID != nil {
+		goodsIDs = []string{*goodsID}
+		if subOrgs {
+			ids, err := s.r.MatchingGoods(ctx, *goodsID, orgIDs)
+			if err != nil {
+				return nil, nil, err
+			}
+			goodsIDs = ids
+		}
+	}
+	if categoryID != nil {
+		roots := []string{*categoryID}
+		if subOrgs {
+			ids, err := s.r.MatchingCategories(ctx, *categoryID, orgIDs)
+			if err != nil {
+				return nil, nil, err
+			}
+			roots = ids
+		}
+		for _, root := range roots {
+			ids, err := s.categories.DescendantIDs(ctx, root)
+			if err != nil {
+				return nil, nil, err
+			}
+			categoryIDs = append(categoryIDs, ids...)
+		}
+	}
+	return goodsIDs, categoryIDs, nil
+}
+
+// bucket 一个时间段内的样本汇总
+type bucket struct {
+	key      string
+	start    time.Time
+	sum      decimal.Decimal
+	min, max decimal.Decimal
+	n        int
+}
+
+func (b *bucket) add(v domain.Sample) {
+	if b.n == 0 || v.MinPrice.LessThan(b.min) {
+		b.min = v.MinPrice
+	}
+	if b.n == 0 || v.MaxPrice.GreaterThan(b.max) {
+		b.max = v.MaxPrice
+	}
+	b.sum = b.sum.Add(v.AvgPrice)
+	b.n++
+}
+
+func (b *bucket) avg() decimal.Decimal {
+	return b.sum.Div(decimal.NewFromInt(int64(b.n))).Round(2)
+}
+
+// group 按粒度将样本（已按日期升序）归入时间段，返回升序结果及按 key 的索引
+func group(samples []domain.Sample, granularity string) ([]*bucket, map[string]*bucket) {
+	var list []*bucket
+	idx := map[string]*bucket{}
+	for _, v := range samples {
+		key, start := periodOf(utils.DateOf(v.InquiryDate), granularity)
+		b, ok := idx[key]
+		if !ok {
+			b = &bucket{key: key, start: start}
+			idx[key] = b
+			list = append(list, b)
+		}
+		b.add(v)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].start.Before(list[j].start) })
+	return list, idx
+}
+
+func series(samples []domain.Sample, granularity string, from time.Time) []domain.TrendPoint {
+	buckets, _ := group(samples, granularity)
+	// 逐次询价时同比取去年同月均价
+	yoyGranularity := granularity
+	if granularity == domain.GranularityInquiry {
+		yoyGranularity = domain.GranularityMonth
+	}
+	_, yoyIdx := group(samples, yoyGranularity)
+
+	points := []domain.TrendPoint{}
+	for i, b := range buckets {
+		if b.start.Before(from) {
+			continue
+		}
+		pt := domain.TrendPoint{
+			Period:   b.key,
+			Start:    b.start,
+			AvgPrice: b.avg(),
+			MinPrice: b.min,
+			MaxPrice: b.max,
+			Samples:  b.n,
+		}
+		if i > 0 {
+			prev := buckets[i-1].avg()
+			pt.PrevPrice = &prev
+			pt.Change, pt.ChangeRate = diff(pt.AvgPrice, prev)
+		}
+		if ly, ok := yoyIdx[lastYearKey(b.start, yoyGranularity)]; ok {
+			v := ly.avg()
+			pt.LastYear = &v
+			pt.YoYChange, pt.YoYRate = diff(pt.AvgPrice, v)
+		}
+		points = append(points, pt)
+	}
+	return points
+}
+
+// diff 返回差值与百分比变化；基数为 0 时变化率为空
+func diff(cur, base decimal.Decimal) (*decimal.Decimal, *decimal.Decimal) {
+	d := cur.Sub(base)
+	if base.IsZero() {
+		return &d, nil
+	}
+	rate := d.Mul(hundred).Div(base).Round(2)
+	return &d, &rate
+}
+
+// periodOf 返回日期所属时间段的标识与起始日
+func periodOf(day time.Time, granularity string) (string, time.Time) {
+	switch granularity {
+	case domain.GranularityWeek:
+		y, w := day.ISOWeek()
+		offset := (int(day.Weekday()) + 6) % 7
+		return fmt.Sprintf("%d-W%02d", y, w), day.AddDate(0, 0, -offset)
+	case domain.GranularityMonth:
+		return day.Format("2006-01"), time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day.Format("2006-01-02"), day
+	}
+}
+
+// lastYearKey 返回去年同期的时间段标识（同月或同一 ISO 周序号）
+func lastYearKey(start time.Time, granularity string) string {
+	if granularity == domain.GranularityWeek {
+		y, w := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y-1, w)
+	}
+	return start.AddDate(0, 0, 1-start.Day()).AddDate(-1, 0, 0).Format("2006-01")
+}
@@ -0,0 +1,143 @@
+package price
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/price"
+	repo "hdzk.cn/foodapp/internal/repository/price"
+)
+
+// trendRepo 只实现 Trend 用到的方法，其余方法调用时 panic
+type trendRepo struct {
+	repo.Repository
+	goods      map[string][]string // goodsID → 各机构对应商品
+	categories map[string][]string // categoryID → 各机构同名品类
+	samples    []domain.Sample
+	got        repo.SampleParams
+}
+
+func (r *trendRepo) MatchingGoods(_ context.Context, goodsID string, _ []string) ([]string, error) {
+	return r.goods[goodsID], nil
+}
+
+func (r *trendRepo) MatchingCategories(_ context.Context, categoryID string, _ []string) ([]string, error) {
+	return r.categories[categoryID], nil
+}
+
+func (r *trendRepo) Samples(_ context.Context, p repo.SampleParams) ([]domain.Sample, error) {
+	r.got = p
+	var out []domain.Sample
+	for _, v := range r.samples {
+		if slices.Contains(p.GoodsIDs, v.GoodsID) {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+type orgTree map[string][]string
+
+func (t orgTree) SubtreeIDs(_ context.Context, id string) ([]string, error) { return t[id], nil }
+
+type categoryTree map[string][]string
+
+func (t categoryTree) DescendantIDs(_ context.Context, id string) ([]string, error) {
+	return t[id], nil
+}
+
+func sample(goodsID, orgID, day, price string) domain.Sample {
+	d, _ := time.Parse("2006-01-02", day)
+	p := decimal.RequireFromString(price)
+	return domain.Sample{GoodsID: goodsID, OrgID: orgID, InquiryDate: d, AvgPrice: p, MinPrice: p, MaxPrice: p}
+}
+
+func TestTrendSubOrgs(t *testing.T) {
+	samples := []domain.Sample{
+		sample("g-root", "root", "2026-03-02", "10.00"),
+		sample("g-sub", "sub", "2026-03-02", "12.00"),
+		sample("g-sub", "sub", "2026-03-09", "14.00"),
+	}
+	goodsID, categoryID := "g-root", "c-root"
+	cases := []struct {
+		name       string
+		subOrgs    bool
+		goodsID    *string
+		categoryID *string
+		wantOrgs   []string
+		wantGoods  []string
+		wantCats   []string
+		wantSeries map[string][]string // goodsID → 各点均价
+	}{
+		{
+			name: "本机构商品", goodsID: &goodsID,
+			wantOrgs: []string{"root"}, wantGoods: []string{"g-root"},
+			wantSeries: map[string][]string{"g-root": {"10"}},
+		},
+		{
+			name: "含下级机构时对应商品并入所选商品", subOrgs: true, goodsID: &goodsID,
+			wantOrgs: []string{"root", "sub"}, wantGoods: []string{"g-root", "g-sub"},
+			wantSeries: map[string][]string{"g-root": {"11", "14"}},
+		},
+		{
+			name: "本机构品类", categoryID: &categoryID,
+			wantOrgs: []string{"root"}, wantCats: []string{"c-root", "c-root-child"},
+			wantSeries: map[string][]string{},
+		},
+		{
+			name: "含下级机构时展开各机构同名品类", subOrgs: true, categoryID: &categoryID,
+			wantOrgs: []string{"root", "sub"}, wantCats: []string{"c-root", "c-root-child", "c-sub"},
+			wantSeries: map[string][]string{},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := &trendRepo{
+				goods:      map[string][]string{"g-root": {"g-root", "g-sub"}},
+				categories: map[string][]string{"c-root": {"c-root", "c-sub"}},
+				samples:    samples,
+			}
+			s := NewService(r, nil, nil,
+				orgTree{"root": {"root", "sub"}},
+				categoryTree{"c-root": {"c-root", "c-root-child"}, "c-sub": {"c-sub"}},
+				nil, nil, nil)
+			out, err := s.Trend(context.Background(), TrendParams{
+				OrgID:          "root",
+				IncludeSubOrgs: c.subOrgs,
+				GoodsID:        c.goodsID,
+				CategoryID:     c.categoryID,
+				DateFrom:       time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+				DateTo:         time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(out.OrgIDs, c.wantOrgs) {
+				t.Errorf("OrgIDs = %v，期望 %v", out.OrgIDs, c.wantOrgs)
+			}
+			if !slices.Equal(r.got.GoodsIDs, c.wantGoods) {
+				t.Errorf("GoodsIDs = %v，期望 %v", r.got.GoodsIDs, c.wantGoods)
+			}
+			if !slices.Equal(r.got.CategoryIDs, c.wantCats) {
+				t.Errorf("CategoryIDs = %v，期望 %v", r.got.CategoryIDs, c.wantCats)
+			}
+			if len(out.Series) != len(c.wantSeries) {
+				t.Fatalf("序列数 = %d，期望 %d", len(out.Series), len(c.wantSeries))
+			}
+			for _, ser := range out.Series {
+				want := c.wantSeries[ser.GoodsID]
+				if len(ser.Points) != len(want) {
+					t.Fatalf("商品 %s 点数 = %d，期望 %d", ser.GoodsID, len(ser.Points), len(want))
+				}
+				for i, pt := range ser.Points {
+					if !pt.AvgPrice.Equal(decimal.RequireFromString(want[i])) {
+						t.Errorf("商品 %s 第 %d 点均价 = %s，期望 %s", ser.GoodsID, i+1, pt.AvgPrice, want[i])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package purchase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/purchase"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/purchase"
+	dictsvc "hdzk.cn/foodapp/internal/service/dict"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// SupplierSource 供应商及浮动比例历史（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+	FloatRatioOn(ctx context.Context, supplierID string, at time.Time) (*supplier.FloatRatio, error)
+}
+
+// QualificationChecker 供应商必备资质校验（由资质服务实现）
+type QualificationChecker interface {
+	Check(ctx context.Context, supplierID string, at time.Time) error
+}
+
+type Service struct {
+	r         repo.Repository
+	suppliers SupplierSource
+	quals     QualificationChecker
+	units     dictsvc.UnitConverter
+}
+
+func NewService(r repo.Repository, suppliers SupplierSource, quals QualificationChecker, units dictsvc.UnitConverter) *Service {
+	return &Service{r: r, suppliers: suppliers, quals: quals, units: units}
+}
+
+type LineParams struct {
+	GoodsID     string
+	UnitID      string
+	Quantity    decimal.Decimal
+	UnitPrice   decimal.Decimal
+	FloatRatio  *decimal.Decimal // 为空取期望到货日期生效的供应商浮动比例
+	InquiryID   *string
+	Explanation *string
+	Sort        int
+}
+
+type CreateParams struct {
+	OrgID        string
+	SupplierID   string
+	ExpectedDate time.Time
+	Source       string
+	Remark       *string
+	OperatorID   *string
+	Lines        []LineParams
+}
+
+type ListParams = repo.ListParams
+
+// Create 创建草稿采购单；供应商须当前及期望到货日期均在合同期内且必备资质未过期
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Order, error) {
+	if strings.TrimSpace(p.OrgID) == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	if strings.TrimSpace(p.SupplierID) == "" {
+		return nil, fmt.Errorf("supplier_id 不能为空")
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+	if sup.OrgID != strings.TrimSpace(p.OrgID) {
+		return nil, fmt.Errorf("供应商不属于该机构")
+	}
+	if err := sup.CheckActive(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := sup.CheckActive(p.ExpectedDate); err != nil {
+		return nil, fmt.Errorf("期望到货日期 %s 不在合同期内: %w", p.ExpectedDate.Format("2006-01-02"), err)
+	}
+	for _, at := range []time.Time{time.Now(), p.ExpectedDate} {
+		if err := s.quals.Check(ctx, sup.ID, at); err != nil {
+			return nil, err
+		}
+	}
+	if len(p.Lines) == 0 {
+		return nil, fmt.Errorf("采购单至少需要一行明细")
+	}
+	source := strings.TrimSpace(p.Source)
+	if source == "" {
+		source = domain.SourceManual
+	}
+
+	// 未指定比例的明细按期望到货日期生效的供应商浮动比例结算
+	effective, err := s.suppliers.FloatRatioOn(ctx, sup.ID, p.ExpectedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &domain.Order{
+		OrgID:        strings.TrimSpace(p.OrgID),
+		SupplierID:   strings.TrimSpace(p.SupplierID),
+		ExpectedDate: p.ExpectedDate,
+		Status:       domain.StatusDraft,
+		Source:       source,
+		Remark:       utils.NormalizePtr(p.Remark),
+		OperatorID:   utils.NormalizePtr(p.OperatorID),
+	}
+	for i, l := range p.Lines {
+		if strings.TrimSpace(l.GoodsID) == "" || strings.TrimSpace(l.UnitID) == "" {
+			return nil, fmt.Errorf("第 %d 行 goods_id/unit_id 不能为空", i+1)
+		}
+		if !l.Quantity.IsPositive() {
+			return nil, fmt.Errorf("第 %d 行数量必须大于 0", i+1)
+		}
+		if l.UnitPrice.IsNegative() {
+			return nil, fmt.Errorf("第 %d 行单价不能为负数", i+1)
+		}
+		ratio := effective.FloatRatio
+		if l.FloatRatio != nil {
+			if !l.FloatRatio.IsPositive() {
+				return nil, fmt.Errorf("第 %d 行浮动比例必须大于 0", i+1)
+			}
+			ratio = *l.FloatRatio
+		}
+		m.Lines = append(m.Lines, domain.Line{
+			GoodsID:     strings.TrimSpace(l.GoodsID),
+			UnitID:      strings.TrimSpace(l.UnitID),
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			FloatRatio:  ratio,
+			InquiryID:   utils.NormalizePtr(l.InquiryID),
+			Explanation: l.Explanation,
+			Sort:        l.Sort,
+		})
+	}
+	return m, s.r.Create(ctx, m)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Order, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Order, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, fmt.Errorf("org_id 不能为空")
+	}
+	p.SupplierID = utils.NormalizePtr(p.SupplierID)
+	return s.r.List(ctx, p)
+}
+
+// Submit 草稿 → 已提交
+func (s *Service) Submit(ctx context.Context, id string) error {
+	return s.r.UpdateStatus(ctx, strings.TrimSpace(id), domain.StatusDraft, domain.StatusSubmitted)
+}
+
+// Cancel 已提交 → 已作废
+func (s *Service) Cancel(ctx context.Context, id string) error {
+	return s.r.UpdateStatus(ctx, strings.TrimSpace(id), domain.StatusSubmitted, domain.StatusCancelled)
+}
+
+type ReceiveLine struct {
+	LineID      string
+	ReceivedQty decimal.Decimal
+	UnitID      string // 实收（称重）单位，为空表示与明细单位相同
+}
+
+type ReceiveParams struct {
+	ID         string
+	ReceivedAt time.Time
+	ReceivedBy *string
+	Lines      []ReceiveLine
+}
+
+// Receive 已提交 → 已收货，登记到货时间与各明细实收数量（未列出的明细视为未到货）。
+// 实收按称重单位录入时换算为明细（报价）单位保存，与订购数量、单价直接可比
+func (s *Service) Receive(ctx context.Context, p ReceiveParams) (*domain.Order, error) {
+	if p.ReceivedAt.IsZero() {
+		p.ReceivedAt = time.Now()
+	}
+	if p.ReceivedAt.After(time.Now()) {
+		return nil, fmt.Errorf("到货时间不能晚于当前时间")
+	}
+	id := strings.TrimSpace(p.ID)
+	order, err := s.r.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]domain.Line, len(order.Lines))
+	for _, l := range order.Lines {
+		byID[l.ID] = l
+	}
+	qty := make(map[string]decimal.Decimal, len(p.Lines))
+	for i, l := range p.Lines {
+		lineID := strings.TrimSpace(l.LineID)
+		if lineID == "" {
+			return nil, fmt.Errorf("第 %d 行 line_id 不能为空", i+1)
+		}
+		if l.ReceivedQty.IsNegative() {
+			return nil, fmt.Errorf("第 %d 行实收数量不能为负数", i+1)
+		}
+		if _, dup := qty[lineID]; dup {
+			return nil, fmt.Errorf("明细 %s 重复", lineID)
+		}
+		line, ok := byID[lineID]
+		if !ok {
+			return nil, fmt.Errorf("明细 %s 不属于该采购单", lineID)
+		}
+		received := l.ReceivedQty
+		if unitID := strings.TrimSpace(l.UnitID); unitID != "" && unitID != line.UnitID {
+			gid := line.GoodsID
+			conv, err := s.units.ConvertQuantity(ctx, received, unitID, line.UnitID, &gid)
+			if err != nil {
+				return nil, fmt.Errorf("第 %d 行实收单位无法换算为明细单位: %w", i+1, err)
+			}
+			received = conv.Round(domain.QtyScale)
+		}
+		qty[lineID] = received
+	}
+	if err := s.r.Receive(ctx, repo.ReceiveParams{
+		ID:         id,
+		ReceivedAt: p.ReceivedAt,
+		ReceivedBy: utils.NormalizePtr(p.ReceivedBy),
+		Lines:      qty,
+	}); err != nil {
+		return nil, err
+	}
+	return s.r.Get(ctx, id)
+}
+
+func (s *Service) SoftDelete(ctx context.Context, id string) error {
+	return s.r.SoftDeleteDraft(ctx, strings.TrimSpace(id))
+}
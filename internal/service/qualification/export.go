@@ -0,0 +1,141 @@
+package qualification
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/qualification"
+	repo "hdzk.cn/foodapp/internal/repository/qualification"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+var verifyNames = map[int]string{
+	domain.VerifyPending:  "待审核",
+	domain.VerifyApproved: "已审核",
+	domain.VerifyRejected: "已驳回",
+}
+
+// Export 导出资质清单与文件（zip）：清单.csv（含状态），文件按 供应商/资质类型 归档。
+// supplierID 为空时导出机构全部供应商
+func (s *Service) Export(ctx context.Context, orgID string, supplierID *string, at time.Time, w io.Writer) error {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return errors.New("org_id 不能为空")
+	}
+	docs, _, err := s.r.List(ctx, repo.ListParams{OrgID: orgID, SupplierID: utils.NormalizePtr(supplierID), PageSize: -1})
+	if err != nil {
+		return err
+	}
+	names := map[string]string{}
+	for _, d := range docs {
+		if _, ok := names[d.SupplierID]; ok {
+			continue
+		}
+		sup, err := s.suppliers.GetSupplier(ctx, d.SupplierID)
+		if err != nil {
+			names[d.SupplierID] = d.SupplierID
+			continue
+		}
+		names[d.SupplierID] = sup.Name
+	}
+
+	zw := zip.NewWriter(w)
+	manifest, err := zw.CreateHeader(&zip.FileHeader{Name: "清单.csv", Method: zip.Deflate, Modified: at})
+	if err != nil {
+		return err
+	}
+	// 带 BOM，便于 Excel 直接打开
+	if _, err := manifest.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(manifest)
+	_ = cw.Write([]string{"供应商", "资质类型", "证照编号", "发证日期", "有效期至", "审核状态", "是否有效", "原始文件名", "归档文件", "SHA256"})
+	files := make([]string, len(docs))
+	for i, d := range docs {
+		t, _ := domain.LookupType(d.DocType)
+		files[i] = path.Join(safeName(names[d.SupplierID]), safeName(t.Name), fmt.Sprintf("%02d_%s", i+1, safeName(d.FileName)))
+		valid := "否"
+		if d.ValidOn(at) {
+			valid = "是"
+		}
+		_ = cw.Write([]string{
+			names[d.SupplierID], t.Name, deref(d.DocNo), dateStr(d.IssueDate), expiryStr(d.ExpiryDate),
+			verifyNames[d.VerifyStatus], valid, d.FileName, files[i], d.SHA256,
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+
+	for i, d := range docs {
+		if err := s.addFile(zw, files[i], d, at); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func (s *Service) addFile(zw *zip.Writer, name string, d domain.Document, at time.Time) error {
+	f, err := os.Open(s.abs(d.FilePath))
+	if err != nil {
+		// 文件缺失时写入说明，不中断整体导出
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name + ".缺失.txt", Method: zip.Deflate, Modified: at})
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "资质文件缺失："+d.FilePath+"\n")
+		return err
+	}
+	defer f.Close()
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: d.CreatedAt})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// safeName 去除归档路径中不安全的字符
+func safeName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+	if s == "" || s == "." || s == ".." {
+		return "_"
+	}
+	return s
+}
+
+func deref(p *string) string {
+	if p == nil {
+		return ""
+	}
+	return *p
+}
+
+func dateStr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func expiryStr(t *time.Time) string {
+	if t == nil {
+		return "长期"
+	}
+	return t.Format("2006-01-02")
+}
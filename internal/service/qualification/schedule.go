@@ -0,0 +1,121 @@
+package qualification
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	notification "hdzk.cn/foodapp/internal/domain/notification"
+	domain "hdzk.cn/foodapp/internal/domain/qualification"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// Notifier 站内通知（由通知服务实现）
+type Notifier interface {
+	Notify(ctx context.Context, n *notification.Notification) (bool, error)
+}
+
+// lapseLookback 已过期必备资质的提醒回看天数（超过后不再补发）
+const lapseLookback = 30
+
+// RunExpirySchedule 对 noticeDays 内到期的已审核资质按提醒档各提醒一次（已有续期文件的不提醒），
+// 并对近期过期且未续期的必备资质提醒一次；返回新发送的通知数
+func (s *Service) RunExpirySchedule(ctx context.Context, now time.Time, noticeDays []int) (int, error) {
+	if s.notifier == nil {
+		return 0, nil
+	}
+	today := utils.DateOf(now)
+	days := append([]int(nil), noticeDays...)
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	maxDays := 0
+	if len(days) > 0 && days[0] > 0 {
+		maxDays = days[0]
+	}
+
+	docs, err := s.r.ExpiringApproved(ctx, today.AddDate(0, 0, -lapseLookback), today.AddDate(0, 0, maxDays))
+	if err != nil {
+		return 0, err
+	}
+	status := map[string][]domain.TypeStatus{} // 供应商 → 各类资质状态
+	sent := 0
+	for _, d := range docs {
+		st, ok := status[d.SupplierID]
+		if !ok {
+			all, err := s.r.ListBySupplier(ctx, d.SupplierID)
+			if err != nil {
+				return sent, err
+			}
+			st = domain.Summarize(all, today, 0)
+			status[d.SupplierID] = st
+		}
+		ts := typeStatus(st, d.DocType)
+		// 同类资质还有更晚到期（或长期）的有效文件，视为已续期
+		if ts.DocumentID != nil && *ts.DocumentID != d.ID && (ts.ExpiryDate == nil || ts.ExpiryDate.After(*d.ExpiryDate)) {
+			continue
+		}
+		name := d.SupplierID
+		if sup, err := s.suppliers.GetSupplier(ctx, d.SupplierID); err == nil {
+			name = sup.Name
+		}
+		expiry := d.ExpiryDate.Format("2006-01-02")
+
+		if d.ExpiryDate.Before(today) {
+			if !ts.Mandatory {
+				continue
+			}
+			ok, err := s.notify(ctx, d, notification.KindDocLapsed,
+				fmt.Sprintf("供应商「%s」的%s已于 %s 过期，已禁止报价和下单", name, ts.Name, expiry),
+				"lapsed:"+expiry)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+			continue
+		}
+
+		left := int(d.ExpiryDate.Sub(today).Hours()/24) + 1 // 含到期日当天
+		tier := 0
+		for _, n := range days {
+			if n > 0 && left <= n {
+				tier = n
+			}
+		}
+		if tier == 0 {
+			continue
+		}
+		ok, err := s.notify(ctx, d, notification.KindDocExpiring,
+			fmt.Sprintf("供应商「%s」的%s将在 %d 天内到期（%s）", name, ts.Name, left, expiry),
+			fmt.Sprintf("expiring:%s:%d", expiry, tier))
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+func (s *Service) notify(ctx context.Context, d domain.Document, kind, title, key string) (bool, error) {
+	return s.notifier.Notify(ctx, &notification.Notification{
+		OrgID:    d.OrgID,
+		Kind:     kind,
+		RefType:  "supplier_document",
+		RefID:    d.ID,
+		Title:    title,
+		DedupKey: "doc:" + d.ID + ":" + key,
+	})
+}
+
+func typeStatus(list []domain.TypeStatus, code string) domain.TypeStatus {
+	for _, st := range list {
+		if st.Code == code {
+			return st
+		}
+	}
+	t, _ := domain.LookupType(code)
+	return domain.TypeStatus{DocType: t, State: domain.StateMissing}
+}
@@ -0,0 +1,256 @@
+package qualification
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	domain "hdzk.cn/foodapp/internal/domain/qualification"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/qualification"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// SupplierSource 供应商（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+}
+
+type Service struct {
+	r         repo.Repository
+	suppliers SupplierSource
+	notifier  Notifier
+	dir       string // 上传文件根目录
+	maxBytes  int64
+}
+
+// NewService dir 为上传文件根目录，资质文件存放在其下 supplier_docs/<机构>/<供应商>/
+func NewService(r repo.Repository, suppliers SupplierSource, notifier Notifier, dir string, maxUploadMB int) *Service {
+	return &Service{r: r, suppliers: suppliers, notifier: notifier, dir: dir, maxBytes: int64(maxUploadMB) << 20}
+}
+
+// 允许上传的文件类型（按内容识别）
+var allowedTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
+
+type UploadParams struct {
+	SupplierID string
+	DocType    string
+	DocNo      *string
+	IssueDate  *time.Time
+	ExpiryDate *time.Time
+	Remark     *string
+	FileName   string
+	File       io.Reader
+	UploadedBy *string
+}
+
+// Upload 保存资质文件并登记，新文件为待审核状态
+func (s *Service) Upload(ctx context.Context, p UploadParams) (*domain.Document, error) {
+	if _, ok := domain.LookupType(p.DocType); !ok {
+		return nil, fmt.Errorf("不支持的资质类型: %s", p.DocType)
+	}
+	if err := checkDates(p.IssueDate, p.ExpiryDate); err != nil {
+		return nil, err
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(p.File, s.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("文件为空")
+	}
+	if int64(len(data)) > s.maxBytes {
+		return nil, fmt.Errorf("文件超过 %d MB", s.maxBytes>>20)
+	}
+	ctype := http.DetectContentType(data)
+	ext, ok := allowedTypes[ctype]
+	if !ok {
+		return nil, fmt.Errorf("仅支持 PDF/JPG/PNG 文件，当前为 %s", ctype)
+	}
+	sum := sha256.Sum256(data)
+
+	d := &domain.Document{
+		ID:           uuid.NewString(),
+		OrgID:        sup.OrgID,
+		SupplierID:   sup.ID,
+		DocType:      p.DocType,
+		DocNo:        utils.NormalizePtr(p.DocNo),
+		IssueDate:    p.IssueDate,
+		ExpiryDate:   p.ExpiryDate,
+		FileName:     filepath.Base(strings.TrimSpace(p.FileName)),
+		FileSize:     int64(len(data)),
+		ContentType:  ctype,
+		SHA256:       hex.EncodeToString(sum[:]),
+		VerifyStatus: domain.VerifyPending,
+		Remark:       utils.NormalizePtr(p.Remark),
+		UploadedBy:   utils.NormalizePtr(p.UploadedBy),
+	}
+	if d.FileName == "." || d.FileName == string(filepath.Separator) {
+		d.FileName = d.ID + ext
+	}
+	d.FilePath = filepath.ToSlash(filepath.Join("supplier_docs", sup.OrgID, sup.ID, d.ID+ext))
+	if err := s.writeFile(d.FilePath, data); err != nil {
+		return nil, err
+	}
+	if err := s.r.Create(ctx, d); err != nil {
+		_ = os.Remove(s.abs(d.FilePath))
+		return nil, err
+	}
+	return d, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Document, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+type ListParams = repo.ListParams
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Document, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	return s.r.List(ctx, p)
+}
+
+type UpdateParams = repo.UpdateParams
+
+// Update 修改证照编号/日期/备注；编号或日期变化后需重新审核
+func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	cur, err := s.r.Get(ctx, strings.TrimSpace(p.ID))
+	if err != nil {
+		return err
+	}
+	issue, expiry := cur.IssueDate, cur.ExpiryDate
+	if p.UpdateIssueDate {
+		issue = p.IssueDate
+	}
+	if p.UpdateExpiry {
+		expiry = p.ExpiryDate
+	}
+	if err := checkDates(issue, expiry); err != nil {
+		return err
+	}
+	p.ID = cur.ID
+	p.DocNo, p.Remark = utils.NormalizePtr(p.DocNo), utils.NormalizePtr(p.Remark)
+	return s.r.Update(ctx, p)
+}
+
+// Verify 审核资质文件：approve=false 为驳回（须填写意见）
+func (s *Service) Verify(ctx context.Context, id string, approve bool, remark, operatorID *string) (*domain.Document, error) {
+	remark = utils.NormalizePtr(remark)
+	status := domain.VerifyApproved
+	if !approve {
+		if remark == nil {
+			return nil, errors.New("驳回时须填写审核意见")
+		}
+		status = domain.VerifyRejected
+	}
+	id = strings.TrimSpace(id)
+	if err := s.r.Verify(ctx, repo.VerifyParams{
+		ID:         id,
+		Status:     status,
+		Remark:     remark,
+		VerifiedBy: utils.NormalizePtr(operatorID),
+		VerifiedAt: time.Now(),
+	}); err != nil {
+		return nil, err
+	}
+	return s.r.Get(ctx, id)
+}
+
+// Delete 软删登记记录；文件保留以备追溯
+func (s *Service) Delete(ctx context.Context, id string) error {
+	return s.r.SoftDelete(ctx, strings.TrimSpace(id))
+}
+
+// Open 打开资质文件，调用方负责关闭
+func (s *Service) Open(ctx context.Context, id string) (*domain.Document, *os.File, error) {
+	d, err := s.r.Get(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(s.abs(d.FilePath))
+	if err != nil {
+		return nil, nil, fmt.Errorf("资质文件缺失: %w", err)
+	}
+	return d, f, nil
+}
+
+// Compliance 供应商各类资质在 at 当日的状态；warnDays 天内到期为即将到期
+func (s *Service) Compliance(ctx context.Context, supplierID string, at time.Time, warnDays int) ([]domain.TypeStatus, error) {
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(supplierID))
+	if err != nil {
+		return nil, err
+	}
+	docs, err := s.r.ListBySupplier(ctx, sup.ID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.Summarize(docs, at, warnDays), nil
+}
+
+// Check 必备资质在 at 当日已过期时返回包装 ErrLapsed 的错误。
+// 只拦截“曾有已审核文件、现已全部过期”的资质；从未登记的资质不拦截，在资质状态中显示为缺失
+func (s *Service) Check(ctx context.Context, supplierID string, at time.Time) error {
+	docs, err := s.r.ListBySupplier(ctx, supplierID)
+	if err != nil {
+		return err
+	}
+	var lapsed []string
+	for _, st := range domain.Summarize(docs, at, 0) {
+		if st.Mandatory && st.State == domain.StateLapsed {
+			lapsed = append(lapsed, st.Name)
+		}
+	}
+	if len(lapsed) > 0 {
+		return fmt.Errorf("%w：%s（%s）", domain.ErrLapsed, strings.Join(lapsed, "、"), at.Format("2006-01-02"))
+	}
+	return nil
+}
+
+func (s *Service) abs(rel string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(rel))
+}
+
+// writeFile 先写临时文件再改名，避免留下不完整的文件
+func (s *Service) writeFile(rel string, data []byte) error {
+	path := s.abs(rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("创建存储目录失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("保存文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("保存文件失败: %w", err)
+	}
+	return nil
+}
+
+func checkDates(issue, expiry *time.Time) error {
+	if issue != nil && expiry != nil && expiry.Before(*issue) {
+		return errors.New("有效期至不能早于发证日期")
+	}
+	return nil
+}
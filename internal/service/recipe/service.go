@@ -0,0 +1,388 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	mealplan "hdzk.cn/foodapp/internal/domain/mealplan"
+	domain "hdzk.cn/foodapp/internal/domain/recipe"
+	goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
+	mealplanrepo "hdzk.cn/foodapp/internal/repository/mealplan"
+	pricerepo "hdzk.cn/foodapp/internal/repository/price"
+	repo "hdzk.cn/foodapp/internal/repository/recipe"
+	dictsvc "hdzk.cn/foodapp/internal/service/dict"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type Service struct {
+	r      repo.Repository
+	goods  goodsrepo.GoodsRepository
+	plans  mealplanrepo.Repository
+	prices pricerepo.Repository
+	units  dictsvc.UnitConverter
+}
+
+func NewService(r repo.Repository, goods goodsrepo.GoodsRepository, plans mealplanrepo.Repository, prices pricerepo.Repository, units dictsvc.UnitConverter) *Service {
+	return &Service{r: r, goods: goods, plans: plans, prices: prices, units: units}
+}
+
+type LineParams struct {
+	GoodsID       string
+	QtyPerServing decimal.Decimal
+	UnitID        string
+	YieldRate     *decimal.Decimal // 为空按 1
+	LossRate      *decimal.Decimal // 为空按 0
+	Sort          int
+}
+
+type CreateParams struct {
+	OrgID         string
+	Name          string
+	Remark        *string
+	VersionRemark *string
+	OperatorID    *string
+	Lines         []LineParams
+}
+
+type UpdateParams = repo.UpdateParams
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Recipe, *domain.Version, error) {
+	orgID, name := strings.TrimSpace(p.OrgID), strings.TrimSpace(p.Name)
+	if orgID == "" {
+		return nil, nil, fmt.Errorf("org_id 不能为空")
+	}
+	if name == "" {
+		return nil, nil, fmt.Errorf("name 不能为空")
+	}
+	if exists, err := s.r.ExistsName(ctx, orgID, name, ""); err != nil {
+		return nil, nil, err
+	} else if exists {
+		return nil, nil, fmt.Errorf("配方「%s」已存在", name)
+	}
+	lines, err := s.buildLines(ctx, orgID, p.Lines)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := &domain.Recipe{OrgID: orgID, Name: name, Remark: utils.NormalizePtr(p.Remark)}
+	v := &domain.Version{Remark: utils.NormalizePtr(p.VersionRemark), OperatorID: utils.NormalizePtr(p.OperatorID), Lines: lines}
+	if err := s.r.Create(ctx, m, v); err != nil {
+		return nil, nil, err
+	}
+	return m, v, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Recipe, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, orgID, keyword string, page, pageSize int) ([]domain.Recipe, int64, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, 0, fmt.Errorf("org_id 不能为空")
+	}
+	return s.r.List(ctx, orgID, strings.TrimSpace(keyword), page, pageSize)
+}
+
+func (s *Service) Update(ctx context.Context, p UpdateParams) error {
+	p.ID = strings.TrimSpace(p.ID)
+	if p.Name != nil {
+		name := strings.TrimSpace(*p.Name)
+		if name == "" {
+			return fmt.Errorf("name 不能为空")
+		}
+		m, err := s.r.Get(ctx, p.ID)
+		if err != nil {
+			return err
+		}
+		if exists, err := s.r.ExistsName(ctx, m.OrgID, name, m.ID); err != nil {
+			return err
+		} else if exists {
+			return fmt.Errorf("配方「%s」已存在", name)
+		}
+		p.Name = &name
+	}
+	return s.r.Update(ctx, p)
+}
+
+func (s *Service) SoftDelete(ctx context.Context, id string) error {
+	return s.r.SoftDelete(ctx, strings.TrimSpace(id))
+}
+
+// AddVersion 修改配方即追加新版本，旧版本保留可追溯、可回退
+func (s *Service) AddVersion(ctx context.Context, recipeID string, remark, operatorID *string, lines []LineParams) (*domain.Version, error) {
+	recipeID = strings.TrimSpace(recipeID)
+	m, err := s.r.Get(ctx, recipeID)
+	if err != nil {
+		return nil, err
+	}
+	built, err := s.buildLines(ctx, m.OrgID, lines)
+	if err != nil {
+		return nil, err
+	}
+	v := &domain.Version{Remark: utils.NormalizePtr(remark), OperatorID: utils.NormalizePtr(operatorID), Lines: built}
+	return v, s.r.AddVersion(ctx, recipeID, v)
+}
+
+func (s *Service) ListVersions(ctx context.Context, recipeID string) ([]domain.Version, error) {
+	return s.r.ListVersions(ctx, strings.TrimSpace(recipeID))
+}
+
+func (s *Service) GetVersion(ctx context.Context, recipeID string, version int) (*domain.Version, error) {
+	return s.r.GetVersion(ctx, strings.TrimSpace(recipeID), version)
+}
+
+func (s *Service) SetCurrentVersion(ctx context.Context, recipeID string, version int) error {
+	if version <= 0 {
+		return fmt.Errorf("version 非法")
+	}
+	return s.r.SetCurrentVersion(ctx, strings.TrimSpace(recipeID), version)
+}
+
+// Cost 按最近一次询价均价计算配方成本；用量先换算到商品单位再乘单价
+func (s *Service) Cost(ctx context.Context, recipeID string, version, servings int, asOf *time.Time) (*domain.Cost, error) {
+	if servings <= 0 {
+		servings = 1
+	}
+	m, err := s.r.Get(ctx, strings.TrimSpace(recipeID))
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.r.GetVersion(ctx, m.ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	goodsIDs := make([]string, 0, len(v.Lines))
+	for _, l := range v.Lines {
+		goodsIDs = append(goodsIDs, l.GoodsID)
+	}
+	goodsUnits, err := s.goods.GoodsUnits(ctx, goodsIDs)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.prices.LatestAvgPrices(ctx, m.OrgID, goodsIDs, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	n := decimal.NewFromInt(int64(servings))
+	out := &domain.Cost{RecipeID: m.ID, Version: v.Version, Servings: servings, Total: decimal.Zero, Complete: true}
+	for _, l := range v.Lines {
+		gid := l.GoodsID
+		cl := domain.CostLine{
+			GoodsID:     gid,
+			GrossQty:    l.GrossPerServing().Mul(n),
+			UnitID:      l.UnitID,
+			GoodsUnitID: goodsUnits[gid],
+		}
+		qty, err := s.units.ConvertQuantity(ctx, cl.GrossQty, l.UnitID, cl.GoodsUnitID, &gid)
+		if err != nil {
+			cl.Warning = "用量无法换算到商品单位: " + err.Error()
+			out.Complete = false
+			out.Lines = append(out.Lines, cl)
+			continue
+		}
+		cl.GoodsUnitQty = &qty
+		p, ok := prices[gid]
+		if !ok {
+			cl.Warning = "商品尚无询价均价"
+			out.Complete = false
+			out.Lines = append(out.Lines, cl)
+			continue
+		}
+		cost := qty.Mul(p.AvgPrice).Round(2)
+		cl.Price, cl.PriceDate, cl.Cost = &p.AvgPrice, &p.InquiryDate, &cost
+		out.Total = out.Total.Add(cost)
+		out.Lines = append(out.Lines, cl)
+	}
+	out.PerServing = out.Total.DivRound(n, 2)
+	return out, nil
+}
+
+// ExpandPlan 将单个餐次计划展开为商品需求
+func (s *Service) ExpandPlan(ctx context.Context, planID string) ([]domain.Demand, []string, error) {
+	p, err := s.plans.Get(ctx, strings.TrimSpace(planID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.expand(ctx, []mealplan.Plan{*p})
+}
+
+// ExpandPlans 将 [from, to] 内的餐次计划（菜品 × 份数）展开为商品需求清单
+func (s *Service) ExpandPlans(ctx context.Context, orgID string, mealID *string, from, to time.Time) ([]domain.Demand, []string, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, nil, fmt.Errorf("org_id 不能为空")
+	}
+	if to.Before(from) {
+		return nil, nil, fmt.Errorf("date_to 不能早于 date_from")
+	}
+	plans, err := s.plans.ListRange(ctx, orgID, utils.NormalizePtr(mealID), from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.expand(ctx, plans)
+}
+
+// expand 返回需求清单与提示信息（未关联配方、无法换算等）
+func (s *Service) expand(ctx context.Context, plans []mealplan.Plan) ([]domain.Demand, []string, error) {
+	type key struct{ goodsID, unitID string }
+	var warnings []string
+	versions := map[string]*domain.Version{}
+	demand := map[key]*domain.Demand{}
+	var order []key
+
+	// 先加载涉及的配方版本（同一配方同一版本只查一次）
+	for _, p := range plans {
+		for _, d := range p.Dishes {
+			if d.RecipeID == nil {
+				warnings = append(warnings, fmt.Sprintf("%s 菜品「%s」未关联配方", p.PlanDate.Format("2006-01-02"), d.Name))
+				continue
+			}
+			ver := 0
+			if d.RecipeVer != nil {
+				ver = *d.RecipeVer
+			}
+			vk := fmt.Sprintf("%s#%d", *d.RecipeID, ver)
+			if _, ok := versions[vk]; !ok {
+				v, err := s.r.GetVersion(ctx, *d.RecipeID, ver)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("菜品「%s」配方不可用: %v", d.Name, err))
+					versions[vk] = nil
+					continue
+				}
+				versions[vk] = v
+			}
+		}
+	}
+	goodsSet := map[string]struct{}{}
+	for _, v := range versions {
+		if v == nil {
+			continue
+		}
+		for _, l := range v.Lines {
+			goodsSet[l.GoodsID] = struct{}{}
+		}
+	}
+	goodsIDs := make([]string, 0, len(goodsSet))
+	for id := range goodsSet {
+		goodsIDs = append(goodsIDs, id)
+	}
+	goodsUnits, err := s.goods.GoodsUnits(ctx, goodsIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range plans {
+		for _, d := range p.Dishes {
+			if d.RecipeID == nil {
+				continue
+			}
+			ver := 0
+			if d.RecipeVer != nil {
+				ver = *d.RecipeVer
+			}
+			v := versions[fmt.Sprintf("%s#%d", *d.RecipeID, ver)]
+			if v == nil {
+				continue
+			}
+			servings := p.Headcount
+			if d.Servings != nil {
+				servings = *d.Servings
+			}
+			if servings <= 0 {
+				continue
+			}
+			n := decimal.NewFromInt(int64(servings))
+			for _, l := range v.Lines {
+				gid := l.GoodsID
+				qty, unitID := l.GrossPerServing().Mul(n), l.UnitID
+				if gu, ok := goodsUnits[gid]; ok && gu != unitID {
+					if conv, err := s.units.ConvertQuantity(ctx, qty, unitID, gu, &gid); err == nil {
+						qty, unitID = conv, gu
+					} else {
+						warnings = append(warnings, fmt.Sprintf("菜品「%s」用料无法换算到商品单位，按原单位汇总", d.Name))
+					}
+				}
+				k := key{gid, unitID}
+				dm, ok := demand[k]
+				if !ok {
+					dm = &domain.Demand{GoodsID: gid, UnitID: unitID, Quantity: decimal.Zero}
+					demand[k] = dm
+					order = append(order, k)
+				}
+				dm.Quantity = dm.Quantity.Add(qty)
+				dm.Sources = append(dm.Sources, domain.DemandSource{
+					PlanID:   p.ID,
+					PlanDate: p.PlanDate,
+					MealID:   p.MealID,
+					DishName: d.Name,
+					RecipeID: v.RecipeID,
+					Version:  v.Version,
+					Servings: servings,
+					Quantity: qty,
+				})
+			}
+		}
+	}
+
+	out := make([]domain.Demand, 0, len(order))
+	for _, k := range order {
+		out = append(out, *demand[k])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].GoodsID < out[j].GoodsID })
+	return out, warnings, nil
+}
+
+func (s *Service) buildLines(ctx context.Context, orgID string, in []LineParams) ([]domain.Line, error) {
+	if len(in) == 0 {
+		return nil, fmt.Errorf("配方至少需要一行用料")
+	}
+	one := decimal.NewFromInt(1)
+	out := make([]domain.Line, 0, len(in))
+	goodsIDs := make([]string, 0, len(in))
+	for i, l := range in {
+		if strings.TrimSpace(l.GoodsID) == "" || strings.TrimSpace(l.UnitID) == "" {
+			return nil, fmt.Errorf("第 %d 行 goods_id/unit_id 不能为空", i+1)
+		}
+		if !l.QtyPerServing.IsPositive() {
+			return nil, fmt.Errorf("第 %d 行每份用量必须大于 0", i+1)
+		}
+		yield, loss := one, decimal.Zero
+		if l.YieldRate != nil {
+			yield = *l.YieldRate
+		}
+		if l.LossRate != nil {
+			loss = *l.LossRate
+		}
+		if !yield.IsPositive() || yield.GreaterThan(one) {
+			return nil, fmt.Errorf("第 %d 行出成率须在 (0,1] 之间", i+1)
+		}
+		if loss.IsNegative() || !loss.LessThan(one) {
+			return nil, fmt.Errorf("第 %d 行损耗率须在 [0,1) 之间", i+1)
+		}
+		out = append(out, domain.Line{
+			GoodsID:       strings.TrimSpace(l.GoodsID),
+			QtyPerServing: l.QtyPerServing,
+			UnitID:        strings.TrimSpace(l.UnitID),
+			YieldRate:     yield,
+			LossRate:      loss,
+			Sort:          l.Sort,
+		})
+		goodsIDs = append(goodsIDs, strings.TrimSpace(l.GoodsID))
+	}
+	units, err := s.goods.ActiveGoodsUnits(ctx, orgID, goodsIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, l := range out {
+		if _, ok := units[l.GoodsID]; !ok {
+			return nil, fmt.Errorf("第 %d 行商品不存在", i+1)
+		}
+	}
+	return out, nil
+}
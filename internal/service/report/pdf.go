@@ -0,0 +1,283 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/report"
+)
+
+// 版面参数（mm）
+const (
+	fontFamily  = "cjk"
+	margin      = 12.0
+	footerSpace = 10.0 // 页脚占用高度
+	signSpace   = 22.0 // 签字栏占用高度
+)
+
+// 输出只依赖单据数据、版式与字体：文档时间取业务日期、资源目录排序，
+// 同样的输入总是得到逐字节相同的文件，便于比对（golden test）
+
+// RenderInquiry 生成询价单 PDF：抬头、市场列、商品明细、均价与签字栏
+func RenderInquiry(doc domain.InquirySheet, l domain.Layout, font []byte) ([]byte, error) {
+	pdf, err := newDocument(l, font, doc.Date)
+	if err != nil {
+		return nil, err
+	}
+	title := doc.Title
+	if l.Title != nil && *l.Title != "" {
+		title = *l.Title
+	}
+	pdf.SetTitle(title, true)
+
+	w := contentWidth(pdf)
+	cols := []column{{title: "序号", width: 10, align: "C"}, {title: "商品", align: "L"}, {title: "规格", width: 18, align: "L"}, {title: "单位", width: 12, align: "C"}}
+	if l.ShowGuidePrice == 1 {
+		cols = append(cols, column{title: "指导价", width: 18, align: "R"})
+	}
+	fixed := 0.0
+	for _, c := range cols {
+		fixed += c.width
+	}
+	fixed += 18 // 均价
+	mw := 0.0
+	if n := len(doc.Markets); n > 0 {
+		mw = min((w-fixed-30)/float64(n), 25)
+	}
+	cols[1].width = w - fixed - mw*float64(len(doc.Markets))
+	for _, name := range doc.Markets {
+		cols = append(cols, column{title: name, width: mw, align: "R"})
+	}
+	cols = append(cols, column{title: "均价", width: 18, align: "R"})
+
+	header := func() {
+		heading(pdf, l, title)
+		info(pdf, l, "机构："+doc.OrgName, "询价日期："+doc.Date.Format("2006-01-02"), "状态："+doc.Status)
+		tableHeader(pdf, l, cols)
+	}
+	pdf.AddPage()
+	header()
+	for i, line := range doc.Lines {
+		cells := []string{strconv.Itoa(i + 1), line.GoodsName, line.Spec, line.Unit}
+		if l.ShowGuidePrice == 1 {
+			cells = append(cells, money(line.GuidePrice))
+		}
+		for j := range doc.Markets {
+			var v *decimal.Decimal
+			if j < len(line.Prices) {
+				v = line.Prices[j]
+			}
+			cells = append(cells, money(v))
+		}
+		cells = append(cells, money(line.AvgPrice))
+		row(pdf, l, cols, cells, header)
+	}
+	signatures(pdf, l, header)
+	return output(pdf)
+}
+
+// RenderSettlement 生成供应商结算对账单 PDF：期间内已提交（含已收货）采购明细、合计与签字栏
+func RenderSettlement(doc domain.Settlement, l domain.Layout, font []byte) ([]byte, error) {
+	pdf, err := newDocument(l, font, doc.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	title := "供应商结算对账单"
+	if l.Title != nil && *l.Title != "" {
+		title = *l.Title
+	}
+	pdf.SetTitle(title, true)
+
+	w := contentWidth(pdf)
+	cols := []column{
+		{title: "序号", width: 10, align: "C"},
+		{title: "日期", width: 22, align: "C"},
+		{title: "商品", align: "L"},
+		{title: "单位", width: 12, align: "C"},
+		{title: "数量", width: 18, align: "R"},
+		{title: "单价", width: 18, align: "R"},
+		{title: "浮动比例", width: 16, align: "R"},
+		{title: "结算价", width: 18, align: "R"},
+		{title: "金额", width: 22, align: "R"},
+	}
+	fixed := 0.0
+	for _, c := range cols {
+		fixed += c.width
+	}
+	cols[2].width = w - fixed
+
+	header := func() {
+		heading(pdf, l, title)
+		info(pdf, l, "机构："+doc.OrgName, "供应商："+doc.SupplierName,
+			"期间："+doc.DateFrom.Format("2006-01-02")+" 至 "+doc.DateTo.Format("2006-01-02"))
+		tableHeader(pdf, l, cols)
+	}
+	pdf.AddPage()
+	header()
+	for i, line := range doc.Lines {
+		row(pdf, l, cols, []string{
+			strconv.Itoa(i + 1),
+			line.Date.Format("2006-01-02"),
+			line.GoodsName,
+			line.Unit,
+			line.Quantity.String(),
+			line.UnitPrice.StringFixed(2),
+			line.FloatRatio.StringFixed(4),
+			line.SettlePrice.StringFixed(2),
+			line.Amount.StringFixed(2),
+		}, header)
+	}
+	total := []column{{width: w - cols[len(cols)-1].width, align: "R"}, cols[len(cols)-1]}
+	row(pdf, l, total, []string{fmt.Sprintf("合计（%d 条）", len(doc.Lines)), doc.Total.StringFixed(2)}, header)
+	signatures(pdf, l, header)
+	return output(pdf)
+}
+
+type column struct {
+	title string
+	width float64
+	align string
+}
+
+func newDocument(l domain.Layout, font []byte, stamp time.Time) (*gofpdf.Fpdf, error) {
+	if len(font) == 0 {
+		return nil, domain.ErrNoFont
+	}
+	pdf := gofpdf.New(l.Orientation, "mm", l.PageSize, "")
+	pdf.SetCreationDate(stamp)
+	pdf.SetModificationDate(stamp)
+	pdf.SetCatalogSort(true)
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	// 页码别名须在加载字体前设置
+	pdf.AliasNbPages("{nb}")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", font)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("加载字体失败: %w", err)
+	}
+	pdf.SetFooterFunc(func() {
+		w := contentWidth(pdf)
+		pdf.SetXY(margin, -margin-4)
+		pdf.SetFont(fontFamily, "", 8)
+		footer := ""
+		if l.Footer != nil {
+			footer = *l.Footer
+		}
+		pdf.CellFormat(w*2/3, 4, footer, "", 0, "L", false, 0, "")
+		pdf.CellFormat(w/3, 4, fmt.Sprintf("第 %d 页 / 共 {nb} 页", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	return pdf, nil
+}
+
+func output(pdf *gofpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func contentWidth(pdf *gofpdf.Fpdf) float64 {
+	pw, _ := pdf.GetPageSize()
+	return pw - 2*margin
+}
+
+func lineHeight(l domain.Layout) float64 {
+	return float64(l.FontSize)*0.45 + 2
+}
+
+func heading(pdf *gofpdf.Fpdf, l domain.Layout, title string) {
+	pdf.SetFont(fontFamily, "", float64(l.FontSize+6))
+	pdf.CellFormat(0, float64(l.FontSize+6)*0.5, title, "", 1, "C", false, 0, "")
+	if l.Subtitle != nil && *l.Subtitle != "" {
+		pdf.SetFont(fontFamily, "", float64(l.FontSize+1))
+		pdf.CellFormat(0, lineHeight(l), *l.Subtitle, "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(2)
+}
+
+// info 抬头信息行，各项等分整行宽度
+func info(pdf *gofpdf.Fpdf, l domain.Layout, items ...string) {
+	pdf.SetFont(fontFamily, "", float64(l.FontSize))
+	w := contentWidth(pdf) / float64(len(items))
+	for _, s := range items {
+		pdf.CellFormat(w, lineHeight(l), fitText(pdf, s, w), "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(lineHeight(l) + 1)
+}
+
+func tableHeader(pdf *gofpdf.Fpdf, l domain.Layout, cols []column) {
+	pdf.SetFont(fontFamily, "", float64(l.FontSize))
+	pdf.SetFillColor(235, 235, 235)
+	for _, c := range cols {
+		pdf.CellFormat(c.width, lineHeight(l), fitText(pdf, c.title, c.width), "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// row 输出一行表格；剩余空间不足时换页并重绘抬头
+func row(pdf *gofpdf.Fpdf, l domain.Layout, cols []column, cells []string, header func()) {
+	h := lineHeight(l)
+	if pdf.GetY()+h > bottomLimit(pdf) {
+		pdf.AddPage()
+		header()
+	}
+	pdf.SetFont(fontFamily, "", float64(l.FontSize))
+	for i, c := range cols {
+		pdf.CellFormat(c.width, h, fitText(pdf, cells[i], c.width), "1", 0, c.align, false, 0, "")
+	}
+	pdf.Ln(-1)
+}
+
+// signatures 签字栏：每位签字人一格，含签名与日期
+func signatures(pdf *gofpdf.Fpdf, l domain.Layout, header func()) {
+	signers := l.SignerList()
+	if len(signers) == 0 {
+		return
+	}
+	if pdf.GetY()+signSpace > bottomLimit(pdf) {
+		pdf.AddPage()
+		header()
+	}
+	pdf.Ln(6)
+	pdf.SetFont(fontFamily, "", float64(l.FontSize))
+	w := contentWidth(pdf) / float64(len(signers))
+	h := lineHeight(l) + 1
+	for _, s := range signers {
+		pdf.CellFormat(w, h, fitText(pdf, s+"：________________", w), "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(h + 2)
+	for range signers {
+		pdf.CellFormat(w, h, fitText(pdf, "日期：________________", w), "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(h)
+}
+
+func bottomLimit(pdf *gofpdf.Fpdf) float64 {
+	_, ph := pdf.GetPageSize()
+	return ph - margin - footerSpace
+}
+
+// fitText 按列宽截断文本，超出部分以省略号结尾
+func fitText(pdf *gofpdf.Fpdf, s string, w float64) string {
+	const pad = 2 // CellFormat 左右内边距合计
+	if pdf.GetStringWidth(s) <= w-pad {
+		return s
+	}
+	r := []rune(s)
+	for len(r) > 0 && pdf.GetStringWidth(string(r)+"…") > w-pad {
+		r = r[:len(r)-1]
+	}
+	return string(r) + "…"
+}
+
+func money(v *decimal.Decimal) string {
+	if v == nil {
+		return ""
+	}
+	return v.StringFixed(2)
+}
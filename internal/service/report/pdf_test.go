@@ -0,0 +1,121 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/report"
+)
+
+// go test ./internal/service/report -run Golden -update 重新生成 golden 文件
+var update = flag.Bool("update", false, "重新生成 testdata 下的 golden PDF")
+
+// 测试字体只需稳定，不要求覆盖中文字形
+func testFont(t *testing.T) []byte {
+	t.Helper()
+	font, err := os.ReadFile(filepath.Join("testdata", "DejaVuSansCondensed.ttf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return font
+}
+
+func dec(s string) *decimal.Decimal {
+	d := decimal.RequireFromString(s)
+	return &d
+}
+
+func goldenInquiry() domain.InquirySheet {
+	return domain.InquirySheet{
+		OrgName: "Canteen A",
+		Title:   "Weekly inquiry",
+		Date:    time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Status:  "locked",
+		Markets: []string{"North", "South", "East"},
+		Lines: []domain.InquirySheetLine{
+			{GoodsName: "Pork belly", Spec: "500g", Unit: "kg", GuidePrice: dec("28.00"),
+				Prices: []*decimal.Decimal{dec("27.50"), dec("28.30"), nil}, AvgPrice: dec("27.90")},
+			{GoodsName: "Cabbage", Spec: "", Unit: "kg", GuidePrice: nil,
+				Prices: []*decimal.Decimal{dec("2.10"), dec("2.30"), dec("2.20")}, AvgPrice: dec("2.20")},
+		},
+	}
+}
+
+func goldenSettlement() domain.Settlement {
+	line := func(day int, goods, qty, price, ratio string) domain.SettlementLine {
+		l := domain.SettlementLine{
+			Date:       time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC),
+			OrderID:    "order",
+			GoodsName:  goods,
+			Unit:       "kg",
+			Quantity:   decimal.RequireFromString(qty),
+			UnitPrice:  decimal.RequireFromString(price),
+			FloatRatio: decimal.RequireFromString(ratio),
+		}
+		l.SettlePrice = l.UnitPrice.Mul(l.FloatRatio).Round(2)
+		l.Amount = l.Quantity.Mul(l.SettlePrice).Round(2)
+		return l
+	}
+	doc := domain.Settlement{
+		OrgName:      "Canteen A",
+		SupplierName: "Fresh Foods Ltd",
+		DateFrom:     time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		DateTo:       time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+		Lines: []domain.SettlementLine{
+			line(3, "Pork belly", "12.5", "27.90", "0.9500"),
+			line(10, "Cabbage", "40", "2.20", "1.0000"),
+		},
+	}
+	for _, l := range doc.Lines {
+		doc.Total = doc.Total.Add(l.Amount)
+	}
+	return doc
+}
+
+func TestRenderGolden(t *testing.T) {
+	font := testFont(t)
+	cases := []struct {
+		name   string
+		render func() ([]byte, error)
+	}{
+		{"inquiry.golden.pdf", func() ([]byte, error) {
+			return RenderInquiry(goldenInquiry(), domain.DefaultLayout("org", domain.KindInquiry), font)
+		}},
+		{"settlement.golden.pdf", func() ([]byte, error) {
+			return RenderSettlement(goldenSettlement(), domain.DefaultLayout("org", domain.KindSettlement), font)
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := c.render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := c.render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, again) {
+				t.Fatal("同样输入两次生成的 PDF 不一致")
+			}
+			path := filepath.Join("testdata", c.name)
+			if *update {
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("PDF 与 %s 不一致（%d 字节，期望 %d 字节）；版式有意调整时用 -update 重新生成", path, len(got), len(want))
+			}
+		})
+	}
+}
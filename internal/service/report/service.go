@@ -0,0 +1,290 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	organ "hdzk.cn/foodapp/internal/domain/organ"
+	price "hdzk.cn/foodapp/internal/domain/price"
+	domain "hdzk.cn/foodapp/internal/domain/report"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/report"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// InquirySource 询价单抬头及参与市场（由询价仓储实现）
+type InquirySource interface {
+	Get(ctx context.Context, id string) (*inquiry.PriceInquiry, error)
+	ListMarkets(ctx context.Context, inquiryID string) ([]inquiry.InquiryMarket, error)
+}
+
+// LineSource 询价明细及各市场价（由价格仓储实现）
+type LineSource interface {
+	ListInquiryLines(ctx context.Context, inquiryID string) ([]price.InquiryLine, error)
+}
+
+// OrgSource 机构（由组织仓储实现）
+type OrgSource interface {
+	GetByID(ctx context.Context, id string) (*organ.Organ, error)
+}
+
+// SupplierSource 供应商（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+}
+
+type Service struct {
+	r         repo.Repository
+	inquiries InquirySource
+	lines     LineSource
+	orgs      OrgSource
+	suppliers SupplierSource
+
+	fontPath string
+	fontMu   sync.Mutex
+	font     []byte
+}
+
+// NewService fontPath 为中文 TrueType 字体文件，首次生成 PDF 时加载
+func NewService(r repo.Repository, inquiries InquirySource, lines LineSource, orgs OrgSource, suppliers SupplierSource, fontPath string) *Service {
+	return &Service{r: r, inquiries: inquiries, lines: lines, orgs: orgs, suppliers: suppliers, fontPath: fontPath}
+}
+
+var statusNames = map[int]string{
+	inquiry.StatusDraft:     "草稿",
+	inquiry.StatusSubmitted: "已提交",
+	inquiry.StatusApproved:  "已审核",
+	inquiry.StatusArchived:  "已归档",
+}
+
+// InquiryPDF 生成询价单打印件（按机构版式）
+func (s *Service) InquiryPDF(ctx context.Context, inquiryID string) ([]byte, *inquiry.PriceInquiry, error) {
+	inq, err := s.inquiries.Get(ctx, strings.TrimSpace(inquiryID))
+	if err != nil {
+		return nil, nil, err
+	}
+	markets, err := s.inquiries.ListMarkets(ctx, inq.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	lines, err := s.lines.ListInquiryLines(ctx, inq.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	org, err := s.orgs.GetByID(ctx, inq.OrgID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("机构不存在: %w", err)
+	}
+	goodsIDs := make([]string, len(lines))
+	for i, l := range lines {
+		goodsIDs[i] = l.GoodsID
+	}
+	goods, err := s.r.GoodsInfo(ctx, goodsIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	doc := domain.InquirySheet{
+		OrgName: org.Name,
+		Title:   inq.InquiryTitle,
+		Date:    inq.InquiryDate,
+		Status:  statusNames[inq.Status],
+		Markets: make([]string, len(markets)),
+		Lines:   make([]domain.InquirySheetLine, len(lines)),
+	}
+	for i, m := range markets {
+		doc.Markets[i] = m.Name
+	}
+	for i, l := range lines {
+		g := goods[l.GoodsID]
+		byMarket := l.MarketPrices()
+		line := domain.InquirySheetLine{
+			GoodsName:  g.Name,
+			Spec:       g.Spec,
+			Unit:       g.Unit,
+			GuidePrice: l.GuidePrice,
+			AvgPrice:   l.AvgPrice,
+			Prices:     make([]*decimal.Decimal, len(markets)),
+		}
+		for j, m := range markets {
+			if v, ok := byMarket[m.MarketID]; ok {
+				line.Prices[j] = &v
+			}
+		}
+		doc.Lines[i] = line
+	}
+
+	layout, err := s.layout(ctx, inq.OrgID, domain.KindInquiry)
+	if err != nil {
+		return nil, nil, err
+	}
+	font, err := s.loadFont()
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := RenderInquiry(doc, *layout, font)
+	return out, inq, err
+}
+
+type SettlementParams struct {
+	OrgID      string
+	SupplierID string
+	DateFrom   time.Time
+	DateTo     time.Time
+}
+
+// SettlementPDF 生成供应商结算对账单：期间内（按期望到货日期）已提交及已收货采购单的明细与合计
+func (s *Service) SettlementPDF(ctx context.Context, p SettlementParams) ([]byte, error) {
+	orgID := strings.TrimSpace(p.OrgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	from, to := utils.DateOf(p.DateFrom), utils.DateOf(p.DateTo)
+	if to.Before(from) {
+		return nil, errors.New("date_to 不能早于 date_from")
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+	if sup.OrgID != orgID {
+		return nil, errors.New("供应商不属于该机构")
+	}
+	org, err := s.orgs.GetByID(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("机构不存在: %w", err)
+	}
+	lines, err := s.r.SettlementLines(ctx, orgID, sup.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	doc := domain.Settlement{
+		OrgName:      org.Name,
+		SupplierName: sup.Name,
+		DateFrom:     from,
+		DateTo:       to,
+		Lines:        lines,
+	}
+	for _, l := range lines {
+		doc.Total = doc.Total.Add(l.Amount)
+	}
+
+	layout, err := s.layout(ctx, orgID, domain.KindSettlement)
+	if err != nil {
+		return nil, err
+	}
+	font, err := s.loadFont()
+	if err != nil {
+		return nil, err
+	}
+	return RenderSettlement(doc, *layout, font)
+}
+
+// GetLayout 返回机构的打印版式，未配置时返回默认版式
+func (s *Service) GetLayout(ctx context.Context, orgID, kind string) (*domain.Layout, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if !domain.ValidKind(kind) {
+		return nil, fmt.Errorf("不支持的单据类型: %s", kind)
+	}
+	return s.layout(ctx, orgID, kind)
+}
+
+type LayoutParams struct {
+	OrgID          string
+	Kind           string
+	Title          *string
+	Subtitle       *string
+	Signers        []string
+	PageSize       string
+	Orientation    string
+	FontSize       int
+	ShowGuidePrice bool
+	Footer         *string
+}
+
+func (s *Service) SaveLayout(ctx context.Context, p LayoutParams) (*domain.Layout, error) {
+	orgID := strings.TrimSpace(p.OrgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if !domain.ValidKind(p.Kind) {
+		return nil, fmt.Errorf("不支持的单据类型: %s", p.Kind)
+	}
+	m := domain.DefaultLayout(orgID, p.Kind)
+	m.Title, m.Subtitle, m.Footer = utils.NormalizePtr(p.Title), utils.NormalizePtr(p.Subtitle), utils.NormalizePtr(p.Footer)
+	var signers []string
+	for _, v := range p.Signers {
+		v = strings.TrimSpace(v)
+		if strings.Contains(v, ",") {
+			return nil, errors.New("签字人名称不能包含逗号")
+		}
+		if v != "" {
+			signers = append(signers, v)
+		}
+	}
+	m.Signers = strings.Join(signers, ",")
+	if p.PageSize != "" {
+		m.PageSize = p.PageSize
+	}
+	if p.Orientation != "" {
+		m.Orientation = p.Orientation
+	}
+	if m.PageSize != domain.PageA4 && m.PageSize != domain.PageA5 {
+		return nil, errors.New("page_size 仅支持 A4/A5")
+	}
+	if m.Orientation != domain.Portrait && m.Orientation != domain.Landscape {
+		return nil, errors.New("orientation 仅支持 P/L")
+	}
+	if p.FontSize != 0 {
+		if p.FontSize < 6 || p.FontSize > 16 {
+			return nil, errors.New("font_size 须在 6 与 16 之间")
+		}
+		m.FontSize = p.FontSize
+	}
+	m.ShowGuidePrice = 0
+	if p.ShowGuidePrice {
+		m.ShowGuidePrice = 1
+	}
+	if err := s.r.SaveLayout(ctx, &m); err != nil {
+		return nil, err
+	}
+	return s.r.GetLayout(ctx, orgID, p.Kind)
+}
+
+func (s *Service) layout(ctx context.Context, orgID, kind string) (*domain.Layout, error) {
+	m, err := s.r.GetLayout(ctx, orgID, kind)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		d := domain.DefaultLayout(orgID, kind)
+		return &d, nil
+	}
+	return m, err
+}
+
+// loadFont 读取并缓存字体；读取失败不缓存，修正配置后无需重启
+func (s *Service) loadFont() ([]byte, error) {
+	s.fontMu.Lock()
+	defer s.fontMu.Unlock()
+	if s.font != nil {
+		return s.font, nil
+	}
+	if strings.TrimSpace(s.fontPath) == "" {
+		return nil, domain.ErrNoFont
+	}
+	b, err := os.ReadFile(s.fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrNoFont, err)
+	}
+	s.font = b
+	return b, nil
+}
@@ -0,0 +1,37 @@
+package scorecard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/scorecard"
+)
+
+// RunMonthlySnapshot 为各机构生成上一自然月的评分快照（已生成的期间跳过），返回新生成快照的机构数
+func (s *Service) RunMonthlySnapshot(ctx context.Context, now time.Time) (int, error) {
+	first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	from, to := first.AddDate(0, -1, 0), first.AddDate(0, 0, -1)
+
+	orgs, err := s.r.OrgsWithSuppliers(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var done int
+	var errs []error
+	for _, orgID := range orgs {
+		if ctx.Err() != nil {
+			return done, ctx.Err()
+		}
+		_, err := s.Compute(ctx, ComputeParams{OrgID: orgID, From: from, To: to})
+		switch {
+		case err == nil:
+			done++
+		case errors.Is(err, domain.ErrSnapshotExists):
+		default:
+			errs = append(errs, fmt.Errorf("机构 %s: %w", orgID, err))
+		}
+	}
+	return done, errors.Join(errs...)
+}
@@ -0,0 +1,200 @@
+package scorecard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "hdzk.cn/foodapp/internal/domain/scorecard"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	repo "hdzk.cn/foodapp/internal/repository/scorecard"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// SupplierSource 供应商（由供应商仓储实现）
+type SupplierSource interface {
+	GetSupplier(ctx context.Context, id string) (*supplier.Supplier, error)
+}
+
+type Service struct {
+	r         repo.Repository
+	suppliers SupplierSource
+}
+
+func NewService(r repo.Repository, suppliers SupplierSource) *Service {
+	return &Service{r: r, suppliers: suppliers}
+}
+
+// 评分期间最长天数
+const maxPeriodDays = 366
+
+type RatingParams struct {
+	SupplierID string
+	Kind       string
+	Score      *int
+	RatedOn    time.Time
+	Content    *string
+	OperatorID *string
+}
+
+// CreateRating 登记人工评分或投诉；投诉须填写内容，评分须为 1~5 分
+func (s *Service) CreateRating(ctx context.Context, p RatingParams) (*domain.Rating, error) {
+	content := utils.NormalizePtr(p.Content)
+	switch p.Kind {
+	case domain.KindRating:
+		if p.Score == nil || *p.Score < 1 || *p.Score > 5 {
+			return nil, errors.New("评分须为 1~5 分")
+		}
+	case domain.KindComplaint:
+		if content == nil {
+			return nil, errors.New("投诉须填写内容")
+		}
+		p.Score = nil
+	default:
+		return nil, fmt.Errorf("不支持的评价类型: %s", p.Kind)
+	}
+	if p.RatedOn.IsZero() {
+		p.RatedOn = time.Now()
+	}
+	if utils.DateOf(p.RatedOn).After(utils.DateOf(time.Now())) {
+		return nil, errors.New("评价日期不能晚于今天")
+	}
+	sup, err := s.suppliers.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, fmt.Errorf("供应商不存在: %w", err)
+	}
+	m := &domain.Rating{
+		OrgID:      sup.OrgID,
+		SupplierID: sup.ID,
+		Kind:       p.Kind,
+		Score:      p.Score,
+		RatedOn:    utils.DateOf(p.RatedOn),
+		Content:    content,
+		OperatorID: utils.NormalizePtr(p.OperatorID),
+	}
+	return m, s.r.CreateRating(ctx, m)
+}
+
+type RatingListParams = repo.RatingListParams
+
+func (s *Service) ListRatings(ctx context.Context, p RatingListParams) ([]domain.Rating, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.SupplierID, p.Kind = utils.NormalizePtr(p.SupplierID), utils.NormalizePtr(p.Kind)
+	return s.r.ListRatings(ctx, p)
+}
+
+// DeleteRating 软删评价；已生成的快照不受影响
+func (s *Service) DeleteRating(ctx context.Context, id string) error {
+	return s.r.SoftDeleteRating(ctx, strings.TrimSpace(id))
+}
+
+type ComputeParams struct {
+	OrgID      string
+	From       time.Time
+	To         time.Time
+	Replace    bool // 已有快照时重算并覆盖
+	OperatorID *string
+}
+
+// Compute 统计期间内各供应商指标、计算综合得分并排名，结果保存为快照。
+// 期间内无业务数据的供应商不参与排名
+func (s *Service) Compute(ctx context.Context, p ComputeParams) ([]domain.Snapshot, error) {
+	orgID := strings.TrimSpace(p.OrgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	from, to, err := checkPeriod(p.From, p.To)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if to.After(utils.DateOf(now)) {
+		return nil, errors.New("期间止不能晚于今天")
+	}
+	if !p.Replace {
+		exists, err := s.r.HasSnapshots(ctx, orgID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, domain.ErrSnapshotExists
+		}
+	}
+
+	metrics, err := s.r.Metrics(ctx, orgID, from, to, now)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]domain.Snapshot, 0, len(metrics))
+	for _, m := range metrics {
+		if !m.Active() {
+			continue
+		}
+		snap := domain.Evaluate(m)
+		snap.OrgID, snap.PeriodFrom, snap.PeriodTo = orgID, from, to
+		snap.ComputedAt, snap.ComputedBy = now, utils.NormalizePtr(p.OperatorID)
+		list = append(list, snap)
+	}
+	domain.Rank(list)
+	if err := s.r.SaveSnapshots(ctx, orgID, from, to, list, p.Replace); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Ranking 读取期间快照排名；未生成快照时返回错误（不即时计算，避免历史排名随数据变化）
+func (s *Service) Ranking(ctx context.Context, orgID string, from, to time.Time) ([]domain.Snapshot, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	from, to, err := checkPeriod(from, to)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.r.Ranking(ctx, orgID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		exists, err := s.r.HasSnapshots(ctx, orgID, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, ErrNoSnapshot
+		}
+	}
+	return list, nil
+}
+
+// ErrNoSnapshot 期间尚未生成评分快照
+var ErrNoSnapshot = errors.New("该期间尚未生成评分快照")
+
+func (s *Service) Periods(ctx context.Context, orgID string) ([]domain.Period, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	return s.r.Periods(ctx, orgID)
+}
+
+func (s *Service) SupplierHistory(ctx context.Context, supplierID string) ([]domain.Snapshot, error) {
+	return s.r.SupplierHistory(ctx, strings.TrimSpace(supplierID))
+}
+
+func checkPeriod(from, to time.Time) (time.Time, time.Time, error) {
+	from, to = utils.DateOf(from), utils.DateOf(to)
+	if to.Before(from) {
+		return from, to, errors.New("期间止不能早于期间起")
+	}
+	if to.Sub(from) >= maxPeriodDays*24*time.Hour {
+		return from, to, fmt.Errorf("评分期间不能超过 %d 天", maxPeriodDays)
+	}
+	return from, to, nil
+}
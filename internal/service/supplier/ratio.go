@@ -0,0 +1,81 @@
+package supplier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	domain "hdzk.cn/foodapp/internal/domain/supplier"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+type FloatRatioParams struct {
+	SupplierID    string
+	FloatRatio    decimal.Decimal
+	EffectiveFrom time.Time
+	Remark        *string
+	OperatorID    *string
+}
+
+var maxFloatRatio = decimal.RequireFromString("99.9999") // decimal(6,4)
+
+// ScheduleFloatRatio 设置自 EffectiveFrom 起生效的浮动比例；生效日期不得早于今天（已生效的历史不可改写），
+// 同一日期重复设置时覆盖。当日生效的调整立即同步到供应商当前比例
+func (s *Service) ScheduleFloatRatio(ctx context.Context, p FloatRatioParams) (*domain.FloatRatio, error) {
+	if !p.FloatRatio.IsPositive() || p.FloatRatio.GreaterThan(maxFloatRatio) {
+		return nil, errors.New("浮动比例须大于 0 且不超过 99.9999")
+	}
+	today := utils.DateOf(time.Now())
+	from := utils.DateOf(p.EffectiveFrom)
+	if from.Before(today) {
+		return nil, errors.New("生效日期不能早于今天")
+	}
+	sup, err := s.r.GetSupplier(ctx, strings.TrimSpace(p.SupplierID))
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.FloatRatio{
+		SupplierID:    sup.ID,
+		FloatRatio:    p.FloatRatio.Round(4),
+		EffectiveFrom: from,
+		Remark:        utils.NormalizePtr(p.Remark),
+		OperatorID:    utils.NormalizePtr(p.OperatorID),
+	}
+	if err := s.r.SaveFloatRatio(ctx, m); err != nil {
+		return nil, err
+	}
+	if from.Equal(today) {
+		if _, err := s.r.SyncFloatRatios(ctx, today, &sup.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.r.FloatRatioOn(ctx, sup.ID, from)
+}
+
+// ListFloatRatios 供应商比例历史（含计划调整），按生效日期倒序
+func (s *Service) ListFloatRatios(ctx context.Context, supplierID string) ([]domain.FloatRatio, error) {
+	sup, err := s.r.GetSupplier(ctx, strings.TrimSpace(supplierID))
+	if err != nil {
+		return nil, err
+	}
+	return s.r.ListFloatRatios(ctx, sup.ID)
+}
+
+// FloatRatioOn 指定日期生效的浮动比例
+func (s *Service) FloatRatioOn(ctx context.Context, supplierID string, at time.Time) (*domain.FloatRatio, error) {
+	return s.r.FloatRatioOn(ctx, strings.TrimSpace(supplierID), at)
+}
+
+// CancelFloatRatio 撤销尚未生效的计划调整
+func (s *Service) CancelFloatRatio(ctx context.Context, id string) error {
+	ok, err := s.r.DeleteFloatRatio(ctx, strings.TrimSpace(id), time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("记录不存在或已生效，不能撤销")
+	}
+	return nil
+}
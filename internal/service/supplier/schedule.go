@@ -0,0 +1,120 @@
+package supplier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	notification "hdzk.cn/foodapp/internal/domain/notification"
+	domain "hdzk.cn/foodapp/internal/domain/supplier"
+)
+
+// Notifier 站内通知（由通知服务实现）
+type Notifier interface {
+	Notify(ctx context.Context, n *notification.Notification) (bool, error)
+}
+
+// ScheduleResult 一次合同期调度的结果
+type ScheduleResult struct {
+	Activated   []string `json:"activated"`    // 自动启用的供应商ID
+	Deactivated []string `json:"deactivated"`  // 自动停用的供应商ID
+	Notified    int      `json:"notified"`     // 新发送的通知数
+	RatioSynced int64    `json:"ratio_synced"` // 计划浮动比例生效、同步了当前比例的供应商数
+}
+
+// RunContractSchedule 按合同期启用/停用供应商，并对 noticeDays 内到期的供应商发送提醒。
+// 每个提醒档（如 30/7/1 天）对同一到期时间只提醒一次，可重复执行；同时将到期生效的计划浮动比例同步为当前比例
+func (s *Service) RunContractSchedule(ctx context.Context, now time.Time, noticeDays []int) (*ScheduleResult, error) {
+	out := &ScheduleResult{Activated: []string{}, Deactivated: []string{}}
+
+	synced, err := s.r.SyncFloatRatios(ctx, now, nil)
+	if err != nil {
+		return nil, err
+	}
+	out.RatioSynced = synced
+
+	activated, err := s.r.ActivateDue(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, sup := range activated {
+		out.Activated = append(out.Activated, sup.ID)
+		if err := s.notify(ctx, out, sup, notification.KindSupplierActive,
+			fmt.Sprintf("供应商「%s」合同已生效，已自动启用", sup.Name),
+			"active:"+timeKey(sup.StartTime)); err != nil {
+			return nil, err
+		}
+	}
+
+	deactivated, err := s.r.DeactivateDue(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, sup := range deactivated {
+		out.Deactivated = append(out.Deactivated, sup.ID)
+		title := fmt.Sprintf("供应商「%s」合同已到期，已自动停用", sup.Name)
+		if sup.StartTime != nil && now.Before(*sup.StartTime) {
+			title = fmt.Sprintf("供应商「%s」合同尚未生效，已自动停用", sup.Name)
+		}
+		if err := s.notify(ctx, out, sup, notification.KindSupplierExpired, title,
+			"inactive:"+timeKey(sup.StartTime)+":"+timeKey(sup.EndTime)); err != nil {
+			return nil, err
+		}
+	}
+
+	days := append([]int(nil), noticeDays...)
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	if len(days) == 0 || days[0] <= 0 {
+		return out, nil
+	}
+	expiring, err := s.r.ExpiringSuppliers(ctx, now, now.AddDate(0, 0, days[0]))
+	if err != nil {
+		return nil, err
+	}
+	for _, sup := range expiring {
+		// 取剩余天数所在的最小提醒档，跨档时各提醒一次
+		left := sup.EndTime.Sub(now)
+		tier := 0
+		for _, d := range days {
+			if d > 0 && left <= time.Duration(d)*24*time.Hour {
+				tier = d
+			}
+		}
+		if tier == 0 {
+			continue
+		}
+		daysLeft := int(left.Hours()/24) + 1
+		if err := s.notify(ctx, out, sup, notification.KindSupplierExpiring,
+			fmt.Sprintf("供应商「%s」合同将在 %d 天内到期（%s）", sup.Name, daysLeft, sup.EndTime.Format("2006-01-02 15:04")),
+			fmt.Sprintf("expiring:%s:%d", timeKey(sup.EndTime), tier)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func (s *Service) notify(ctx context.Context, out *ScheduleResult, sup domain.Supplier, kind, title, key string) error {
+	if s.notifier == nil {
+		return nil
+	}
+	sent, err := s.notifier.Notify(ctx, &notification.Notification{
+		OrgID:    sup.OrgID,
+		Kind:     kind,
+		RefType:  "supplier",
+		RefID:    sup.ID,
+		Title:    title,
+		DedupKey: "supplier:" + sup.ID + ":" + key,
+	})
+	if sent {
+		out.Notified++
+	}
+	return err
+}
+
+func timeKey(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format("20060102150405")
+}
@@ -11,10 +11,13 @@ import (
 )
 
 type Service struct {
-	r repo.SupplierRepository
+	r        repo.SupplierRepository
+	notifier Notifier
 }
 
-func NewService(r repo.SupplierRepository) *Service { return &Service{r: r} }
+func NewService(r repo.SupplierRepository, notifier Notifier) *Service {
+	return &Service{r: r, notifier: notifier}
+}
 
 type CreateParams struct {
 	Name           string
@@ -34,6 +37,7 @@ type CreateParams struct {
 
 type UpdateParams struct {
 	ID              string
+	Version         int
 	Name            *string
 	Code            *string
 	Pinyin          *string
@@ -60,10 +64,14 @@ func (s *Service) CreateSupplier(ctx context.Context, params CreateParams) (*dom
 	contactEmail, _ := normalizeString(params.ContactEmail)
 	contactAddress, _ := normalizeString(params.ContactAddress)
 
-	status := 1
+	status, autoDisabled := domain.StatusActive, 0
 	if params.Status != nil {
 		status = *params.Status
 	}
+	// 不在合同期内的新供应商先停用，由合同期调度在生效时自动启用
+	if status == domain.StatusActive && !(domain.Supplier{StartTime: params.StartTime, EndTime: params.EndTime}).InWindow(time.Now()) {
+		status, autoDisabled = domain.StatusDisabled, 1
+	}
 
 	m := &domain.Supplier{
 		ID:             uuid.NewString(),
@@ -78,6 +86,7 @@ func (s *Service) CreateSupplier(ctx context.Context, params CreateParams) (*dom
 		ContactEmail:   contactEmail,
 		ContactAddress: contactAddress,
 		Status:         status,
+		AutoDisabled:   autoDisabled,
 		StartTime:      params.StartTime,
 		EndTime:        params.EndTime,
 	}
@@ -102,6 +111,7 @@ func (s *Service) UpdateSupplier(ctx context.Context, params UpdateParams) error
 
 	repoParams := repo.UpdateParams{
 		ID:                   params.ID,
+		Version:              params.Version,
 		Name:                 params.Name,
 		Code:                 normalizedCode,
 		Pinyin:               normalizedPinyin,
@@ -109,6 +119,7 @@ func (s *Service) UpdateSupplier(ctx context.Context, params UpdateParams) error
 		Status:               params.Status,
 		Description:          params.Description,
 		FloatRatio:           params.FloatRatio,
+		FloatRatioFrom:       time.Now(),
 		ContactName:          normalizedContactName,
 		ContactPhone:         normalizedContactPhone,
 		ContactEmail:         normalizedContactEmail,
@@ -0,0 +1,465 @@
+package waste
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	dict "hdzk.cn/foodapp/internal/domain/dict"
+	inv "hdzk.cn/foodapp/internal/domain/inventory"
+	domain "hdzk.cn/foodapp/internal/domain/waste"
+	weighing "hdzk.cn/foodapp/internal/domain/weighing"
+	goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
+	pricerepo "hdzk.cn/foodapp/internal/repository/price"
+	repo "hdzk.cn/foodapp/internal/repository/waste"
+	weighingrepo "hdzk.cn/foodapp/internal/repository/weighing"
+	dictsvc "hdzk.cn/foodapp/internal/service/dict"
+	inventorysvc "hdzk.cn/foodapp/internal/service/inventory"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// MealResolver 按机构供餐时段判断发生时刻所属餐次（由字典服务实现）
+type MealResolver interface {
+	ResolveMeal(ctx context.Context, orgID string, at time.Time) (*dict.MealWindow, time.Time, error)
+}
+
+// ReasonSource 浪费原因字典（由字典服务实现）
+type ReasonSource interface {
+	GetWasteReason(ctx context.Context, id string) (*dict.WasteReason, error)
+}
+
+// OrgTree 组织层级（由组织仓储实现）
+type OrgTree interface {
+	SubtreeIDs(ctx context.Context, rootID string) ([]string, error)
+}
+
+type Service struct {
+	r         repo.Repository
+	weighings weighingrepo.Repository
+	goods     goodsrepo.GoodsRepository
+	prices    pricerepo.Repository
+	meals     MealResolver
+	units     dictsvc.UnitConverter
+	reasons   ReasonSource
+	orgs      OrgTree
+	stock     *inventorysvc.Service
+}
+
+func NewService(
+	r repo.Repository,
+	weighings weighingrepo.Repository,
+	goods goodsrepo.GoodsRepository,
+	prices pricerepo.Repository,
+	meals MealResolver,
+	units dictsvc.UnitConverter,
+	reasons ReasonSource,
+	orgs OrgTree,
+	stock *inventorysvc.Service,
+) *Service {
+	return &Service{
+		r:         r,
+		weighings: weighings,
+		goods:     goods,
+		prices:    prices,
+		meals:     meals,
+		units:     units,
+		reasons:   reasons,
+		orgs:      orgs,
+		stock:     stock,
+	}
+}
+
+type ListParams = repo.ListParams
+
+// CreateParams 指定 WeighingID 时，商品/数量/单位/时间/餐次为空则取自称重记录
+type CreateParams struct {
+	OrgID         string
+	GoodsID       string
+	ReasonID      string
+	Weight        decimal.Decimal
+	UnitID        string
+	WastedAt      time.Time
+	MealID        *string    // 指定时视为人工归属
+	MealDate      *time.Time // 人工归属的就餐日期，空则取发生日期
+	WeighingID    *string
+	PostInventory bool // 同时生成报损库存流水（适用于库存物料变质等）
+	OperatorID    *string
+	Remark        *string
+}
+
+type ReportParams struct {
+	OrgID          string
+	IncludeSubOrgs bool
+	DateFrom       time.Time
+	DateTo         time.Time
+	GroupBy        []string // org/category/meal/reason/goods/period，默认 org
+	Period         string   // day/week/month，按 period 分组时有效，默认 month
+}
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Record, error) {
+	p.OrgID, p.GoodsID, p.UnitID = strings.TrimSpace(p.OrgID), strings.TrimSpace(p.GoodsID), strings.TrimSpace(p.UnitID)
+	p.ReasonID = strings.TrimSpace(p.ReasonID)
+	if p.OrgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	if p.ReasonID == "" {
+		return nil, errors.New("reason_id 不能为空")
+	}
+	if _, err := s.reasons.GetWasteReason(ctx, p.ReasonID); err != nil {
+		return nil, fmt.Errorf("浪费原因不存在: %w", err)
+	}
+
+	m := &domain.Record{
+		OrgID:      p.OrgID,
+		ReasonID:   p.ReasonID,
+		OperatorID: utils.NormalizePtr(p.OperatorID),
+		Remark:     utils.NormalizePtr(p.Remark),
+	}
+	var w *weighing.Record
+	if wid := utils.NormalizePtr(p.WeighingID); wid != nil {
+		var err error
+		if w, err = s.fromWeighing(ctx, *wid, &p); err != nil {
+			return nil, err
+		}
+		m.WeighingID = &w.ID
+	}
+	if p.GoodsID == "" {
+		return nil, errors.New("goods_id 不能为空")
+	}
+	if p.UnitID == "" {
+		return nil, errors.New("unit_id 不能为空")
+	}
+	if !p.Weight.IsPositive() {
+		return nil, errors.New("weight 必须大于 0")
+	}
+	if p.WastedAt.IsZero() {
+		p.WastedAt = time.Now()
+	}
+	m.GoodsID, m.Weight, m.UnitID, m.WastedAt = p.GoodsID, p.Weight, p.UnitID, p.WastedAt
+
+	switch {
+	case utils.NormalizePtr(p.MealID) != nil:
+		day := utils.DateOf(p.WastedAt)
+		if p.MealDate != nil {
+			day = utils.DateOf(*p.MealDate)
+		}
+		m.MealID, m.MealDate, m.MealSource = utils.NormalizePtr(p.MealID), &day, weighing.MealSourceManual
+	case w != nil && w.MealID != nil:
+		// 沿用称重记录的餐次归属
+		m.MealID, m.MealDate, m.MealSource = w.MealID, w.MealDate, w.MealSource
+	default:
+		win, day, err := s.meals.ResolveMeal(ctx, m.OrgID, m.WastedAt)
+		if err != nil {
+			return nil, err
+		}
+		if win != nil {
+			mealID := win.MealID
+			m.MealID, m.MealDate, m.MealSource = &mealID, &day, weighing.MealSourceAuto
+		}
+	}
+
+	if p.PostInventory {
+		if s.stock == nil {
+			return nil, errors.New("未接入库存，无法生成报损流水")
+		}
+		mv, err := s.stock.Move(ctx, inventorysvc.MoveParams{
+			OrgID:      m.OrgID,
+			GoodsID:    m.GoodsID,
+			Type:       inv.TypeWaste,
+			Quantity:   m.Weight,
+			UnitID:     m.UnitID,
+			OccurredAt: m.WastedAt,
+			WeighingID: m.WeighingID,
+			Remark:     m.Remark,
+			OperatorID: m.OperatorID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		m.MovementID = &mv.ID
+	}
+	return m, s.r.Create(ctx, m)
+}
+
+// fromWeighing 校验称重记录并补全空缺字段
+func (s *Service) fromWeighing(ctx context.Context, weighingID string, p *CreateParams) (*weighing.Record, error) {
+	w, err := s.weighings.Get(ctx, weighingID)
+	if err != nil {
+		return nil, fmt.Errorf("称重记录不存在: %w", err)
+	}
+	if w.OrgID != p.OrgID {
+		return nil, errors.New("称重记录不属于该机构")
+	}
+	if p.GoodsID != "" && p.GoodsID != w.GoodsID {
+		return nil, errors.New("商品与称重记录不一致")
+	}
+	linked, err := s.r.WeighingLinked(ctx, w.ID)
+	if err != nil {
+		return nil, err
+	}
+	if linked {
+		return nil, errors.New("该称重记录已登记过浪费")
+	}
+	p.GoodsID = w.GoodsID
+	if p.Weight.IsZero() {
+		p.Weight, p.UnitID = w.Weight, w.UnitID
+	}
+	if p.WastedAt.IsZero() {
+		p.WastedAt = w.WeighedAt
+	}
+	return w, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Record, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Record, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, errors.New("org_id 不能为空")
+	}
+	p.GoodsID, p.ReasonID, p.MealID = utils.NormalizePtr(p.GoodsID), utils.NormalizePtr(p.ReasonID), utils.NormalizePtr(p.MealID)
+	return s.r.List(ctx, p)
+}
+
+// SoftDelete 删除浪费记录；已生成的报损流水不会回滚，需另行调整库存
+func (s *Service) SoftDelete(ctx context.Context, id string) error {
+	return s.r.SoftDelete(ctx, strings.TrimSpace(id))
+}
+
+// Report 按维度汇总浪费重量与金额。
+// 金额 = 数量（折算到商品单位）× 询价均价：取本机构区间内各次询价均价的平均值，区间内无询价则取截至区间末最近一次。
+func (s *Service) Report(ctx context.Context, p ReportParams) (*domain.Report, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, errors.New("org_id 不能为空")
+	}
+	p.DateFrom, p.DateTo = utils.DateOf(p.DateFrom), utils.DateOf(p.DateTo)
+	if p.DateTo.Before(p.DateFrom) {
+		return nil, errors.New("date_to 不能早于 date_from")
+	}
+	groupBy, err := normalizeGroupBy(p.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	period := ""
+	if contains(groupBy, domain.GroupPeriod) {
+		period = p.Period
+		if period == "" {
+			period = domain.PeriodMonth
+		}
+		if period != domain.PeriodDay && period != domain.PeriodWeek && period != domain.PeriodMonth {
+			return nil, fmt.Errorf("period 非法: %s（可选 day/week/month）", period)
+		}
+	}
+
+	orgIDs := []string{p.OrgID}
+	if p.IncludeSubOrgs {
+		if orgIDs, err = s.orgs.SubtreeIDs(ctx, p.OrgID); err != nil {
+			return nil, err
+		}
+	}
+	aggs, err := s.r.Aggregate(ctx, orgIDs, p.DateFrom, p.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	prices, err := s.valuation(ctx, aggs, p.DateFrom, p.DateTo)
+	if err != nil {
+		return nil, err
+	}
+	goodsIDs := make([]string, 0, len(aggs))
+	for _, a := range aggs {
+		goodsIDs = append(goodsIDs, a.GoodsID)
+	}
+	goodsUnits, err := s.goods.GoodsUnits(ctx, goodsIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &domain.Report{
+		DateFrom:   p.DateFrom,
+		DateTo:     p.DateTo,
+		GroupBy:    groupBy,
+		Period:     period,
+		PriceBasis: "区间内询价均价的平均值，区间内无询价取截至区间末最近一次",
+		Rows:       []domain.ReportRow{},
+	}
+	rows := map[string]*domain.ReportRow{}
+	keys := []string{}
+	kg := factorCache{}
+	toGoods := factorCache{}
+	for _, a := range aggs {
+		row, key := bucket(a, groupBy, period)
+		if _, ok := rows[key]; !ok {
+			rows[key] = &row
+			keys = append(keys, key)
+		}
+		r := rows[key]
+		r.Records += a.Records
+		out.Total.Records += a.Records
+
+		gid := a.GoodsID
+		if f, ok := kg.get(a.GoodsID, a.UnitID, func() (decimal.Decimal, error) {
+			v, _, err := s.units.ToBaseUnit(ctx, decimal.NewFromInt(1), a.UnitID, &gid, dict.DimensionMass)
+			return v, err
+		}); ok {
+			w := a.Weight.Mul(f)
+			r.WeightKg = r.WeightKg.Add(w)
+			out.Total.WeightKg = out.Total.WeightKg.Add(w)
+		} else {
+			r.Unconverted += a.Records
+			out.Total.Unconverted += a.Records
+		}
+
+		price, priced := prices[a.OrgID][a.GoodsID]
+		goodsUnit, known := goodsUnits[a.GoodsID]
+		f, ok := decimal.Zero, false
+		if priced && known {
+			f, ok = toGoods.get(a.GoodsID, a.UnitID, func() (decimal.Decimal, error) {
+				return s.units.ConvertQuantity(ctx, decimal.NewFromInt(1), a.UnitID, goodsUnit, &gid)
+			})
+		}
+		if !ok {
+			r.Unpriced += a.Records
+			out.Total.Unpriced += a.Records
+			continue
+		}
+		cost := a.Weight.Mul(f).Mul(price)
+		r.Cost = r.Cost.Add(cost)
+		out.Total.Cost = out.Total.Cost.Add(cost)
+	}
+
+	sort.Strings(keys)
+	for _, k := range keys {
+		r := rows[k]
+		r.WeightKg, r.Cost = r.WeightKg.Round(3), r.Cost.Round(2)
+		out.Rows = append(out.Rows, *r)
+	}
+	out.Total.WeightKg, out.Total.Cost = out.Total.WeightKg.Round(3), out.Total.Cost.Round(2)
+	return out, nil
+}
+
+// valuation 机构 → 商品 → 估值单价（按商品单位）
+func (s *Service) valuation(ctx context.Context, aggs []domain.Agg, from, to time.Time) (map[string]map[string]decimal.Decimal, error) {
+	byOrg := map[string][]string{}
+	seen := map[string]bool{}
+	for _, a := range aggs {
+		k := a.OrgID + "|" + a.GoodsID
+		if !seen[k] {
+			seen[k] = true
+			byOrg[a.OrgID] = append(byOrg[a.OrgID], a.GoodsID)
+		}
+	}
+	out := make(map[string]map[string]decimal.Decimal, len(byOrg))
+	for orgID, ids := range byOrg {
+		m, err := s.prices.MeanAvgPrices(ctx, orgID, ids, from, to)
+		if err != nil {
+			return nil, err
+		}
+		missing := make([]string, 0)
+		for _, id := range ids {
+			if _, ok := m[id]; !ok {
+				missing = append(missing, id)
+			}
+		}
+		if len(missing) > 0 {
+			latest, err := s.prices.LatestAvgPrices(ctx, orgID, missing, &to)
+			if err != nil {
+				return nil, err
+			}
+			for id, pt := range latest {
+				m[id] = pt.AvgPrice
+			}
+		}
+		out[orgID] = m
+	}
+	return out, nil
+}
+
+// bucket 按分组维度生成报表行骨架与分组键
+func bucket(a domain.Agg, groupBy []string, period string) (domain.ReportRow, string) {
+	var row domain.ReportRow
+	parts := make([]string, 0, len(groupBy))
+	for _, g := range groupBy {
+		var v *string
+		switch g {
+		case domain.GroupOrg:
+			v = ptr(a.OrgID)
+			row.OrgID = v
+		case domain.GroupCategory:
+			v = a.CategoryID
+			row.CategoryID = v
+		case domain.GroupMeal:
+			v = a.MealID
+			row.MealID = v
+		case domain.GroupReason:
+			v = ptr(a.ReasonID)
+			row.ReasonID = v
+		case domain.GroupGoods:
+			v = ptr(a.GoodsID)
+			row.GoodsID = v
+		case domain.GroupPeriod:
+			v = ptr(domain.PeriodLabel(a.Day, period))
+			row.Period = v
+		}
+		if v == nil {
+			parts = append(parts, "")
+		} else {
+			parts = append(parts, *v)
+		}
+	}
+	return row, strings.Join(parts, "|")
+}
+
+func normalizeGroupBy(in []string) ([]string, error) {
+	if len(in) == 0 {
+		return []string{domain.GroupOrg}, nil
+	}
+	out := make([]string, 0, len(in))
+	for _, g := range in {
+		g = strings.TrimSpace(g)
+		switch g {
+		case domain.GroupOrg, domain.GroupCategory, domain.GroupMeal, domain.GroupReason, domain.GroupGoods, domain.GroupPeriod:
+		default:
+			return nil, fmt.Errorf("group_by 非法: %s（可选 org/category/meal/reason/goods/period）", g)
+		}
+		if !contains(out, g) {
+			out = append(out, g)
+		}
+	}
+	return out, nil
+}
+
+// factorCache 缓存 商品×单位 的换算系数（换算为线性，1 单位的结果即系数）；换算失败记为不可用
+type factorCache map[string]*decimal.Decimal
+
+func (c factorCache) get(goodsID, unitID string, fn func() (decimal.Decimal, error)) (decimal.Decimal, bool) {
+	k := goodsID + "|" + unitID
+	f, ok := c[k]
+	if !ok {
+		if v, err := fn(); err == nil {
+			f = &v
+		}
+		c[k] = f
+	}
+	if f == nil {
+		return decimal.Zero, false
+	}
+	return *f, true
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func ptr(s string) *string { return &s }
@@ -0,0 +1,234 @@
+package weighing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	dict "hdzk.cn/foodapp/internal/domain/dict"
+	domain "hdzk.cn/foodapp/internal/domain/weighing"
+	goodsrepo "hdzk.cn/foodapp/internal/repository/goods"
+	repo "hdzk.cn/foodapp/internal/repository/weighing"
+	utils "hdzk.cn/foodapp/pkg/utils"
+)
+
+// MealResolver 机构生效的供餐时段与时区，用于判断称重时刻所属餐次；
+// 单位、餐次用于校验请求中的 ID（由字典服务实现）
+type MealResolver interface {
+	EffectiveMealWindows(ctx context.Context, orgID string) ([]dict.MealWindow, error)
+	OrgLocation(ctx context.Context, orgID string) (*time.Location, error)
+	GetUnit(ctx context.Context, id string) (*dict.Unit, error)
+	GetMealTime(ctx context.Context, id string) (*dict.MealTime, error)
+}
+
+type Service struct {
+	r     repo.Repository
+	goods goodsrepo.GoodsRepository
+	meals MealResolver
+}
+
+func NewService(r repo.Repository, goods goodsrepo.GoodsRepository, meals MealResolver) *Service {
+	return &Service{r: r, goods: goods, meals: meals}
+}
+
+type CreateParams struct {
+	OrgID      string
+	ScaleID    *string
+	GoodsID    string
+	Weight     decimal.Decimal
+	UnitID     string
+	WeighedAt  time.Time
+	MealID     *string    // 指定时视为人工归属
+	MealDate   *time.Time // 人工归属的就餐日期，空则取称重日期
+	OperatorID *string
+	Remark     *string
+}
+
+type ListParams = repo.ListParams
+
+func (s *Service) Create(ctx context.Context, p CreateParams) (*domain.Record, error) {
+	if strings.TrimSpace(p.OrgID) == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	if strings.TrimSpace(p.GoodsID) == "" {
+		return nil, fmt.Errorf("goods_id 不能为空")
+	}
+	if strings.TrimSpace(p.UnitID) == "" {
+		return nil, fmt.Errorf("unit_id 不能为空")
+	}
+	if !p.Weight.IsPositive() {
+		return nil, fmt.Errorf("weight 必须大于 0")
+	}
+	if p.WeighedAt.IsZero() {
+		p.WeighedAt = time.Now()
+	}
+	p.OrgID, p.GoodsID, p.UnitID = strings.TrimSpace(p.OrgID), strings.TrimSpace(p.GoodsID), strings.TrimSpace(p.UnitID)
+	g, err := s.goods.GetGoods(ctx, p.GoodsID)
+	if err != nil {
+		return nil, fmt.Errorf("商品不存在: %w", err)
+	}
+	if g.OrgID != p.OrgID {
+		return nil, fmt.Errorf("商品不属于该机构")
+	}
+	if _, err := s.meals.GetUnit(ctx, p.UnitID); err != nil {
+		return nil, fmt.Errorf("单位不存在: %w", err)
+	}
+	loc, err := s.meals.OrgLocation(ctx, p.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &domain.Record{
+		OrgID:      p.OrgID,
+		ScaleID:    utils.NormalizePtr(p.ScaleID),
+		GoodsID:    p.GoodsID,
+		Weight:     p.Weight,
+		UnitID:     p.UnitID,
+		WeighedAt:  p.WeighedAt,
+		OperatorID: utils.NormalizePtr(p.OperatorID),
+		Remark:     utils.NormalizePtr(p.Remark),
+	}
+	if mealID := utils.NormalizePtr(p.MealID); mealID != nil {
+		a, err := s.manualAssign(ctx, m.ID, *mealID, p.MealDate, p.WeighedAt, loc)
+		if err != nil {
+			return nil, err
+		}
+		m.MealID, m.MealDate, m.MealSource = a.MealID, a.MealDate, a.Source
+	} else {
+		windows, err := s.meals.EffectiveMealWindows(ctx, m.OrgID)
+		if err != nil {
+			return nil, err
+		}
+		a := autoAssign(windows, loc, m.ID, m.WeighedAt)
+		m.MealID, m.MealDate, m.MealSource = a.MealID, a.MealDate, a.Source
+	}
+	return m, s.r.Create(ctx, m)
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*domain.Record, error) {
+	return s.r.Get(ctx, strings.TrimSpace(id))
+}
+
+func (s *Service) List(ctx context.Context, p ListParams) ([]domain.Record, int64, error) {
+	p.OrgID = strings.TrimSpace(p.OrgID)
+	if p.OrgID == "" {
+		return nil, 0, fmt.Errorf("org_id 不能为空")
+	}
+	p.GoodsID, p.MealID = utils.NormalizePtr(p.GoodsID), utils.NormalizePtr(p.MealID)
+	return s.r.List(ctx, p)
+}
+
+func (s *Service) SoftDelete(ctx context.Context, id string) error {
+	return s.r.SoftDelete(ctx, strings.TrimSpace(id))
+}
+
+// SetMeal 人工指定餐次；mealID 为空表示取消人工指定，按供餐时段重新自动归属
+func (s *Service) SetMeal(ctx context.Context, id string, mealID *string, mealDate *time.Time) error {
+	rec, err := s.r.Get(ctx, strings.TrimSpace(id))
+	if err != nil {
+		return err
+	}
+	loc, err := s.meals.OrgLocation(ctx, rec.OrgID)
+	if err != nil {
+		return err
+	}
+	var a repo.MealAssign
+	if mid := utils.NormalizePtr(mealID); mid != nil {
+		if a, err = s.manualAssign(ctx, rec.ID, *mid, mealDate, rec.WeighedAt, loc); err != nil {
+			return err
+		}
+	} else {
+		windows, err := s.meals.EffectiveMealWindows(ctx, rec.OrgID)
+		if err != nil {
+			return err
+		}
+		a = autoAssign(windows, loc, rec.ID, rec.WeighedAt)
+	}
+	return s.r.AssignMeals(ctx, []repo.MealAssign{a})
+}
+
+// Reattribute 供餐时段调整后，按当前时段重新归属 [dateFrom, dateTo] 内的非人工记录，返回发生变化的条数
+func (s *Service) Reattribute(ctx context.Context, orgID string, dateFrom, dateTo time.Time) (int, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return 0, fmt.Errorf("org_id 不能为空")
+	}
+	if dateTo.Before(dateFrom) {
+		return 0, fmt.Errorf("date_to 不能早于 date_from")
+	}
+	loc, err := s.meals.OrgLocation(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+	// 按机构当地日期取 [dateFrom 零点, dateTo 次日零点)
+	from, to := dayStart(dateFrom, loc), dayStart(dateTo, loc).AddDate(0, 0, 1)
+	list, err := s.r.ListForAttribution(ctx, orgID, from, to)
+	if err != nil {
+		return 0, err
+	}
+	windows, err := s.meals.EffectiveMealWindows(ctx, orgID)
+	if err != nil {
+		return 0, err
+	}
+	changed := make([]repo.MealAssign, 0, len(list))
+	for _, rec := range list {
+		a := autoAssign(windows, loc, rec.ID, rec.WeighedAt)
+		if sameAssign(rec, a) {
+			continue
+		}
+		changed = append(changed, a)
+	}
+	return len(changed), s.r.AssignMeals(ctx, changed)
+}
+
+// MealConsumption 按餐次统计消耗
+func (s *Service) MealConsumption(ctx context.Context, orgID string, dateFrom, dateTo time.Time, mealID *string) ([]domain.MealConsumption, error) {
+	orgID = strings.TrimSpace(orgID)
+	if orgID == "" {
+		return nil, fmt.Errorf("org_id 不能为空")
+	}
+	return s.r.MealConsumption(ctx, orgID, utils.DateOf(dateFrom), utils.DateOf(dateTo), utils.NormalizePtr(mealID))
+}
+
+// manualAssign 人工指定餐次；mealDate 为空时取称重时刻在机构时区下的日期
+func (s *Service) manualAssign(ctx context.Context, id, mealID string, mealDate *time.Time, at time.Time, loc *time.Location) (repo.MealAssign, error) {
+	if _, err := s.meals.GetMealTime(ctx, mealID); err != nil {
+		return repo.MealAssign{}, fmt.Errorf("餐次不存在: %w", err)
+	}
+	day := utils.DateIn(at, loc)
+	if mealDate != nil {
+		day = utils.DateOf(*mealDate)
+	}
+	return repo.MealAssign{ID: id, MealID: &mealID, MealDate: &day, Source: domain.MealSourceManual}, nil
+}
+
+// dayStart 日期 d（按年月日）在 loc 下的零点
+func dayStart(d time.Time, loc *time.Location) time.Time {
+	y, m, day := d.Date()
+	return time.Date(y, m, day, 0, 0, 0, 0, loc)
+}
+
+// autoAssign 按给定时段自动归属；批量重新归属时同一机构只加载一次时段与时区
+func autoAssign(windows []dict.MealWindow, loc *time.Location, id string, at time.Time) repo.MealAssign {
+	w, day, ok := dict.Attribute(windows, at, loc)
+	if !ok {
+		return repo.MealAssign{ID: id, Source: domain.MealSourceNone}
+	}
+	mealID := w.MealID
+	return repo.MealAssign{ID: id, MealID: &mealID, MealDate: &day, Source: domain.MealSourceAuto}
+}
+
+func sameAssign(rec domain.Record, a repo.MealAssign) bool {
+	if rec.MealSource != a.Source {
+		return false
+	}
+	if (rec.MealID == nil) != (a.MealID == nil) || (rec.MealID != nil && *rec.MealID != *a.MealID) {
+		return false
+	}
+	if (rec.MealDate == nil) != (a.MealDate == nil) {
+		return false
+	}
+	return rec.MealDate == nil || rec.MealDate.Format("2006-01-02") == a.MealDate.Format("2006-01-02")
+}
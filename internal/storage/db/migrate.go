@@ -4,20 +4,158 @@ package foodDB
 import (
 	"gorm.io/gorm"
 	acc "hdzk.cn/foodapp/internal/domain/account"
+	bidding "hdzk.cn/foodapp/internal/domain/bidding"
 	category "hdzk.cn/foodapp/internal/domain/category"
+	coderule "hdzk.cn/foodapp/internal/domain/coderule"
 	dict "hdzk.cn/foodapp/internal/domain/dict"
+	goods "hdzk.cn/foodapp/internal/domain/goods"
+	idempotency "hdzk.cn/foodapp/internal/domain/idempotency"
+	inquiry "hdzk.cn/foodapp/internal/domain/inquiry"
+	inventory "hdzk.cn/foodapp/internal/domain/inventory"
+	market "hdzk.cn/foodapp/internal/domain/market"
+	mealplan "hdzk.cn/foodapp/internal/domain/mealplan"
+	merge "hdzk.cn/foodapp/internal/domain/merge"
+	notification "hdzk.cn/foodapp/internal/domain/notification"
 	organ "hdzk.cn/foodapp/internal/domain/organ"
+	portal "hdzk.cn/foodapp/internal/domain/portal"
+	price "hdzk.cn/foodapp/internal/domain/price"
+	purchase "hdzk.cn/foodapp/internal/domain/purchase"
+	qualification "hdzk.cn/foodapp/internal/domain/qualification"
+	recipe "hdzk.cn/foodapp/internal/domain/recipe"
+	report "hdzk.cn/foodapp/internal/domain/report"
+	scorecard "hdzk.cn/foodapp/internal/domain/scorecard"
+	supplier "hdzk.cn/foodapp/internal/domain/supplier"
+	waste "hdzk.cn/foodapp/internal/domain/waste"
+	weighing "hdzk.cn/foodapp/internal/domain/weighing"
 )
 
 func AutoMigrate(gdb *gorm.DB) error {
-	return gdb.AutoMigrate(
+	if err := plainAvgPrice(gdb); err != nil {
+		return err
+	}
+	if err := sqlOnlyColumns(gdb); err != nil {
+		return err
+	}
+	if err := barcodeActiveUnique(gdb); err != nil {
+		return err
+	}
+	if err := gdb.AutoMigrate(
 		&organ.Organ{},
 		&acc.Account{},
 		&dict.Unit{},
 		&dict.Spec{},
 		&dict.MealTime{},
+		&dict.GoodsUnitConversion{},
+		&dict.MealOrgWindow{},
+		&dict.WasteReason{},
 		&category.Category{},
+		&goods.Barcode{},
+		&merge.Record{},
+		&mealplan.Plan{},
+		&mealplan.Dish{},
+		&weighing.Record{},
+		&recipe.Recipe{},
+		&recipe.Version{},
+		&recipe.Line{},
+		&purchase.Order{},
+		&purchase.Line{},
+		&inventory.Movement{},
+		&inventory.Balance{},
+		&inventory.Count{},
+		&inventory.CountLine{},
+		&waste.Record{},
+		&inquiry.History{},
+		&inquiry.Template{},
+		&inquiry.TemplateLine{},
+		&market.Market{},
+		&inquiry.InquiryMarket{},
+		&inquiry.TemplateMarket{},
+		&price.LinePrice{},
+		&report.Layout{},
+		&price.Rule{},
+		&price.Flag{},
+		&notification.Notification{},
+		&idempotency.Record{},
+		&coderule.Rule{},
+		&coderule.Counter{},
+		&supplier.FloatRatio{},
+		&qualification.Document{},
+		&scorecard.Rating{},
+		&scorecard.Snapshot{},
+		&portal.Account{},
+		&portal.Invitation{},
+		&bidding.Round{},
+		&bidding.Invitee{},
+		&bidding.Bid{},
+		&bidding.Award{},
+		&bidding.AccessLog{},
 		// 其他模型
 		// 以后新增模型都放这里
-	)
+	); err != nil {
+		return err
+	}
+	return backfillFloatRatio(gdb)
+}
+
+// plainAvgPrice 旧库的 base_goods_avg_detail.avg_price 是由 market1..3_price 计算的生成列；
+// 市场改为可变数量后均价由应用写入，这里将其改为普通列（保留已有值）
+func plainAvgPrice(gdb *gorm.DB) error {
+	var n int64
+	err := gdb.Raw(`SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = 'avg_price' AND EXTRA LIKE '%GENERATED%'`,
+		price.AvgDetailTable).Scan(&n).Error
+	if err != nil || n == 0 {
+		return err
+	}
+	return gdb.Exec("ALTER TABLE " + price.AvgDetailTable +
+		" MODIFY COLUMN avg_price DECIMAL(10,2) NULL COMMENT '均价（各市场价的平均值）'").Error
+}
+
+// sqlOnlyColumns 以下表由 SQL 脚本维护（不在 AutoMigrate 中），
+// 旧库按模型字段补充后续新增的列（CREATE TABLE IF NOT EXISTS 不会修改已有表）
+func sqlOnlyColumns(gdb *gorm.DB) error {
+	m := gdb.Migrator()
+	for _, t := range []struct {
+		model  any
+		fields []string
+	}{
+		{&goods.Goods{}, []string{"Version"}},
+		{&inquiry.PriceInquiry{}, []string{"Status", "Version"}},
+		{&supplier.Supplier{}, []string{"AutoDisabled", "Version"}},
+	} {
+		if !m.HasTable(t.model) {
+			continue
+		}
+		for _, f := range t.fields {
+			if m.HasColumn(t.model, f) {
+				continue
+			}
+			if err := m.AddColumn(t.model, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// barcodeActiveUnique 条码改为软删后唯一键只约束有效行（uk_goods_barcode_org_active，由 AutoMigrate 创建），
+// 旧库先删除原 (org_id, barcode) 唯一键，否则已删除条码仍会占用
+func barcodeActiveUnique(gdb *gorm.DB) error {
+	m := gdb.Migrator()
+	if !m.HasTable(&goods.Barcode{}) || !m.HasIndex(&goods.Barcode{}, "uk_goods_barcode_org") {
+		return nil
+	}
+	return m.DropIndex(&goods.Barcode{}, "uk_goods_barcode_org")
+}
+
+// backfillFloatRatio 为尚无比例历史的供应商补一条当前比例（自合同开始日或创建日起生效）
+func backfillFloatRatio(gdb *gorm.DB) error {
+	if !gdb.Migrator().HasTable(&supplier.Supplier{}) {
+		return nil
+	}
+	return gdb.Exec(`INSERT INTO ` + supplier.FloatRatio{}.TableName() + `
+		(id, supplier_id, float_ratio, effective_from, remark, created_at, updated_at)
+		SELECT UUID(), s.id, s.float_ratio, DATE(COALESCE(s.start_time, s.created_at)), '历史数据迁移', NOW(), NOW()
+		FROM ` + supplier.Supplier{}.TableName() + ` AS s
+		WHERE NOT EXISTS (SELECT 1 FROM ` + supplier.FloatRatio{}.TableName() + ` AS h WHERE h.supplier_id = s.id)`).Error
 }
@@ -53,7 +53,7 @@ func EnsureDefaultAccount(ctx context.Context, gdb *gorm.DB) error {
 			)
 			return nil
 		}
-		if err := repo.UpdateFields(ctx, existing.ID, patch); err != nil {
+		if err := repo.UpdateFields(ctx, existing.ID, existing.Version, patch); err != nil {
 			return err
 		}
 		logger.L().Info("patched existing default admin",
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 批量操作模式
+const (
+	BatchAtomic     = "atomic"      // 全部成功才提交，任一失败整体回滚
+	BatchBestEffort = "best_effort" // 失败项单独回滚，其余照常提交
+)
+
+// 批量操作类型
+const (
+	BatchOpCreate  = "create"
+	BatchOpUpdate  = "update"
+	BatchOpDelete  = "delete" // 软删
+	BatchOpReorder = "reorder"
+)
+
+// BatchMaxOps 单次批量的操作数上限
+const BatchMaxOps = 500
+
+var ErrBatchAborted = errors.New("批量操作存在失败项，已整体回滚")
+
+// BatchItem 单项执行结果（Index 与请求 ops 下标一致）
+type BatchItem struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	ID    string `json:"id,omitempty"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type BatchResult struct {
+	Mode      string      `json:"mode"`
+	Committed bool        `json:"committed"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	Items     []BatchItem `json:"items"`
+}
+
+// NormalizeBatchMode 空串默认 atomic
+func NormalizeBatchMode(mode string) (string, error) {
+	switch m := strings.TrimSpace(mode); m {
+	case "":
+		return BatchAtomic, nil
+	case BatchAtomic, BatchBestEffort:
+		return m, nil
+	}
+	return "", fmt.Errorf("mode 只能为 %s 或 %s", BatchAtomic, BatchBestEffort)
+}
+
+// RunBatch 在同一事务内逐项执行 apply（返回受影响记录 ID），每项经 savepoint 包裹（嵌套事务即 SAVEPOINT）。
+// best_effort：失败项仅回滚到其 savepoint，继续后续项；
+// atomic：遇首个失败即停止，余项标记未执行并返回 ErrBatchAborted，调用方据此回滚整个事务
+func RunBatch(mode string, ops []string, savepoint func(fn func() error) error, apply func(i int) (string, error)) (*BatchResult, error) {
+	res := &BatchResult{Mode: mode, Items: make([]BatchItem, len(ops))}
+	aborted := false
+	for i, op := range ops {
+		item := BatchItem{Index: i, Op: op}
+		if aborted {
+			item.Error = "未执行（前序操作失败，整体回滚）"
+			res.Items[i] = item
+			continue
+		}
+		err := savepoint(func() error {
+			id, err := apply(i)
+			item.ID = id
+			return err
+		})
+		if err != nil {
+			item.Error = err.Error()
+			res.Failed++
+			if mode == BatchAtomic {
+				aborted = true
+			}
+		} else {
+			item.OK = true
+			res.Succeeded++
+		}
+		res.Items[i] = item
+	}
+	if aborted {
+		return res, ErrBatchAborted
+	}
+	return res, nil
+}
+
+// ReorderSorts 按 ids 给定顺序重排：取这些记录当前占用的 sort 升序后依次分配，其余记录不动，
+// 因此不会新增空洞，已有空洞仍由 NextColoumSort / NextSortSuffix 按最小缺口回填。
+// orgID 非空时限定该 org；重复或越出段下界的 sort 依次顺延，顺延后越过段上界 base+999 时返回 ErrSortSegmentFull
+// （与 NextSortSuffix 一致）；变更的记录版本号 +1
+func ReorderSorts(tx *gorm.DB, tableName, orgID string, base int, ids []string) error {
+	if len(ids) == 0 {
+		return errors.New("ids 不能为空")
+	}
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("ids 重复: %s", id)
+		}
+		seen[id] = true
+	}
+
+	var rows []struct {
+		ID   string
+		Sort int
+	}
+	q := tx.Table(tableName).
+		Select("id, sort").
+		Where("id IN ? AND is_deleted = 0", ids)
+	if orgID != "" {
+		q = q.Where("org_id = ?", orgID)
+	}
+	if err := q.Clauses(clause.Locking{Strength: "UPDATE"}).Scan(&rows).Error; err != nil {
+		return fmt.Errorf("读取 sort 失败: %w", err)
+	}
+	if len(rows) != len(ids) {
+		return fmt.Errorf("有 %d 条记录不存在、已删除或不属于该 org", len(ids)-len(rows))
+	}
+
+	current := make(map[string]int, len(rows))
+	slots := make([]int, 0, len(rows))
+	for _, r := range rows {
+		current[r.ID] = r.Sort
+		slots = append(slots, r.Sort)
+	}
+	sort.Ints(slots)
+	prev := base
+	for i := range slots {
+		if slots[i] <= prev {
+			slots[i] = prev + 1
+		}
+		prev = slots[i]
+	}
+	if orgID != "" && prev > base+SortSegmentSize {
+		return ErrSortSegmentFull
+	}
+
+	for i, id := range ids {
+		if current[id] == slots[i] {
+			continue
+		}
+		if err := tx.Table(tableName).
+			Where("id = ?", id).
+			Updates(map[string]any{"sort": slots[i], "version": gorm.Expr("version + 1")}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// Transactor 跨仓储（跨服务）事务：fn 收到的 ctx 携带事务，
+// 仓储方法经 DB(ctx, db) 取库即加入该事务；已处于事务中时为嵌套事务（SAVEPOINT）
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type gormTransactor struct{ db *gorm.DB }
+
+func NewTransactor(db *gorm.DB) Transactor { return gormTransactor{db: db} }
+
+func (t gormTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return DB(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// DB 返回 ctx 携带的事务（见 Transactor），否则返回 db.WithContext(ctx)
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
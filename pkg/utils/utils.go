@@ -123,6 +123,13 @@ func GetOrgCodeAndSortByID(ctx context.Context, db *gorm.DB, id string, forUpdat
 	return row.Code, row.Sort, nil
 }
 
+// SortSegmentSize 每个 org 的 sort 段容量：org 内记录的 sort 取值 (org.sort*1000, org.sort*1000+999]
+const SortSegmentSize = 999
+
+// ErrSortSegmentFull 段内 1..999 均已占用；越过段上界会与下一 org 的段重叠，因此直接拒绝
+var ErrSortSegmentFull = errors.New("该 org 的 sort 段已满（1..999）")
+
+// NextSortSuffix 返回 org 段 (base, base+999] 内的最小空缺后缀；段满返回 ErrSortSegmentFull
 func NextSortSuffix(tx *gorm.DB, table_name, orgID string, base int, forUpdate bool) (int, error) {
 	type rec struct{ Sort int }
 	var rows []rec
@@ -134,7 +141,7 @@ func NextSortSuffix(tx *gorm.DB, table_name, orgID string, base int, forUpdate b
 			org_id = ?
 			AND is_deleted = 0
 			AND sort > ? AND sort <= ?`,
-			orgID, base, base+999).
+			orgID, base, base+SortSegmentSize).
 		Order("sort ASC")
 	if forUpdate {
 		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
@@ -155,49 +162,8 @@ func NextSortSuffix(tx *gorm.DB, table_name, orgID string, base int, forUpdate b
 		}
 		break
 	}
-	if next > 999 {
-		return 0, fmt.Errorf("该 org 的 sort 段已满（1..999）")
-	}
-	return next, nil
-}
-
-// code 段的最小缺口：仅统计本 organ 形如 <orgCode><三位数字>
-func NextCodeSuffixByPrefix(tx *gorm.DB, table_name, orgID, orgCode string, forUpdate bool) (int, error) {
-	type rec struct{ Suffix int }
-	var rows []rec
-
-	// 用 SUBSTRING(code, ? + 1) + REGEXP/LIKE；以 len(orgCode) 作为位置参数，避免 CHAR_LENGTH(?) 触发计数差异
-	prefixLen := len(orgCode)
-
-	q := tx.Table(table_name).
-		Select("CAST(SUBSTRING(code, ? + 1) AS UNSIGNED) AS suffix", prefixLen).
-		Where(`
-			org_id = ?
-			AND is_deleted = 0
-			AND code LIKE CONCAT(?, '___')
-			AND code REGEXP CONCAT('^', ?, '[0-9]{3}$')`,
-			orgID, orgCode, orgCode).
-		Order("suffix ASC")
-	if forUpdate {
-		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
-	}
-	if err := q.Scan(&rows).Error; err != nil {
-		return 0, fmt.Errorf("扫描 code 后缀失败: %w", err)
-	}
-
-	next := 1
-	for _, r := range rows {
-		if r.Suffix < next {
-			continue
-		}
-		if r.Suffix == next {
-			next++
-			continue
-		}
-		break
-	}
-	if next > 999 {
-		return 0, fmt.Errorf("该 org 的 code 段已满（001..999）")
+	if next > SortSegmentSize {
+		return 0, ErrSortSegmentFull
 	}
 	return next, nil
 }
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+// NormalizePtr 去除首尾空白；nil 或空串返回 nil（可选文本字段统一按未填写处理）
+func NormalizePtr(p *string) *string {
+	if p == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*p)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+// DateOf 截取到当天零点（保留时区）
+func DateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// DateIn 取 t 在 loc 下的日期，以 time.Local 零点承载（DATE 列按连接参数 loc=Local 读写）
+func DateIn(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict 乐观锁冲突：行已被他人修改
+var ErrVersionConflict = errors.New("数据已被他人修改，请刷新后重试")
+
+// UpdateVersioned 乐观锁条件更新：q 为已限定目标行的查询（Model + Where），
+// 仅当 version 等于期望值时更新并递增版本号。版本不符返回 ErrVersionConflict，行不存在返回 gorm.ErrRecordNotFound
+func UpdateVersioned(q *gorm.DB, version int, updates map[string]any) error {
+	base := q.Session(&gorm.Session{})
+	if updates == nil {
+		updates = map[string]any{}
+	}
+	updates["version"] = gorm.Expr("version + 1")
+	res := base.Where("version = ?", version).Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 {
+		return nil
+	}
+	var n int64
+	if err := base.Count(&n).Error; err != nil {
+		return err
+	}
+	if n == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionConflict
+}
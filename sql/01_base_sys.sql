@@ -15,13 +15,18 @@ CREATE TABLE IF NOT EXISTS base_unit (
   name        VARCHAR(32)  NOT NULL COMMENT '单位名称',
   code        VARCHAR(32)      NULL COMMENT '单位编码（可选）',
   sort        INT          NOT NULL DEFAULT 0 COMMENT '排序码',
+  dimension   VARCHAR(16)      NULL COMMENT '量纲：mass/volume/count（空=未定义换算）',
+  factor      DECIMAL(20,8)    NULL COMMENT '1 本单位 = factor 基准单位',
+  is_base     TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '是否为该量纲基准单位：0=否 1=是',
   is_deleted  TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '是否已删除：0=否 1=是',
+  version     INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (id),
   UNIQUE KEY uk_unit_name (name),
   UNIQUE KEY uk_unit_code (code),
   KEY idx_unit_sort (sort),
+  KEY idx_unit_dimension (dimension),
   KEY idx_unit_del  (is_deleted)
 ) ENGINE=InnoDB
   COMMENT='单位字典';
@@ -33,6 +38,7 @@ CREATE TABLE IF NOT EXISTS base_spec (
   code        VARCHAR(32)      NULL COMMENT '规格编码（可选）',
   sort        INT          NOT NULL DEFAULT 0 COMMENT '排序码',
   is_deleted  TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '是否已删除：0=否 1=是',
+  version     INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (id),
@@ -49,7 +55,10 @@ CREATE TABLE IF NOT EXISTS menu_meal (
   name        VARCHAR(32)  NOT NULL COMMENT '餐次名称',
   code        VARCHAR(32)      NULL COMMENT '餐次编码（可选）',
   sort        INT          NOT NULL DEFAULT 0 COMMENT '排序码',
+  start_time  VARCHAR(5)       NULL COMMENT '默认供餐开始时刻 HH:MM',
+  end_time    VARCHAR(5)       NULL COMMENT '默认供餐结束时刻 HH:MM（小于开始时刻表示跨零点）',
   is_deleted  TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=已删除',
+  version     INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (id),
@@ -60,6 +69,24 @@ CREATE TABLE IF NOT EXISTS menu_meal (
 ) ENGINE=InnoDB
   COMMENT='餐次字典';
 
+/* ---------- 浪费原因字典（剩饭剩菜/变质/加工损耗/过期 等） ---------- */
+CREATE TABLE IF NOT EXISTS base_waste_reason (
+  id          CHAR(36)     NOT NULL COMMENT '主键UUID',
+  name        VARCHAR(32)  NOT NULL COMMENT '浪费原因',
+  code        VARCHAR(32)      NULL COMMENT '原因编码（可选）',
+  sort        INT          NOT NULL DEFAULT 0 COMMENT '排序码',
+  is_deleted  TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '是否已删除：0=否 1=是',
+  version     INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_waste_reason_name (name),
+  UNIQUE KEY uk_waste_reason_code (code),
+  KEY idx_waste_reason_sort (sort),
+  KEY idx_waste_reason_del  (is_deleted)
+) ENGINE=InnoDB
+  COMMENT='浪费原因字典';
+
 /* =======================================================================
    组织 / 设备 / AI 模型 / 用户
    ======================================================================= */
@@ -70,9 +97,11 @@ CREATE TABLE IF NOT EXISTS base_org (
   name        VARCHAR(128)  NOT NULL COMMENT '组织机构名称',
   code        VARCHAR(64)   NOT NULL COMMENT '组织机构编码',
   sort        INT           NOT NULL DEFAULT 0 COMMENT '排序码（-1 表示系统保留/隐藏）',
+  time_zone   VARCHAR(64)   NOT NULL DEFAULT 'Asia/Shanghai' COMMENT '时区（IANA 名称），按当地时刻归属餐次',
   parent_id   CHAR(36)      NOT NULL COMMENT '上级组织机构Id（base_org.id；根节点自指）',
   description TEXT          NOT NULL COMMENT '组织机构描述',
   is_deleted  TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '是否删除：0=否 1=是',
+  version     INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
 
@@ -148,6 +177,7 @@ CREATE TABLE IF NOT EXISTS base_user (
   role           TINYINT      NOT NULL DEFAULT 1 COMMENT '角色 0管理员 1用户',
   sort           INT          NOT NULL DEFAULT 0 COMMENT '排序码',
   is_deleted     TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '是否删除标记：0=否 1=是',
+  version        INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at     DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   last_login_at  DATETIME         NULL COMMENT '登录时间',
   login_ip       VARCHAR(45)      NULL COMMENT '登录ip（支持IPv4/IPv6）',
@@ -159,4 +189,86 @@ CREATE TABLE IF NOT EXISTS base_user (
   KEY idx_user_login (last_login_at),
   CONSTRAINT fk_user_org FOREIGN KEY (org_id) REFERENCES base_org(id)
 ) ENGINE=InnoDB
-  COMMENT='Base_用户表';
\ No newline at end of file
+  COMMENT='Base_用户表';
+/* ---------- 打印版式：每机构每种单据一份，未配置时使用默认版式 ---------- */
+CREATE TABLE IF NOT EXISTS print_layout (
+  id                CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id            CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  kind              VARCHAR(16)   NOT NULL COMMENT '单据类型：inquiry/settlement',
+  title             VARCHAR(64)       NULL COMMENT '标题（为空取单据默认标题）',
+  subtitle          VARCHAR(128)      NULL COMMENT '副标题（如单位全称）',
+  signers           VARCHAR(255)  NOT NULL DEFAULT '' COMMENT '签字栏，逗号分隔，如 询价人,复核人,负责人',
+  page_size         VARCHAR(8)    NOT NULL DEFAULT 'A4' COMMENT '纸张：A4/A5',
+  orientation       VARCHAR(1)    NOT NULL DEFAULT 'P' COMMENT '方向：P=纵向 L=横向',
+  font_size         INT           NOT NULL DEFAULT 10 COMMENT '正文字号（pt）',
+  show_guide_price  INT           NOT NULL DEFAULT 1 COMMENT '询价单是否打印指导价：0=否 1=是',
+  footer            VARCHAR(255)      NULL COMMENT '页脚说明',
+  created_at        DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at        DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_pl_org_kind (org_id, kind)
+) ENGINE=InnoDB
+  COMMENT='打印版式';
+/* ---------- 站内通知：按机构推送，dedup_key 保证同一事件只通知一次 ---------- */
+CREATE TABLE IF NOT EXISTS sys_notification (
+  id          CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id      CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  kind        VARCHAR(32)   NOT NULL COMMENT '通知类型',
+  ref_type    VARCHAR(32)   NOT NULL COMMENT '关联对象类型，如 supplier/supplier_document',
+  ref_id      CHAR(36)      NOT NULL COMMENT '关联对象ID',
+  title       VARCHAR(128)  NOT NULL COMMENT '标题',
+  content     VARCHAR(512)  NOT NULL DEFAULT '' COMMENT '内容',
+  dedup_key   VARCHAR(191)  NOT NULL COMMENT '去重键',
+  read_at     DATETIME          NULL COMMENT '已读时间（空=未读）',
+  created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_notice_dedup (dedup_key),
+  KEY idx_notice_org (org_id, read_at),
+  KEY idx_sys_notification_ref_id (ref_id)
+) ENGINE=InnoDB
+  COMMENT='站内通知';
+/* ---------- 幂等键：POST create_* 接口按 操作者+Idempotency-Key 保存首次响应，过期由定时任务清理 ---------- */
+CREATE TABLE IF NOT EXISTS sys_idempotency_key (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  actor_id      CHAR(36)      NOT NULL COMMENT '操作者账户ID',
+  idem_key      VARCHAR(128)  NOT NULL COMMENT '客户端幂等键（Idempotency-Key）',
+  method        VARCHAR(8)    NOT NULL COMMENT '请求方法',
+  path          VARCHAR(255)  NOT NULL COMMENT '请求路径',
+  request_hash  CHAR(64)      NOT NULL COMMENT '请求摘要（方法+路径+请求体 SHA-256）',
+  status        TINYINT       NOT NULL DEFAULT 0 COMMENT '状态：0=处理中,1=已完成',
+  status_code   INT           NOT NULL DEFAULT 0 COMMENT '首次响应状态码',
+  content_type  VARCHAR(128)  NOT NULL DEFAULT '' COMMENT '首次响应 Content-Type',
+  body          MEDIUMBLOB        NULL COMMENT '首次响应体',
+  expires_at    DATETIME      NOT NULL COMMENT '过期时间',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_idem_actor_key (actor_id, idem_key),
+  KEY idx_sys_idempotency_key_expires_at (expires_at)
+) ENGINE=InnoDB
+  COMMENT='幂等键（重复提交返回首次响应）';
+/* ---------- 编码规则：按 实体类型+机构 配置编码模式（如 {org}{cat}{seq:5}），org_id 为空表示全局默认 ---------- */
+CREATE TABLE IF NOT EXISTS base_code_rule (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id        CHAR(36)      NOT NULL DEFAULT '' COMMENT '机构ID（空=全局默认）',
+  entity_type   VARCHAR(32)   NOT NULL COMMENT '实体类型：goods/category/supplier/unit/spec/meal_time/waste_reason',
+  pattern       VARCHAR(128)  NOT NULL COMMENT '编码模式，如 {org}{cat}{seq:5}',
+  reuse_gaps    TINYINT       NOT NULL DEFAULT 0 COMMENT '是否回填序号空缺：0=否（计数器递增） 1=是',
+  version       INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_code_rule_org_entity (org_id, entity_type)
+) ENGINE=InnoDB
+  COMMENT='编码规则';
+/* ---------- 编码序号计数器：按 实体类型+作用域 递增，发号时行锁，首次使用以已有编码的最大序号初始化 ---------- */
+CREATE TABLE IF NOT EXISTS base_code_counter (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  entity_type   VARCHAR(32)   NOT NULL COMMENT '实体类型',
+  scope         VARCHAR(191)  NOT NULL COMMENT '作用域（模式渲染后 {seq} 以外的部分），如 HD001{seq}',
+  last_seq      INT           NOT NULL DEFAULT 0 COMMENT '已发放的最大序号',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_code_counter_scope (entity_type, scope)
+) ENGINE=InnoDB
+  COMMENT='编码序号计数器';
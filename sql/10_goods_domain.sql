@@ -10,17 +10,20 @@ CREATE TABLE IF NOT EXISTS base_category (
   id          CHAR(36)     NOT NULL COMMENT '主键UUID',
   name        VARCHAR(64)  NOT NULL COMMENT '品类名称（同一中队内唯一）',
   org_id      CHAR(36)     NOT NULL COMMENT '中队ID',
-  code        VARCHAR(64)      NULL COMMENT '品类编码',
+  parent_id   CHAR(36)         NULL COMMENT '上级品类ID（base_category.id；NULL=顶级）',
+  code        VARCHAR(64)      NULL COMMENT '品类编码（子品类=上级 code + 三位后缀）',
   pinyin      VARCHAR(64)      NULL COMMENT '拼音（可选，用于搜索）',
   sort        INT          NOT NULL DEFAULT 0 COMMENT '排序码',
   is_deleted  TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '软删标记：0=有效,1=已删除',
+  version     INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (id),
   -- 同一中队下品类名称唯一
   UNIQUE KEY uq_category_org_name (org_id, name),
   -- 品类编码全局唯一（如果业务需要）
-  UNIQUE KEY uq_category_code (code)
+  UNIQUE KEY uq_category_code (code),
+  KEY idx_category_parent (parent_id)
 ) ENGINE=InnoDB
   COMMENT='商品品类（如 蔬菜/肉类/调味品 等）';
 
@@ -43,6 +46,7 @@ CREATE TABLE IF NOT EXISTS base_goods (
   category_id   CHAR(36)      NOT NULL COMMENT '商品品类ID（base_category.id）',
   org_id        CHAR(36)      NOT NULL COMMENT '中队ID',
   is_deleted    TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删标记：0=有效 1=删除',
+  version       INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   PRIMARY KEY (id),
@@ -64,18 +68,66 @@ CREATE TABLE IF NOT EXISTS base_goods (
 ) ENGINE=InnoDB
   COMMENT='Base_商品库（基础商品主数据：名称/拼音/规格/SKU/图片/品类）';
 
+/* ---------- Base_商品级单位换算（覆盖通用换算，如 1 箱 = 12 瓶） ---------- */
+CREATE TABLE IF NOT EXISTS base_goods_unit_conversion (
+  id          CHAR(36)       NOT NULL COMMENT '主键UUID',
+  goods_id    CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  unit_id     CHAR(36)       NOT NULL COMMENT '源单位ID（base_unit.id）',
+  to_unit_id  CHAR(36)       NOT NULL COMMENT '目标单位ID（base_unit.id）',
+  factor      DECIMAL(20,8)  NOT NULL COMMENT '1 源单位 = factor 目标单位',
+  org_id      CHAR(36)       NOT NULL COMMENT '中队ID',
+  created_at  DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at  DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_guc_goods_unit_to (goods_id, unit_id, to_unit_id),
+  KEY idx_guc_org (org_id),
+  CONSTRAINT fk_guc_goods   FOREIGN KEY (goods_id)   REFERENCES base_goods(id),
+  CONSTRAINT fk_guc_unit    FOREIGN KEY (unit_id)    REFERENCES base_unit(id),
+  CONSTRAINT fk_guc_to_unit FOREIGN KEY (to_unit_id) REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='Base_商品级单位换算';
+
+/* ---------- Base_商品条码：每种包装（单品/中包/整箱）一个 GS1 条码，机构内有效条码唯一 ---------- */
+CREATE TABLE IF NOT EXISTS base_goods_barcode (
+  id          CHAR(36)       NOT NULL COMMENT '主键UUID',
+  org_id      CHAR(36)       NOT NULL COMMENT '所属机构ID',
+  barcode     VARCHAR(14)    NOT NULL COMMENT '条码（规范化 GTIN：8/13/14 位，UPC-A 补前导 0）',
+  symbology   VARCHAR(16)    NOT NULL COMMENT '录入时的条码类型：ean13/ean8/upca/gtin14',
+  goods_id    CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  unit_id     CHAR(36)       NOT NULL COMMENT '包装单位ID（base_unit.id），如 瓶/箱',
+  pack_qty    DECIMAL(20,8)  NOT NULL DEFAULT 1 COMMENT '每包装折合商品单位数量',
+  is_primary  INT            NOT NULL DEFAULT 0 COMMENT '是否为商品主条码：0=否 1=是',
+  remark      VARCHAR(255)       NULL COMMENT '备注',
+  is_deleted  TINYINT(1)     NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  created_at  DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at  DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+
+  -- 仅对未删除行生效的唯一：利用 NULL 不参与唯一的特性
+  active_barcode VARCHAR(14) AS (CASE WHEN is_deleted = 0 THEN barcode ELSE NULL END) STORED,
+
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_goods_barcode_org_active (org_id, active_barcode),
+  KEY idx_base_goods_barcode_goods_id (goods_id),
+  CONSTRAINT fk_gbc_goods FOREIGN KEY (goods_id) REFERENCES base_goods(id),
+  CONSTRAINT fk_gbc_unit  FOREIGN KEY (unit_id)  REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='Base_商品条码';
+
 /* ---------- Base_询价记录 ---------- */
 CREATE TABLE IF NOT EXISTS base_price_inquiry (
   id                 CHAR(36)     NOT NULL COMMENT 'UUID',
   inquiry_title      VARCHAR(64)  NOT NULL COMMENT '询价单标题',
   inquiry_date       DATE         NOT NULL COMMENT '询价单日期（业务日）',
 
-  market_1           VARCHAR(128)     NULL COMMENT '市场1',
-  market_2           VARCHAR(128)     NULL COMMENT '市场2',
-  market_3           VARCHAR(128)     NULL COMMENT '市场3',
+  -- 旧版市场名称，仅兼容保留；参与市场见 base_price_inquiry_market
+  market_1           VARCHAR(128)     NULL COMMENT '市场1（旧版）',
+  market_2           VARCHAR(128)     NULL COMMENT '市场2（旧版）',
+  market_3           VARCHAR(128)     NULL COMMENT '市场3（旧版）',
 
   org_id             CHAR(36)     NOT NULL COMMENT '中队ID',
+  status             INT          NOT NULL DEFAULT 0 COMMENT '状态：0=草稿 1=已提交 2=已审核 3=已归档',
   is_deleted         TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version            INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
 
   created_at         DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
   updated_at         DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
@@ -101,37 +153,107 @@ CREATE TABLE IF NOT EXISTS base_price_inquiry (
   -- ,CONSTRAINT chk_date_match CHECK (inquiry_date = DATE(inquiry_start_date))
 ) ENGINE=InnoDB COMMENT='询价记录';
 
+/* ---------- 询价市场：机构维护的市场主数据 ---------- */
+CREATE TABLE IF NOT EXISTS base_market (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id         CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  name           VARCHAR(128)  NOT NULL COMMENT '市场名称（同机构有效市场内唯一）',
+  address        VARCHAR(255)      NULL COMMENT '地址',
+  type           VARCHAR(16)   NOT NULL DEFAULT 'other' COMMENT '类型：wholesale/retail/supermarket/online/other',
+  sort           INT           NOT NULL DEFAULT 0 COMMENT '排序码',
+  is_deleted     TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version        INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_market_org (org_id, is_deleted)
+) ENGINE=InnoDB
+  COMMENT='询价市场';
+
+/* ---------- 询价单参与市场 ---------- */
+CREATE TABLE IF NOT EXISTS base_price_inquiry_market (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  inquiry_id     CHAR(36)      NOT NULL COMMENT '询价单ID（base_price_inquiry.id）',
+  market_id      CHAR(36)      NOT NULL COMMENT '市场ID（base_market.id）',
+  sort           INT           NOT NULL DEFAULT 0 COMMENT '排序码',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_pim_inquiry_market (inquiry_id, market_id),
+  KEY idx_pim_market (market_id)
+) ENGINE=InnoDB
+  COMMENT='询价单参与市场';
+
+/* ---------- 询价单状态流转记录 ---------- */
+CREATE TABLE IF NOT EXISTS base_price_inquiry_history (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  inquiry_id    CHAR(36)      NOT NULL COMMENT '询价单ID（base_price_inquiry.id）',
+  action        VARCHAR(16)   NOT NULL COMMENT '动作：submit/approve/reject/archive/reopen',
+  from_status   INT           NOT NULL COMMENT '原状态',
+  to_status     INT           NOT NULL COMMENT '新状态',
+  comment       VARCHAR(255)      NULL COMMENT '意见/重新打开原因',
+  operator_id   CHAR(36)      NOT NULL COMMENT '操作人ID（base_user.id）',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  KEY idx_pih_inquiry (inquiry_id)
+) ENGINE=InnoDB
+  COMMENT='询价单状态流转记录';
+
+/* ---------- 询价模板：机构常用的市场与商品清单 ---------- */
+CREATE TABLE IF NOT EXISTS base_price_inquiry_template (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id         CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  name           VARCHAR(64)   NOT NULL COMMENT '模板名称（同机构有效模板内唯一）',
+  inquiry_title  VARCHAR(64)   NOT NULL COMMENT '生成询价单的默认标题',
+  remark         VARCHAR(255)      NULL COMMENT '备注',
+  is_deleted     TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version        INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_pit_org (org_id, is_deleted)
+) ENGINE=InnoDB
+  COMMENT='询价模板';
+
+CREATE TABLE IF NOT EXISTS base_price_inquiry_template_market (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  template_id    CHAR(36)      NOT NULL COMMENT '模板ID',
+  market_id      CHAR(36)      NOT NULL COMMENT '市场ID（base_market.id）',
+  sort           INT           NOT NULL DEFAULT 0 COMMENT '排序码',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ptm_template_market (template_id, market_id)
+) ENGINE=InnoDB
+  COMMENT='询价模板市场清单';
+
+CREATE TABLE IF NOT EXISTS base_price_inquiry_template_line (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  template_id    CHAR(36)      NOT NULL COMMENT '模板ID（base_price_inquiry_template.id）',
+  goods_id       CHAR(36)      NOT NULL COMMENT '商品ID（base_goods.id）',
+  sort           INT           NOT NULL DEFAULT 0 COMMENT '排序码',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_pitl_template_goods (template_id, goods_id),
+  KEY idx_pitl_goods (goods_id)
+) ENGINE=InnoDB
+  COMMENT='询价模板商品清单';
+
 /* ---------- Base_商品均价明细 ---------- */
 /* 说明：
    - goods_id   → base_goods.id（商品库）
    - inquiry_id → price_inquiry.id（询价抬头）
-   - avg_price  按已填写的市场价自动算“非空项平均”，都为空则为 NULL
+   - 各市场价见 base_goods_market_price；market1..3_price 为旧版字段，按询价单前 3 个市场同步保留
+   - avg_price  由应用按已录入的市场价求“非空项平均”（保留 2 位），都为空则为 NULL
 */
 CREATE TABLE IF NOT EXISTS base_goods_avg_detail (
   id              CHAR(36)      NOT NULL COMMENT '商品均价明细Id(UUID)',
   goods_id        CHAR(36)      NOT NULL COMMENT '商品Id（base_goods.id）',
   guide_price     DECIMAL(10,2)     NULL COMMENT '指导价',
 
-  market1_price   DECIMAL(10,2)     NULL COMMENT '市场1价格',
-  market2_price   DECIMAL(10,2)     NULL COMMENT '市场2价格',
-  market3_price   DECIMAL(10,2)     NULL COMMENT '市场3价格',
-
-  -- 非空平均：有几项填几项求平均；若都为空则为 NULL
-  avg_price       DECIMAL(10,2)
-    GENERATED ALWAYS AS (
-      CASE
-        WHEN NULLIF(
-               (market1_price IS NOT NULL) +
-               (market2_price IS NOT NULL) +
-               (market3_price IS NOT NULL), 0
-             ) IS NULL
-        THEN NULL
-        ELSE ROUND(
-          (IFNULL(market1_price,0) + IFNULL(market2_price,0) + IFNULL(market3_price,0)) /
-          ((market1_price IS NOT NULL) + (market2_price IS NOT NULL) + (market3_price IS NOT NULL))
-        , 2)
-      END
-    ) STORED COMMENT '商品均价（自动按非空项求平均，保留2位）',
+  market1_price   DECIMAL(10,2)     NULL COMMENT '市场1价格（旧版）',
+  market2_price   DECIMAL(10,2)     NULL COMMENT '市场2价格（旧版）',
+  market3_price   DECIMAL(10,2)     NULL COMMENT '市场3价格（旧版）',
+
+  avg_price       DECIMAL(10,2)     NULL COMMENT '均价（各市场价的平均值）',
 
   inquiry_id      CHAR(36)      NOT NULL COMMENT '询价记录Id（price_inquiry.id）',
   org_id          CHAR(36)          NULL COMMENT '中队Id',
@@ -154,6 +276,24 @@ CREATE TABLE IF NOT EXISTS base_goods_avg_detail (
 ) ENGINE=InnoDB
   COMMENT='Base_商品均价明细（按询价记录保存各市场价并生成均价）';
 
+/* ---------- 询价明细的各市场价 ---------- */
+CREATE TABLE IF NOT EXISTS base_goods_market_price (
+  id              CHAR(36)      NOT NULL COMMENT '主键UUID',
+  inquiry_id      CHAR(36)      NOT NULL COMMENT '询价单ID（base_price_inquiry.id）',
+  line_id         CHAR(36)      NOT NULL COMMENT '询价明细ID（base_goods_avg_detail.id）',
+  market_id       CHAR(36)      NOT NULL COMMENT '市场ID（base_market.id）',
+  goods_id        CHAR(36)      NOT NULL COMMENT '商品ID（base_goods.id）',
+  price           DECIMAL(10,2) NOT NULL COMMENT '市场价',
+  created_at      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_gmp_line_market (line_id, market_id),
+  KEY idx_gmp_inquiry (inquiry_id),
+  KEY idx_gmp_market (market_id),
+  KEY idx_gmp_goods (goods_id)
+) ENGINE=InnoDB
+  COMMENT='询价明细市场价';
+
 CREATE TABLE IF NOT EXISTS supplier (
   id              CHAR(36)     NOT NULL COMMENT '主键UUID',
   name            VARCHAR(128) NOT NULL COMMENT '供货商名称',
@@ -170,7 +310,9 @@ CREATE TABLE IF NOT EXISTS supplier (
   org_id          CHAR(36)     NOT NULL COMMENT '中队ID（必填）',
   start_time      DATETIME         NULL COMMENT '开始时间', 
   end_time        DATETIME         NULL COMMENT '结束时间',
+  auto_disabled   TINYINT      NOT NULL DEFAULT 0 COMMENT '是否因合同期由调度停用：0=否 1=是（到期后自动启用）',
   is_deleted      TINYINT(1)   NOT NULL DEFAULT 0 COMMENT '软删标记：0=有效,1=已删除',
+  version         INT          NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
   created_at      DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间', 
   updated_at      DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
   
@@ -195,6 +337,220 @@ CREATE TABLE IF NOT EXISTS supplier (
 ) ENGINE=InnoDB
   COMMENT='供货商';
 
+/* ---------- 供应商浮动比例历史：自生效日期起适用，生效日期晚于今天的为计划调整 ---------- */
+CREATE TABLE IF NOT EXISTS supplier_float_ratio (
+  id              CHAR(36)      NOT NULL COMMENT '主键UUID',
+  supplier_id     CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  float_ratio     DECIMAL(6,4)  NOT NULL COMMENT '浮动比例：结算价=合同价*float_ratio',
+  effective_from  DATE          NOT NULL COMMENT '生效日期（含）',
+  remark          VARCHAR(255)      NULL COMMENT '调整说明',
+  operator_id     CHAR(36)          NULL COMMENT '操作人ID',
+  created_at      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at      DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_sfr_supplier_date (supplier_id, effective_from)
+) ENGINE=InnoDB
+  COMMENT='供应商浮动比例历史';
+
+/* ---------- 供应商资质文件：仅已审核且在有效期内的视为有效，必备资质过期后禁止报价/下单 ---------- */
+CREATE TABLE IF NOT EXISTS supplier_document (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id         CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  supplier_id    CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  doc_type       VARCHAR(32)   NOT NULL COMMENT '资质类型',
+  doc_no         VARCHAR(64)       NULL COMMENT '证照编号',
+  issue_date     DATE              NULL COMMENT '发证日期',
+  expiry_date    DATE              NULL COMMENT '有效期至（含，空=长期）',
+  file_name      VARCHAR(255)  NOT NULL COMMENT '原始文件名',
+  file_path      VARCHAR(255)  NOT NULL COMMENT '存储相对路径',
+  file_size      BIGINT        NOT NULL COMMENT '文件大小（字节）',
+  content_type   VARCHAR(64)   NOT NULL COMMENT '文件类型',
+  sha256         CHAR(64)      NOT NULL COMMENT '文件摘要',
+  verify_status  TINYINT       NOT NULL DEFAULT 0 COMMENT '审核状态：0=待审核 1=已审核 2=已驳回',
+  verify_remark  VARCHAR(255)      NULL COMMENT '审核意见',
+  verified_by    CHAR(36)          NULL COMMENT '审核人ID',
+  verified_at    DATETIME          NULL COMMENT '审核时间',
+  remark         VARCHAR(255)      NULL COMMENT '备注',
+  uploaded_by    CHAR(36)          NULL COMMENT '上传人ID',
+  is_deleted     TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删标记：0=有效,1=已删除',
+  version        INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_sd_supplier_type (supplier_id, doc_type),
+  KEY idx_sd_org_expiry (org_id, expiry_date)
+) ENGINE=InnoDB
+  COMMENT='供应商资质文件';
+
+/* ---------- 供应商人工评价：评分（1~5）或投诉，按评价日期计入评分期间 ---------- */
+CREATE TABLE IF NOT EXISTS supplier_rating (
+  id           CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id       CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  supplier_id  CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  kind         VARCHAR(16)   NOT NULL COMMENT '类型：rating=评分 complaint=投诉',
+  score        INT               NULL COMMENT '评分 1~5（仅评分）',
+  rated_on     DATE          NOT NULL COMMENT '评价日期',
+  content      VARCHAR(512)      NULL COMMENT '评价/投诉内容',
+  operator_id  CHAR(36)          NULL COMMENT '登记人ID（base_user.id）',
+  is_deleted   TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  created_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_sr_org_date (org_id, rated_on),
+  KEY idx_supplier_rating_supplier_id (supplier_id)
+) ENGINE=InnoDB
+  COMMENT='供应商人工评价';
+
+/* ---------- 供应商评分快照：生成后不随业务数据变化，保证历史排名稳定 ---------- */
+CREATE TABLE IF NOT EXISTS supplier_score_snapshot (
+  id               CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id           CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  period_from      DATE          NOT NULL COMMENT '期间起（含）',
+  period_to        DATE          NOT NULL COMMENT '期间止（含）',
+  supplier_id      CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  supplier_name    VARCHAR(128)  NOT NULL COMMENT '供应商名称快照',
+  due_orders       INT           NOT NULL DEFAULT 0 COMMENT '应到货采购单数',
+  on_time_orders   INT           NOT NULL DEFAULT 0 COMMENT '按期到货采购单数',
+  on_time_rate     DECIMAL(6,4)      NULL COMMENT '准时到货率',
+  received_lines   INT           NOT NULL DEFAULT 0 COMMENT '已收货明细数',
+  weight_variance  DECIMAL(8,4)      NULL COMMENT '平均重量偏差率 |实收-订购|/订购',
+  quotes           INT           NOT NULL DEFAULT 0 COMMENT '可比报价数',
+  price_index      DECIMAL(8,4)      NULL COMMENT '价格指数 结算价/询价均价（<1 低于均价）',
+  complaints       INT           NOT NULL DEFAULT 0 COMMENT '投诉次数',
+  ratings          INT           NOT NULL DEFAULT 0 COMMENT '评分次数',
+  avg_rating       DECIMAL(4,2)      NULL COMMENT '平均评分',
+  score            DECIMAL(5,2)  NOT NULL COMMENT '综合得分（0~100）',
+  rank_no          INT           NOT NULL COMMENT '机构内排名',
+  computed_at      DATETIME      NOT NULL COMMENT '计算时间',
+  computed_by      CHAR(36)          NULL COMMENT '计算人ID（空=定时任务）',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ss_period_supplier (org_id, period_from, period_to, supplier_id),
+  KEY idx_supplier_score_snapshot_supplier_id (supplier_id)
+) ENGINE=InnoDB
+  COMMENT='供应商评分快照';
+
+/* ---------- 供应商门户账户：外部用户，与员工账户 base_user 相互独立 ---------- */
+CREATE TABLE IF NOT EXISTS supplier_user (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  supplier_id    CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  org_id         CHAR(36)      NOT NULL COMMENT '机构ID（与供应商一致）',
+  username       VARCHAR(64)   NOT NULL COMMENT '登录名',
+  password_hash  VARCHAR(255)  NOT NULL COMMENT '密码Hash',
+  display_name   VARCHAR(64)       NULL COMMENT '姓名',
+  phone          VARCHAR(32)       NULL COMMENT '联系电话',
+  scopes         VARCHAR(128)  NOT NULL DEFAULT '' COMMENT '权限范围（逗号分隔）：quote,order,settlement,document',
+  status         INT           NOT NULL DEFAULT 1 COMMENT '状态：1=正常 2=停用',
+  last_login_at  DATETIME          NULL COMMENT '最后登录时间',
+  created_by     CHAR(36)          NULL COMMENT '创建人ID（base_user.id）',
+  is_deleted     TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version        INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at     DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_supplier_user_username (username),
+  KEY idx_supplier_user_supplier_id (supplier_id),
+  KEY idx_supplier_user_org_id (org_id)
+) ENGINE=InnoDB
+  COMMENT='供应商门户账户';
+
+/* ---------- 询价单向供应商开放报价 ---------- */
+CREATE TABLE IF NOT EXISTS supplier_inquiry_invite (
+  id           CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id       CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  inquiry_id   CHAR(36)      NOT NULL COMMENT '询价单ID（base_price_inquiry.id）',
+  supplier_id  CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  closes_at    DATETIME          NULL COMMENT '报价截止时间（空=询价单锁定前均可报价）',
+  invited_by   CHAR(36)          NULL COMMENT '开放人ID（base_user.id）',
+  created_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_sii_inquiry_supplier (inquiry_id, supplier_id),
+  KEY idx_supplier_inquiry_invite_supplier_id (supplier_id)
+) ENGINE=InnoDB
+  COMMENT='询价单门户开放';
+
+/* ---------- 询价单密封报价轮次：截止前报价对任何人（含员工）密封，截止后排名、授标 ---------- */
+CREATE TABLE IF NOT EXISTS inquiry_round (
+  id          CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id      CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  inquiry_id  CHAR(36)      NOT NULL COMMENT '询价单ID（base_price_inquiry.id）',
+  title       VARCHAR(128)      NULL COMMENT '轮次名称',
+  opens_at    DATETIME      NOT NULL COMMENT '开始报价时间',
+  closes_at   DATETIME      NOT NULL COMMENT '报价截止（开标）时间',
+  status      INT           NOT NULL DEFAULT 1 COMMENT '状态：1=正常 2=已取消',
+  awarded_at  DATETIME          NULL COMMENT '最近授标时间',
+  created_by  CHAR(36)          NULL COMMENT '创建人ID（base_user.id）',
+  is_deleted  TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version     INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_inquiry_round_org_id (org_id),
+  KEY idx_inquiry_round_inquiry_id (inquiry_id)
+) ENGINE=InnoDB
+  COMMENT='密封报价轮次';
+
+CREATE TABLE IF NOT EXISTS inquiry_round_supplier (
+  id           CHAR(36)  NOT NULL COMMENT '主键UUID',
+  round_id     CHAR(36)  NOT NULL COMMENT '轮次ID（inquiry_round.id）',
+  supplier_id  CHAR(36)  NOT NULL COMMENT '供应商ID（supplier.id）',
+  created_at   DATETIME  NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_irs_round_supplier (round_id, supplier_id),
+  KEY idx_inquiry_round_supplier_supplier_id (supplier_id)
+) ENGINE=InnoDB
+  COMMENT='密封报价轮次受邀供应商';
+
+CREATE TABLE IF NOT EXISTS inquiry_round_bid (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  round_id      CHAR(36)      NOT NULL COMMENT '轮次ID（inquiry_round.id）',
+  supplier_id   CHAR(36)      NOT NULL COMMENT '供应商ID（supplier.id）',
+  goods_id      CHAR(36)      NOT NULL COMMENT '商品ID（base_goods.id）',
+  unit_price    DECIMAL(10,2) NOT NULL COMMENT '报价单价',
+  float_ratio   DECIMAL(6,4)  NOT NULL DEFAULT 1.0000 COMMENT '浮动比例快照',
+  settle_price  DECIMAL(10,2) NOT NULL COMMENT '结算价（单价×浮动比例）',
+  submitted_by  CHAR(36)          NULL COMMENT '提交人ID（supplier_user.id）',
+  submitted_at  DATETIME      NOT NULL COMMENT '最近提交时间',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_irb_round_supplier_goods (round_id, supplier_id, goods_id)
+) ENGINE=InnoDB
+  COMMENT='密封报价';
+
+CREATE TABLE IF NOT EXISTS inquiry_round_award (
+  id             CHAR(36)      NOT NULL COMMENT '主键UUID',
+  round_id       CHAR(36)      NOT NULL COMMENT '轮次ID（inquiry_round.id）',
+  goods_id       CHAR(36)      NOT NULL COMMENT '商品ID（base_goods.id）',
+  supplier_id    CHAR(36)      NOT NULL COMMENT '中标供应商ID（supplier.id）',
+  bid_id         CHAR(36)      NOT NULL COMMENT '中标报价ID（inquiry_round_bid.id）',
+  unit_price     DECIMAL(10,2) NOT NULL COMMENT '中标单价',
+  settle_price   DECIMAL(10,2) NOT NULL COMMENT '中标结算价',
+  rank_no        INT           NOT NULL COMMENT '中标报价排名',
+  quote_line_id  CHAR(36)          NULL COMMENT '写入的正式报价ID（base_goods_price.id）',
+  awarded_by     CHAR(36)          NULL COMMENT '授标人ID（base_user.id）',
+  awarded_at     DATETIME      NOT NULL COMMENT '授标时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_ira_round_goods (round_id, goods_id)
+) ENGINE=InnoDB
+  COMMENT='密封报价授标';
+
+CREATE TABLE IF NOT EXISTS inquiry_round_access (
+  id          CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id      CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  round_id    CHAR(36)      NOT NULL COMMENT '轮次ID（inquiry_round.id）',
+  actor_type  VARCHAR(16)   NOT NULL COMMENT '操作者类型：staff/supplier',
+  actor_id    CHAR(36)      NOT NULL COMMENT '操作者ID（base_user.id / supplier_user.id）',
+  action      VARCHAR(32)   NOT NULL COMMENT '动作：view_bids/view_ranking/award/view_own/submit',
+  sealed      TINYINT(1)    NOT NULL COMMENT '访问时是否仍密封',
+  allowed     TINYINT(1)    NOT NULL COMMENT '是否放行',
+  detail      VARCHAR(255)      NULL COMMENT '说明（拒绝原因等）',
+  client_ip   VARCHAR(64)       NULL COMMENT '客户端IP',
+  created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  KEY idx_irac_round_time (round_id, created_at)
+) ENGINE=InnoDB
+  COMMENT='密封报价访问审计';
+
 /* ---------- Base_商品单价 ----------
    同一询价(inquiry) × 同一供应商 × 同一商品 只允许一条报价
    采购明细从这里取“商品单价”，再结合 supplier.float_ratio 计算结算价/金额
@@ -236,3 +592,49 @@ CREATE TABLE IF NOT EXISTS base_goods_price (
 ) ENGINE=InnoDB
   COMMENT='Base_商品单价：按 询价×供应商×商品 的报价记录';
 
+
+/* ---------- 价格异常检测规则（每机构一条，未配置时取默认值） ---------- */
+CREATE TABLE IF NOT EXISTS price_anomaly_rule (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id        CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  band_pct      DECIMAL(6,2)  NOT NULL DEFAULT 30 COMMENT '允许偏离历史中位数的幅度（%）',
+  spread_pct    DECIMAL(6,2)  NOT NULL DEFAULT 50 COMMENT '允许的市场价差（最高相对最低，%）',
+  window_size   INT           NOT NULL DEFAULT 5 COMMENT '计算中位数取最近几次询价',
+  min_samples   INT           NOT NULL DEFAULT 3 COMMENT '历史样本少于该数时不做偏离检测',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_price_rule_org (org_id)
+) ENGINE=InnoDB
+  COMMENT='价格异常检测规则';
+
+/* ---------- 价格异常标记：询价明细/供应商报价录入时生成，复核确认后保留 ---------- */
+CREATE TABLE IF NOT EXISTS price_anomaly_flag (
+  id            CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id        CHAR(36)      NOT NULL COMMENT '机构ID',
+  inquiry_id    CHAR(36)      NOT NULL COMMENT '询价单ID（base_price_inquiry.id）',
+  goods_id      CHAR(36)      NOT NULL COMMENT '商品ID（base_goods.id）',
+  supplier_id   CHAR(36)          NULL COMMENT '供应商ID（报价标记）',
+  line_type     VARCHAR(16)   NOT NULL COMMENT '明细类型：inquiry_line/quote',
+  line_id       CHAR(36)      NOT NULL COMMENT '明细ID',
+  field         VARCHAR(32)   NOT NULL COMMENT '异常字段：market_price/unit_price',
+  market_id     CHAR(36)          NULL COMMENT '市场ID（market_price 标记）',
+  kind          VARCHAR(16)   NOT NULL COMMENT '异常类型：band=偏离历史 spread=市场价差',
+  value         DECIMAL(10,2) NOT NULL COMMENT '录入值',
+  reference     DECIMAL(10,2) NOT NULL COMMENT '参照值（历史中位数或最低市场价）',
+  deviation     DECIMAL(10,2) NOT NULL COMMENT '偏离幅度（%）',
+  limit_pct     DECIMAL(6,2)  NOT NULL COMMENT '检测时的允许幅度（%）',
+  status        INT           NOT NULL DEFAULT 0 COMMENT '状态：0=待复核 1=已确认',
+  comment       VARCHAR(255)      NULL COMMENT '复核意见',
+  reviewer_id   CHAR(36)          NULL COMMENT '复核人ID（base_user.id）',
+  reviewed_at   DATETIME          NULL COMMENT '复核时间',
+  is_deleted    TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除（明细重算时作废）',
+  created_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at    DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_paf_org_status (org_id, status),
+  KEY idx_paf_line (line_type, line_id),
+  KEY idx_paf_inquiry (inquiry_id),
+  KEY idx_paf_goods (goods_id)
+) ENGINE=InnoDB
+  COMMENT='价格异常标记（复核队列）';
@@ -0,0 +1,168 @@
+/* ======== 就餐与称重（供餐时段、餐次计划、称重记录） ======== */
+USE main;
+
+/* ---------- 机构级供餐时段（覆盖 menu_meal 默认时段） ---------- */
+CREATE TABLE IF NOT EXISTS menu_meal_org_window (
+  id          CHAR(36)     NOT NULL COMMENT '主键UUID',
+  org_id      CHAR(36)     NOT NULL COMMENT '机构ID（base_org.id）',
+  meal_id     CHAR(36)     NOT NULL COMMENT '餐次ID（menu_meal.id）',
+  start_time  VARCHAR(5)   NOT NULL COMMENT '供餐开始时刻 HH:MM',
+  end_time    VARCHAR(5)   NOT NULL COMMENT '供餐结束时刻 HH:MM（小于开始时刻表示跨零点）',
+  created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_mow_org_meal (org_id, meal_id),
+  CONSTRAINT fk_mow_org  FOREIGN KEY (org_id)  REFERENCES base_org(id),
+  CONSTRAINT fk_mow_meal FOREIGN KEY (meal_id) REFERENCES menu_meal(id)
+) ENGINE=InnoDB
+  COMMENT='机构供餐时段';
+
+/* ---------- 餐次计划：机构 + 日期 + 餐次 唯一 ---------- */
+CREATE TABLE IF NOT EXISTS menu_meal_plan (
+  id          CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id      CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  plan_date   DATE          NOT NULL COMMENT '就餐日期',
+  meal_id     CHAR(36)      NOT NULL COMMENT '餐次ID（menu_meal.id）',
+  headcount   INT           NOT NULL DEFAULT 0 COMMENT '计划就餐人数',
+  remark      VARCHAR(255)      NULL COMMENT '备注',
+  version     INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_meal_plan_org_date_meal (org_id, plan_date, meal_id),
+  CONSTRAINT fk_meal_plan_org  FOREIGN KEY (org_id)  REFERENCES base_org(id),
+  CONSTRAINT fk_meal_plan_meal FOREIGN KEY (meal_id) REFERENCES menu_meal(id)
+) ENGINE=InnoDB
+  COMMENT='餐次计划';
+
+/* ---------- 餐次计划菜品 ---------- */
+CREATE TABLE IF NOT EXISTS menu_meal_plan_dish (
+  id          CHAR(36)      NOT NULL COMMENT '主键UUID',
+  plan_id     CHAR(36)      NOT NULL COMMENT '餐次计划ID（menu_meal_plan.id）',
+  name        VARCHAR(128)  NOT NULL COMMENT '菜品名称',
+  recipe_id   CHAR(36)          NULL COMMENT '配方ID（menu_recipe.id）',
+  recipe_version INT            NULL COMMENT '固定配方版本（为空取当前版本）',
+  servings    INT               NULL COMMENT '份数（为空按计划人数）',
+  sort        INT           NOT NULL DEFAULT 0 COMMENT '排序码',
+  remark      VARCHAR(255)      NULL COMMENT '备注',
+  created_at  DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  KEY idx_meal_plan_dish_plan (plan_id),
+  KEY idx_meal_plan_dish_recipe (recipe_id),
+  CONSTRAINT fk_meal_plan_dish_plan FOREIGN KEY (plan_id) REFERENCES menu_meal_plan(id)
+) ENGINE=InnoDB
+  COMMENT='餐次计划菜品';
+
+/* ---------- 称重记录（按供餐时段自动归属餐次） ---------- */
+CREATE TABLE IF NOT EXISTS base_weighing_record (
+  id           CHAR(36)       NOT NULL COMMENT '主键UUID',
+  org_id       CHAR(36)       NOT NULL COMMENT '机构ID（base_org.id）',
+  scale_id     CHAR(36)           NULL COMMENT '秤ID（base_smart_scale.id）',
+  goods_id     CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  weight       DECIMAL(20,3)  NOT NULL COMMENT '称重数量（按 unit_id 计）',
+  unit_id      CHAR(36)       NOT NULL COMMENT '单位ID（base_unit.id）',
+  weighed_at   DATETIME       NOT NULL COMMENT '称重时间',
+  meal_id      CHAR(36)           NULL COMMENT '归属餐次ID（menu_meal.id）',
+  meal_date    DATE               NULL COMMENT '归属就餐日期（跨零点时段归前一天）',
+  meal_source  TINYINT        NOT NULL DEFAULT 0 COMMENT '归属来源：0=未归属 1=自动 2=人工',
+  operator_id  CHAR(36)           NULL COMMENT '操作人ID（base_user.id）',
+  remark       VARCHAR(255)       NULL COMMENT '备注',
+  is_deleted   TINYINT(1)     NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  created_at   DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at   DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_weighing_org_time (org_id, weighed_at),
+  KEY idx_weighing_meal (meal_date, meal_id),
+  KEY idx_weighing_goods (goods_id),
+  KEY idx_weighing_scale (scale_id),
+  KEY idx_weighing_del (is_deleted),
+  CONSTRAINT fk_weighing_org   FOREIGN KEY (org_id)   REFERENCES base_org(id),
+  CONSTRAINT fk_weighing_goods FOREIGN KEY (goods_id) REFERENCES base_goods(id),
+  CONSTRAINT fk_weighing_unit  FOREIGN KEY (unit_id)  REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='称重记录';
+
+/* ---------- 菜品配方（BOM）抬头 ---------- */
+CREATE TABLE IF NOT EXISTS menu_recipe (
+  id               CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id           CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  name             VARCHAR(128)  NOT NULL COMMENT '菜品名称',
+  current_version  INT           NOT NULL DEFAULT 0 COMMENT '当前生效版本号（0=尚无版本）',
+  remark           VARCHAR(255)      NULL COMMENT '备注',
+  is_deleted       TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version          INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at       DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at       DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_recipe_org (org_id),
+  KEY idx_recipe_del (is_deleted),
+  CONSTRAINT fk_recipe_org FOREIGN KEY (org_id) REFERENCES base_org(id)
+) ENGINE=InnoDB
+  COMMENT='菜品配方';
+
+/* ---------- 配方版本（只增不改） ---------- */
+CREATE TABLE IF NOT EXISTS menu_recipe_version (
+  id           CHAR(36)      NOT NULL COMMENT '主键UUID',
+  recipe_id    CHAR(36)      NOT NULL COMMENT '配方ID（menu_recipe.id）',
+  version      INT           NOT NULL COMMENT '版本号（从1递增）',
+  remark       VARCHAR(255)      NULL COMMENT '版本说明',
+  operator_id  CHAR(36)          NULL COMMENT '操作人ID（base_user.id）',
+  created_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_recipe_version (recipe_id, version),
+  CONSTRAINT fk_recipe_version_recipe FOREIGN KEY (recipe_id) REFERENCES menu_recipe(id)
+) ENGINE=InnoDB
+  COMMENT='配方版本';
+
+/* ---------- 配方用料行：毛用量 = 每份净用量 / 出成率 / (1 - 损耗率) ---------- */
+CREATE TABLE IF NOT EXISTS menu_recipe_line (
+  id               CHAR(36)       NOT NULL COMMENT '主键UUID',
+  version_id       CHAR(36)       NOT NULL COMMENT '配方版本ID（menu_recipe_version.id）',
+  goods_id         CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  qty_per_serving  DECIMAL(20,4)  NOT NULL COMMENT '每份净用量',
+  unit_id          CHAR(36)       NOT NULL COMMENT '用量单位ID（base_unit.id）',
+  yield_rate       DECIMAL(6,4)   NOT NULL DEFAULT 1 COMMENT '出成率 (0,1]',
+  loss_rate        DECIMAL(6,4)   NOT NULL DEFAULT 0 COMMENT '损耗率 [0,1)',
+  sort             INT            NOT NULL DEFAULT 0 COMMENT '排序码',
+  created_at       DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  KEY idx_recipe_line_version (version_id),
+  KEY idx_recipe_line_goods (goods_id),
+  CONSTRAINT fk_recipe_line_version FOREIGN KEY (version_id) REFERENCES menu_recipe_version(id),
+  CONSTRAINT fk_recipe_line_goods   FOREIGN KEY (goods_id)   REFERENCES base_goods(id),
+  CONSTRAINT fk_recipe_line_unit    FOREIGN KEY (unit_id)    REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='配方用料行';
+
+/* ---------- 浪费记录：原因 + 餐次归属，可关联称重记录与报损流水 ---------- */
+CREATE TABLE IF NOT EXISTS base_waste_record (
+  id           CHAR(36)       NOT NULL COMMENT '主键UUID',
+  org_id       CHAR(36)       NOT NULL COMMENT '机构ID（base_org.id）',
+  goods_id     CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  reason_id    CHAR(36)       NOT NULL COMMENT '浪费原因ID（base_waste_reason.id）',
+  weight       DECIMAL(20,3)  NOT NULL COMMENT '浪费数量（按 unit_id 计）',
+  unit_id      CHAR(36)       NOT NULL COMMENT '单位ID（base_unit.id）',
+  wasted_at    DATETIME       NOT NULL COMMENT '发生时间',
+  meal_id      CHAR(36)           NULL COMMENT '归属餐次ID（menu_meal.id）',
+  meal_date    DATE               NULL COMMENT '归属就餐日期（跨零点时段归前一天）',
+  meal_source  INT            NOT NULL DEFAULT 0 COMMENT '归属来源：0=未归属 1=自动 2=人工',
+  weighing_id  CHAR(36)           NULL COMMENT '来源称重记录ID（base_weighing_record.id）',
+  movement_id  CHAR(36)           NULL COMMENT '报损库存流水ID（inv_movement.id）',
+  operator_id  CHAR(36)           NULL COMMENT '操作人ID（base_user.id）',
+  remark       VARCHAR(255)       NULL COMMENT '备注',
+  is_deleted   TINYINT(1)     NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  created_at   DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at   DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_waste_weighing (weighing_id),
+  KEY idx_waste_org_time (org_id, wasted_at),
+  KEY idx_waste_meal (meal_date, meal_id),
+  KEY idx_waste_goods (goods_id),
+  KEY idx_waste_reason (reason_id),
+  KEY idx_waste_del (is_deleted),
+  CONSTRAINT fk_waste_org    FOREIGN KEY (org_id)    REFERENCES base_org(id),
+  CONSTRAINT fk_waste_goods  FOREIGN KEY (goods_id)  REFERENCES base_goods(id),
+  CONSTRAINT fk_waste_reason FOREIGN KEY (reason_id) REFERENCES base_waste_reason(id),
+  CONSTRAINT fk_waste_unit   FOREIGN KEY (unit_id)   REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='浪费记录';
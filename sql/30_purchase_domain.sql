@@ -0,0 +1,52 @@
+/* ======== 采购（采购单、需求预测生成的采购草稿） ======== */
+USE main;
+
+/* ---------- 采购单抬头：一张单对应一个供应商 ---------- */
+CREATE TABLE IF NOT EXISTS purchase_order (
+  id             CHAR(36)       NOT NULL COMMENT '主键UUID',
+  org_id         CHAR(36)       NOT NULL COMMENT '机构ID（base_org.id）',
+  supplier_id    CHAR(36)       NOT NULL COMMENT '供应商ID（supplier.id）',
+  expected_date  DATE           NOT NULL COMMENT '期望到货日期',
+  status         INT            NOT NULL DEFAULT 0 COMMENT '状态：0=草稿 1=已提交 2=已收货 9=已作废',
+  source         VARCHAR(16)    NOT NULL DEFAULT 'manual' COMMENT '来源：manual=手工 forecast=需求预测',
+  amount         DECIMAL(14,2)  NOT NULL DEFAULT 0 COMMENT '合计金额（按结算价）',
+  remark         VARCHAR(255)       NULL COMMENT '备注',
+  operator_id    CHAR(36)           NULL COMMENT '创建人ID（base_user.id）',
+  received_at    DATETIME           NULL COMMENT '实际到货时间',
+  received_by    CHAR(36)           NULL COMMENT '收货人ID（base_user.id）',
+  is_deleted     TINYINT(1)     NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  created_at     DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at     DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_po_org_date (org_id, expected_date),
+  KEY idx_po_supplier (supplier_id),
+  KEY idx_po_del (is_deleted),
+  CONSTRAINT fk_po_org      FOREIGN KEY (org_id)      REFERENCES base_org(id),
+  CONSTRAINT fk_po_supplier FOREIGN KEY (supplier_id) REFERENCES supplier(id)
+) ENGINE=InnoDB
+  COMMENT='采购单';
+
+/* ---------- 采购明细：结算价 = 单价 × 浮动比例，金额 = 数量 × 结算价 ---------- */
+CREATE TABLE IF NOT EXISTS purchase_order_line (
+  id            CHAR(36)       NOT NULL COMMENT '主键UUID',
+  order_id      CHAR(36)       NOT NULL COMMENT '采购单ID（purchase_order.id）',
+  goods_id      CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  unit_id       CHAR(36)       NOT NULL COMMENT '单位ID（base_unit.id）',
+  quantity      DECIMAL(20,3)  NOT NULL COMMENT '采购数量',
+  unit_price    DECIMAL(10,2)  NOT NULL COMMENT '单价（报价）',
+  float_ratio   DECIMAL(6,4)   NOT NULL DEFAULT 1.0000 COMMENT '浮动比例快照',
+  settle_price  DECIMAL(10,2)  NOT NULL COMMENT '结算单价',
+  amount        DECIMAL(14,2)  NOT NULL COMMENT '金额',
+  inquiry_id    CHAR(36)           NULL COMMENT '报价来源询价ID（base_price_inquiry.id）',
+  explanation   TEXT               NULL COMMENT '生成依据（需求预测时记录推导过程）',
+  received_qty  DECIMAL(20,3)      NULL COMMENT '实收数量（收货后填写）',
+  sort          INT            NOT NULL DEFAULT 0 COMMENT '排序码',
+  created_at    DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  KEY idx_pol_order (order_id),
+  KEY idx_pol_goods (goods_id),
+  CONSTRAINT fk_pol_order FOREIGN KEY (order_id) REFERENCES purchase_order(id),
+  CONSTRAINT fk_pol_goods FOREIGN KEY (goods_id) REFERENCES base_goods(id),
+  CONSTRAINT fk_pol_unit  FOREIGN KEY (unit_id)  REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='采购明细';
@@ -0,0 +1,90 @@
+/* ======== 库存（流水、结存、盘点）：数量统一按商品基准单位记账 ======== */
+USE main;
+
+/* ---------- 库存流水：只追加；入库为正、出库为负，更正通过调整流水 ---------- */
+CREATE TABLE IF NOT EXISTS inv_movement (
+  id             CHAR(36)       NOT NULL COMMENT '主键UUID',
+  org_id         CHAR(36)       NOT NULL COMMENT '机构ID（base_org.id）',
+  goods_id       CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  type           VARCHAR(16)    NOT NULL COMMENT '类型：receipt/issue/adjust/waste/transfer_out/transfer_in',
+  quantity       DECIMAL(20,3)  NOT NULL COMMENT '变动数量（基准单位，入正出负）',
+  unit_id        CHAR(36)       NOT NULL COMMENT '基准单位ID（base_unit.id）',
+  input_qty      DECIMAL(20,3)  NOT NULL COMMENT '录入数量',
+  input_unit_id  CHAR(36)       NOT NULL COMMENT '录入单位ID（base_unit.id）',
+  balance_after  DECIMAL(20,3)  NOT NULL COMMENT '变动后结存',
+  occurred_at    DATETIME       NOT NULL COMMENT '业务发生时间',
+  weighing_id    CHAR(36)           NULL COMMENT '来源称重记录ID（base_weighing_record.id）',
+  count_id       CHAR(36)           NULL COMMENT '来源盘点单ID（inv_count.id）',
+  transfer_id    CHAR(36)           NULL COMMENT '调拨批次ID（出入两条流水共用）',
+  peer_org_id    CHAR(36)           NULL COMMENT '调拨对方机构ID（base_org.id）',
+  remark         VARCHAR(255)       NULL COMMENT '备注',
+  operator_id    CHAR(36)           NULL COMMENT '操作人ID（base_user.id）',
+  created_at     DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_inv_mv_weighing (weighing_id),
+  KEY idx_inv_mv_org_goods (org_id, goods_id, occurred_at),
+  KEY idx_inv_mv_type (type),
+  KEY idx_inv_mv_count (count_id),
+  KEY idx_inv_mv_transfer (transfer_id),
+  CONSTRAINT fk_inv_mv_org   FOREIGN KEY (org_id)   REFERENCES base_org(id),
+  CONSTRAINT fk_inv_mv_goods FOREIGN KEY (goods_id) REFERENCES base_goods(id),
+  CONSTRAINT fk_inv_mv_unit  FOREIGN KEY (unit_id)  REFERENCES base_unit(id)
+) ENGINE=InnoDB
+  COMMENT='库存流水';
+
+/* ---------- 当前结存：流水投影，与流水同事务维护 ---------- */
+CREATE TABLE IF NOT EXISTS inv_balance (
+  id             CHAR(36)       NOT NULL COMMENT '主键UUID',
+  org_id         CHAR(36)       NOT NULL COMMENT '机构ID（base_org.id）',
+  goods_id       CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  unit_id        CHAR(36)       NOT NULL COMMENT '基准单位ID（base_unit.id）',
+  quantity       DECIMAL(20,3)  NOT NULL DEFAULT 0 COMMENT '结存数量（基准单位）',
+  last_moved_at  DATETIME           NULL COMMENT '最近一次变动的业务时间',
+  updated_at     DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_inv_balance (org_id, goods_id),
+  CONSTRAINT fk_inv_bal_org   FOREIGN KEY (org_id)   REFERENCES base_org(id),
+  CONSTRAINT fk_inv_bal_goods FOREIGN KEY (goods_id) REFERENCES base_goods(id)
+) ENGINE=InnoDB
+  COMMENT='库存结存';
+
+/* ---------- 盘点单 ---------- */
+CREATE TABLE IF NOT EXISTS inv_count (
+  id           CHAR(36)      NOT NULL COMMENT '主键UUID',
+  org_id       CHAR(36)      NOT NULL COMMENT '机构ID（base_org.id）',
+  count_date   DATE          NOT NULL COMMENT '盘点日期',
+  status       INT           NOT NULL DEFAULT 0 COMMENT '状态：0=录入中 1=已过账',
+  remark       VARCHAR(255)      NULL COMMENT '备注',
+  operator_id  CHAR(36)          NULL COMMENT '创建人ID（base_user.id）',
+  posted_at    DATETIME          NULL COMMENT '过账时间',
+  is_deleted   TINYINT(1)    NOT NULL DEFAULT 0 COMMENT '软删：0=有效 1=删除',
+  version      INT           NOT NULL DEFAULT 1 COMMENT '版本号（乐观锁）',
+  created_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  updated_at   DATETIME      NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  PRIMARY KEY (id),
+  KEY idx_inv_count_org_date (org_id, count_date),
+  KEY idx_inv_count_del (is_deleted),
+  CONSTRAINT fk_inv_count_org FOREIGN KEY (org_id) REFERENCES base_org(id)
+) ENGINE=InnoDB
+  COMMENT='盘点单';
+
+/* ---------- 盘点明细：账面数与差异在过账时回填 ---------- */
+CREATE TABLE IF NOT EXISTS inv_count_line (
+  id             CHAR(36)       NOT NULL COMMENT '主键UUID',
+  count_id       CHAR(36)       NOT NULL COMMENT '盘点单ID（inv_count.id）',
+  goods_id       CHAR(36)       NOT NULL COMMENT '商品ID（base_goods.id）',
+  unit_id        CHAR(36)       NOT NULL COMMENT '基准单位ID（base_unit.id）',
+  input_qty      DECIMAL(20,3)  NOT NULL COMMENT '录入实盘数量',
+  input_unit_id  CHAR(36)       NOT NULL COMMENT '录入单位ID（base_unit.id）',
+  counted_qty    DECIMAL(20,3)  NOT NULL COMMENT '实盘数量（基准单位）',
+  book_qty       DECIMAL(20,3)      NULL COMMENT '账面数量（过账时回填）',
+  diff_qty       DECIMAL(20,3)      NULL COMMENT '差异 = 实盘 - 账面（过账时回填）',
+  movement_id    CHAR(36)           NULL COMMENT '差异调整流水ID（inv_movement.id）',
+  sort           INT            NOT NULL DEFAULT 0 COMMENT '排序码',
+  created_at     DATETIME       NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  PRIMARY KEY (id),
+  UNIQUE KEY uk_inv_count_goods (count_id, goods_id),
+  CONSTRAINT fk_inv_cl_count FOREIGN KEY (count_id) REFERENCES inv_count(id),
+  CONSTRAINT fk_inv_cl_goods FOREIGN KEY (goods_id) REFERENCES base_goods(id)
+) ENGINE=InnoDB
+  COMMENT='盘点明细';